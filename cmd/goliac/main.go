@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Alayacare/goliac/internal"
 	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/github"
 	"github.com/Alayacare/goliac/internal/notification"
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/sirupsen/logrus"
@@ -16,8 +22,18 @@ import (
 
 var dryrunParameter bool
 var forceParameter bool
+var fixParameter bool
 var repositoryParameter string
 var branchParameter string
+var formatParameter string
+var exitCodeParameter bool
+var onlyDestructiveParameter bool
+var explainParameter bool
+var onlyParameter []string
+var sinceDurationParameter string
+var baseParameter string
+var headParameter string
+var targetBranchParameter string
 var goliacAdminTeamnameParameter string
 
 func main() {
@@ -39,17 +55,56 @@ func main() {
 		},
 	}
 
+	verifyFileCmd := &cobra.Command{
+		Use:   "verify-file <file>",
+		Short: "Verify the validity of a single IAC file",
+		Long: `Verify the validity of a single IAC file (user, team, repository, ruleset or org variables)
+without the rest of the directory structure: the apiVersion, the kind, the unknown fields and the
+invalid values. The references to other users, teams or repositories are not checked.
+Errors are written to stderr as <file>:<line>: <message>`,
+		Args: cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			goliac, err := internal.NewGoliacLightImpl()
+			if err != nil {
+				logrus.Fatalf("failed to create goliac: %s", err)
+			}
+			err = goliac.ValidateFile(args[0])
+			if err != nil {
+				logrus.Fatalf("failed to verify: %s", err)
+			}
+		},
+	}
+
 	planCmd := &cobra.Command{
-		Use:   "plan [--repository https_team_repository_url] [--branch branch]",
+		Use:   "plan [--repository https_team_repository_url] [--branch branch] [--format text|json] [--exit-code] [--only-destructive] [--only kind:pattern] [--explain] [--show-values] [--force-auto-merge]",
 		Short: "Check the validity of IAC directory structure against a Github organization",
 		Long: `Check the validity of IAC directory structure against a Github organization.
 repository: a remote repository in the form https://github.com/...
 repository can be passed by parameter or by defining GOLIAC_SERVER_GIT_REPOSITORY env variable
-branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable`,
+branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable
+format: text (default) or json. With json, the list of planned operations is
+written to stdout, while the logs are still written to stderr
+only-destructive: if set, only the destructive operations (deletions, removals,
+  archivals) and the ones blocked by goliac.yaml are written to stdout
+only: restrict the plan to the matching entities, like team:payments or repo:billing-*
+  (kinds: user, team, repo, ruleset, variable, secret). Can be repeated
+explain: if set, each planned operation is written to stdout with the reason
+  it is planned (like a member present on Github but absent of the team file).
+  With json, each operation has a "reason" field
+exit-code: if set, the exit code reflects the plan result:
+  0: no changes
+  1: an error occurred
+  2: changes detected`,
 		Run: func(cmd *cobra.Command, args []string) {
 			repo := repositoryParameter
 			branch := branchParameter
 
+			if formatParameter != "text" && formatParameter != "json" {
+				logrus.Fatalf("invalid format %s, must be text or json", formatParameter)
+			}
+			// keep stdout for the json output
+			logrus.SetOutput(os.Stderr)
+
 			if repo == "" {
 				repo = config.Config.ServerGitRepository
 			}
@@ -60,29 +115,83 @@ branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env va
 				logrus.Fatalf("missing arguments. Try --help")
 			}
 
+			filter, err := newEntityFilter(onlyParameter)
+			if err != nil {
+				logrus.Fatalf("%s", err)
+			}
+
 			goliac, err := internal.NewGoliacImpl()
 			if err != nil {
 				logrus.Fatalf("failed to create goliac: %s", err)
 			}
+			goliac.SetEntityFilter(filter)
 			ctx := context.Background()
 			fs := osfs.New("/")
-			err, _, _, _ = goliac.Apply(ctx, fs, true, repo, branch, true)
+			err, errs, _, unmanaged := goliac.Apply(ctx, fs, true, repo, branch, true)
+			internal.WriteErrorReport(os.Stderr, errs)
 			if err != nil {
 				logrus.Errorf("Failed to plan: %v", err)
+				if exitCodeParameter {
+					os.Exit(1)
+				}
+				return
+			}
+			actions := goliac.GetPlannedActions()
+			if onlyDestructiveParameter {
+				actions = engine.DestructiveActions(actions, unmanaged)
+				if formatParameter == "text" && !explainParameter {
+					for _, action := range actions {
+						fmt.Printf("%s %s\n", action.Operation, action.Target)
+					}
+				}
+			}
+			if explainParameter {
+				if formatParameter == "text" {
+					for _, action := range actions {
+						fmt.Printf("%s\n    because %s\n", formatPlannedAction(action), action.Reason)
+					}
+				}
+			} else {
+				actions = engine.WithoutReasons(actions)
+			}
+			if formatParameter == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(actions); err != nil {
+					logrus.Errorf("Failed to encode the plan: %v", err)
+					if exitCodeParameter {
+						os.Exit(1)
+					}
+				}
+			}
+			if exitCodeParameter && len(actions) > 0 {
+				os.Exit(2)
 			}
 		},
 	}
 
 	planCmd.Flags().StringVarP(&repositoryParameter, "repository", "r", config.Config.ServerGitRepository, "repository (default env variable GOLIAC_SERVER_GIT_REPOSITORY)")
 	planCmd.Flags().StringVarP(&branchParameter, "branch", "b", config.Config.ServerGitBranch, "branch (default env variable GOLIAC_SERVER_GIT_BRANCH)")
+	planCmd.Flags().StringVarP(&formatParameter, "format", "f", "text", "output format: text or json")
+	planCmd.Flags().BoolVarP(&exitCodeParameter, "exit-code", "", false, "return 2 if changes are detected, 1 on error and 0 otherwise")
+	planCmd.Flags().BoolVarP(&onlyDestructiveParameter, "only-destructive", "", false, "show only the destructive and blocked operations")
+	planCmd.Flags().StringArrayVarP(&onlyParameter, "only", "", []string{}, "restrict the plan to the matching entities (like team:payments or repo:billing-*)")
+	planCmd.Flags().BoolVarP(&explainParameter, "explain", "", false, "show the reason of each planned operation")
+	planCmd.Flags().BoolVarP(&config.Config.ShowVariableValues, "show-values", "", config.Config.ShowVariableValues, "show the long org variables values (masked by default) in the logs and the plan")
+	planCmd.Flags().BoolVarP(&config.Config.ForceAutoMerge, "force-auto-merge", "", config.Config.ForceAutoMerge, "enable allow_auto_merge even on the repositories without required status checks")
 
 	applyCmd := &cobra.Command{
-		Use:   "apply [--repository https_team_repository_url] [--branch branch]",
+		Use:   "apply [--repository https_team_repository_url] [--branch branch] [--target-branch branch] [--only kind:pattern] [--show-values] [--force-auto-merge]",
 		Short: "Verify and apply a IAC directory structure to a Github organization",
 		Long: `Apply a IAC directory structure to a Github organization.
 repository: a remote repository in the form https://github.com/...
 repository can be passed by parameter or by defining GOLIAC_SERVER_GIT_REPOSITORY env variable
-branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable`,
+branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable
+target-branch: if set, the changes done by Goliac to the teams repository (CODEOWNERS, users sync, ...)
+are pushed to this branch, and a pull request is opened, instead of pushing to the branch
+only: restrict the apply to the matching entities, like team:payments or repo:billing-*
+  (kinds: user, team, repo, ruleset, variable, secret). Can be repeated. The other entities
+  are ignored (never deleted), and the org settings are not applied`,
 		Run: func(cmd *cobra.Command, args []string) {
 			repo := repositoryParameter
 			branch := branchParameter
@@ -97,14 +206,25 @@ branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env va
 				logrus.Fatalf("missing arguments, try --help")
 			}
 
+			if targetBranchParameter == branch {
+				logrus.Fatalf("the target branch must be different from the branch %s", branch)
+			}
+
 			goliac, err := internal.NewGoliacImpl()
 			if err != nil {
 				logrus.Fatalf("failed to create goliac: %s", err)
 			}
+			goliac.SetTargetBranch(targetBranchParameter)
+			filter, err := newEntityFilter(onlyParameter)
+			if err != nil {
+				logrus.Fatalf("%s", err)
+			}
+			goliac.SetEntityFilter(filter)
 
 			ctx := context.Background()
 			fs := osfs.New("/")
-			err, _, _, _ = goliac.Apply(ctx, fs, false, repo, branch, true)
+			err, errs, _, _ := goliac.Apply(ctx, fs, false, repo, branch, true)
+			internal.WriteErrorReport(os.Stderr, errs)
 			if err != nil {
 				logrus.Errorf("Failed to apply: %v", err)
 			}
@@ -112,14 +232,20 @@ branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env va
 	}
 	applyCmd.Flags().StringVarP(&repositoryParameter, "repository", "r", config.Config.ServerGitRepository, "repository (default env variable GOLIAC_SERVER_GIT_REPOSITORY)")
 	applyCmd.Flags().StringVarP(&branchParameter, "branch", "b", config.Config.ServerGitBranch, "branch (default env variable GOLIAC_SERVER_GIT_BRANCH)")
+	applyCmd.Flags().StringVarP(&targetBranchParameter, "target-branch", "", "", "push the changes to the teams repository to this branch and open a pull request")
+	applyCmd.Flags().StringArrayVarP(&onlyParameter, "only", "", []string{}, "restrict the apply to the matching entities (like team:payments or repo:billing-*)")
+	applyCmd.Flags().BoolVarP(&config.Config.ShowVariableValues, "show-values", "", config.Config.ShowVariableValues, "show the long org variables values (masked by default) in the logs and the plan")
+	applyCmd.Flags().BoolVarP(&config.Config.ForceAutoMerge, "force-auto-merge", "", config.Config.ForceAutoMerge, "enable allow_auto_merge even on the repositories without required status checks")
 
 	postSyncUsersCmd := &cobra.Command{
-		Use:   "syncusers [--repository https_team_repository_url] [--branch branch] [--dryrun] [--force]",
+		Use:   "syncusers [--repository https_team_repository_url] [--branch branch] [--target-branch branch] [--dryrun] [--force]",
 		Short: "Update and commit users and teams definition",
 		Long: `This command will use a user sync plugin to adjust users
  and team yaml definition, and commit them.
  repository: a remote repository in the form https://github.com/...
  branch: the branch to commit to.
+ force: sync even if the users set didn't change since the last sync, and ignore max_changesets
+ target-branch: if set, the changes are pushed to this branch, and a pull request is opened, instead of pushing to the branch
  repository can be passed by parameter or by defining GOLIAC_SERVER_GIT_REPOSITORY env variable
  branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable`,
 		Args: cobra.MatchAll(cobra.MinimumNArgs(2), cobra.OnlyValidArgs),
@@ -137,10 +263,15 @@ branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env va
 				logrus.Fatalf("missing arguments, try --help")
 			}
 
+			if targetBranchParameter == branch {
+				logrus.Fatalf("the target branch must be different from the branch %s", branch)
+			}
+
 			goliac, err := internal.NewGoliacImpl()
 			if err != nil {
 				logrus.Fatalf("failed to create goliac: %s", err)
 			}
+			goliac.SetTargetBranch(targetBranchParameter)
 			ctx := context.Background()
 			fs := osfs.New("/")
 			_, err = goliac.UsersUpdate(ctx, fs, repo, branch, dryrunParameter, forceParameter)
@@ -151,9 +282,158 @@ branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env va
 	}
 	postSyncUsersCmd.Flags().StringVarP(&repositoryParameter, "repository", "r", config.Config.ServerGitRepository, "repository (default env variable GOLIAC_SERVER_GIT_REPOSITORY)")
 	postSyncUsersCmd.Flags().StringVarP(&branchParameter, "branch", "b", config.Config.ServerGitBranch, "branch (default env variable GOLIAC_SERVER_GIT_BRANCH)")
+	postSyncUsersCmd.Flags().StringVarP(&targetBranchParameter, "target-branch", "", "", "push the changes to this branch and open a pull request")
 	postSyncUsersCmd.Flags().BoolVarP(&dryrunParameter, "dryrun", "d", false, "dryrun mode")
 	postSyncUsersCmd.Flags().BoolVarP(&forceParameter, "force", "f", false, "force mode")
 
+	doctorCmd := &cobra.Command{
+		Use:   "doctor [--repository https_team_repository_url] [--branch branch] [--fix]",
+		Short: "Look for inconsistencies between the IAC directory structure and a Github organization",
+		Long: `Look for inconsistencies between the IAC directory structure and a Github organization
+(like orphaned owners teams), and fix them if --fix is used (and destructive operations are allowed).
+repository: a remote repository in the form https://github.com/...
+repository can be passed by parameter or by defining GOLIAC_SERVER_GIT_REPOSITORY env variable
+branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable`,
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := repositoryParameter
+			branch := branchParameter
+
+			if repo == "" {
+				repo = config.Config.ServerGitRepository
+			}
+			if branch == "" {
+				branch = config.Config.ServerGitBranch
+			}
+			if repo == "" || branch == "" {
+				logrus.Fatalf("missing arguments, try --help")
+			}
+
+			goliac, err := internal.NewGoliacImpl()
+			if err != nil {
+				logrus.Fatalf("failed to create goliac: %s", err)
+			}
+			ctx := context.Background()
+			fs := osfs.New("/")
+			issues, err := goliac.Doctor(ctx, fs, repo, branch, fixParameter)
+			if err != nil {
+				logrus.Fatalf("failed to run doctor: %s", err)
+			}
+			for _, issue := range issues {
+				fmt.Println(issue)
+			}
+		},
+	}
+	doctorCmd.Flags().StringVarP(&repositoryParameter, "repository", "r", config.Config.ServerGitRepository, "repository (default env variable GOLIAC_SERVER_GIT_REPOSITORY)")
+	doctorCmd.Flags().StringVarP(&branchParameter, "branch", "b", config.Config.ServerGitBranch, "branch (default env variable GOLIAC_SERVER_GIT_BRANCH)")
+	doctorCmd.Flags().BoolVarP(&fixParameter, "fix", "f", false, "fix the issues found")
+
+	staleReposCmd := &cobra.Command{
+		Use:   "stale-repos [--repository https_team_repository_url] [--branch branch] [--since-duration 90d]",
+		Short: "List the managed repositories without any push for a given duration",
+		Long: `List the managed (and not archived) repositories without any push for a given duration,
+to help deciding which repositories to archive.
+repository: a remote repository in the form https://github.com/...
+repository can be passed by parameter or by defining GOLIAC_SERVER_GIT_REPOSITORY env variable
+branch can be passed by parameter or by defining GOLIAC_SERVER_GIT_BRANCH env variable
+since-duration: a number of days like '90d' (default), or a Go duration like '720h'`,
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := repositoryParameter
+			branch := branchParameter
+
+			if repo == "" {
+				repo = config.Config.ServerGitRepository
+			}
+			if branch == "" {
+				branch = config.Config.ServerGitBranch
+			}
+			if repo == "" || branch == "" {
+				logrus.Fatalf("missing arguments, try --help")
+			}
+			since, err := parseSinceDuration(sinceDurationParameter)
+			if err != nil {
+				logrus.Fatalf("invalid since-duration: %s", err)
+			}
+
+			goliac, err := internal.NewGoliacImpl()
+			if err != nil {
+				logrus.Fatalf("failed to create goliac: %s", err)
+			}
+			ctx := context.Background()
+			fs := osfs.New("/")
+			stale, err := goliac.StaleRepositories(ctx, fs, repo, branch, since)
+			if err != nil {
+				logrus.Fatalf("failed to list stale repositories: %s", err)
+			}
+			for _, r := range stale {
+				if r.PushedAt == nil {
+					fmt.Printf("%s: never pushed\n", r.Name)
+				} else {
+					fmt.Printf("%s: last pushed on %s\n", r.Name, r.PushedAt.Format("2006-01-02"))
+				}
+			}
+		},
+	}
+	staleReposCmd.Flags().StringVarP(&repositoryParameter, "repository", "r", config.Config.ServerGitRepository, "repository (default env variable GOLIAC_SERVER_GIT_REPOSITORY)")
+	staleReposCmd.Flags().StringVarP(&branchParameter, "branch", "b", config.Config.ServerGitBranch, "branch (default env variable GOLIAC_SERVER_GIT_BRANCH)")
+	staleReposCmd.Flags().StringVarP(&sinceDurationParameter, "since-duration", "s", "90d", "list the repositories without any push for this duration")
+
+	diffCmd := &cobra.Command{
+		Use:   "diff --base ref --head ref [--repository https_team_repository_url] [--format text|json] [--show-values]",
+		Short: "Show the changes of the IAC directory structure between 2 git refs",
+		Long: `Show the changes of the declared state (teams, users, repositories, rulesets, ...)
+of the teams repository between 2 git refs, typically to review a pending PR.
+Github is not involved: it is a structural diff of the yaml files.
+base, head: a branch, a tag or a commit of the teams repository
+repository: a remote repository in the form https://github.com/...
+repository can be passed by parameter or by defining GOLIAC_SERVER_GIT_REPOSITORY env variable
+format: text (default) or json. With json, the list of changes is
+written to stdout (with the same structure as the plan), while the logs are still written to stderr`,
+		Run: func(cmd *cobra.Command, args []string) {
+			repo := repositoryParameter
+
+			if formatParameter != "text" && formatParameter != "json" {
+				logrus.Fatalf("invalid format %s, must be text or json", formatParameter)
+			}
+			// keep stdout for the json output
+			logrus.SetOutput(os.Stderr)
+
+			if repo == "" {
+				repo = config.Config.ServerGitRepository
+			}
+			if repo == "" || baseParameter == "" || headParameter == "" {
+				logrus.Fatalf("missing arguments. Try --help")
+			}
+
+			goliac, err := internal.NewGoliacImpl()
+			if err != nil {
+				logrus.Fatalf("failed to create goliac: %s", err)
+			}
+			ctx := context.Background()
+			fs := osfs.New("/")
+			actions, err := goliac.Diff(ctx, fs, repo, baseParameter, headParameter)
+			if err != nil {
+				logrus.Fatalf("failed to diff: %s", err)
+			}
+			if formatParameter == "json" {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(actions); err != nil {
+					logrus.Fatalf("failed to encode the diff: %v", err)
+				}
+				return
+			}
+			for _, action := range actions {
+				fmt.Println(formatPlannedAction(action))
+			}
+		},
+	}
+	diffCmd.Flags().StringVarP(&repositoryParameter, "repository", "r", config.Config.ServerGitRepository, "repository (default env variable GOLIAC_SERVER_GIT_REPOSITORY)")
+	diffCmd.Flags().StringVarP(&baseParameter, "base", "", "", "base branch, tag or commit")
+	diffCmd.Flags().StringVarP(&headParameter, "head", "", "", "head branch, tag or commit")
+	diffCmd.Flags().StringVarP(&formatParameter, "format", "f", "text", "output format: text or json")
+	diffCmd.Flags().StringVarP(&formatParameter, "output", "o", "text", "alias of --format")
+	diffCmd.Flags().BoolVarP(&config.Config.ShowVariableValues, "show-values", "", config.Config.ShowVariableValues, "show the long org variables values (masked by default) in the logs and the plan")
+
 	scaffoldcmd := &cobra.Command{
 		Use:   "scaffold <directory> [--adminteam goliac_admin_team_name]",
 		Short: "Will create a base directory based on your current Github organization",
@@ -206,6 +486,71 @@ Now you can push this directory as a new repository to Github, like:
 	}
 	scaffoldcmd.Flags().StringVarP(&goliacAdminTeamnameParameter, "adminteam", "a", "goliac-admin", "name of the goliac admin team")
 
+	exportcmd := &cobra.Command{
+		Use:   "export <directory> [--adminteam goliac_admin_team_name]",
+		Short: "Will write the current Github organization state as a goliac directory",
+		Long: `Unlike scaffold, this command writes the Github organization as it is
+(without creating an admin team, nor adding a README or a Github action), so that
+the directory can be diffed with a teams repository, or used for disaster recovery.
+The adminteam is the existing team that contains Github administrator`,
+		Args: cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			directory := args[0]
+			adminteam := goliacAdminTeamnameParameter
+			if directory == "" || adminteam == "" {
+				logrus.Fatalf("missing arguments. Try --help")
+			}
+			scaffold, err := internal.NewScaffold()
+			if err != nil {
+				logrus.Fatalf("failed to create scaffold: %s", err)
+			}
+			fmt.Println("Exporting the Github organization, it can take several minutes to list everything. \u2615")
+
+			err = scaffold.Export(directory, adminteam)
+			if err != nil {
+				logrus.Fatalf("failed to export the organization: %s", err)
+			}
+			fmt.Printf("Github organization exported in %s\n", directory)
+		},
+	}
+	exportcmd.Flags().StringVarP(&goliacAdminTeamnameParameter, "adminteam", "a", "goliac-admin", "name of the goliac admin team")
+
+	validateRemoteCmd := &cobra.Command{
+		Use:   "validate-remote",
+		Short: "Check that the Github App has the permissions needed by Goliac",
+		Long: `Check (with read only calls) that the Github App defined by the GOLIAC_GITHUB_APP_*
+env variables has the permissions needed by Goliac on the organization, and print a checklist.
+It exits with 1 if a permission is missing`,
+		Run: func(cmd *cobra.Command, args []string) {
+			client, err := github.NewGitHubClientImpl(
+				config.Config.GithubServer,
+				config.Config.GithubAppOrganization,
+				config.Config.GithubAppID,
+				config.Config.GithubAppPrivateKeyFile,
+			)
+			if err != nil {
+				logrus.Fatalf("failed to authenticate the Github App: %s", err)
+			}
+
+			missing := false
+			checks := engine.ValidateRemote(context.Background(), config.Config.GithubAppOrganization, client)
+			for _, check := range checks {
+				switch {
+				case check.Ok:
+					fmt.Printf("[x] %s\n", check.Name)
+				case check.Skipped:
+					fmt.Printf("[-] %s: skipped (%v)\n", check.Name, check.Err)
+				default:
+					missing = true
+					fmt.Printf("[ ] %s: missing %s (%v)\n", check.Name, check.Permission, check.Err)
+				}
+			}
+			if missing {
+				os.Exit(1)
+			}
+		},
+	}
+
 	servecmd := &cobra.Command{
 		Use:   "serve",
 		Short: "This will start the application in server mode",
@@ -217,10 +562,19 @@ any changes from the teams Git repository to Github.`,
 			if err != nil {
 				logrus.Fatalf("failed to create goliac: %s", err)
 			}
+			if config.Config.NotifyOn != "always" && config.Config.NotifyOn != "changes" && config.Config.NotifyOn != "errors" {
+				logrus.Fatalf("invalid GOLIAC_NOTIFY_ON: %s (must be always, changes or errors)", config.Config.NotifyOn)
+			}
 			notificationService := notification.NewNullNotificationService()
 			if config.Config.SlackToken != "" && config.Config.SlackChannel != "" {
 				slackService := notification.NewSlackNotificationService(config.Config.SlackToken, config.Config.SlackChannel)
 				notificationService = slackService
+			} else if config.Config.MsTeamsWebhookUrl != "" {
+				msteamsService := notification.NewMsTeamsNotificationService(config.Config.MsTeamsWebhookUrl)
+				notificationService = msteamsService
+			} else if config.Config.NotificationWebhookUrl != "" {
+				webhookService := notification.NewWebhookNotificationService(config.Config.NotificationWebhookUrl, config.Config.NotificationWebhookToken, config.Config.GithubAppOrganization)
+				notificationService = webhookService
 			}
 
 			server := internal.NewGoliacServer(goliac, notificationService)
@@ -245,10 +599,16 @@ Either local directory, or remote git repository`,
 	}
 
 	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(verifyFileCmd)
 	rootCmd.AddCommand(planCmd)
 	rootCmd.AddCommand(applyCmd)
 	rootCmd.AddCommand(postSyncUsersCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(validateRemoteCmd)
+	rootCmd.AddCommand(staleReposCmd)
+	rootCmd.AddCommand(diffCmd)
 	rootCmd.AddCommand(scaffoldcmd)
+	rootCmd.AddCommand(exportcmd)
 	rootCmd.AddCommand(servecmd)
 	rootCmd.AddCommand(versioncmd)
 
@@ -265,3 +625,46 @@ Either local directory, or remote git repository`,
 		os.Exit(1)
 	}
 }
+
+/*
+ * parseSinceDuration parses a number of days (like "90d"), or a Go duration (like "720h")
+ */
+/*
+ * newEntityFilter parses the --only parameters (nil if there is none)
+ */
+func newEntityFilter(only []string) (engine.EntityFilter, error) {
+	filter, err := engine.NewEntityFilter(only)
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		logrus.Warnf("only the entities matching %s are reconciliated: the global consistency (like the org settings or the dangling references) is not checked", strings.Join(only, ", "))
+	}
+	return filter, nil
+}
+
+/*
+ * formatPlannedAction returns a one line description of a planned action, like
+ * update_team_add_member team/team1/member/user1: null -> "member"
+ */
+func formatPlannedAction(action engine.PlannedAction) string {
+	toJson := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(b)
+	}
+	return fmt.Sprintf("%s %s: %s -> %s", action.Operation, action.Target, toJson(action.Before), toJson(action.After))
+}
+
+func parseSinceDuration(duration string) (time.Duration, error) {
+	if strings.HasSuffix(duration, "d") {
+		nbDays, err := strconv.Atoi(strings.TrimSuffix(duration, "d"))
+		if err != nil || nbDays < 0 {
+			return 0, fmt.Errorf("not a number of days: %s", duration)
+		}
+		return time.Duration(nbDays) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(duration)
+}
@@ -25,7 +25,9 @@ func setupLogrus() {
 		logrus.SetFormatter(&logrus.TextFormatter{})
 	case "json":
 		logrus.SetFormatter(&logrus.JSONFormatter{})
+	case "github-actions":
+		logrus.SetFormatter(&GithubActionsFormatter{})
 	default:
-		logrus.Warnf("unexpected logrus format: %s, should be one of: text, json", Config.LogrusFormat)
+		logrus.Warnf("unexpected logrus format: %s, should be one of: text, json, github-actions", Config.LogrusFormat)
 	}
 }
@@ -7,7 +7,10 @@ const (
 	ContextKeyStatistics contextKey = "githubStatistics"
 )
 
+// the statistics are updated concurrently (with sync/atomic)
 type GoliacStatistics struct {
-	GithubApiCalls  int
-	GithubThrottled int
+	GithubApiCalls  int64
+	GithubThrottled int64
+	// REST calls answered with a 304 Not Modified (see GOLIAC_GITHUB_CONDITIONAL_REQUESTS)
+	GithubNotModified int64
 }
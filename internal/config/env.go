@@ -6,7 +6,7 @@ var Config = struct {
 	// LogrusLevel sets the logrus logging level
 	LogrusLevel string `env:"GOLIAC_LOGRUS_LEVEL" envDefault:"info"`
 	// LogrusFormat sets the logrus logging formatter
-	// Possible values: text, json
+	// Possible values: text, json, github-actions
 	LogrusFormat string `env:"GOLIAC_LOGRUS_FORMAT" envDefault:"text"`
 
 	GithubServer                string `env:"GOLIAC_GITHUB_SERVER" envDefault:"https://api.github.com"`
@@ -20,6 +20,26 @@ var Config = struct {
 
 	GithubConcurrentThreads int64 `env:"GOLIAC_GITHUB_CONCURRENT_THREADS" envDefault:"1"`
 	GithubCacheTTL          int64 `env:"GOLIAC_GITHUB_CACHE_TTL" envDefault:"86400"`
+	// how many times a Github request hitting a secondary rate limit is retried, the base delay (in milliseconds) of the
+	// exponential backoff and the maximum delay (in milliseconds) we wait before a retry, whatever Github asks for.
+	// On the primary rate limit, the request waits for X-RateLimit-Reset (whatever these settings)
+	GithubMaxRetries     int   `env:"GOLIAC_GITHUB_MAX_RETRIES" envDefault:"5"`
+	GithubRetryBaseDelay int64 `env:"GOLIAC_GITHUB_RETRY_BASE_DELAY" envDefault:"1000"`
+	GithubRetryMaxDelay  int64 `env:"GOLIAC_GITHUB_RETRY_MAX_DELAY" envDefault:"60000"`
+	// number of repositories fetched per GraphQL page (between 1 and 100). It is halved automatically on timeout
+	GithubRepositoriesPageSize int `env:"GOLIAC_GITHUB_REPOSITORIES_PAGE_SIZE" envDefault:"100"`
+	// send the ETag of the previous response (If-None-Match) on REST GET calls, a 304 Not Modified doesn't count against the rate limit
+	GithubConditionalRequests bool `env:"GOLIAC_GITHUB_CONDITIONAL_REQUESTS" envDefault:"false"`
+	// the installation token is reused until it expires in less than this window (in seconds)
+	GithubTokenRefreshWindow int64 `env:"GOLIAC_GITHUB_TOKEN_REFRESH_WINDOW" envDefault:"300"`
+	// a PEM bundle of CA certificates trusted (in addition to the system ones) when calling Github, for proxies with an internal CA
+	// (the proxy itself is set with HTTPS_PROXY/NO_PROXY)
+	GithubCACert string `env:"GOLIAC_GITHUB_CA_CERT" envDefault:""`
+
+	// show the org variables values in the logs and in the plan (long values are masked by default)
+	ShowVariableValues bool `env:"GOLIAC_SHOW_VARIABLE_VALUES" envDefault:"false"`
+	// enable allow_auto_merge even on the repositories without required status checks
+	ForceAutoMerge bool `env:"GOLIAC_FORCE_AUTO_MERGE" envDefault:"false"`
 
 	ServerApplyInterval int64  `env:"GOLIAC_SERVER_APPLY_INTERVAL" envDefault:"600"`
 	ServerGitRepository string `env:"GOLIAC_SERVER_GIT_REPOSITORY" envDefault:""`
@@ -27,12 +47,43 @@ var Config = struct {
 	// the name of the CI validating each PR on the teams repsotiry. See scaffold.go for the Github action
 	ServerGitBranchProtectionRequiredCheck string `env:"GOLIAC_SERVER_GIT_BRANCH_PROTECTION_REQUIRED_CHECK" envDefault:"validate"`
 
+	// how old (in seconds) the last successful apply can be before /readyz fails (0 means 2 apply intervals)
+	ServerReadinessMaxAge int64 `env:"GOLIAC_SERVER_READINESS_MAX_AGE" envDefault:"0"`
+
+	// if set, the server writes a JSON report of each apply in this directory (keeping the last ServerReportMaxCount ones)
+	ServerReportDir      string `env:"GOLIAC_SERVER_REPORT_DIR" envDefault:""`
+	ServerReportMaxCount int    `env:"GOLIAC_SERVER_REPORT_MAX_COUNT" envDefault:"100"`
+
+	// if set, the hash of the last notified plan is kept in this file (to not notify again the same changes after a restart)
+	ServerPlanHashFile string `env:"GOLIAC_SERVER_PLAN_HASH_FILE" envDefault:""`
+	// if set, the server only detects (and reports) the drift: every apply is a dryrun, and nothing is ever changed
+	ServerReadOnly bool `env:"GOLIAC_SERVER_READONLY" envDefault:"false"`
+
+	// expose Prometheus metrics on the /metrics endpoint of the server
+	ServerMetricsEnabled bool `env:"GOLIAC_SERVER_METRICS_ENABLED" envDefault:"false"`
+	// if set (like ":9090"), the metrics are (also) served on /metrics of a dedicated listener, without the UI and the REST API
+	ServerMetricsAddr string `env:"GOLIAC_METRICS_ADDR" envDefault:""`
+
 	// MaxChangesetsOverride - override the max changesets limitation from the repository config
 	MaxChangesetsOverride bool `env:"GOLIAC_MAX_CHANGESETS_OVERRIDE" envDefault:"false"`
 
 	// SyncUsersBeforeApply - to sync users before applying the commits
 	SyncUsersBeforeApply bool `env:"GOLIAC_SYNC_USERS_BEFORE_APPLY" envDefault:"true"`
 
+	// to sync users from a LDAP directory (with the "ldap" usersync plugin)
+	SyncLdapUrl             string `env:"GOLIAC_SYNC_LDAP_URL" envDefault:""` // ldap://host:389 or ldaps://host:636
+	SyncLdapBindDN          string `env:"GOLIAC_SYNC_LDAP_BIND_DN" envDefault:""`
+	SyncLdapBindPassword    string `env:"GOLIAC_SYNC_LDAP_BIND_PASSWORD" envDefault:""`
+	SyncLdapBaseDN          string `env:"GOLIAC_SYNC_LDAP_BASE_DN" envDefault:""`
+	SyncLdapFilter          string `env:"GOLIAC_SYNC_LDAP_FILTER" envDefault:"(objectClass=person)"`
+	SyncLdapUserAttribute   string `env:"GOLIAC_SYNC_LDAP_USER_ATTRIBUTE" envDefault:"uid"`           // used as the user name
+	SyncLdapGithubAttribute string `env:"GOLIAC_SYNC_LDAP_GITHUB_ATTRIBUTE" envDefault:"githubLogin"` // used as the githubID
+
+	// to sync users from Okta (with the "okta" usersync plugin)
+	SyncOktaDomain          string `env:"GOLIAC_SYNC_OKTA_DOMAIN" envDefault:""` // like mycompany.okta.com
+	SyncOktaToken           string `env:"GOLIAC_SYNC_OKTA_TOKEN" envDefault:""`
+	SyncOktaGithubAttribute string `env:"GOLIAC_SYNC_OKTA_GITHUB_ATTRIBUTE" envDefault:"githubLogin"` // custom profile attribute used as the githubID
+
 	// Host - golang-skeleton server host
 	SwaggerHost string `env:"GOLIAC_SERVER_HOST" envDefault:"localhost"`
 	// Port - golang-skeleton server port
@@ -59,10 +110,23 @@ var Config = struct {
 	// API path => localhost:18000/foo/api/v1"
 	WebPrefix string `env:"GOLIAC_WEB_PREFIX" envDefault:""`
 
+	// when the server sends a notification:
+	// - errors: on a new apply error
+	// - changes: when changes were applied, or on a new apply error
+	// - always: after each apply run, even if nothing changed
+	NotifyOn string `env:"GOLIAC_NOTIFY_ON" envDefault:"errors"`
+
 	// to receive slack notifications on errors
 	SlackToken   string `env:"GOLIAC_SLACK_TOKEN" envDefault:""`
 	SlackChannel string `env:"GOLIAC_SLACK_CHANNEL" envDefault:""`
 
+	// to receive notifications on errors in a Microsoft Teams channel (incoming webhook)
+	MsTeamsWebhookUrl string `env:"GOLIAC_MSTEAMS_WEBHOOK_URL" envDefault:""`
+
+	// to receive notifications on errors via a generic webhook (JSON POST)
+	NotificationWebhookUrl   string `env:"GOLIAC_NOTIFICATION_WEBHOOK_URL" envDefault:""`
+	NotificationWebhookToken string `env:"GOLIAC_NOTIFICATION_WEBHOOK_TOKEN" envDefault:""`
+
 	// to receive Github main branch merge webhook events on the /webhook endpoint
 	GithubWebhookSecret        string `env:"GOLIAC_GITHUB_WEBHOOK_SECRET" envDefault:""`
 	GithubWebhookDedicatedHost string `env:"GOLIAC_GITHUB_WEBHOOK_HOST" envDefault:"localhost"`
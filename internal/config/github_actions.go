@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+ * GithubActionsFormatter is a logrus formatter rendering the errors and the
+ * warnings as Github workflow commands (::error::, ::warning::), so they are
+ * surfaced as annotations in the Github Actions run UI
+ * See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+ */
+type GithubActionsFormatter struct {
+}
+
+func (f *GithubActionsFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	message := entry.Message
+
+	keys := make([]string, 0, len(entry.Data))
+	for k := range entry.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		message += fmt.Sprintf(" %s=%v", k, entry.Data[k])
+	}
+
+	var b bytes.Buffer
+	switch entry.Level {
+	case logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel:
+		b.WriteString("::error::" + escapeGithubActionsData(message))
+	case logrus.WarnLevel:
+		b.WriteString("::warning::" + escapeGithubActionsData(message))
+	case logrus.DebugLevel, logrus.TraceLevel:
+		b.WriteString("::debug::" + escapeGithubActionsData(message))
+	default:
+		b.WriteString(message)
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
+
+/*
+ * escapeGithubActionsData escapes a workflow command message (a multi lines
+ * message would be truncated otherwise)
+ */
+func escapeGithubActionsData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+/*
+ * LogGroup starts a collapsible group of logs (when GOLIAC_LOGRUS_FORMAT is
+ * github-actions) and returns the function to close it
+ * Usage:
+ *
+ *	defer config.LogGroup("Reconciliation")()
+ */
+func LogGroup(title string) func() {
+	if Config.LogrusFormat != "github-actions" {
+		return func() {}
+	}
+	out := logrus.StandardLogger().Out
+	fmt.Fprintf(out, "::group::%s\n", escapeGithubActionsData(title))
+	return func() {
+		fmt.Fprintln(out, "::endgroup::")
+	}
+}
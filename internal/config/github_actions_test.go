@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubActionsFormatter(t *testing.T) {
+
+	newLogger := func() (*logrus.Logger, *bytes.Buffer) {
+		var out bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&out)
+		logger.SetFormatter(&GithubActionsFormatter{})
+		logger.SetLevel(logrus.DebugLevel)
+		return logger, &out
+	}
+
+	t.Run("happy path: errors are rendered as error annotations", func(t *testing.T) {
+		logger, out := newLogger()
+		logger.Errorf("invalid team %s", "team1")
+		assert.Equal(t, "::error::invalid team team1\n", out.String())
+	})
+
+	t.Run("happy path: warnings are rendered as warning annotations", func(t *testing.T) {
+		logger, out := newLogger()
+		logger.WithField("command", "create_team").Warn("team1 already exists")
+		assert.Equal(t, "::warning::team1 already exists command=create_team\n", out.String())
+	})
+
+	t.Run("happy path: info and debug messages", func(t *testing.T) {
+		logger, out := newLogger()
+		logger.Info("applying")
+		logger.Debug("details")
+		assert.Equal(t, "applying\n::debug::details\n", out.String())
+	})
+
+	t.Run("happy path: multi lines messages are escaped", func(t *testing.T) {
+		logger, out := newLogger()
+		logger.Error("100% failed\nsee logs")
+		assert.Equal(t, "::error::100%25 failed%0Asee logs\n", out.String())
+	})
+}
+
+func TestLogGroup(t *testing.T) {
+
+	var out bytes.Buffer
+	previous := logrus.StandardLogger().Out
+	logrus.SetOutput(&out)
+	defer logrus.SetOutput(previous)
+	format := Config.LogrusFormat
+	defer func() { Config.LogrusFormat = format }()
+
+	t.Run("happy path: group with the github-actions format", func(t *testing.T) {
+		out.Reset()
+		Config.LogrusFormat = "github-actions"
+		LogGroup("Reconciliation")()
+		assert.Equal(t, "::group::Reconciliation\n::endgroup::\n", out.String())
+	})
+
+	t.Run("happy path: no group with the text format", func(t *testing.T) {
+		out.Reset()
+		Config.LogrusFormat = "text"
+		LogGroup("Reconciliation")()
+		assert.Equal(t, "", out.String())
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"net/http"
 
+	"github.com/Alayacare/goliac/internal/metrics"
 	negronilogrus "github.com/meatballhat/negroni-logrus"
 	"github.com/phyber/negroni-gzip/gzip"
 	"github.com/rs/cors"
@@ -43,6 +44,10 @@ func SetupGlobalMiddleware(handler http.Handler) http.Handler {
 		}))
 	}
 
+	if Config.ServerMetricsEnabled {
+		n.Use(metricsMiddleware())
+	}
+
 	n.Use(&negroni.Static{
 		Dir:       http.Dir("./browser/goliac-ui/dist/"),
 		Prefix:    Config.WebPrefix,
@@ -56,6 +61,18 @@ func SetupGlobalMiddleware(handler http.Handler) http.Handler {
 	return n
 }
 
+// metricsMiddleware serves the Prometheus metrics on /metrics
+func metricsMiddleware() negroni.HandlerFunc {
+	handler := metrics.Handler()
+	return func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		if r.URL.Path == "/metrics" && r.Method == http.MethodGet {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		next(w, r)
+	}
+}
+
 type recoveryLogger struct{}
 
 func (r *recoveryLogger) Printf(format string, v ...interface{}) {
@@ -1,9 +1,22 @@
 package config
 
 import (
+	"path"
+
 	"gopkg.in/yaml.v3"
 )
 
+const DEFAULT_ARCHIVED_TEAM_PREFIX = "archived/"
+
+/*
+ * BranchProtectionTemplate is a named (default branch) protection that the
+ * repositories can reference with branch_protection_template. A nil value
+ * means the setting is left to the repository
+ */
+type BranchProtectionTemplate struct {
+	RequireSignedCommits *bool `yaml:"require_signed_commits"`
+}
+
 type RepositoryConfig struct {
 	AdminTeam           string `yaml:"admin_team"`
 	EveryoneTeamEnabled bool   `yaml:"everyone_team_enabled"`
@@ -18,12 +31,90 @@ type RepositoryConfig struct {
 		Plugin string `yaml:"plugin"`
 		Path   string `yaml:"path"`
 	}
-	ArchiveOnDelete       bool `yaml:"archive_on_delete"`
+	ArchiveOnDelete bool `yaml:"archive_on_delete"`
+	// Github cannot archive teams: if enabled, a deleted team is renamed (with the
+	// ArchivedTeamPrefix prefix) and detached from all its repositories instead
+	ArchiveTeamByRenaming bool   `yaml:"archive_team_by_renaming"`
+	ArchivedTeamPrefix    string `yaml:"archived_team_prefix"`
+	// org members (githubid) that are never removed from the organization, even if they are not defined in the users directory
+	ExemptMembers         []string `yaml:"exempt_members"`
+	ManageGithubVariables bool     `yaml:"manage_github_variables"`
+	// if enabled, the visibility of the org Actions secrets (defined in org-secrets.yaml) is managed.
+	// The secrets values are never managed: the secrets must be created out-of-band
+	ManageGithubSecrets bool `yaml:"manage_github_secrets"`
+	// if enabled, the repositories custom properties not defined in the repository files are unset
+	// (if destructive_operations.custom_properties is enabled)
+	ManageGithubRepositoryCustomProperties bool `yaml:"manage_github_repository_custom_properties"`
+	// when a repository grants a team a custom repository role that doesn't exist in the organization:
+	// - base_role (default): the team gets its base permission (as a writer or a reader), with a warning
+	// - error: the apply fails
+	CustomRolesFallback string `yaml:"custom_roles_fallback"`
+	// organization members privileges. A nil value means the setting is not managed by Goliac
+	OrgSettings struct {
+		MembersCanCreatePages                *bool `yaml:"members_can_create_pages"`
+		MembersCanCreatePrivatePages         *bool `yaml:"members_can_create_private_pages"`
+		MembersCanCreateInternalRepositories *bool `yaml:"members_can_create_internal_repositories"`
+		// the two-factor requirement cannot be enabled through the Github API: it is only checked (and reported)
+		TwoFactorRequirementEnabled *bool `yaml:"two_factor_requirement_enabled"`
+		// link shown when the secret scanning push protection blocks a push (an empty value disables it)
+		SecretScanningPushProtectionCustomLink *string `yaml:"secret_scanning_push_protection_custom_link"`
+		// base permission of the members on all the repositories: read, write, admin or none
+		DefaultRepositoryPermission *string `yaml:"default_repository_permission"`
+	} `yaml:"org_settings"`
+	// merge methods allowed in the organization. A false value disables the merge method
+	// on all the repositories (and a repository cannot enable it). A nil value lets the
+	// repositories decide
+	MergeMethods struct {
+		AllowMergeCommit *bool `yaml:"allow_merge_commit"`
+		AllowSquashMerge *bool `yaml:"allow_squash_merge"`
+		AllowRebaseMerge *bool `yaml:"allow_rebase_merge"`
+	} `yaml:"merge_methods"`
+	// named branch protection templates, referenced by the repositories.
+	// The settings set in a repository file override the template ones
+	BranchProtectionTemplates map[string]BranchProtectionTemplate `yaml:"branch_protection_templates"`
+	// the visibilities (public, private or internal) the repositories can have. Empty means all
+	AllowedRepositoryVisibilities []string `yaml:"allowed_repository_visibilities"`
+	// the repositories (globs, like "bot-*") not managed by Goliac: they are never
+	// created, updated, archived nor deleted
+	IgnoredRepositories []string `yaml:"ignored_repositories"`
+	// the maximum number of (non archived) repositories a team can own. 0 means no limit
+	RepositoriesQuota struct {
+		MaxPerTeam int            `yaml:"max_per_team"`
+		Teams      map[string]int `yaml:"teams"` // team name -> limit, overrides max_per_team
+	} `yaml:"repositories_quota"`
+	// the maximum number of repositories (deleted or archived), teams (deleted or archived)
+	// and org members (removed) in a single apply: above, the apply is aborted before
+	// any change. 0 means no limit
+	MaxDestructiveChanges struct {
+		Repositories int `yaml:"repositories"`
+		Teams        int `yaml:"teams"`
+		Members      int `yaml:"members"`
+	} `yaml:"max_destructive_changes"`
+	// the number of approvals a pull request of the teams repository needs: it is
+	// required by the branch protection (and a ruleset) of the teams repository, and a
+	// commit merged with less approvals is not applied. 0 means no approval required
+	RequiredApprovals     int `yaml:"required_approvals"`
 	DestructiveOperations struct {
 		AllowDestructiveRepositories bool `yaml:"repositories"`
 		AllowDestructiveTeams        bool `yaml:"teams"`
 		AllowDestructiveUsers        bool `yaml:"users"`
 		AllowDestructiveRulesets     bool `yaml:"rulesets"`
+		// only the rulesets still defined in the rulesets directory, but not
+		// referenced in goliac.yaml anymore (the other ones follow "rulesets")
+		AllowDestructiveRemovedRulesets bool `yaml:"removed_rulesets"`
+		AllowDestructiveOrgSettings     bool `yaml:"org_settings"`
+		// the org secrets not defined in org-secrets.yaml are deleted
+		AllowDestructiveOrgSecrets bool `yaml:"org_secrets"`
+		// the org variables not defined in org-variables.yaml are deleted
+		AllowDestructiveOrgVariables bool `yaml:"org_variables"`
+		// the repository webhooks not listed in the repository file are deleted
+		AllowDestructiveWebhooks bool `yaml:"webhooks"`
+		// the repository labels not listed in the repository file are deleted (with labels_managed)
+		AllowDestructiveRepositoryLabels bool `yaml:"labels"`
+		// the repository custom properties not listed in the repository file are unset (with manage_github_repository_custom_properties)
+		AllowDestructiveCustomProperties bool `yaml:"custom_properties"`
+		// a private repository can be made public
+		AllowPublicVisibilityChange bool `yaml:"public_visibility_change"`
 	} `yaml:"destructive_operations"`
 }
 
@@ -36,6 +127,8 @@ func (rc *RepositoryConfig) UnmarshalYAML(value *yaml.Node) error {
 	x.GithubConcurrentThreads = 4
 	x.UserSync.Plugin = "noop"
 	x.ArchiveOnDelete = true
+	x.ArchivedTeamPrefix = DEFAULT_ARCHIVED_TEAM_PREFIX
+	x.CustomRolesFallback = "base_role"
 
 	if err := value.Decode(x); err != nil {
 		return err
@@ -44,3 +137,16 @@ func (rc *RepositoryConfig) UnmarshalYAML(value *yaml.Node) error {
 	*rc = RepositoryConfig(*x)
 	return nil
 }
+
+/*
+ * IsIgnoredRepository returns true if the repository matches one of the
+ * ignored_repositories globs
+ */
+func (rc *RepositoryConfig) IsIgnoredRepository(reponame string) bool {
+	for _, glob := range rc.IgnoredRepositories {
+		if ok, _ := path.Match(glob, reponame); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package engine
+
+import "strings"
+
+// the permissions, from the weakest to the strongest
+var permissionRanks = map[string]int{
+	"none":     0,
+	"read":     1,
+	"pull":     1,
+	"triage":   2,
+	"write":    3,
+	"push":     3,
+	"maintain": 4,
+	"admin":    5,
+}
+
+/*
+ * DefaultRepositoryPermissionImpact estimates the members access changed by
+ * a new organization default repository permission
+ */
+type DefaultRepositoryPermissionImpact struct {
+	Repositories int // repositories on which the effective access of at least one member changes
+	Members      int // members whose effective access changes on at least one repository
+}
+
+/*
+ * defaultRepositoryPermissionImpact compares, for each (non owner) member and
+ * each repository, the effective access (the strongest of the default
+ * permission and of the teams permissions) before and after the change.
+ * It is an estimate: the access inherited from a parent team and the
+ * repositories collaborators are not taken into account
+ */
+func defaultRepositoryPermissionImpact(remote *MutableGoliacRemoteImpl, from string, to string) DefaultRepositoryPermissionImpact {
+	impact := DefaultRepositoryPermissionImpact{}
+
+	var customRoles map[string]string
+	rank := func(permission string) int {
+		if r, ok := permissionRanks[strings.ToLower(permission)]; ok {
+			return r
+		}
+		// a custom repository role: its base role
+		if customRoles == nil {
+			customRoles = remote.CustomRepositoryRoles()
+		}
+		return permissionRanks[strings.ToLower(customRoles[permission])]
+	}
+
+	// repository -> member -> strongest team permission
+	teamsAccess := make(map[string]map[string]int)
+	for teamslug, repos := range remote.TeamRepositories() {
+		team, ok := remote.Teams()[teamslug]
+		if !ok {
+			continue
+		}
+		for reponame, tr := range repos {
+			if teamsAccess[reponame] == nil {
+				teamsAccess[reponame] = make(map[string]int)
+			}
+			r := rank(tr.Permission)
+			for _, member := range append(append([]string{}, team.Members...), team.Maintainers...) {
+				if r > teamsAccess[reponame][member] {
+					teamsAccess[reponame][member] = r
+				}
+			}
+		}
+	}
+
+	members := []string{}
+	for githubid, role := range remote.Users() {
+		// the owners are admin of all the repositories
+		if role != "ADMIN" {
+			members = append(members, githubid)
+		}
+	}
+
+	fromRank := permissionRanks[from]
+	toRank := permissionRanks[to]
+	affected := make(map[string]bool)
+	for reponame, repo := range remote.Repositories() {
+		floor := 0
+		if !repo.BoolProperties["private"] {
+			// everyone can read a public repository
+			floor = permissionRanks["read"]
+		}
+		changed := false
+		for _, member := range members {
+			access := teamsAccess[reponame][member]
+			if access < floor {
+				access = floor
+			}
+			before, after := access, access
+			if fromRank > before {
+				before = fromRank
+			}
+			if toRank > after {
+				after = toRank
+			}
+			if before != after {
+				changed = true
+				affected[member] = true
+			}
+		}
+		if changed {
+			impact.Repositories++
+		}
+	}
+	impact.Members = len(affected)
+
+	return impact
+}
@@ -1,7 +1,7 @@
 package engine
 
 type Comparable interface {
-	*GithubTeamComparable | *GithubRepoComparable | *GithubRuleSet
+	*GithubTeamComparable | *GithubRepoComparable | *GithubRuleSet | *GithubOrgVariable | *GithubOrgSecret
 }
 
 type CompareEqualAB[A Comparable, B Comparable] func(value1 A, value2 B) bool
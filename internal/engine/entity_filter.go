@@ -0,0 +1,84 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Alayacare/goliac/internal/config"
+)
+
+// the kinds of entities a reconciliation can be restricted to
+var entityFilterKinds = map[string]bool{
+	"user":     true, // github id
+	"team":     true, // team slug (the owners team follows its team)
+	"repo":     true, // repository name
+	"ruleset":  true, // ruleset name
+	"variable": true, // org variable name
+	"secret":   true, // org secret name
+}
+
+/*
+ * EntityFilter restricts a reconciliation to the matching entities (like
+ * kind "team", name "payments"). The other entities, local or remote, are
+ * ignored: they are neither created, updated nor deleted.
+ * A nil filter matches all the entities
+ */
+type EntityFilter func(kind string, name string) bool
+
+/*
+ * NewEntityFilter returns a filter matching any of the patterns, in the form
+ * <kind>:<glob> (like "team:payments" or "repo:billing-*")
+ */
+func NewEntityFilter(patterns []string) (EntityFilter, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	globs := make(map[string][]string)
+	for _, p := range patterns {
+		kind, glob, found := strings.Cut(p, ":")
+		if !found || glob == "" {
+			return nil, fmt.Errorf("invalid filter %s: must be <kind>:<pattern>", p)
+		}
+		if !entityFilterKinds[kind] {
+			return nil, fmt.Errorf("invalid filter %s: unknown kind %s (must be user, team, repo, ruleset, variable or secret)", p, kind)
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter %s: %v", p, err)
+		}
+		globs[kind] = append(globs[kind], glob)
+	}
+
+	return func(kind string, name string) bool {
+		if kind == "team" {
+			name = strings.TrimSuffix(name, config.Config.GoliacTeamOwnerSuffix)
+		}
+		for _, glob := range globs[kind] {
+			if ok, _ := path.Match(glob, name); ok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func (f EntityFilter) match(kind string, name string) bool {
+	return f == nil || f(kind, name)
+}
+
+/*
+ * filterEntities returns the entities (local or remote) matching the filter
+ */
+func filterEntities[V any](entities map[string]V, filter EntityFilter, kind string) map[string]V {
+	if filter == nil {
+		return entities
+	}
+	filtered := make(map[string]V)
+	for name, entity := range entities {
+		if filter.match(kind, name) {
+			filtered[name] = entity
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/gosimple/slug"
+)
+
+/*
+ * explainer finds why an action is planned, from the local definitions
+ * (the teams repository files)
+ */
+type explainer struct {
+	teams     map[string]*entity.Team // team slug -> team
+	repos     map[string]*entity.Repository
+	githubids map[string]bool // the github ids defined in the users directory
+}
+
+func newExplainer(local GoliacLocal) *explainer {
+	e := &explainer{
+		teams:     make(map[string]*entity.Team),
+		repos:     local.Repositories(),
+		githubids: make(map[string]bool),
+	}
+	for name, team := range local.Teams() {
+		e.teams[slug.Make(name)] = team
+	}
+	for _, user := range local.Users() {
+		e.githubids[user.Spec.GithubID] = true
+	}
+	return e
+}
+
+/*
+ * explainActions attaches to each planned action the reason it is planned
+ * (shown by `goliac plan --explain`)
+ */
+func (r *GoliacReconciliatorImpl) explainActions(local GoliacLocal) {
+	e := newExplainer(local)
+	for i := range r.plannedActions {
+		r.plannedActions[i].Reason = e.explain(r.plannedActions[i], r.archivedTeamPrefix())
+	}
+}
+
+// teamFile returns the file defining the team (like teams/platform/payments/team.yaml)
+func (e *explainer) teamFile(teamslug string) string {
+	team, ok := e.teams[teamslug]
+	if !ok {
+		return "the teams directory"
+	}
+	dirs := []string{"team.yaml"}
+	for ok {
+		dirs = append([]string{team.Name}, dirs...)
+		if team.ParentTeam == nil {
+			break
+		}
+		team, ok = e.teams[slug.Make(*team.ParentTeam)]
+	}
+	return path.Join(append([]string{"teams"}, dirs...)...)
+}
+
+// repositoryFile returns the file defining the repository (like teams/payments/billing.yaml)
+func (e *explainer) repositoryFile(reponame string) string {
+	repo, ok := e.repos[reponame]
+	if !ok {
+		return ""
+	}
+	if repo.Archived || repo.Owner == nil {
+		return path.Join("archived", reponame+".yaml")
+	}
+	if _, ok := e.teams[slug.Make(*repo.Owner)]; !ok {
+		return "the teams directory"
+	}
+	return path.Join(path.Dir(e.teamFile(slug.Make(*repo.Owner))), reponame+".yaml")
+}
+
+func (e *explainer) explain(action PlannedAction, archivedTeamPrefix string) string {
+	parts := strings.Split(action.Target, "/")
+	kind, name := parts[0], ""
+	if len(parts) > 1 {
+		name = parts[1]
+	}
+
+	switch action.Operation {
+	case "add_user_to_org":
+		return fmt.Sprintf("%s is defined in the users directory but is not a member of the organization", name)
+	case "remove_user_from_org":
+		return fmt.Sprintf("%s is a member of the organization but is not defined in the users directory", name)
+	case "create_team":
+		return fmt.Sprintf("the team %s is defined in %s but doesn't exist on Github", name, e.teamSource(name))
+	case "delete_team":
+		return fmt.Sprintf("the team %s exists on Github but is not defined in the teams directory", name)
+	case "update_team_rename":
+		if newname, ok := action.After.(string); ok && strings.HasPrefix(newname, archivedTeamPrefix) {
+			return fmt.Sprintf("the team %s exists on Github but is not defined in the teams directory (archive_team_by_renaming)", name)
+		}
+	case "update_team_add_member", "update_team_remove_member", "update_team_change_maintainer_to_member":
+		if len(parts) == 4 {
+			return e.explainTeamMember(action.Operation, name, parts[3])
+		}
+	case "update_team_remove_external_groups":
+		return fmt.Sprintf("the team %s is synchronized with IdP groups on Github but has no externalGroups in %s", name, e.teamSource(name))
+	case "update_team_parentteam":
+		return fmt.Sprintf("the parent team of %s on Github differs from %s", name, e.teamSource(name))
+	case "create_repository":
+		return fmt.Sprintf("the repository %s is defined in %s but doesn't exist on Github", name, e.repositoryFile(name))
+	case "transfer_repository":
+		return fmt.Sprintf("the repository %s is defined in %s to be transferred from another organization", name, e.repositoryFile(name))
+	case "delete_repository":
+		return fmt.Sprintf("the repository %s exists on Github but is not defined in the teams directory", name)
+	case "update_repository_update_bool_property":
+		if strings.HasSuffix(action.Target, "/archived") && action.After == true {
+			if _, ok := e.repos[name]; ok {
+				return fmt.Sprintf("the repository %s is defined in %s", name, e.repositoryFile(name))
+			}
+			return fmt.Sprintf("the repository %s exists on Github but is not defined in the teams directory (archive_on_delete)", name)
+		}
+	}
+
+	source := e.source(kind, name)
+	switch changeType(action.Operation) {
+	case "created":
+		return fmt.Sprintf("defined in %s but absent on Github", source)
+	case "deleted":
+		return fmt.Sprintf("present on Github but not defined in %s", source)
+	default:
+		return fmt.Sprintf("the Github value differs from %s", source)
+	}
+}
+
+func (e *explainer) explainTeamMember(operation string, teamslug string, githubid string) string {
+	if operation == "update_team_change_maintainer_to_member" {
+		return fmt.Sprintf("%s is a maintainer of %s on Github: Goliac only manages members", githubid, teamslug)
+	}
+	if teamslug == "everyone" {
+		if operation == "update_team_add_member" {
+			return fmt.Sprintf("%s is defined in the users directory (everyone_team_enabled)", githubid)
+		}
+		return fmt.Sprintf("%s is not defined in the users directory (everyone_team_enabled)", githubid)
+	}
+
+	role := "a member"
+	if strings.HasSuffix(teamslug, config.Config.GoliacTeamOwnerSuffix) {
+		role = "an owner"
+	}
+	file := e.teamSource(teamslug)
+	if operation == "update_team_add_member" {
+		return fmt.Sprintf("%s is %s in %s but is not a member of %s on Github", githubid, role, file, teamslug)
+	}
+	if !e.githubids[githubid] {
+		return fmt.Sprintf("%s is a member of %s on Github but is not defined in the users directory", githubid, teamslug)
+	}
+	return fmt.Sprintf("%s is a member of %s on Github but is not %s in %s", githubid, teamslug, role, file)
+}
+
+// teamSource returns the file defining the team (or its owners team)
+func (e *explainer) teamSource(teamslug string) string {
+	teamslug = slug.Make(strings.TrimSuffix(teamslug, config.Config.GoliacTeamOwnerSuffix))
+	if teamslug == "everyone" {
+		if _, ok := e.teams[teamslug]; !ok {
+			return "goliac.yaml (everyone_team_enabled)"
+		}
+	}
+	return e.teamFile(teamslug)
+}
+
+// source returns the file (or the directory) where the resource is defined
+func (e *explainer) source(kind string, name string) string {
+	switch kind {
+	case "user":
+		return "the users directory"
+	case "team":
+		return e.teamSource(name)
+	case "repository":
+		if file := e.repositoryFile(name); file != "" {
+			return file
+		}
+		return "the teams directory"
+	case "ruleset":
+		return "goliac.yaml and the rulesets directory"
+	case "variable":
+		return "org-variables.yaml"
+	case "secret":
+		return "org-secrets.yaml"
+	default:
+		return "goliac.yaml"
+	}
+}
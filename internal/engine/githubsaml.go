@@ -42,6 +42,7 @@ type GraplQLUsersFromGithubOrgSaml struct {
 	Data struct {
 		Organization struct {
 			SamlIdentityProvider struct {
+				SsoUrl             string `json:"ssoUrl"`
 				ExternalIdentities struct {
 					Edges []struct {
 						Node struct {
@@ -79,28 +80,55 @@ type GraplQLUsersFromGithubOrgSaml struct {
  * This function works only for Github organization that have the Entreprise plan ANAD use SAML integration
  */
 func LoadUsersFromGithubOrgSaml(ctx context.Context, client github.GitHubClient) (map[string]*entity.User, error) {
+	users, _, err := loadUsersFromGithubOrgSaml(ctx, client)
+	return users, err
+}
+
+/*
+ * LoadGithubSamlIdentities returns the Github logins that have a linked SAML identity
+ * (the value is the SAML nameId).
+ * It returns nil if the organization doesn't use a SAML IdP
+ */
+func LoadGithubSamlIdentities(ctx context.Context, client github.GitHubClient) (map[string]string, error) {
+	users, samlEnabled, err := loadUsersFromGithubOrgSaml(ctx, client)
+	if err != nil || !samlEnabled {
+		return nil, err
+	}
+	identities := make(map[string]string)
+	for nameId, user := range users {
+		identities[user.Spec.GithubID] = nameId
+	}
+	return identities, nil
+}
+
+func loadUsersFromGithubOrgSaml(ctx context.Context, client github.GitHubClient) (map[string]*entity.User, bool, error) {
 	users := make(map[string]*entity.User)
 
 	variables := make(map[string]interface{})
 	variables["orgLogin"] = config.Config.GithubAppOrganization
 	variables["endCursor"] = nil
 
+	samlEnabled := false
 	hasNextPage := true
 	count := 0
 	for hasNextPage {
 		data, err := client.QueryGraphQLAPI(ctx, listUsersFromGithubOrgSaml, variables)
 		if err != nil {
-			return users, err
+			return users, samlEnabled, err
 		}
 		var gResult GraplQLUsersFromGithubOrgSaml
 
 		// parse first page
 		err = json.Unmarshal(data, &gResult)
 		if err != nil {
-			return users, err
+			return users, samlEnabled, err
 		}
 		if len(gResult.Errors) > 0 {
-			return users, fmt.Errorf("graphql error: %v", gResult.Errors[0].Message)
+			return users, samlEnabled, fmt.Errorf("graphql error: %v", gResult.Errors[0].Message)
+		}
+
+		if gResult.Data.Organization.SamlIdentityProvider.SsoUrl != "" {
+			samlEnabled = true
 		}
 
 		for _, c := range gResult.Data.Organization.SamlIdentityProvider.ExternalIdentities.Edges {
@@ -131,5 +159,5 @@ func LoadUsersFromGithubOrgSaml(ctx context.Context, client github.GitHubClient)
 		}
 	}
 
-	return users, nil
+	return users, samlEnabled, nil
 }
@@ -5,10 +5,12 @@ import (
 	"regexp"
 	"testing"
 
+	"github.com/Alayacare/goliac/internal/github"
 	"github.com/stretchr/testify/assert"
 )
 
 type GithubSamlGitHubClient struct {
+	noSaml bool // the organization doesn't use a SAML IdP
 }
 
 func NewGithubSamlGitHubClient() *GithubSamlGitHubClient {
@@ -28,11 +30,15 @@ func (c *GithubSamlGitHubClient) QueryGraphQLAPI(ctx context.Context, query stri
 	// extract query name
 	queryName := extractQueryName(query)
 
+	if queryName == "listSamlUsers" && c.noSaml {
+		return []byte(`{"data": {"organization": {"samlIdentityProvider": null}}}`), nil
+	}
 	if queryName == "listSamlUsers" {
 		return []byte(`{
 			"data": {
 				"organization": {
 					"samlIdentityProvider": {
+						"ssoUrl": "https://idp.example.com/sso",
 						"externalIdentities": {
 							"edges": [
 								{
@@ -103,6 +109,9 @@ func (c *GithubSamlGitHubClient) GetAccessToken(context.Context) (string, error)
 func (c *GithubSamlGitHubClient) GetAppSlug() string {
 	return "foobar"
 }
+func (c *GithubSamlGitHubClient) GetRateLimits() map[string]github.RateLimit {
+	return map[string]github.RateLimit{}
+}
 
 func TestLoadUsersFromGithubOrgSaml(t *testing.T) {
 
@@ -115,3 +124,21 @@ func TestLoadUsersFromGithubOrgSaml(t *testing.T) {
 		assert.Equal(t, 4, len(users))
 	})
 }
+
+func TestLoadGithubSamlIdentities(t *testing.T) {
+
+	t.Run("happy path: load SAML identities", func(t *testing.T) {
+		client := NewGithubSamlGitHubClient()
+		identities, err := LoadGithubSamlIdentities(context.TODO(), client)
+		assert.Nil(t, err)
+		assert.Equal(t, 4, len(identities))
+		assert.Equal(t, "username1", identities["githubid1"])
+	})
+
+	t.Run("happy path: organization without SAML", func(t *testing.T) {
+		client := &GithubSamlGitHubClient{noSaml: true}
+		identities, err := LoadGithubSamlIdentities(context.TODO(), client)
+		assert.Nil(t, err)
+		assert.Nil(t, identities)
+	})
+}
@@ -3,11 +3,15 @@ package engine
 import (
 	"context"
 	"fmt"
+	"os"
+	"path"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/metrics"
 	"github.com/gosimple/slug"
 	"github.com/sirupsen/logrus"
 )
@@ -16,27 +20,60 @@ type key string
 
 const (
 	KeyAuthor key = "author"
+	// the *DestructiveChanges of the apply, shared by the reconciliations of its commits
+	KeyDestructiveChanges key = "destructive_changes"
+	// the slug of the app pushing the Goliac commits to the teams repository
+	KeyTeamsRepoApp key = "teams_repo_app"
 )
 
+/*
+ * DestructiveChanges counts the repositories and teams deleted (or archived)
+ * and the members removed by the previous commits of an apply: they count
+ * against max_destructive_changes with the changes of the current commit
+ */
+type DestructiveChanges struct {
+	Repositories int
+	Teams        int
+	Members      int
+}
+
 type UnmanagedResources struct {
 	Users                  map[string]bool
 	ExternallyManagedTeams map[string]bool
 	Teams                  map[string]bool
 	Repositories           map[string]bool
 	RuleSets               map[int]bool
+	OrgSecrets             map[string]bool
+	OrgVariables           map[string]bool
+	Webhooks               map[string]bool // <reponame>/webhook/<url>
+	TeamsExternalGroups    map[string]bool // teams still synchronized with IdP groups on Github
+	Labels                 map[string]bool // <reponame>/label/<labelname>
+	CustomProperties       map[string]bool // <reponame>/custom_property/<name>
 }
 
 /*
  * GoliacReconciliator is here to sync the local state to the remote state
  */
 type GoliacReconciliator interface {
-	Reconciliate(ctx context.Context, local GoliacLocal, remote GoliacRemote, teamreponame string, dryrun bool, reposToArchive map[string]*GithubRepoComparable) (*UnmanagedResources, error)
+	// if filter is not nil, only the matching entities are reconciliated (the other ones are ignored)
+	Reconciliate(ctx context.Context, local GoliacLocal, remote GoliacRemote, teamreponame string, dryrun bool, reposToArchive map[string]*GithubRepoComparable, filter EntityFilter) (*UnmanagedResources, error)
+
+	// list of the operations collected during the last reconciliation
+	PlannedActions() []PlannedAction
 }
 
+// name of the ruleset generated for the repositories requiring signed commits
+const REQUIRED_SIGNATURES_RULESET = "goliac-required-signatures"
+
+// name of the ruleset generated for the teams repository (with required_approvals)
+const TEAMS_REPO_APPROVALS_RULESET = "goliac-teams-repo-approvals"
+
 type GoliacReconciliatorImpl struct {
-	executor   ReconciliatorExecutor
-	repoconfig *config.RepositoryConfig
-	unmanaged  *UnmanagedResources
+	executor       ReconciliatorExecutor
+	repoconfig     *config.RepositoryConfig
+	unmanaged      *UnmanagedResources
+	filter         EntityFilter
+	plannedActions []PlannedAction
 }
 
 func NewGoliacReconciliatorImpl(executor ReconciliatorExecutor, repoconfig *config.RepositoryConfig) GoliacReconciliator {
@@ -47,7 +84,7 @@ func NewGoliacReconciliatorImpl(executor ReconciliatorExecutor, repoconfig *conf
 	}
 }
 
-func (r *GoliacReconciliatorImpl) Reconciliate(ctx context.Context, local GoliacLocal, remote GoliacRemote, teamsreponame string, dryrun bool, reposToArchive map[string]*GithubRepoComparable) (*UnmanagedResources, error) {
+func (r *GoliacReconciliatorImpl) Reconciliate(ctx context.Context, local GoliacLocal, remote GoliacRemote, teamsreponame string, dryrun bool, reposToArchive map[string]*GithubRepoComparable, filter EntityFilter) (*UnmanagedResources, error) {
 	rremote := NewMutableGoliacRemoteImpl(ctx, remote)
 	r.Begin(ctx, dryrun)
 	unmanaged := &UnmanagedResources{
@@ -56,8 +93,16 @@ func (r *GoliacReconciliatorImpl) Reconciliate(ctx context.Context, local Goliac
 		Teams:                  make(map[string]bool),
 		Repositories:           make(map[string]bool),
 		RuleSets:               make(map[int]bool),
+		OrgSecrets:             make(map[string]bool),
+		OrgVariables:           make(map[string]bool),
+		Webhooks:               make(map[string]bool),
+		TeamsExternalGroups:    make(map[string]bool),
+		Labels:                 make(map[string]bool),
+		CustomProperties:       make(map[string]bool),
 	}
 	r.unmanaged = unmanaged
+	r.filter = filter
+	r.plannedActions = []PlannedAction{}
 
 	err := r.reconciliateUsers(ctx, local, rremote, dryrun, unmanaged)
 	if err != nil {
@@ -65,27 +110,67 @@ func (r *GoliacReconciliatorImpl) Reconciliate(ctx context.Context, local Goliac
 		return nil, err
 	}
 
-	err = r.reconciliateTeams(ctx, local, rremote, dryrun)
+	err = r.reconciliateTeams(ctx, local, rremote, dryrun, remote.IsEnterprise())
 	if err != nil {
 		r.Rollback(ctx, dryrun, err)
 		return nil, err
 	}
 
-	err = r.reconciliateRepositories(ctx, local, rremote, teamsreponame, dryrun, reposToArchive)
+	// required signatures are enforced via rulesets when available, else via classic branch protection
+	err = r.reconciliateRepositories(ctx, local, rremote, teamsreponame, dryrun, reposToArchive, !remote.IsEnterprise())
 	if err != nil {
 		r.Rollback(ctx, dryrun, err)
 		return nil, err
 	}
 
 	if remote.IsEnterprise() {
-		err = r.reconciliateRulesets(ctx, local, rremote, r.repoconfig, dryrun)
+		err = r.reconciliateRulesets(ctx, local, rremote, teamsreponame, r.repoconfig, dryrun)
+		if err != nil {
+			r.Rollback(ctx, dryrun, err)
+			return nil, err
+		}
+	}
+
+	if r.repoconfig.ManageGithubVariables {
+		err = r.reconciliateOrgVariables(ctx, local, rremote, dryrun)
+		if err != nil {
+			r.Rollback(ctx, dryrun, err)
+			return nil, err
+		}
+	}
+
+	if r.repoconfig.ManageGithubSecrets {
+		err = r.reconciliateOrgSecrets(ctx, local, rremote, dryrun)
+		if err != nil {
+			r.Rollback(ctx, dryrun, err)
+			return nil, err
+		}
+	}
+
+	// the org settings are not entities: they are ignored by a filtered reconciliation
+	if filter == nil {
+		err = r.reconciliateOrgSettings(ctx, rremote, dryrun)
 		if err != nil {
 			r.Rollback(ctx, dryrun, err)
 			return nil, err
 		}
 	}
 
-	return r.unmanaged, r.Commit(ctx, dryrun)
+	r.explainActions(local)
+
+	// nothing is applied yet: the whole apply is aborted if it is too destructive
+	err = r.checkMaxDestructiveChanges(ctx, dryrun)
+	if err != nil {
+		r.Rollback(ctx, dryrun, err)
+		return nil, err
+	}
+
+	err = r.Commit(ctx, dryrun)
+	// in dryrun the remote is not changed: the plan of the next commit contains these changes again
+	if err == nil && !dryrun {
+		r.addDestructiveChanges(ctx)
+	}
+	return r.unmanaged, err
 }
 
 /*
@@ -96,10 +181,15 @@ func (r *GoliacReconciliatorImpl) reconciliateUsers(ctx context.Context, local G
 
 	rUsers := make(map[string]string)
 	for u := range ghUsers {
-		rUsers[u] = u
+		if r.filter.match("user", u) {
+			rUsers[u] = u
+		}
 	}
 
 	for _, lUser := range local.Users() {
+		if !r.filter.match("user", lUser.Spec.GithubID) {
+			continue
+		}
 		user, ok := rUsers[lUser.Spec.GithubID]
 
 		if !ok {
@@ -110,6 +200,15 @@ func (r *GoliacReconciliatorImpl) reconciliateUsers(ctx context.Context, local G
 		}
 	}
 
+	// exempt members are never removed from the org
+	for _, exempt := range r.repoconfig.ExemptMembers {
+		for rUser := range rUsers {
+			if strings.EqualFold(rUser, exempt) {
+				delete(rUsers, rUser)
+			}
+		}
+	}
+
 	// remaining (GH) users (aka not found locally)
 	for _, rUser := range rUsers {
 		// DELETE User
@@ -119,17 +218,20 @@ func (r *GoliacReconciliatorImpl) reconciliateUsers(ctx context.Context, local G
 }
 
 type GithubTeamComparable struct {
-	Name        string
-	Slug        string
-	Members     []string
-	Maintainers []string
-	ParentTeam  *string
+	Name                string
+	Slug                string
+	Members             []string
+	Maintainers         []string
+	ParentTeam          *string
+	ExternalGroups      []string // the IdP groups (team synchronization). nil if the members are managed by Goliac (local only), or not loaded (remote only)
+	Privacy             string   // secret or closed. Not managed if empty (local only)
+	NotificationSetting string   // notifications_enabled or notifications_disabled. Not managed if empty (local only)
 }
 
 /*
  * This function sync teams and team's members
  */
-func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, dryrun bool) error {
+func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, dryrun bool, isEnterprise bool) error {
 	ghTeams := remote.Teams()
 	rUsers := remote.Users()
 
@@ -154,11 +256,13 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 		}
 
 		team := &GithubTeamComparable{
-			Name:        v.Name,
-			Slug:        v.Slug,
-			Members:     members,
-			Maintainers: maintainers,
-			ParentTeam:  nil,
+			Name:                v.Name,
+			Slug:                v.Slug,
+			Members:             members,
+			Maintainers:         maintainers,
+			ParentTeam:          nil,
+			Privacy:             v.Privacy,
+			NotificationSetting: v.NotificationSetting,
 		}
 		if v.ParentTeam != nil {
 			if parent, ok := ghTeamsPerId[*v.ParentTeam]; ok {
@@ -174,6 +278,7 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 	slugTeams := make(map[string]*GithubTeamComparable)
 	lTeams := local.Teams()
 	lUsers := local.Users()
+	withExternalGroups := false
 
 	for teamname, teamvalue := range lTeams {
 		teamslug := slug.Make(teamname)
@@ -181,18 +286,18 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 		// if the team is externally managed, we don't want to touch it
 		// we just remove it from the list
 		if teamvalue.Spec.ExternallyManaged {
-			// let's add it to the special -goliac-owners
+			// but its -goliac-owners team is still reconciled from the team's owners
 			membersOwners := []string{}
-			membersMaintainers := []string{}
-			if rt, ok := rTeams[teamslug]; ok {
-				membersOwners = append(membersOwners, rt.Members...)
-				membersMaintainers = append(membersMaintainers, rt.Maintainers...)
+			for _, m := range teamvalue.Spec.Owners {
+				if u, ok := lUsers[m]; ok {
+					membersOwners = append(membersOwners, u.Spec.GithubID)
+				}
 			}
 			team := &GithubTeamComparable{
 				Name:        teamslug + config.Config.GoliacTeamOwnerSuffix,
 				Slug:        teamslug + config.Config.GoliacTeamOwnerSuffix,
 				Members:     membersOwners,
-				Maintainers: membersMaintainers,
+				Maintainers: []string{},
 			}
 			slugTeams[teamslug+config.Config.GoliacTeamOwnerSuffix] = team
 
@@ -217,9 +322,18 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 		}
 
 		team := &GithubTeamComparable{
-			Name:    teamname,
-			Slug:    teamslug,
-			Members: members,
+			Name:                teamname,
+			Slug:                teamslug,
+			Members:             members,
+			Privacy:             teamvalue.Spec.Privacy,
+			NotificationSetting: teamvalue.Spec.NotificationSetting,
+		}
+		// the members of a team synchronized with IdP groups are managed by Github
+		if len(teamvalue.Spec.ExternalGroups) > 0 {
+			team.Members = []string{}
+			team.ExternalGroups = append([]string{}, teamvalue.Spec.ExternalGroups...)
+			sort.Strings(team.ExternalGroups)
+			withExternalGroups = true
 		}
 		if teamvalue.ParentTeam != nil {
 			parentTeam := slug.Make(*teamvalue.ParentTeam)
@@ -237,6 +351,30 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 		slugTeams[teamslug+config.Config.GoliacTeamOwnerSuffix] = team
 	}
 
+	// the IdP groups are only loaded if needed (it costs one call per team):
+	// to synchronize a team, or to remove the groups of a team no longer synchronized
+	// (the team synchronization is only available on Enterprise)
+	if withExternalGroups || isEnterprise {
+		for teamslug, groups := range remote.TeamsExternalGroups() {
+			if rt, ok := rTeams[teamslug]; ok {
+				rt.ExternalGroups = groups
+			}
+		}
+	}
+
+	// on a SAML organization, Github refuses to add a user without a linked SAML identity to a team
+	if samlIdentities := remote.SamlIdentities(); samlIdentities != nil {
+		missing := teamMembersWithoutSamlIdentity(lTeams, lUsers, samlIdentities)
+		githubids := make([]string, 0, len(missing))
+		for githubid := range missing {
+			githubids = append(githubids, githubid)
+		}
+		sort.Strings(githubids)
+		for _, githubid := range githubids {
+			logrus.Warnf("user %s (member of %s) doesn't have a linked SAML identity: Github will not be able to add it to the team(s)", githubid, strings.Join(missing[githubid], ", "))
+		}
+	}
+
 	// adding the "everyone" team
 	if r.repoconfig.EveryoneTeamEnabled {
 		everyone := GithubTeamComparable{
@@ -253,17 +391,35 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 	// now we compare local (slugTeams) and remote (rTeams)
 
 	compareTeam := func(lTeam *GithubTeamComparable, rTeam *GithubTeamComparable) bool {
-		if res, _, _ := entity.StringArrayEquivalent(lTeam.Members, rTeam.Members); !res {
-			return false
-		}
-		if res, _, _ := entity.StringArrayEquivalent(lTeam.Maintainers, rTeam.Maintainers); !res {
-			return false
+		if lTeam.ExternalGroups != nil {
+			if rTeam.ExternalGroups != nil {
+				if res, _, _ := entity.StringArrayEquivalent(lTeam.ExternalGroups, rTeam.ExternalGroups); !res {
+					return false
+				}
+			}
+		} else {
+			// the team is not synchronized anymore with IdP groups
+			if len(rTeam.ExternalGroups) > 0 {
+				return false
+			}
+			if res, _, _ := entity.StringArrayEquivalent(lTeam.Members, rTeam.Members); !res {
+				return false
+			}
+			if res, _, _ := entity.StringArrayEquivalent(lTeam.Maintainers, rTeam.Maintainers); !res {
+				return false
+			}
 		}
 		if (lTeam.ParentTeam == nil && rTeam.ParentTeam != nil) ||
 			(lTeam.ParentTeam != nil && rTeam.ParentTeam == nil) ||
 			(lTeam.ParentTeam != nil && rTeam.ParentTeam != nil && *lTeam.ParentTeam != *rTeam.ParentTeam) {
 			return false
 		}
+		if lTeam.Privacy != "" && lTeam.Privacy != rTeam.Privacy {
+			return false
+		}
+		if lTeam.NotificationSetting != "" && lTeam.NotificationSetting != rTeam.NotificationSetting {
+			return false
+		}
 
 		return true
 	}
@@ -277,59 +433,44 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 			parentTeam = &ghTeams[*lTeam.ParentTeam].Id
 		}
 		r.CreateTeam(ctx, dryrun, remote, lTeam.Name, lTeam.Name, parentTeam, lTeam.Members)
+		if lTeam.ExternalGroups != nil {
+			r.UpdateTeamSetExternalGroups(ctx, dryrun, remote, key, lTeam.ExternalGroups)
+		}
+		// the team is created closed, with the notifications enabled
+		if lTeam.Privacy != "" && lTeam.Privacy != "closed" {
+			r.UpdateTeamUpdateProperty(ctx, dryrun, remote, key, "privacy", lTeam.Privacy)
+		}
+		if lTeam.NotificationSetting != "" && lTeam.NotificationSetting != "notifications_enabled" {
+			r.UpdateTeamUpdateProperty(ctx, dryrun, remote, key, "notification_setting", lTeam.NotificationSetting)
+		}
 	}
 
 	onRemoved := func(key string, lTeam *GithubTeamComparable, rTeam *GithubTeamComparable) {
+		if r.repoconfig.ArchiveTeamByRenaming {
+			// already archived
+			if strings.HasPrefix(rTeam.Name, r.archivedTeamPrefix()) {
+				return
+			}
+			r.ArchiveTeam(ctx, dryrun, remote, rTeam.Slug)
+			return
+		}
 		// DELETE team
 		r.DeleteTeam(ctx, dryrun, remote, rTeam.Slug)
 	}
 
 	onChanged := func(slugTeam string, lTeam *GithubTeamComparable, rTeam *GithubTeamComparable) {
-		// change membership from maintainers to members
-
-		rmaintainers := make([]string, len(rTeam.Maintainers))
-		copy(rmaintainers, rTeam.Maintainers)
-
-		for _, r_maintainer := range rmaintainers {
-			found := false
-			for _, l_maintainer := range lTeam.Maintainers {
-				if r_maintainer == l_maintainer {
-					found = true
-					break
-				}
-			}
-			if !found {
-				// let's downgrade the maintainer to member
-				r.UpdateTeamChangeMaintainerToMember(ctx, dryrun, remote, slugTeam, r_maintainer)
-				for i, m := range rTeam.Maintainers {
-					if m == r_maintainer {
-						rTeam.Maintainers = append(rTeam.Maintainers[:i], rTeam.Maintainers[i+1:]...)
-						break
-					}
-				}
-				rTeam.Members = append(rTeam.Members, r_maintainer)
-			}
-		}
-
-		// membership change
-		if res, _, _ := entity.StringArrayEquivalent(lTeam.Members, rTeam.Members); !res {
-			localMembers := make(map[string]bool)
-			for _, m := range lTeam.Members {
-				localMembers[m] = true
-			}
-
-			for _, m := range rTeam.Members {
-				if _, ok := localMembers[m]; !ok {
-					// REMOVE team member
-					r.UpdateTeamRemoveMember(ctx, dryrun, remote, slugTeam, m)
-				} else {
-					delete(localMembers, m)
+		if lTeam.ExternalGroups != nil {
+			// the members are synchronized (by Github) from the IdP groups
+			if rTeam.ExternalGroups != nil {
+				if res, _, _ := entity.StringArrayEquivalent(lTeam.ExternalGroups, rTeam.ExternalGroups); !res {
+					r.UpdateTeamSetExternalGroups(ctx, dryrun, remote, slugTeam, lTeam.ExternalGroups)
 				}
 			}
-			for m := range localMembers {
-				// ADD team member
-				r.UpdateTeamAddMember(ctx, dryrun, remote, slugTeam, m, "member")
+		} else {
+			if len(rTeam.ExternalGroups) > 0 {
+				r.RemoveTeamExternalGroups(ctx, dryrun, remote, slugTeam, rTeam.ExternalGroups)
 			}
+			r.reconciliateTeamMembers(ctx, dryrun, remote, slugTeam, lTeam, rTeam)
 		}
 
 		// parent team change
@@ -343,562 +484,2409 @@ func (r *GoliacReconciliatorImpl) reconciliateTeams(ctx context.Context, local G
 			}
 			r.UpdateTeamSetParent(ctx, dryrun, remote, slugTeam, parentTeam)
 		}
+
+		if lTeam.Privacy != "" && lTeam.Privacy != rTeam.Privacy {
+			r.UpdateTeamUpdateProperty(ctx, dryrun, remote, slugTeam, "privacy", lTeam.Privacy)
+		}
+		if lTeam.NotificationSetting != "" && lTeam.NotificationSetting != rTeam.NotificationSetting {
+			r.UpdateTeamUpdateProperty(ctx, dryrun, remote, slugTeam, "notification_setting", lTeam.NotificationSetting)
+		}
 	}
 
+	slugTeams = filterEntities(slugTeams, r.filter, "team")
+	rTeams = filterEntities(rTeams, r.filter, "team")
 	CompareEntities(slugTeams, rTeams, compareTeam, onAdded, onRemoved, onChanged)
 
 	return nil
 }
 
-type GithubRepoComparable struct {
-	BoolProperties      map[string]bool
-	Writers             []string
-	Readers             []string
-	ExternalUserReaders []string // githubids
-	ExternalUserWriters []string // githubids
-}
-
 /*
- * This function sync repositories and team's repositories permissions
- * It returns the list of deleted repos that must not be deleted but archived
+ * reconciliateTeamMembers reconciliates the members (and the maintainers) of
+ * a team managed by Goliac
  */
-func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, teamsreponame string, dryrun bool, toArchive map[string]*GithubRepoComparable) error {
-	ghRepos := remote.Repositories()
-	rRepos := make(map[string]*GithubRepoComparable)
-	for k, v := range ghRepos {
-		repo := &GithubRepoComparable{
-			BoolProperties:      map[string]bool{},
-			Writers:             []string{},
-			Readers:             []string{},
-			ExternalUserReaders: []string{},
-			ExternalUserWriters: []string{},
+func (r *GoliacReconciliatorImpl) reconciliateTeamMembers(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, slugTeam string, lTeam *GithubTeamComparable, rTeam *GithubTeamComparable) {
+	// change membership from maintainers to members
+
+	rmaintainers := make([]string, len(rTeam.Maintainers))
+	copy(rmaintainers, rTeam.Maintainers)
+
+	for _, r_maintainer := range rmaintainers {
+		found := false
+		for _, l_maintainer := range lTeam.Maintainers {
+			if r_maintainer == l_maintainer {
+				found = true
+				break
+			}
 		}
-		for pk, pv := range v.BoolProperties {
-			repo.BoolProperties[pk] = pv
+		if !found {
+			// let's downgrade the maintainer to member
+			r.UpdateTeamChangeMaintainerToMember(ctx, dryrun, remote, slugTeam, r_maintainer)
+			for i, m := range rTeam.Maintainers {
+				if m == r_maintainer {
+					rTeam.Maintainers = append(rTeam.Maintainers[:i], rTeam.Maintainers[i+1:]...)
+					break
+				}
+			}
+			rTeam.Members = append(rTeam.Members, r_maintainer)
 		}
+	}
 
-		for cGithubid, cPermission := range v.ExternalUsers {
-			if cPermission == "WRITE" {
-				repo.ExternalUserWriters = append(repo.ExternalUserWriters, cGithubid)
+	// membership change
+	if res, _, _ := entity.StringArrayEquivalent(lTeam.Members, rTeam.Members); !res {
+		localMembers := make(map[string]bool)
+		for _, m := range lTeam.Members {
+			localMembers[m] = true
+		}
+
+		for _, m := range rTeam.Members {
+			if _, ok := localMembers[m]; !ok {
+				// REMOVE team member
+				r.UpdateTeamRemoveMember(ctx, dryrun, remote, slugTeam, m)
 			} else {
-				repo.ExternalUserReaders = append(repo.ExternalUserReaders, cGithubid)
+				delete(localMembers, m)
 			}
 		}
+		for m := range localMembers {
+			// ADD team member
+			r.UpdateTeamAddMember(ctx, dryrun, remote, slugTeam, m, "member")
+		}
+	}
+}
 
-		rRepos[k] = repo
+type GithubRepoComparable struct {
+	BoolProperties             map[string]bool
+	StringProperties           map[string]string         // only the properties managed by Goliac (description, homepage)
+	RequireSignedCommits       *bool                     // only used with classic branch protection. nil if not managed by Goliac (local only)
+	TeamCustomRoles            map[string]string         // team slug -> custom repository role (these teams are neither readers nor writers)
+	DependabotAlerts           *bool                     // nil if not managed by Goliac (local only)
+	ActionsPermissions         *GithubActionsPermissions // nil if not managed by Goliac (local only), or not loaded (remote only)
+	CustomProperties           map[string]string         // nil if not managed by Goliac (local only), or not loaded (remote only)
+	Security                   map[string]bool           // secret_scanning, secret_scanning_push_protection, dependabot_security_updates. Only the managed ones (local), nil if not loaded (remote)
+	Webhooks                   map[string]*GithubWebhook // the key is the url. nil if not managed by Goliac (local only), or not loaded (remote only)
+	Labels                     map[string]*GithubLabel   // the key is the label name in lower case. nil if not managed by Goliac (local only), or not loaded (remote only)
+	LabelsManaged              bool                      // the labels not listed are removed (local only)
+	TemplateRepository         string                    // only used at creation (local only)
+	TemplateIncludeAllBranches bool                      // only used at creation (local only)
+	TransferFrom               string                    // only used at creation (local only)
+	Writers                    []string
+	Readers                    []string
+	ExternalUserReaders        []string // githubids
+	ExternalUserWriters        []string // githubids
+}
+
+/*
+ * codeownersTeamsWithoutAccess returns the teams (slugs) referenced in a CODEOWNERS
+ * file that have neither read nor write access to the repository
+ */
+func codeownersTeamsWithoutAccess(repo *GithubRepoComparable, codeownersTeams []string) []string {
+	access := make(map[string]bool)
+	for _, t := range repo.Readers {
+		access[t] = true
+	}
+	for _, t := range repo.Writers {
+		access[t] = true
+	}
+	for t := range repo.TeamCustomRoles {
+		access[t] = true
 	}
 
-	// on the remote object, I have teams->repos, and I need repos->teams
-	for t, repos := range remote.TeamRepositories() {
-		for r, p := range repos {
-			if rr, ok := rRepos[r]; ok {
-				if p.Permission == "ADMIN" || p.Permission == "WRITE" {
-					rr.Writers = append(rr.Writers, t)
-				} else {
-					rr.Readers = append(rr.Readers, t)
-				}
-			}
+	missing := make([]string, 0)
+	for _, t := range codeownersTeams {
+		if !access[t] {
+			missing = append(missing, t)
 		}
 	}
+	sort.Strings(missing)
+	return missing
+}
 
-	lRepos := make(map[string]*GithubRepoComparable)
-	for reponame, lRepo := range local.Repositories() {
-		writers := make([]string, 0)
-		for _, w := range lRepo.Spec.Writers {
-			writers = append(writers, slug.Make(w))
-		}
-		// add the team owner's name ;-)
-		if lRepo.Owner != nil {
-			writers = append(writers, slug.Make(*lRepo.Owner))
-		}
-		readers := make([]string, 0)
-		for _, r := range lRepo.Spec.Readers {
-			readers = append(readers, slug.Make(r))
-		}
+// in the order they must be applied (push protection requires secret scanning)
+var securityFeatures = []string{"secret_scanning", "secret_scanning_push_protection", "dependabot_security_updates"}
 
-		// special case for the Goliac "teams" repo
-		if reponame == teamsreponame {
-			for teamname := range local.Teams() {
-				writers = append(writers, slug.Make(teamname)+config.Config.GoliacTeamOwnerSuffix)
-			}
-		}
+func localSecurity(security *entity.RepositorySecurity) map[string]bool {
+	features := map[string]bool{}
+	if security == nil {
+		return features
+	}
+	if security.SecretScanning != nil {
+		features["secret_scanning"] = *security.SecretScanning
+	}
+	if security.SecretScanningPushProtection != nil {
+		features["secret_scanning_push_protection"] = *security.SecretScanningPushProtection
+	}
+	if security.DependabotSecurityUpdates != nil {
+		features["dependabot_security_updates"] = *security.DependabotSecurityUpdates
+	}
+	return features
+}
 
-		// adding the "everyone" team to each repository
-		if r.repoconfig.EveryoneTeamEnabled {
-			readers = append(readers, "everyone")
+/*
+ * securityToUpdate returns the security features that must be toggled (in order)
+ */
+func securityToUpdate(lSecurity map[string]bool, rSecurity map[string]bool) []string {
+	toUpdate := []string{}
+	// not loaded from Github (we don't know if they differ)
+	if rSecurity == nil {
+		return toUpdate
+	}
+	for _, feature := range securityFeatures {
+		lv, ok := lSecurity[feature]
+		if !ok {
+			continue
 		}
-
-		// adding exernal reader/writer
-		eReaders := make([]string, 0)
-		for _, r := range lRepo.Spec.ExternalUserReaders {
-			if user, ok := local.ExternalUsers()[r]; ok {
-				eReaders = append(eReaders, user.Spec.GithubID)
-			}
+		if rv, ok := rSecurity[feature]; !ok || rv != lv {
+			toUpdate = append(toUpdate, feature)
 		}
+	}
+	return toUpdate
+}
 
-		eWriters := make([]string, 0)
-		for _, w := range lRepo.Spec.ExternalUserWriters {
-			if user, ok := local.ExternalUsers()[w]; ok {
-				eWriters = append(eWriters, user.Spec.GithubID)
+/*
+ * localWebhooks returns the webhooks of a repository (with the Github default values)
+ * indexed by url. nil if they are not managed by Goliac
+ */
+func localWebhooks(webhooks []entity.RepositoryWebhook) map[string]*GithubWebhook {
+	if webhooks == nil {
+		return nil
+	}
+	lWebhooks := make(map[string]*GithubWebhook)
+	for _, w := range webhooks {
+		webhook := &GithubWebhook{
+			URL:         w.URL,
+			Events:      w.Events,
+			ContentType: w.ContentType,
+			Active:      w.Active == nil || *w.Active,
+		}
+		if w.SecretEnv != "" {
+			// the secret is never stored in the repository file
+			webhook.Secret = os.Getenv(w.SecretEnv)
+			if webhook.Secret == "" {
+				logrus.Warnf("the secret of the webhook %s is not set: the environment variable %s is empty", w.URL, w.SecretEnv)
 			}
 		}
-
-		lRepos[slug.Make(reponame)] = &GithubRepoComparable{
-			BoolProperties: map[string]bool{
-				"private":                !lRepo.Spec.IsPublic,
-				"archived":               lRepo.Archived,
-				"allow_auto_merge":       lRepo.Spec.AllowAutoMerge,
-				"delete_branch_on_merge": lRepo.Spec.DeleteBranchOnMerge,
-				"allow_update_branch":    lRepo.Spec.AllowUpdateBranch,
-			},
-			Readers:             readers,
-			Writers:             writers,
-			ExternalUserReaders: eReaders,
-			ExternalUserWriters: eWriters,
+		if len(webhook.Events) == 0 {
+			webhook.Events = []string{"push"}
+		}
+		if webhook.ContentType == "" {
+			webhook.ContentType = "form"
 		}
+		lWebhooks[w.URL] = webhook
 	}
+	return lWebhooks
+}
 
-	// now we compare local (slugTeams) and remote (rTeams)
-
-	compareRepos := func(lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) bool {
-		for lk, lv := range lRepo.BoolProperties {
-			if rv, ok := rRepo.BoolProperties[lk]; !ok || rv != lv {
-				return false
-			}
+/*
+ * webhooksToUpdate returns the webhooks (sorted by url) to add, to update
+ * (with the remote Id) and to remove. Nothing if the webhooks are not managed
+ * by Goliac, or not loaded
+ */
+func webhooksToUpdate(lWebhooks map[string]*GithubWebhook, rWebhooks map[string]*GithubWebhook) ([]*GithubWebhook, []*GithubWebhook, []*GithubWebhook) {
+	toAdd := []*GithubWebhook{}
+	toUpdate := []*GithubWebhook{}
+	toRemove := []*GithubWebhook{}
+	if lWebhooks == nil || rWebhooks == nil {
+		return toAdd, toUpdate, toRemove
+	}
+
+	urls := make([]string, 0, len(lWebhooks))
+	for url := range lWebhooks {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		lw := lWebhooks[url]
+		rw, ok := rWebhooks[url]
+		if !ok {
+			toAdd = append(toAdd, lw)
+			continue
 		}
-
-		if res, _, _ := entity.StringArrayEquivalent(lRepo.Readers, rRepo.Readers); !res {
-			return false
+		if res, _, _ := entity.StringArrayEquivalent(lw.Events, rw.Events); !res || lw.ContentType != rw.ContentType || lw.Active != rw.Active {
+			w := *lw
+			w.Id = rw.Id
+			toUpdate = append(toUpdate, &w)
 		}
+	}
 
-		if res, _, _ := entity.StringArrayEquivalent(lRepo.Writers, rRepo.Writers); !res {
-			return false
+	urls = make([]string, 0, len(rWebhooks))
+	for url := range rWebhooks {
+		if _, ok := lWebhooks[url]; !ok {
+			urls = append(urls, url)
 		}
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		toRemove = append(toRemove, rWebhooks[url])
+	}
+	return toAdd, toUpdate, toRemove
+}
 
-		if res, _, _ := entity.StringArrayEquivalent(lRepo.ExternalUserReaders, rRepo.ExternalUserReaders); !res {
-			return false
+/*
+ * localMergeMethods returns the merge methods managed for a repository: the
+ * ones set in the repository file, and the ones disabled in goliac.yaml (the
+ * organization policy is enforced, even if the repository doesn't set them)
+ */
+func localMergeMethods(repoconfig *config.RepositoryConfig, lRepo *entity.Repository) map[string]bool {
+	methods := make(map[string]bool)
+	set := func(name string, org *bool, local *bool) {
+		if local != nil {
+			methods[name] = *local
 		}
-
-		if res, _, _ := entity.StringArrayEquivalent(lRepo.ExternalUserWriters, rRepo.ExternalUserWriters); !res {
-			return false
+		if org != nil && !*org {
+			methods[name] = false
 		}
+	}
+	set("allow_merge_commit", repoconfig.MergeMethods.AllowMergeCommit, lRepo.Spec.AllowMergeCommit)
+	set("allow_squash_merge", repoconfig.MergeMethods.AllowSquashMerge, lRepo.Spec.AllowSquashMerge)
+	set("allow_rebase_merge", repoconfig.MergeMethods.AllowRebaseMerge, lRepo.Spec.AllowRebaseMerge)
+	return methods
+}
 
-		return true
+/*
+ * templateRepositoryAvailable checks that the template_repository (<owner>/<name>)
+ * of a new repository is a template. Only the repositories of the organization
+ * are known: a template owned by another organization is not checked.
+ */
+func templateRepositoryAvailable(remote *MutableGoliacRemoteImpl, templateRepository string) error {
+	owner, name, found := strings.Cut(templateRepository, "/")
+	if !found || owner != config.Config.GithubAppOrganization {
+		return nil
+	}
+	rRepo, ok := remote.Repositories()[name]
+	if !ok {
+		return fmt.Errorf("the template repository %s doesn't exist", templateRepository)
 	}
+	if !rRepo.BoolProperties["is_template"] {
+		return fmt.Errorf("the repository %s is not a template repository", templateRepository)
+	}
+	return nil
+}
 
-	onChanged := func(reponame string, lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) {
-		// reconciliate repositories boolean properties
-		for lk, lv := range lRepo.BoolProperties {
-			if rv, ok := rRepo.BoolProperties[lk]; !ok || rv != lv {
-				r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, lk, lv)
-			}
+/*
+ * requiredChecksRepositories returns the repositories (slug) on which an active
+ * ruleset of goliac.yaml requires status checks
+ */
+func requiredChecksRepositories(repoconfig *config.RepositoryConfig, local GoliacLocal) map[string]bool {
+	repos := map[string]bool{}
+	for _, confrs := range repoconfig.Rulesets {
+		rs, ok := local.RuleSets()[confrs.Ruleset]
+		if !ok || rs.Spec.Enforcement != "active" {
+			continue
 		}
-
-		if res, readToRemove, readToAdd := entity.StringArrayEquivalent(lRepo.Readers, rRepo.Readers); !res {
-			for _, teamSlug := range readToAdd {
-				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "pull")
-			}
-			for _, teamSlug := range readToRemove {
-				r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+		requireChecks := false
+		for _, rule := range rs.Spec.Rules {
+			if rule.Ruletype == "required_status_checks" && len(rule.Parameters.RequiredStatusChecks) > 0 {
+				requireChecks = true
 			}
 		}
-
-		if res, writeToRemove, writeToAdd := entity.StringArrayEquivalent(lRepo.Writers, rRepo.Writers); !res {
-			for _, teamSlug := range writeToAdd {
-				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "push")
-			}
-			for _, teamSlug := range writeToRemove {
-				r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+		if !requireChecks {
+			continue
+		}
+		// an invalid pattern is reported by reconciliateRulesets
+		match, err := regexp.Compile(confrs.Pattern)
+		if err != nil {
+			continue
+		}
+		for reponame := range local.Repositories() {
+			name := slug.Make(reponame)
+			if len(rs.Spec.Repositories.Include) > 0 {
+				if repositoryNameMatch(rs.Spec.Repositories.Include, name) && !repositoryNameMatch(rs.Spec.Repositories.Exclude, name) {
+					repos[name] = true
+				}
+			} else if match.Match([]byte(name)) {
+				repos[name] = true
 			}
 		}
+	}
+	return repos
+}
 
-		resEreader, ereaderToRemove, ereaderToAdd := entity.StringArrayEquivalent(lRepo.ExternalUserReaders, rRepo.ExternalUserReaders)
-		resEWriter, ewriteToRemove, ewriteToAdd := entity.StringArrayEquivalent(lRepo.ExternalUserWriters, rRepo.ExternalUserWriters)
+// repositoryNameMatch matches a repository name against ruleset patterns (~ALL or fnmatch like patterns)
+func repositoryNameMatch(patterns []string, reponame string) bool {
+	for _, pattern := range patterns {
+		if pattern == "~ALL" {
+			return true
+		}
+		if ok, _ := path.Match(pattern, reponame); ok {
+			return true
+		}
+	}
+	return false
+}
 
-		if !resEreader {
-			for _, eReader := range ereaderToRemove {
-				// check if it is added in the writers
-				found := false
-				for _, eWriter := range ewriteToAdd {
-					if eWriter == eReader {
-						found = true
-						break
-					}
-				}
-				if !found {
-					r.UpdateRepositoryRemoveExternalUser(ctx, dryrun, remote, reponame, eReader)
-				}
+/*
+ * localRequireSignedCommits expands the branch protection template referenced
+ * by a repository: the value set in the repository file overrides the template one.
+ * nil if neither sets it (not managed by Goliac)
+ */
+func localRequireSignedCommits(repoconfig *config.RepositoryConfig, lRepo *entity.Repository) *bool {
+	if lRepo.Spec.RequireSignedCommits != nil {
+		return lRepo.Spec.RequireSignedCommits
+	}
+	if template, ok := repoconfig.BranchProtectionTemplates[lRepo.Spec.BranchProtectionTemplate]; ok && template.RequireSignedCommits != nil {
+		return template.RequireSignedCommits
+	}
+	return nil
+}
+
+/*
+ * localCustomRoles resolves the custom repository roles granted to the teams
+ * of a repository (team slug -> role), and removes these teams from the
+ * readers and writers.
+ * If a role doesn't exist in the organization, the team falls back to its
+ * base permission (as a writer or a reader, pull if it is neither), unless
+ * custom_roles_fallback is "error"
+ */
+func (r *GoliacReconciliatorImpl) localCustomRoles(reponame string, customRoles map[string]string, readers []string, writers []string, rCustomRoles map[string]string) (map[string]string, []string, []string, error) {
+	roles := make(map[string]string)
+	if len(customRoles) == 0 {
+		return roles, readers, writers, nil
+	}
+
+	access := make(map[string]string)
+	for _, t := range readers {
+		access[t] = "pull"
+	}
+	for _, t := range writers {
+		access[t] = "push"
+	}
+
+	teamnames := make([]string, 0, len(customRoles))
+	for teamname := range customRoles {
+		teamnames = append(teamnames, teamname)
+	}
+	sort.Strings(teamnames)
+
+	for _, teamname := range teamnames {
+		role := customRoles[teamname]
+		teamslug := slug.Make(teamname)
+		if _, ok := rCustomRoles[role]; ok {
+			roles[teamslug] = role
+			continue
+		}
+		if r.repoconfig.CustomRolesFallback == "error" {
+			return nil, nil, nil, fmt.Errorf("the custom repository role %s granted to the team %s on the repository %s doesn't exist", role, teamname, reponame)
+		}
+		permission, ok := access[teamslug]
+		if !ok {
+			permission = "pull"
+			readers = append(readers, teamslug)
+		}
+		logrus.Warnf("the custom repository role %s doesn't exist: the team %s gets the %s base permission on the repository %s", role, teamname, permission, reponame)
+	}
+
+	filter := func(teams []string) []string {
+		filtered := make([]string, 0, len(teams))
+		for _, t := range teams {
+			if _, ok := roles[t]; !ok {
+				filtered = append(filtered, t)
 			}
-			for _, eReader := range ereaderToAdd {
-				r.UpdateRepositorySetExternalUser(ctx, dryrun, remote, reponame, eReader, "pull")
+		}
+		return filtered
+	}
+	return roles, filter(readers), filter(writers), nil
+}
+
+/*
+ * sortedTeams returns the teams (keys) of a team -> custom role map, sorted
+ */
+func sortedTeams(roles map[string]string) []string {
+	teams := make([]string, 0, len(roles))
+	for t := range roles {
+		teams = append(teams, t)
+	}
+	sort.Strings(teams)
+	return teams
+}
+
+/*
+ * localLabels returns the labels of a repository indexed by their name
+ * in lower case. nil if they are not managed by Goliac
+ */
+func localLabels(labels []entity.RepositoryLabel) map[string]*GithubLabel {
+	if labels == nil {
+		return nil
+	}
+	lLabels := make(map[string]*GithubLabel)
+	for _, l := range labels {
+		lLabels[strings.ToLower(l.Name)] = &GithubLabel{
+			Name:        l.Name,
+			Color:       strings.ToLower(l.Color),
+			Description: l.Description,
+		}
+	}
+	return lLabels
+}
+
+/*
+ * labelsToUpdate returns the labels (sorted by name) to create, to update and
+ * to delete (only if managed is set). Nothing if the labels are not managed by
+ * Goliac, or not loaded
+ */
+func labelsToUpdate(lLabels map[string]*GithubLabel, rLabels map[string]*GithubLabel, managed bool) ([]*GithubLabel, []*GithubLabel, []*GithubLabel) {
+	toCreate := []*GithubLabel{}
+	toUpdate := []*GithubLabel{}
+	toDelete := []*GithubLabel{}
+	if lLabels == nil || rLabels == nil {
+		return toCreate, toUpdate, toDelete
+	}
+
+	names := make([]string, 0, len(lLabels))
+	for name := range lLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ll := lLabels[name]
+		rl, ok := rLabels[name]
+		if !ok {
+			toCreate = append(toCreate, ll)
+			continue
+		}
+		if ll.Name != rl.Name || ll.Color != strings.ToLower(rl.Color) ||
+			(ll.Description != nil && (rl.Description == nil || *ll.Description != *rl.Description)) {
+			toUpdate = append(toUpdate, ll)
+		}
+	}
+
+	if managed {
+		names = make([]string, 0, len(rLabels))
+		for name := range rLabels {
+			if _, ok := lLabels[name]; !ok {
+				names = append(names, name)
 			}
 		}
+		sort.Strings(names)
+		for _, name := range names {
+			toDelete = append(toDelete, rLabels[name])
+		}
+	}
+	return toCreate, toUpdate, toDelete
+}
 
-		if !resEWriter {
-			for _, eWriter := range ewriteToRemove {
-				// check if it is added in the writers
-				found := false
-				for _, eReader := range ereaderToAdd {
-					if eReader == eWriter {
-						found = true
-						break
-					}
-				}
-				if !found {
-					r.UpdateRepositoryRemoveExternalUser(ctx, dryrun, remote, reponame, eWriter)
-				}
+/*
+ * customPropertiesToUpdate returns the custom properties that must be sent to Github
+ * (an empty value unsets the property). If manageAll is set, the remote properties
+ * that are not defined locally are unset
+ */
+func customPropertiesToUpdate(lProperties map[string]string, rProperties map[string]string, manageAll bool) map[string]string {
+	toUpdate := make(map[string]string)
+	if rProperties == nil {
+		// not loaded
+		return toUpdate
+	}
+	for lk, lv := range lProperties {
+		if rv, ok := rProperties[lk]; (!ok && lv != "") || (ok && rv != lv) {
+			toUpdate[lk] = lv
+		}
+	}
+	if manageAll {
+		for _, rk := range undeclaredCustomProperties(lProperties, rProperties) {
+			toUpdate[rk] = ""
+		}
+	}
+	return toUpdate
+}
+
+/*
+ * undeclaredCustomProperties returns the (sorted) remote custom properties that
+ * are not defined locally
+ */
+func undeclaredCustomProperties(lProperties map[string]string, rProperties map[string]string) []string {
+	undeclared := []string{}
+	for rk := range rProperties {
+		if _, ok := lProperties[rk]; !ok {
+			undeclared = append(undeclared, rk)
+		}
+	}
+	sort.Strings(undeclared)
+	return undeclared
+}
+
+/*
+ * definedCustomProperties returns the custom properties of a repository that are
+ * defined at the organization level. The other ones are ignored (with a warning),
+ * Github would reject the whole update else.
+ * If the definitions are not known (nil), all the properties are returned
+ */
+func definedCustomProperties(reponame string, properties map[string]string, definitions map[string]bool) map[string]string {
+	if properties == nil || definitions == nil {
+		return properties
+	}
+	defined := make(map[string]string)
+	for k, v := range properties {
+		if !definitions[k] {
+			logrus.Warnf("repository %s: custom property %s is not defined at the organization level, ignoring it", reponame, k)
+			continue
+		}
+		defined[k] = v
+	}
+	return defined
+}
+
+/*
+ * teamMembersWithoutSamlIdentity returns the members (githubids) of the (non externally managed)
+ * teams that don't have a linked SAML identity, with the teams they belong to (sorted)
+ */
+func teamMembersWithoutSamlIdentity(lTeams map[string]*entity.Team, lUsers map[string]*entity.User, samlIdentities map[string]string) map[string][]string {
+	missing := make(map[string][]string)
+	for teamname, team := range lTeams {
+		if team.Spec.ExternallyManaged {
+			continue
+		}
+		members := append(append([]string{}, team.Spec.Owners...), team.Spec.Members...)
+		for _, m := range members {
+			u, ok := lUsers[m]
+			if !ok {
+				continue
 			}
-			for _, eWriter := range ewriteToAdd {
-				r.UpdateRepositorySetExternalUser(ctx, dryrun, remote, reponame, eWriter, "push")
+			if _, linked := samlIdentities[u.Spec.GithubID]; !linked {
+				missing[u.Spec.GithubID] = append(missing[u.Spec.GithubID], teamname)
 			}
 		}
+	}
+	for githubid := range missing {
+		sort.Strings(missing[githubid])
+	}
+	return missing
+}
 
+/*
+ * This function sync repositories and team's repositories permissions
+ * It returns the list of deleted repos that must not be deleted but archived
+ */
+func (r *GoliacReconciliatorImpl) reconciliateRepositories(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, teamsreponame string, dryrun bool, toArchive map[string]*GithubRepoComparable, classicSignatures bool) error {
+	// actions permissions cost one Github call per repository
+	// so we only load them if at least one repository manages them
+	var rActionsPermissions map[string]*GithubActionsPermissions
+	for _, lRepo := range local.Repositories() {
+		if lRepo.Spec.ActionsPermissions != nil {
+			rActionsPermissions = remote.RepositoriesActionsPermissions()
+			break
+		}
+	}
+	// same for the security settings
+	var rSecurity map[string]*GithubRepositorySecurity
+	for _, lRepo := range local.Repositories() {
+		if len(localSecurity(lRepo.Spec.Security)) > 0 {
+			rSecurity = remote.RepositoriesSecurity()
+			break
+		}
+	}
+	// same for the webhooks
+	var rWebhooks map[string]map[string]*GithubWebhook
+	for _, lRepo := range local.Repositories() {
+		if lRepo.Spec.Webhooks != nil {
+			rWebhooks = remote.RepositoriesWebhooks()
+			break
+		}
+	}
+	// same for the labels
+	var rLabels map[string]map[string]*GithubLabel
+	for _, lRepo := range local.Repositories() {
+		if lRepo.Spec.Labels != nil {
+			rLabels = remote.RepositoriesLabels()
+			break
+		}
+	}
+	// same for the custom repository roles (defined at the organization level)
+	var rCustomRoles map[string]string
+	for _, lRepo := range local.Repositories() {
+		if len(lRepo.Spec.CustomRoles) > 0 {
+			rCustomRoles = remote.CustomRepositoryRoles()
+			break
+		}
+	}
+	// same for the custom properties
+	manageCustomProperties := r.repoconfig.ManageGithubRepositoryCustomProperties
+	for _, lRepo := range local.Repositories() {
+		if lRepo.Spec.CustomProperties != nil {
+			manageCustomProperties = true
+			break
+		}
+	}
+	var customPropertiesDefinitions map[string]bool
+	if manageCustomProperties {
+		remote.LoadRepositoriesCustomProperties()
+		customPropertiesDefinitions = remote.CustomPropertiesDefinitions()
 	}
 
-	onAdded := func(reponame string, lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) {
-		// CREATE repository
+	ghRepos := remote.Repositories()
+	rRepos := make(map[string]*GithubRepoComparable)
+	for k, v := range ghRepos {
+		repo := &GithubRepoComparable{
+			BoolProperties:      map[string]bool{},
+			StringProperties:    map[string]string{},
+			Writers:             []string{},
+			Readers:             []string{},
+			ExternalUserReaders: []string{},
+			ExternalUserWriters: []string{},
+			TeamCustomRoles:     map[string]string{},
+		}
+		if classicSignatures {
+			requireSignedCommits := v.RequireSignedCommits
+			repo.RequireSignedCommits = &requireSignedCommits
+		}
+		dependabotAlerts := v.DependabotAlerts
+		repo.DependabotAlerts = &dependabotAlerts
+		if p, ok := rActionsPermissions[k]; ok {
+			actionsPermissions := *p
+			repo.ActionsPermissions = &actionsPermissions
+		}
+		if s, ok := rSecurity[k]; ok {
+			repo.Security = s.Features()
+		}
+		if l, ok := rLabels[k]; ok {
+			repo.Labels = make(map[string]*GithubLabel)
+			for name, label := range l {
+				repo.Labels[name] = label
+			}
+		}
+		if w, ok := rWebhooks[k]; ok {
+			repo.Webhooks = make(map[string]*GithubWebhook)
+			for url, webhook := range w {
+				repo.Webhooks[url] = webhook
+			}
+		}
+		if v.CustomProperties != nil {
+			repo.CustomProperties = make(map[string]string)
+			for pk, pv := range v.CustomProperties {
+				repo.CustomProperties[pk] = pv
+			}
+		}
+		for pk, pv := range v.BoolProperties {
+			repo.BoolProperties[pk] = pv
+		}
+		for pk, pv := range v.StringProperties {
+			repo.StringProperties[pk] = pv
+		}
 
-		// if the repo was just archived in a previous commit and we "resume it"
-		if aRepo, ok := toArchive[reponame]; ok {
-			delete(toArchive, reponame)
-			r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, "archived", false)
-			// calling onChanged to update the repository permissions
-			onChanged(reponame, aRepo, rRepo)
-		} else {
-			r.CreateRepository(ctx, dryrun, remote, reponame, reponame, lRepo.Writers, lRepo.Readers, lRepo.BoolProperties)
+		for cGithubid, cPermission := range v.ExternalUsers {
+			if cPermission == "WRITE" {
+				repo.ExternalUserWriters = append(repo.ExternalUserWriters, cGithubid)
+			} else {
+				repo.ExternalUserReaders = append(repo.ExternalUserReaders, cGithubid)
+			}
 		}
+
+		rRepos[k] = repo
 	}
 
-	onRemoved := func(reponame string, lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) {
-		// here we have a repository that is not listed in the teams repository.
-		// we should call DeleteRepository (that will delete if AllowDestructiveRepositories is on).
-		// but if we have ArchiveOnDelete...
-		if r.repoconfig.ArchiveOnDelete {
-			if r.repoconfig.DestructiveOperations.AllowDestructiveRepositories {
-				r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, "archived", true)
-				toArchive[reponame] = rRepo
-			} else {
-				r.unmanaged.Repositories[reponame] = true
+	// on the remote object, I have teams->repos, and I need repos->teams
+	for t, repos := range remote.TeamRepositories() {
+		for r, p := range repos {
+			if rr, ok := rRepos[r]; ok {
+				if p.RoleName != "" {
+					rr.TeamCustomRoles[t] = p.RoleName
+				} else if p.Permission == "ADMIN" || p.Permission == "WRITE" {
+					rr.Writers = append(rr.Writers, t)
+				} else {
+					rr.Readers = append(rr.Readers, t)
+				}
 			}
-		} else {
-			r.DeleteRepository(ctx, dryrun, remote, reponame)
 		}
 	}
 
-	CompareEntities(lRepos, rRepos, compareRepos, onAdded, onRemoved, onChanged)
-
-	return nil
+	// the status checks required by the rulesets (only enforced when the rulesets are available)
+	checkedRepos := map[string]bool{}
+	if !classicSignatures {
+		checkedRepos = requiredChecksRepositories(r.repoconfig, local)
+	}
+
+	lRepos := make(map[string]*GithubRepoComparable)
+	for reponame, lRepo := range local.Repositories() {
+		// already checked when loading the teams repository
+		if err := lRepo.ValidateVisibility(r.repoconfig); err != nil {
+			return err
+		}
+		writers := make([]string, 0)
+		for _, w := range lRepo.Spec.Writers {
+			writers = append(writers, slug.Make(w))
+		}
+		// add the team owner's name ;-)
+		if lRepo.Owner != nil {
+			writers = append(writers, slug.Make(*lRepo.Owner))
+		}
+		readers := make([]string, 0)
+		for _, r := range lRepo.Spec.Readers {
+			readers = append(readers, slug.Make(r))
+		}
+
+		// special case for the Goliac "teams" repo
+		if reponame == teamsreponame {
+			for teamname := range local.Teams() {
+				writers = append(writers, slug.Make(teamname)+config.Config.GoliacTeamOwnerSuffix)
+			}
+		}
+
+		// adding the "everyone" team to each repository
+		if r.repoconfig.EveryoneTeamEnabled {
+			readers = append(readers, "everyone")
+		}
+
+		// adding exernal reader/writer
+		eReaders := make([]string, 0)
+		for _, r := range lRepo.Spec.ExternalUserReaders {
+			if user, ok := local.ExternalUsers()[r]; ok {
+				eReaders = append(eReaders, user.Spec.GithubID)
+			}
+		}
+
+		eWriters := make([]string, 0)
+		for _, w := range lRepo.Spec.ExternalUserWriters {
+			if user, ok := local.ExternalUsers()[w]; ok {
+				eWriters = append(eWriters, user.Spec.GithubID)
+			}
+		}
+
+		// description and homepage are only managed if they are explicitly set
+		stringProperties := map[string]string{}
+		if lRepo.Spec.Description != nil {
+			stringProperties["description"] = *lRepo.Spec.Description
+		}
+		if lRepo.Spec.Homepage != nil {
+			stringProperties["homepage"] = *lRepo.Spec.Homepage
+		}
+
+		// security.vulnerability_alerts is the same as dependabot_alerts
+		dependabotAlerts := lRepo.Spec.DependabotAlerts
+		if dependabotAlerts == nil && lRepo.Spec.Security != nil {
+			dependabotAlerts = lRepo.Spec.Security.VulnerabilityAlerts
+		}
+
+		var actionsPermissions *GithubActionsPermissions
+		if lRepo.Spec.ActionsPermissions != nil {
+			actionsPermissions = &GithubActionsPermissions{
+				Enabled: lRepo.Spec.ActionsPermissions.Enabled,
+			}
+			if lRepo.Spec.ActionsPermissions.Enabled {
+				actionsPermissions.AllowedActions = lRepo.Spec.ActionsPermissions.AllowedActions
+			}
+		}
+
+		boolProperties := map[string]bool{
+			"private":                !lRepo.Spec.IsPublic,
+			"archived":               lRepo.Archived,
+			"allow_auto_merge":       lRepo.Spec.AllowAutoMerge,
+			"delete_branch_on_merge": lRepo.Spec.DeleteBranchOnMerge,
+			"allow_update_branch":    lRepo.Spec.AllowUpdateBranch,
+		}
+		for name, allowed := range localMergeMethods(r.repoconfig, lRepo) {
+			boolProperties[name] = allowed
+		}
+		if lRepo.Spec.AllowAutoMerge && !config.Config.ForceAutoMerge {
+			// without required checks, auto-merge merges a pull request as soon as it is approved
+			rRepo, exist := ghRepos[reponame]
+			alreadyEnabled := exist && rRepo.BoolProperties["allow_auto_merge"]
+			classicChecks := exist && rRepo.RequireStatusChecks
+			if !alreadyEnabled && !classicChecks && !checkedRepos[slug.Make(reponame)] {
+				logrus.Warnf("not enabling allow_auto_merge on the repository %s: no status checks are required by a ruleset or a branch protection (use --force-auto-merge to enable it anyway)", reponame)
+				boolProperties["allow_auto_merge"] = false
+			}
+		}
+		if lRepo.Spec.IsTemplate != nil {
+			boolProperties["is_template"] = *lRepo.Spec.IsTemplate
+		}
+
+		// the classic branch protection is only managed if the repository (or its template) sets it
+		var requireSignedCommits *bool
+		if classicSignatures {
+			requireSignedCommits = localRequireSignedCommits(r.repoconfig, lRepo)
+		}
+
+		customRoles, readers, writers, err := r.localCustomRoles(reponame, lRepo.Spec.CustomRoles, readers, writers, rCustomRoles)
+		if err != nil {
+			return err
+		}
+
+		lRepos[slug.Make(reponame)] = &GithubRepoComparable{
+			BoolProperties:             boolProperties,
+			StringProperties:           stringProperties,
+			Readers:                    readers,
+			Writers:                    writers,
+			ExternalUserReaders:        eReaders,
+			ExternalUserWriters:        eWriters,
+			RequireSignedCommits:       requireSignedCommits,
+			TeamCustomRoles:            customRoles,
+			TemplateRepository:         lRepo.Spec.TemplateRepository,
+			TemplateIncludeAllBranches: lRepo.Spec.TemplateIncludeAllBranches,
+			TransferFrom:               lRepo.Spec.TransferFrom,
+			DependabotAlerts:           dependabotAlerts,
+			ActionsPermissions:         actionsPermissions,
+			Security:                   localSecurity(lRepo.Spec.Security),
+			Webhooks:                   localWebhooks(lRepo.Spec.Webhooks),
+			Labels:                     localLabels(lRepo.Spec.Labels),
+			LabelsManaged:              lRepo.Spec.LabelsManaged,
+			CustomProperties:           definedCustomProperties(reponame, lRepo.Spec.CustomProperties, customPropertiesDefinitions),
+		}
+	}
+
+	// the CODEOWNERS file generated for the teams repository is only effective
+	// if the teams it references have access to the repository
+	if lRepo, ok := lRepos[slug.Make(teamsreponame)]; ok {
+		codeownersTeams := make([]string, 0)
+		if r.repoconfig.AdminTeam != "" {
+			codeownersTeams = append(codeownersTeams, slug.Make(r.repoconfig.AdminTeam))
+		}
+		for teamname := range local.Teams() {
+			codeownersTeams = append(codeownersTeams, slug.Make(teamname)+config.Config.GoliacTeamOwnerSuffix)
+		}
+		for _, teamslug := range codeownersTeamsWithoutAccess(lRepo, codeownersTeams) {
+			logrus.Warnf("team %s is referenced in the CODEOWNERS file of the repository %s but doesn't have access to it: the CODEOWNERS rule is ineffective", teamslug, teamsreponame)
+		}
+	}
+
+	// an empty allowed_actions means we only manage if Actions are enabled
+	actionsPermissionsDiffer := func(lPermissions *GithubActionsPermissions, rPermissions *GithubActionsPermissions) bool {
+		// not managed, or not loaded from Github (we don't know if they differ)
+		if lPermissions == nil || rPermissions == nil {
+			return false
+		}
+		if lPermissions.Enabled != rPermissions.Enabled {
+			return true
+		}
+		return lPermissions.AllowedActions != "" && lPermissions.AllowedActions != rPermissions.AllowedActions
+	}
+
+	// now we compare local (slugTeams) and remote (rTeams)
+
+	compareRepos := func(lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) bool {
+		for lk, lv := range lRepo.BoolProperties {
+			if rv, ok := rRepo.BoolProperties[lk]; !ok || rv != lv {
+				return false
+			}
+		}
+
+		for lk, lv := range lRepo.StringProperties {
+			if rv, ok := rRepo.StringProperties[lk]; !ok || rv != lv {
+				return false
+			}
+		}
+
+		if res, _, _ := entity.StringArrayEquivalent(lRepo.Readers, rRepo.Readers); !res {
+			return false
+		}
+
+		if res, _, _ := entity.StringArrayEquivalent(lRepo.Writers, rRepo.Writers); !res {
+			return false
+		}
+
+		if len(lRepo.TeamCustomRoles) != len(rRepo.TeamCustomRoles) {
+			return false
+		}
+		for t, role := range lRepo.TeamCustomRoles {
+			if rRepo.TeamCustomRoles[t] != role {
+				return false
+			}
+		}
+
+		if res, _, _ := entity.StringArrayEquivalent(lRepo.ExternalUserReaders, rRepo.ExternalUserReaders); !res {
+			return false
+		}
+
+		if res, _, _ := entity.StringArrayEquivalent(lRepo.ExternalUserWriters, rRepo.ExternalUserWriters); !res {
+			return false
+		}
+
+		if lRepo.RequireSignedCommits != nil && (rRepo.RequireSignedCommits == nil || *lRepo.RequireSignedCommits != *rRepo.RequireSignedCommits) {
+			return false
+		}
+
+		if lRepo.DependabotAlerts != nil && (rRepo.DependabotAlerts == nil || *lRepo.DependabotAlerts != *rRepo.DependabotAlerts) {
+			return false
+		}
+
+		if actionsPermissionsDiffer(lRepo.ActionsPermissions, rRepo.ActionsPermissions) {
+			return false
+		}
+
+		if len(securityToUpdate(lRepo.Security, rRepo.Security)) > 0 {
+			return false
+		}
+
+		if len(customPropertiesToUpdate(lRepo.CustomProperties, rRepo.CustomProperties, r.repoconfig.ManageGithubRepositoryCustomProperties)) > 0 {
+			return false
+		}
+
+		if toAdd, toUpdate, toRemove := webhooksToUpdate(lRepo.Webhooks, rRepo.Webhooks); len(toAdd)+len(toUpdate)+len(toRemove) > 0 {
+			return false
+		}
+
+		if toCreate, toUpdate, toDelete := labelsToUpdate(lRepo.Labels, rRepo.Labels, lRepo.LabelsManaged); len(toCreate)+len(toUpdate)+len(toDelete) > 0 {
+			return false
+		}
+
+		return true
+	}
+
+	onChanged := func(reponame string, lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) {
+		// an archived repository rejects any mutation: so we must unarchive it
+		// before any other change, and archive it only after all other changes
+		archive := false
+		if lv, ok := lRepo.BoolProperties["archived"]; ok {
+			if rv, ok := rRepo.BoolProperties["archived"]; !ok || rv != lv {
+				if lv {
+					archive = true
+				} else {
+					r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, "archived", false)
+				}
+			}
+		}
+
+		// reconciliate repositories boolean properties
+		for lk, lv := range lRepo.BoolProperties {
+			if lk == "archived" {
+				continue
+			}
+			if rv, ok := rRepo.BoolProperties[lk]; !ok || rv != lv {
+				r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, lk, lv)
+			}
+		}
+
+		// reconciliate repositories string properties
+		for lk, lv := range lRepo.StringProperties {
+			if rv, ok := rRepo.StringProperties[lk]; !ok || rv != lv {
+				r.UpdateRepositoryUpdateProperty(ctx, dryrun, remote, reponame, lk, lv)
+			}
+		}
+
+		// the teams moving from or to a custom role are reconciliated with the custom roles (below)
+		if res, readToRemove, readToAdd := entity.StringArrayEquivalent(lRepo.Readers, rRepo.Readers); !res {
+			for _, teamSlug := range readToAdd {
+				if _, ok := rRepo.TeamCustomRoles[teamSlug]; !ok {
+					r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "pull")
+				}
+			}
+			for _, teamSlug := range readToRemove {
+				if _, ok := lRepo.TeamCustomRoles[teamSlug]; !ok {
+					r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+				}
+			}
+		}
+
+		if res, writeToRemove, writeToAdd := entity.StringArrayEquivalent(lRepo.Writers, rRepo.Writers); !res {
+			for _, teamSlug := range writeToAdd {
+				if _, ok := rRepo.TeamCustomRoles[teamSlug]; !ok {
+					r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, "push")
+				}
+			}
+			for _, teamSlug := range writeToRemove {
+				if _, ok := lRepo.TeamCustomRoles[teamSlug]; !ok {
+					r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+				}
+			}
+		}
+
+		// reconciliate the custom repository roles
+		lAccess := make(map[string]string)
+		for _, t := range lRepo.Readers {
+			lAccess[t] = "pull"
+		}
+		for _, t := range lRepo.Writers {
+			lAccess[t] = "push"
+		}
+		rAccess := make(map[string]bool)
+		for _, t := range rRepo.Readers {
+			rAccess[t] = true
+		}
+		for _, t := range rRepo.Writers {
+			rAccess[t] = true
+		}
+		for _, teamSlug := range sortedTeams(lRepo.TeamCustomRoles) {
+			role := lRepo.TeamCustomRoles[teamSlug]
+			if rRole, ok := rRepo.TeamCustomRoles[teamSlug]; ok {
+				if rRole != role {
+					r.UpdateRepositoryUpdateTeamAccess(ctx, dryrun, remote, reponame, teamSlug, role)
+				}
+			} else if rAccess[teamSlug] {
+				r.UpdateRepositoryUpdateTeamAccess(ctx, dryrun, remote, reponame, teamSlug, role)
+			} else {
+				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, role)
+			}
+		}
+		for _, teamSlug := range sortedTeams(rRepo.TeamCustomRoles) {
+			if _, ok := lRepo.TeamCustomRoles[teamSlug]; ok {
+				continue
+			}
+			if permission, ok := lAccess[teamSlug]; ok {
+				r.UpdateRepositoryUpdateTeamAccess(ctx, dryrun, remote, reponame, teamSlug, permission)
+			} else {
+				r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamSlug)
+			}
+		}
+
+		resEreader, ereaderToRemove, ereaderToAdd := entity.StringArrayEquivalent(lRepo.ExternalUserReaders, rRepo.ExternalUserReaders)
+		resEWriter, ewriteToRemove, ewriteToAdd := entity.StringArrayEquivalent(lRepo.ExternalUserWriters, rRepo.ExternalUserWriters)
+
+		if !resEreader {
+			for _, eReader := range ereaderToRemove {
+				// check if it is added in the writers
+				found := false
+				for _, eWriter := range ewriteToAdd {
+					if eWriter == eReader {
+						found = true
+						break
+					}
+				}
+				if !found {
+					r.UpdateRepositoryRemoveExternalUser(ctx, dryrun, remote, reponame, eReader)
+				}
+			}
+			for _, eReader := range ereaderToAdd {
+				r.UpdateRepositorySetExternalUser(ctx, dryrun, remote, reponame, eReader, "pull")
+			}
+		}
+
+		if !resEWriter {
+			for _, eWriter := range ewriteToRemove {
+				// check if it is added in the writers
+				found := false
+				for _, eReader := range ereaderToAdd {
+					if eReader == eWriter {
+						found = true
+						break
+					}
+				}
+				if !found {
+					r.UpdateRepositoryRemoveExternalUser(ctx, dryrun, remote, reponame, eWriter)
+				}
+			}
+			for _, eWriter := range ewriteToAdd {
+				r.UpdateRepositorySetExternalUser(ctx, dryrun, remote, reponame, eWriter, "push")
+			}
+		}
+
+		if lRepo.RequireSignedCommits != nil && (rRepo.RequireSignedCommits == nil || *lRepo.RequireSignedCommits != *rRepo.RequireSignedCommits) {
+			r.UpdateRepositorySetRequiredSignatures(ctx, dryrun, remote, reponame, *lRepo.RequireSignedCommits)
+		}
+
+		if lRepo.DependabotAlerts != nil && (rRepo.DependabotAlerts == nil || *lRepo.DependabotAlerts != *rRepo.DependabotAlerts) {
+			r.UpdateRepositorySetDependabotAlerts(ctx, dryrun, remote, reponame, *lRepo.DependabotAlerts)
+		}
+
+		for _, feature := range securityToUpdate(lRepo.Security, rRepo.Security) {
+			r.UpdateRepositorySetSecurity(ctx, dryrun, remote, reponame, feature, lRepo.Security[feature])
+		}
+
+		if actionsPermissionsDiffer(lRepo.ActionsPermissions, rRepo.ActionsPermissions) {
+			r.UpdateRepositoryActionsPermissions(ctx, dryrun, remote, reponame, lRepo.ActionsPermissions.Enabled, lRepo.ActionsPermissions.AllowedActions)
+		}
+
+		if properties := customPropertiesToUpdate(lRepo.CustomProperties, rRepo.CustomProperties, false); len(properties) > 0 {
+			r.UpdateRepositoryCustomProperties(ctx, dryrun, remote, reponame, properties)
+		}
+		if r.repoconfig.ManageGithubRepositoryCustomProperties && rRepo.CustomProperties != nil {
+			if undeclared := undeclaredCustomProperties(lRepo.CustomProperties, rRepo.CustomProperties); len(undeclared) > 0 {
+				r.RemoveRepositoryCustomProperties(ctx, dryrun, remote, reponame, undeclared)
+			}
+		}
+
+		toAdd, toUpdate, toRemove := webhooksToUpdate(lRepo.Webhooks, rRepo.Webhooks)
+		for _, webhook := range toAdd {
+			r.AddRepositoryWebhook(ctx, dryrun, remote, reponame, webhook)
+		}
+		for _, webhook := range toUpdate {
+			r.UpdateRepositoryWebhook(ctx, dryrun, remote, reponame, webhook)
+		}
+		for _, webhook := range toRemove {
+			r.DeleteRepositoryWebhook(ctx, dryrun, remote, reponame, webhook)
+		}
+
+		labelsToCreate, labelsToUpdate, labelsToDelete := labelsToUpdate(lRepo.Labels, rRepo.Labels, lRepo.LabelsManaged)
+		for _, label := range labelsToCreate {
+			r.CreateRepositoryLabel(ctx, dryrun, remote, reponame, label)
+		}
+		for _, label := range labelsToUpdate {
+			r.UpdateRepositoryLabel(ctx, dryrun, remote, reponame, rRepo.Labels[strings.ToLower(label.Name)].Name, label)
+		}
+		for _, label := range labelsToDelete {
+			r.DeleteRepositoryLabel(ctx, dryrun, remote, reponame, label.Name)
+		}
+
+		if archive {
+			r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, "archived", true)
+		}
+	}
+
+	onAdded := func(reponame string, lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) {
+		// CREATE repository
+
+		// if the repo was just archived in a previous commit and we "resume it"
+		if aRepo, ok := toArchive[reponame]; ok {
+			delete(toArchive, reponame)
+			r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, "archived", false)
+			// calling onChanged to update the repository permissions
+			onChanged(reponame, aRepo, rRepo)
+		} else {
+			description := reponame
+			if d, ok := lRepo.StringProperties["description"]; ok {
+				description = d
+			}
+			if lRepo.TransferFrom != "" {
+				// the transferred repository keeps its settings: they are applied (with the teams access) once it is available
+				r.TransferRepository(ctx, dryrun, remote, reponame, lRepo.TransferFrom)
+				for _, reader := range lRepo.Readers {
+					r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, reader, "pull")
+				}
+				for _, writer := range lRepo.Writers {
+					r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, writer, "push")
+				}
+				boolProperties := make([]string, 0, len(lRepo.BoolProperties))
+				for name := range lRepo.BoolProperties {
+					boolProperties = append(boolProperties, name)
+				}
+				sort.Strings(boolProperties)
+				for _, name := range boolProperties {
+					r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, name, lRepo.BoolProperties[name])
+				}
+				r.UpdateRepositoryUpdateProperty(ctx, dryrun, remote, reponame, "description", description)
+			} else {
+				if err := templateRepositoryAvailable(remote, lRepo.TemplateRepository); err != nil {
+					logrus.Errorf("not able to create the repository %s: %v", reponame, err)
+					return
+				}
+				r.CreateRepository(ctx, dryrun, remote, reponame, description, lRepo.Writers, lRepo.Readers, lRepo.BoolProperties, lRepo.TemplateRepository, lRepo.TemplateIncludeAllBranches)
+			}
+			for _, teamSlug := range sortedTeams(lRepo.TeamCustomRoles) {
+				r.UpdateRepositoryAddTeamAccess(ctx, dryrun, remote, reponame, teamSlug, lRepo.TeamCustomRoles[teamSlug])
+			}
+			if homepage, ok := lRepo.StringProperties["homepage"]; ok {
+				r.UpdateRepositoryUpdateProperty(ctx, dryrun, remote, reponame, "homepage", homepage)
+			}
+			if lRepo.DependabotAlerts != nil {
+				r.UpdateRepositorySetDependabotAlerts(ctx, dryrun, remote, reponame, *lRepo.DependabotAlerts)
+			}
+			for _, feature := range securityToUpdate(lRepo.Security, map[string]bool{}) {
+				r.UpdateRepositorySetSecurity(ctx, dryrun, remote, reponame, feature, lRepo.Security[feature])
+			}
+			if lRepo.ActionsPermissions != nil {
+				r.UpdateRepositoryActionsPermissions(ctx, dryrun, remote, reponame, lRepo.ActionsPermissions.Enabled, lRepo.ActionsPermissions.AllowedActions)
+			}
+			// a new repository has no custom property set
+			if properties := customPropertiesToUpdate(lRepo.CustomProperties, map[string]string{}, false); len(properties) > 0 {
+				r.UpdateRepositoryCustomProperties(ctx, dryrun, remote, reponame, properties)
+			}
+			// a new repository has no webhook
+			toAdd, _, _ := webhooksToUpdate(lRepo.Webhooks, map[string]*GithubWebhook{})
+			for _, webhook := range toAdd {
+				r.AddRepositoryWebhook(ctx, dryrun, remote, reponame, webhook)
+			}
+			// a new repository has the Github default labels, that are not known yet:
+			// the labels are only reconciled at the next run
+		}
+	}
+
+	onRemoved := func(reponame string, lRepo *GithubRepoComparable, rRepo *GithubRepoComparable) {
+		// here we have a repository that is not listed in the teams repository.
+		// we should call DeleteRepository (that will delete if AllowDestructiveRepositories is on).
+		// but if we have ArchiveOnDelete...
+		if r.repoconfig.ArchiveOnDelete {
+			if r.repoconfig.DestructiveOperations.AllowDestructiveRepositories {
+				r.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, remote, reponame, "archived", true)
+				toArchive[reponame] = rRepo
+			} else {
+				r.unmanaged.Repositories[reponame] = true
+			}
+		} else {
+			r.DeleteRepository(ctx, dryrun, remote, reponame)
+		}
+	}
+
+	lRepos = filterEntities(lRepos, r.filter, "repo")
+	rRepos = filterEntities(rRepos, r.filter, "repo")
+	// the ignored repositories are left untouched
+	for _, repos := range []map[string]*GithubRepoComparable{lRepos, rRepos} {
+		for reponame := range repos {
+			if r.repoconfig.IsIgnoredRepository(reponame) {
+				logrus.Debugf("repository %s is ignored (ignored_repositories in goliac.yaml)", reponame)
+				delete(repos, reponame)
+			}
+		}
+	}
+	CompareEntities(lRepos, rRepos, compareRepos, onAdded, onRemoved, onChanged)
+
+	return nil
+}
+
+func (r *GoliacReconciliatorImpl) reconciliateRulesets(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, teamsreponame string, conf *config.RepositoryConfig, dryrun bool) error {
+	repositories := local.Repositories()
+
+	lgrs := map[string]*GithubRuleSet{}
+	// prepare local comparable
+	for _, confrs := range conf.Rulesets {
+		match, err := regexp.Compile(confrs.Pattern)
+		if err != nil {
+			return fmt.Errorf("not able to parse ruleset regular expression %s: %v", confrs.Pattern, err)
+		}
+		rs, ok := local.RuleSets()[confrs.Ruleset]
+		if !ok {
+			return fmt.Errorf("not able to find ruleset %s definition", confrs.Ruleset)
+		}
+
+		enforcement := rs.Spec.Enforcement
+		if enforcement == "disable" {
+			enforcement = "disabled"
+		}
+		grs := GithubRuleSet{
+			Name:        rs.Name,
+			Enforcement: enforcement,
+			BypassApps:  map[string]string{},
+			OnInclude:   rs.Spec.On.Include,
+			OnExclude:   rs.Spec.On.Exclude,
+			Rules:       map[string]entity.RuleSetParameters{},
+		}
+		for _, b := range rs.Spec.BypassApps {
+			grs.BypassApps[b.AppName] = b.Mode
+		}
+		for _, r := range rs.Spec.Rules {
+			grs.Rules[r.Ruletype] = r.Parameters
+		}
+		if len(rs.Spec.Repositories.Properties.Include) > 0 {
+			// targeted by custom property values: the goliac.yaml pattern is not used
+			definitions := remote.CustomPropertiesDefinitions()
+			for _, p := range append(append([]entity.RuleSetRepositoryProperty{}, rs.Spec.Repositories.Properties.Include...), rs.Spec.Repositories.Properties.Exclude...) {
+				if definitions != nil && !definitions[p.Name] {
+					return fmt.Errorf("not able to target the repositories of the ruleset %s: custom property %s is not defined at the organization level", rs.Name, p.Name)
+				}
+			}
+			grs.RepositoryPropertyInclude = rs.Spec.Repositories.Properties.Include
+			grs.RepositoryPropertyExclude = rs.Spec.Repositories.Properties.Exclude
+		} else if len(rs.Spec.Repositories.Include) > 0 {
+			// targeted by repository names: the goliac.yaml pattern is not used
+			grs.RepositoryNameInclude = rs.Spec.Repositories.Include
+			grs.RepositoryNameExclude = rs.Spec.Repositories.Exclude
+		} else {
+			for reponame := range repositories {
+				if match.Match([]byte(slug.Make(reponame))) {
+					grs.Repositories = append(grs.Repositories, slug.Make(reponame))
+				}
+			}
+		}
+		lgrs[rs.Name] = &grs
+	}
+
+	// repositories requiring signed commits that are not already covered by a ruleset
+	signedRepos := []string{}
+	for reponame, lRepo := range repositories {
+		if requireSignedCommits := localRequireSignedCommits(conf, lRepo); requireSignedCommits == nil || !*requireSignedCommits {
+			continue
+		}
+		covered := false
+		for _, grs := range lgrs {
+			if _, ok := grs.Rules["required_signatures"]; !ok || grs.Enforcement != "active" {
+				continue
+			}
+			for _, rn := range grs.Repositories {
+				if rn == slug.Make(reponame) {
+					covered = true
+					break
+				}
+			}
+			if covered {
+				break
+			}
+		}
+		if !covered {
+			signedRepos = append(signedRepos, slug.Make(reponame))
+		}
+	}
+	if len(signedRepos) > 0 {
+		lgrs[REQUIRED_SIGNATURES_RULESET] = &GithubRuleSet{
+			Name:        REQUIRED_SIGNATURES_RULESET,
+			Enforcement: "active",
+			BypassApps:  map[string]string{},
+			OnInclude:   []string{"~DEFAULT_BRANCH"},
+			OnExclude:   []string{},
+			Rules: map[string]entity.RuleSetParameters{
+				"required_signatures": {},
+			},
+			Repositories: signedRepos,
+		}
+	}
+
+	// the pull requests of the teams repository need required_approvals approvals.
+	// Goliac itself pushes to the teams repository (codeowners, users sync, ...)
+	if conf.RequiredApprovals > 0 && teamsreponame != "" {
+		bypassApps := map[string]string{}
+		if app, ok := ctx.Value(KeyTeamsRepoApp).(string); ok && app != "" {
+			bypassApps[app] = "always"
+		}
+		lgrs[TEAMS_REPO_APPROVALS_RULESET] = &GithubRuleSet{
+			Name:        TEAMS_REPO_APPROVALS_RULESET,
+			Enforcement: "active",
+			BypassApps:  bypassApps,
+			OnInclude:   []string{"~DEFAULT_BRANCH"},
+			OnExclude:   []string{},
+			Rules: map[string]entity.RuleSetParameters{
+				"pull_request": {
+					DismissStaleReviewsOnPush:    true,
+					RequiredApprovingReviewCount: conf.RequiredApprovals,
+				},
+			},
+			Repositories: []string{teamsreponame},
+		}
+	}
+
+	// prepare remote comparable
+	rgrs := remote.RuleSets()
+
+	// prepare the diff computation
+
+	compareRulesets := func(lrs *GithubRuleSet, rrs *GithubRuleSet) bool {
+		if len(RulesetFieldsDiff(rrs, lrs)) > 0 {
+			return false
+		}
+		if len(lrs.Rules) != len(rrs.Rules) {
+			return false
+		}
+		for k, v := range lrs.Rules {
+			if !entity.CompareRulesetParameters(k, v, rrs.Rules[k]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	onAdded := func(rulesetname string, lRuleset *GithubRuleSet, rRuleset *GithubRuleSet) {
+		// CREATE ruleset
+
+		r.AddRuleset(ctx, dryrun, lRuleset)
+	}
+
+	onRemoved := func(rulesetname string, lRuleset *GithubRuleSet, rRuleset *GithubRuleSet) {
+		// DELETE ruleset
+		_, defined := local.RuleSets()[rulesetname]
+		if (defined || rulesetname == REQUIRED_SIGNATURES_RULESET || rulesetname == TEAMS_REPO_APPROVALS_RULESET) && conf.DestructiveOperations.AllowDestructiveRemovedRulesets {
+			// still defined (or generated by Goliac) but not referenced anymore
+			r.deleteRuleset(ctx, dryrun, rRuleset.Id)
+			return
+		}
+		r.DeleteRuleset(ctx, dryrun, rRuleset.Id)
+	}
+
+	onChanged := func(rulesetname string, lRuleset *GithubRuleSet, rRuleset *GithubRuleSet) {
+		// UPDATE ruleset
+		lRuleset.Id = rRuleset.Id
+		r.UpdateRuleset(ctx, dryrun, remote, lRuleset)
+		if changes := RulesetFieldsDiff(rRuleset, lRuleset); len(changes) > 0 {
+			logrus.Infof("ruleset %s changes: %s", rulesetname, strings.Join(changes, ", "))
+		}
+		if changes := RulesetRulesDiff(rRuleset.Rules, lRuleset.Rules); len(changes) > 0 {
+			logrus.Infof("ruleset %s rules changes:\n%s", rulesetname, RenderRulesetRulesDiff(changes))
+		}
+	}
+
+	lgrs = filterEntities(lgrs, r.filter, "ruleset")
+	rgrs = filterEntities(rgrs, r.filter, "ruleset")
+	CompareEntities(lgrs, rgrs, compareRulesets, onAdded, onRemoved, onChanged)
+
+	// migrate the classic "required signatures" branch protection to the rulesets.
+	// The rulesets have been created (or updated) above, so removing the classic
+	// protection now doesn't leave the default branch unprotected
+	signedByRuleset := map[string]bool{}
+	for _, grs := range lgrs {
+		if _, ok := grs.Rules["required_signatures"]; !ok || grs.Enforcement != "active" {
+			continue
+		}
+		for _, rn := range grs.Repositories {
+			signedByRuleset[rn] = true
+		}
+	}
+	for reponame, rRepo := range filterEntities(remote.Repositories(), r.filter, "repo") {
+		if rRepo.RequireSignedCommits && signedByRuleset[slug.Make(reponame)] && !conf.IsIgnoredRepository(reponame) {
+			r.UpdateRepositorySetRequiredSignatures(ctx, dryrun, remote, reponame, false)
+		}
+	}
+
+	return nil
+}
+
+func (r *GoliacReconciliatorImpl) reconciliateOrgVariables(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, dryrun bool) error {
+	// the org variables are not loaded: no diff
+	if remote.OrgVariables() == nil {
+		return nil
+	}
+
+	// prepare local comparable
+	lVariables := map[string]*GithubOrgVariable{}
+	for name, v := range local.OrgVariables() {
+		variable := GithubOrgVariable{
+			Name:                 v.Name,
+			Value:                v.Value,
+			Visibility:           v.Visibility,
+			SelectedRepositories: []string{},
+		}
+		if v.Visibility == "selected" {
+			for _, reponame := range v.SelectedRepositories {
+				variable.SelectedRepositories = append(variable.SelectedRepositories, slug.Make(reponame))
+			}
+		}
+		lVariables[name] = &variable
+	}
+
+	// prepare remote comparable
+	rVariables := remote.OrgVariables()
+
+	compareVariables := func(lv *GithubOrgVariable, rv *GithubOrgVariable) bool {
+		if lv.Value != rv.Value {
+			return false
+		}
+		if lv.Visibility != rv.Visibility {
+			return false
+		}
+		if lv.Visibility == "selected" {
+			if res, _, _ := entity.StringArrayEquivalent(lv.SelectedRepositories, rv.SelectedRepositories); !res {
+				return false
+			}
+		}
+		return true
+	}
+
+	onAdded := func(variablename string, lVariable *GithubOrgVariable, rVariable *GithubOrgVariable) {
+		// CREATE org variable
+		r.AddOrgVariable(ctx, dryrun, remote, lVariable)
+	}
+
+	onRemoved := func(variablename string, lVariable *GithubOrgVariable, rVariable *GithubOrgVariable) {
+		// DELETE org variable
+		r.DeleteOrgVariable(ctx, dryrun, remote, variablename)
+	}
+
+	onChanged := func(variablename string, lVariable *GithubOrgVariable, rVariable *GithubOrgVariable) {
+		// UPDATE org variable
+		r.UpdateOrgVariable(ctx, dryrun, remote, lVariable)
+	}
+
+	lVariables = filterEntities(lVariables, r.filter, "variable")
+	rVariables = filterEntities(rVariables, r.filter, "variable")
+	CompareEntities(lVariables, rVariables, compareVariables, onAdded, onRemoved, onChanged)
+
+	return nil
+}
+
+/*
+ * This function sync the visibility of the org Actions secrets. Github never
+ * returns the value of a secret: the secrets are created out-of-band, and a
+ * secret declared but not found in Github is only reported
+ */
+func (r *GoliacReconciliatorImpl) reconciliateOrgSecrets(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, dryrun bool) error {
+	// the org secrets are not loaded: no diff
+	if remote.OrgSecrets() == nil {
+		return nil
+	}
+
+	// prepare local comparable
+	lSecrets := map[string]*GithubOrgSecret{}
+	for name, s := range local.OrgSecrets() {
+		secret := GithubOrgSecret{
+			Name:                 s.Name,
+			Visibility:           s.Visibility,
+			SelectedRepositories: []string{},
+		}
+		if s.Visibility == "selected" {
+			for _, reponame := range s.SelectedRepositories {
+				secret.SelectedRepositories = append(secret.SelectedRepositories, slug.Make(reponame))
+			}
+		}
+		lSecrets[name] = &secret
+	}
+
+	// prepare remote comparable
+	rSecrets := remote.OrgSecrets()
+
+	compareSecrets := func(ls *GithubOrgSecret, rs *GithubOrgSecret) bool {
+		if ls.Visibility != rs.Visibility {
+			return false
+		}
+		if ls.Visibility == "selected" {
+			if res, _, _ := entity.StringArrayEquivalent(ls.SelectedRepositories, rs.SelectedRepositories); !res {
+				return false
+			}
+		}
+		return true
+	}
+
+	onAdded := func(secretname string, lSecret *GithubOrgSecret, rSecret *GithubOrgSecret) {
+		// the secret value is not known by Goliac
+		logrus.Warnf("org secret %s is defined in org-secrets.yaml but doesn't exist in Github: it must be created out-of-band", secretname)
+	}
+
+	onRemoved := func(secretname string, lSecret *GithubOrgSecret, rSecret *GithubOrgSecret) {
+		// DELETE org secret
+		r.DeleteOrgSecret(ctx, dryrun, remote, secretname)
+	}
+
+	onChanged := func(secretname string, lSecret *GithubOrgSecret, rSecret *GithubOrgSecret) {
+		// UPDATE org secret
+		r.UpdateOrgSecret(ctx, dryrun, remote, lSecret)
+	}
+
+	lSecrets = filterEntities(lSecrets, r.filter, "secret")
+	rSecrets = filterEntities(rSecrets, r.filter, "secret")
+	CompareEntities(lSecrets, rSecrets, compareSecrets, onAdded, onRemoved, onChanged)
+
+	return nil
+}
+
+func (r *GoliacReconciliatorImpl) AddUserToOrg(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, ghuserid string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "add_user_to_org"}).Infof("ghusername: %s", ghuserid)
+	r.recordAction("add_user_to_org", "user/"+ghuserid, nil, nil)
+	remote.AddUserToOrg(ghuserid)
+	if r.executor != nil {
+		r.executor.AddUserToOrg(ctx, dryrun, ghuserid)
+	}
+}
+
+func (r *GoliacReconciliatorImpl) RemoveUserFromOrg(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, ghuserid string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	if r.repoconfig.DestructiveOperations.AllowDestructiveUsers {
+		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "remove_user_from_org"}).Infof("ghusername: %s", ghuserid)
+		r.recordAction("remove_user_from_org", "user/"+ghuserid, nil, nil)
+		remote.RemoveUserFromOrg(ghuserid)
+		if r.executor != nil {
+			r.executor.RemoveUserFromOrg(ctx, dryrun, ghuserid)
+		}
+	} else {
+		r.unmanaged.Users[ghuserid] = true
+	}
+}
+
+func (r *GoliacReconciliatorImpl) CreateTeam(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamname string, description string, parentTeam *int, members []string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	parenTeamId := "nil"
+	if parentTeam != nil {
+		parenTeamId = fmt.Sprintf("%d", *parentTeam)
+	}
+
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "create_team"}).Infof("teamname: %s, parentTeam : %s, members: %s", teamname, parenTeamId, strings.Join(members, ","))
+	r.recordAction("create_team", "team/"+teamname, nil, map[string]interface{}{"description": description, "parent_team": parentTeam, "members": members})
+	remote.CreateTeam(teamname, description, members)
+	if r.executor != nil {
+		r.executor.CreateTeam(ctx, dryrun, teamname, description, parentTeam, members)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamAddMember(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, username string, role string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_add_member"}).Infof("teamslug: %s, username: %s, role: %s", teamslug, username, role)
+	r.recordAction("update_team_add_member", "team/"+teamslug+"/member/"+username, nil, "member")
+	remote.UpdateTeamAddMember(teamslug, username, "member")
+	if r.executor != nil {
+		r.executor.UpdateTeamAddMember(ctx, dryrun, teamslug, username, "member")
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamRemoveMember(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, username string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_remove_member"}).Infof("teamslug: %s, username: %s", teamslug, username)
+	r.recordAction("update_team_remove_member", "team/"+teamslug+"/member/"+username, "member", nil)
+	remote.UpdateTeamRemoveMember(teamslug, username)
+	if r.executor != nil {
+		r.executor.UpdateTeamRemoveMember(ctx, dryrun, teamslug, username)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamChangeMaintainerToMember(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, username string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_change_maintainer_to_member"}).Infof("teamslug: %s, username: %s", teamslug, username)
+	r.recordAction("update_team_change_maintainer_to_member", "team/"+teamslug+"/member/"+username, "maintainer", "member")
+	remote.UpdateTeamUpdateMember(teamslug, username, "member")
+	if r.executor != nil {
+		r.executor.UpdateTeamUpdateMember(ctx, dryrun, teamslug, username, "member")
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamSetParent(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, parentTeam *int) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	parenTeamId := "nil"
+	if parentTeam != nil {
+		parenTeamId = fmt.Sprintf("%d", *parentTeam)
+	}
+
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_parentteam"}).Infof("teamslug: %s, parentteam: %s", teamslug, parenTeamId)
+	var beforeParent *int
+	if t, ok := remote.Teams()[teamslug]; ok {
+		beforeParent = t.ParentTeam
+	}
+	r.recordAction("update_team_parentteam", "team/"+teamslug, beforeParent, parentTeam)
+	remote.UpdateTeamSetParent(ctx, dryrun, teamslug, parentTeam)
+	if r.executor != nil {
+		r.executor.UpdateTeamSetParent(ctx, dryrun, teamslug, parentTeam)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamUpdateProperty(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, propertyName string, propertyValue string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_update_property"}).Infof("teamslug: %s %s:%s", teamslug, propertyName, propertyValue)
+	var beforeValue interface{}
+	if t, ok := remote.Teams()[teamslug]; ok {
+		switch propertyName {
+		case "privacy":
+			beforeValue = t.Privacy
+		case "notification_setting":
+			beforeValue = t.NotificationSetting
+		}
+	}
+	r.recordAction("update_team_update_property", "team/"+teamslug+"/"+propertyName, beforeValue, propertyValue)
+	remote.UpdateTeamUpdateProperty(teamslug, propertyName, propertyValue)
+	if r.executor != nil {
+		r.executor.UpdateTeamUpdateProperty(ctx, dryrun, teamslug, propertyName, propertyValue)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamSetExternalGroups(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, groups []string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_external_groups"}).Infof("teamslug: %s, external groups: %s", teamslug, strings.Join(groups, ","))
+	var beforeGroups []string
+	if g, ok := remote.TeamsExternalGroups()[teamslug]; ok {
+		beforeGroups = g
+	}
+	r.recordAction("update_team_external_groups", "team/"+teamslug, beforeGroups, groups)
+	remote.UpdateTeamSetExternalGroups(teamslug, groups)
+	if r.executor != nil {
+		r.executor.UpdateTeamSetExternalGroups(ctx, dryrun, teamslug, groups)
+	}
+}
+
+/*
+ * RemoveTeamExternalGroups removes the IdP groups of a team not synchronized
+ * anymore (its members are then managed by Goliac)
+ */
+func (r *GoliacReconciliatorImpl) RemoveTeamExternalGroups(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, groups []string) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveTeams {
+		r.removeTeamExternalGroups(ctx, dryrun, remote, teamslug, groups)
+	} else {
+		r.unmanaged.TeamsExternalGroups[teamslug] = true
+	}
+}
+func (r *GoliacReconciliatorImpl) removeTeamExternalGroups(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, groups []string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_remove_external_groups"}).Infof("teamslug: %s, external groups: %s", teamslug, strings.Join(groups, ","))
+	r.recordAction("update_team_remove_external_groups", "team/"+teamslug, groups, nil)
+	remote.UpdateTeamSetExternalGroups(teamslug, []string{})
+	if r.executor != nil {
+		// an empty list of groups removes all the connections of the team
+		r.executor.UpdateTeamSetExternalGroups(ctx, dryrun, teamslug, []string{})
+	}
+}
+func (r *GoliacReconciliatorImpl) DeleteTeam(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	if r.repoconfig.DestructiveOperations.AllowDestructiveTeams {
+		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_team"}).Infof("teamslug: %s", teamslug)
+		r.recordAction("delete_team", "team/"+teamslug, nil, nil)
+		remote.DeleteTeam(teamslug)
+		if r.executor != nil {
+			r.executor.DeleteTeam(ctx, dryrun, teamslug)
+		}
+	} else {
+		r.unmanaged.Teams[teamslug] = true
+	}
+}
+func (r *GoliacReconciliatorImpl) archivedTeamPrefix() string {
+	if r.repoconfig.ArchivedTeamPrefix == "" {
+		return config.DEFAULT_ARCHIVED_TEAM_PREFIX
+	}
+	return r.repoconfig.ArchivedTeamPrefix
+}
+
+/*
+ * ArchiveTeam renames a team (with the archived_team_prefix prefix) and
+ * removes its access to all the repositories, instead of deleting it (and
+ * losing its discussions)
+ */
+func (r *GoliacReconciliatorImpl) ArchiveTeam(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string) {
+	if !r.repoconfig.DestructiveOperations.AllowDestructiveTeams {
+		r.unmanaged.Teams[teamslug] = true
+		return
+	}
+	t, ok := remote.Teams()[teamslug]
+	if !ok {
+		return
+	}
+
+	reponames := make([]string, 0)
+	for reponame := range remote.TeamRepositories()[teamslug] {
+		reponames = append(reponames, reponame)
+	}
+	sort.Strings(reponames)
+	for _, reponame := range reponames {
+		r.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, remote, reponame, teamslug)
+	}
+
+	r.UpdateTeamRename(ctx, dryrun, remote, teamslug, r.archivedTeamPrefix()+t.Name)
+}
+func (r *GoliacReconciliatorImpl) UpdateTeamRename(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, newname string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_rename"}).Infof("teamslug: %s, newname: %s", teamslug, newname)
+	var beforeName interface{}
+	if t, ok := remote.Teams()[teamslug]; ok {
+		beforeName = t.Name
+	}
+	r.recordAction("update_team_rename", "team/"+teamslug, beforeName, newname)
+	remote.UpdateTeamRename(teamslug, newname)
+	if r.executor != nil {
+		r.executor.UpdateTeamRename(ctx, dryrun, teamslug, newname)
+	}
+}
+func (r *GoliacReconciliatorImpl) CreateRepository(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "create_repository"}).Infof("repositoryname: %s, readers: %s, writers: %s, boolProperties: %v, template: %s", reponame, strings.Join(readers, ","), strings.Join(writers, ","), boolProperties, templateRepository)
+	after := map[string]interface{}{"description": descrition, "writers": writers, "readers": readers, "bool_properties": boolProperties}
+	if templateRepository != "" {
+		after["template_repository"] = templateRepository
+		after["template_include_all_branches"] = includeAllBranches
+	}
+	r.recordAction("create_repository", "repository/"+reponame, nil, after)
+	remote.CreateRepository(reponame, descrition, writers, readers, boolProperties)
+	if r.executor != nil {
+		r.executor.CreateRepository(ctx, dryrun, reponame, descrition, writers, readers, boolProperties, templateRepository, includeAllBranches)
+	}
+}
+func (r *GoliacReconciliatorImpl) TransferRepository(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, source string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "transfer_repository"}).Infof("repositoryname: %s, source: %s", reponame, source)
+	r.recordAction("transfer_repository", "repository/"+reponame, nil, map[string]interface{}{"transfer_from": source})
+	remote.TransferRepository(reponame)
+	if r.executor != nil {
+		r.executor.TransferRepository(ctx, dryrun, reponame, source)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, teamslug string, permission string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_add_team"}).Infof("repositoryname: %s, teamslug: %s, permission: %s", reponame, teamslug, permission)
+	r.recordAction("update_repository_add_team", "repository/"+reponame+"/team/"+teamslug, nil, permission)
+	remote.UpdateRepositoryAddTeamAccess(reponame, teamslug, permission)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryAddTeamAccess(ctx, dryrun, reponame, teamslug, permission)
+	}
+}
+
+func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, teamslug string, permission string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_update_team"}).Infof("repositoryname: %s, teamslug:%s, permission: %s", reponame, teamslug, permission)
+	var beforePermission interface{}
+	if tr, ok := remote.TeamRepositories()[teamslug][reponame]; ok {
+		beforePermission = tr.Permission
+	}
+	r.recordAction("update_repository_update_team", "repository/"+reponame+"/team/"+teamslug, beforePermission, permission)
+	remote.UpdateRepositoryUpdateTeamAccess(reponame, teamslug, permission)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryUpdateTeamAccess(ctx, dryrun, reponame, teamslug, permission)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositoryRemoveTeamAccess(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, teamslug string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_remove_team"}).Infof("repositoryname: %s, teamslug:%s", reponame, teamslug)
+	var beforePermission interface{}
+	if tr, ok := remote.TeamRepositories()[teamslug][reponame]; ok {
+		beforePermission = tr.Permission
+	}
+	r.recordAction("update_repository_remove_team", "repository/"+reponame+"/team/"+teamslug, beforePermission, nil)
+	remote.UpdateRepositoryRemoveTeamAccess(reponame, teamslug)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, reponame, teamslug)
+	}
+}
+
+func (r *GoliacReconciliatorImpl) DeleteRepository(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	if r.repoconfig.DestructiveOperations.AllowDestructiveRepositories {
+		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_repository"}).Infof("repositoryname: %s", reponame)
+		r.recordAction("delete_repository", "repository/"+reponame, nil, nil)
+		remote.DeleteRepository(reponame)
+		if r.executor != nil {
+			r.executor.DeleteRepository(ctx, dryrun, reponame)
+		}
+	} else {
+		r.unmanaged.Repositories[reponame] = true
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, propertyName string, propertyValue bool) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	var beforeValue interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok {
+		if v, ok := rr.BoolProperties[propertyName]; ok {
+			beforeValue = v
+		}
+	}
+	// making a private repository public may leak its content
+	if propertyName == "private" && !propertyValue && beforeValue == true && !r.repoconfig.DestructiveOperations.AllowPublicVisibilityChange {
+		logrus.Errorf("not making the private repository %s public: destructive_operations.public_visibility_change is not enabled in goliac.yaml", reponame)
+		return
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_update_bool_property"}).Infof("repositoryname: %s %s:%v", reponame, propertyName, propertyValue)
+	r.recordAction("update_repository_update_bool_property", "repository/"+reponame+"/"+propertyName, beforeValue, propertyValue)
+	remote.UpdateRepositoryUpdateBoolProperty(reponame, propertyName, propertyValue)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, reponame, propertyName, propertyValue)
+	}
+}
+func (r *GoliacReconciliatorImpl) AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "add_ruleset"}).Infof("ruleset: %s (id: %d) enforcement: %s", ruleset.Name, ruleset.Id, ruleset.Enforcement)
+	r.recordAction("add_ruleset", "ruleset/"+ruleset.Name, nil, ruleset)
+	if r.executor != nil {
+		r.executor.AddRuleset(ctx, dryrun, ruleset)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRuleset(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, ruleset *GithubRuleSet) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_ruleset"}).Infof("ruleset: %s (id: %d) enforcement: %s", ruleset.Name, ruleset.Id, ruleset.Enforcement)
+	r.recordAction("update_ruleset", "ruleset/"+ruleset.Name, remote.RuleSets()[ruleset.Name], ruleset)
+	if r.executor != nil {
+		r.executor.UpdateRuleset(ctx, dryrun, ruleset)
+	}
+}
+func (r *GoliacReconciliatorImpl) DeleteRuleset(ctx context.Context, dryrun bool, rulesetid int) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveRulesets {
+		r.deleteRuleset(ctx, dryrun, rulesetid)
+	} else {
+		r.unmanaged.RuleSets[rulesetid] = true
+	}
+}
+
+func (r *GoliacReconciliatorImpl) deleteRuleset(ctx context.Context, dryrun bool, rulesetid int) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_ruleset"}).Infof("ruleset id:%d", rulesetid)
+	r.recordAction("delete_ruleset", fmt.Sprintf("ruleset/%d", rulesetid), nil, nil)
+	if r.executor != nil {
+		r.executor.DeleteRuleset(ctx, dryrun, rulesetid)
+	}
 }
 
-func (r *GoliacReconciliatorImpl) reconciliateRulesets(ctx context.Context, local GoliacLocal, remote *MutableGoliacRemoteImpl, conf *config.RepositoryConfig, dryrun bool) error {
-	repositories := local.Repositories()
+/*
+ * This function sync the organization members privileges defined in goliac.yaml
+ * (only the settings explicitly set are managed)
+ */
+func (r *GoliacReconciliatorImpl) reconciliateOrgSettings(ctx context.Context, remote *MutableGoliacRemoteImpl, dryrun bool) error {
+	orgSettings := r.repoconfig.OrgSettings
+	// the org settings are only loaded if at least one of them is managed
+	if orgSettings.MembersCanCreatePages == nil &&
+		orgSettings.MembersCanCreatePrivatePages == nil &&
+		orgSettings.MembersCanCreateInternalRepositories == nil &&
+		orgSettings.TwoFactorRequirementEnabled == nil &&
+		orgSettings.SecretScanningPushProtectionCustomLink == nil &&
+		orgSettings.DefaultRepositoryPermission == nil {
+		return nil
+	}
 
-	lgrs := map[string]*GithubRuleSet{}
-	// prepare local comparable
-	for _, confrs := range conf.Rulesets {
-		match, err := regexp.Compile(confrs.Pattern)
-		if err != nil {
-			return fmt.Errorf("not able to parse ruleset regular expression %s: %v", confrs.Pattern, err)
-		}
-		rs, ok := local.RuleSets()[confrs.Ruleset]
-		if !ok {
-			return fmt.Errorf("not able to find ruleset %s definition", confrs.Ruleset)
-		}
+	lSettings := map[string]bool{}
+	if v := r.repoconfig.OrgSettings.MembersCanCreatePages; v != nil {
+		lSettings["members_can_create_pages"] = *v
+	}
+	if v := r.repoconfig.OrgSettings.MembersCanCreatePrivatePages; v != nil {
+		lSettings["members_can_create_private_pages"] = *v
+	}
+	if v := r.repoconfig.OrgSettings.MembersCanCreateInternalRepositories; v != nil {
+		lSettings["members_can_create_internal_repositories"] = *v
+	}
 
-		grs := GithubRuleSet{
-			Name:        rs.Name,
-			Enforcement: rs.Spec.Enforcement,
-			BypassApps:  map[string]string{},
-			OnInclude:   rs.Spec.On.Include,
-			OnExclude:   rs.Spec.On.Exclude,
-			Rules:       map[string]entity.RuleSetParameters{},
-		}
-		for _, b := range rs.Spec.BypassApps {
-			grs.BypassApps[b.AppName] = b.Mode
-		}
-		for _, r := range rs.Spec.Rules {
-			grs.Rules[r.Ruletype] = r.Parameters
+	rSettings := remote.OrgSettings()
+	if v := r.repoconfig.OrgSettings.TwoFactorRequirementEnabled; v != nil {
+		r.checkTwoFactorRequirement(remote, *v, rSettings)
+	}
+	for name, lv := range lSettings {
+		if rv, ok := rSettings[name]; ok && rv == lv {
+			continue
 		}
-		for reponame := range repositories {
-			if match.Match([]byte(slug.Make(reponame))) {
-				grs.Repositories = append(grs.Repositories, slug.Make(reponame))
-			}
+		if !r.repoconfig.DestructiveOperations.AllowDestructiveOrgSettings {
+			logrus.Warnf("org setting %s differs from goliac.yaml but destructive operations on org settings are not allowed", name)
+			continue
 		}
-		lgrs[rs.Name] = &grs
+		r.UpdateOrgSetting(ctx, dryrun, remote, name, lv)
 	}
 
-	// prepare remote comparable
-	rgrs := remote.RuleSets()
-
-	// prepare the diff computation
-
-	compareRulesets := func(lrs *GithubRuleSet, rrs *GithubRuleSet) bool {
-		if lrs.Enforcement != rrs.Enforcement {
-			return false
-		}
-		if len(lrs.BypassApps) != len(rrs.BypassApps) {
-			return false
-		}
-		for k, v := range lrs.BypassApps {
-			if rrs.BypassApps[k] != v {
-				return false
-			}
-		}
-		if res, _, _ := entity.StringArrayEquivalent(lrs.OnInclude, rrs.OnInclude); !res {
-			return false
-		}
-		if res, _, _ := entity.StringArrayEquivalent(lrs.OnExclude, rrs.OnExclude); !res {
-			return false
-		}
-		if len(lrs.Rules) != len(rrs.Rules) {
-			return false
+	if link := r.repoconfig.OrgSettings.SecretScanningPushProtectionCustomLink; link != nil && *link != remote.OrgPushProtectionCustomLink() {
+		if !r.repoconfig.DestructiveOperations.AllowDestructiveOrgSettings {
+			logrus.Warnf("org setting secret_scanning_push_protection_custom_link differs from goliac.yaml but destructive operations on org settings are not allowed")
+		} else {
+			r.UpdateOrgPushProtectionCustomLink(ctx, dryrun, remote, *link)
 		}
-		for k, v := range lrs.Rules {
-			if !entity.CompareRulesetParameters(k, v, rrs.Rules[k]) {
-				return false
-			}
+	}
+
+	// an empty remote value means the org settings were not loaded
+	if permission := r.repoconfig.OrgSettings.DefaultRepositoryPermission; permission != nil && remote.OrgDefaultRepositoryPermission() != "" && *permission != remote.OrgDefaultRepositoryPermission() {
+		if *permission != "read" && *permission != "write" && *permission != "admin" && *permission != "none" {
+			return fmt.Errorf("invalid org_settings.default_repository_permission: %s (must be read, write, admin or none)", *permission)
 		}
-		if res, _, _ := entity.StringArrayEquivalent(lrs.Repositories, rrs.Repositories); !res {
-			return false
+		// the default permission applies to all the repositories: the access changed is reported first
+		impact := defaultRepositoryPermissionImpact(remote, remote.OrgDefaultRepositoryPermission(), *permission)
+		logrus.Warnf("org setting default_repository_permission %s -> %s changes the access of %d member(s) on %d repositories", remote.OrgDefaultRepositoryPermission(), *permission, impact.Members, impact.Repositories)
+		if !r.repoconfig.DestructiveOperations.AllowDestructiveOrgSettings {
+			logrus.Warnf("org setting default_repository_permission differs from goliac.yaml but destructive operations on org settings are not allowed")
+		} else {
+			r.UpdateOrgDefaultRepositoryPermission(ctx, dryrun, remote, *permission)
 		}
-
-		return true
 	}
 
-	onAdded := func(rulesetname string, lRuleset *GithubRuleSet, rRuleset *GithubRuleSet) {
-		// CREATE ruleset
+	return nil
+}
 
-		r.AddRuleset(ctx, dryrun, lRuleset)
+/*
+ * checkTwoFactorRequirement reports the difference between the two-factor
+ * requirement defined in goliac.yaml and the organization one. It cannot be
+ * changed through the Github API, and enabling it removes the members without
+ * two-factor authentication: so they are reported first
+ */
+func (r *GoliacReconciliatorImpl) checkTwoFactorRequirement(remote *MutableGoliacRemoteImpl, enabled bool, rSettings map[string]bool) {
+	rv, ok := rSettings["two_factor_requirement_enabled"]
+	if !ok || rv == enabled {
+		return
+	}
+	if !enabled {
+		logrus.Warnf("org setting two_factor_requirement_enabled is enabled on Github but disabled in goliac.yaml: it can only be disabled from the organization settings")
+		return
+	}
+	members := remote.MembersWithoutTwoFactor()
+	if members == nil {
+		logrus.Warnf("org setting two_factor_requirement_enabled is disabled on Github: not able to list the members without two-factor authentication")
+	} else if len(members) > 0 {
+		logrus.Warnf("org setting two_factor_requirement_enabled is disabled on Github: enabling it would remove %d member(s) without two-factor authentication: %s", len(members), strings.Join(members, ", "))
+	} else {
+		logrus.Warnf("org setting two_factor_requirement_enabled is disabled on Github: all the members have two-factor authentication enabled")
 	}
+	logrus.Warnf("org setting two_factor_requirement_enabled can only be enabled from the organization settings")
+}
 
-	onRemoved := func(rulesetname string, lRuleset *GithubRuleSet, rRuleset *GithubRuleSet) {
-		// DELETE ruleset
-		r.DeleteRuleset(ctx, dryrun, rRuleset.Id)
-	}
+// values longer than this are masked in the logs and in the plan (unless ShowVariableValues)
+const maskedVariableValueLength = 8
 
-	onChanged := func(rulesetname string, lRuleset *GithubRuleSet, rRuleset *GithubRuleSet) {
-		// UPDATE ruleset
-		lRuleset.Id = rRuleset.Id
-		r.UpdateRuleset(ctx, dryrun, lRuleset)
+/*
+ * maskVariableValue shows only the length of a long variable value.
+ * Variables are not secrets, but can still be sensitive
+ */
+func maskVariableValue(value string) string {
+	if config.Config.ShowVariableValues || len(value) <= maskedVariableValueLength {
+		return value
 	}
+	return fmt.Sprintf("<masked, %d characters>", len(value))
+}
 
-	CompareEntities(lgrs, rgrs, compareRulesets, onAdded, onRemoved, onChanged)
-
-	return nil
+/*
+ * maskedOrgVariable returns a copy of the variable (to be recorded in the plan)
+ * with a masked value
+ */
+func maskedOrgVariable(variable *GithubOrgVariable) *GithubOrgVariable {
+	if variable == nil {
+		return nil
+	}
+	masked := *variable
+	masked.Value = maskVariableValue(variable.Value)
+	return &masked
 }
 
-func (r *GoliacReconciliatorImpl) AddUserToOrg(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, ghuserid string) {
+func (r *GoliacReconciliatorImpl) AddOrgVariable(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, variable *GithubOrgVariable) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "add_user_to_org"}).Infof("ghusername: %s", ghuserid)
-	remote.AddUserToOrg(ghuserid)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "add_org_variable"}).Infof("variable: %s value: %s visibility: %s", variable.Name, maskVariableValue(variable.Value), variable.Visibility)
+	r.recordAction("add_org_variable", "variable/"+variable.Name, nil, maskedOrgVariable(variable))
+	remote.AddOrgVariable(variable)
 	if r.executor != nil {
-		r.executor.AddUserToOrg(ctx, dryrun, ghuserid)
+		r.executor.AddOrgVariable(ctx, dryrun, variable)
 	}
 }
-
-func (r *GoliacReconciliatorImpl) RemoveUserFromOrg(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, ghuserid string) {
+func (r *GoliacReconciliatorImpl) UpdateOrgVariable(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, variable *GithubOrgVariable) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	if r.repoconfig.DestructiveOperations.AllowDestructiveUsers {
-		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "remove_user_from_org"}).Infof("ghusername: %s", ghuserid)
-		remote.RemoveUserFromOrg(ghuserid)
-		if r.executor != nil {
-			r.executor.RemoveUserFromOrg(ctx, dryrun, ghuserid)
-		}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_org_variable"}).Infof("variable: %s value: %s visibility: %s", variable.Name, maskVariableValue(variable.Value), variable.Visibility)
+	r.recordAction("update_org_variable", "variable/"+variable.Name, maskedOrgVariable(remote.OrgVariables()[variable.Name]), maskedOrgVariable(variable))
+	remote.UpdateOrgVariable(variable)
+	if r.executor != nil {
+		r.executor.UpdateOrgVariable(ctx, dryrun, variable)
+	}
+}
+func (r *GoliacReconciliatorImpl) DeleteOrgVariable(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, variablename string) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveOrgVariables {
+		r.deleteOrgVariable(ctx, dryrun, remote, variablename)
 	} else {
-		r.unmanaged.Users[ghuserid] = true
+		r.unmanaged.OrgVariables[variablename] = true
 	}
 }
-
-func (r *GoliacReconciliatorImpl) CreateTeam(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamname string, description string, parentTeam *int, members []string) {
+func (r *GoliacReconciliatorImpl) deleteOrgVariable(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, variablename string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	parenTeamId := "nil"
-	if parentTeam != nil {
-		parenTeamId = fmt.Sprintf("%d", *parentTeam)
-	}
-
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "create_team"}).Infof("teamname: %s, parentTeam : %s, members: %s", teamname, parenTeamId, strings.Join(members, ","))
-	remote.CreateTeam(teamname, description, members)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_org_variable"}).Infof("variable: %s", variablename)
+	r.recordAction("delete_org_variable", "variable/"+variablename, maskedOrgVariable(remote.OrgVariables()[variablename]), nil)
+	remote.DeleteOrgVariable(variablename)
 	if r.executor != nil {
-		r.executor.CreateTeam(ctx, dryrun, teamname, description, parentTeam, members)
+		r.executor.DeleteOrgVariable(ctx, dryrun, variablename)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateTeamAddMember(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, username string, role string) {
+func (r *GoliacReconciliatorImpl) UpdateOrgSecret(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, secret *GithubOrgSecret) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_add_member"}).Infof("teamslug: %s, username: %s, role: %s", teamslug, username, role)
-	remote.UpdateTeamAddMember(teamslug, username, "member")
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_org_secret"}).Infof("secret: %s visibility: %s", secret.Name, secret.Visibility)
+	r.recordAction("update_org_secret", "secret/"+secret.Name, remote.OrgSecrets()[secret.Name], secret)
+	remote.UpdateOrgSecret(secret)
 	if r.executor != nil {
-		r.executor.UpdateTeamAddMember(ctx, dryrun, teamslug, username, "member")
+		r.executor.UpdateOrgSecret(ctx, dryrun, secret)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateTeamRemoveMember(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, username string) {
+func (r *GoliacReconciliatorImpl) DeleteOrgSecret(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, secretname string) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveOrgSecrets {
+		r.deleteOrgSecret(ctx, dryrun, remote, secretname)
+	} else {
+		r.unmanaged.OrgSecrets[secretname] = true
+	}
+}
+func (r *GoliacReconciliatorImpl) deleteOrgSecret(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, secretname string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_remove_member"}).Infof("teamslug: %s, username: %s", teamslug, username)
-	remote.UpdateTeamRemoveMember(teamslug, username)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_org_secret"}).Infof("secret: %s", secretname)
+	r.recordAction("delete_org_secret", "secret/"+secretname, remote.OrgSecrets()[secretname], nil)
+	remote.DeleteOrgSecret(secretname)
 	if r.executor != nil {
-		r.executor.UpdateTeamRemoveMember(ctx, dryrun, teamslug, username)
+		r.executor.DeleteOrgSecret(ctx, dryrun, secretname)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateTeamChangeMaintainerToMember(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, username string) {
+func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, propertyName string, propertyValue string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_change_maintainer_to_member"}).Infof("teamslug: %s, username: %s", teamslug, username)
-	remote.UpdateTeamUpdateMember(teamslug, username, "member")
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_update_property"}).Infof("repositoryname: %s %s:%s", reponame, propertyName, propertyValue)
+	var beforeValue interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok {
+		if v, ok := rr.StringProperties[propertyName]; ok {
+			beforeValue = v
+		}
+	}
+	r.recordAction("update_repository_update_property", "repository/"+reponame+"/"+propertyName, beforeValue, propertyValue)
+	remote.UpdateRepositoryUpdateProperty(reponame, propertyName, propertyValue)
 	if r.executor != nil {
-		r.executor.UpdateTeamUpdateMember(ctx, dryrun, teamslug, username, "member")
+		r.executor.UpdateRepositoryUpdateProperty(ctx, dryrun, reponame, propertyName, propertyValue)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateTeamSetParent(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string, parentTeam *int) {
+func (r *GoliacReconciliatorImpl) UpdateOrgSetting(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, settingName string, settingValue bool) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	parenTeamId := "nil"
-	if parentTeam != nil {
-		parenTeamId = fmt.Sprintf("%d", *parentTeam)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_org_setting"}).Infof("setting: %s:%v", settingName, settingValue)
+	var beforeValue interface{}
+	if v, ok := remote.OrgSettings()[settingName]; ok {
+		beforeValue = v
 	}
-
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_team_parentteam"}).Infof("teamslug: %s, parentteam: %s", teamslug, parenTeamId)
-	remote.UpdateTeamSetParent(ctx, dryrun, teamslug, parentTeam)
+	r.recordAction("update_org_setting", "org_setting/"+settingName, beforeValue, settingValue)
+	remote.UpdateOrgSetting(settingName, settingValue)
 	if r.executor != nil {
-		r.executor.UpdateTeamSetParent(ctx, dryrun, teamslug, parentTeam)
+		r.executor.UpdateOrgSetting(ctx, dryrun, settingName, settingValue)
 	}
 }
-func (r *GoliacReconciliatorImpl) DeleteTeam(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, teamslug string) {
+func (r *GoliacReconciliatorImpl) UpdateOrgPushProtectionCustomLink(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, link string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	if r.repoconfig.DestructiveOperations.AllowDestructiveTeams {
-		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_team"}).Infof("teamslug: %s", teamslug)
-		remote.DeleteTeam(teamslug)
-		if r.executor != nil {
-			r.executor.DeleteTeam(ctx, dryrun, teamslug)
-		}
-	} else {
-		r.unmanaged.Teams[teamslug] = true
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_org_push_protection_custom_link"}).Infof("link: %s", link)
+	r.recordAction("update_org_push_protection_custom_link", "org_setting/secret_scanning_push_protection_custom_link", remote.OrgPushProtectionCustomLink(), link)
+	remote.UpdateOrgPushProtectionCustomLink(link)
+	if r.executor != nil {
+		r.executor.UpdateOrgPushProtectionCustomLink(ctx, dryrun, link)
 	}
 }
-func (r *GoliacReconciliatorImpl) CreateRepository(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool) {
+func (r *GoliacReconciliatorImpl) UpdateOrgDefaultRepositoryPermission(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, permission string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "create_repository"}).Infof("repositoryname: %s, readers: %s, writers: %s, boolProperties: %v", reponame, strings.Join(readers, ","), strings.Join(writers, ","), boolProperties)
-	remote.CreateRepository(reponame, reponame, writers, readers, boolProperties)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_org_default_repository_permission"}).Infof("permission: %s", permission)
+	r.recordAction("update_org_default_repository_permission", "org_setting/default_repository_permission", remote.OrgDefaultRepositoryPermission(), permission)
+	remote.UpdateOrgDefaultRepositoryPermission(permission)
 	if r.executor != nil {
-		r.executor.CreateRepository(ctx, dryrun, reponame, reponame, writers, readers, boolProperties)
+		r.executor.UpdateOrgDefaultRepositoryPermission(ctx, dryrun, permission)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, teamslug string, permission string) {
+func (r *GoliacReconciliatorImpl) UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, enabled bool) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_add_team"}).Infof("repositoryname: %s, teamslug: %s, permission: %s", reponame, teamslug, permission)
-	remote.UpdateRepositoryAddTeamAccess(reponame, teamslug, permission)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_set_dependabot_alerts"}).Infof("repositoryname: %s dependabot_alerts:%v", reponame, enabled)
+	var beforeValue interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok {
+		beforeValue = rr.DependabotAlerts
+	}
+	r.recordAction("update_repository_set_dependabot_alerts", "repository/"+reponame+"/dependabot_alerts", beforeValue, enabled)
+	remote.UpdateRepositorySetDependabotAlerts(reponame, enabled)
 	if r.executor != nil {
-		r.executor.UpdateRepositoryAddTeamAccess(ctx, dryrun, reponame, teamslug, permission)
+		r.executor.UpdateRepositorySetDependabotAlerts(ctx, dryrun, reponame, enabled)
 	}
 }
-
-func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, teamslug string, permission string) {
+func (r *GoliacReconciliatorImpl) UpdateRepositorySetSecurity(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, feature string, enabled bool) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_update_team"}).Infof("repositoryname: %s, teamslug:%s, permission: %s", reponame, teamslug, permission)
-	remote.UpdateRepositoryUpdateTeamAccess(reponame, teamslug, permission)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_set_security"}).Infof("repositoryname: %s %s:%v", reponame, feature, enabled)
+	var beforeValue interface{}
+	if s, ok := remote.RepositoriesSecurity()[reponame]; ok {
+		beforeValue = s.Features()[feature]
+	}
+	r.recordAction("update_repository_set_security", "repository/"+reponame+"/"+feature, beforeValue, enabled)
+	remote.UpdateRepositorySetSecurity(reponame, feature, enabled)
 	if r.executor != nil {
-		r.executor.UpdateRepositoryUpdateTeamAccess(ctx, dryrun, reponame, teamslug, permission)
+		r.executor.UpdateRepositorySetSecurity(ctx, dryrun, reponame, feature, enabled)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateRepositoryRemoveTeamAccess(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, teamslug string) {
+func (r *GoliacReconciliatorImpl) UpdateRepositoryActionsPermissions(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, enabled bool, allowedActions string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_remove_team"}).Infof("repositoryname: %s, teamslug:%s", reponame, teamslug)
-	remote.UpdateRepositoryRemoveTeamAccess(reponame, teamslug)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_actions_permissions"}).Infof("repositoryname: %s enabled:%v allowed_actions:%s", reponame, enabled, allowedActions)
+	var beforeValue interface{}
+	if p, ok := remote.RepositoriesActionsPermissions()[reponame]; ok {
+		beforeValue = *p
+	}
+	r.recordAction("update_repository_actions_permissions", "repository/"+reponame+"/actions_permissions", beforeValue, GithubActionsPermissions{Enabled: enabled, AllowedActions: allowedActions})
+	remote.UpdateRepositoryActionsPermissions(reponame, enabled, allowedActions)
 	if r.executor != nil {
-		r.executor.UpdateRepositoryRemoveTeamAccess(ctx, dryrun, reponame, teamslug)
+		r.executor.UpdateRepositoryActionsPermissions(ctx, dryrun, reponame, enabled, allowedActions)
 	}
 }
-
-func (r *GoliacReconciliatorImpl) DeleteRepository(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string) {
+func (r *GoliacReconciliatorImpl) UpdateRepositoryCustomProperties(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, properties map[string]string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	if r.repoconfig.DestructiveOperations.AllowDestructiveRepositories {
-		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_repository"}).Infof("repositoryname: %s", reponame)
-		remote.DeleteRepository(reponame)
-		if r.executor != nil {
-			r.executor.DeleteRepository(ctx, dryrun, reponame)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_custom_properties"}).Infof("repositoryname: %s custom_properties:%v", reponame, properties)
+	var beforeValue interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok && rr.CustomProperties != nil {
+		before := make(map[string]string)
+		for k := range properties {
+			before[k] = rr.CustomProperties[k]
 		}
+		beforeValue = before
+	}
+	r.recordAction("update_repository_custom_properties", "repository/"+reponame+"/custom_properties", beforeValue, properties)
+	remote.UpdateRepositoryCustomProperties(reponame, properties)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryCustomProperties(ctx, dryrun, reponame, properties)
+	}
+}
+
+/*
+ * RemoveRepositoryCustomProperties unsets the custom properties of a repository
+ * that are not defined locally (manage_github_repository_custom_properties)
+ */
+func (r *GoliacReconciliatorImpl) RemoveRepositoryCustomProperties(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, propertyNames []string) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveCustomProperties {
+		r.removeRepositoryCustomProperties(ctx, dryrun, remote, reponame, propertyNames)
 	} else {
-		r.unmanaged.Repositories[reponame] = true
+		for _, name := range propertyNames {
+			r.unmanaged.CustomProperties[reponame+"/custom_property/"+name] = true
+		}
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, propertyName string, propertyValue bool) {
+func (r *GoliacReconciliatorImpl) removeRepositoryCustomProperties(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, propertyNames []string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_update_bool_property"}).Infof("repositoryname: %s %s:%v", reponame, propertyName, propertyValue)
-	remote.UpdateRepositoryUpdateBoolProperty(reponame, propertyName, propertyValue)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_remove_custom_properties"}).Infof("repositoryname: %s custom_properties:%s", reponame, strings.Join(propertyNames, ","))
+	// an empty value unsets the property
+	properties := make(map[string]string)
+	before := make(map[string]string)
+	for _, name := range propertyNames {
+		properties[name] = ""
+		if rr, ok := remote.Repositories()[reponame]; ok && rr.CustomProperties != nil {
+			before[name] = rr.CustomProperties[name]
+		}
+	}
+	r.recordAction("update_repository_remove_custom_properties", "repository/"+reponame+"/custom_properties", before, nil)
+	remote.UpdateRepositoryCustomProperties(reponame, properties)
 	if r.executor != nil {
-		r.executor.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, reponame, propertyName, propertyValue)
+		r.executor.UpdateRepositoryCustomProperties(ctx, dryrun, reponame, properties)
 	}
 }
-func (r *GoliacReconciliatorImpl) AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet) {
+
+func (r *GoliacReconciliatorImpl) CreateRepositoryLabel(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, label *GithubLabel) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "add_ruleset"}).Infof("ruleset: %s (id: %d) enforcement: %s", ruleset.Name, ruleset.Id, ruleset.Enforcement)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "create_repository_label"}).Infof("repositoryname: %s label:%s color:%s", reponame, label.Name, label.Color)
+	r.recordAction("create_repository_label", "repository/"+reponame+"/label/"+label.Name, nil, *label)
+	remote.UpdateRepositoryLabel(reponame, label)
 	if r.executor != nil {
-		r.executor.AddRuleset(ctx, dryrun, ruleset)
+		r.executor.CreateRepositoryLabel(ctx, dryrun, reponame, label)
 	}
 }
-func (r *GoliacReconciliatorImpl) UpdateRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet) {
+func (r *GoliacReconciliatorImpl) UpdateRepositoryLabel(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, labelname string, label *GithubLabel) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_ruleset"}).Infof("ruleset: %s (id: %d) enforcement: %s", ruleset.Name, ruleset.Id, ruleset.Enforcement)
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_label"}).Infof("repositoryname: %s label:%s name:%s color:%s", reponame, labelname, label.Name, label.Color)
+	var beforeValue interface{}
+	if l, ok := remote.RepositoriesLabels()[reponame][strings.ToLower(labelname)]; ok {
+		beforeValue = *l
+	}
+	r.recordAction("update_repository_label", "repository/"+reponame+"/label/"+labelname, beforeValue, *label)
+	remote.UpdateRepositoryLabel(reponame, label)
 	if r.executor != nil {
-		r.executor.UpdateRuleset(ctx, dryrun, ruleset)
+		r.executor.UpdateRepositoryLabel(ctx, dryrun, reponame, labelname, label)
 	}
 }
-func (r *GoliacReconciliatorImpl) DeleteRuleset(ctx context.Context, dryrun bool, rulesetid int) {
+func (r *GoliacReconciliatorImpl) DeleteRepositoryLabel(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, labelname string) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveRepositoryLabels {
+		r.deleteRepositoryLabel(ctx, dryrun, remote, reponame, labelname)
+	} else {
+		r.unmanaged.Labels[reponame+"/label/"+labelname] = true
+	}
+}
+func (r *GoliacReconciliatorImpl) deleteRepositoryLabel(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, labelname string) {
 	author := "unknown"
 	if a := ctx.Value(KeyAuthor); a != nil {
 		author = a.(string)
 	}
-	if r.repoconfig.DestructiveOperations.AllowDestructiveRulesets {
-		logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_ruleset"}).Infof("ruleset id:%d", rulesetid)
-		if r.executor != nil {
-			r.executor.DeleteRuleset(ctx, dryrun, rulesetid)
-		}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_repository_label"}).Infof("repositoryname: %s label:%s", reponame, labelname)
+	r.recordAction("delete_repository_label", "repository/"+reponame+"/label/"+labelname, labelname, nil)
+	remote.DeleteRepositoryLabel(reponame, labelname)
+	if r.executor != nil {
+		r.executor.DeleteRepositoryLabel(ctx, dryrun, reponame, labelname)
+	}
+}
+
+// the webhook secret must never be logged (nor recorded)
+func webhookWithoutSecret(webhook *GithubWebhook) GithubWebhook {
+	w := *webhook
+	w.Secret = ""
+	return w
+}
+func (r *GoliacReconciliatorImpl) AddRepositoryWebhook(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, webhook *GithubWebhook) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "add_repository_webhook"}).Infof("repositoryname: %s url:%s events:%v content_type:%s active:%v", reponame, webhook.URL, webhook.Events, webhook.ContentType, webhook.Active)
+	r.recordAction("add_repository_webhook", "repository/"+reponame+"/webhook/"+webhook.URL, nil, webhookWithoutSecret(webhook))
+	remote.UpdateRepositoryWebhook(reponame, webhook)
+	if r.executor != nil {
+		r.executor.AddRepositoryWebhook(ctx, dryrun, reponame, webhook)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositoryWebhook(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, webhook *GithubWebhook) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_webhook"}).Infof("repositoryname: %s url:%s events:%v content_type:%s active:%v", reponame, webhook.URL, webhook.Events, webhook.ContentType, webhook.Active)
+	var beforeValue interface{}
+	if w, ok := remote.RepositoriesWebhooks()[reponame][webhook.URL]; ok {
+		beforeValue = webhookWithoutSecret(w)
+	}
+	r.recordAction("update_repository_webhook", "repository/"+reponame+"/webhook/"+webhook.URL, beforeValue, webhookWithoutSecret(webhook))
+	remote.UpdateRepositoryWebhook(reponame, webhook)
+	if r.executor != nil {
+		r.executor.UpdateRepositoryWebhook(ctx, dryrun, reponame, webhook)
+	}
+}
+func (r *GoliacReconciliatorImpl) DeleteRepositoryWebhook(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, webhook *GithubWebhook) {
+	if r.repoconfig.DestructiveOperations.AllowDestructiveWebhooks {
+		r.deleteRepositoryWebhook(ctx, dryrun, remote, reponame, webhook)
 	} else {
-		r.unmanaged.RuleSets[rulesetid] = true
+		r.unmanaged.Webhooks[reponame+"/webhook/"+webhook.URL] = true
+	}
+}
+func (r *GoliacReconciliatorImpl) deleteRepositoryWebhook(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, webhook *GithubWebhook) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "delete_repository_webhook"}).Infof("repositoryname: %s url:%s", reponame, webhook.URL)
+	r.recordAction("delete_repository_webhook", "repository/"+reponame+"/webhook/"+webhook.URL, webhookWithoutSecret(webhook), nil)
+	remote.DeleteRepositoryWebhook(reponame, webhook)
+	if r.executor != nil {
+		r.executor.DeleteRepositoryWebhook(ctx, dryrun, reponame, webhook)
+	}
+}
+func (r *GoliacReconciliatorImpl) UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, enabled bool) {
+	author := "unknown"
+	if a := ctx.Value(KeyAuthor); a != nil {
+		author = a.(string)
+	}
+	var beforeValue interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok {
+		beforeValue = rr.RequireSignedCommits
+	}
+	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_set_required_signatures"}).Infof("repositoryname: %s branch protection requiresCommitSignatures: %v -> %v", reponame, beforeValue, enabled)
+	r.recordAction("update_repository_set_required_signatures", "repository/"+reponame+"/required_signatures", beforeValue, enabled)
+	remote.UpdateRepositorySetRequiredSignatures(reponame, enabled)
+	if r.executor != nil {
+		r.executor.UpdateRepositorySetRequiredSignatures(ctx, dryrun, reponame, enabled)
 	}
 }
 func (r *GoliacReconciliatorImpl) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, remote *MutableGoliacRemoteImpl, reponame string, collaboatorGithubId string, permission string) {
@@ -907,6 +2895,13 @@ func (r *GoliacReconciliatorImpl) UpdateRepositorySetExternalUser(ctx context.Co
 		author = a.(string)
 	}
 	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_set_external_user"}).Infof("repositoryname: %s collaborator:%s permission:%s", reponame, collaboatorGithubId, permission)
+	var beforePermission interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok {
+		if p, ok := rr.ExternalUsers[collaboatorGithubId]; ok {
+			beforePermission = p
+		}
+	}
+	r.recordAction("update_repository_set_external_user", "repository/"+reponame+"/collaborator/"+collaboatorGithubId, beforePermission, permission)
 	remote.UpdateRepositorySetExternalUser(reponame, collaboatorGithubId, permission)
 	if r.executor != nil {
 		r.executor.UpdateRepositorySetExternalUser(ctx, dryrun, reponame, collaboatorGithubId, permission)
@@ -918,6 +2913,13 @@ func (r *GoliacReconciliatorImpl) UpdateRepositoryRemoveExternalUser(ctx context
 		author = a.(string)
 	}
 	logrus.WithFields(map[string]interface{}{"dryrun": dryrun, "author": author, "command": "update_repository_remove_external_user"}).Infof("repositoryname: %s collaborator:%s", reponame, collaboatorGithubId)
+	var beforePermission interface{}
+	if rr, ok := remote.Repositories()[reponame]; ok {
+		if p, ok := rr.ExternalUsers[collaboatorGithubId]; ok {
+			beforePermission = p
+		}
+	}
+	r.recordAction("update_repository_remove_external_user", "repository/"+reponame+"/collaborator/"+collaboatorGithubId, beforePermission, nil)
 	remote.UpdateRepositoryRemoveExternalUser(reponame, collaboatorGithubId)
 	if r.executor != nil {
 		r.executor.UpdateRepositoryRemoveExternalUser(ctx, dryrun, reponame, collaboatorGithubId)
@@ -937,8 +2939,14 @@ func (r *GoliacReconciliatorImpl) Rollback(ctx context.Context, dryrun bool, err
 }
 func (r *GoliacReconciliatorImpl) Commit(ctx context.Context, dryrun bool) error {
 	logrus.WithFields(map[string]interface{}{"dryrun": dryrun}).Debugf("reconciliation commit")
+	var err error
 	if r.executor != nil {
-		return r.executor.Commit(ctx, dryrun)
+		err = r.executor.Commit(ctx, dryrun)
 	}
-	return nil
+	if err == nil && !dryrun {
+		for _, action := range r.plannedActions {
+			metrics.ResourcesChangesTotal.Inc(changeType(action.Operation))
+		}
+	}
+	return err
 }
@@ -3,14 +3,18 @@ package engine
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/metrics"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/gosimple/slug"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,6 +24,8 @@ type GoliacLocalMock struct {
 	teams     map[string]*entity.Team
 	repos     map[string]*entity.Repository
 	rulesets  map[string]*entity.RuleSet
+	variables map[string]*entity.OrgVariable
+	secrets   map[string]*entity.OrgSecret
 }
 
 func (m *GoliacLocalMock) Clone(fs billy.Filesystem, accesstoken, repositoryUrl, branch string) error {
@@ -34,6 +40,9 @@ func (m *GoliacLocalMock) GetHeadCommit() (*object.Commit, error) {
 func (m *GoliacLocalMock) CheckoutCommit(commit *object.Commit) error {
 	return nil
 }
+func (m *GoliacLocalMock) CheckoutRef(ref string) error {
+	return nil
+}
 func (m *GoliacLocalMock) PushTag(tagname string, hash plumbing.Hash, accesstoken string) error {
 	return nil
 }
@@ -61,6 +70,12 @@ func (m *GoliacLocalMock) ExternalUsers() map[string]*entity.User {
 func (m *GoliacLocalMock) RuleSets() map[string]*entity.RuleSet {
 	return m.rulesets
 }
+func (m *GoliacLocalMock) OrgVariables() map[string]*entity.OrgVariable {
+	return m.variables
+}
+func (m *GoliacLocalMock) OrgSecrets() map[string]*entity.OrgSecret {
+	return m.secrets
+}
 func (m *GoliacLocalMock) UpdateAndCommitCodeOwners(repoconfig *config.RepositoryConfig, dryrun bool, accesstoken string, branch string, tagname string, githubOrganization string) error {
 	return nil
 }
@@ -70,6 +85,11 @@ func (m *GoliacLocalMock) ArchiveRepos(reposToArchiveList []string, accesstoken
 func (m *GoliacLocalMock) SyncUsersAndTeams(repoconfig *config.RepositoryConfig, plugin UserSyncPlugin, accesstoken string, dryrun bool, force bool) (bool, error) {
 	return false, nil
 }
+func (m *GoliacLocalMock) SetTargetBranch(branch string) {
+}
+func (m *GoliacLocalMock) TargetBranchPushed() bool {
+	return false
+}
 func (m *GoliacLocalMock) Close(fs billy.Filesystem) {
 
 }
@@ -81,6 +101,25 @@ type GoliacRemoteMock struct {
 	teamsrepos map[string]map[string]*GithubTeamRepo // key is the slug team
 	rulesets   map[string]*GithubRuleSet
 	appids     map[string]int
+	variables  map[string]*GithubOrgVariable
+	secrets    map[string]*GithubOrgSecret
+	settings   map[string]bool
+	pushLink   string
+	// number of times the org settings were requested
+	settingsLoads int
+	// default repository permission
+	defaultPermission string
+
+	actionsPermissions map[string]*GithubActionsPermissions
+	customProperties   map[string]map[string]string
+	security           map[string]*GithubRepositorySecurity
+	webhooks           map[string]map[string]*GithubWebhook
+	labels             map[string]map[string]*GithubLabel
+	samlIdentities     map[string]string
+	externalGroups     map[string][]string
+	customPropsDefs    map[string]bool
+	customRoles        map[string]string
+	membersWithout2FA  []string
 }
 
 func (m *GoliacRemoteMock) Load(ctx context.Context, continueOnError bool) error {
@@ -122,31 +161,119 @@ func (m *GoliacRemoteMock) TeamRepositories(ctx context.Context) map[string]map[
 func (m *GoliacRemoteMock) AppIds(ctx context.Context) map[string]int {
 	return m.appids
 }
+func (m *GoliacRemoteMock) OrgVariables(ctx context.Context) map[string]*GithubOrgVariable {
+	return m.variables
+}
+func (m *GoliacRemoteMock) OrgSecrets(ctx context.Context) map[string]*GithubOrgSecret {
+	return m.secrets
+}
+func (m *GoliacRemoteMock) OrgSettings(ctx context.Context) map[string]bool {
+	m.settingsLoads++
+	return m.settings
+}
+func (m *GoliacRemoteMock) OrgPushProtectionCustomLink(ctx context.Context) string {
+	return m.pushLink
+}
+func (m *GoliacRemoteMock) OrgDefaultRepositoryPermission(ctx context.Context) string {
+	return m.defaultPermission
+}
+func (m *GoliacRemoteMock) RepositoriesActionsPermissions(ctx context.Context) map[string]*GithubActionsPermissions {
+	return m.actionsPermissions
+}
+func (m *GoliacRemoteMock) RepositoriesCustomProperties(ctx context.Context) map[string]map[string]string {
+	return m.customProperties
+}
+func (m *GoliacRemoteMock) RepositoriesSecurity(ctx context.Context) map[string]*GithubRepositorySecurity {
+	return m.security
+}
+func (m *GoliacRemoteMock) RepositoriesWebhooks(ctx context.Context) map[string]map[string]*GithubWebhook {
+	return m.webhooks
+}
+func (m *GoliacRemoteMock) RepositoriesLabels(ctx context.Context) map[string]map[string]*GithubLabel {
+	return m.labels
+}
+func (m *GoliacRemoteMock) SamlIdentities(ctx context.Context) map[string]string {
+	return m.samlIdentities
+}
+func (m *GoliacRemoteMock) TeamsExternalGroups(ctx context.Context) map[string][]string {
+	return m.externalGroups
+}
+func (m *GoliacRemoteMock) CustomRepositoryRoles(ctx context.Context) map[string]string {
+	return m.customRoles
+}
+func (m *GoliacRemoteMock) CustomPropertiesDefinitions(ctx context.Context) map[string]bool {
+	return m.customPropsDefs
+}
+func (m *GoliacRemoteMock) MembersWithoutTwoFactor(ctx context.Context) []string {
+	return m.membersWithout2FA
+}
+func (m *GoliacRemoteMock) VerifiedDomains(ctx context.Context) []string {
+	return nil
+}
+
+// GoliacRemoteNonEnterpriseMock is a GoliacRemoteMock without rulesets support
+type GoliacRemoteNonEnterpriseMock struct {
+	GoliacRemoteMock
+}
+
+func (m *GoliacRemoteNonEnterpriseMock) IsEnterprise() bool {
+	return false
+}
 
 type ReconciliatorListenerRecorder struct {
 	UsersCreated map[string]string
 	UsersRemoved map[string]string
-
-	TeamsCreated      map[string][]string
-	TeamMemberAdded   map[string][]string
-	TeamMemberRemoved map[string][]string
-	TeamMemberUpdated map[string][]string
-	TeamParentUpdated map[string]*int
-	TeamDeleted       map[string]bool
+	Committed    bool // the changes are only applied on Commit
+
+	TeamsCreated        map[string][]string
+	TeamMemberAdded     map[string][]string
+	TeamMemberRemoved   map[string][]string
+	TeamMemberUpdated   map[string][]string
+	TeamParentUpdated   map[string]*int
+	TeamDeleted         map[string]bool
+	TeamRenamed         map[string]string
+	TeamExtGroups       map[string][]string
+	TeamPropertyUpdated map[string]map[string]string
 
 	RepositoryCreated              map[string]bool
+	RepositoryTemplate             map[string]string
+	RepositoryTransferred          map[string]string
 	RepositoryTeamAdded            map[string][]string
 	RepositoryTeamUpdated          map[string][]string
+	RepositoryTeamPermission       map[string]map[string]string
 	RepositoryTeamRemoved          map[string][]string
 	RepositoriesDeleted            map[string]bool
 	RepositoriesUpdatePrivate      map[string]bool
 	RepositoriesUpdateArchived     map[string]bool
+	RepositoriesUpdateBoolProperty map[string]map[string]bool
+	RepositoriesUpdateProperty     map[string]map[string]string
 	RepositoriesSetExternalUser    map[string]string
 	RepositoriesRemoveExternalUser map[string]bool
+	RepositoriesRequiredSignatures map[string]bool
+	RepositoriesDependabotAlerts   map[string]bool
+	RepositoriesActionsPermissions map[string]GithubActionsPermissions
+	RepositoriesCustomProperties   map[string]map[string]string
+	RepositoriesSecurity           map[string][]string // "feature:enabled" in the order they were applied
+	RepositoryWebhookAdded         map[string][]*GithubWebhook
+	RepositoryWebhookUpdated       map[string][]*GithubWebhook
+	RepositoryWebhookDeleted       map[string][]*GithubWebhook
+	RepositoryLabelCreated         map[string][]*GithubLabel
+	RepositoryLabelUpdated         map[string]map[string]*GithubLabel // key is the repository, second key the current label name
+	RepositoryLabelDeleted         map[string][]string
 
 	RuleSetCreated map[string]*GithubRuleSet
 	RuleSetUpdated map[string]*GithubRuleSet
 	RuleSetDeleted []int
+
+	OrgVariableCreated map[string]*GithubOrgVariable
+	OrgVariableUpdated map[string]*GithubOrgVariable
+	OrgVariableDeleted map[string]bool
+	OrgSecretUpdated   map[string]*GithubOrgSecret
+	OrgSecretDeleted   map[string]bool
+
+	OrgSettingUpdated           map[string]bool
+	OrgPushLinkUpdated          []string
+	OrgDefaultPermissionUpdated []string
 }
 
 func NewReconciliatorListenerRecorder() *ReconciliatorListenerRecorder {
@@ -159,18 +286,43 @@ func NewReconciliatorListenerRecorder() *ReconciliatorListenerRecorder {
 		TeamMemberUpdated:              make(map[string][]string),
 		TeamParentUpdated:              make(map[string]*int),
 		TeamDeleted:                    make(map[string]bool),
+		TeamRenamed:                    make(map[string]string),
+		TeamExtGroups:                  make(map[string][]string),
+		TeamPropertyUpdated:            make(map[string]map[string]string),
 		RepositoryCreated:              make(map[string]bool),
+		RepositoryTemplate:             make(map[string]string),
+		RepositoryTransferred:          make(map[string]string),
 		RepositoryTeamAdded:            make(map[string][]string),
 		RepositoryTeamUpdated:          make(map[string][]string),
+		RepositoryTeamPermission:       make(map[string]map[string]string),
 		RepositoryTeamRemoved:          make(map[string][]string),
 		RepositoriesDeleted:            make(map[string]bool),
 		RepositoriesUpdatePrivate:      make(map[string]bool),
 		RepositoriesUpdateArchived:     make(map[string]bool),
+		RepositoriesUpdateBoolProperty: make(map[string]map[string]bool),
+		RepositoriesUpdateProperty:     make(map[string]map[string]string),
 		RepositoriesSetExternalUser:    make(map[string]string),
 		RepositoriesRemoveExternalUser: make(map[string]bool),
+		RepositoriesRequiredSignatures: make(map[string]bool),
+		RepositoriesDependabotAlerts:   make(map[string]bool),
+		RepositoriesActionsPermissions: make(map[string]GithubActionsPermissions),
+		RepositoriesCustomProperties:   make(map[string]map[string]string),
+		RepositoriesSecurity:           make(map[string][]string),
+		RepositoryWebhookAdded:         make(map[string][]*GithubWebhook),
+		RepositoryWebhookUpdated:       make(map[string][]*GithubWebhook),
+		RepositoryWebhookDeleted:       make(map[string][]*GithubWebhook),
+		RepositoryLabelCreated:         make(map[string][]*GithubLabel),
+		RepositoryLabelUpdated:         make(map[string]map[string]*GithubLabel),
+		RepositoryLabelDeleted:         make(map[string][]string),
 		RuleSetCreated:                 make(map[string]*GithubRuleSet),
 		RuleSetUpdated:                 make(map[string]*GithubRuleSet),
 		RuleSetDeleted:                 make([]int, 0),
+		OrgVariableCreated:             make(map[string]*GithubOrgVariable),
+		OrgVariableUpdated:             make(map[string]*GithubOrgVariable),
+		OrgVariableDeleted:             make(map[string]bool),
+		OrgSecretUpdated:               make(map[string]*GithubOrgSecret),
+		OrgSecretDeleted:               make(map[string]bool),
+		OrgSettingUpdated:              make(map[string]bool),
 	}
 	return &r
 }
@@ -195,17 +347,43 @@ func (r *ReconciliatorListenerRecorder) UpdateTeamUpdateMember(ctx context.Conte
 func (r *ReconciliatorListenerRecorder) UpdateTeamSetParent(ctx context.Context, dryrun bool, teamslug string, parentTeam *int) {
 	r.TeamParentUpdated[teamslug] = parentTeam
 }
+func (r *ReconciliatorListenerRecorder) UpdateTeamRename(ctx context.Context, dryrun bool, teamslug string, newname string) {
+	r.TeamRenamed[teamslug] = newname
+}
+func (r *ReconciliatorListenerRecorder) UpdateTeamUpdateProperty(ctx context.Context, dryrun bool, teamslug string, propertyName string, propertyValue string) {
+	if _, ok := r.TeamPropertyUpdated[teamslug]; !ok {
+		r.TeamPropertyUpdated[teamslug] = make(map[string]string)
+	}
+	r.TeamPropertyUpdated[teamslug][propertyName] = propertyValue
+}
+func (r *ReconciliatorListenerRecorder) UpdateTeamSetExternalGroups(ctx context.Context, dryrun bool, teamslug string, groups []string) {
+	r.TeamExtGroups[teamslug] = groups
+}
 func (r *ReconciliatorListenerRecorder) DeleteTeam(ctx context.Context, dryrun bool, teamslug string) {
 	r.TeamDeleted[teamslug] = true
 }
-func (r *ReconciliatorListenerRecorder) CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool) {
+func (r *ReconciliatorListenerRecorder) CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) {
 	r.RepositoryCreated[reponame] = true
+	if templateRepository != "" {
+		r.RepositoryTemplate[reponame] = templateRepository
+	}
+}
+func (r *ReconciliatorListenerRecorder) TransferRepository(ctx context.Context, dryrun bool, reponame string, source string) {
+	r.RepositoryTransferred[reponame] = source
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) {
 	r.RepositoryTeamAdded[reponame] = append(r.RepositoryTeamAdded[reponame], teamslug)
+	r.recordTeamPermission(reponame, teamslug, permission)
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) {
 	r.RepositoryTeamUpdated[reponame] = append(r.RepositoryTeamUpdated[reponame], teamslug)
+	r.recordTeamPermission(reponame, teamslug, permission)
+}
+func (r *ReconciliatorListenerRecorder) recordTeamPermission(reponame string, teamslug string, permission string) {
+	if r.RepositoryTeamPermission[reponame] == nil {
+		r.RepositoryTeamPermission[reponame] = make(map[string]string)
+	}
+	r.RepositoryTeamPermission[reponame][teamslug] = permission
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositoryRemoveTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string) {
 	r.RepositoryTeamRemoved[reponame] = append(r.RepositoryTeamRemoved[reponame], teamslug)
@@ -215,6 +393,16 @@ func (r *ReconciliatorListenerRecorder) DeleteRepository(ctx context.Context, dr
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
 	r.RepositoriesUpdatePrivate[reponame] = true
+	if _, ok := r.RepositoriesUpdateBoolProperty[reponame]; !ok {
+		r.RepositoriesUpdateBoolProperty[reponame] = make(map[string]bool)
+	}
+	r.RepositoriesUpdateBoolProperty[reponame][propertyName] = propertyValue
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	if _, ok := r.RepositoriesUpdateProperty[reponame]; !ok {
+		r.RepositoriesUpdateProperty[reponame] = make(map[string]string)
+	}
+	r.RepositoriesUpdateProperty[reponame][propertyName] = propertyValue
 }
 func (r *ReconciliatorListenerRecorder) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	r.RepositoriesSetExternalUser[githubid] = permission
@@ -222,6 +410,47 @@ func (r *ReconciliatorListenerRecorder) UpdateRepositorySetExternalUser(ctx cont
 func (r *ReconciliatorListenerRecorder) UpdateRepositoryRemoveExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string) {
 	r.RepositoriesRemoveExternalUser[githubid] = true
 }
+func (r *ReconciliatorListenerRecorder) UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	r.RepositoriesRequiredSignatures[reponame] = enabled
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	r.RepositoriesDependabotAlerts[reponame] = enabled
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryActionsPermissions(ctx context.Context, dryrun bool, reponame string, enabled bool, allowedActions string) {
+	r.RepositoriesActionsPermissions[reponame] = GithubActionsPermissions{Enabled: enabled, AllowedActions: allowedActions}
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositorySetSecurity(ctx context.Context, dryrun bool, reponame string, feature string, enabled bool) {
+	r.RepositoriesSecurity[reponame] = append(r.RepositoriesSecurity[reponame], fmt.Sprintf("%s:%v", feature, enabled))
+}
+func (r *ReconciliatorListenerRecorder) AddRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) {
+	r.RepositoryWebhookAdded[reponame] = append(r.RepositoryWebhookAdded[reponame], webhook)
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) {
+	r.RepositoryWebhookUpdated[reponame] = append(r.RepositoryWebhookUpdated[reponame], webhook)
+}
+func (r *ReconciliatorListenerRecorder) DeleteRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) {
+	r.RepositoryWebhookDeleted[reponame] = append(r.RepositoryWebhookDeleted[reponame], webhook)
+}
+func (r *ReconciliatorListenerRecorder) CreateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, label *GithubLabel) {
+	r.RepositoryLabelCreated[reponame] = append(r.RepositoryLabelCreated[reponame], label)
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string, label *GithubLabel) {
+	if _, ok := r.RepositoryLabelUpdated[reponame]; !ok {
+		r.RepositoryLabelUpdated[reponame] = make(map[string]*GithubLabel)
+	}
+	r.RepositoryLabelUpdated[reponame][labelname] = label
+}
+func (r *ReconciliatorListenerRecorder) DeleteRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string) {
+	r.RepositoryLabelDeleted[reponame] = append(r.RepositoryLabelDeleted[reponame], labelname)
+}
+func (r *ReconciliatorListenerRecorder) UpdateRepositoryCustomProperties(ctx context.Context, dryrun bool, reponame string, properties map[string]string) {
+	if _, ok := r.RepositoriesCustomProperties[reponame]; !ok {
+		r.RepositoriesCustomProperties[reponame] = make(map[string]string)
+	}
+	for k, v := range properties {
+		r.RepositoriesCustomProperties[reponame][k] = v
+	}
+}
 func (r *ReconciliatorListenerRecorder) AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet) {
 	r.RuleSetCreated[ruleset.Name] = ruleset
 }
@@ -231,11 +460,36 @@ func (r *ReconciliatorListenerRecorder) UpdateRuleset(ctx context.Context, dryru
 func (r *ReconciliatorListenerRecorder) DeleteRuleset(ctx context.Context, dryrun bool, rulesetid int) {
 	r.RuleSetDeleted = append(r.RuleSetDeleted, rulesetid)
 }
+func (r *ReconciliatorListenerRecorder) AddOrgVariable(ctx context.Context, dryrun bool, variable *GithubOrgVariable) {
+	r.OrgVariableCreated[variable.Name] = variable
+}
+func (r *ReconciliatorListenerRecorder) UpdateOrgVariable(ctx context.Context, dryrun bool, variable *GithubOrgVariable) {
+	r.OrgVariableUpdated[variable.Name] = variable
+}
+func (r *ReconciliatorListenerRecorder) DeleteOrgVariable(ctx context.Context, dryrun bool, variablename string) {
+	r.OrgVariableDeleted[variablename] = true
+}
+func (r *ReconciliatorListenerRecorder) UpdateOrgSecret(ctx context.Context, dryrun bool, secret *GithubOrgSecret) {
+	r.OrgSecretUpdated[secret.Name] = secret
+}
+func (r *ReconciliatorListenerRecorder) DeleteOrgSecret(ctx context.Context, dryrun bool, secretname string) {
+	r.OrgSecretDeleted[secretname] = true
+}
+func (r *ReconciliatorListenerRecorder) UpdateOrgPushProtectionCustomLink(ctx context.Context, dryrun bool, link string) {
+	r.OrgPushLinkUpdated = append(r.OrgPushLinkUpdated, link)
+}
+func (r *ReconciliatorListenerRecorder) UpdateOrgDefaultRepositoryPermission(ctx context.Context, dryrun bool, permission string) {
+	r.OrgDefaultPermissionUpdated = append(r.OrgDefaultPermissionUpdated, permission)
+}
+func (r *ReconciliatorListenerRecorder) UpdateOrgSetting(ctx context.Context, dryrun bool, settingName string, settingValue bool) {
+	r.OrgSettingUpdated[settingName] = settingValue
+}
 func (r *ReconciliatorListenerRecorder) Begin(dryrun bool) {
 }
 func (r *ReconciliatorListenerRecorder) Rollback(dryrun bool, err error) {
 }
 func (r *ReconciliatorListenerRecorder) Commit(ctx context.Context, dryrun bool) error {
+	r.Committed = true
 	return nil
 }
 
@@ -278,7 +532,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 2 members created
 		assert.Equal(t, 2, len(recorder.TeamsCreated["new"]))
@@ -322,7 +576,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 2 members created
 		assert.Equal(t, 2, len(recorder.TeamsCreated["nouveauté"]))
@@ -384,7 +638,7 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["existing"+config.Config.GoliacTeamOwnerSuffix] = existingowners
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 members added
 		assert.Equal(t, 0, len(recorder.TeamsCreated))
@@ -447,7 +701,7 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["exist-ing"+config.Config.GoliacTeamOwnerSuffix] = existingowners
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 members added
 		ctx := context.TODO()
@@ -495,7 +749,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 2 members created
 		assert.Equal(t, 2, len(recorder.TeamsCreated["new"]))
@@ -533,7 +787,7 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["removing"] = removing
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team deleted
 		assert.Equal(t, 0, len(recorder.TeamDeleted))
@@ -607,7 +861,7 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["childteam"+config.Config.GoliacTeamOwnerSuffix] = childTeamOwners
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 0 parent updated
 		assert.Equal(t, 0, len(recorder.TeamParentUpdated))
@@ -685,7 +939,7 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["childteam"+config.Config.GoliacTeamOwnerSuffix] = childTeamOwners
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team parent updated
 		assert.Equal(t, 1, len(recorder.TeamParentUpdated))
@@ -718,12 +972,103 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["removing"] = removing
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team deleted
 		assert.Equal(t, 1, len(recorder.TeamDeleted))
 	})
 
+	t.Run("happy path: removed team archived by renaming", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := &config.RepositoryConfig{}
+		repoconfig.DestructiveOperations.AllowDestructiveTeams = true
+		repoconfig.ArchiveTeamByRenaming = true
+		repoconfig.ArchivedTeamPrefix = "archived/"
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["removing"] = &GithubTeam{
+			Name:    "removing",
+			Slug:    "removing",
+			Members: []string{"existing_owner"},
+		}
+		// already archived: left untouched
+		remote.teams["archived-old"] = &GithubTeam{
+			Name:    "archived/old",
+			Slug:    "archived-old",
+			Members: []string{"existing_owner"},
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		remote.teamsrepos["removing"] = map[string]*GithubTeamRepo{
+			"myrepo": {Name: "myrepo", Permission: "WRITE"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.TeamDeleted))
+		assert.Equal(t, map[string]string{"removing": "archived/removing"}, recorder.TeamRenamed)
+		assert.Equal(t, map[string][]string{"myrepo": {"removing"}}, recorder.RepositoryTeamRemoved)
+	})
+
+	t.Run("happy path: removed team not archived without destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := &config.RepositoryConfig{}
+		repoconfig.ArchiveTeamByRenaming = true
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["removing"] = &GithubTeam{
+			Name:    "removing",
+			Slug:    "removing",
+			Members: []string{"existing_owner"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.TeamDeleted))
+		assert.Equal(t, 0, len(recorder.TeamRenamed))
+		assert.True(t, unmanaged.Teams["removing"])
+	})
+
 	t.Run("happy path: new repo without owner", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 		repoconf := config.RepositoryConfig{}
@@ -751,12 +1096,101 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo created
 		assert.Equal(t, 1, len(recorder.RepositoryCreated))
 	})
 
+	t.Run("happy path: new repo from a template", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		newRepo := &entity.Repository{}
+		newRepo.Name = "new"
+		newRepo.Spec.Readers = []string{}
+		newRepo.Spec.Writers = []string{}
+		newRepo.Spec.TemplateRepository = "myorg/template"
+		local.repos["new"] = newRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 1, len(recorder.RepositoryCreated))
+		assert.Equal(t, "myorg/template", recorder.RepositoryTemplate["new"])
+	})
+
+	newTemplateReconciliation := func(isTemplate bool) *ReconciliatorListenerRecorder {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		template := &entity.Repository{}
+		template.Name = "template"
+		template.Spec.Readers = []string{}
+		template.Spec.Writers = []string{}
+		local.repos["template"] = template
+		newRepo := &entity.Repository{}
+		newRepo.Name = "new"
+		newRepo.Spec.Readers = []string{}
+		newRepo.Spec.Writers = []string{}
+		newRepo.Spec.TemplateRepository = config.Config.GithubAppOrganization + "/template"
+		local.repos["new"] = newRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["template"] = &GithubRepository{
+			Name:             "template",
+			BoolProperties:   map[string]bool{"private": true, "is_template": isTemplate},
+			StringProperties: map[string]string{"description": "template"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		return recorder
+	}
+
+	t.Run("happy path: new repo from a template of the organization", func(t *testing.T) {
+		recorder := newTemplateReconciliation(true)
+
+		assert.Equal(t, 1, len(recorder.RepositoryCreated))
+		assert.Equal(t, config.Config.GithubAppOrganization+"/template", recorder.RepositoryTemplate["new"])
+	})
+
+	t.Run("not happy path: new repo from a repository that is not a template", func(t *testing.T) {
+		recorder := newTemplateReconciliation(false)
+
+		assert.Equal(t, 0, len(recorder.RepositoryCreated))
+	})
+
 	t.Run("happy path: new repo with owner", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
@@ -799,7 +1233,7 @@ func TestReconciliation(t *testing.T) {
 		remote.teams["existing"] = existing
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo created
 		assert.Equal(t, 1, len(recorder.RepositoryCreated))
@@ -859,7 +1293,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team updated
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -925,7 +1359,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team updated
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1002,7 +1436,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team added
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1083,7 +1517,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team removed
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1153,7 +1587,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 member removed
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1225,7 +1659,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 member removed
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1297,7 +1731,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo updated
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1365,7 +1799,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo updated
 		assert.Equal(t, 1, len(recorder.TeamsCreated)) // the newerTeam-goliac-owners team
@@ -1375,6 +1809,119 @@ func TestReconciliation(t *testing.T) {
 		assert.Equal(t, 1, len(recorder.RepositoryTeamAdded))
 	})
 
+	t.Run("happy path: the owners team of an externally managed team are its owners", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		owner1 := &entity.User{}
+		owner1.Name = "owner1"
+		owner1.Spec.GithubID = "owner1_gh"
+		local.users["owner1"] = owner1
+		owner2 := &entity.User{}
+		owner2.Name = "owner2"
+		owner2.Spec.GithubID = "owner2_gh"
+		local.users["owner2"] = owner2
+
+		external := &entity.Team{}
+		external.Name = "external"
+		external.Spec.ExternallyManaged = true
+		external.Spec.Owners = []string{"owner1", "owner2"}
+		local.teams["external"] = external
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["external"] = &GithubTeam{
+			Name:        "external",
+			Slug:        "external",
+			Members:     []string{"member1", "member2"},
+			Maintainers: []string{"maintainer1"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// the owners team is created with the owners, not with the Github maintainers
+		assert.Equal(t, []string{"owner1_gh", "owner2_gh"}, recorder.TeamsCreated["external"+config.Config.GoliacTeamOwnerSuffix])
+		// the externally managed team is not touched
+		assert.Equal(t, 1, len(recorder.TeamsCreated))
+		assert.Equal(t, 0, len(recorder.TeamMemberAdded))
+		assert.Equal(t, 0, len(recorder.TeamMemberRemoved))
+		assert.Equal(t, 0, len(recorder.TeamMemberUpdated))
+		assert.Equal(t, 0, len(recorder.TeamDeleted))
+	})
+
+	t.Run("happy path: the owners team of an externally managed team is maintained", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		owner1 := &entity.User{}
+		owner1.Name = "owner1"
+		owner1.Spec.GithubID = "owner1_gh"
+		local.users["owner1"] = owner1
+		owner2 := &entity.User{}
+		owner2.Name = "owner2"
+		owner2.Spec.GithubID = "owner2_gh"
+		local.users["owner2"] = owner2
+
+		external := &entity.Team{}
+		external.Name = "external"
+		external.Spec.ExternallyManaged = true
+		external.Spec.Owners = []string{"owner1", "owner2"}
+		local.teams["external"] = external
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		// the Github maintainers of the team are not taken into account
+		remote.teams["external"] = &GithubTeam{
+			Name:        "external",
+			Slug:        "external",
+			Members:     []string{"member2", "member3"},
+			Maintainers: []string{"maintainer1", "maintainer2"},
+		}
+		// member1 is not an owner anymore, owner2 is a new owner
+		remote.teams["external"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{
+			Name:        "external" + config.Config.GoliacTeamOwnerSuffix,
+			Slug:        "external" + config.Config.GoliacTeamOwnerSuffix,
+			Members:     []string{"member1", "owner1_gh"},
+			Maintainers: []string{},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.TeamsCreated))
+		assert.Equal(t, 0, len(recorder.TeamDeleted))
+		assert.Equal(t, map[string][]string{"external" + config.Config.GoliacTeamOwnerSuffix: {"owner2_gh"}}, recorder.TeamMemberAdded)
+		assert.Equal(t, map[string][]string{"external" + config.Config.GoliacTeamOwnerSuffix: {"member1"}}, recorder.TeamMemberRemoved)
+		assert.Equal(t, 0, len(recorder.TeamMemberUpdated))
+	})
+
 	t.Run("happy path: existing repo with new external write collaborator", func(t *testing.T) {
 		recorder := NewReconciliatorListenerRecorder()
 
@@ -1436,7 +1983,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team updated
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1506,7 +2053,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team updated
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1582,7 +2129,7 @@ func TestReconciliation(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 team updated
 		assert.Equal(t, 0, len(recorder.RepositoryCreated))
@@ -1621,7 +2168,7 @@ func TestReconciliation(t *testing.T) {
 		remote.repos["removing"] = removing
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo deleted
 		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
@@ -1657,7 +2204,7 @@ func TestReconciliation(t *testing.T) {
 		remote.repos["removing"] = removing
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo deleted
 		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
@@ -1694,7 +2241,7 @@ func TestReconciliation(t *testing.T) {
 		remote.repos["removing"] = removing
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 repo deleted
 		assert.Equal(t, 1, len(recorder.RepositoriesDeleted))
@@ -1738,7 +2285,7 @@ func TestReconciliationRulesets(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 ruleset created
 		assert.Equal(t, 0, len(recorder.RuleSetCreated))
@@ -1793,7 +2340,7 @@ func TestReconciliationRulesets(t *testing.T) {
 		}
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 ruleset created
 		assert.Equal(t, 1, len(recorder.RuleSetCreated))
@@ -1856,7 +2403,7 @@ func TestReconciliationRulesets(t *testing.T) {
 		remote.rulesets["update"] = rRuleset
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 ruleset created
 		assert.Equal(t, 0, len(recorder.RuleSetCreated))
@@ -1902,11 +2449,4458 @@ func TestReconciliationRulesets(t *testing.T) {
 		remote.rulesets["delete"] = rRuleset
 
 		toArchive := make(map[string]*GithubRepoComparable)
-		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
 
 		// 1 ruleset created
 		assert.Equal(t, 0, len(recorder.RuleSetCreated))
 		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
 		assert.Equal(t, 1, len(recorder.RuleSetDeleted))
 	})
+
+	newRemovedRulesetReconciliation := func(allowRemoved bool) (*ReconciliatorListenerRecorder, *UnmanagedResources) {
+		recorder := NewReconciliatorListenerRecorder()
+
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveRemovedRulesets = allowRemoved
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		// "removed" is still defined, but not referenced in goliac.yaml anymore
+		removed := &entity.RuleSet{}
+		removed.Name = "removed"
+		removed.Spec.Enforcement = "disabled"
+		local.rulesets["removed"] = removed
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.rulesets["removed"] = &GithubRuleSet{
+			Name:        "removed",
+			Id:          1,
+			Enforcement: "disabled",
+			Rules:       make(map[string]entity.RuleSetParameters),
+		}
+		// created manually in Github
+		remote.rulesets["manual"] = &GithubRuleSet{
+			Name:        "manual",
+			Id:          2,
+			Enforcement: "active",
+			Rules:       make(map[string]entity.RuleSetParameters),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+		return recorder, unmanaged
+	}
+
+	t.Run("happy path: removed ruleset declaration deleted", func(t *testing.T) {
+		recorder, unmanaged := newRemovedRulesetReconciliation(true)
+
+		assert.Equal(t, []int{1}, recorder.RuleSetDeleted)
+		// the manual one still follows the rulesets destructive operation
+		assert.Equal(t, map[int]bool{2: true}, unmanaged.RuleSets)
+	})
+
+	t.Run("happy path: removed ruleset declaration kept without the option", func(t *testing.T) {
+		recorder, unmanaged := newRemovedRulesetReconciliation(false)
+
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+		assert.Equal(t, map[int]bool{1: true, 2: true}, unmanaged.RuleSets)
+	})
+
+	newPatternRulesetLocal := func(pattern string) GoliacLocalMock {
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRuleset := &entity.RuleSet{}
+		lRuleset.Name = "pattern"
+		lRuleset.Spec.Enforcement = "active"
+		lRuleset.Spec.Rules = append(lRuleset.Spec.Rules, struct {
+			Ruletype   string
+			Parameters entity.RuleSetParameters
+		}{
+			"commit_message_pattern", entity.RuleSetParameters{
+				Name:     "jira ticket",
+				Operator: "regex",
+				Pattern:  pattern,
+			},
+		})
+		local.rulesets["pattern"] = lRuleset
+		return local
+	}
+	newPatternRulesetRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.rulesets["pattern"] = &GithubRuleSet{
+			Name:        "pattern",
+			Enforcement: "active",
+			Rules: map[string]entity.RuleSetParameters{
+				"commit_message_pattern": {
+					Name:     "jira ticket",
+					Operator: "regex",
+					Pattern:  "^[A-Z]+-[0-9]+ ",
+				},
+			},
+		}
+		return remote
+	}
+	patternRepoconf := func() config.RepositoryConfig {
+		repoconf := config.RepositoryConfig{}
+		repoconf.Rulesets = append(repoconf.Rulesets, struct {
+			Pattern string
+			Ruleset string
+		}{
+			Pattern: ".*",
+			Ruleset: "pattern",
+		})
+		return repoconf
+	}
+
+	t.Run("happy path: commit message pattern ruleset in sync", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newPatternRulesetLocal("^[A-Z]+-[0-9]+ ")
+		remote := newPatternRulesetRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	t.Run("happy path: disable enforcement in sync with the Github disabled one", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newPatternRulesetLocal("^[A-Z]+-[0-9]+ ")
+		local.rulesets["pattern"].Spec.Enforcement = "disable"
+		remote := newPatternRulesetRemote()
+		remote.rulesets["pattern"].Enforcement = "disabled"
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+	})
+
+	t.Run("happy path: commit message pattern changed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newPatternRulesetLocal("^[A-Z]+-[0-9]+: ")
+		remote := newPatternRulesetRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 1, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	newMergeQueueRulesetLocal := func(parameters entity.RuleSetParameters) GoliacLocalMock {
+		local := newPatternRulesetLocal("")
+		local.rulesets["pattern"].Spec.Rules[0].Ruletype = "merge_queue"
+		local.rulesets["pattern"].Spec.Rules[0].Parameters = parameters
+		return local
+	}
+	newMergeQueueRulesetRemote := func() GoliacRemoteMock {
+		remote := newPatternRulesetRemote()
+		// as returned by Github
+		remote.rulesets["pattern"].Rules = map[string]entity.RuleSetParameters{
+			"merge_queue": {
+				MergeMethod:                  "squash",
+				GroupingStrategy:             "allgreen",
+				CheckResponseTimeoutMinutes:  60,
+				MaxEntriesToBuild:            5,
+				MaxEntriesToMerge:            5,
+				MinEntriesToMerge:            1,
+				MinEntriesToMergeWaitMinutes: 5,
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: merge queue ruleset in sync with the Github default values", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newMergeQueueRulesetLocal(entity.RuleSetParameters{
+			MergeMethod:                  "squash",
+			MinEntriesToMerge:            1,
+			MinEntriesToMergeWaitMinutes: 5,
+		})
+		remote := newMergeQueueRulesetRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	t.Run("happy path: merge queue max entries to build changed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newMergeQueueRulesetLocal(entity.RuleSetParameters{
+			MergeMethod:                  "squash",
+			MaxEntriesToBuild:            10,
+			MinEntriesToMerge:            1,
+			MinEntriesToMergeWaitMinutes: 5,
+		})
+		remote := newMergeQueueRulesetRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 1, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	newRepositoryNameRulesetLocal := func() GoliacLocalMock {
+		local := newPatternRulesetLocal("^[A-Z]+-[0-9]+ ")
+		local.repos["sandbox-1"] = &entity.Repository{}
+		local.repos["sandbox-1"].Name = "sandbox-1"
+		local.rulesets["pattern"].Spec.Repositories.Include = []string{"~ALL"}
+		local.rulesets["pattern"].Spec.Repositories.Exclude = []string{"sandbox-*"}
+		return local
+	}
+
+	t.Run("happy path: ruleset on ~ALL minus an excluded pattern in sync", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newRepositoryNameRulesetLocal()
+		remote := newPatternRulesetRemote()
+		remote.rulesets["pattern"].RepositoryNameInclude = []string{"~ALL"}
+		remote.rulesets["pattern"].RepositoryNameExclude = []string{"sandbox-*"}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// the repositories matching the goliac.yaml pattern are not added
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	t.Run("happy path: ruleset moved from repository ids to ~ALL minus an excluded pattern", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newRepositoryNameRulesetLocal()
+		remote := newPatternRulesetRemote()
+		remote.rulesets["pattern"].Repositories = []string{"sandbox-1"}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 1, len(recorder.RuleSetUpdated))
+		updated := recorder.RuleSetUpdated["pattern"]
+		assert.Equal(t, []string{"~ALL"}, updated.RepositoryNameInclude)
+		assert.Equal(t, []string{"sandbox-*"}, updated.RepositoryNameExclude)
+		assert.Equal(t, 0, len(updated.Repositories))
+	})
+
+	newRepositoryPropertyRulesetLocal := func() GoliacLocalMock {
+		local := newPatternRulesetLocal("^[A-Z]+-[0-9]+ ")
+		local.rulesets["pattern"].Spec.Repositories.Properties.Include = []entity.RuleSetRepositoryProperty{
+			{Name: "tier", Values: []string{"1", "2"}},
+		}
+		return local
+	}
+
+	t.Run("happy path: ruleset targeting the repositories of a custom property in sync", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newRepositoryPropertyRulesetLocal()
+		remote := newPatternRulesetRemote()
+		remote.customPropsDefs = map[string]bool{"tier": true}
+		remote.rulesets["pattern"].Repositories = []string{}
+		// the order of the values is not relevant
+		remote.rulesets["pattern"].RepositoryPropertyInclude = []entity.RuleSetRepositoryProperty{
+			{Name: "tier", Values: []string{"2", "1"}},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+		assert.Equal(t, 0, len(recorder.RuleSetDeleted))
+	})
+
+	t.Run("happy path: ruleset moved from repository ids to a custom property", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newRepositoryPropertyRulesetLocal()
+		remote := newPatternRulesetRemote()
+		remote.customPropsDefs = map[string]bool{"tier": true}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(recorder.RuleSetUpdated))
+		updated := recorder.RuleSetUpdated["pattern"]
+		assert.Equal(t, []entity.RuleSetRepositoryProperty{{Name: "tier", Values: []string{"1", "2"}}}, updated.RepositoryPropertyInclude)
+		assert.Equal(t, 0, len(updated.Repositories))
+	})
+
+	t.Run("not happy path: ruleset targeting an undefined custom property", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := patternRepoconf()
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newRepositoryPropertyRulesetLocal()
+		remote := newPatternRulesetRemote()
+		remote.customPropsDefs = map[string]bool{"lifecycle": true}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+	})
+}
+
+func TestReconciliationOrgVariables(t *testing.T) {
+
+	t.Run("happy path: variables not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+			variables: make(map[string]*entity.OrgVariable),
+		}
+		local.variables["FOO"] = &entity.OrgVariable{Name: "FOO", Value: "bar", Visibility: "all"}
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			variables:  make(map[string]*GithubOrgVariable),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgVariableCreated))
+		assert.Equal(t, 0, len(recorder.OrgVariableUpdated))
+		assert.Equal(t, 0, len(recorder.OrgVariableDeleted))
+	})
+
+	t.Run("happy path: variables not loaded are not reconciled", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubVariables: true,
+		}
+		repoconf.DestructiveOperations.AllowDestructiveOrgVariables = true
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+			variables: make(map[string]*entity.OrgVariable),
+		}
+		local.variables["FOO"] = &entity.OrgVariable{Name: "FOO", Value: "bar", Visibility: "all"}
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			variables:  nil,
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.OrgVariableCreated))
+		assert.Equal(t, 0, len(recorder.OrgVariableUpdated))
+		assert.Equal(t, 0, len(recorder.OrgVariableDeleted))
+	})
+
+	t.Run("happy path: new, updated and deleted variables", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubVariables: true,
+		}
+		repoconf.DestructiveOperations.AllowDestructiveOrgVariables = true
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+			variables: make(map[string]*entity.OrgVariable),
+		}
+		local.variables["NEW"] = &entity.OrgVariable{Name: "NEW", Value: "new", Visibility: "all"}
+		local.variables["UPDATED"] = &entity.OrgVariable{Name: "UPDATED", Value: "value", Visibility: "selected", SelectedRepositories: []string{"repo1", "repo2"}}
+		local.variables["SAME"] = &entity.OrgVariable{Name: "SAME", Value: "same", Visibility: "selected", SelectedRepositories: []string{"repo1"}}
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			variables:  make(map[string]*GithubOrgVariable),
+		}
+		remote.variables["UPDATED"] = &GithubOrgVariable{Name: "UPDATED", Value: "value", Visibility: "selected", SelectedRepositories: []string{"repo1"}}
+		remote.variables["SAME"] = &GithubOrgVariable{Name: "SAME", Value: "same", Visibility: "selected", SelectedRepositories: []string{"repo1"}}
+		remote.variables["DELETED"] = &GithubOrgVariable{Name: "DELETED", Value: "deleted", Visibility: "private"}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 1, len(recorder.OrgVariableCreated))
+		assert.NotNil(t, recorder.OrgVariableCreated["NEW"])
+		assert.Equal(t, 1, len(recorder.OrgVariableUpdated))
+		assert.Equal(t, []string{"repo1", "repo2"}, recorder.OrgVariableUpdated["UPDATED"].SelectedRepositories)
+		assert.Equal(t, 1, len(recorder.OrgVariableDeleted))
+		assert.True(t, recorder.OrgVariableDeleted["DELETED"])
+	})
+
+	t.Run("happy path: deleted variables are not removed without destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubVariables: true,
+		}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+			variables: make(map[string]*entity.OrgVariable),
+		}
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			variables:  make(map[string]*GithubOrgVariable),
+		}
+		remote.variables["DELETED"] = &GithubOrgVariable{Name: "DELETED", Value: "deleted", Visibility: "private"}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.OrgVariableDeleted))
+		assert.Equal(t, map[string]bool{"DELETED": true}, unmanaged.OrgVariables)
+	})
+
+	t.Run("happy path: long values are masked in the logs and the plan", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubVariables: true,
+		}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:     make(map[string]*entity.User),
+			teams:     make(map[string]*entity.Team),
+			repos:     make(map[string]*entity.Repository),
+			variables: make(map[string]*entity.OrgVariable),
+		}
+		local.variables["ENDPOINT"] = &entity.OrgVariable{Name: "ENDPOINT", Value: "https://internal.example.com", Visibility: "all"}
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			variables:  make(map[string]*GithubOrgVariable),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		// the real value is applied
+		assert.Equal(t, "https://internal.example.com", recorder.OrgVariableCreated["ENDPOINT"].Value)
+
+		found := false
+		for _, entry := range hook.AllEntries() {
+			assert.NotContains(t, entry.Message, "https://internal.example.com")
+			if entry.Data["command"] == "add_org_variable" {
+				found = true
+				assert.Equal(t, "variable: ENDPOINT value: <masked, 28 characters> visibility: all", entry.Message)
+			}
+		}
+		assert.True(t, found)
+
+		actions := r.PlannedActions()
+		assert.Equal(t, 1, len(actions))
+		assert.Equal(t, "<masked, 28 characters>", actions[0].After.(*GithubOrgVariable).Value)
+	})
+
+	t.Run("happy path: values are shown with ShowVariableValues", func(t *testing.T) {
+		config.Config.ShowVariableValues = true
+		defer func() { config.Config.ShowVariableValues = false }()
+
+		assert.Equal(t, "https://internal.example.com", maskVariableValue("https://internal.example.com"))
+	})
+
+	t.Run("happy path: short values stay readable", func(t *testing.T) {
+		assert.Equal(t, "abc", maskVariableValue("abc"))
+		assert.Equal(t, "12345678", maskVariableValue("12345678"))
+		assert.Equal(t, "<masked, 9 characters>", maskVariableValue("123456789"))
+	})
+
+}
+
+func TestReconciliationOrgSecrets(t *testing.T) {
+
+	newMocks := func() (*GoliacLocalMock, *GoliacRemoteMock) {
+		local := &GoliacLocalMock{
+			users:   make(map[string]*entity.User),
+			teams:   make(map[string]*entity.Team),
+			repos:   make(map[string]*entity.Repository),
+			secrets: make(map[string]*entity.OrgSecret),
+		}
+		local.secrets["UPDATED"] = &entity.OrgSecret{Name: "UPDATED", Visibility: "selected", SelectedRepositories: []string{"repo1", "repo2"}}
+		local.secrets["SAME"] = &entity.OrgSecret{Name: "SAME", Visibility: "all"}
+		local.secrets["MISSING"] = &entity.OrgSecret{Name: "MISSING", Visibility: "private"}
+
+		remote := &GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			secrets:    make(map[string]*GithubOrgSecret),
+		}
+		remote.secrets["UPDATED"] = &GithubOrgSecret{Name: "UPDATED", Visibility: "selected", SelectedRepositories: []string{"repo1"}}
+		remote.secrets["SAME"] = &GithubOrgSecret{Name: "SAME", Visibility: "all", SelectedRepositories: []string{}}
+		remote.secrets["DELETED"] = &GithubOrgSecret{Name: "DELETED", Visibility: "private", SelectedRepositories: []string{}}
+		return local, remote
+	}
+
+	t.Run("happy path: secrets not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgSecretUpdated))
+		assert.Equal(t, 0, len(recorder.OrgSecretDeleted))
+	})
+
+	t.Run("happy path: updated secret, and the missing one is reported", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubSecrets: true,
+		}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(recorder.OrgSecretUpdated))
+		assert.Equal(t, []string{"repo1", "repo2"}, recorder.OrgSecretUpdated["UPDATED"].SelectedRepositories)
+
+		// not deleted: destructive operations are not allowed
+		assert.Equal(t, 0, len(recorder.OrgSecretDeleted))
+		assert.Equal(t, map[string]bool{"DELETED": true}, unmanaged.OrgSecrets)
+
+		found := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel && strings.Contains(entry.Message, "org secret MISSING") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("happy path: secrets not loaded are not reconciled (nor reported)", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubSecrets: true,
+		}
+		repoconf.DestructiveOperations.AllowDestructiveOrgSecrets = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		remote.secrets = nil
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.OrgSecretUpdated))
+		assert.Equal(t, 0, len(recorder.OrgSecretDeleted))
+		for _, entry := range hook.AllEntries() {
+			assert.NotContains(t, entry.Message, "doesn't exist in Github")
+		}
+	})
+
+	t.Run("happy path: undeclared secret deleted", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{
+			ManageGithubSecrets: true,
+		}
+		repoconf.DestructiveOperations.AllowDestructiveOrgSecrets = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(recorder.OrgSecretDeleted))
+		assert.True(t, recorder.OrgSecretDeleted["DELETED"])
+		assert.Equal(t, 0, len(unmanaged.OrgSecrets))
+	})
+}
+
+func TestReconciliationTeamsRepoApprovals(t *testing.T) {
+	newLocal := func() *GoliacLocalMock {
+		return &GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() *GoliacRemoteMock {
+		return &GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     map[string]int{"goliac-app": 1},
+		}
+	}
+
+	t.Run("happy path: the approvals are required by a generated ruleset on the teams repository", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{RequiredApprovals: 2}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		ctx := context.WithValue(context.TODO(), KeyTeamsRepoApp, "goliac-app")
+		_, err := r.Reconciliate(ctx, newLocal(), newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+
+		ruleset := recorder.RuleSetCreated[TEAMS_REPO_APPROVALS_RULESET]
+		assert.NotNil(t, ruleset)
+		assert.Equal(t, []string{"teams"}, ruleset.Repositories)
+		assert.Equal(t, []string{"~DEFAULT_BRANCH"}, ruleset.OnInclude)
+		assert.Equal(t, map[string]string{"goliac-app": "always"}, ruleset.BypassApps)
+		assert.Equal(t, 2, ruleset.Rules["pull_request"].RequiredApprovingReviewCount)
+	})
+
+	t.Run("happy path: no ruleset without required approvals", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		_, err := r.Reconciliate(context.TODO(), newLocal(), newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+	})
+
+	t.Run("happy path: the ruleset is removed when the approvals are not required anymore", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveRemovedRulesets = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		remote := newRemote()
+		remote.rulesets[TEAMS_REPO_APPROVALS_RULESET] = &GithubRuleSet{
+			Name:         TEAMS_REPO_APPROVALS_RULESET,
+			Id:           42,
+			Enforcement:  "active",
+			Rules:        map[string]entity.RuleSetParameters{"pull_request": {RequiredApprovingReviewCount: 2}},
+			Repositories: []string{"teams"},
+		}
+		_, err := r.Reconciliate(context.TODO(), newLocal(), remote, "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []int{42}, recorder.RuleSetDeleted)
+	})
+}
+
+func TestReconciliationRequiredSignatures(t *testing.T) {
+
+	t.Run("happy path: required signatures via a generated ruleset", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		requireSignedCommits := true
+		lRepo.Spec.RequireSignedCommits = &requireSignedCommits
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch: "main",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// the ruleset is used, not the classic branch protection
+		assert.Equal(t, 0, len(recorder.RepositoriesRequiredSignatures))
+		assert.Equal(t, 1, len(recorder.RuleSetCreated))
+		assert.Equal(t, []string{"myrepo"}, recorder.RuleSetCreated[REQUIRED_SIGNATURES_RULESET].Repositories)
+	})
+
+	t.Run("happy path: required signatures from a branch protection template", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		requireSignedCommits := true
+		repoconf := config.RepositoryConfig{
+			BranchProtectionTemplates: map[string]config.BranchProtectionTemplate{
+				"standard": {RequireSignedCommits: &requireSignedCommits},
+			},
+		}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo1 := &entity.Repository{}
+		lRepo1.Name = "myrepo1"
+		lRepo1.Spec.BranchProtectionTemplate = "standard"
+		local.repos["myrepo1"] = lRepo1
+
+		// the repository overrides the template
+		lRepo2 := &entity.Repository{}
+		lRepo2.Name = "myrepo2"
+		lRepo2.Spec.BranchProtectionTemplate = "standard"
+		notRequired := false
+		lRepo2.Spec.RequireSignedCommits = &notRequired
+		local.repos["myrepo2"] = lRepo2
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		for _, reponame := range []string{"myrepo1", "myrepo2"} {
+			remote.repos[reponame] = &GithubRepository{
+				Name: reponame,
+				BoolProperties: map[string]bool{
+					"private": true,
+				},
+				DefaultBranch: "main",
+			}
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 1, len(recorder.RuleSetCreated))
+		assert.Equal(t, []string{"myrepo1"}, recorder.RuleSetCreated[REQUIRED_SIGNATURES_RULESET].Repositories)
+	})
+
+	t.Run("happy path: required signatures already covered by a ruleset", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.Rulesets = append(repoconf.Rulesets, struct {
+			Pattern string
+			Ruleset string
+		}{
+			Pattern: ".*",
+			Ruleset: "signed",
+		})
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		requireSignedCommits := true
+		lRepo.Spec.RequireSignedCommits = &requireSignedCommits
+		local.repos["myrepo"] = lRepo
+
+		lRuleset := &entity.RuleSet{}
+		lRuleset.Name = "signed"
+		lRuleset.Spec.Enforcement = "active"
+		lRuleset.Spec.Rules = append(lRuleset.Spec.Rules, struct {
+			Ruletype   string
+			Parameters entity.RuleSetParameters
+		}{
+			"required_signatures", entity.RuleSetParameters{},
+		})
+		local.rulesets["signed"] = lRuleset
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch: "main",
+		}
+		rRuleset := &GithubRuleSet{
+			Name:         "signed",
+			Enforcement:  "active",
+			Rules:        map[string]entity.RuleSetParameters{"required_signatures": {}},
+			Repositories: []string{"myrepo"},
+		}
+		remote.rulesets["signed"] = rRuleset
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// nothing to do
+		assert.Equal(t, 0, len(recorder.RepositoriesRequiredSignatures))
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+		assert.Equal(t, 0, len(recorder.RuleSetUpdated))
+	})
+
+	t.Run("happy path: required signatures via classic branch protection", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		requireSignedCommits := true
+		lRepo.Spec.RequireSignedCommits = &requireSignedCommits
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteNonEnterpriseMock{
+			GoliacRemoteMock{
+				users:      make(map[string]string),
+				teams:      make(map[string]*GithubTeam),
+				repos:      make(map[string]*GithubRepository),
+				teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+				rulesets:   make(map[string]*GithubRuleSet),
+				appids:     make(map[string]int),
+			},
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch: "main",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 1, len(recorder.RepositoriesRequiredSignatures))
+		assert.True(t, recorder.RepositoriesRequiredSignatures["myrepo"])
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+	})
+
+	t.Run("happy path: classic branch protection already converged", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		requireSignedCommits := true
+		lRepo.Spec.RequireSignedCommits = &requireSignedCommits
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteNonEnterpriseMock{
+			GoliacRemoteMock{
+				users:      make(map[string]string),
+				teams:      make(map[string]*GithubTeam),
+				repos:      make(map[string]*GithubRepository),
+				teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+				rulesets:   make(map[string]*GithubRuleSet),
+				appids:     make(map[string]int),
+			},
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch:          "main",
+			DefaultBranchProtected: true,
+			RequireSignedCommits:   true,
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesRequiredSignatures))
+		assert.Equal(t, 0, len(recorder.RuleSetCreated))
+	})
+
+	t.Run("happy path: classic branch protection not managed when require_signed_commits is not set", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteNonEnterpriseMock{
+			GoliacRemoteMock{
+				users:      make(map[string]string),
+				teams:      make(map[string]*GithubTeam),
+				repos:      make(map[string]*GithubRepository),
+				teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+				rulesets:   make(map[string]*GithubRuleSet),
+				appids:     make(map[string]int),
+			},
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch:          "main",
+			DefaultBranchProtected: true,
+			RequireSignedCommits:   true,
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// the signature protection set on Github is kept
+		assert.Equal(t, 0, len(recorder.RepositoriesRequiredSignatures))
+	})
+}
+
+func TestReconciliationRepositoryProperties(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			StringProperties: map[string]string{
+				"description": "old description",
+				"homepage":    "https://old.example.com",
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: description and homepage drift", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		description := "new description"
+		homepage := "https://new.example.com"
+		lRepo.Spec.Description = &description
+		lRepo.Spec.Homepage = &homepage
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]string{
+			"description": "new description",
+			"homepage":    "https://new.example.com",
+		}, recorder.RepositoriesUpdateProperty["myrepo"])
+	})
+
+	t.Run("happy path: unset properties are not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesUpdateProperty))
+	})
+
+	t.Run("happy path: empty description wipes the remote one", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		description := ""
+		lRepo.Spec.Description = &description
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]string{
+			"description": "",
+		}, recorder.RepositoriesUpdateProperty["myrepo"])
+	})
+
+	t.Run("happy path: homepage set on a new repository", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "newrepo"
+		homepage := "https://new.example.com"
+		lRepo.Spec.Homepage = &homepage
+		local.repos["newrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.True(t, recorder.RepositoryCreated["newrepo"])
+		assert.Equal(t, "https://new.example.com", recorder.RepositoriesUpdateProperty["newrepo"]["homepage"])
+	})
+}
+
+func TestReconciliationOrgSettings(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		return GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			settings: map[string]bool{
+				"members_can_create_pages":         true,
+				"members_can_create_private_pages": true,
+			},
+		}
+	}
+
+	t.Run("happy path: disable members pages creation", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		disabled := false
+		repoconf.OrgSettings.MembersCanCreatePages = &disabled
+		repoconf.OrgSettings.MembersCanCreatePrivatePages = &disabled
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]bool{
+			"members_can_create_pages":         false,
+			"members_can_create_private_pages": false,
+		}, recorder.OrgSettingUpdated)
+	})
+
+	t.Run("happy path: unset and unchanged settings are not updated", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		enabled := true
+		repoconf.OrgSettings.MembersCanCreatePages = &enabled
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgSettingUpdated))
+	})
+
+	t.Run("happy path: org settings are not loaded if none is managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, remote.settingsLoads)
+		assert.Equal(t, 0, len(recorder.OrgSettingUpdated))
+	})
+
+	t.Run("not happy path: destructive operations not allowed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		disabled := false
+		repoconf.OrgSettings.MembersCanCreatePages = &disabled
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgSettingUpdated))
+	})
+
+	t.Run("happy path: report the members without two-factor authentication", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		enabled := true
+		repoconf.OrgSettings.TwoFactorRequirementEnabled = &enabled
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.settings["two_factor_requirement_enabled"] = false
+		remote.membersWithout2FA = []string{"user2", "user3"}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// the two-factor requirement is never updated
+		assert.Equal(t, 0, len(recorder.OrgSettingUpdated))
+
+		found := false
+		for _, entry := range hook.AllEntries() {
+			if strings.Contains(entry.Message, "would remove 2 member(s) without two-factor authentication: user2, user3") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("happy path: two-factor requirement already enabled", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		enabled := true
+		repoconf.OrgSettings.TwoFactorRequirementEnabled = &enabled
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.settings["two_factor_requirement_enabled"] = true
+		remote.membersWithout2FA = []string{"user2"}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgSettingUpdated))
+		for _, entry := range hook.AllEntries() {
+			assert.NotContains(t, entry.Message, "two_factor_requirement_enabled")
+		}
+	})
+
+	t.Run("happy path: set the push protection custom link", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		link := "https://wiki.example.com/secrets"
+		repoconf.OrgSettings.SecretScanningPushProtectionCustomLink = &link
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, []string{"https://wiki.example.com/secrets"}, recorder.OrgPushLinkUpdated)
+		assert.Equal(t, 0, len(recorder.OrgSettingUpdated))
+	})
+
+	t.Run("happy path: push protection custom link unchanged", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		link := "https://wiki.example.com/secrets"
+		repoconf.OrgSettings.SecretScanningPushProtectionCustomLink = &link
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.pushLink = "https://wiki.example.com/secrets"
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgPushLinkUpdated))
+	})
+
+	t.Run("happy path: disable the push protection custom link", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		link := ""
+		repoconf.OrgSettings.SecretScanningPushProtectionCustomLink = &link
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.pushLink = "https://wiki.example.com/secrets"
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, []string{""}, recorder.OrgPushLinkUpdated)
+	})
+
+	t.Run("not happy path: push protection custom link without destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		link := "https://wiki.example.com/secrets"
+		repoconf.OrgSettings.SecretScanningPushProtectionCustomLink = &link
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.OrgPushLinkUpdated))
+	})
+}
+
+func TestDefaultRepositoryPermissionImpact(t *testing.T) {
+
+	newRemote := func() *GoliacRemoteMock {
+		return &GoliacRemoteMock{
+			users: map[string]string{
+				"owner1": "ADMIN",
+				"user1":  "MEMBER",
+				"user2":  "MEMBER",
+			},
+			teams: map[string]*GithubTeam{
+				"team1": {Name: "team1", Slug: "team1", Members: []string{"user1"}},
+			},
+			repos: map[string]*GithubRepository{
+				"repo1": {Name: "repo1", BoolProperties: map[string]bool{"private": true}},
+				"repo2": {Name: "repo2", BoolProperties: map[string]bool{"private": true}},
+				"repo3": {Name: "repo3", BoolProperties: map[string]bool{"private": false}},
+			},
+			teamsrepos: map[string]map[string]*GithubTeamRepo{
+				"team1": {
+					"repo1": {Name: "repo1", Permission: "WRITE"},
+				},
+			},
+			rulesets:          make(map[string]*GithubRuleSet),
+			appids:            make(map[string]int),
+			defaultPermission: "read",
+		}
+	}
+
+	t.Run("happy path: read to write", func(t *testing.T) {
+		remote := NewMutableGoliacRemoteImpl(context.TODO(), newRemote())
+
+		// user1 already writes on repo1 (with team1): only user2 gets more access there
+		impact := defaultRepositoryPermissionImpact(remote, "read", "write")
+		assert.Equal(t, DefaultRepositoryPermissionImpact{Repositories: 3, Members: 2}, impact)
+	})
+
+	t.Run("happy path: none to read doesn't change the public repositories", func(t *testing.T) {
+		remote := NewMutableGoliacRemoteImpl(context.TODO(), newRemote())
+
+		impact := defaultRepositoryPermissionImpact(remote, "none", "read")
+		assert.Equal(t, DefaultRepositoryPermissionImpact{Repositories: 2, Members: 2}, impact)
+	})
+
+	t.Run("happy path: no effective access change", func(t *testing.T) {
+		mock := newRemote()
+		mock.teams["team1"].Members = []string{"user1", "user2"}
+		mock.teamsrepos["team1"] = map[string]*GithubTeamRepo{
+			"repo1": {Name: "repo1", Permission: "ADMIN"},
+			"repo2": {Name: "repo2", Permission: "ADMIN"},
+			"repo3": {Name: "repo3", Permission: "ADMIN"},
+		}
+		remote := NewMutableGoliacRemoteImpl(context.TODO(), mock)
+
+		impact := defaultRepositoryPermissionImpact(remote, "read", "write")
+		assert.Equal(t, DefaultRepositoryPermissionImpact{Repositories: 0, Members: 0}, impact)
+	})
+
+	t.Run("happy path: the impact is reported before the update", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		permission := "write"
+		repoconf.OrgSettings.DefaultRepositoryPermission = &permission
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, []string{"write"}, recorder.OrgDefaultPermissionUpdated)
+		found := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel && entry.Message == "org setting default_repository_permission read -> write changes the access of 2 member(s) on 3 repositories" {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("not happy path: default permission without destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		permission := "write"
+		repoconf.OrgSettings.DefaultRepositoryPermission = &permission
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(recorder.OrgDefaultPermissionUpdated))
+	})
+
+	t.Run("not happy path: invalid default permission", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		permission := "maintain"
+		repoconf.OrgSettings.DefaultRepositoryPermission = &permission
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, toArchive, nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(recorder.OrgDefaultPermissionUpdated))
+	})
+}
+
+func TestReconciliationDependabotAlerts(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DependabotAlerts: false,
+		}
+		return remote
+	}
+
+	t.Run("happy path: enable dependabot alerts", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		enabled := true
+		lRepo.Spec.DependabotAlerts = &enabled
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]bool{"myrepo": true}, recorder.RepositoriesDependabotAlerts)
+	})
+
+	t.Run("happy path: dependabot alerts not set are left untouched", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.repos["myrepo"].DependabotAlerts = true
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesDependabotAlerts))
+	})
+
+	t.Run("happy path: disable dependabot alerts", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		disabled := false
+		lRepo.Spec.DependabotAlerts = &disabled
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.repos["myrepo"].DependabotAlerts = true
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]bool{"myrepo": false}, recorder.RepositoriesDependabotAlerts)
+	})
+}
+
+/*
+ * OrderedReconciliatorListenerRecorder records the order of the rulesets
+ * and required signatures operations
+ */
+type OrderedReconciliatorListenerRecorder struct {
+	ReconciliatorListenerRecorder
+	operations []string
+}
+
+func (r *OrderedReconciliatorListenerRecorder) AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet) {
+	r.operations = append(r.operations, "add_ruleset:"+ruleset.Name)
+	r.ReconciliatorListenerRecorder.AddRuleset(ctx, dryrun, ruleset)
+}
+func (r *OrderedReconciliatorListenerRecorder) UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	r.operations = append(r.operations, fmt.Sprintf("required_signatures:%s:%v", reponame, enabled))
+	r.ReconciliatorListenerRecorder.UpdateRepositorySetRequiredSignatures(ctx, dryrun, reponame, enabled)
+}
+
+func TestReconciliationSignaturesMigration(t *testing.T) {
+
+	t.Run("happy path: ruleset created before removing the classic protection", func(t *testing.T) {
+		recorder := &OrderedReconciliatorListenerRecorder{
+			ReconciliatorListenerRecorder: *NewReconciliatorListenerRecorder(),
+		}
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		requireSignedCommits := true
+		lRepo.Spec.RequireSignedCommits = &requireSignedCommits
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch:          "main",
+			DefaultBranchProtected: true,
+			RequireSignedCommits:   true,
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, []string{
+			"add_ruleset:" + REQUIRED_SIGNATURES_RULESET,
+			"required_signatures:myrepo:false",
+		}, recorder.operations)
+	})
+
+	t.Run("happy path: classic protection kept if no ruleset covers the repository", func(t *testing.T) {
+		recorder := &OrderedReconciliatorListenerRecorder{
+			ReconciliatorListenerRecorder: *NewReconciliatorListenerRecorder(),
+		}
+		repoconf := config.RepositoryConfig{}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private": true,
+			},
+			DefaultBranch:          "main",
+			DefaultBranchProtected: true,
+			RequireSignedCommits:   true,
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.operations))
+	})
+}
+
+func TestReconciliationExemptMembers(t *testing.T) {
+
+	t.Run("happy path: exempt members are not removed from the org", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveUsers = true
+		repoconf.ExemptMembers = []string{"ceo", "Contractor"}
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lUser := &entity.User{}
+		lUser.Name = "alice"
+		lUser.Spec.GithubID = "alice"
+		local.users["alice"] = lUser
+
+		remote := GoliacRemoteMock{
+			users: map[string]string{
+				"alice":      "MEMBER",
+				"ceo":        "MEMBER",
+				"contractor": "MEMBER",
+				"bob":        "MEMBER",
+			},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		// only bob is removed
+		assert.Equal(t, map[string]string{"bob": "bob"}, recorder.UsersRemoved)
+		assert.Equal(t, 0, len(unmanaged.Users))
+	})
+}
+
+func TestReconciliationPlannedActions(t *testing.T) {
+
+	t.Run("happy path: new team is planned", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		newTeam := &entity.Team{}
+		newTeam.Name = "new"
+		newTeam.Spec.Owners = []string{"new.owner"}
+		local.teams["new"] = newTeam
+
+		newOwner := entity.User{}
+		newOwner.Name = "new.owner"
+		newOwner.Spec.GithubID = "new_owner"
+		local.users["new.owner"] = &newOwner
+
+		remote := GoliacRemoteMock{
+			users:      map[string]string{"new_owner": "new_owner"},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		// the team and its owners team (in any order)
+		actions := r.PlannedActions()
+		assert.Equal(t, 2, len(actions))
+		var newTeamAction *PlannedAction
+		for i := range actions {
+			assert.Equal(t, "create_team", actions[i].Operation)
+			if actions[i].Target == "team/new" {
+				newTeamAction = &actions[i]
+			}
+		}
+		assert.NotNil(t, newTeamAction)
+		assert.Nil(t, newTeamAction.Before)
+	})
+
+	t.Run("happy path: before and after values of an org setting", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		disabled := false
+		repoconf.OrgSettings.MembersCanCreatePages = &disabled
+		repoconf.DestructiveOperations.AllowDestructiveOrgSettings = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+			settings:   map[string]bool{"members_can_create_pages": true},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, []PlannedAction{
+			{
+				Operation: "update_org_setting",
+				Target:    "org_setting/members_can_create_pages",
+				Before:    true,
+				After:     false,
+				Reason:    "the Github value differs from goliac.yaml",
+			},
+		}, r.PlannedActions())
+	})
+
+	t.Run("happy path: no change means no planned action", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, 0, len(r.PlannedActions()))
+	})
+
+	t.Run("happy path: only the deletions and archivals are destructive", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.ArchiveOnDelete = true
+		repoconf.DestructiveOperations.AllowDestructiveRepositories = true
+		repoconf.DestructiveOperations.AllowDestructiveTeams = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		newTeam := &entity.Team{}
+		newTeam.Name = "new"
+		local.teams["new"] = newTeam
+
+		remote := GoliacRemoteMock{
+			users: make(map[string]string),
+			teams: map[string]*GithubTeam{
+				"removed": {Name: "removed", Slug: "removed", Members: []string{}},
+			},
+			repos: map[string]*GithubRepository{
+				"oldrepo": {Name: "oldrepo", BoolProperties: map[string]bool{"archived": false}},
+			},
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		// creating the new team (and its owners team) is not destructive
+		assert.Equal(t, 4, len(r.PlannedActions()))
+		destructive := DestructiveActions(r.PlannedActions(), nil)
+		assert.Equal(t, 2, len(destructive))
+		operations := map[string]string{}
+		for _, action := range destructive {
+			operations[action.Target] = action.Operation
+		}
+		assert.Equal(t, map[string]string{
+			"team/removed":                "delete_team",
+			"repository/oldrepo/archived": "update_repository_update_bool_property",
+		}, operations)
+	})
+
+	t.Run("happy path: the deletions not allowed are blocked", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.ArchiveOnDelete = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		remote := GoliacRemoteMock{
+			users: make(map[string]string),
+			teams: map[string]*GithubTeam{
+				"removed": {Name: "removed", Slug: "removed", Members: []string{}},
+			},
+			repos: map[string]*GithubRepository{
+				"oldrepo": {Name: "oldrepo", BoolProperties: map[string]bool{"archived": false}},
+			},
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, []PlannedAction{
+			{Operation: "blocked", Target: "repository/oldrepo", Reason: "the destructive operations are not allowed in goliac.yaml (destructive_operations)"},
+			{Operation: "blocked", Target: "team/removed", Reason: "the destructive operations are not allowed in goliac.yaml (destructive_operations)"},
+		}, DestructiveActions(r.PlannedActions(), unmanaged))
+	})
+
+	t.Run("happy path: applied changes are counted in the metrics", func(t *testing.T) {
+		reconciliate := func(dryrun bool) {
+			recorder := NewReconciliatorListenerRecorder()
+			repoconf := config.RepositoryConfig{}
+			r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+			local := GoliacLocalMock{
+				users: make(map[string]*entity.User),
+				teams: make(map[string]*entity.Team),
+				repos: make(map[string]*entity.Repository),
+			}
+			lRepo := &entity.Repository{}
+			lRepo.Name = "myrepo"
+			local.repos["myrepo"] = lRepo
+
+			remote := GoliacRemoteMock{
+				users:      make(map[string]string),
+				teams:      make(map[string]*GithubTeam),
+				repos:      make(map[string]*GithubRepository),
+				teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+				rulesets:   make(map[string]*GithubRuleSet),
+				appids:     make(map[string]int),
+			}
+
+			toArchive := make(map[string]*GithubRepoComparable)
+			_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", dryrun, toArchive, nil)
+			assert.Nil(t, err)
+		}
+
+		created := metrics.ResourcesChangesTotal.Value("created")
+		reconciliate(true)
+		assert.Equal(t, created, metrics.ResourcesChangesTotal.Value("created"))
+		reconciliate(false)
+		assert.Equal(t, created+1, metrics.ResourcesChangesTotal.Value("created"))
+	})
+}
+
+/*
+ * PropertiesOrderedReconciliatorListenerRecorder records the order of
+ * the repositories properties updates
+ */
+type PropertiesOrderedReconciliatorListenerRecorder struct {
+	ReconciliatorListenerRecorder
+	operations []string
+}
+
+func (r *PropertiesOrderedReconciliatorListenerRecorder) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
+	r.operations = append(r.operations, fmt.Sprintf("%s:%s:%v", propertyName, reponame, propertyValue))
+	r.ReconciliatorListenerRecorder.UpdateRepositoryUpdateBoolProperty(ctx, dryrun, reponame, propertyName, propertyValue)
+}
+func (r *PropertiesOrderedReconciliatorListenerRecorder) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	r.operations = append(r.operations, fmt.Sprintf("%s:%s:%s", propertyName, reponame, propertyValue))
+	r.ReconciliatorListenerRecorder.UpdateRepositoryUpdateProperty(ctx, dryrun, reponame, propertyName, propertyValue)
+}
+
+func TestReconciliationArchivedOrdering(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		return GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+	}
+
+	t.Run("happy path: unarchive before updating the other properties", func(t *testing.T) {
+		recorder := &PropertiesOrderedReconciliatorListenerRecorder{
+			ReconciliatorListenerRecorder: *NewReconciliatorListenerRecorder(),
+		}
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.AllowAutoMerge = true
+		lRepo.Spec.DeleteBranchOnMerge = true
+		description := "my repository"
+		lRepo.Spec.Description = &description
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:                "myrepo",
+			RequireStatusChecks: true,
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               true,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 4, len(recorder.operations))
+		assert.Equal(t, "archived:myrepo:false", recorder.operations[0])
+		assert.ElementsMatch(t, []string{
+			"allow_auto_merge:myrepo:true",
+			"delete_branch_on_merge:myrepo:true",
+			"description:myrepo:my repository",
+		}, recorder.operations[1:])
+	})
+
+	t.Run("happy path: archive after updating the other properties", func(t *testing.T) {
+		recorder := &PropertiesOrderedReconciliatorListenerRecorder{
+			ReconciliatorListenerRecorder: *NewReconciliatorListenerRecorder(),
+		}
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Archived = true
+		lRepo.Spec.AllowAutoMerge = true
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:                "myrepo",
+			RequireStatusChecks: true,
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, []string{
+			"allow_auto_merge:myrepo:true",
+			"archived:myrepo:true",
+		}, recorder.operations)
+	})
+}
+
+func TestReconciliationActionsPermissions(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: restrict the allowed actions", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.ActionsPermissions = &entity.RepositoryActionsPermissions{
+			Enabled:        true,
+			AllowedActions: "local_only",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.actionsPermissions = map[string]*GithubActionsPermissions{
+			"myrepo": {Enabled: true, AllowedActions: "all"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]GithubActionsPermissions{
+			"myrepo": {Enabled: true, AllowedActions: "local_only"},
+		}, recorder.RepositoriesActionsPermissions)
+	})
+
+	t.Run("happy path: Actions already disabled", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.ActionsPermissions = &entity.RepositoryActionsPermissions{
+			Enabled:        false,
+			AllowedActions: "all",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.actionsPermissions = map[string]*GithubActionsPermissions{
+			"myrepo": {Enabled: false},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesActionsPermissions))
+	})
+
+	t.Run("happy path: disable Actions", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.ActionsPermissions = &entity.RepositoryActionsPermissions{
+			Enabled: false,
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.actionsPermissions = map[string]*GithubActionsPermissions{
+			"myrepo": {Enabled: true, AllowedActions: "all"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]GithubActionsPermissions{
+			"myrepo": {Enabled: false},
+		}, recorder.RepositoriesActionsPermissions)
+	})
+
+	t.Run("happy path: not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.actionsPermissions = map[string]*GithubActionsPermissions{
+			"myrepo": {Enabled: true, AllowedActions: "all"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesActionsPermissions))
+	})
+
+	t.Run("not happy path: actions permissions not loaded from Github", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.ActionsPermissions = &entity.RepositoryActionsPermissions{
+			Enabled:        true,
+			AllowedActions: "local_only",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.actionsPermissions = map[string]*GithubActionsPermissions{}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesActionsPermissions))
+	})
+}
+
+func TestReconciliationCustomProperties(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		remote.customProperties = map[string]map[string]string{
+			"myrepo": {
+				"data-classification": "internal",
+				"team-owner":          "foo",
+				"cost-center":         "42",
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: only the changed properties are updated", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.CustomProperties = map[string]string{
+			"data-classification": "confidential",
+			"team-owner":          "foo",
+			"tier":                "1",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// cost-center is not unset: manage_github_repository_custom_properties is off
+		assert.Equal(t, map[string]map[string]string{
+			"myrepo": {
+				"data-classification": "confidential",
+				"tier":                "1",
+			},
+		}, recorder.RepositoriesCustomProperties)
+	})
+
+	t.Run("happy path: unset a property with an empty value", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.CustomProperties = map[string]string{
+			"team-owner": "",
+			"tier":       "",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]map[string]string{
+			"myrepo": {
+				"team-owner": "",
+			},
+		}, recorder.RepositoriesCustomProperties)
+	})
+
+	t.Run("happy path: unset the properties not defined locally", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.ManageGithubRepositoryCustomProperties = true
+		repoconf.DestructiveOperations.AllowDestructiveCustomProperties = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.CustomProperties = map[string]string{
+			"team-owner": "foo",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]map[string]string{
+			"myrepo": {
+				"data-classification": "",
+				"cost-center":         "",
+			},
+		}, recorder.RepositoriesCustomProperties)
+	})
+
+	t.Run("happy path: the properties not defined locally are not unset without the custom_properties destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.ManageGithubRepositoryCustomProperties = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.CustomProperties = map[string]string{
+			"team-owner": "bar",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		// the declared properties are still updated
+		assert.Equal(t, map[string]map[string]string{
+			"myrepo": {
+				"team-owner": "bar",
+			},
+		}, recorder.RepositoriesCustomProperties)
+		assert.Equal(t, map[string]bool{
+			"myrepo/custom_property/cost-center":         true,
+			"myrepo/custom_property/data-classification": true,
+		}, unmanaged.CustomProperties)
+
+		blocked := map[string]string{}
+		for _, a := range DestructiveActions(r.PlannedActions(), unmanaged) {
+			blocked[a.Target] = a.Operation
+		}
+		assert.Equal(t, "blocked", blocked["repository/myrepo/custom_property/cost-center"])
+	})
+
+	t.Run("happy path: not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesCustomProperties))
+	})
+
+	t.Run("happy path: new repository", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "newrepo"
+		lRepo.Spec.CustomProperties = map[string]string{
+			"team-owner": "foo",
+			"tier":       "",
+		}
+		local.repos["newrepo"] = lRepo
+		existing := &entity.Repository{}
+		existing.Name = "myrepo"
+		local.repos["myrepo"] = existing
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.True(t, recorder.RepositoryCreated["newrepo"])
+		assert.Equal(t, map[string]map[string]string{
+			"newrepo": {
+				"team-owner": "foo",
+			},
+		}, recorder.RepositoriesCustomProperties)
+	})
+	t.Run("not happy path: unknown property definitions are ignored with a warning", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.CustomProperties = map[string]string{
+			"data-classification": "confidential",
+			"data-clasification":  "public",
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.customPropsDefs = map[string]bool{
+			"data-classification": true,
+			"team-owner":          true,
+			"cost-center":         true,
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]map[string]string{
+			"myrepo": {
+				"data-classification": "confidential",
+			},
+		}, recorder.RepositoriesCustomProperties)
+		warnings := 0
+		for _, e := range hook.AllEntries() {
+			if e.Level == logrus.WarnLevel {
+				assert.Equal(t, "repository myrepo: custom property data-clasification is not defined at the organization level, ignoring it", e.Message)
+				warnings++
+			}
+		}
+		assert.Equal(t, 1, warnings)
+	})
+}
+
+func TestReconciliationSecurity(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		remote.security = map[string]*GithubRepositorySecurity{
+			"myrepo": {DependabotSecurityUpdates: true},
+		}
+		return remote
+	}
+	enabled := true
+	disabled := false
+
+	t.Run("happy path: enable secret scanning and push protection (in order)", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Security = &entity.RepositorySecurity{
+			SecretScanningPushProtection: &enabled,
+			SecretScanning:               &enabled,
+			DependabotSecurityUpdates:    &enabled,
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string][]string{
+			"myrepo": {"secret_scanning:true", "secret_scanning_push_protection:true"},
+		}, recorder.RepositoriesSecurity)
+	})
+
+	t.Run("happy path: vulnerability alerts are the dependabot alerts", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Security = &entity.RepositorySecurity{
+			VulnerabilityAlerts:       &enabled,
+			DependabotSecurityUpdates: &disabled,
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]bool{"myrepo": true}, recorder.RepositoriesDependabotAlerts)
+		assert.Equal(t, map[string][]string{
+			"myrepo": {"dependabot_security_updates:false"},
+		}, recorder.RepositoriesSecurity)
+	})
+
+	t.Run("happy path: not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Security = &entity.RepositorySecurity{}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesSecurity))
+		assert.Equal(t, 0, len(recorder.RepositoriesDependabotAlerts))
+	})
+
+	t.Run("not happy path: security settings not loaded from Github", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Security = &entity.RepositorySecurity{
+			SecretScanning:            &enabled,
+			DependabotSecurityUpdates: &enabled,
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+		remote.security = map[string]*GithubRepositorySecurity{}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesSecurity))
+	})
+}
+
+func TestReconciliationWebhooks(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		remote.webhooks = map[string]map[string]*GithubWebhook{
+			"myrepo": {
+				"https://ci.example.com/hook":   {Id: 1, URL: "https://ci.example.com/hook", Events: []string{"push"}, ContentType: "form", Active: true},
+				"https://old.example.com/hook":  {Id: 2, URL: "https://old.example.com/hook", Events: []string{"push"}, ContentType: "form", Active: true},
+				"https://chat.example.com/hook": {Id: 3, URL: "https://chat.example.com/hook", Events: []string{"pull_request", "push"}, ContentType: "json", Active: true},
+			},
+		}
+		return remote
+	}
+	disabled := false
+
+	t.Run("happy path: add, update and remove webhooks", func(t *testing.T) {
+		t.Setenv("NEW_WEBHOOK_SECRET", "s3cr3t")
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveWebhooks = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Webhooks = []entity.RepositoryWebhook{
+			// unchanged (Github default values)
+			{URL: "https://ci.example.com/hook"},
+			// events in another order, but disabled
+			{URL: "https://chat.example.com/hook", Events: []string{"push", "pull_request"}, ContentType: "json", Active: &disabled},
+			{URL: "https://new.example.com/hook", Events: []string{"release"}, ContentType: "json", SecretEnv: "NEW_WEBHOOK_SECRET"},
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string][]*GithubWebhook{
+			"myrepo": {{URL: "https://new.example.com/hook", Events: []string{"release"}, ContentType: "json", Active: true, Secret: "s3cr3t"}},
+		}, recorder.RepositoryWebhookAdded)
+		assert.Equal(t, map[string][]*GithubWebhook{
+			"myrepo": {{Id: 3, URL: "https://chat.example.com/hook", Events: []string{"push", "pull_request"}, ContentType: "json", Active: false}},
+		}, recorder.RepositoryWebhookUpdated)
+		assert.Equal(t, 1, len(recorder.RepositoryWebhookDeleted["myrepo"]))
+		assert.Equal(t, 2, recorder.RepositoryWebhookDeleted["myrepo"][0].Id)
+	})
+
+	t.Run("not happy path: webhooks not removed without destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Webhooks = []entity.RepositoryWebhook{
+			{URL: "https://ci.example.com/hook"},
+			{URL: "https://chat.example.com/hook", Events: []string{"push", "pull_request"}, ContentType: "json"},
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(recorder.RepositoryWebhookDeleted))
+		assert.Equal(t, map[string]bool{"myrepo/webhook/https://old.example.com/hook": true}, unmanaged.Webhooks)
+	})
+
+	t.Run("happy path: webhooks not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoryWebhookAdded))
+		assert.Equal(t, 0, len(recorder.RepositoryWebhookUpdated))
+		assert.Equal(t, 0, len(recorder.RepositoryWebhookDeleted))
+	})
+
+	t.Run("happy path: an empty list removes all the webhooks", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveWebhooks = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Webhooks = []entity.RepositoryWebhook{}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 3, len(recorder.RepositoryWebhookDeleted["myrepo"]))
+	})
+
+	t.Run("happy path: webhooks of a new repository", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "newrepo"
+		lRepo.Spec.Webhooks = []entity.RepositoryWebhook{
+			{URL: "https://ci.example.com/hook"},
+		}
+		local.repos["newrepo"] = lRepo
+
+		remote := newRemote()
+		remote.repos = make(map[string]*GithubRepository)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, true, recorder.RepositoryCreated["newrepo"])
+		assert.Equal(t, map[string][]*GithubWebhook{
+			"newrepo": {{URL: "https://ci.example.com/hook", Events: []string{"push"}, ContentType: "form", Active: true}},
+		}, recorder.RepositoryWebhookAdded)
+	})
+
+	t.Run("happy path: the secret is never logged in dryrun", func(t *testing.T) {
+		t.Setenv("CI_WEBHOOK_SECRET", "s3cr3t")
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Webhooks = []entity.RepositoryWebhook{
+			{URL: "https://ci.example.com/hook", ContentType: "json", SecretEnv: "CI_WEBHOOK_SECRET"},
+			{URL: "https://new.example.com/hook", SecretEnv: "CI_WEBHOOK_SECRET"},
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, 1, len(recorder.RepositoryWebhookAdded["myrepo"]))
+		assert.Equal(t, 1, len(recorder.RepositoryWebhookUpdated["myrepo"]))
+		for _, entry := range hook.AllEntries() {
+			assert.NotContains(t, entry.Message, "s3cr3t")
+		}
+		assert.NotContains(t, fmt.Sprintf("%+v", r.PlannedActions()), "s3cr3t")
+	})
+}
+
+func TestReconciliationSamlIdentities(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		for _, name := range []string{"linked", "unlinked", "external"} {
+			user := &entity.User{}
+			user.Name = name
+			user.Spec.GithubID = name + "_githubid"
+			local.users[name] = user
+		}
+		team1 := &entity.Team{}
+		team1.Name = "team1"
+		team1.Spec.Owners = []string{"linked"}
+		team1.Spec.Members = []string{"unlinked"}
+		local.teams["team1"] = team1
+		team2 := &entity.Team{}
+		team2.Name = "team2"
+		team2.Spec.Owners = []string{"unlinked"}
+		local.teams["team2"] = team2
+		team3 := &entity.Team{}
+		team3.Name = "team3"
+		team3.Spec.ExternallyManaged = true
+		team3.Spec.Members = []string{"external"}
+		local.teams["team3"] = team3
+		return local
+	}
+	newRemote := func() GoliacRemoteMock {
+		return GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+	}
+	warnings := func(hook *logrustest.Hook) []string {
+		warnings := make([]string, 0)
+		for _, e := range hook.AllEntries() {
+			if e.Level == logrus.WarnLevel {
+				warnings = append(warnings, e.Message)
+			}
+		}
+		return warnings
+	}
+
+	t.Run("not happy path: team member without a SAML identity is flagged", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.samlIdentities = map[string]string{
+			"linked_githubid": "linked@example.com",
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, []string{
+			"user unlinked_githubid (member of team1, team2) doesn't have a linked SAML identity: Github will not be able to add it to the team(s)",
+		}, warnings(hook))
+	})
+
+	t.Run("happy path: organization without SAML", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, 0, len(warnings(hook)))
+	})
+}
+
+func TestReconciliationCodeownersTeamsAccess(t *testing.T) {
+
+	t.Run("happy path: teams without access", func(t *testing.T) {
+		repo := &GithubRepoComparable{
+			Readers: []string{"reader"},
+			Writers: []string{"writer"},
+		}
+		missing := codeownersTeamsWithoutAccess(repo, []string{"writer", "reader", "other", "admin"})
+		assert.Equal(t, []string{"admin", "other"}, missing)
+	})
+
+	newLocal := func() GoliacLocalMock {
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		admin := &entity.Team{}
+		admin.Name = "admin"
+		local.teams["admin"] = admin
+		reviewers := &entity.Team{}
+		reviewers.Name = "reviewers"
+		local.teams["reviewers"] = reviewers
+		return local
+	}
+	newRemote := func() GoliacRemoteMock {
+		return GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+	}
+	codeownersWarnings := func(hook *logrustest.Hook) []string {
+		warnings := make([]string, 0)
+		for _, e := range hook.AllEntries() {
+			if e.Level == logrus.WarnLevel {
+				warnings = append(warnings, e.Message)
+			}
+		}
+		return warnings
+	}
+
+	t.Run("not happy path: admin team referenced in CODEOWNERS without access", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{AdminTeam: "admin"}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "teams"
+		local.repos["teams"] = lRepo
+
+		remote := newRemote()
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, []string{
+			"team admin is referenced in the CODEOWNERS file of the repository teams but doesn't have access to it: the CODEOWNERS rule is ineffective",
+		}, codeownersWarnings(hook))
+	})
+
+	t.Run("happy path: admin team referenced in CODEOWNERS as a reader", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{AdminTeam: "admin"}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "teams"
+		lRepo.Spec.Readers = []string{"admin"}
+		local.repos["teams"] = lRepo
+
+		remote := newRemote()
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+
+		assert.Equal(t, 0, len(codeownersWarnings(hook)))
+	})
+}
+
+func TestReconciliationLabels(t *testing.T) {
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	bugDescription := "Something isn't working"
+	emptyDescription := ""
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		remote.labels = map[string]map[string]*GithubLabel{
+			"myrepo": {
+				"bug":         {Name: "bug", Color: "D73A4A", Description: &bugDescription},
+				"enhancement": {Name: "enhancement", Color: "a2eeef", Description: &emptyDescription},
+				"wontfix":     {Name: "wontfix", Color: "ffffff", Description: &emptyDescription},
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: create and update labels", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		newDescription := "New feature or request"
+		lRepo.Spec.Labels = []entity.RepositoryLabel{
+			// unchanged (the color is case insensitive, the description is not managed)
+			{Name: "bug", Color: "d73a4a"},
+			{Name: "Enhancement", Color: "a2eeef", Description: &newDescription},
+			{Name: "triage", Color: "ededed"},
+		}
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string][]*GithubLabel{
+			"myrepo": {{Name: "triage", Color: "ededed"}},
+		}, recorder.RepositoryLabelCreated)
+		assert.Equal(t, map[string]map[string]*GithubLabel{
+			"myrepo": {"enhancement": {Name: "Enhancement", Color: "a2eeef", Description: &newDescription}},
+		}, recorder.RepositoryLabelUpdated)
+		// not managed: the other labels are kept
+		assert.Equal(t, 0, len(recorder.RepositoryLabelDeleted))
+	})
+
+	t.Run("happy path: managed labels are deleted", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveRepositoryLabels = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Labels = []entity.RepositoryLabel{
+			{Name: "bug", Color: "d73a4a"},
+		}
+		lRepo.Spec.LabelsManaged = true
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoryLabelCreated))
+		assert.Equal(t, 0, len(recorder.RepositoryLabelUpdated))
+		assert.Equal(t, map[string][]string{
+			"myrepo": {"enhancement", "wontfix"},
+		}, recorder.RepositoryLabelDeleted)
+	})
+
+	t.Run("happy path: managed labels are not deleted without the labels destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.Labels = []entity.RepositoryLabel{
+			{Name: "bug", Color: "d73a4a"},
+		}
+		lRepo.Spec.LabelsManaged = true
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.RepositoryLabelDeleted))
+		assert.Equal(t, map[string]bool{
+			"myrepo/label/enhancement": true,
+			"myrepo/label/wontfix":     true,
+		}, unmanaged.Labels)
+
+		blocked := map[string]string{}
+		for _, a := range DestructiveActions(r.PlannedActions(), unmanaged) {
+			blocked[a.Target] = a.Operation
+		}
+		assert.Equal(t, "blocked", blocked["repository/myrepo/label/enhancement"])
+		assert.Equal(t, "blocked", blocked["repository/myrepo/label/wontfix"])
+	})
+
+	t.Run("happy path: labels not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoryLabelCreated))
+		assert.Equal(t, 0, len(recorder.RepositoryLabelUpdated))
+		assert.Equal(t, 0, len(recorder.RepositoryLabelDeleted))
+	})
+}
+
+func TestReconciliationMergeMethods(t *testing.T) {
+	disabled := false
+	enabled := true
+
+	newLocal := func() GoliacLocalMock {
+		return GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+	}
+	newRemote := func() GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+				"allow_merge_commit":     true,
+				"allow_squash_merge":     true,
+				"allow_rebase_merge":     true,
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: the organization policy is enforced", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.MergeMethods.AllowMergeCommit = &disabled
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.AllowRebaseMerge = &disabled
+		lRepo.Spec.AllowSquashMerge = &enabled
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		// the repository doesn't set allow_merge_commit, but it is disabled at the organization level
+		assert.Equal(t, map[string]bool{"allow_merge_commit": false, "allow_rebase_merge": false}, recorder.RepositoriesUpdateBoolProperty["myrepo"])
+	})
+
+	t.Run("happy path: merge methods not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		local.repos["myrepo"] = lRepo
+
+		remote := newRemote()
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesUpdateBoolProperty))
+	})
+}
+
+func TestReconciliationTransferRepository(t *testing.T) {
+
+	t.Run("happy path: transfer a repository and apply the teams access", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lTeam := &entity.Team{}
+		lTeam.Name = "team1"
+		lTeam.Spec.Owners = []string{"user1"}
+		local.teams["team1"] = lTeam
+		lRepo := &entity.Repository{}
+		lRepo.Name = "newrepo"
+		lRepo.Owner = &lTeam.Name
+		lRepo.Spec.TransferFrom = "someuser/repo1"
+		local.repos["newrepo"] = lRepo
+
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["team1"] = &GithubTeam{Name: "team1", Slug: "team1", Members: []string{"user1"}}
+		remote.teams["team1"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "team1" + config.Config.GoliacTeamOwnerSuffix, Slug: "team1" + config.Config.GoliacTeamOwnerSuffix, Members: []string{"user1"}}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Equal(t, map[string]string{"newrepo": "someuser/repo1"}, recorder.RepositoryTransferred)
+		assert.False(t, recorder.RepositoryCreated["newrepo"])
+		assert.Equal(t, []string{"team1"}, recorder.RepositoryTeamAdded["newrepo"])
+		assert.Equal(t, "newrepo", recorder.RepositoriesUpdateProperty["newrepo"]["description"])
+		assert.False(t, recorder.RepositoriesUpdateBoolProperty["newrepo"]["archived"])
+		assert.True(t, recorder.RepositoriesUpdateBoolProperty["newrepo"]["private"])
+	})
+}
+
+func TestReconciliationCustomRoles(t *testing.T) {
+
+	newLocal := func(customRoles map[string]string) GoliacLocalMock {
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		for _, name := range []string{"team1", "team2"} {
+			lTeam := &entity.Team{}
+			lTeam.Name = name
+			lTeam.Spec.Owners = []string{"user1"}
+			local.teams[name] = lTeam
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		owner := "team1"
+		lRepo.Owner = &owner
+		lRepo.Spec.CustomRoles = customRoles
+		local.repos["myrepo"] = lRepo
+		return local
+	}
+	newRemote := func(customRoles map[string]string) GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:       make(map[string]string),
+			teams:       make(map[string]*GithubTeam),
+			repos:       make(map[string]*GithubRepository),
+			teamsrepos:  make(map[string]map[string]*GithubTeamRepo),
+			rulesets:    make(map[string]*GithubRuleSet),
+			appids:      make(map[string]int),
+			customRoles: customRoles,
+		}
+		for _, name := range []string{"team1", "team2"} {
+			remote.teams[name] = &GithubTeam{Name: name, Slug: name, Members: []string{"user1"}}
+			remote.teams[name+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: name + config.Config.GoliacTeamOwnerSuffix, Slug: name + config.Config.GoliacTeamOwnerSuffix, Members: []string{"user1"}}
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name:           "myrepo",
+			ExternalUsers:  map[string]string{},
+			BoolProperties: map[string]bool{"private": true},
+		}
+		remote.teamsrepos["team1"] = map[string]*GithubTeamRepo{
+			"myrepo": {Name: "myrepo", Permission: "WRITE"},
+		}
+		return remote
+	}
+
+	t.Run("happy path: grant an existing custom role", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(map[string]string{"team2": "security-reviewer"})
+		remote := newRemote(map[string]string{"security-reviewer": "read"})
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"team2"}, recorder.RepositoryTeamAdded["myrepo"])
+		assert.Equal(t, "security-reviewer", recorder.RepositoryTeamPermission["myrepo"]["team2"])
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+	})
+
+	t.Run("happy path: the custom role is already granted", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(map[string]string{"team2": "security-reviewer"})
+		remote := newRemote(map[string]string{"security-reviewer": "read"})
+		remote.teamsrepos["team2"] = map[string]*GithubTeamRepo{
+			"myrepo": {Name: "myrepo", Permission: "READ", RoleName: "security-reviewer"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(recorder.RepositoryTeamAdded))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamUpdated))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+	})
+
+	t.Run("happy path: a reader moving to a custom role is updated", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(map[string]string{"team2": "security-reviewer"})
+		local.repos["myrepo"].Spec.Readers = []string{"team2"}
+		remote := newRemote(map[string]string{"security-reviewer": "read"})
+		remote.teamsrepos["team2"] = map[string]*GithubTeamRepo{
+			"myrepo": {Name: "myrepo", Permission: "READ"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(recorder.RepositoryTeamAdded))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, []string{"team2"}, recorder.RepositoryTeamUpdated["myrepo"])
+		assert.Equal(t, "security-reviewer", recorder.RepositoryTeamPermission["myrepo"]["team2"])
+	})
+
+	t.Run("happy path: a custom role no longer granted falls back to the reader permission", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(nil)
+		local.repos["myrepo"].Spec.Readers = []string{"team2"}
+		remote := newRemote(nil)
+		remote.teamsrepos["team2"] = map[string]*GithubTeamRepo{
+			"myrepo": {Name: "myrepo", Permission: "READ", RoleName: "security-reviewer"},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(recorder.RepositoryTeamAdded))
+		assert.Equal(t, 0, len(recorder.RepositoryTeamRemoved))
+		assert.Equal(t, "pull", recorder.RepositoryTeamPermission["myrepo"]["team2"])
+	})
+
+	t.Run("not happy path: missing custom role falls back to the base role", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(map[string]string{"team2": "security-reviewer"})
+		remote := newRemote(nil)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"team2"}, recorder.RepositoryTeamAdded["myrepo"])
+		assert.Equal(t, "pull", recorder.RepositoryTeamPermission["myrepo"]["team2"])
+	})
+
+	t.Run("not happy path: missing custom role keeps the writer permission", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(map[string]string{"team2": "security-reviewer"})
+		local.repos["myrepo"].Spec.Writers = []string{"team2"}
+		remote := newRemote(map[string]string{"other-role": "write"})
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"team2"}, recorder.RepositoryTeamAdded["myrepo"])
+		assert.Equal(t, "push", recorder.RepositoryTeamPermission["myrepo"]["team2"])
+	})
+
+	t.Run("not happy path: missing custom role with the error fallback", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{CustomRolesFallback: "error"}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(map[string]string{"team2": "security-reviewer"})
+		remote := newRemote(nil)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(recorder.RepositoryTeamAdded))
+	})
+}
+
+func TestReconciliationIsTemplate(t *testing.T) {
+	enabled := true
+
+	newLocal := func(isTemplate *bool) GoliacLocalMock {
+		local := GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.IsTemplate = isTemplate
+		local.repos["myrepo"] = lRepo
+		return local
+	}
+	newRemote := func(isTemplate bool) GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+				"is_template":            isTemplate,
+			},
+		}
+		return remote
+	}
+
+	t.Run("happy path: the repository is marked as template once", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(&enabled)
+		remote := newRemote(false)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Equal(t, map[string]bool{"is_template": true}, recorder.RepositoriesUpdateBoolProperty["myrepo"])
+
+		// once applied, there is nothing left to do
+		recorder = NewReconciliatorListenerRecorder()
+		r = NewGoliacReconciliatorImpl(recorder, &repoconf)
+		remote = newRemote(true)
+
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Equal(t, 0, len(recorder.RepositoriesUpdateBoolProperty))
+	})
+
+	t.Run("happy path: the template flag is not managed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal(nil)
+		remote := newRemote(true)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Equal(t, 0, len(recorder.RepositoriesUpdateBoolProperty))
+	})
+}
+
+func TestReconciliationEntityFilter(t *testing.T) {
+
+	t.Run("happy path: the entities not matching the filter are ignored", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveTeams = true
+		repoconf.DestructiveOperations.AllowDestructiveUsers = true
+		repoconf.DestructiveOperations.AllowDestructiveRepositories = true
+
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		owner := entity.User{}
+		owner.Name = "owner"
+		owner.Spec.GithubID = "owner_gh"
+		local.users["owner"] = &owner
+		payments := &entity.Team{}
+		payments.Name = "payments"
+		payments.Spec.Owners = []string{"owner"}
+		local.teams["payments"] = payments
+
+		remote := GoliacRemoteMock{
+			users:      map[string]string{"owner_gh": "MEMBER", "other_gh": "MEMBER"},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["other"] = &GithubTeam{Name: "other", Slug: "other", Members: []string{"other_gh"}}
+		remote.repos["billing"] = &GithubRepository{Name: "billing", BoolProperties: map[string]bool{}}
+
+		filter, err := NewEntityFilter([]string{"team:payments"})
+		assert.Nil(t, err)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err = r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, filter)
+		assert.Nil(t, err)
+
+		// the payments team (and its owners team) are created
+		assert.Equal(t, 1, len(recorder.TeamsCreated["payments"]))
+		assert.Equal(t, 1, len(recorder.TeamsCreated["payments"+config.Config.GoliacTeamOwnerSuffix]))
+		// but the other team, user and repository are not deleted
+		assert.Equal(t, 0, len(recorder.TeamDeleted))
+		assert.Equal(t, 0, len(recorder.UsersRemoved))
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+	})
+
+	t.Run("happy path: glob", func(t *testing.T) {
+		filter, err := NewEntityFilter([]string{"repo:billing-*", "team:payments"})
+		assert.Nil(t, err)
+
+		assert.True(t, filter.match("repo", "billing-api"))
+		assert.False(t, filter.match("repo", "billing"))
+		assert.True(t, filter.match("team", "payments"+config.Config.GoliacTeamOwnerSuffix))
+		assert.False(t, filter.match("user", "payments"))
+	})
+
+	t.Run("happy path: no filter", func(t *testing.T) {
+		filter, err := NewEntityFilter([]string{})
+		assert.Nil(t, err)
+		assert.Nil(t, filter)
+		assert.True(t, filter.match("repo", "anything"))
+	})
+
+	t.Run("not happy path: invalid filters", func(t *testing.T) {
+		_, err := NewEntityFilter([]string{"payments"})
+		assert.NotNil(t, err)
+
+		_, err = NewEntityFilter([]string{"environment:prod"})
+		assert.NotNil(t, err)
+
+		_, err = NewEntityFilter([]string{"repo:[billing"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestReconciliationAutoMerge(t *testing.T) {
+
+	newMocks := func() (*GoliacLocalMock, *GoliacRemoteMock) {
+		local := &GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.AllowAutoMerge = true
+		local.repos["myrepo"] = lRepo
+
+		remote := &GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                true,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		return local, remote
+	}
+
+	t.Run("not happy path: no required checks, auto-merge skipped with a warning", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesUpdateBoolProperty["myrepo"]))
+		found := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel && strings.Contains(entry.Message, "not enabling allow_auto_merge on the repository myrepo") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("happy path: forced", func(t *testing.T) {
+		config.Config.ForceAutoMerge = true
+		defer func() { config.Config.ForceAutoMerge = false }()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.True(t, recorder.RepositoriesUpdateBoolProperty["myrepo"]["allow_auto_merge"])
+	})
+
+	t.Run("happy path: checks required by a classic branch protection", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		remote.repos["myrepo"].RequireStatusChecks = true
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.True(t, recorder.RepositoriesUpdateBoolProperty["myrepo"]["allow_auto_merge"])
+	})
+
+	t.Run("happy path: checks required by a ruleset", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.Rulesets = append(repoconf.Rulesets, struct {
+			Pattern string
+			Ruleset string
+		}{
+			Pattern: "my.*",
+			Ruleset: "checks",
+		})
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks()
+		ruleset := &entity.RuleSet{}
+		ruleset.Name = "checks"
+		ruleset.Spec.Enforcement = "active"
+		ruleset.Spec.Rules = append(ruleset.Spec.Rules, struct {
+			Ruletype   string
+			Parameters entity.RuleSetParameters
+		}{
+			"required_status_checks", entity.RuleSetParameters{RequiredStatusChecks: []string{"ci"}},
+		})
+		local.rulesets["checks"] = ruleset
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.True(t, recorder.RepositoriesUpdateBoolProperty["myrepo"]["allow_auto_merge"])
+	})
+}
+
+func TestReconciliationPublicVisibilityChange(t *testing.T) {
+
+	newMocks := func(public bool, remotePrivate bool) (*GoliacLocalMock, *GoliacRemoteMock) {
+		local := &GoliacLocalMock{
+			users:    make(map[string]*entity.User),
+			teams:    make(map[string]*entity.Team),
+			repos:    make(map[string]*entity.Repository),
+			rulesets: make(map[string]*entity.RuleSet),
+		}
+		lRepo := &entity.Repository{}
+		lRepo.Name = "myrepo"
+		lRepo.Spec.IsPublic = public
+		local.repos["myrepo"] = lRepo
+
+		remote := &GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.repos["myrepo"] = &GithubRepository{
+			Name: "myrepo",
+			BoolProperties: map[string]bool{
+				"private":                remotePrivate,
+				"archived":               false,
+				"allow_auto_merge":       false,
+				"delete_branch_on_merge": false,
+				"allow_update_branch":    false,
+			},
+		}
+		return local, remote
+	}
+
+	t.Run("not happy path: private to public is blocked", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks(true, true)
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		_, updated := recorder.RepositoriesUpdateBoolProperty["myrepo"]["private"]
+		assert.False(t, updated)
+		assert.Equal(t, 0, len(r.PlannedActions()))
+		found := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.ErrorLevel && strings.Contains(entry.Message, "not making the private repository myrepo public") {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("happy path: private to public is allowed by the destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowPublicVisibilityChange = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks(true, true)
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		private, updated := recorder.RepositoriesUpdateBoolProperty["myrepo"]["private"]
+		assert.True(t, updated)
+		assert.False(t, private)
+	})
+
+	t.Run("happy path: public to private is allowed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks(false, false)
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.True(t, recorder.RepositoriesUpdateBoolProperty["myrepo"]["private"])
+	})
+
+	t.Run("happy path: internal to private is allowed", func(t *testing.T) {
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		// Github reports an internal repository as private: there is nothing to change
+		local, remote := newMocks(false, true)
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		_, updated := recorder.RepositoriesUpdateBoolProperty["myrepo"]["private"]
+		assert.False(t, updated)
+		for _, entry := range hook.AllEntries() {
+			assert.NotEqual(t, logrus.ErrorLevel, entry.Level)
+		}
+	})
+
+	t.Run("not happy path: a forbidden visibility fails the reconciliation", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{AllowedRepositoryVisibilities: []string{"private", "internal"}}
+		repoconf.DestructiveOperations.AllowPublicVisibilityChange = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local, remote := newMocks(true, true)
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), local, remote, "teams", false, toArchive, nil)
+		assert.NotNil(t, err)
+
+		_, updated := recorder.RepositoriesUpdateBoolProperty["myrepo"]["private"]
+		assert.False(t, updated)
+	})
+}
+
+func TestReconciliationIgnoredRepositories(t *testing.T) {
+	newRemote := func() *GoliacRemoteMock {
+		remote := &GoliacRemoteMock{
+			users:      make(map[string]string),
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		for _, reponame := range []string{"renovate-config", "bot-cache", "bot-metrics", "legacy"} {
+			remote.repos[reponame] = &GithubRepository{
+				Name:           reponame,
+				ExternalUsers:  map[string]string{},
+				BoolProperties: map[string]bool{},
+			}
+		}
+		return remote
+	}
+	local := GoliacLocalMock{
+		users: make(map[string]*entity.User),
+		teams: make(map[string]*entity.Team),
+		repos: make(map[string]*entity.Repository),
+	}
+
+	t.Run("happy path: the ignored repositories are not deleted", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := &config.RepositoryConfig{
+			IgnoredRepositories: []string{"renovate-config", "bot-*"},
+		}
+		repoconfig.DestructiveOperations.AllowDestructiveRepositories = true
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string]bool{"legacy": true}, recorder.RepositoriesDeleted)
+		assert.Equal(t, 0, len(unmanaged.Repositories))
+	})
+
+	t.Run("happy path: the ignored repositories are not archived", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := &config.RepositoryConfig{
+			ArchiveOnDelete:     true,
+			IgnoredRepositories: []string{"renovate-config", "bot-*"},
+		}
+		repoconfig.DestructiveOperations.AllowDestructiveRepositories = true
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.RepositoriesDeleted))
+		assert.Equal(t, 1, len(toArchive))
+		assert.NotNil(t, toArchive["legacy"])
+		assert.Equal(t, map[string]bool{"archived": true}, recorder.RepositoriesUpdateBoolProperty["legacy"])
+		assert.Equal(t, 1, len(recorder.RepositoriesUpdateBoolProperty))
+	})
+
+	t.Run("happy path: glob matching", func(t *testing.T) {
+		repoconfig := &config.RepositoryConfig{
+			IgnoredRepositories: []string{"renovate-config", "bot-*"},
+		}
+		assert.True(t, repoconfig.IsIgnoredRepository("renovate-config"))
+		assert.False(t, repoconfig.IsIgnoredRepository("renovate-config2"))
+		assert.True(t, repoconfig.IsIgnoredRepository("bot-cache"))
+		assert.False(t, repoconfig.IsIgnoredRepository("bot"))
+		assert.False(t, repoconfig.IsIgnoredRepository("my-bot-cache"))
+		assert.False(t, (&config.RepositoryConfig{}).IsIgnoredRepository("bot-cache"))
+	})
+}
+
+func TestReconciliationMaxDestructiveChanges(t *testing.T) {
+	newRemote := func() *GoliacRemoteMock {
+		remote := &GoliacRemoteMock{
+			users:      map[string]string{"user1": "MEMBER", "user2": "MEMBER", "user3": "MEMBER"},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		for _, reponame := range []string{"repo1", "repo2"} {
+			remote.repos[reponame] = &GithubRepository{
+				Name:           reponame,
+				ExternalUsers:  map[string]string{},
+				BoolProperties: map[string]bool{},
+			}
+		}
+		remote.teams["team1"] = &GithubTeam{Name: "team1", Slug: "team1", Members: []string{}}
+		return remote
+	}
+	local := GoliacLocalMock{
+		users: make(map[string]*entity.User),
+		teams: make(map[string]*entity.Team),
+		repos: make(map[string]*entity.Repository),
+	}
+	newRepoConfig := func() *config.RepositoryConfig {
+		repoconfig := &config.RepositoryConfig{
+			ArchiveOnDelete: true,
+		}
+		repoconfig.DestructiveOperations.AllowDestructiveRepositories = true
+		repoconfig.DestructiveOperations.AllowDestructiveTeams = true
+		repoconfig.DestructiveOperations.AllowDestructiveUsers = true
+		return repoconfig
+	}
+
+	t.Run("happy path: under the limits", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := newRepoConfig()
+		repoconfig.MaxDestructiveChanges.Repositories = 2
+		repoconfig.MaxDestructiveChanges.Teams = 1
+		repoconfig.MaxDestructiveChanges.Members = 3
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+		assert.True(t, recorder.Committed)
+		assert.Equal(t, 3, len(recorder.UsersRemoved))
+	})
+
+	t.Run("not happy path: too many members removed", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := newRepoConfig()
+		repoconfig.MaxDestructiveChanges.Members = 2
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, "too many destructive changes: 3 members removed (max_destructive_changes.members is 2). Aborting (raise max_destructive_changes in goliac.yaml if they are expected)", err.Error())
+		assert.False(t, recorder.Committed)
+	})
+
+	t.Run("not happy path: too many repositories archived and teams deleted", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := newRepoConfig()
+		repoconfig.MaxDestructiveChanges.Repositories = 1
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "2 repositories deleted or archived (max_destructive_changes.repositories is 1)")
+		assert.False(t, recorder.Committed)
+
+		// the archived (renamed) teams count as deleted
+		recorder = NewReconciliatorListenerRecorder()
+		repoconfig = newRepoConfig()
+		repoconfig.ArchiveTeamByRenaming = true
+		repoconfig.MaxDestructiveChanges.Teams = 1
+		remote := newRemote()
+		remote.teams["team2"] = &GithubTeam{Name: "team2", Slug: "team2", Members: []string{}}
+		r = NewGoliacReconciliatorImpl(recorder, repoconfig)
+		_, err = r.Reconciliate(context.TODO(), &local, remote, "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "2 teams deleted or archived (max_destructive_changes.teams is 1)")
+		assert.False(t, recorder.Committed)
+	})
+
+	t.Run("not happy path: the limits count the destructive changes of the previous commits", func(t *testing.T) {
+		repoconfig := newRepoConfig()
+		repoconfig.MaxDestructiveChanges.Members = 4
+		destructiveChanges := &DestructiveChanges{}
+		ctx := context.WithValue(context.TODO(), KeyDestructiveChanges, destructiveChanges)
+
+		// first commit
+		recorder := NewReconciliatorListenerRecorder()
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+		_, err := r.Reconciliate(ctx, &local, newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+		assert.True(t, recorder.Committed)
+		assert.Equal(t, 3, destructiveChanges.Members)
+
+		// second commit
+		recorder = NewReconciliatorListenerRecorder()
+		r = NewGoliacReconciliatorImpl(recorder, repoconfig)
+		_, err = r.Reconciliate(ctx, &local, newRemote(), "teams", false, make(map[string]*GithubRepoComparable), nil)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "6 members removed (max_destructive_changes.members is 4)")
+		assert.False(t, recorder.Committed)
+		assert.Equal(t, 3, destructiveChanges.Members)
+	})
+
+	t.Run("happy path: a dryrun doesn't count the destructive changes for the next commits", func(t *testing.T) {
+		repoconfig := newRepoConfig()
+		repoconfig.MaxDestructiveChanges.Members = 4
+		destructiveChanges := &DestructiveChanges{}
+		ctx := context.WithValue(context.TODO(), KeyDestructiveChanges, destructiveChanges)
+
+		r := NewGoliacReconciliatorImpl(NewReconciliatorListenerRecorder(), repoconfig)
+		_, err := r.Reconciliate(ctx, &local, newRemote(), "teams", true, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, destructiveChanges.Members)
+	})
+
+	t.Run("happy path: the plan still shows the changes", func(t *testing.T) {
+		logsCollector := logrustest.NewGlobal()
+		recorder := NewReconciliatorListenerRecorder()
+		repoconfig := newRepoConfig()
+		repoconfig.MaxDestructiveChanges.Members = 2
+		r := NewGoliacReconciliatorImpl(recorder, repoconfig)
+
+		_, err := r.Reconciliate(context.TODO(), &local, newRemote(), "teams", true, make(map[string]*GithubRepoComparable), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(recorder.UsersRemoved))
+
+		found := false
+		for _, entry := range logsCollector.AllEntries() {
+			if entry.Message == "the apply would be aborted: 3 members removed (max_destructive_changes.members is 2)" {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+}
+
+func TestReconciliationExplain(t *testing.T) {
+
+	t.Run("happy path: the reason of the team members changes", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveUsers = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		for _, name := range []string{"owner", "moved", "new"} {
+			user := &entity.User{}
+			user.Name = name
+			user.Spec.GithubID = name + "_gh"
+			local.users[name] = user
+		}
+		platform := &entity.Team{}
+		platform.Name = "platform"
+		platform.Spec.Owners = []string{"owner"}
+		local.teams["platform"] = platform
+		parent := "platform"
+		payments := &entity.Team{}
+		payments.Name = "payments"
+		payments.Spec.Owners = []string{"owner"}
+		payments.Spec.Members = []string{"new"}
+		payments.ParentTeam = &parent
+		local.teams["payments"] = payments
+		owner := "payments"
+		billing := &entity.Repository{}
+		billing.Name = "billing"
+		billing.Owner = &owner
+		local.repos["billing"] = billing
+
+		parentId := 1
+		remote := GoliacRemoteMock{
+			users:      map[string]string{"owner_gh": "MEMBER", "moved_gh": "MEMBER", "new_gh": "MEMBER", "left_gh": "MEMBER"},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["platform"] = &GithubTeam{Name: "platform", Slug: "platform", Id: 1, Members: []string{"owner_gh"}}
+		remote.teams["platform"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "platform" + config.Config.GoliacTeamOwnerSuffix, Slug: "platform" + config.Config.GoliacTeamOwnerSuffix, Members: []string{"owner_gh"}}
+		remote.teams["payments"] = &GithubTeam{Name: "payments", Slug: "payments", Id: 2, ParentTeam: &parentId, Members: []string{"owner_gh", "moved_gh", "left_gh"}}
+		remote.teams["payments"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "payments" + config.Config.GoliacTeamOwnerSuffix, Slug: "payments" + config.Config.GoliacTeamOwnerSuffix, Members: []string{"owner_gh"}}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", true, toArchive, nil)
+		assert.Nil(t, err)
+
+		reasons := make(map[string]string)
+		for _, action := range r.PlannedActions() {
+			reasons[action.Operation+" "+action.Target] = action.Reason
+		}
+		assert.Equal(t, "left_gh is a member of the organization but is not defined in the users directory", reasons["remove_user_from_org user/left_gh"])
+		assert.Equal(t, "left_gh is a member of payments on Github but is not defined in the users directory", reasons["update_team_remove_member team/payments/member/left_gh"])
+		assert.Equal(t, "moved_gh is a member of payments on Github but is not a member in teams/platform/payments/team.yaml", reasons["update_team_remove_member team/payments/member/moved_gh"])
+		assert.Equal(t, "new_gh is a member in teams/platform/payments/team.yaml but is not a member of payments on Github", reasons["update_team_add_member team/payments/member/new_gh"])
+		assert.Equal(t, "the repository billing is defined in teams/platform/payments/billing.yaml but doesn't exist on Github", reasons["create_repository repository/billing"])
+	})
+}
+
+func TestReconciliationExternalGroups(t *testing.T) {
+	newLocal := func() GoliacLocalMock {
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		for _, name := range []string{"owner1", "owner2"} {
+			user := &entity.User{}
+			user.Name = name
+			user.Spec.GithubID = name + "_gh"
+			local.users[name] = user
+		}
+		synced := &entity.Team{}
+		synced.Name = "synced"
+		synced.Spec.Owners = []string{"owner1", "owner2"}
+		synced.Spec.ExternalGroups = []string{"okta-synced", "okta-admins"}
+		local.teams["synced"] = synced
+		return local
+	}
+	newRemote := func() GoliacRemoteMock {
+		return GoliacRemoteMock{
+			users:      map[string]string{"owner1_gh": "MEMBER", "owner2_gh": "MEMBER", "idp_gh": "MEMBER"},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+	}
+
+	t.Run("happy path: create a team synchronized with external groups", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.externalGroups = map[string][]string{}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		// the members come from the IdP, the owners team is still managed
+		assert.Equal(t, map[string][]string{
+			"synced": nil,
+			"synced" + config.Config.GoliacTeamOwnerSuffix: {"owner1_gh", "owner2_gh"},
+		}, recorder.TeamsCreated)
+		assert.Equal(t, map[string][]string{"synced": {"okta-admins", "okta-synced"}}, recorder.TeamExtGroups)
+	})
+
+	t.Run("happy path: the members of a synchronized team are not reconciled", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.teams["synced"] = &GithubTeam{Name: "synced", Slug: "synced", Id: 1, Members: []string{"idp_gh"}, Maintainers: []string{"owner1_gh"}}
+		remote.teams["synced"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "synced" + config.Config.GoliacTeamOwnerSuffix, Slug: "synced" + config.Config.GoliacTeamOwnerSuffix, Id: 2, Members: []string{"owner1_gh", "owner2_gh"}}
+		remote.externalGroups = map[string][]string{
+			"synced": {"okta-synced"},
+			"synced" + config.Config.GoliacTeamOwnerSuffix: {},
+		}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string][]string{"synced": {"okta-admins", "okta-synced"}}, recorder.TeamExtGroups)
+		assert.Equal(t, 0, len(recorder.TeamsCreated))
+		assert.Equal(t, 0, len(recorder.TeamMemberAdded))
+		assert.Equal(t, 0, len(recorder.TeamMemberRemoved))
+		assert.Equal(t, 0, len(recorder.TeamMemberUpdated))
+
+		var action PlannedAction
+		for _, a := range r.PlannedActions() {
+			if a.Operation == "update_team_external_groups" {
+				action = a
+			}
+		}
+		assert.Equal(t, "team/synced", action.Target)
+		assert.Equal(t, []string{"okta-synced"}, action.Before)
+	})
+
+	t.Run("happy path: no change when the external groups are the same", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.teams["synced"] = &GithubTeam{Name: "synced", Slug: "synced", Id: 1, Members: []string{"idp_gh"}}
+		remote.teams["synced"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "synced" + config.Config.GoliacTeamOwnerSuffix, Slug: "synced" + config.Config.GoliacTeamOwnerSuffix, Id: 2, Members: []string{"owner1_gh", "owner2_gh"}}
+		remote.externalGroups = map[string][]string{"synced": {"okta-admins", "okta-synced"}}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.TeamExtGroups))
+		assert.Equal(t, 0, len(recorder.TeamMemberAdded))
+		assert.Equal(t, 0, len(recorder.TeamMemberRemoved))
+	})
+
+	t.Run("not happy path: the external groups are not loaded", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		remote := newRemote()
+		remote.teams["synced"] = &GithubTeam{Name: "synced", Slug: "synced", Id: 1, Members: []string{"idp_gh"}}
+		remote.teams["synced"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "synced" + config.Config.GoliacTeamOwnerSuffix, Slug: "synced" + config.Config.GoliacTeamOwnerSuffix, Id: 2, Members: []string{"owner1_gh", "owner2_gh"}}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.TeamExtGroups))
+		assert.Equal(t, 0, len(recorder.TeamMemberRemoved))
+	})
+
+	t.Run("happy path: remove the external groups of a team not synchronized anymore", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		repoconf.DestructiveOperations.AllowDestructiveTeams = true
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		local.teams["synced"].Spec.ExternalGroups = nil
+		remote := newRemote()
+		remote.teams["synced"] = &GithubTeam{Name: "synced", Slug: "synced", Id: 1, Members: []string{"idp_gh"}}
+		remote.teams["synced"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "synced" + config.Config.GoliacTeamOwnerSuffix, Slug: "synced" + config.Config.GoliacTeamOwnerSuffix, Id: 2, Members: []string{"owner1_gh", "owner2_gh"}}
+		remote.externalGroups = map[string][]string{"synced": {"okta-admins", "okta-synced"}}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string][]string{"synced": {}}, recorder.TeamExtGroups)
+		assert.Equal(t, 0, len(unmanaged.TeamsExternalGroups))
+		// the members are managed by Goliac again
+		assert.Equal(t, 1, len(recorder.TeamMemberRemoved["synced"]))
+		assert.Equal(t, 2, len(recorder.TeamMemberAdded["synced"]))
+
+		var action PlannedAction
+		for _, a := range r.PlannedActions() {
+			if a.Operation == "update_team_remove_external_groups" {
+				action = a
+			}
+		}
+		assert.Equal(t, "team/synced", action.Target)
+		assert.Equal(t, []string{"okta-admins", "okta-synced"}, action.Before)
+		assert.True(t, IsDestructive(action))
+	})
+
+	t.Run("happy path: the external groups are not removed without the teams destructive operations", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal()
+		local.teams["synced"].Spec.ExternalGroups = nil
+		remote := newRemote()
+		remote.teams["synced"] = &GithubTeam{Name: "synced", Slug: "synced", Id: 1, Members: []string{"owner1_gh", "owner2_gh"}}
+		remote.teams["synced"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "synced" + config.Config.GoliacTeamOwnerSuffix, Slug: "synced" + config.Config.GoliacTeamOwnerSuffix, Id: 2, Members: []string{"owner1_gh", "owner2_gh"}}
+		remote.externalGroups = map[string][]string{"synced": {"okta-synced"}}
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		unmanaged, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.TeamExtGroups))
+		assert.Equal(t, map[string]bool{"synced": true}, unmanaged.TeamsExternalGroups)
+
+		blocked := map[string]string{}
+		for _, a := range DestructiveActions(r.PlannedActions(), unmanaged) {
+			blocked[a.Target] = a.Operation
+		}
+		assert.Equal(t, "blocked", blocked["team/synced/external_groups"])
+	})
+}
+
+func TestReconciliationTeamSettings(t *testing.T) {
+	newLocal := func(privacy string, notificationSetting string) GoliacLocalMock {
+		local := GoliacLocalMock{
+			users: make(map[string]*entity.User),
+			teams: make(map[string]*entity.Team),
+			repos: make(map[string]*entity.Repository),
+		}
+		for _, name := range []string{"owner1", "owner2"} {
+			user := &entity.User{}
+			user.Name = name
+			user.Spec.GithubID = name + "_gh"
+			local.users[name] = user
+		}
+		team := &entity.Team{}
+		team.Name = "team1"
+		team.Spec.Owners = []string{"owner1", "owner2"}
+		team.Spec.Privacy = privacy
+		team.Spec.NotificationSetting = notificationSetting
+		local.teams["team1"] = team
+		return local
+	}
+	newRemote := func(privacy string, notificationSetting string) GoliacRemoteMock {
+		remote := GoliacRemoteMock{
+			users:      map[string]string{"owner1_gh": "MEMBER", "owner2_gh": "MEMBER"},
+			teams:      make(map[string]*GithubTeam),
+			repos:      make(map[string]*GithubRepository),
+			teamsrepos: make(map[string]map[string]*GithubTeamRepo),
+			rulesets:   make(map[string]*GithubRuleSet),
+			appids:     make(map[string]int),
+		}
+		remote.teams["team1"] = &GithubTeam{Name: "team1", Slug: "team1", Id: 1, Members: []string{"owner1_gh", "owner2_gh"}, Privacy: privacy, NotificationSetting: notificationSetting}
+		remote.teams["team1"+config.Config.GoliacTeamOwnerSuffix] = &GithubTeam{Name: "team1" + config.Config.GoliacTeamOwnerSuffix, Slug: "team1" + config.Config.GoliacTeamOwnerSuffix, Id: 2, Members: []string{"owner1_gh", "owner2_gh"}, Privacy: "closed", NotificationSetting: "notifications_enabled"}
+		return remote
+	}
+
+	t.Run("happy path: no change when the settings are not defined", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal("", "")
+		remote := newRemote("secret", "notifications_disabled")
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.TeamPropertyUpdated))
+		assert.Equal(t, 0, len(r.PlannedActions()))
+	})
+
+	t.Run("happy path: update the privacy and the notification setting", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal("secret", "notifications_disabled")
+		remote := newRemote("closed", "notifications_enabled")
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string]map[string]string{
+			"team1": {"privacy": "secret", "notification_setting": "notifications_disabled"},
+		}, recorder.TeamPropertyUpdated)
+
+		var action PlannedAction
+		for _, a := range r.PlannedActions() {
+			if a.Target == "team/team1/privacy" {
+				action = a
+			}
+		}
+		assert.Equal(t, "update_team_update_property", action.Operation)
+		assert.Equal(t, "closed", action.Before)
+	})
+
+	t.Run("happy path: no change when the settings are the same", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal("secret", "notifications_enabled")
+		remote := newRemote("secret", "notifications_enabled")
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 0, len(recorder.TeamPropertyUpdated))
+	})
+
+	t.Run("happy path: create a secret team", func(t *testing.T) {
+		recorder := NewReconciliatorListenerRecorder()
+		repoconf := config.RepositoryConfig{}
+		r := NewGoliacReconciliatorImpl(recorder, &repoconf)
+
+		local := newLocal("secret", "notifications_enabled")
+		remote := newRemote("", "")
+		delete(remote.teams, "team1")
+
+		toArchive := make(map[string]*GithubRepoComparable)
+		_, err := r.Reconciliate(context.TODO(), &local, &remote, "teams", false, toArchive, nil)
+		assert.Nil(t, err)
+
+		assert.Equal(t, 1, len(recorder.TeamsCreated))
+		// the team is created with the notifications enabled
+		assert.Equal(t, map[string]map[string]string{"team1": {"privacy": "secret"}}, recorder.TeamPropertyUpdated)
+	})
 }
@@ -1,9 +1,12 @@
 package engine
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -41,6 +44,8 @@ type GoliacLocalGit interface {
 	ListCommitsFromTag(tagname string) ([]*object.Commit, error)
 	GetHeadCommit() (*object.Commit, error)
 	CheckoutCommit(commit *object.Commit) error
+	// checkout a branch (of the origin remote), a tag or a commit hash
+	CheckoutRef(ref string) error
 	PushTag(tagname string, hash plumbing.Hash, accesstoken string) error
 
 	LoadRepoConfig() (*config.RepositoryConfig, error)
@@ -55,6 +60,11 @@ type GoliacLocalGit interface {
 	// (force will bypass the max_changesets check)
 	// return true if some changes were done
 	SyncUsersAndTeams(repoconfig *config.RepositoryConfig, plugin UserSyncPlugin, accesstoken string, dryrun bool, force bool) (bool, error)
+	// push the commits done by Goliac (CODEOWNERS, users sync, archived repositories)
+	// to this branch instead of the cloned one (empty to push to the cloned branch)
+	SetTargetBranch(branch string)
+	// return true if some commits were pushed to the target branch
+	TargetBranchPushed() bool
 	Close(fs billy.Filesystem)
 
 	// Load and Validate from a local directory
@@ -67,6 +77,8 @@ type GoliacLocalResources interface {
 	Users() map[string]*entity.User              // github username, user definition
 	ExternalUsers() map[string]*entity.User
 	RuleSets() map[string]*entity.RuleSet
+	OrgVariables() map[string]*entity.OrgVariable // variable name, variable definition
+	OrgSecrets() map[string]*entity.OrgSecret     // secret name, secret definition
 }
 
 type GoliacLocalImpl struct {
@@ -75,7 +87,12 @@ type GoliacLocalImpl struct {
 	users         map[string]*entity.User
 	externalUsers map[string]*entity.User
 	rulesets      map[string]*entity.RuleSet
+	orgVariables  map[string]*entity.OrgVariable
+	orgSecrets    map[string]*entity.OrgSecret
 	repo          *git.Repository
+	// if set, the commits are pushed to this branch (to open a PR) instead of the cloned one
+	targetBranch       string
+	targetBranchPushed bool
 }
 
 func NewGoliacLocalImpl() GoliacLocal {
@@ -85,6 +102,8 @@ func NewGoliacLocalImpl() GoliacLocal {
 		users:         map[string]*entity.User{},
 		externalUsers: map[string]*entity.User{},
 		rulesets:      map[string]*entity.RuleSet{},
+		orgVariables:  map[string]*entity.OrgVariable{},
+		orgSecrets:    map[string]*entity.OrgSecret{},
 		repo:          nil,
 	}
 }
@@ -97,6 +116,8 @@ func NewGoliacLocalImplWithRepo(repo *git.Repository) GoliacLocal {
 		users:         map[string]*entity.User{},
 		externalUsers: map[string]*entity.User{},
 		rulesets:      map[string]*entity.RuleSet{},
+		orgVariables:  map[string]*entity.OrgVariable{},
+		orgSecrets:    map[string]*entity.OrgSecret{},
 		repo:          repo,
 	}
 }
@@ -121,10 +142,19 @@ func (g *GoliacLocalImpl) RuleSets() map[string]*entity.RuleSet {
 	return g.rulesets
 }
 
+func (g *GoliacLocalImpl) OrgVariables() map[string]*entity.OrgVariable {
+	return g.orgVariables
+}
+
+func (g *GoliacLocalImpl) OrgSecrets() map[string]*entity.OrgSecret {
+	return g.orgSecrets
+}
+
 func (g *GoliacLocalImpl) Clone(fs billy.Filesystem, accesstoken, repositoryUrl, branch string) error {
 	if g.repo != nil {
 		g.Close(fs)
 	}
+	g.targetBranchPushed = false
 
 	// create a temp directory
 	tmpDir, err := utils.MkdirTemp(fs, "", "goliac")
@@ -183,6 +213,49 @@ func (g *GoliacLocalImpl) PushTag(tagname string, hash plumbing.Hash, accesstoke
 	return err
 }
 
+func (g *GoliacLocalImpl) SetTargetBranch(branch string) {
+	g.targetBranch = branch
+}
+
+func (g *GoliacLocalImpl) TargetBranchPushed() bool {
+	return g.targetBranchPushed
+}
+
+/*
+ * push pushes the local commits to the cloned branch, or (force pushes them)
+ * to the target branch if one is set
+ */
+func (g *GoliacLocalImpl) push(accesstoken string) error {
+	auth := &http.BasicAuth{
+		Username: "x-access-token", // This can be anything except an empty string
+		Password: accesstoken,
+	}
+
+	if g.targetBranch == "" {
+		return g.repo.Push(&git.PushOptions{
+			RemoteName: "origin",
+			Auth:       auth,
+		})
+	}
+
+	// the target branch is recreated from the cloned branch on each run
+	head, err := g.repo.Head()
+	if err != nil {
+		return err
+	}
+	pushRefSpec := fmt.Sprintf("+%s:%s", head.Name(), plumbing.NewBranchReferenceName(g.targetBranch))
+	err = g.repo.Push(&git.PushOptions{
+		RemoteName: "origin",
+		RefSpecs:   []goconfig.RefSpec{goconfig.RefSpec(pushRefSpec)},
+		Auth:       auth,
+	})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return err
+	}
+	g.targetBranchPushed = true
+	return nil
+}
+
 func (g *GoliacLocalImpl) CheckoutCommit(commit *object.Commit) error {
 	// checkout the branch
 	w, err := g.repo.Worktree()
@@ -198,6 +271,34 @@ func (g *GoliacLocalImpl) CheckoutCommit(commit *object.Commit) error {
 	return nil
 }
 
+/*
+ * CheckoutRef checkouts (detached) a branch of the origin remote, a tag or a commit hash
+ */
+func (g *GoliacLocalImpl) CheckoutRef(ref string) error {
+	if g.repo == nil {
+		return fmt.Errorf("git repository not cloned")
+	}
+
+	var hash *plumbing.Hash
+	var err error
+	// branches are resolved against the origin remote (to get the latest commit)
+	for _, revision := range []string{"refs/remotes/origin/" + ref, ref} {
+		hash, err = g.repo.ResolveRevision(plumbing.Revision(revision))
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("not able to resolve %s: %v", ref, err)
+	}
+
+	commit, err := g.repo.CommitObject(*hash)
+	if err != nil {
+		return err
+	}
+	return g.CheckoutCommit(commit)
+}
+
 func (g *GoliacLocalImpl) GetHeadCommit() (*object.Commit, error) {
 	// Get reference to the HEAD
 	refHead, err := g.repo.Head()
@@ -286,9 +387,13 @@ func (g *GoliacLocalImpl) LoadRepoConfig() (*config.RepositoryConfig, error) {
 		return nil, err
 	}
 
+	return readRepoConfig(w.Filesystem)
+}
+
+func readRepoConfig(fs billy.Filesystem) (*config.RepositoryConfig, error) {
 	var repoconfig config.RepositoryConfig
 
-	content, err := utils.ReadFile(w.Filesystem, "goliac.yaml")
+	content, err := utils.ReadFile(fs, "goliac.yaml")
 	if err != nil {
 		return nil, fmt.Errorf("not able to find the /goliac.yaml configuration file: %v", err)
 	}
@@ -297,6 +402,14 @@ func (g *GoliacLocalImpl) LoadRepoConfig() (*config.RepositoryConfig, error) {
 		return nil, fmt.Errorf("not able to unmarshall the /goliac.yaml configuration file: %v", err)
 	}
 
+	if permission := repoconfig.OrgSettings.DefaultRepositoryPermission; permission != nil {
+		switch *permission {
+		case "read", "write", "admin", "none":
+		default:
+			return nil, fmt.Errorf("invalid org_settings.default_repository_permission %s in goliac.yaml: must be read, write, admin or none", *permission)
+		}
+	}
+
 	return &repoconfig, nil
 }
 
@@ -406,13 +519,7 @@ func (g *GoliacLocalImpl) ArchiveRepos(reposToArchiveList []string, accesstoken
 		return err
 	}
 
-	err = g.repo.Push(&git.PushOptions{
-		RemoteName: "origin",
-		Auth: &http.BasicAuth{
-			Username: "x-access-token", // This can be anything except an empty string
-			Password: accesstoken,
-		},
-	})
+	err = g.push(accesstoken)
 
 	if err != nil {
 		return fmt.Errorf("error pushing to remote: %v", err)
@@ -512,13 +619,7 @@ func (g *GoliacLocalImpl) UpdateAndCommitCodeOwners(repoconfig *config.Repositor
 			return err
 		}
 
-		err = g.repo.Push(&git.PushOptions{
-			RemoteName: "origin",
-			Auth: &http.BasicAuth{
-				Username: "x-access-token", // This can be anything except an empty string
-				Password: accesstoken,
-			},
-		})
+		err = g.push(accesstoken)
 
 		if err != nil {
 			return fmt.Errorf("error pushing to remote: %v", err)
@@ -526,7 +627,9 @@ func (g *GoliacLocalImpl) UpdateAndCommitCodeOwners(repoconfig *config.Repositor
 	}
 
 	// push the tagname
-	if !dryrun {
+	// (unless the HEAD commit is only on the target branch: the tag is moved
+	// when the pull request is merged and applied)
+	if !dryrun && !g.targetBranchPushed {
 		// Get the HEAD reference
 		headRef, err := g.repo.Head()
 		if err != nil {
@@ -539,24 +642,62 @@ func (g *GoliacLocalImpl) UpdateAndCommitCodeOwners(repoconfig *config.Repositor
 	return nil
 }
 
+// file (at the root of the teams repository) keeping the hash of the last users set synced
+const USERS_SYNC_STATE_FILE = ".goliac-sync-state"
+
+/*
+ * usersSyncHash returns a hash of the users set returned by a user sync plugin
+ */
+func usersSyncHash(users map[string]*entity.User) string {
+	usernames := make([]string, 0, len(users))
+	for username := range users {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+
+	h := sha256.New()
+	for _, username := range usernames {
+		u := users[username]
+		fmt.Fprintf(h, "%s\t%s\t%s\t%s\n", username, u.ApiVersion, u.Kind, u.Spec.GithubID)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+/*
+ * readUsersSyncState returns the hash of the last users set synced ("" if unknown)
+ */
+func readUsersSyncState(fs billy.Filesystem) string {
+	content, err := utils.ReadFile(fs, USERS_SYNC_STATE_FILE)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
+
 /**
  * syncusers will
  * - list the current users list
  * - call the external user sync plugin
- * - collect the difference
- * - returns deleted users, and add/updated users
+ * - collect the difference (unless the users set is the same as the last
+ *   synced one, and force is not set)
+ * - returns deleted users, add/updated users, and the hash of the users set
  */
-func syncUsersViaUserPlugin(repoconfig *config.RepositoryConfig, fs billy.Filesystem, userplugin UserSyncPlugin) ([]string, []string, error) {
+func syncUsersViaUserPlugin(repoconfig *config.RepositoryConfig, fs billy.Filesystem, userplugin UserSyncPlugin, syncState string, force bool) ([]string, []string, string, error) {
 	usersOrgPath := filepath.Join("users", "org")
 	orgUsers, errs, _ := entity.ReadUserDirectory(fs, usersOrgPath)
 	if len(errs) > 0 {
-		return nil, nil, fmt.Errorf("cannot load org users (for example: %v)", errs[0])
+		return nil, nil, "", fmt.Errorf("cannot load org users (for example: %v)", errs[0])
 	}
 
 	// use usersync to update the users
 	newOrgUsers, err := userplugin.UpdateUsers(repoconfig, fs, usersOrgPath)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
+	}
+
+	usersHash := usersSyncHash(newOrgUsers)
+	if !force && syncState != "" && usersHash == syncState {
+		return []string{}, []string{}, usersHash, nil
 	}
 
 	// write back to disk
@@ -573,7 +714,7 @@ func syncUsersViaUserPlugin(repoconfig *config.RepositoryConfig, fs billy.Filesy
 				// changed user
 				file, err := fs.Create(filepath.Join(usersOrgPath, fmt.Sprintf("%s.yaml", username)))
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, "", err
 				}
 				defer file.Close()
 
@@ -581,7 +722,7 @@ func syncUsersViaUserPlugin(repoconfig *config.RepositoryConfig, fs billy.Filesy
 				encoder.SetIndent(2)
 				err = encoder.Encode(newuser)
 				if err != nil {
-					return nil, nil, err
+					return nil, nil, "", err
 				}
 				updatedusers = append(updatedusers, filepath.Join(usersOrgPath, fmt.Sprintf("%s.yaml", username)))
 			}
@@ -593,7 +734,7 @@ func syncUsersViaUserPlugin(repoconfig *config.RepositoryConfig, fs billy.Filesy
 		// new user
 		file, err := fs.Create(filepath.Join(usersOrgPath, fmt.Sprintf("%s.yaml", username)))
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, "", err
 		}
 		defer file.Close()
 
@@ -601,11 +742,11 @@ func syncUsersViaUserPlugin(repoconfig *config.RepositoryConfig, fs billy.Filesy
 		encoder.SetIndent(2)
 		err = encoder.Encode(user)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, "", err
 		}
 		updatedusers = append(updatedusers, filepath.Join(usersOrgPath, fmt.Sprintf("%s.yaml", username)))
 	}
-	return deletedusers, updatedusers, nil
+	return deletedusers, updatedusers, usersHash, nil
 }
 
 func (g *GoliacLocalImpl) SyncUsersAndTeams(repoconfig *config.RepositoryConfig, userplugin UserSyncPlugin, accesstoken string, dryrun bool, force bool) (bool, error) {
@@ -625,10 +766,15 @@ func (g *GoliacLocalImpl) SyncUsersAndTeams(repoconfig *config.RepositoryConfig,
 	//
 
 	// Parse all the users in the <orgDirectory>/org-users directory
-	deletedusers, addedusers, err := syncUsersViaUserPlugin(repoconfig, w.Filesystem, userplugin)
+	syncState := readUsersSyncState(w.Filesystem)
+	deletedusers, addedusers, usersHash, err := syncUsersViaUserPlugin(repoconfig, w.Filesystem, userplugin, syncState, force)
 	if err != nil {
 		return false, err
 	}
+	if !force && syncState != "" && usersHash == syncState {
+		logrus.Info("the users returned by the user sync plugin didn't change since the last sync: nothing to commit")
+		return false, nil
+	}
 
 	//
 	// let's update teams
@@ -652,7 +798,7 @@ func (g *GoliacLocalImpl) SyncUsersAndTeams(repoconfig *config.RepositoryConfig,
 	//
 	// let's commit
 	//
-	if len(teamschanged) > 0 || len(deletedusers) > 0 || len(addedusers) > 0 {
+	if len(teamschanged) > 0 || len(deletedusers) > 0 || len(addedusers) > 0 || usersHash != syncState {
 
 		logrus.Info("some users and/or teams must be commited")
 
@@ -690,6 +836,18 @@ func (g *GoliacLocalImpl) SyncUsersAndTeams(repoconfig *config.RepositoryConfig,
 			return false, nil
 		}
 
+		// keep the users set synced, to skip the next sync if it didn't change
+		if usersHash != syncState {
+			err = utils.WriteFile(w.Filesystem, USERS_SYNC_STATE_FILE, []byte(usersHash+"\n"), 0644)
+			if err != nil {
+				return false, err
+			}
+			_, err = w.Add(USERS_SYNC_STATE_FILE)
+			if err != nil {
+				return false, err
+			}
+		}
+
 		_, err = w.Commit("update teams and users", &git.CommitOptions{
 			Author: &object.Signature{
 				Name:  "Goliac",
@@ -702,16 +860,7 @@ func (g *GoliacLocalImpl) SyncUsersAndTeams(repoconfig *config.RepositoryConfig,
 			return false, err
 		}
 
-		// Now push the tag to the remote repository
-		auth := &http.BasicAuth{
-			Username: "x-access-token", // This can be anything except an empty string
-			Password: accesstoken,
-		}
-
-		err = g.repo.Push(&git.PushOptions{
-			RemoteName: "origin",
-			Auth:       auth,
-		})
+		err = g.push(accesstoken)
 
 		return true, err
 	}
@@ -801,11 +950,62 @@ func (g *GoliacLocalImpl) LoadAndValidateLocal(fs billy.Filesystem) ([]error, []
 	warnings = append(warnings, warns...)
 	g.repositories = repos
 
+	// the repositories cannot enable a merge method disabled in goliac.yaml,
+	// have a forbidden visibility, reference an unknown branch protection template
+	// nor be ignored
+	if _, err := fs.Stat("goliac.yaml"); err == nil {
+		repoconfig, err := readRepoConfig(fs)
+		if err != nil {
+			errors = append(errors, err)
+		} else {
+			for _, glob := range repoconfig.IgnoredRepositories {
+				if _, err := path.Match(glob, ""); err != nil {
+					errors = append(errors, fmt.Errorf("invalid ignored_repositories pattern %s in goliac.yaml: %v", glob, err))
+				}
+			}
+			reponames := make([]string, 0, len(repos))
+			for reponame := range repos {
+				reponames = append(reponames, reponame)
+			}
+			sort.Strings(reponames)
+			for _, reponame := range reponames {
+				if err := repos[reponame].ValidateMergeMethods(repoconfig); err != nil {
+					errors = append(errors, entity.NewEntityError("repository", reponame, "", err))
+				}
+				if err := repos[reponame].ValidateBranchProtectionTemplate(repoconfig); err != nil {
+					errors = append(errors, entity.NewEntityError("repository", reponame, "", err))
+				}
+				if err := repos[reponame].ValidateVisibility(repoconfig); err != nil {
+					errors = append(errors, entity.NewEntityError("repository", reponame, "", err))
+				}
+				if repoconfig.IsIgnoredRepository(reponame) {
+					errors = append(errors, entity.NewEntityError("repository", reponame, "", fmt.Errorf("invalid repository: %s matches ignored_repositories in goliac.yaml", reponame)))
+				}
+			}
+			errors = append(errors, entity.ValidateRepositoriesQuota(repos, repoconfig)...)
+		}
+	}
+
 	rulesets, errs, warns := entity.ReadRuleSetDirectory(fs, "rulesets")
 	errors = append(errors, errs...)
 	warnings = append(warnings, warns...)
+	for _, ruleset := range rulesets {
+		if err := ruleset.ValidateRequiredWorkflows(g.repositories); err != nil {
+			errors = append(errors, entity.NewEntityError("ruleset", ruleset.Name, "", err))
+		}
+	}
 	g.rulesets = rulesets
 
+	orgVariables, errs, warns := entity.ReadOrgVariables(fs, "org-variables.yaml", g.repositories)
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+	g.orgVariables = orgVariables
+
+	orgSecrets, errs, warns := entity.ReadOrgSecrets(fs, "org-secrets.yaml", g.repositories)
+	errors = append(errors, errs...)
+	warnings = append(warnings, warns...)
+	g.orgSecrets = orgSecrets
+
 	logrus.Debugf("Nb local users: %d", len(g.users))
 	logrus.Debugf("Nb local external users: %d", len(g.externalUsers))
 	logrus.Debugf("Nb local teams: %d", len(g.teams))
@@ -100,6 +100,112 @@ func TestRepository(t *testing.T) {
 		assert.Equal(t, 0, len(warns))
 	})
 
+	t.Run("not happy path: a repository enables a merge method disabled in goliac.yaml", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+merge_methods:
+  allow_merge_commit: false
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  allow_merge_commit: true
+`), 0644)
+		assert.Nil(t, err)
+
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "allow_merge_commit is disabled in goliac.yaml")
+	})
+
+	t.Run("not happy path: a public repository when the organization restricts to private and internal", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+allowed_repository_visibilities:
+  - private
+  - internal
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/repo2.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo2
+spec:
+  public: true
+`), 0644)
+		assert.Nil(t, err)
+
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid visibility: public is not allowed by allowed_repository_visibilities in goliac.yaml for the repository repo2", errs[0].Error())
+	})
+
+	t.Run("not happy path: a repository matching ignored_repositories", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+ignored_repositories:
+  - bot-*
+  - "[invalid"
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/bot-cache.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: bot-cache
+`), 0644)
+		assert.Nil(t, err)
+
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+
+		assert.Equal(t, 2, len(errs))
+		assert.Equal(t, "invalid ignored_repositories pattern [invalid in goliac.yaml: syntax error in pattern", errs[0].Error())
+		assert.Equal(t, "invalid repository: bot-cache matches ignored_repositories in goliac.yaml", errs[1].Error())
+	})
+
+	t.Run("not happy path: an invalid default repository permission", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+org_settings:
+  default_repository_permission: maintain
+`), 0644)
+		assert.Nil(t, err)
+
+		g := NewGoliacLocalImpl()
+		errs, _ := g.LoadAndValidateLocal(fs)
+
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid org_settings.default_repository_permission maintain in goliac.yaml: must be read, write, admin or none", errs[0].Error())
+
+		_, err = readRepoConfig(fs)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("happy path: a valid default repository permission", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+		err := utils.WriteFile(fs, "goliac.yaml", []byte(`
+org_settings:
+  default_repository_permission: none
+`), 0644)
+		assert.Nil(t, err)
+
+		repoconfig, err := readRepoConfig(fs)
+		assert.Nil(t, err)
+		assert.Equal(t, "none", *repoconfig.OrgSettings.DefaultRepositoryPermission)
+	})
+
 	t.Run("happy path: local repository", func(t *testing.T) {
 		fs := memfs.New()
 		storer := memory.NewStorage()
@@ -195,7 +301,7 @@ func TestSyncUsersViaUserPlugin(t *testing.T) {
 		fs := memfs.New()
 		createBasicStructure(fs)
 
-		removed, added, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &UserSyncPluginNoop{})
+		removed, added, _, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &UserSyncPluginNoop{}, "", false)
 
 		assert.Nil(t, err)
 		assert.Equal(t, 0, len(removed))
@@ -206,7 +312,7 @@ func TestSyncUsersViaUserPlugin(t *testing.T) {
 		fs := memfs.New()
 		createBasicStructure(fs)
 
-		removed, added, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &ScrambleUserSync{})
+		removed, added, _, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &ScrambleUserSync{}, "", false)
 
 		assert.Nil(t, err)
 		assert.Equal(t, 1, len(removed))
@@ -218,10 +324,40 @@ func TestSyncUsersViaUserPlugin(t *testing.T) {
 		fs := memfs.New()
 		createBasicStructure(fs)
 
-		_, _, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &ErroreUserSync{})
+		_, _, _, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &ErroreUserSync{}, "", false)
 
 		assert.NotNil(t, err)
 	})
+
+	t.Run("happy path: users set unchanged since the last sync", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+
+		// what the plugin returns
+		users, _ := (&ScrambleUserSync{}).UpdateUsers(&config.RepositoryConfig{}, fs, "users/org")
+		syncState := usersSyncHash(users)
+
+		removed, added, hash, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &ScrambleUserSync{}, syncState, false)
+
+		assert.Nil(t, err)
+		assert.Equal(t, syncState, hash)
+		assert.Equal(t, 0, len(removed))
+		assert.Equal(t, 0, len(added))
+	})
+
+	t.Run("happy path: force a users set unchanged since the last sync", func(t *testing.T) {
+		fs := memfs.New()
+		createBasicStructure(fs)
+
+		users, _ := (&ScrambleUserSync{}).UpdateUsers(&config.RepositoryConfig{}, fs, "users/org")
+		syncState := usersSyncHash(users)
+
+		removed, added, _, err := syncUsersViaUserPlugin(&config.RepositoryConfig{}, fs, &ScrambleUserSync{}, syncState, true)
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(removed))
+		assert.Equal(t, 2, len(added))
+	})
 }
 
 func createEmptyTeamRepo(src billy.Filesystem) (*git.Repository, error) {
@@ -778,6 +914,71 @@ func TestGoliacLocalImpl(t *testing.T) {
 		content, err := utils.ReadFile(target, "users/org/foobar.yaml")
 		assert.Nil(t, err)
 		assert.Equal(t, "apiVersion: v1\nkind: User\nname: foobar\nspec:\n  githubID: foobar\n", string(content))
+
+		// the users set synced is committed
+		syncState, err := utils.ReadFile(target, USERS_SYNC_STATE_FILE)
+		assert.Nil(t, err)
+		users, _ := mockUserPlugin.UpdateUsers(goliacConfig, target, "users/org")
+		assert.Equal(t, usersSyncHash(users)+"\n", string(syncState))
+
+		// the plugin returns the same users set: the sync is skipped
+		target.Remove("users/org/foobar.yaml")
+		change, err = g.SyncUsersAndTeams(goliacConfig, mockUserPlugin, "none", false, false)
+		assert.Nil(t, err)
+		assert.False(t, change)
+		_, err = target.Stat("users/org/foobar.yaml")
+		assert.NotNil(t, err)
+
+		// unless forced
+		change, err = g.SyncUsersAndTeams(goliacConfig, mockUserPlugin, "none", false, true)
+		assert.Nil(t, err)
+		assert.True(t, change)
+		_, err = target.Stat("users/org/foobar.yaml")
+		assert.Nil(t, err)
+	})
+
+	t.Run("SyncUsersAndTeams to a target branch", func(t *testing.T) {
+		rootfs := memfs.New()
+		src, _ := rootfs.Chroot("/src")
+		target, _ := src.Chroot("/target")
+
+		repo, clonedRepo, err := helperCreateAndClone(rootfs, src, target)
+		assert.Nil(t, err)
+		assert.NotNil(t, repo)
+		assert.NotNil(t, clonedRepo)
+
+		masterRef, err := repo.Reference(plumbing.NewBranchReferenceName("master"), true)
+		assert.Nil(t, err)
+
+		g := GoliacLocalImpl{
+			teams:         map[string]*entity.Team{},
+			repositories:  map[string]*entity.Repository{},
+			users:         map[string]*entity.User{},
+			externalUsers: map[string]*entity.User{},
+			rulesets:      map[string]*entity.RuleSet{},
+			repo:          clonedRepo,
+		}
+		g.SetTargetBranch("goliac-sync")
+
+		goliacConfig, err := g.LoadRepoConfig()
+		assert.Nil(t, err)
+
+		change, err := g.SyncUsersAndTeams(goliacConfig, &UserSyncPluginMock{}, "none", false, false)
+		assert.Nil(t, err)
+		assert.True(t, change)
+		assert.True(t, g.TargetBranchPushed())
+
+		// the upstream master branch is untouched
+		ref, err := repo.Reference(plumbing.NewBranchReferenceName("master"), true)
+		assert.Nil(t, err)
+		assert.Equal(t, masterRef.Hash(), ref.Hash())
+
+		// the commit has been pushed to the target branch
+		ref, err = repo.Reference(plumbing.NewBranchReferenceName("goliac-sync"), true)
+		assert.Nil(t, err)
+		head, err := clonedRepo.Head()
+		assert.Nil(t, err)
+		assert.Equal(t, head.Hash(), ref.Hash())
 	})
 }
 
@@ -2,6 +2,7 @@ package engine
 
 import (
 	"context"
+	"strings"
 
 	"github.com/gosimple/slug"
 )
@@ -19,6 +20,51 @@ type MutableGoliacRemoteImpl struct {
 	teamSlugByName map[string]string
 	rulesets       map[string]*GithubRuleSet
 	appIds         map[string]int
+	orgVariables   map[string]*GithubOrgVariable
+	orgSecrets     map[string]*GithubOrgSecret
+
+	// org settings are lazy loaded (only if requested)
+	orgSettingsLoaded     bool
+	orgSettings           map[string]bool
+	orgPushProtectionLink string
+	orgDefaultPermission  string
+	loadOrgSettings       func() (map[string]bool, string, string)
+
+	// actions permissions are lazy loaded (only if requested)
+	actionsPermissions     map[string]*GithubActionsPermissions
+	loadActionsPermissions func() map[string]*GithubActionsPermissions
+
+	// security settings are lazy loaded (only if requested)
+	security     map[string]*GithubRepositorySecurity
+	loadSecurity func() map[string]*GithubRepositorySecurity
+
+	// webhooks are lazy loaded (only if requested)
+	webhooks     map[string]map[string]*GithubWebhook
+	loadWebhooks func() map[string]map[string]*GithubWebhook
+
+	// labels are lazy loaded (only if requested)
+	labels     map[string]map[string]*GithubLabel
+	loadLabels func() map[string]map[string]*GithubLabel
+
+	// repositories custom properties are lazy loaded (only if requested)
+	customPropertiesLoaded bool
+	loadCustomProperties   func() map[string]map[string]string
+
+	// teams external groups are lazy loaded (only if requested)
+	externalGroups     map[string][]string
+	loadExternalGroups func() map[string][]string
+
+	// SAML identities are read only (and lazy loaded)
+	loadSamlIdentities func() map[string]string
+
+	// custom properties definitions are read only (and lazy loaded)
+	loadCustomPropertiesDefinitions func() map[string]bool
+
+	// custom repository roles are read only (and lazy loaded)
+	loadCustomRepositoryRoles func() map[string]string
+
+	// members without two-factor authentication are read only (and lazy loaded)
+	loadMembersWithoutTwoFactor func() []string
 }
 
 func NewMutableGoliacRemoteImpl(ctx context.Context, remote GoliacRemote) *MutableGoliacRemoteImpl {
@@ -62,6 +108,24 @@ func NewMutableGoliacRemoteImpl(ctx context.Context, remote GoliacRemote) *Mutab
 		appids[k] = v
 	}
 
+	// nil if the org variables are not loaded
+	var orgVariables map[string]*GithubOrgVariable
+	if rOrgVariables := remote.OrgVariables(ctx); rOrgVariables != nil {
+		orgVariables = make(map[string]*GithubOrgVariable)
+		for k, v := range rOrgVariables {
+			orgVariables[k] = v
+		}
+	}
+
+	// nil if the org secrets are not loaded
+	var orgSecrets map[string]*GithubOrgSecret
+	if rOrgSecrets := remote.OrgSecrets(ctx); rOrgSecrets != nil {
+		orgSecrets = make(map[string]*GithubOrgSecret)
+		for k, v := range rOrgSecrets {
+			orgSecrets[k] = v
+		}
+	}
+
 	return &MutableGoliacRemoteImpl{
 		users:          rUsers,
 		repositories:   rRepositories,
@@ -70,6 +134,41 @@ func NewMutableGoliacRemoteImpl(ctx context.Context, remote GoliacRemote) *Mutab
 		teamSlugByName: rTeamSlugByName,
 		rulesets:       rulesets,
 		appIds:         appids,
+		orgVariables:   orgVariables,
+		orgSecrets:     orgSecrets,
+		loadOrgSettings: func() (map[string]bool, string, string) {
+			return remote.OrgSettings(ctx), remote.OrgPushProtectionCustomLink(ctx), remote.OrgDefaultRepositoryPermission(ctx)
+		},
+		loadActionsPermissions: func() map[string]*GithubActionsPermissions {
+			return remote.RepositoriesActionsPermissions(ctx)
+		},
+		loadSecurity: func() map[string]*GithubRepositorySecurity {
+			return remote.RepositoriesSecurity(ctx)
+		},
+		loadWebhooks: func() map[string]map[string]*GithubWebhook {
+			return remote.RepositoriesWebhooks(ctx)
+		},
+		loadLabels: func() map[string]map[string]*GithubLabel {
+			return remote.RepositoriesLabels(ctx)
+		},
+		loadCustomProperties: func() map[string]map[string]string {
+			return remote.RepositoriesCustomProperties(ctx)
+		},
+		loadExternalGroups: func() map[string][]string {
+			return remote.TeamsExternalGroups(ctx)
+		},
+		loadSamlIdentities: func() map[string]string {
+			return remote.SamlIdentities(ctx)
+		},
+		loadCustomPropertiesDefinitions: func() map[string]bool {
+			return remote.CustomPropertiesDefinitions(ctx)
+		},
+		loadCustomRepositoryRoles: func() map[string]string {
+			return remote.CustomRepositoryRoles(ctx)
+		},
+		loadMembersWithoutTwoFactor: func() []string {
+			return remote.MembersWithoutTwoFactor(ctx)
+		},
 	}
 }
 
@@ -96,6 +195,132 @@ func (m *MutableGoliacRemoteImpl) RuleSets() map[string]*GithubRuleSet {
 func (g *MutableGoliacRemoteImpl) AppIds() map[string]int {
 	return g.appIds
 }
+func (m *MutableGoliacRemoteImpl) OrgVariables() map[string]*GithubOrgVariable {
+	return m.orgVariables
+}
+func (m *MutableGoliacRemoteImpl) OrgSecrets() map[string]*GithubOrgSecret {
+	return m.orgSecrets
+}
+func (m *MutableGoliacRemoteImpl) ensureOrgSettings() {
+	if m.orgSettingsLoaded {
+		return
+	}
+	settings, link, permission := m.loadOrgSettings()
+	m.orgSettings = make(map[string]bool)
+	for k, v := range settings {
+		m.orgSettings[k] = v
+	}
+	m.orgPushProtectionLink = link
+	m.orgDefaultPermission = permission
+	m.orgSettingsLoaded = true
+}
+func (m *MutableGoliacRemoteImpl) OrgSettings() map[string]bool {
+	m.ensureOrgSettings()
+	return m.orgSettings
+}
+func (m *MutableGoliacRemoteImpl) OrgPushProtectionCustomLink() string {
+	m.ensureOrgSettings()
+	return m.orgPushProtectionLink
+}
+func (m *MutableGoliacRemoteImpl) OrgDefaultRepositoryPermission() string {
+	m.ensureOrgSettings()
+	return m.orgDefaultPermission
+}
+func (m *MutableGoliacRemoteImpl) RepositoriesActionsPermissions() map[string]*GithubActionsPermissions {
+	if m.actionsPermissions == nil {
+		m.actionsPermissions = make(map[string]*GithubActionsPermissions)
+		for k, v := range m.loadActionsPermissions() {
+			p := *v
+			m.actionsPermissions[k] = &p
+		}
+	}
+	return m.actionsPermissions
+}
+
+func (m *MutableGoliacRemoteImpl) SamlIdentities() map[string]string {
+	return m.loadSamlIdentities()
+}
+
+func (m *MutableGoliacRemoteImpl) CustomPropertiesDefinitions() map[string]bool {
+	return m.loadCustomPropertiesDefinitions()
+}
+
+func (m *MutableGoliacRemoteImpl) CustomRepositoryRoles() map[string]string {
+	return m.loadCustomRepositoryRoles()
+}
+
+func (m *MutableGoliacRemoteImpl) MembersWithoutTwoFactor() []string {
+	return m.loadMembersWithoutTwoFactor()
+}
+
+func (m *MutableGoliacRemoteImpl) RepositoriesSecurity() map[string]*GithubRepositorySecurity {
+	if m.security == nil {
+		m.security = make(map[string]*GithubRepositorySecurity)
+		for k, v := range m.loadSecurity() {
+			s := *v
+			m.security[k] = &s
+		}
+	}
+	return m.security
+}
+
+func (m *MutableGoliacRemoteImpl) RepositoriesWebhooks() map[string]map[string]*GithubWebhook {
+	if m.webhooks == nil {
+		m.webhooks = make(map[string]map[string]*GithubWebhook)
+		for reponame, hooks := range m.loadWebhooks() {
+			repoWebhooks := make(map[string]*GithubWebhook)
+			for url, h := range hooks {
+				w := *h
+				repoWebhooks[url] = &w
+			}
+			m.webhooks[reponame] = repoWebhooks
+		}
+	}
+	return m.webhooks
+}
+
+func (m *MutableGoliacRemoteImpl) TeamsExternalGroups() map[string][]string {
+	if m.externalGroups == nil {
+		m.externalGroups = make(map[string][]string)
+		for teamslug, groups := range m.loadExternalGroups() {
+			m.externalGroups[teamslug] = append([]string{}, groups...)
+		}
+	}
+	return m.externalGroups
+}
+
+func (m *MutableGoliacRemoteImpl) RepositoriesLabels() map[string]map[string]*GithubLabel {
+	if m.labels == nil {
+		m.labels = make(map[string]map[string]*GithubLabel)
+		for reponame, labels := range m.loadLabels() {
+			repoLabels := make(map[string]*GithubLabel)
+			for name, l := range labels {
+				label := *l
+				repoLabels[name] = &label
+			}
+			m.labels[reponame] = repoLabels
+		}
+	}
+	return m.labels
+}
+
+/*
+ * LoadRepositoriesCustomProperties populates the CustomProperties of the repositories
+ * (the first time it is called)
+ */
+func (m *MutableGoliacRemoteImpl) LoadRepositoriesCustomProperties() {
+	if m.customPropertiesLoaded {
+		return
+	}
+	m.customPropertiesLoaded = true
+	properties := m.loadCustomProperties()
+	for reponame, r := range m.repositories {
+		r.CustomProperties = make(map[string]string)
+		for k, v := range properties[reponame] {
+			r.CustomProperties[k] = v
+		}
+	}
+}
 
 // LISTENER
 
@@ -161,6 +386,40 @@ func (m *MutableGoliacRemoteImpl) UpdateTeamSetParent(ctx context.Context, dryru
 		t.ParentTeam = parentTeam
 	}
 }
+func (m *MutableGoliacRemoteImpl) UpdateTeamSetExternalGroups(teamslug string, groups []string) {
+	m.TeamsExternalGroups()[teamslug] = append([]string{}, groups...)
+}
+
+/*
+UpdateTeamUpdateProperty is used for
+- privacy
+- notification_setting
+*/
+func (m *MutableGoliacRemoteImpl) UpdateTeamUpdateProperty(teamslug string, propertyName string, propertyValue string) {
+	if t, ok := m.teams[teamslug]; ok {
+		switch propertyName {
+		case "privacy":
+			t.Privacy = propertyValue
+		case "notification_setting":
+			t.NotificationSetting = propertyValue
+		}
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateTeamRename(teamslug string, newname string) {
+	if t, ok := m.teams[teamslug]; ok {
+		newslug := slug.Make(newname)
+		delete(m.teams, teamslug)
+		delete(m.teamSlugByName, t.Name)
+		t.Name = newname
+		t.Slug = newslug
+		m.teams[newslug] = t
+		m.teamSlugByName[newname] = newslug
+		if tr, ok := m.teamRepos[teamslug]; ok {
+			delete(m.teamRepos, teamslug)
+			m.teamRepos[newslug] = tr
+		}
+	}
+}
 func (m *MutableGoliacRemoteImpl) DeleteTeam(teamslug string) {
 	if t, ok := m.teams[teamslug]; ok {
 		teamname := t.Name
@@ -173,7 +432,10 @@ func (m *MutableGoliacRemoteImpl) CreateRepository(reponame string, descrition s
 	r := GithubRepository{
 		Name:           reponame,
 		BoolProperties: boolProperties,
-		ExternalUsers:  map[string]string{},
+		StringProperties: map[string]string{
+			"description": descrition,
+		},
+		ExternalUsers: map[string]string{},
 	}
 	m.repositories[reponame] = &r
 }
@@ -182,14 +444,24 @@ func (m *MutableGoliacRemoteImpl) UpdateRepositoryAddTeamAccess(reponame string,
 		tr[reponame] = &GithubTeamRepo{
 			Name:       reponame,
 			Permission: permission,
+			RoleName:   customRoleName(permission),
 		}
 	}
 }
 
+// customRoleName returns the permission if it is a custom repository role
+func customRoleName(permission string) string {
+	if _, ok := basePermissions[permission]; ok {
+		return ""
+	}
+	return permission
+}
+
 func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateTeamAccess(reponame string, teamslug string, permission string) {
 	if tr, ok := m.teamRepos[teamslug]; ok {
 		if r, ok := tr[reponame]; ok {
 			r.Permission = permission
+			r.RoleName = customRoleName(permission)
 		}
 	}
 }
@@ -198,6 +470,14 @@ func (m *MutableGoliacRemoteImpl) UpdateRepositoryRemoveTeamAccess(reponame stri
 		delete(tr, reponame)
 	}
 }
+func (m *MutableGoliacRemoteImpl) TransferRepository(reponame string) {
+	m.repositories[reponame] = &GithubRepository{
+		Name:             reponame,
+		BoolProperties:   map[string]bool{},
+		StringProperties: map[string]string{},
+		ExternalUsers:    map[string]string{},
+	}
+}
 func (m *MutableGoliacRemoteImpl) DeleteRepository(reponame string) {
 	delete(m.repositories, reponame)
 }
@@ -209,12 +489,106 @@ UpdateRepositoryUpdateBoolProperty is used for
 - allow_auto_merge
 - delete_branch_on_merge
 - allow_update_branch
+- allow_merge_commit
+- allow_squash_merge
+- allow_rebase_merge
+- is_template
 */
 func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateBoolProperty(reponame string, propertyName string, propertyValue bool) {
 	if r, ok := m.repositories[reponame]; ok {
 		r.BoolProperties[propertyName] = propertyValue
 	}
 }
+
+/*
+UpdateRepositoryUpdateProperty is used for
+- description
+- homepage
+*/
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryUpdateProperty(reponame string, propertyName string, propertyValue string) {
+	if r, ok := m.repositories[reponame]; ok {
+		if r.StringProperties == nil {
+			r.StringProperties = make(map[string]string)
+		}
+		r.StringProperties[propertyName] = propertyValue
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositorySetRequiredSignatures(reponame string, enabled bool) {
+	if r, ok := m.repositories[reponame]; ok {
+		r.RequireSignedCommits = enabled
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositorySetDependabotAlerts(reponame string, enabled bool) {
+	if r, ok := m.repositories[reponame]; ok {
+		r.DependabotAlerts = enabled
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryActionsPermissions(reponame string, enabled bool, allowedActions string) {
+	if !enabled {
+		allowedActions = ""
+	}
+	m.RepositoriesActionsPermissions()[reponame] = &GithubActionsPermissions{
+		Enabled:        enabled,
+		AllowedActions: allowedActions,
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositorySetSecurity(reponame string, feature string, enabled bool) {
+	security := m.RepositoriesSecurity()[reponame]
+	if security == nil {
+		security = &GithubRepositorySecurity{}
+		m.RepositoriesSecurity()[reponame] = security
+	}
+	switch feature {
+	case "secret_scanning":
+		security.SecretScanning = enabled
+	case "secret_scanning_push_protection":
+		security.SecretScanningPushProtection = enabled
+	case "dependabot_security_updates":
+		security.DependabotSecurityUpdates = enabled
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryWebhook(reponame string, webhook *GithubWebhook) {
+	webhooks := m.RepositoriesWebhooks()
+	if _, ok := webhooks[reponame]; !ok {
+		webhooks[reponame] = make(map[string]*GithubWebhook)
+	}
+	w := *webhook
+	w.Secret = ""
+	webhooks[reponame][webhook.URL] = &w
+}
+func (m *MutableGoliacRemoteImpl) DeleteRepositoryWebhook(reponame string, webhook *GithubWebhook) {
+	delete(m.RepositoriesWebhooks()[reponame], webhook.URL)
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryLabel(reponame string, label *GithubLabel) {
+	labels := m.RepositoriesLabels()
+	if _, ok := labels[reponame]; !ok {
+		labels[reponame] = make(map[string]*GithubLabel)
+	}
+	l := *label
+	if l.Description == nil {
+		if previous, ok := labels[reponame][strings.ToLower(label.Name)]; ok {
+			l.Description = previous.Description
+		}
+	}
+	labels[reponame][strings.ToLower(label.Name)] = &l
+}
+func (m *MutableGoliacRemoteImpl) DeleteRepositoryLabel(reponame string, labelname string) {
+	delete(m.RepositoriesLabels()[reponame], strings.ToLower(labelname))
+}
+func (m *MutableGoliacRemoteImpl) UpdateRepositoryCustomProperties(reponame string, properties map[string]string) {
+	if r, ok := m.repositories[reponame]; ok {
+		if r.CustomProperties == nil {
+			r.CustomProperties = make(map[string]string)
+		}
+		for k, v := range properties {
+			if v == "" {
+				delete(r.CustomProperties, k)
+			} else {
+				r.CustomProperties[k] = v
+			}
+		}
+	}
+}
 func (m *MutableGoliacRemoteImpl) UpdateRepositorySetExternalUser(reponame string, collaboatorGithubId string, permission string) {
 	if r, ok := m.repositories[reponame]; ok {
 		r.ExternalUsers[collaboatorGithubId] = permission
@@ -235,3 +609,36 @@ func (m *MutableGoliacRemoteImpl) UpdateRuleset(ruleset *GithubRuleSet) {
 func (m *MutableGoliacRemoteImpl) DeleteRuleset(rulesetid int) {
 
 }
+func (m *MutableGoliacRemoteImpl) AddOrgVariable(variable *GithubOrgVariable) {
+	if m.orgVariables != nil {
+		m.orgVariables[variable.Name] = variable
+	}
+}
+func (m *MutableGoliacRemoteImpl) UpdateOrgVariable(variable *GithubOrgVariable) {
+	if m.orgVariables != nil {
+		m.orgVariables[variable.Name] = variable
+	}
+}
+func (m *MutableGoliacRemoteImpl) DeleteOrgVariable(variablename string) {
+	delete(m.orgVariables, variablename)
+}
+func (m *MutableGoliacRemoteImpl) UpdateOrgSecret(secret *GithubOrgSecret) {
+	if m.orgSecrets != nil {
+		m.orgSecrets[secret.Name] = secret
+	}
+}
+func (m *MutableGoliacRemoteImpl) DeleteOrgSecret(secretname string) {
+	delete(m.orgSecrets, secretname)
+}
+func (m *MutableGoliacRemoteImpl) UpdateOrgSetting(settingName string, settingValue bool) {
+	m.ensureOrgSettings()
+	m.orgSettings[settingName] = settingValue
+}
+func (m *MutableGoliacRemoteImpl) UpdateOrgPushProtectionCustomLink(link string) {
+	m.ensureOrgSettings()
+	m.orgPushProtectionLink = link
+}
+func (m *MutableGoliacRemoteImpl) UpdateOrgDefaultRepositoryPermission(permission string) {
+	m.ensureOrgSettings()
+	m.orgDefaultPermission = permission
+}
@@ -0,0 +1,212 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+/*
+ * PlannedAction is a machine readable description of a single operation
+ * the reconciliator wants to apply to Github.
+ * Its fields are part of the `goliac plan --format json` output, so
+ * they must remain stable across releases.
+ */
+type PlannedAction struct {
+	Operation string      `json:"operation"`        // same as the "command" log field (create_team, update_team_add_member, ...)
+	Target    string      `json:"target"`           // the resource operated on (team/<slug>, repository/<name>/team/<slug>, ...)
+	Before    interface{} `json:"before,omitempty"` // the current value on Github (if any)
+	After     interface{} `json:"after,omitempty"`  // the desired value (if any)
+	Reason    string      `json:"reason,omitempty"` // why the operation is planned (only shown by `goliac plan --explain`)
+}
+
+func (r *GoliacReconciliatorImpl) recordAction(operation string, target string, before interface{}, after interface{}) {
+	r.plannedActions = append(r.plannedActions, PlannedAction{
+		Operation: operation,
+		Target:    target,
+		Before:    before,
+		After:     after,
+	})
+}
+
+func (r *GoliacReconciliatorImpl) PlannedActions() []PlannedAction {
+	return r.plannedActions
+}
+
+/*
+ * WithoutReasons returns a copy of the planned actions without their reason
+ * (the reasons are only shown on demand)
+ */
+func WithoutReasons(actions []PlannedAction) []PlannedAction {
+	stripped := make([]PlannedAction, len(actions))
+	for i, action := range actions {
+		action.Reason = ""
+		stripped[i] = action
+	}
+	return stripped
+}
+
+/*
+ * changeType returns if an operation creates, updates or deletes
+ * a Github resource (used for the goliac_resources_changes_total metric)
+ */
+func changeType(operation string) string {
+	switch {
+	case strings.HasPrefix(operation, "create_"), strings.HasPrefix(operation, "add_"), strings.HasPrefix(operation, "transfer_"):
+		return "created"
+	case strings.HasPrefix(operation, "delete_"), strings.HasPrefix(operation, "remove_"):
+		return "deleted"
+	default:
+		return "updated"
+	}
+}
+
+/*
+ * IsDestructive returns if a planned action removes a resource (or an access,
+ * or a member), or archives a repository
+ */
+func IsDestructive(action PlannedAction) bool {
+	if changeType(action.Operation) == "deleted" || strings.Contains(action.Operation, "_remove_") {
+		return true
+	}
+	return action.Operation == "update_repository_update_bool_property" &&
+		strings.HasSuffix(action.Target, "/archived") &&
+		action.After == true
+}
+
+/*
+ * DestructiveActions returns only the destructive planned actions, followed by
+ * the ones blocked because the destructive operations are not allowed in
+ * goliac.yaml (with the "blocked" operation)
+ */
+func DestructiveActions(actions []PlannedAction, unmanaged *UnmanagedResources) []PlannedAction {
+	destructive := []PlannedAction{}
+	for _, action := range actions {
+		if IsDestructive(action) {
+			destructive = append(destructive, action)
+		}
+	}
+	if unmanaged == nil {
+		return destructive
+	}
+
+	blocked := []string{}
+	for githubid := range unmanaged.Users {
+		blocked = append(blocked, "user/"+githubid)
+	}
+	for teamslug := range unmanaged.Teams {
+		blocked = append(blocked, "team/"+teamslug)
+	}
+	for reponame := range unmanaged.Repositories {
+		blocked = append(blocked, "repository/"+reponame)
+	}
+	for rulesetid := range unmanaged.RuleSets {
+		blocked = append(blocked, fmt.Sprintf("ruleset/%d", rulesetid))
+	}
+	for secretname := range unmanaged.OrgSecrets {
+		blocked = append(blocked, "secret/"+secretname)
+	}
+	for variablename := range unmanaged.OrgVariables {
+		blocked = append(blocked, "variable/"+variablename)
+	}
+	for webhook := range unmanaged.Webhooks {
+		blocked = append(blocked, "repository/"+webhook)
+	}
+	for label := range unmanaged.Labels {
+		blocked = append(blocked, "repository/"+label)
+	}
+	for property := range unmanaged.CustomProperties {
+		blocked = append(blocked, "repository/"+property)
+	}
+	for teamslug := range unmanaged.TeamsExternalGroups {
+		blocked = append(blocked, "team/"+teamslug+"/external_groups")
+	}
+	sort.Strings(blocked)
+	for _, target := range blocked {
+		destructive = append(destructive, PlannedAction{
+			Operation: "blocked",
+			Target:    target,
+			Reason:    "the destructive operations are not allowed in goliac.yaml (destructive_operations)",
+		})
+	}
+	return destructive
+}
+
+/*
+ * destructiveChanges counts the repositories deleted or archived, the teams
+ * deleted or archived (renamed) and the org members removed by the planned actions
+ */
+func (r *GoliacReconciliatorImpl) destructiveChanges() (repositories int, teams int, members int) {
+	for _, action := range r.plannedActions {
+		switch action.Operation {
+		case "delete_repository":
+			repositories++
+		case "update_repository_update_bool_property":
+			if strings.HasSuffix(action.Target, "/archived") && action.After == true {
+				repositories++
+			}
+		case "delete_team":
+			teams++
+		case "update_team_rename":
+			if newname, ok := action.After.(string); ok && strings.HasPrefix(newname, r.archivedTeamPrefix()) {
+				teams++
+			}
+		case "remove_user_from_org":
+			members++
+		}
+	}
+	return repositories, teams, members
+}
+
+/*
+ * checkMaxDestructiveChanges returns an error if the planned actions delete (or
+ * archive) more resources than allowed by max_destructive_changes in goliac.yaml,
+ * including the ones of the previous commits of the apply (KeyDestructiveChanges).
+ * In dryrun it only warns: the plan still shows all the changes
+ */
+func (r *GoliacReconciliatorImpl) checkMaxDestructiveChanges(ctx context.Context, dryrun bool) error {
+	repositories, teams, members := r.destructiveChanges()
+	previous, _ := ctx.Value(KeyDestructiveChanges).(*DestructiveChanges)
+	if previous != nil {
+		repositories += previous.Repositories
+		teams += previous.Teams
+		members += previous.Members
+	}
+	limits := r.repoconfig.MaxDestructiveChanges
+	exceeded := []string{}
+	if limits.Repositories > 0 && repositories > limits.Repositories {
+		exceeded = append(exceeded, fmt.Sprintf("%d repositories deleted or archived (max_destructive_changes.repositories is %d)", repositories, limits.Repositories))
+	}
+	if limits.Teams > 0 && teams > limits.Teams {
+		exceeded = append(exceeded, fmt.Sprintf("%d teams deleted or archived (max_destructive_changes.teams is %d)", teams, limits.Teams))
+	}
+	if limits.Members > 0 && members > limits.Members {
+		exceeded = append(exceeded, fmt.Sprintf("%d members removed (max_destructive_changes.members is %d)", members, limits.Members))
+	}
+	if len(exceeded) == 0 {
+		return nil
+	}
+	if dryrun {
+		logrus.Warnf("the apply would be aborted: %s", strings.Join(exceeded, ", "))
+		return nil
+	}
+	return fmt.Errorf("too many destructive changes: %s. Aborting (raise max_destructive_changes in goliac.yaml if they are expected)", strings.Join(exceeded, ", "))
+}
+
+/*
+ * addDestructiveChanges adds the destructive changes of the (committed)
+ * planned actions to the ones of the apply (KeyDestructiveChanges)
+ */
+func (r *GoliacReconciliatorImpl) addDestructiveChanges(ctx context.Context) {
+	total, _ := ctx.Value(KeyDestructiveChanges).(*DestructiveChanges)
+	if total == nil {
+		return
+	}
+	repositories, teams, members := r.destructiveChanges()
+	total.Repositories += repositories
+	total.Teams += teams
+	total.Members += members
+}
@@ -11,16 +11,40 @@ type ReconciliatorExecutor interface {
 	UpdateTeamUpdateMember(ctx context.Context, dryrun bool, teamslug string, username string, role string) // role can be 'member' or 'maintainer'
 	UpdateTeamRemoveMember(ctx context.Context, dryrun bool, teamslug string, username string)
 	UpdateTeamSetParent(ctx context.Context, dryrun bool, teamslug string, parentTeam *int)
+	UpdateTeamRename(ctx context.Context, dryrun bool, teamslug string, newname string)
+	UpdateTeamUpdateProperty(ctx context.Context, dryrun bool, teamslug string, propertyName string, propertyValue string) // propertyName can be "privacy" or "notification_setting"
+	UpdateTeamSetExternalGroups(ctx context.Context, dryrun bool, teamslug string, groups []string)                        // groups are the names of the IdP groups (team synchronization)
 	DeleteTeam(ctx context.Context, dryrun bool, teamslug string)
 
-	CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool)
+	CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool)
+	TransferRepository(ctx context.Context, dryrun bool, reponame string, source string) // source is <owner>/<name>. Returns once the repository is available in the organization
 	UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool)
-	UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string)    // permission can be "pull", "push", or "admin" which correspond to read, write, and admin access.
-	UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) // permission can be "pull", "push", or "admin" which correspond to read, write, and admin access.
+	UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) // propertyName can be "description" or "homepage"
+	UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string)         // permission can be "pull", "push", or "admin" which correspond to read, write, and admin access.
+	UpdateRepositoryUpdateTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string)      // permission can be "pull", "push", or "admin" which correspond to read, write, and admin access.
 	UpdateRepositoryRemoveTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string)
+	UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, reponame string, enabled bool) // classic branch protection on the default branch
+	UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool)
+	UpdateRepositorySetSecurity(ctx context.Context, dryrun bool, reponame string, feature string, enabled bool)               // feature can be "secret_scanning", "secret_scanning_push_protection" or "dependabot_security_updates"
+	UpdateRepositoryActionsPermissions(ctx context.Context, dryrun bool, reponame string, enabled bool, allowedActions string) // allowedActions can be "all", "local_only" or "selected"
+	UpdateRepositoryCustomProperties(ctx context.Context, dryrun bool, reponame string, properties map[string]string)          // an empty value unsets the property
+	AddRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook)
+	UpdateRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) // the webhook Id must be set
+	DeleteRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) // the webhook Id must be set
+	CreateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, label *GithubLabel)
+	UpdateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string, label *GithubLabel) // labelname is the current name of the label
+	DeleteRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string)
 	AddRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet)
 	UpdateRuleset(ctx context.Context, dryrun bool, ruleset *GithubRuleSet)
 	DeleteRuleset(ctx context.Context, dryrun bool, rulesetid int)
+	AddOrgVariable(ctx context.Context, dryrun bool, variable *GithubOrgVariable)
+	UpdateOrgVariable(ctx context.Context, dryrun bool, variable *GithubOrgVariable)
+	DeleteOrgVariable(ctx context.Context, dryrun bool, variablename string)
+	UpdateOrgSecret(ctx context.Context, dryrun bool, secret *GithubOrgSecret) // only the visibility, the value is never changed
+	DeleteOrgSecret(ctx context.Context, dryrun bool, secretname string)
+	UpdateOrgPushProtectionCustomLink(ctx context.Context, dryrun bool, link string)                                       // an empty link disables it
+	UpdateOrgDefaultRepositoryPermission(ctx context.Context, dryrun bool, permission string)                              // permission can be "read", "write", "admin" or "none"
+	UpdateOrgSetting(ctx context.Context, dryrun bool, settingName string, settingValue bool)                              // settingName can be "members_can_create_pages", "members_can_create_private_pages" or "members_can_create_internal_repositories"
 	UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) // permission can be "pull" or "push"
 	UpdateRepositoryRemoveExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string)
 	DeleteRepository(ctx context.Context, dryrun bool, reponame string)
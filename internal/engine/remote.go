@@ -3,7 +3,11 @@ package engine
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,6 +21,8 @@ import (
 )
 
 const FORLOOP_STOP = 100
+const TRANSFER_MAX_ATTEMPTS = 6
+const GRAPHQL_MAX_PAGE_SIZE = 100
 
 /*
  * GoliacRemote
@@ -40,6 +46,34 @@ type GoliacRemote interface {
 	TeamRepositories(ctx context.Context) map[string]map[string]*GithubTeamRepo // key is team slug, second key is repo name
 	RuleSets(ctx context.Context) map[string]*GithubRuleSet
 	AppIds(ctx context.Context) map[string]int
+	OrgVariables(ctx context.Context) map[string]*GithubOrgVariable // the key is the variable name (nil if not loaded)
+	OrgSecrets(ctx context.Context) map[string]*GithubOrgSecret     // the key is the secret name (nil if not loaded)
+	OrgSettings(ctx context.Context) map[string]bool                // members_can_create_pages, members_can_create_private_pages, members_can_create_internal_repositories, two_factor_requirement_enabled (read only)
+	OrgPushProtectionCustomLink(ctx context.Context) string         // link shown when the secret scanning push protection blocks a push (empty if not enabled)
+	OrgDefaultRepositoryPermission(ctx context.Context) string      // base permission of the members on the repositories: read, write, admin or none
+
+	// the key is the repository name. Lazy loaded: it costs one call per repository
+	RepositoriesActionsPermissions(ctx context.Context) map[string]*GithubActionsPermissions
+	// the key is the repository name. Lazy loaded: it costs one call per repository
+	RepositoriesSecurity(ctx context.Context) map[string]*GithubRepositorySecurity
+	// the key is the repository name, the second key the webhook url. Lazy loaded: it costs one call per repository
+	RepositoriesWebhooks(ctx context.Context) map[string]map[string]*GithubWebhook
+	// the key is the repository name, the second key the label name in lower case. Lazy loaded: it costs one call per repository
+	RepositoriesLabels(ctx context.Context) map[string]map[string]*GithubLabel
+	// the key is the repository name, the second key the custom property name. Lazy loaded: it costs one call per repository
+	RepositoriesCustomProperties(ctx context.Context) map[string]map[string]string
+	// the custom properties defined at the organization level (the key is the property name). nil if not loaded. Lazy loaded
+	CustomPropertiesDefinitions(ctx context.Context) map[string]bool
+	// the custom repository roles defined at the organization level (the key is the role name, the value its base role). nil if not loaded. Lazy loaded
+	CustomRepositoryRoles(ctx context.Context) map[string]string
+	// the members (githubids) without two-factor authentication. nil if not loaded. Lazy loaded
+	MembersWithoutTwoFactor(ctx context.Context) []string
+	// the verified (or approved) domains of the organization (sorted). nil if not loaded. Lazy loaded
+	VerifiedDomains(ctx context.Context) []string
+	// the key is the github login, the value is the SAML nameId. nil if the organization doesn't use SAML. Lazy loaded
+	SamlIdentities(ctx context.Context) map[string]string
+	// the key is the team slug, the value the (sorted) names of the IdP groups mapped with the team synchronization. Lazy loaded: it costs one call per team
+	TeamsExternalGroups(ctx context.Context) map[string][]string
 
 	IsEnterprise() bool // check if we are on an Enterprise version, or if we are on GHES 3.11+
 }
@@ -50,25 +84,88 @@ type GoliacRemoteExecutor interface {
 }
 
 type GithubRepository struct {
-	Name           string
-	Id             int
-	RefId          string
-	BoolProperties map[string]bool   // archived, private, allow_auto_merge, delete_branch_on_merge, allow_update_branch
-	ExternalUsers  map[string]string // [githubid]permission
+	Name             string
+	Id               int
+	RefId            string
+	BoolProperties   map[string]bool   // archived, private, allow_auto_merge, delete_branch_on_merge, allow_update_branch, allow_merge_commit, allow_squash_merge, allow_rebase_merge, is_template
+	StringProperties map[string]string // description, homepage
+	ExternalUsers    map[string]string // [githubid]permission
+
+	DefaultBranch          string
+	DefaultBranchProtected bool       // is there a (classic) branch protection on the default branch
+	RequireSignedCommits   bool       // (classic) branch protection on the default branch
+	RequireStatusChecks    bool       // (classic) branch protection on the default branch
+	DependabotAlerts       bool       // vulnerability alerts enabled
+	PushedAt               *time.Time // last push (nil if the repository was never pushed)
+	TemplateRepository     string     // <owner>/<name> of the template the repository was generated from (if any)
+
+	CustomProperties map[string]string // [property name]value. nil if not loaded (lazy loaded, see RepositoriesCustomProperties)
 }
 
-type GithubTeam struct {
-	Name        string
+type GithubRepositorySecurity struct {
+	SecretScanning               bool
+	SecretScanningPushProtection bool
+	DependabotSecurityUpdates    bool
+}
+
+// Features returns the security settings indexed by their Github name
+func (s *GithubRepositorySecurity) Features() map[string]bool {
+	return map[string]bool{
+		"secret_scanning":                 s.SecretScanning,
+		"secret_scanning_push_protection": s.SecretScanningPushProtection,
+		"dependabot_security_updates":     s.DependabotSecurityUpdates,
+	}
+}
+
+type GithubWebhook struct {
 	Id          int
-	Slug        string
-	Members     []string // user login, aka githubid
-	Maintainers []string // user login (that are not in the Members array)
-	ParentTeam  *int
+	URL         string
+	Events      []string
+	ContentType string // json or form
+	Active      bool
+	Secret      string `json:"-"` // only set locally (Github never returns it), must never be logged
+}
+
+type GithubLabel struct {
+	Name        string
+	Color       string  // hexadecimal color code, without the leading '#'
+	Description *string // nil if not managed by Goliac (local only)
+}
+
+type GithubTeam struct {
+	Name                string
+	Id                  int
+	Slug                string
+	Members             []string // user login, aka githubid
+	Maintainers         []string // user login (that are not in the Members array)
+	ParentTeam          *int
+	Privacy             string // secret or closed
+	NotificationSetting string // notifications_enabled or notifications_disabled
+}
+
+type GithubOrgVariable struct {
+	Name                 string
+	Value                string
+	Visibility           string   // all, private, selected
+	SelectedRepositories []string // repository names, only used if visibility is selected
+}
+
+// the value of a secret is never returned by Github (and not managed by Goliac)
+type GithubOrgSecret struct {
+	Name                 string
+	Visibility           string   // all, private, selected
+	SelectedRepositories []string // repository names, only used if visibility is selected
+}
+
+type GithubActionsPermissions struct {
+	Enabled        bool
+	AllowedActions string // all, local_only, selected (empty if Actions are disabled)
 }
 
 type GithubTeamRepo struct {
 	Name       string // repository name
 	Permission string // possible values: ADMIN, MAINTAIN, WRITE, TRIAGE, READ
+	RoleName   string // the custom repository role granted (if any)
 }
 
 type GoliacRemoteImpl struct {
@@ -81,12 +178,44 @@ type GoliacRemoteImpl struct {
 	teamSlugByName        map[string]string
 	rulesets              map[string]*GithubRuleSet
 	appIds                map[string]int
+	orgVariables          map[string]*GithubOrgVariable
+	orgSecrets            map[string]*GithubOrgSecret
+	orgSettings           map[string]bool
+	orgPushProtectionLink string
+	orgDefaultPermission  string
+	actionsPermissions    map[string]*GithubActionsPermissions
+	customProperties      map[string]map[string]string
+	security              map[string]*GithubRepositorySecurity
+	webhooks              map[string]map[string]*GithubWebhook
+	labels                map[string]map[string]*GithubLabel
+	samlIdentities        map[string]string
+	externalGroups        map[string][]string
+	customPropsDefs       map[string]bool
+	customRoles           map[string]string
+	membersWithout2FA     []string
+	verifiedDomains       []string
+	actionMutex           sync.Mutex    // protects the in-memory cache updates done by the (concurrent) actions
+	transferPollDelay     time.Duration // initial delay, doubled after each attempt, to wait for a repository transfer
 	ttlExpireUsers        time.Time
 	ttlExpireRepositories time.Time
 	ttlExpireTeams        time.Time
 	ttlExpireTeamsRepos   time.Time
 	ttlExpireRulesets     time.Time
 	ttlExpireAppIds       time.Time
+	ttlExpireOrgVariables time.Time
+	ttlExpireOrgSecrets   time.Time
+	ttlExpireOrgSettings  time.Time
+	ttlExpireActionsPerms time.Time
+	ttlExpireCustomProps  time.Time
+	ttlExpireSecurity     time.Time
+	ttlExpireWebhooks     time.Time
+	ttlExpireLabels       time.Time
+	ttlExpireSaml         time.Time
+	ttlExpireExtGroups    time.Time
+	ttlExpireCustomDefs   time.Time
+	ttlExpireCustomRoles  time.Time
+	ttlExpire2FA          time.Time
+	ttlExpireDomains      time.Time
 	isEnterprise          bool
 }
 
@@ -167,12 +296,32 @@ func NewGoliacRemoteImpl(client github.GitHubClient) *GoliacRemoteImpl {
 		teamSlugByName:        make(map[string]string),
 		rulesets:              make(map[string]*GithubRuleSet),
 		appIds:                make(map[string]int),
+		orgSettings:           make(map[string]bool),
+		actionsPermissions:    make(map[string]*GithubActionsPermissions),
+		customProperties:      make(map[string]map[string]string),
+		security:              make(map[string]*GithubRepositorySecurity),
+		webhooks:              make(map[string]map[string]*GithubWebhook),
+		labels:                make(map[string]map[string]*GithubLabel),
 		ttlExpireUsers:        time.Now(),
 		ttlExpireRepositories: time.Now(),
 		ttlExpireTeams:        time.Now(),
 		ttlExpireTeamsRepos:   time.Now(),
 		ttlExpireRulesets:     time.Now(),
 		ttlExpireAppIds:       time.Now(),
+		ttlExpireOrgVariables: time.Now(),
+		ttlExpireOrgSecrets:   time.Now(),
+		ttlExpireOrgSettings:  time.Now(),
+		ttlExpireActionsPerms: time.Now(),
+		ttlExpireCustomProps:  time.Now(),
+		ttlExpireSecurity:     time.Now(),
+		ttlExpireWebhooks:     time.Now(),
+		ttlExpireLabels:       time.Now(),
+		ttlExpireSaml:         time.Now(),
+		ttlExpireCustomDefs:   time.Now(),
+		ttlExpireCustomRoles:  time.Now(),
+		ttlExpire2FA:          time.Now(),
+		ttlExpireDomains:      time.Now(),
+		transferPollDelay:     2 * time.Second,
 		isEnterprise:          isEnterprise(ctx, config.Config.GithubAppOrganization, client),
 	}
 }
@@ -193,6 +342,20 @@ func (g *GoliacRemoteImpl) FlushCache() {
 	g.ttlExpireTeamsRepos = time.Now()
 	g.ttlExpireRulesets = time.Now()
 	g.ttlExpireAppIds = time.Now()
+	g.ttlExpireOrgVariables = time.Now()
+	g.ttlExpireOrgSecrets = time.Now()
+	g.ttlExpireOrgSettings = time.Now()
+	g.ttlExpireActionsPerms = time.Now()
+	g.ttlExpireCustomProps = time.Now()
+	g.ttlExpireSecurity = time.Now()
+	g.ttlExpireWebhooks = time.Now()
+	g.ttlExpireLabels = time.Now()
+	g.ttlExpireSaml = time.Now()
+	g.ttlExpireExtGroups = time.Now()
+	g.ttlExpireCustomDefs = time.Now()
+	g.ttlExpireCustomRoles = time.Now()
+	g.ttlExpire2FA = time.Now()
+	g.ttlExpireDomains = time.Now()
 }
 
 func (g *GoliacRemoteImpl) RuleSets(ctx context.Context) map[string]*GithubRuleSet {
@@ -217,6 +380,211 @@ func (g *GoliacRemoteImpl) AppIds(ctx context.Context) map[string]int {
 	return g.appIds
 }
 
+func (g *GoliacRemoteImpl) OrgVariables(ctx context.Context) map[string]*GithubOrgVariable {
+	if time.Now().After(g.ttlExpireOrgVariables) {
+		variables, err := g.loadOrgVariables(ctx)
+		if err == nil {
+			g.orgVariables = variables
+			g.ttlExpireOrgVariables = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the org variables are not reconciled if they are not loaded
+			logrus.Warnf("Error loading org variables: %v", err)
+		}
+	}
+	return g.orgVariables
+}
+
+func (g *GoliacRemoteImpl) OrgSecrets(ctx context.Context) map[string]*GithubOrgSecret {
+	if time.Now().After(g.ttlExpireOrgSecrets) {
+		secrets, err := g.loadOrgSecrets(ctx)
+		if err == nil {
+			g.orgSecrets = secrets
+			g.ttlExpireOrgSecrets = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the org secrets are not reconciled if they are not loaded
+			logrus.Warnf("Error loading org secrets: %v", err)
+		}
+	}
+	return g.orgSecrets
+}
+
+func (g *GoliacRemoteImpl) OrgSettings(ctx context.Context) map[string]bool {
+	if time.Now().After(g.ttlExpireOrgSettings) {
+		settings, pushProtectionLink, defaultPermission, err := g.loadOrgSettings(ctx)
+		if err == nil {
+			g.orgSettings = settings
+			g.orgPushProtectionLink = pushProtectionLink
+			g.orgDefaultPermission = defaultPermission
+			g.ttlExpireOrgSettings = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			logrus.Debugf("Error loading org settings: %v", err)
+		}
+	}
+	return g.orgSettings
+}
+
+func (g *GoliacRemoteImpl) OrgPushProtectionCustomLink(ctx context.Context) string {
+	// loaded with the org settings
+	g.OrgSettings(ctx)
+	return g.orgPushProtectionLink
+}
+
+func (g *GoliacRemoteImpl) OrgDefaultRepositoryPermission(ctx context.Context) string {
+	// loaded with the org settings
+	g.OrgSettings(ctx)
+	return g.orgDefaultPermission
+}
+
+func (g *GoliacRemoteImpl) RepositoriesActionsPermissions(ctx context.Context) map[string]*GithubActionsPermissions {
+	if time.Now().After(g.ttlExpireActionsPerms) {
+		permissions, err := g.loadRepositoriesActionsPermissions(ctx)
+		if err == nil {
+			g.actionsPermissions = permissions
+			g.ttlExpireActionsPerms = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the actions permissions are not reconciled if they are not loaded
+			logrus.Warnf("Error loading repositories actions permissions: %v", err)
+		}
+	}
+	return g.actionsPermissions
+}
+
+func (g *GoliacRemoteImpl) RepositoriesSecurity(ctx context.Context) map[string]*GithubRepositorySecurity {
+	if time.Now().After(g.ttlExpireSecurity) {
+		security, err := g.loadRepositoriesSecurity(ctx)
+		if err == nil {
+			g.security = security
+			g.ttlExpireSecurity = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the security settings are not reconciled if they are not loaded
+			logrus.Warnf("Error loading repositories security settings: %v", err)
+		}
+	}
+	return g.security
+}
+
+func (g *GoliacRemoteImpl) RepositoriesWebhooks(ctx context.Context) map[string]map[string]*GithubWebhook {
+	if time.Now().After(g.ttlExpireWebhooks) {
+		webhooks, err := g.loadRepositoriesWebhooks(ctx)
+		if err == nil {
+			g.webhooks = webhooks
+			g.ttlExpireWebhooks = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the webhooks are not reconciled if they are not loaded
+			logrus.Warnf("Error loading repositories webhooks: %v", err)
+		}
+	}
+	return g.webhooks
+}
+
+func (g *GoliacRemoteImpl) RepositoriesLabels(ctx context.Context) map[string]map[string]*GithubLabel {
+	if time.Now().After(g.ttlExpireLabels) {
+		labels, err := g.loadRepositoriesLabels(ctx)
+		if err == nil {
+			g.labels = labels
+			g.ttlExpireLabels = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the labels are not reconciled if they are not loaded
+			logrus.Warnf("Error loading repositories labels: %v", err)
+		}
+	}
+	return g.labels
+}
+
+func (g *GoliacRemoteImpl) SamlIdentities(ctx context.Context) map[string]string {
+	if time.Now().After(g.ttlExpireSaml) {
+		identities, err := LoadGithubSamlIdentities(ctx, g.client)
+		if err == nil {
+			g.samlIdentities = identities
+			g.ttlExpireSaml = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			logrus.Debugf("Error loading SAML identities: %v", err)
+		}
+	}
+	return g.samlIdentities
+}
+
+func (g *GoliacRemoteImpl) TeamsExternalGroups(ctx context.Context) map[string][]string {
+	if time.Now().After(g.ttlExpireExtGroups) {
+		externalGroups, err := g.loadTeamsExternalGroups(ctx)
+		if err == nil {
+			g.externalGroups = externalGroups
+			g.ttlExpireExtGroups = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the external groups are not reconciled if they are not loaded
+			logrus.Warnf("Error loading teams external groups: %v", err)
+		}
+	}
+	return g.externalGroups
+}
+
+func (g *GoliacRemoteImpl) MembersWithoutTwoFactor(ctx context.Context) []string {
+	if time.Now().After(g.ttlExpire2FA) {
+		members, err := g.loadMembersWithoutTwoFactor(ctx)
+		if err == nil {
+			g.membersWithout2FA = members
+			g.ttlExpire2FA = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			logrus.Debugf("Error loading members without two-factor authentication: %v", err)
+		}
+	}
+	return g.membersWithout2FA
+}
+
+func (g *GoliacRemoteImpl) VerifiedDomains(ctx context.Context) []string {
+	if time.Now().After(g.ttlExpireDomains) {
+		domains, err := g.loadVerifiedDomains(ctx)
+		if err == nil {
+			g.verifiedDomains = domains
+			g.ttlExpireDomains = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			logrus.Debugf("Error loading verified domains: %v", err)
+		}
+	}
+	return g.verifiedDomains
+}
+
+func (g *GoliacRemoteImpl) CustomRepositoryRoles(ctx context.Context) map[string]string {
+	if time.Now().After(g.ttlExpireCustomRoles) {
+		roles, err := g.loadCustomRepositoryRoles(ctx)
+		if err == nil {
+			g.customRoles = roles
+			g.ttlExpireCustomRoles = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			logrus.Debugf("Error loading custom repository roles: %v", err)
+		}
+	}
+	return g.customRoles
+}
+
+func (g *GoliacRemoteImpl) CustomPropertiesDefinitions(ctx context.Context) map[string]bool {
+	if time.Now().After(g.ttlExpireCustomDefs) {
+		definitions, err := g.loadCustomPropertiesDefinitions(ctx)
+		if err == nil {
+			g.customPropsDefs = definitions
+			g.ttlExpireCustomDefs = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the custom properties are all sent if their definitions are not loaded
+			logrus.Warnf("Error loading custom properties definitions: %v", err)
+		}
+	}
+	return g.customPropsDefs
+}
+
+func (g *GoliacRemoteImpl) RepositoriesCustomProperties(ctx context.Context) map[string]map[string]string {
+	if time.Now().After(g.ttlExpireCustomProps) {
+		properties, err := g.loadRepositoriesCustomProperties(ctx)
+		if err == nil {
+			g.customProperties = properties
+			g.ttlExpireCustomProps = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		} else {
+			// the custom properties are not reconciled if they are not loaded
+			logrus.Warnf("Error loading repositories custom properties: %v", err)
+		}
+	}
+	return g.customProperties
+}
+
 func (g *GoliacRemoteImpl) Users(ctx context.Context) map[string]string {
 	if time.Now().After(g.ttlExpireUsers) {
 		users, err := g.loadOrgUsers(ctx)
@@ -303,6 +671,53 @@ query listAllReposInOrg($orgLogin: String!, $endCursor: String) {
 }
 `
 
+// hasTwoFactorEnabled is only visible to the organization owners (else null)
+const listAllOrgMembersTwoFactor = `
+query listAllOrgMembersTwoFactor($orgLogin: String!, $endCursor: String) {
+    organization(login: $orgLogin) {
+		membersWithRole(first: 100, after: $endCursor) {
+		  edges {
+            node {
+              login
+            }
+            hasTwoFactorEnabled
+          }
+          pageInfo {
+            hasNextPage
+            endCursor
+          }
+        }
+    }
+}
+`
+
+type GraplQLUsersTwoFactor struct {
+	Data struct {
+		Organization struct {
+			MembersWithRole struct {
+				Edges []struct {
+					Node struct {
+						Login string
+					} `json:"node"`
+					HasTwoFactorEnabled *bool `json:"hasTwoFactorEnabled"`
+				} `json:"edges"`
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				} `json:"pageInfo"`
+			} `json:"membersWithRole"`
+		}
+	}
+	Errors []struct {
+		Path       []interface{} `json:"path"`
+		Extensions struct {
+			Code         string
+			ErrorMessage string
+		} `json:"extensions"`
+		Message string
+	} `json:"errors"`
+}
+
 type GraplQLUsers struct {
 	Data struct {
 		Organization struct {
@@ -336,6 +751,151 @@ loadOrgUsers returns
 map[githubid]permission (role)
 role can be 'ADMIN', 'MEMBER'
 */
+/*
+ * loadMembersWithoutTwoFactor returns the organization members (githubids, sorted)
+ * without two-factor authentication
+ */
+func (g *GoliacRemoteImpl) loadMembersWithoutTwoFactor(ctx context.Context) ([]string, error) {
+	logrus.Debug("loading members without two-factor authentication")
+	members := []string{}
+
+	variables := make(map[string]interface{})
+	variables["orgLogin"] = config.Config.GithubAppOrganization
+	variables["endCursor"] = nil
+
+	hasNextPage := true
+	count := 0
+	for hasNextPage {
+		data, err := g.client.QueryGraphQLAPI(ctx, listAllOrgMembersTwoFactor, variables)
+		if err != nil {
+			return nil, err
+		}
+		var gResult GraplQLUsersTwoFactor
+
+		err = json.Unmarshal(data, &gResult)
+		if err != nil {
+			return nil, err
+		}
+		if len(gResult.Errors) > 0 {
+			return nil, fmt.Errorf("graphql error on loadMembersWithoutTwoFactor: %v (%v)", gResult.Errors[0].Message, gResult.Errors[0].Path)
+		}
+
+		for _, c := range gResult.Data.Organization.MembersWithRole.Edges {
+			if c.HasTwoFactorEnabled == nil {
+				return nil, fmt.Errorf("the two-factor authentication status of the members is not visible (only to the organization owners)")
+			}
+			if !*c.HasTwoFactorEnabled {
+				members = append(members, c.Node.Login)
+			}
+		}
+
+		hasNextPage = gResult.Data.Organization.MembersWithRole.PageInfo.HasNextPage
+		variables["endCursor"] = gResult.Data.Organization.MembersWithRole.PageInfo.EndCursor
+
+		count++
+		// sanity check to avoid loops
+		if count > FORLOOP_STOP {
+			break
+		}
+	}
+
+	sort.Strings(members)
+	return members, nil
+}
+
+const listAllOrgDomains = `
+query listAllOrgDomains($orgLogin: String!, $endCursor: String) {
+    organization(login: $orgLogin) {
+		domains(first: 100, after: $endCursor) {
+          nodes {
+            domain
+            isVerified
+            isApproved
+          }
+          pageInfo {
+            hasNextPage
+            endCursor
+          }
+        }
+    }
+}
+`
+
+type GraplQLOrgDomains struct {
+	Data struct {
+		Organization struct {
+			Domains struct {
+				Nodes []struct {
+					Domain     string
+					IsVerified bool
+					IsApproved bool
+				} `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				} `json:"pageInfo"`
+			} `json:"domains"`
+		}
+	}
+	Errors []struct {
+		Path       []interface{} `json:"path"`
+		Extensions struct {
+			Code         string
+			ErrorMessage string
+		} `json:"extensions"`
+		Message string
+	} `json:"errors"`
+}
+
+/*
+ * loadVerifiedDomains returns the verified or approved domains (sorted) of the
+ * organization (only visible to the organization owners)
+ */
+func (g *GoliacRemoteImpl) loadVerifiedDomains(ctx context.Context) ([]string, error) {
+	logrus.Debug("loading verified domains")
+	domains := []string{}
+
+	variables := make(map[string]interface{})
+	variables["orgLogin"] = config.Config.GithubAppOrganization
+	variables["endCursor"] = nil
+
+	hasNextPage := true
+	count := 0
+	for hasNextPage {
+		data, err := g.client.QueryGraphQLAPI(ctx, listAllOrgDomains, variables)
+		if err != nil {
+			return nil, err
+		}
+		var gResult GraplQLOrgDomains
+
+		err = json.Unmarshal(data, &gResult)
+		if err != nil {
+			return nil, err
+		}
+		if len(gResult.Errors) > 0 {
+			return nil, fmt.Errorf("graphql error on loadVerifiedDomains: %v (%v)", gResult.Errors[0].Message, gResult.Errors[0].Path)
+		}
+
+		for _, d := range gResult.Data.Organization.Domains.Nodes {
+			if d.IsVerified || d.IsApproved {
+				domains = append(domains, d.Domain)
+			}
+		}
+
+		hasNextPage = gResult.Data.Organization.Domains.PageInfo.HasNextPage
+		variables["endCursor"] = gResult.Data.Organization.Domains.PageInfo.EndCursor
+
+		count++
+		// sanity check to avoid loops
+		if count > FORLOOP_STOP {
+			break
+		}
+	}
+
+	sort.Strings(domains)
+	return domains, nil
+}
+
 func (g *GoliacRemoteImpl) loadOrgUsers(ctx context.Context) (map[string]string, error) {
 	logrus.Debug("loading orgUsers")
 	users := make(map[string]string)
@@ -380,9 +940,9 @@ func (g *GoliacRemoteImpl) loadOrgUsers(ctx context.Context) (map[string]string,
 }
 
 const listAllReposInOrg = `
-query listAllReposInOrg($orgLogin: String!, $endCursor: String) {
+query listAllReposInOrg($orgLogin: String!, $endCursor: String, $pageSize: Int!) {
     organization(login: $orgLogin) {
-      repositories(first: 100, after: $endCursor) {
+      repositories(first: $pageSize, after: $endCursor) {
         nodes {
           name
 		  id
@@ -390,8 +950,26 @@ query listAllReposInOrg($orgLogin: String!, $endCursor: String) {
           isArchived
           isPrivate
 		  autoMergeAllowed
+          mergeCommitAllowed
+          squashMergeAllowed
+          rebaseMergeAllowed
           deleteBranchOnMerge
           allowUpdateBranch
+          description
+          homepageUrl
+          hasVulnerabilityAlertsEnabled
+          pushedAt
+          isTemplate
+          templateRepository {
+            nameWithOwner
+          }
+          defaultBranchRef {
+            name
+            branchProtectionRule {
+              requiresCommitSignatures
+              requiresStatusChecks
+            }
+          }
           collaborators(affiliation: OUTSIDE, first: 100) {
             edges {
               node {
@@ -416,15 +994,33 @@ type GraplQLRepositories struct {
 		Organization struct {
 			Repositories struct {
 				Nodes []struct {
-					Name                string
-					Id                  string
-					DatabaseId          int
-					IsArchived          bool
-					IsPrivate           bool
-					AutoMergeAllowed    bool
-					DeleteBranchOnMerge bool
-					AllowUpdateBranch   bool
-					Collaborators       struct {
+					Name                          string
+					Id                            string
+					DatabaseId                    int
+					IsArchived                    bool
+					IsPrivate                     bool
+					AutoMergeAllowed              bool
+					MergeCommitAllowed            bool
+					SquashMergeAllowed            bool
+					RebaseMergeAllowed            bool
+					DeleteBranchOnMerge           bool
+					AllowUpdateBranch             bool
+					Description                   string
+					HomepageUrl                   string
+					HasVulnerabilityAlertsEnabled bool
+					PushedAt                      *time.Time
+					IsTemplate                    bool
+					TemplateRepository            *struct {
+						NameWithOwner string
+					}
+					DefaultBranchRef struct {
+						Name                 string
+						BranchProtectionRule *struct {
+							RequiresCommitSignatures bool
+							RequiresStatusChecks     bool
+						}
+					}
+					Collaborators struct {
 						Edges []struct {
 							Node struct {
 								Login string
@@ -451,11 +1047,95 @@ type GraplQLRepositories struct {
 	} `json:"errors"`
 }
 
-func (g *GoliacRemoteImpl) loadRepositories(ctx context.Context) (map[string]*GithubRepository, map[string]*GithubRepository, error) {
+/*
+isGraphQLPageTooLarge returns true if the GraphQL query failed because the
+requested page was too expensive for Github (timeout or secondary rate limit),
+meaning we can retry the same page with a smaller page size
+*/
+func isGraphQLPageTooLarge(err error, message string) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return true
+		}
+		message = err.Error()
+	}
+	message = strings.ToLower(message)
+	return strings.Contains(message, "timeout") ||
+		strings.Contains(message, "timed out") ||
+		strings.Contains(message, "secondary rate limit")
+}
+
+/*
+ * graphQLRepositoriesPageInfo is the part of a repositories page needed to
+ * query the next page (much faster to parse than the whole page)
+ */
+type graphQLRepositoriesPageInfo struct {
+	Data struct {
+		Organization struct {
+			Repositories struct {
+				Nodes    []struct{} `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				} `json:"pageInfo"`
+				TotalCount int `json:"totalCount"`
+			} `json:"repositories"`
+		}
+	}
+	Errors []struct {
+		Path    []interface{} `json:"path"`
+		Message string
+	} `json:"errors"`
+}
+
+func (g *GoliacRemoteImpl) loadRepositories(ctx context.Context) (map[string]*GithubRepository, map[string]*GithubRepository, error) {
 	logrus.Debug("loading repositories")
 	repositories := make(map[string]*GithubRepository)
 	repositoriesByRefId := make(map[string]*GithubRepository)
 
+	if config.Config.GithubConcurrentThreads <= 1 {
+		retErr := g.fetchRepositoriesPages(ctx, func(data []byte) error {
+			return addRepositoriesPage(data, repositories, repositoriesByRefId)
+		})
+		return repositories, repositoriesByRefId, retErr
+	}
+
+	// parse the pages while the next ones are fetched
+	pages := make(chan []byte, config.Config.GithubConcurrentThreads)
+	parsed := make(chan error, 1)
+	go func() {
+		var parseErr error
+		for data := range pages {
+			if parseErr == nil {
+				parseErr = addRepositoriesPage(data, repositories, repositoriesByRefId)
+			}
+		}
+		parsed <- parseErr
+	}()
+
+	retErr := g.fetchRepositoriesPages(ctx, func(data []byte) error {
+		pages <- data
+		return nil
+	})
+	close(pages)
+	if parseErr := <-parsed; parseErr != nil {
+		return repositories, repositoriesByRefId, parseErr
+	}
+	return repositories, repositoriesByRefId, retErr
+}
+
+/*
+ * fetchRepositoriesPages queries the repositories pages (one after the other,
+ * the cursor of the next page is in the current page) and calls onPage with
+ * each page. The page size is halved on timeout
+ */
+func (g *GoliacRemoteImpl) fetchRepositoriesPages(ctx context.Context, onPage func(data []byte) error) error {
+	pageSize := config.Config.GithubRepositoriesPageSize
+	if pageSize <= 0 || pageSize > GRAPHQL_MAX_PAGE_SIZE {
+		pageSize = GRAPHQL_MAX_PAGE_SIZE
+	}
+
 	variables := make(map[string]interface{})
 	variables["orgLogin"] = config.Config.GithubAppOrganization
 	variables["endCursor"] = nil
@@ -463,54 +1143,112 @@ func (g *GoliacRemoteImpl) loadRepositories(ctx context.Context) (map[string]*Gi
 	var retErr error
 	hasNextPage := true
 	count := 0
+	totalCount := 0
+	nbLoaded := 0
 	for hasNextPage {
+		variables["pageSize"] = pageSize
 		data, err := g.client.QueryGraphQLAPI(ctx, listAllReposInOrg, variables)
 		if err != nil {
-			return repositories, repositoriesByRefId, err
+			if isGraphQLPageTooLarge(err, "") && pageSize > 1 {
+				pageSize = pageSize / 2
+				logrus.Debugf("loadRepositories: %v, retrying with a page size of %d", err, pageSize)
+				count++
+				continue
+			}
+			return err
 		}
-		var gResult GraplQLRepositories
+		var gResult graphQLRepositoriesPageInfo
 
-		// parse first page
 		err = json.Unmarshal(data, &gResult)
 		if err != nil {
-			return repositories, repositoriesByRefId, err
+			return err
 		}
 		if len(gResult.Errors) > 0 {
+			if isGraphQLPageTooLarge(nil, gResult.Errors[0].Message) && pageSize > 1 {
+				pageSize = pageSize / 2
+				logrus.Debugf("loadRepositories: %s, retrying with a page size of %d", gResult.Errors[0].Message, pageSize)
+				count++
+				continue
+			}
 			retErr = fmt.Errorf("graphql error on loadRepositories: %v (%v)", gResult.Errors[0].Message, gResult.Errors[0].Path)
 		}
 
-		for _, c := range gResult.Data.Organization.Repositories.Nodes {
-			repo := &GithubRepository{
-				Name:  c.Name,
-				Id:    c.DatabaseId,
-				RefId: c.Id,
-				BoolProperties: map[string]bool{
-					"archived":               c.IsArchived,
-					"private":                c.IsPrivate,
-					"allow_auto_merge":       c.AutoMergeAllowed,
-					"delete_branch_on_merge": c.DeleteBranchOnMerge,
-					"allow_update_branch":    c.AllowUpdateBranch,
-				},
-				ExternalUsers: make(map[string]string),
-			}
-			for _, collaborator := range c.Collaborators.Edges {
-				repo.ExternalUsers[collaborator.Node.Login] = collaborator.Permission
-			}
-			repositories[c.Name] = repo
-			repositoriesByRefId[c.Id] = repo
+		if err := onPage(data); err != nil {
+			return err
 		}
+		nbLoaded += len(gResult.Data.Organization.Repositories.Nodes)
 
 		hasNextPage = gResult.Data.Organization.Repositories.PageInfo.HasNextPage
 		variables["endCursor"] = gResult.Data.Organization.Repositories.PageInfo.EndCursor
+		if gResult.Data.Organization.Repositories.TotalCount > totalCount {
+			totalCount = gResult.Data.Organization.Repositories.TotalCount
+		}
 
 		count++
-		// sanity check to avoid loops
-		if count > FORLOOP_STOP {
+		// sanity check to avoid loops (scaled with the number of repositories to load)
+		if count > FORLOOP_STOP+totalCount/pageSize {
+			logrus.Warnf("loadRepositories: stopping after %d queries, %d/%d repositories loaded", count, nbLoaded, totalCount)
 			break
 		}
 	}
 
-	return repositories, repositoriesByRefId, retErr
+	logrus.Debugf("repositories loaded with an effective page size of %d", pageSize)
+
+	return retErr
+}
+
+/*
+ * addRepositoriesPage parses a repositories page, and adds its repositories
+ * to the repositories maps
+ */
+func addRepositoriesPage(data []byte, repositories map[string]*GithubRepository, repositoriesByRefId map[string]*GithubRepository) error {
+	var gResult GraplQLRepositories
+
+	err := json.Unmarshal(data, &gResult)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range gResult.Data.Organization.Repositories.Nodes {
+		repo := &GithubRepository{
+			Name:  c.Name,
+			Id:    c.DatabaseId,
+			RefId: c.Id,
+			BoolProperties: map[string]bool{
+				"archived":               c.IsArchived,
+				"private":                c.IsPrivate,
+				"allow_auto_merge":       c.AutoMergeAllowed,
+				"delete_branch_on_merge": c.DeleteBranchOnMerge,
+				"allow_update_branch":    c.AllowUpdateBranch,
+				"allow_merge_commit":     c.MergeCommitAllowed,
+				"allow_squash_merge":     c.SquashMergeAllowed,
+				"allow_rebase_merge":     c.RebaseMergeAllowed,
+				"is_template":            c.IsTemplate,
+			},
+			StringProperties: map[string]string{
+				"description": c.Description,
+				"homepage":    c.HomepageUrl,
+			},
+			ExternalUsers:    make(map[string]string),
+			DefaultBranch:    c.DefaultBranchRef.Name,
+			DependabotAlerts: c.HasVulnerabilityAlertsEnabled,
+			PushedAt:         c.PushedAt,
+		}
+		if c.TemplateRepository != nil {
+			repo.TemplateRepository = c.TemplateRepository.NameWithOwner
+		}
+		if c.DefaultBranchRef.BranchProtectionRule != nil {
+			repo.DefaultBranchProtected = true
+			repo.RequireSignedCommits = c.DefaultBranchRef.BranchProtectionRule.RequiresCommitSignatures
+			repo.RequireStatusChecks = c.DefaultBranchRef.BranchProtectionRule.RequiresStatusChecks
+		}
+		for _, collaborator := range c.Collaborators.Edges {
+			repo.ExternalUsers[collaborator.Node.Login] = collaborator.Permission
+		}
+		repositories[c.Name] = repo
+		repositoriesByRefId[c.Id] = repo
+	}
+	return nil
 }
 
 const listAllTeamsInOrg = `
@@ -524,6 +1262,8 @@ query listAllTeamsInOrg($orgLogin: String!, $endCursor: String) {
 		  parentTeam {
 		    databaseId
 		  }
+		  privacy
+		  notificationSetting
         }
         pageInfo {
           hasNextPage
@@ -546,6 +1286,8 @@ type GraplQLTeams struct {
 					ParentTeam struct {
 						DatabaseId int `json:"databaseId"`
 					} `json:"parentTeam"`
+					Privacy             string `json:"privacy"`             // SECRET or VISIBLE
+					NotificationSetting string `json:"notificationSetting"` // NOTIFICATIONS_ENABLED or NOTIFICATIONS_DISABLED
 				} `json:"nodes"`
 				PageInfo struct {
 					HasNextPage bool
@@ -599,76 +1341,148 @@ func (g *GoliacRemoteImpl) loadAppIds(ctx context.Context) (map[string]int, erro
 	return appIds, nil
 }
 
-func (g *GoliacRemoteImpl) Load(ctx context.Context, continueOnError bool) error {
-	var retErr error
+/*
+ * remoteLoadResult is the outcome of one of the (independent) loads of Load
+ */
+type remoteLoadResult struct {
+	name string
+	err  error
+}
 
-	if time.Now().After(g.ttlExpireRulesets) {
-		rulesets, err := g.loadRulesets(ctx)
-		if err != nil {
-			if !continueOnError {
-				return err
-			}
-			logrus.Debugf("Error loading rulesets: %v", err)
-			retErr = fmt.Errorf("error loading rulesets: %v", err)
+func firstRemoteLoadError(results []remoteLoadResult) error {
+	for _, result := range results {
+		if result.err != nil {
+			return result.err
 		}
-		g.rulesets = rulesets
-		g.ttlExpireRulesets = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
 	}
+	return nil
+}
 
-	if time.Now().After(g.ttlExpireAppIds) {
-		appIds, err := g.loadAppIds(ctx)
-		if err != nil {
-			if !continueOnError {
-				return err
-			}
-			logrus.Debugf("Error loading app ids: %v", err)
-			retErr = fmt.Errorf("error loading app ids: %v", err)
-		}
-		g.appIds = appIds
-		g.ttlExpireAppIds = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+/*
+ * The load<X>IfExpired functions (re)load a part of the remote if its cache
+ * has expired. On error, the cache is kept unless continueOnError
+ */
+func (g *GoliacRemoteImpl) loadRulesetsIfExpired(ctx context.Context, continueOnError bool) remoteLoadResult {
+	if !time.Now().After(g.ttlExpireRulesets) {
+		return remoteLoadResult{name: "rulesets"}
+	}
+	rulesets, err := g.loadRulesets(ctx)
+	if err != nil && !continueOnError {
+		return remoteLoadResult{name: "rulesets", err: err}
 	}
+	g.rulesets = rulesets
+	g.ttlExpireRulesets = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+	return remoteLoadResult{name: "rulesets", err: err}
+}
 
-	if time.Now().After(g.ttlExpireUsers) {
-		users, err := g.loadOrgUsers(ctx)
-		if err != nil {
-			if !continueOnError {
-				return err
-			}
-			logrus.Debugf("Error loading users: %v", err)
-			retErr = fmt.Errorf("error loading users: %v", err)
+func (g *GoliacRemoteImpl) loadAppIdsIfExpired(ctx context.Context, continueOnError bool) remoteLoadResult {
+	if !time.Now().After(g.ttlExpireAppIds) {
+		return remoteLoadResult{name: "app ids"}
+	}
+	appIds, err := g.loadAppIds(ctx)
+	if err != nil && !continueOnError {
+		return remoteLoadResult{name: "app ids", err: err}
+	}
+	g.appIds = appIds
+	g.ttlExpireAppIds = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+	return remoteLoadResult{name: "app ids", err: err}
+}
+
+func (g *GoliacRemoteImpl) loadOrgUsersIfExpired(ctx context.Context, continueOnError bool) remoteLoadResult {
+	if !time.Now().After(g.ttlExpireUsers) {
+		return remoteLoadResult{name: "users"}
+	}
+	users, err := g.loadOrgUsers(ctx)
+	if err != nil && !continueOnError {
+		return remoteLoadResult{name: "users", err: err}
+	}
+	g.users = users
+	g.ttlExpireUsers = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+	return remoteLoadResult{name: "users", err: err}
+}
+
+func (g *GoliacRemoteImpl) loadRepositoriesIfExpired(ctx context.Context, continueOnError bool) remoteLoadResult {
+	if !time.Now().After(g.ttlExpireRepositories) {
+		return remoteLoadResult{name: "repositories"}
+	}
+	repositories, repositoriesByRefId, err := g.loadRepositories(ctx)
+	if err != nil && !continueOnError {
+		return remoteLoadResult{name: "repositories", err: err}
+	}
+	g.repositories = repositories
+	g.repositoriesByRefId = repositoriesByRefId
+	g.ttlExpireRepositories = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+	return remoteLoadResult{name: "repositories", err: err}
+}
+
+func (g *GoliacRemoteImpl) loadTeamsIfExpired(ctx context.Context, continueOnError bool) remoteLoadResult {
+	if !time.Now().After(g.ttlExpireTeams) {
+		return remoteLoadResult{name: "teams"}
+	}
+	teams, teamSlugByName, err := g.loadTeams(ctx)
+	if err != nil && !continueOnError {
+		return remoteLoadResult{name: "teams", err: err}
+	}
+	g.teams = teams
+	g.teamSlugByName = teamSlugByName
+	g.ttlExpireTeams = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+	return remoteLoadResult{name: "teams", err: err}
+}
+
+func (g *GoliacRemoteImpl) Load(ctx context.Context, continueOnError bool) error {
+	var retErr error
+
+	// the rulesets are converted with the repositories (their repositories and
+	// required workflows are given by repository ids), so they are loaded after
+	// the repositories, in the same goroutine
+	repositoriesAndRulesets := func() []remoteLoadResult {
+		results := []remoteLoadResult{g.loadRepositoriesIfExpired(ctx, continueOnError)}
+		if results[0].err != nil && !continueOnError {
+			return results
 		}
-		g.users = users
-		g.ttlExpireUsers = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+		return append(results, g.loadRulesetsIfExpired(ctx, continueOnError))
+	}
+	independentLoads := []func() []remoteLoadResult{
+		repositoriesAndRulesets,
+		func() []remoteLoadResult { return []remoteLoadResult{g.loadAppIdsIfExpired(ctx, continueOnError)} },
+		func() []remoteLoadResult { return []remoteLoadResult{g.loadOrgUsersIfExpired(ctx, continueOnError)} },
+		func() []remoteLoadResult { return []remoteLoadResult{g.loadTeamsIfExpired(ctx, continueOnError)} },
 	}
 
-	if time.Now().After(g.ttlExpireRepositories) {
-		repositories, repositoriesByRefId, err := g.loadRepositories(ctx)
-		if err != nil {
-			if !continueOnError {
+	results := make([][]remoteLoadResult, len(independentLoads))
+	if config.Config.GithubConcurrentThreads <= 1 {
+		for i, load := range independentLoads {
+			results[i] = load()
+			if err := firstRemoteLoadError(results[i]); err != nil && !continueOnError {
 				return err
 			}
-			logrus.Debugf("Error loading repositories: %v", err)
-			retErr = fmt.Errorf("error loading repositories: %v", err)
 		}
-		g.repositories = repositories
-		g.repositoriesByRefId = repositoriesByRefId
-		g.ttlExpireRepositories = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
+	} else {
+		var wg sync.WaitGroup
+		for i, load := range independentLoads {
+			wg.Add(1)
+			go func(i int, load func() []remoteLoadResult) {
+				defer wg.Done()
+				results[i] = load()
+			}(i, load)
+		}
+		wg.Wait()
 	}
 
-	if time.Now().After(g.ttlExpireTeams) {
-		teams, teamSlugByName, err := g.loadTeams(ctx)
-		if err != nil {
+	for _, loadResults := range results {
+		for _, result := range loadResults {
+			if result.err == nil {
+				continue
+			}
 			if !continueOnError {
-				return err
+				return result.err
 			}
-			logrus.Debugf("Error loading teams: %v", err)
-			retErr = fmt.Errorf("error loading teams: %v", err)
+			logrus.Debugf("Error loading %s: %v", result.name, result.err)
+			retErr = fmt.Errorf("error loading %s: %v", result.name, result.err)
 		}
-		g.teams = teams
-		g.teamSlugByName = teamSlugByName
-		g.ttlExpireTeams = time.Now().Add(time.Duration(config.Config.GithubCacheTTL) * time.Second)
 	}
 
+	// the teams-repos are loaded per repository
 	if time.Now().After(g.ttlExpireTeamsRepos) {
 		if config.Config.GithubConcurrentThreads <= 1 {
 			teamsrepos, err := g.loadTeamReposNonConcurrently(ctx)
@@ -728,6 +1542,55 @@ func (g *GoliacRemoteImpl) loadTeamReposNonConcurrently(ctx context.Context) (ma
 	return teamRepos, nil
 }
 
+/*
+ * concurrentCall calls fn for each repository, with up to maxGoroutines
+ * concurrent calls (sequentially if maxGoroutines <= 1).
+ * It returns the first error returned by fn, if any
+ */
+func concurrentCall(ctx context.Context, maxGoroutines int64, reponames []string, fn func(ctx context.Context, reponame string) error) error {
+	if maxGoroutines <= 1 {
+		for _, reponame := range reponames {
+			if err := fn(ctx, reponame); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	reposChan := make(chan string, len(reponames))
+	errChan := make(chan error, 1) // will hold the first error
+
+	for i := int64(0); i < maxGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for reponame := range reposChan {
+				if err := fn(ctx, reponame); err != nil {
+					select {
+					case errChan <- err:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	for _, reponame := range reponames {
+		reposChan <- reponame
+	}
+	close(reposChan)
+	wg.Wait()
+
+	select {
+	case err := <-errChan:
+		return err
+	default:
+		return nil
+	}
+}
+
 func (g *GoliacRemoteImpl) loadTeamReposConcurrently(ctx context.Context, maxGoroutines int64) (map[string]map[string]*GithubTeamRepo, error) {
 	logrus.Debug("loading teamReposConcurrentlyV2")
 	teamRepos := make(map[string]map[string]*GithubTeamRepo)
@@ -805,9 +1668,28 @@ func (g *GoliacRemoteImpl) loadTeamReposConcurrently(ctx context.Context, maxGor
 type TeamsRepoResponse struct {
 	Name       string `json:"name"`
 	Permission string `json:"permission"`
+	RoleName   string `json:"role_name"`
 	Slug       string `json:"slug"`
 }
 
+// the base repository roles, as returned in the role_name field
+var baseRepositoryRoles = map[string]bool{
+	"read":     true,
+	"triage":   true,
+	"write":    true,
+	"maintain": true,
+	"admin":    true,
+}
+
+// the base permissions accepted when granting a team access to a repository
+var basePermissions = map[string]string{
+	"pull":     "READ",
+	"triage":   "TRIAGE",
+	"push":     "WRITE",
+	"maintain": "MAINTAIN",
+	"admin":    "ADMIN",
+}
+
 /*
 loadTeamRepos returns
 map[teamSlug]repoinfo
@@ -841,6 +1723,9 @@ func (g *GoliacRemoteImpl) loadTeamRepos(ctx context.Context, repository string)
 			Name:       repository,
 			Permission: permission,
 		}
+		if t.RoleName != "" && !baseRepositoryRoles[t.RoleName] {
+			teamsrepo[t.Slug].RoleName = t.RoleName
+		}
 	}
 
 	return teamsrepo, nil
@@ -927,9 +1812,15 @@ func (g *GoliacRemoteImpl) loadTeams(ctx context.Context) (map[string]*GithubTea
 
 		for _, c := range gResult.Data.Organization.Teams.Nodes {
 			team := GithubTeam{
-				Name: c.Name,
-				Id:   c.DatabaseId,
-				Slug: c.Slug,
+				Name:                c.Name,
+				Id:                  c.DatabaseId,
+				Slug:                c.Slug,
+				Privacy:             strings.ToLower(c.Privacy),
+				NotificationSetting: strings.ToLower(c.NotificationSetting),
+			}
+			// the REST API calls a visible team "closed"
+			if team.Privacy == "visible" {
+				team.Privacy = "closed"
 			}
 			if c.ParentTeam.DatabaseId != 0 {
 				parentId := c.ParentTeam.DatabaseId
@@ -1027,6 +1918,16 @@ query listRulesets ($orgLogin: String!) {
 			repositoryId {
 				repositoryIds
 			}
+			repositoryProperty {
+				include {
+					name
+					propertyValues
+				}
+				exclude {
+					name
+					propertyValues
+				}
+			}
 		  }
 		  rules(first:100) {
 			nodes {
@@ -1038,6 +1939,43 @@ query listRulesets ($orgLogin: String!) {
 						requiredReviewThreadResolution
 						requireLastPushApproval
 					}
+					... on CommitMessagePatternParameters {
+						name
+						negate
+						operator
+						pattern
+					}
+					... on CommitAuthorEmailPatternParameters {
+						name
+						negate
+						operator
+						pattern
+					}
+					... on CommitterEmailPatternParameters {
+						name
+						negate
+						operator
+						pattern
+					}
+					... on WorkflowsParameters {
+						workflows {
+							path
+							ref
+							repositoryId
+						}
+					}
+					... on RequiredDeploymentsParameters {
+						requiredDeploymentEnvironments
+					}
+					... on MergeQueueParameters {
+						checkResponseTimeoutMinutes
+						groupingStrategy
+						maxEntriesToBuild
+						maxEntriesToMerge
+						mergeMethod
+						minEntriesToMerge
+						minEntriesToMergeWaitMinutes
+					}
 				}
 				type
 			}
@@ -1078,9 +2016,39 @@ type GithubRuleSetRule struct {
 		// RequiredStatusChecksParameters
 		RequiredStatusChecks             []GithubRuleSetRuleStatusCheck
 		StrictRequiredStatusChecksPolicy bool
+
+		// CommitMessagePatternParameters, CommitAuthorEmailPatternParameters, CommitterEmailPatternParameters
+		Name     string
+		Negate   bool
+		Operator string
+		Pattern  string
+
+		// MergeQueueParameters
+		CheckResponseTimeoutMinutes  int
+		GroupingStrategy             string
+		MaxEntriesToBuild            int
+		MaxEntriesToMerge            int
+		MergeMethod                  string
+		MinEntriesToMerge            int
+		MinEntriesToMergeWaitMinutes int
+
+		// WorkflowsParameters
+		Workflows []struct {
+			Path         string
+			Ref          string
+			RepositoryId int
+		}
+
+		// RequiredDeploymentsParameters
+		RequiredDeploymentEnvironments []string
 	}
 	ID   int
-	Type string // CREATION, UPDATE, DELETION, REQUIRED_LINEAR_HISTORY, REQUIRED_DEPLOYMENTS, REQUIRED_SIGNATURES, PULL_REQUEST, REQUIRED_STATUS_CHECKS, NON_FAST_FORWARD, COMMIT_MESSAGE_PATTERN, COMMIT_AUTHOR_EMAIL_PATTERN, COMMITTER_EMAIL_PATTERN, BRANCH_NAME_PATTERN, TAG_NAME_PATTERN
+	Type string // CREATION, UPDATE, DELETION, REQUIRED_LINEAR_HISTORY, REQUIRED_DEPLOYMENTS, REQUIRED_SIGNATURES, PULL_REQUEST, REQUIRED_STATUS_CHECKS, NON_FAST_FORWARD, COMMIT_MESSAGE_PATTERN, COMMIT_AUTHOR_EMAIL_PATTERN, COMMITTER_EMAIL_PATTERN, BRANCH_NAME_PATTERN, TAG_NAME_PATTERN, MERGE_QUEUE, WORKFLOWS
+}
+
+type GraphQLRuleSetPropertyTarget struct {
+	Name           string
+	PropertyValues []string
 }
 
 type GraphQLGithubRuleSet struct {
@@ -1104,6 +2072,10 @@ type GraphQLGithubRuleSet struct {
 		RepositoryId struct { // per repo
 			RepositoryIds []string
 		}
+		RepositoryProperty struct { // per custom property values
+			Include []GraphQLRuleSetPropertyTarget
+			Exclude []GraphQLRuleSetPropertyTarget
+		}
 	}
 	Rules struct {
 		Nodes []GithubRuleSetRule
@@ -1145,6 +2117,15 @@ type GithubRuleSet struct {
 	Rules map[string]entity.RuleSetParameters
 
 	Repositories []string
+
+	// if set, the ruleset targets the repositories by name (fnmatch patterns, ~ALL)
+	// instead of by ids (Repositories)
+	RepositoryNameInclude []string
+	RepositoryNameExclude []string
+
+	// if set, the ruleset targets the repositories by custom property values
+	RepositoryPropertyInclude []entity.RuleSetRepositoryProperty
+	RepositoryPropertyExclude []entity.RuleSetRepositoryProperty
 }
 
 func (g *GoliacRemoteImpl) fromGraphQLToGithubRulset(src *GraphQLGithubRuleSet) *GithubRuleSet {
@@ -1157,10 +2138,19 @@ func (g *GoliacRemoteImpl) fromGraphQLToGithubRulset(src *GraphQLGithubRuleSet)
 		OnExclude:    src.Conditions.RefName.Exclude,
 		Rules:        map[string]entity.RuleSetParameters{},
 		Repositories: []string{},
+
+		RepositoryNameInclude: src.Conditions.RepositoryName.Include,
+		RepositoryNameExclude: src.Conditions.RepositoryName.Exclude,
 	}
 	for _, b := range src.BypassActors.App {
 		ruleset.BypassApps[b.Actor.Name] = strings.ToLower(b.BypassMode)
 	}
+	for _, p := range src.Conditions.RepositoryProperty.Include {
+		ruleset.RepositoryPropertyInclude = append(ruleset.RepositoryPropertyInclude, entity.RuleSetRepositoryProperty{Name: p.Name, Values: p.PropertyValues})
+	}
+	for _, p := range src.Conditions.RepositoryProperty.Exclude {
+		ruleset.RepositoryPropertyExclude = append(ruleset.RepositoryPropertyExclude, entity.RuleSetRepositoryProperty{Name: p.Name, Values: p.PropertyValues})
+	}
 
 	for _, r := range src.Rules.Nodes {
 		rule := entity.RuleSetParameters{
@@ -1174,6 +2164,38 @@ func (g *GoliacRemoteImpl) fromGraphQLToGithubRulset(src *GraphQLGithubRuleSet)
 		for _, s := range r.Parameters.RequiredStatusChecks {
 			rule.RequiredStatusChecks = append(rule.RequiredStatusChecks, s.Context)
 		}
+		if entity.IsPatternRuletype(strings.ToLower(r.Type)) {
+			rule.Name = r.Parameters.Name
+			rule.Negate = r.Parameters.Negate
+			rule.Operator = strings.ToLower(r.Parameters.Operator)
+			rule.Pattern = r.Parameters.Pattern
+		}
+		if strings.ToLower(r.Type) == "merge_queue" {
+			rule.MergeMethod = strings.ToLower(r.Parameters.MergeMethod)
+			rule.GroupingStrategy = strings.ToLower(r.Parameters.GroupingStrategy)
+			rule.CheckResponseTimeoutMinutes = r.Parameters.CheckResponseTimeoutMinutes
+			rule.MaxEntriesToBuild = r.Parameters.MaxEntriesToBuild
+			rule.MaxEntriesToMerge = r.Parameters.MaxEntriesToMerge
+			rule.MinEntriesToMerge = r.Parameters.MinEntriesToMerge
+			rule.MinEntriesToMergeWaitMinutes = r.Parameters.MinEntriesToMergeWaitMinutes
+		}
+		if strings.ToLower(r.Type) == "required_deployments" {
+			rule.RequiredDeploymentEnvironments = r.Parameters.RequiredDeploymentEnvironments
+		}
+		for _, w := range r.Parameters.Workflows {
+			workflow := entity.RuleSetRequiredWorkflow{
+				RepositoryID: w.RepositoryId,
+				Path:         w.Path,
+				Ref:          w.Ref,
+			}
+			for _, repo := range g.repositories {
+				if repo.Id == w.RepositoryId {
+					workflow.Repository = repo.Name
+					break
+				}
+			}
+			rule.RequiredWorkflows = append(rule.RequiredWorkflows, workflow)
+		}
 		ruleset.Rules[strings.ToLower(r.Type)] = rule
 	}
 
@@ -1229,6 +2251,22 @@ func (g *GoliacRemoteImpl) loadRulesets(ctx context.Context) (map[string]*Github
 	return rulesets, nil
 }
 
+func repositoryPropertyTargets(properties []entity.RuleSetRepositoryProperty) []map[string]interface{} {
+	targets := make([]map[string]interface{}, 0, len(properties))
+	for _, p := range properties {
+		values := p.Values
+		if values == nil {
+			values = []string{}
+		}
+		targets = append(targets, map[string]interface{}{
+			"name":            p.Name,
+			"property_values": values,
+			"source":          "custom",
+		})
+	}
+	return targets
+}
+
 func (g *GoliacRemoteImpl) prepareRuleset(ruleset *GithubRuleSet) map[string]interface{} {
 	bypassActors := make([]map[string]interface{}, 0)
 
@@ -1263,9 +2301,27 @@ func (g *GoliacRemoteImpl) prepareRuleset(ruleset *GithubRuleSet) map[string]int
 			"include": include,
 			"exclude": exclude,
 		},
-		"repository_id": map[string]interface{}{
+	}
+	// Github accepts only one repository condition
+	if len(ruleset.RepositoryPropertyInclude) > 0 {
+		conditions["repository_property"] = map[string]interface{}{
+			"include": repositoryPropertyTargets(ruleset.RepositoryPropertyInclude),
+			"exclude": repositoryPropertyTargets(ruleset.RepositoryPropertyExclude),
+		}
+	} else if len(ruleset.RepositoryNameInclude) > 0 {
+		repoExclude := ruleset.RepositoryNameExclude
+		if repoExclude == nil {
+			repoExclude = []string{}
+		}
+		conditions["repository_name"] = map[string]interface{}{
+			"include":   ruleset.RepositoryNameInclude,
+			"exclude":   repoExclude,
+			"protected": false,
+		}
+	} else {
+		conditions["repository_id"] = map[string]interface{}{
 			"repository_ids": repoIds,
-		},
+		}
 	}
 
 	rules := make([]map[string]interface{}, 0)
@@ -1286,6 +2342,63 @@ func (g *GoliacRemoteImpl) prepareRuleset(ruleset *GithubRuleSet) map[string]int
 					"require_last_push_approval":        rule.RequireLastPushApproval,
 				},
 			})
+		case "commit_message_pattern", "commit_author_email_pattern", "committer_email_pattern":
+			rules = append(rules, map[string]interface{}{
+				"type": ruletype,
+				"parameters": map[string]interface{}{
+					"name":     rule.Name,
+					"negate":   rule.Negate,
+					"operator": rule.Operator,
+					"pattern":  rule.Pattern,
+				},
+			})
+		case "merge_queue":
+			mergeQueue := entity.MergeQueueParameters(rule)
+			rules = append(rules, map[string]interface{}{
+				"type": "merge_queue",
+				"parameters": map[string]interface{}{
+					"check_response_timeout_minutes":    mergeQueue.CheckResponseTimeoutMinutes,
+					"grouping_strategy":                 strings.ToUpper(mergeQueue.GroupingStrategy),
+					"max_entries_to_build":              mergeQueue.MaxEntriesToBuild,
+					"max_entries_to_merge":              mergeQueue.MaxEntriesToMerge,
+					"merge_method":                      strings.ToUpper(mergeQueue.MergeMethod),
+					"min_entries_to_merge":              mergeQueue.MinEntriesToMerge,
+					"min_entries_to_merge_wait_minutes": mergeQueue.MinEntriesToMergeWaitMinutes,
+				},
+			})
+		case "required_deployments":
+			environments := rule.RequiredDeploymentEnvironments
+			if environments == nil {
+				environments = []string{}
+			}
+			rules = append(rules, map[string]interface{}{
+				"type": "required_deployments",
+				"parameters": map[string]interface{}{
+					"required_deployment_environments": environments,
+				},
+			})
+		case "workflows":
+			workflows := make([]map[string]interface{}, 0, len(rule.RequiredWorkflows))
+			for _, w := range rule.RequiredWorkflows {
+				repositoryId := w.RepositoryID
+				if repo, ok := g.repositories[w.Repository]; ok {
+					repositoryId = repo.Id
+				}
+				workflow := map[string]interface{}{
+					"repository_id": repositoryId,
+					"path":          w.Path,
+				}
+				if w.Ref != "" {
+					workflow["ref"] = w.Ref
+				}
+				workflows = append(workflows, workflow)
+			}
+			rules = append(rules, map[string]interface{}{
+				"type": "workflows",
+				"parameters": map[string]interface{}{
+					"workflows": workflows,
+				},
+			})
 		}
 	}
 
@@ -1362,29 +2475,435 @@ func (g *GoliacRemoteImpl) DeleteRuleset(ctx context.Context, dryrun bool, rules
 	}
 }
 
-func (g *GoliacRemoteImpl) AddUserToOrg(ctx context.Context, dryrun bool, ghuserid string) {
-	// add member
-	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#create-a-team
-	if !dryrun {
-		body, err := g.client.CallRestAPI(
-			ctx,
-			fmt.Sprintf("/orgs/%s/memberships/%s", config.Config.GithubAppOrganization, ghuserid),
-			"PUT",
-			map[string]interface{}{"role": "member"},
-		)
+func (g *GoliacRemoteImpl) loadOrgVariables(ctx context.Context) (map[string]*GithubOrgVariable, error) {
+	logrus.Debug("loading orgVariables")
+	type OrgVariables struct {
+		TotalCount int `json:"total_count"`
+		Variables  []struct {
+			Name       string `json:"name"`
+			Value      string `json:"value"`
+			Visibility string `json:"visibility"`
+		} `json:"variables"`
+	}
+	type SelectedRepositories struct {
+		TotalCount   int `json:"total_count"`
+		Repositories []struct {
+			Id   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"repositories"`
+	}
+
+	// https://docs.github.com/en/rest/actions/variables?apiVersion=2022-11-28#list-organization-variables
+	var orgVariables OrgVariables
+	for page := 1; page <= FORLOOP_STOP; page++ {
+		body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/actions/variables?per_page=100&page=%d", config.Config.GithubAppOrganization, page),
+			"GET",
+			nil)
 		if err != nil {
-			logrus.Errorf("failed to add user to org: %v. %s", err, string(body))
+			return nil, fmt.Errorf("not able to list org variables: %v. %s", err, string(body))
+		}
+
+		var pageVariables OrgVariables
+		err = json.Unmarshal(body, &pageVariables)
+		if err != nil {
+			return nil, fmt.Errorf("not able to list org variables: %v", err)
+		}
+		orgVariables.Variables = append(orgVariables.Variables, pageVariables.Variables...)
+		if len(pageVariables.Variables) == 0 || len(orgVariables.Variables) >= pageVariables.TotalCount {
+			break
 		}
 	}
 
-	g.users[ghuserid] = ghuserid
-}
+	variables := make(map[string]*GithubOrgVariable)
+	for _, v := range orgVariables.Variables {
+		variable := &GithubOrgVariable{
+			Name:                 v.Name,
+			Value:                v.Value,
+			Visibility:           v.Visibility,
+			SelectedRepositories: []string{},
+		}
 
-func (g *GoliacRemoteImpl) RemoveUserFromOrg(ctx context.Context, dryrun bool, ghuserid string) {
-	// remove member
-	// https://docs.github.com/en/rest/orgs/members?apiVersion=2022-11-28#remove-organization-membership-for-a-user
-	if !dryrun {
-		body, err := g.client.CallRestAPI(
+		if v.Visibility == "selected" {
+			// https://docs.github.com/en/rest/actions/variables?apiVersion=2022-11-28#list-selected-repositories-for-an-organization-variable
+			for page := 1; page <= FORLOOP_STOP; page++ {
+				body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/actions/variables/%s/repositories?per_page=100&page=%d", config.Config.GithubAppOrganization, v.Name, page),
+					"GET",
+					nil)
+				if err != nil {
+					return nil, fmt.Errorf("not able to list selected repositories for org variable %s: %v. %s", v.Name, err, string(body))
+				}
+				var selected SelectedRepositories
+				err = json.Unmarshal(body, &selected)
+				if err != nil {
+					return nil, fmt.Errorf("not able to list selected repositories for org variable %s: %v", v.Name, err)
+				}
+				for _, r := range selected.Repositories {
+					variable.SelectedRepositories = append(variable.SelectedRepositories, r.Name)
+				}
+				if len(selected.Repositories) == 0 || len(variable.SelectedRepositories) >= selected.TotalCount {
+					break
+				}
+			}
+		}
+
+		variables[v.Name] = variable
+	}
+
+	return variables, nil
+}
+
+func (g *GoliacRemoteImpl) prepareOrgVariable(variable *GithubOrgVariable) map[string]interface{} {
+	payload := map[string]interface{}{
+		"name":       variable.Name,
+		"value":      variable.Value,
+		"visibility": variable.Visibility,
+	}
+	if variable.Visibility == "selected" {
+		repoIds := []int{}
+		for _, r := range variable.SelectedRepositories {
+			if rid, ok := g.repositories[r]; ok {
+				repoIds = append(repoIds, rid.Id)
+			}
+		}
+		payload["selected_repository_ids"] = repoIds
+	}
+	return payload
+}
+
+func (g *GoliacRemoteImpl) AddOrgVariable(ctx context.Context, dryrun bool, variable *GithubOrgVariable) {
+	// add org variable
+	// https://docs.github.com/en/rest/actions/variables?apiVersion=2022-11-28#create-an-organization-variable
+
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/actions/variables", config.Config.GithubAppOrganization),
+			"POST",
+			g.prepareOrgVariable(variable),
+		)
+		if err != nil {
+			logrus.Errorf("failed to add variable %s to org: %v. %s", variable.Name, err, string(body))
+		}
+	}
+
+	if g.orgVariables != nil {
+		g.orgVariables[variable.Name] = variable
+	}
+}
+
+func (g *GoliacRemoteImpl) UpdateOrgVariable(ctx context.Context, dryrun bool, variable *GithubOrgVariable) {
+	// update org variable
+	// https://docs.github.com/en/rest/actions/variables?apiVersion=2022-11-28#update-an-organization-variable
+
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/actions/variables/%s", config.Config.GithubAppOrganization, variable.Name),
+			"PATCH",
+			g.prepareOrgVariable(variable),
+		)
+		if err != nil {
+			logrus.Errorf("failed to update variable %s in org: %v. %s", variable.Name, err, string(body))
+		}
+	}
+
+	if g.orgVariables != nil {
+		g.orgVariables[variable.Name] = variable
+	}
+}
+
+func (g *GoliacRemoteImpl) DeleteOrgVariable(ctx context.Context, dryrun bool, variablename string) {
+	// remove org variable
+	// https://docs.github.com/en/rest/actions/variables?apiVersion=2022-11-28#delete-an-organization-variable
+
+	if !dryrun {
+		_, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/actions/variables/%s", config.Config.GithubAppOrganization, variablename),
+			"DELETE",
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to remove variable %s from org: %v", variablename, err)
+		}
+	}
+
+	delete(g.orgVariables, variablename)
+}
+
+func (g *GoliacRemoteImpl) loadOrgSecrets(ctx context.Context) (map[string]*GithubOrgSecret, error) {
+	logrus.Debug("loading orgSecrets")
+	type OrgSecrets struct {
+		TotalCount int `json:"total_count"`
+		Secrets    []struct {
+			Name       string `json:"name"`
+			Visibility string `json:"visibility"`
+		} `json:"secrets"`
+	}
+	type SelectedRepositories struct {
+		TotalCount   int `json:"total_count"`
+		Repositories []struct {
+			Id   int    `json:"id"`
+			Name string `json:"name"`
+		} `json:"repositories"`
+	}
+
+	// https://docs.github.com/en/rest/actions/secrets?apiVersion=2022-11-28#list-organization-secrets
+	var orgSecrets OrgSecrets
+	for page := 1; page <= FORLOOP_STOP; page++ {
+		body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/actions/secrets?per_page=100&page=%d", config.Config.GithubAppOrganization, page),
+			"GET",
+			nil)
+		if err != nil {
+			return nil, fmt.Errorf("not able to list org secrets: %v. %s", err, string(body))
+		}
+
+		var pageSecrets OrgSecrets
+		err = json.Unmarshal(body, &pageSecrets)
+		if err != nil {
+			return nil, fmt.Errorf("not able to list org secrets: %v", err)
+		}
+		orgSecrets.Secrets = append(orgSecrets.Secrets, pageSecrets.Secrets...)
+		if len(pageSecrets.Secrets) == 0 || len(orgSecrets.Secrets) >= pageSecrets.TotalCount {
+			break
+		}
+	}
+
+	secrets := make(map[string]*GithubOrgSecret)
+	for _, s := range orgSecrets.Secrets {
+		secret := &GithubOrgSecret{
+			Name:                 s.Name,
+			Visibility:           s.Visibility,
+			SelectedRepositories: []string{},
+		}
+
+		if s.Visibility == "selected" {
+			// https://docs.github.com/en/rest/actions/secrets?apiVersion=2022-11-28#list-selected-repositories-for-an-organization-secret
+			for page := 1; page <= FORLOOP_STOP; page++ {
+				body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/actions/secrets/%s/repositories?per_page=100&page=%d", config.Config.GithubAppOrganization, s.Name, page),
+					"GET",
+					nil)
+				if err != nil {
+					return nil, fmt.Errorf("not able to list selected repositories for org secret %s: %v. %s", s.Name, err, string(body))
+				}
+				var selected SelectedRepositories
+				err = json.Unmarshal(body, &selected)
+				if err != nil {
+					return nil, fmt.Errorf("not able to list selected repositories for org secret %s: %v", s.Name, err)
+				}
+				for _, r := range selected.Repositories {
+					secret.SelectedRepositories = append(secret.SelectedRepositories, r.Name)
+				}
+				if len(selected.Repositories) == 0 || len(secret.SelectedRepositories) >= selected.TotalCount {
+					break
+				}
+			}
+		}
+
+		secrets[s.Name] = secret
+	}
+
+	return secrets, nil
+}
+
+func (g *GoliacRemoteImpl) UpdateOrgSecret(ctx context.Context, dryrun bool, secret *GithubOrgSecret) {
+	// update org secret (without encrypted_value, the value of the secret is kept)
+	// https://docs.github.com/en/rest/actions/secrets?apiVersion=2022-11-28#create-or-update-an-organization-secret
+
+	if !dryrun {
+		payload := map[string]interface{}{
+			"visibility": secret.Visibility,
+		}
+		if secret.Visibility == "selected" {
+			repoIds := []int{}
+			for _, r := range secret.SelectedRepositories {
+				if rid, ok := g.repositories[r]; ok {
+					repoIds = append(repoIds, rid.Id)
+				}
+			}
+			payload["selected_repository_ids"] = repoIds
+		}
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/actions/secrets/%s", config.Config.GithubAppOrganization, secret.Name),
+			"PUT",
+			payload,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update secret %s in org: %v. %s", secret.Name, err, string(body))
+		}
+	}
+
+	if g.orgSecrets != nil {
+		g.orgSecrets[secret.Name] = secret
+	}
+}
+
+func (g *GoliacRemoteImpl) DeleteOrgSecret(ctx context.Context, dryrun bool, secretname string) {
+	// remove org secret
+	// https://docs.github.com/en/rest/actions/secrets?apiVersion=2022-11-28#delete-an-organization-secret
+
+	if !dryrun {
+		_, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/actions/secrets/%s", config.Config.GithubAppOrganization, secretname),
+			"DELETE",
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to remove secret %s from org: %v", secretname, err)
+		}
+	}
+
+	delete(g.orgSecrets, secretname)
+}
+
+/*
+ * loadOrgSettings returns the org settings, the secret scanning push
+ * protection custom link (empty if it is not enabled) and the default
+ * repository permission
+ */
+func (g *GoliacRemoteImpl) loadOrgSettings(ctx context.Context) (map[string]bool, string, string, error) {
+	logrus.Debug("loading orgSettings")
+	// members_can_create_internal_repositories is only returned for enterprise organizations
+	type OrgSettings struct {
+		MembersCanCreatePages                *bool  `json:"members_can_create_pages"`
+		MembersCanCreatePrivatePages         *bool  `json:"members_can_create_private_pages"`
+		MembersCanCreateInternalRepositories *bool  `json:"members_can_create_internal_repositories"`
+		TwoFactorRequirementEnabled          *bool  `json:"two_factor_requirement_enabled"`
+		PushProtectionCustomLinkEnabled      bool   `json:"secret_scanning_push_protection_custom_link_enabled"`
+		PushProtectionCustomLink             string `json:"secret_scanning_push_protection_custom_link"`
+		DefaultRepositoryPermission          string `json:"default_repository_permission"`
+	}
+
+	// https://docs.github.com/en/rest/orgs/orgs?apiVersion=2022-11-28#get-an-organization
+	body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s", config.Config.GithubAppOrganization), "GET", nil)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("not able to get org settings: %v. %s", err, string(body))
+	}
+
+	var orgSettings OrgSettings
+	err = json.Unmarshal(body, &orgSettings)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("not able to get org settings: %v", err)
+	}
+
+	settings := make(map[string]bool)
+	if orgSettings.MembersCanCreatePages != nil {
+		settings["members_can_create_pages"] = *orgSettings.MembersCanCreatePages
+	}
+	if orgSettings.MembersCanCreatePrivatePages != nil {
+		settings["members_can_create_private_pages"] = *orgSettings.MembersCanCreatePrivatePages
+	}
+	if orgSettings.MembersCanCreateInternalRepositories != nil {
+		settings["members_can_create_internal_repositories"] = *orgSettings.MembersCanCreateInternalRepositories
+	}
+	if orgSettings.TwoFactorRequirementEnabled != nil {
+		settings["two_factor_requirement_enabled"] = *orgSettings.TwoFactorRequirementEnabled
+	}
+
+	pushProtectionLink := ""
+	if orgSettings.PushProtectionCustomLinkEnabled {
+		pushProtectionLink = orgSettings.PushProtectionCustomLink
+	}
+
+	return settings, pushProtectionLink, orgSettings.DefaultRepositoryPermission, nil
+}
+
+/*
+UpdateOrgSetting is used for
+- members_can_create_pages
+- members_can_create_private_pages
+- members_can_create_internal_repositories
+*/
+func (g *GoliacRemoteImpl) UpdateOrgSetting(ctx context.Context, dryrun bool, settingName string, settingValue bool) {
+	// https://docs.github.com/en/rest/orgs/orgs?apiVersion=2022-11-28#update-an-organization
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s", config.Config.GithubAppOrganization),
+			"PATCH",
+			map[string]interface{}{settingName: settingValue},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update org setting %s: %v. %s", settingName, err, string(body))
+		}
+	}
+
+	g.orgSettings[settingName] = settingValue
+}
+
+/*
+UpdateOrgDefaultRepositoryPermission sets the base permission of the members
+on all the repositories (read, write, admin or none)
+*/
+func (g *GoliacRemoteImpl) UpdateOrgDefaultRepositoryPermission(ctx context.Context, dryrun bool, permission string) {
+	// https://docs.github.com/en/rest/orgs/orgs?apiVersion=2022-11-28#update-an-organization
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s", config.Config.GithubAppOrganization),
+			"PATCH",
+			map[string]interface{}{"default_repository_permission": permission},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update org default repository permission: %v. %s", err, string(body))
+		}
+	}
+
+	g.orgDefaultPermission = permission
+}
+
+/*
+UpdateOrgPushProtectionCustomLink sets the link shown when the secret scanning
+push protection blocks a push. An empty link disables it
+*/
+func (g *GoliacRemoteImpl) UpdateOrgPushProtectionCustomLink(ctx context.Context, dryrun bool, link string) {
+	// https://docs.github.com/en/rest/orgs/orgs?apiVersion=2022-11-28#update-an-organization
+	if !dryrun {
+		payload := map[string]interface{}{
+			"secret_scanning_push_protection_custom_link_enabled": link != "",
+		}
+		if link != "" {
+			payload["secret_scanning_push_protection_custom_link"] = link
+		}
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s", config.Config.GithubAppOrganization),
+			"PATCH",
+			payload,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update org secret scanning push protection custom link: %v. %s", err, string(body))
+		}
+	}
+
+	g.orgPushProtectionLink = link
+}
+
+func (g *GoliacRemoteImpl) AddUserToOrg(ctx context.Context, dryrun bool, ghuserid string) {
+	// add member
+	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#create-a-team
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/memberships/%s", config.Config.GithubAppOrganization, ghuserid),
+			"PUT",
+			map[string]interface{}{"role": "member"},
+		)
+		if err != nil {
+			logrus.Errorf("failed to add user to org: %v. %s", err, string(body))
+		}
+	}
+
+	g.users[ghuserid] = ghuserid
+}
+
+func (g *GoliacRemoteImpl) RemoveUserFromOrg(ctx context.Context, dryrun bool, ghuserid string) {
+	// remove member
+	// https://docs.github.com/en/rest/orgs/members?apiVersion=2022-11-28#remove-organization-membership-for-a-user
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
 			ctx,
 			fmt.Sprintf("/orgs/%s/memberships/%s", config.Config.GithubAppOrganization, ghuserid),
 			"DELETE",
@@ -1610,88 +3129,433 @@ func (g *GoliacRemoteImpl) UpdateTeamSetParent(ctx context.Context, dryrun bool,
 	}
 }
 
-func (g *GoliacRemoteImpl) DeleteTeam(ctx context.Context, dryrun bool, teamslug string) {
-	// delete team
-	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#delete-a-team
+/*
+UpdateTeamUpdateProperty is used for
+- privacy
+- notification_setting
+*/
+func (g *GoliacRemoteImpl) UpdateTeamUpdateProperty(ctx context.Context, dryrun bool, teamslug string, propertyName string, propertyValue string) {
+	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#update-a-team
 	if !dryrun {
 		body, err := g.client.CallRestAPI(
 			ctx,
 			fmt.Sprintf("/orgs/%s/teams/%s", config.Config.GithubAppOrganization, teamslug),
-			"DELETE",
-			nil,
+			"PATCH",
+			map[string]interface{}{propertyName: propertyValue},
 		)
 		if err != nil {
-			logrus.Errorf("failed to delete a team: %v. %s", err, string(body))
+			logrus.Errorf("failed to update team %s %s: %v. %s", teamslug, propertyName, err, string(body))
+			return
 		}
 	}
 
-	delete(g.teams, teamslug)
-	for name, slug := range g.teamSlugByName {
-		if slug == teamslug {
-			delete(g.teamSlugByName, name)
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if t, ok := g.teams[teamslug]; ok {
+		switch propertyName {
+		case "privacy":
+			t.Privacy = propertyValue
+		case "notification_setting":
+			t.NotificationSetting = propertyValue
 		}
 	}
 }
 
-type CreateRepositoryResponse struct {
-	Id     int    `json:"id"`
-	NodeId string `json:"node_id"`
-}
-
-/*
-boolProperties are:
-- private
-- archived
-- allow_auto_merge
-- delete_branch_on_merge
-- allow_update_branch
-- ...
-*/
-func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, reponame string, description string, writers []string, readers []string, boolProperties map[string]bool) {
-	repoId := 0
-	repoRefId := reponame
-	// create repository
-	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#create-an-organization-repository
+func (g *GoliacRemoteImpl) UpdateTeamRename(ctx context.Context, dryrun bool, teamslug string, newname string) {
+	newslug := slug.Make(newname)
+	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#update-a-team
 	if !dryrun {
-		props := map[string]interface{}{
-			"name":        reponame,
-			"description": description,
-		}
-		for k, v := range boolProperties {
-			props[k] = v
-		}
-
 		body, err := g.client.CallRestAPI(
 			ctx,
-			fmt.Sprintf("/orgs/%s/repos", config.Config.GithubAppOrganization),
-			"POST",
-			props,
+			fmt.Sprintf("/orgs/%s/teams/%s", config.Config.GithubAppOrganization, teamslug),
+			"PATCH",
+			map[string]interface{}{"name": newname},
 		)
 		if err != nil {
-			logrus.Errorf("failed to create repository: %v. %s", err, string(body))
+			logrus.Errorf("failed to rename the team %s: %v. %s", teamslug, err, string(body))
 			return
 		}
-
-		// get the repo id
-		var resp CreateRepositoryResponse
-		err = json.Unmarshal(body, &resp)
+		var res CreateTeamResponse
+		err = json.Unmarshal(body, &res)
 		if err != nil {
-			logrus.Errorf("failed to read the create repository action response: %v", err)
+			logrus.Errorf("failed to rename the team %s: %v", teamslug, err)
 			return
 		}
-		repoId = resp.Id
-		repoRefId = resp.NodeId
+		newslug = res.Slug
 	}
 
-	// update the repositories list
-	newRepo := &GithubRepository{
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if t, ok := g.teams[teamslug]; ok {
+		delete(g.teams, teamslug)
+		delete(g.teamSlugByName, t.Name)
+		t.Name = newname
+		t.Slug = newslug
+		g.teams[newslug] = t
+		g.teamSlugByName[newname] = newslug
+	}
+	if tr, ok := g.teamRepos[teamslug]; ok {
+		delete(g.teamRepos, teamslug)
+		g.teamRepos[newslug] = tr
+	}
+}
+
+func (g *GoliacRemoteImpl) DeleteTeam(ctx context.Context, dryrun bool, teamslug string) {
+	// delete team
+	// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#delete-a-team
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/teams/%s", config.Config.GithubAppOrganization, teamslug),
+			"DELETE",
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to delete a team: %v. %s", err, string(body))
+		}
+	}
+
+	delete(g.teams, teamslug)
+	for name, slug := range g.teamSlugByName {
+		if slug == teamslug {
+			delete(g.teamSlugByName, name)
+		}
+	}
+}
+
+type GithubExternalGroup struct {
+	Id          string `json:"group_id"`
+	Name        string `json:"group_name"`
+	Description string `json:"group_description"`
+}
+
+type ExternalGroupsResponse struct {
+	Groups []GithubExternalGroup `json:"groups"`
+}
+
+func (g *GoliacRemoteImpl) loadTeamsExternalGroups(ctx context.Context) (map[string][]string, error) {
+	logrus.Debug("loading teams external groups")
+	externalGroups := make(map[string][]string)
+	var externalGroupsMutex sync.Mutex
+
+	// the "-goliac-owners" teams are never synchronized with IdP groups
+	teamslugs := []string{}
+	for teamslug := range g.Teams(ctx) {
+		if !strings.HasSuffix(teamslug, config.Config.GoliacTeamOwnerSuffix) {
+			teamslugs = append(teamslugs, teamslug)
+		}
+	}
+
+	err := concurrentCall(ctx, config.Config.GithubConcurrentThreads, teamslugs, func(ctx context.Context, teamslug string) error {
+		// https://docs.github.com/en/enterprise-cloud@latest/rest/teams/team-sync?apiVersion=2022-11-28#list-idp-groups-for-a-team
+		body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/teams/%s/team-sync/group-mappings", config.Config.GithubAppOrganization, teamslug), "GET", nil)
+		if err != nil {
+			return fmt.Errorf("not able to get the external groups of team %s: %v. %s", teamslug, err, string(body))
+		}
+
+		var groups ExternalGroupsResponse
+		err = json.Unmarshal(body, &groups)
+		if err != nil {
+			return fmt.Errorf("not able to get the external groups of team %s: %v", teamslug, err)
+		}
+
+		names := make([]string, 0, len(groups.Groups))
+		for _, group := range groups.Groups {
+			names = append(names, group.Name)
+		}
+		sort.Strings(names)
+		externalGroupsMutex.Lock()
+		externalGroups[teamslug] = names
+		externalGroupsMutex.Unlock()
+		return nil
+	})
+	return externalGroups, err
+}
+
+// findExternalGroup returns the IdP group (available to the team synchronization) named groupname
+func (g *GoliacRemoteImpl) findExternalGroup(ctx context.Context, groupname string) (*GithubExternalGroup, error) {
+	// https://docs.github.com/en/enterprise-cloud@latest/rest/teams/team-sync?apiVersion=2022-11-28#list-idp-groups-for-an-organization
+	body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/team-sync/groups?per_page=100&q=%s", config.Config.GithubAppOrganization, url.QueryEscape(groupname)), "GET", nil)
+	if err != nil {
+		return nil, fmt.Errorf("not able to search the external group %s: %v. %s", groupname, err, string(body))
+	}
+	var groups ExternalGroupsResponse
+	err = json.Unmarshal(body, &groups)
+	if err != nil {
+		return nil, fmt.Errorf("not able to search the external group %s: %v", groupname, err)
+	}
+	// q matches partially the group names
+	for _, group := range groups.Groups {
+		if group.Name == groupname {
+			return &group, nil
+		}
+	}
+	return nil, fmt.Errorf("external group %s not found", groupname)
+}
+
+/*
+UpdateTeamSetExternalGroups replaces the IdP groups mapped to the team: the
+members of the team are then synchronized from these groups by Github
+*/
+func (g *GoliacRemoteImpl) UpdateTeamSetExternalGroups(ctx context.Context, dryrun bool, teamslug string, groups []string) {
+	if !dryrun {
+		mappings := make([]map[string]interface{}, 0, len(groups))
+		for _, groupname := range groups {
+			group, err := g.findExternalGroup(ctx, groupname)
+			if err != nil {
+				logrus.Errorf("failed to set the external groups of team %s: %v", teamslug, err)
+				return
+			}
+			mappings = append(mappings, map[string]interface{}{
+				"group_id":          group.Id,
+				"group_name":        group.Name,
+				"group_description": group.Description,
+			})
+		}
+		// https://docs.github.com/en/enterprise-cloud@latest/rest/teams/team-sync?apiVersion=2022-11-28#create-or-update-idp-group-connections
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/teams/%s/team-sync/group-mappings", config.Config.GithubAppOrganization, teamslug),
+			"PATCH",
+			map[string]interface{}{"groups": mappings},
+		)
+		if err != nil {
+			logrus.Errorf("failed to set the external groups of team %s: %v. %s", teamslug, err, string(body))
+			return
+		}
+	}
+
+	names := append([]string{}, groups...)
+	sort.Strings(names)
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if g.externalGroups == nil {
+		g.externalGroups = make(map[string][]string)
+	}
+	g.externalGroups[teamslug] = names
+}
+
+type TransferredRepositoryResponse struct {
+	Id                  int    `json:"id"`
+	NodeId              string `json:"node_id"`
+	Description         string `json:"description"`
+	Private             bool   `json:"private"`
+	Archived            bool   `json:"archived"`
+	AllowAutoMerge      bool   `json:"allow_auto_merge"`
+	DeleteBranchOnMerge bool   `json:"delete_branch_on_merge"`
+	AllowUpdateBranch   bool   `json:"allow_update_branch"`
+	AllowMergeCommit    bool   `json:"allow_merge_commit"`
+	AllowSquashMerge    bool   `json:"allow_squash_merge"`
+	AllowRebaseMerge    bool   `json:"allow_rebase_merge"`
+	IsTemplate          bool   `json:"is_template"`
+}
+
+/*
+ * TransferRepository transfers the source (<owner>/<name>) repository into
+ * the organization (as reponame). Github transfers the repository
+ * asynchronously: it waits (up to TRANSFER_MAX_ATTEMPTS attempts) until the
+ * repository is available in the organization, so the following actions
+ * on the repository (like the teams access) can be applied.
+ * The Github app must be installed on the source owner (user or
+ * organization) with the administration permission: else Github rejects
+ * the transfer
+ */
+func (g *GoliacRemoteImpl) TransferRepository(ctx context.Context, dryrun bool, reponame string, source string) {
+	newRepo := &GithubRepository{
+		Name:             reponame,
+		RefId:            reponame,
+		BoolProperties:   map[string]bool{},
+		StringProperties: map[string]string{},
+		ExternalUsers:    map[string]string{},
+	}
+
+	if !dryrun {
+		// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#transfer-a-repository
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/transfer", source),
+			"POST",
+			map[string]interface{}{
+				"new_owner": config.Config.GithubAppOrganization,
+				"new_name":  reponame,
+			},
+		)
+		if err != nil {
+			logrus.Errorf("failed to transfer the repository %s: %v. %s", source, err, string(body))
+			return
+		}
+
+		delay := g.transferPollDelay
+		for attempt := 1; ; attempt++ {
+			body, err = g.client.CallRestAPI(
+				ctx,
+				fmt.Sprintf("/repos/%s/%s", config.Config.GithubAppOrganization, reponame),
+				"GET",
+				nil,
+			)
+			if err == nil {
+				break
+			}
+			if attempt >= TRANSFER_MAX_ATTEMPTS {
+				logrus.Errorf("the repository %s transferred from %s is still not available in the organization: %v", reponame, source, err)
+				return
+			}
+			select {
+			case <-ctx.Done():
+				logrus.Errorf("stopped waiting for the repository %s transferred from %s: %v", reponame, source, ctx.Err())
+				return
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		var resp TransferredRepositoryResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			logrus.Errorf("failed to read the transferred repository %s: %v", reponame, err)
+			return
+		}
+		newRepo.Id = resp.Id
+		newRepo.RefId = resp.NodeId
+		newRepo.BoolProperties = map[string]bool{
+			"private":                resp.Private,
+			"archived":               resp.Archived,
+			"allow_auto_merge":       resp.AllowAutoMerge,
+			"delete_branch_on_merge": resp.DeleteBranchOnMerge,
+			"allow_update_branch":    resp.AllowUpdateBranch,
+			"allow_merge_commit":     resp.AllowMergeCommit,
+			"allow_squash_merge":     resp.AllowSquashMerge,
+			"allow_rebase_merge":     resp.AllowRebaseMerge,
+			"is_template":            resp.IsTemplate,
+		}
+		newRepo.StringProperties["description"] = resp.Description
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	g.repositories[reponame] = newRepo
+	g.repositoriesByRefId[newRepo.RefId] = newRepo
+}
+
+type CreateRepositoryResponse struct {
+	Id     int    `json:"id"`
+	NodeId string `json:"node_id"`
+}
+
+/*
+ * createRepositoryFromTemplate creates a repository from a template repository
+ * (<owner>/<name>). The template generation only accepts the visibility,
+ * so the other bool properties are set afterward
+ */
+func (g *GoliacRemoteImpl) createRepositoryFromTemplate(ctx context.Context, reponame string, description string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) ([]byte, error) {
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#create-a-repository-using-a-template
+	body, err := g.client.CallRestAPI(
+		ctx,
+		fmt.Sprintf("/repos/%s/generate", templateRepository),
+		"POST",
+		map[string]interface{}{
+			"owner":                config.Config.GithubAppOrganization,
+			"name":                 reponame,
+			"description":          description,
+			"private":              boolProperties["private"],
+			"include_all_branches": includeAllBranches,
+		},
+	)
+	if err != nil {
+		return body, err
+	}
+
+	props := map[string]interface{}{}
+	for k, v := range boolProperties {
+		if k != "private" {
+			props[k] = v
+		}
+	}
+	if len(props) > 0 {
+		// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
+		patchBody, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s", config.Config.GithubAppOrganization, reponame),
+			"PATCH",
+			props,
+		)
+		if err != nil {
+			// the repository exists anyway
+			logrus.Errorf("failed to update the repository %s created from the template %s: %v. %s", reponame, templateRepository, err, string(patchBody))
+		}
+	}
+	return body, nil
+}
+
+/*
+boolProperties are:
+- private
+- archived
+- allow_auto_merge
+- delete_branch_on_merge
+- allow_update_branch
+- allow_merge_commit
+- allow_squash_merge
+- allow_rebase_merge
+- is_template
+- ...
+*/
+func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, reponame string, description string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) {
+	repoId := 0
+	repoRefId := reponame
+	if !dryrun {
+		var body []byte
+		var err error
+		if templateRepository == "" {
+			// create repository
+			// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#create-an-organization-repository
+			props := map[string]interface{}{
+				"name":        reponame,
+				"description": description,
+			}
+			for k, v := range boolProperties {
+				props[k] = v
+			}
+
+			body, err = g.client.CallRestAPI(
+				ctx,
+				fmt.Sprintf("/orgs/%s/repos", config.Config.GithubAppOrganization),
+				"POST",
+				props,
+			)
+		} else {
+			body, err = g.createRepositoryFromTemplate(ctx, reponame, description, boolProperties, templateRepository, includeAllBranches)
+		}
+		if err != nil {
+			logrus.Errorf("failed to create repository: %v. %s", err, string(body))
+			return
+		}
+
+		// get the repo id
+		var resp CreateRepositoryResponse
+		err = json.Unmarshal(body, &resp)
+		if err != nil {
+			logrus.Errorf("failed to read the create repository action response: %v", err)
+			return
+		}
+		repoId = resp.Id
+		repoRefId = resp.NodeId
+	}
+
+	// update the repositories list
+	g.actionMutex.Lock()
+	newRepo := &GithubRepository{
 		Name:           reponame,
 		Id:             repoId,
 		RefId:          repoRefId,
 		BoolProperties: boolProperties,
+		StringProperties: map[string]string{
+			"description": description,
+		},
 	}
 	g.repositories[reponame] = newRepo
 	g.repositoriesByRefId[repoRefId] = newRepo
+	g.actionMutex.Unlock()
 
 	// add members
 	for _, reader := range readers {
@@ -1709,6 +3573,7 @@ func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, re
 			}
 		}
 
+		g.actionMutex.Lock()
 		teamsRepos := g.teamRepos[reader]
 		if teamsRepos == nil {
 			teamsRepos = make(map[string]*GithubTeamRepo)
@@ -1718,6 +3583,7 @@ func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, re
 			Permission: "READ",
 		}
 		g.teamRepos[reader] = teamsRepos
+		g.actionMutex.Unlock()
 	}
 	for _, writer := range writers {
 		// https://docs.github.com/en/rest/teams/teams?apiVersion=2022-11-28#add-or-update-team-repository-permissions
@@ -1733,6 +3599,7 @@ func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, re
 			}
 		}
 
+		g.actionMutex.Lock()
 		teamsRepos := g.teamRepos[writer]
 		if teamsRepos == nil {
 			teamsRepos = make(map[string]*GithubTeamRepo)
@@ -1742,6 +3609,29 @@ func (g *GoliacRemoteImpl) CreateRepository(ctx context.Context, dryrun bool, re
 			Permission: "WRITE",
 		}
 		g.teamRepos[writer] = teamsRepos
+		g.actionMutex.Unlock()
+	}
+}
+
+/*
+ * teamRepo returns the team access for a permission given to the Github API:
+ * a base permission (pull, triage, push, maintain, admin) or a custom repository role
+ */
+func (g *GoliacRemoteImpl) teamRepo(reponame string, permission string) *GithubTeamRepo {
+	if p, ok := basePermissions[permission]; ok {
+		return &GithubTeamRepo{
+			Name:       reponame,
+			Permission: p,
+		}
+	}
+	rPermission := "READ"
+	if baseRole, ok := g.customRoles[permission]; ok {
+		rPermission = strings.ToUpper(baseRole)
+	}
+	return &GithubTeamRepo{
+		Name:       reponame,
+		Permission: rPermission,
+		RoleName:   permission,
 	}
 }
 
@@ -1760,18 +3650,13 @@ func (g *GoliacRemoteImpl) UpdateRepositoryAddTeamAccess(ctx context.Context, dr
 		}
 	}
 
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	teamsRepos := g.teamRepos[teamslug]
 	if teamsRepos == nil {
 		teamsRepos = make(map[string]*GithubTeamRepo)
 	}
-	rPermission := "READ"
-	if permission == "push" {
-		rPermission = "WRITE"
-	}
-	teamsRepos[reponame] = &GithubTeamRepo{
-		Name:       reponame,
-		Permission: rPermission,
-	}
+	teamsRepos[reponame] = g.teamRepo(reponame, permission)
 	g.teamRepos[teamslug] = teamsRepos
 }
 
@@ -1790,18 +3675,13 @@ func (g *GoliacRemoteImpl) UpdateRepositoryUpdateTeamAccess(ctx context.Context,
 		}
 	}
 
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	teamsRepos := g.teamRepos[teamslug]
 	if teamsRepos == nil {
 		teamsRepos = make(map[string]*GithubTeamRepo)
 	}
-	rPermission := "READ"
-	if permission == "push" {
-		rPermission = "WRITE"
-	}
-	teamsRepos[reponame] = &GithubTeamRepo{
-		Name:       reponame,
-		Permission: rPermission,
-	}
+	teamsRepos[reponame] = g.teamRepo(reponame, permission)
 	g.teamRepos[teamslug] = teamsRepos
 }
 
@@ -1820,6 +3700,8 @@ func (g *GoliacRemoteImpl) UpdateRepositoryRemoveTeamAccess(ctx context.Context,
 		}
 	}
 
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	teamsRepos := g.teamRepos[teamslug]
 	if teamsRepos != nil {
 		delete(g.teamRepos[teamslug], reponame)
@@ -1832,7 +3714,11 @@ Used for
 - allow_auto_merge
 - delete_branch_on_merge
 - allow_update_branch
+- allow_merge_commit
+- allow_squash_merge
+- allow_rebase_merge
 - archived
+- is_template
 */
 func (g *GoliacRemoteImpl) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
 	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
@@ -1848,11 +3734,877 @@ func (g *GoliacRemoteImpl) UpdateRepositoryUpdateBoolProperty(ctx context.Contex
 		}
 	}
 
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	if repo, ok := g.repositories[reponame]; ok {
 		repo.BoolProperties[propertyName] = propertyValue
 	}
 }
 
+/*
+UpdateRepositoryUpdateProperty is used for
+- description
+- homepage
+*/
+func (g *GoliacRemoteImpl) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
+	if !dryrun {
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("repos/%s/%s", config.Config.GithubAppOrganization, reponame),
+			"PATCH",
+			map[string]interface{}{propertyName: propertyValue},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update repository %s setting: %v. %s", propertyName, err, string(body))
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if repo, ok := g.repositories[reponame]; ok {
+		if repo.StringProperties == nil {
+			repo.StringProperties = make(map[string]string)
+		}
+		repo.StringProperties[propertyName] = propertyValue
+	}
+}
+
+/*
+UpdateRepositorySetRequiredSignatures sets the (classic) branch protection
+"required signatures" on the default branch of the repository.
+If the default branch is not protected yet, a minimal protection is created first.
+*/
+func (g *GoliacRemoteImpl) UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	g.actionMutex.Lock()
+	repo, ok := g.repositories[reponame]
+	g.actionMutex.Unlock()
+	if !ok || repo.DefaultBranch == "" {
+		logrus.Warnf("not able to set required signatures on repository %s: no default branch found", reponame)
+		return
+	}
+
+	if !dryrun {
+		if enabled && !repo.DefaultBranchProtected {
+			// https://docs.github.com/en/rest/branches/branch-protection?apiVersion=2022-11-28#update-branch-protection
+			body, err := g.client.CallRestAPI(
+				ctx,
+				fmt.Sprintf("/repos/%s/%s/branches/%s/protection", config.Config.GithubAppOrganization, reponame, repo.DefaultBranch),
+				"PUT",
+				map[string]interface{}{
+					"required_status_checks":        nil,
+					"enforce_admins":                nil,
+					"required_pull_request_reviews": nil,
+					"restrictions":                  nil,
+				},
+			)
+			if err != nil {
+				logrus.Errorf("failed to protect the default branch of repository %s: %v. %s", reponame, err, string(body))
+				return
+			}
+			g.actionMutex.Lock()
+			repo.DefaultBranchProtected = true
+			g.actionMutex.Unlock()
+		}
+
+		method := "DELETE"
+		if enabled {
+			method = "POST"
+		}
+		// https://docs.github.com/en/rest/branches/branch-protection?apiVersion=2022-11-28#create-commit-signature-protection
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/branches/%s/protection/required_signatures", config.Config.GithubAppOrganization, reponame, repo.DefaultBranch),
+			method,
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update required signatures for repository %s: %v. %s", reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	repo.RequireSignedCommits = enabled
+	g.actionMutex.Unlock()
+}
+
+/*
+UpdateRepositorySetDependabotAlerts enables or disables the Dependabot
+vulnerability alerts of the repository
+*/
+func (g *GoliacRemoteImpl) UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	if !dryrun {
+		method := "DELETE"
+		if enabled {
+			method = "PUT"
+		}
+		// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#enable-vulnerability-alerts
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/vulnerability-alerts", config.Config.GithubAppOrganization, reponame),
+			method,
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update dependabot alerts for repository %s: %v. %s", reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if repo, ok := g.repositories[reponame]; ok {
+		repo.DependabotAlerts = enabled
+	}
+}
+
+func (g *GoliacRemoteImpl) loadRepositoriesActionsPermissions(ctx context.Context) (map[string]*GithubActionsPermissions, error) {
+	logrus.Debug("loading repositories actions permissions")
+	// allowed_actions is not returned when Actions are disabled on the repository
+	type ActionsPermissions struct {
+		Enabled        bool   `json:"enabled"`
+		AllowedActions string `json:"allowed_actions"`
+	}
+
+	reponames := make([]string, 0, len(g.Repositories(ctx)))
+	for reponame := range g.Repositories(ctx) {
+		reponames = append(reponames, reponame)
+	}
+
+	var mutex sync.Mutex
+	permissions := make(map[string]*GithubActionsPermissions)
+	err := concurrentCall(ctx, config.Config.GithubConcurrentThreads, reponames, func(ctx context.Context, reponame string) error {
+		// https://docs.github.com/en/rest/actions/permissions?apiVersion=2022-11-28#get-github-actions-permissions-for-a-repository
+		body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/actions/permissions", config.Config.GithubAppOrganization, reponame), "GET", nil)
+		if err != nil {
+			return fmt.Errorf("not able to get actions permissions for repository %s: %v. %s", reponame, err, string(body))
+		}
+
+		var p ActionsPermissions
+		err = json.Unmarshal(body, &p)
+		if err != nil {
+			return fmt.Errorf("not able to get actions permissions for repository %s: %v", reponame, err)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		permissions[reponame] = &GithubActionsPermissions{
+			Enabled:        p.Enabled,
+			AllowedActions: p.AllowedActions,
+		}
+		return nil
+	})
+
+	return permissions, err
+}
+
+/*
+UpdateRepositoryActionsPermissions enables or disables Github Actions on the
+repository, and (if enabled) sets which actions are allowed
+*/
+func (g *GoliacRemoteImpl) UpdateRepositoryActionsPermissions(ctx context.Context, dryrun bool, reponame string, enabled bool, allowedActions string) {
+	if !dryrun {
+		payload := map[string]interface{}{"enabled": enabled}
+		if enabled && allowedActions != "" {
+			payload["allowed_actions"] = allowedActions
+		}
+		// https://docs.github.com/en/rest/actions/permissions?apiVersion=2022-11-28#set-github-actions-permissions-for-a-repository
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/actions/permissions", config.Config.GithubAppOrganization, reponame),
+			"PUT",
+			payload,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update actions permissions for repository %s: %v. %s", reponame, err, string(body))
+			return
+		}
+	}
+
+	if !enabled {
+		allowedActions = ""
+	}
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	g.actionsPermissions[reponame] = &GithubActionsPermissions{
+		Enabled:        enabled,
+		AllowedActions: allowedActions,
+	}
+}
+
+func (g *GoliacRemoteImpl) loadRepositoriesSecurity(ctx context.Context) (map[string]*GithubRepositorySecurity, error) {
+	logrus.Debug("loading repositories security settings")
+	type Status struct {
+		Status string `json:"status"` // enabled or disabled
+	}
+	type Repository struct {
+		SecurityAndAnalysis struct {
+			SecretScanning               Status `json:"secret_scanning"`
+			SecretScanningPushProtection Status `json:"secret_scanning_push_protection"`
+			DependabotSecurityUpdates    Status `json:"dependabot_security_updates"`
+		} `json:"security_and_analysis"`
+	}
+
+	reponames := make([]string, 0, len(g.Repositories(ctx)))
+	for reponame := range g.Repositories(ctx) {
+		reponames = append(reponames, reponame)
+	}
+
+	var mutex sync.Mutex
+	security := make(map[string]*GithubRepositorySecurity)
+	err := concurrentCall(ctx, config.Config.GithubConcurrentThreads, reponames, func(ctx context.Context, reponame string) error {
+		// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#get-a-repository
+		body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s", config.Config.GithubAppOrganization, reponame), "GET", nil)
+		if err != nil {
+			return fmt.Errorf("not able to get security settings for repository %s: %v. %s", reponame, err, string(body))
+		}
+
+		var r Repository
+		err = json.Unmarshal(body, &r)
+		if err != nil {
+			return fmt.Errorf("not able to get security settings for repository %s: %v", reponame, err)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		security[reponame] = &GithubRepositorySecurity{
+			SecretScanning:               r.SecurityAndAnalysis.SecretScanning.Status == "enabled",
+			SecretScanningPushProtection: r.SecurityAndAnalysis.SecretScanningPushProtection.Status == "enabled",
+			DependabotSecurityUpdates:    r.SecurityAndAnalysis.DependabotSecurityUpdates.Status == "enabled",
+		}
+		return nil
+	})
+
+	return security, err
+}
+
+/*
+UpdateRepositorySetSecurity enables or disables a security feature of the repository:
+- secret_scanning
+- secret_scanning_push_protection
+- dependabot_security_updates
+*/
+func (g *GoliacRemoteImpl) UpdateRepositorySetSecurity(ctx context.Context, dryrun bool, reponame string, feature string, enabled bool) {
+	g.actionMutex.Lock()
+	repo, ok := g.repositories[reponame]
+	isPublic := ok && !repo.BoolProperties["private"]
+	g.actionMutex.Unlock()
+
+	// secret scanning is always enabled on public repositories
+	if isPublic && (feature == "secret_scanning" || feature == "secret_scanning_push_protection") {
+		logrus.Warnf("not able to set %s on repository %s: not applicable to public repositories", feature, reponame)
+		return
+	}
+
+	if !dryrun {
+		status := "disabled"
+		if enabled {
+			status = "enabled"
+		}
+
+		var body []byte
+		var err error
+		switch feature {
+		case "secret_scanning", "secret_scanning_push_protection":
+			// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#update-a-repository
+			body, err = g.client.CallRestAPI(
+				ctx,
+				fmt.Sprintf("/repos/%s/%s", config.Config.GithubAppOrganization, reponame),
+				"PATCH",
+				map[string]interface{}{
+					"security_and_analysis": map[string]interface{}{
+						feature: map[string]interface{}{"status": status},
+					},
+				},
+			)
+		case "dependabot_security_updates":
+			method := "DELETE"
+			if enabled {
+				method = "PUT"
+			}
+			// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#enable-automated-security-fixes
+			// (204 without body on success)
+			body, err = g.client.CallRestAPI(
+				ctx,
+				fmt.Sprintf("/repos/%s/%s/automated-security-fixes", config.Config.GithubAppOrganization, reponame),
+				method,
+				nil,
+			)
+		default:
+			logrus.Errorf("unknown security feature %s for repository %s", feature, reponame)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("failed to update %s for repository %s: %v. %s", feature, reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	security := g.security[reponame]
+	if security == nil {
+		security = &GithubRepositorySecurity{}
+		g.security[reponame] = security
+	}
+	switch feature {
+	case "secret_scanning":
+		security.SecretScanning = enabled
+	case "secret_scanning_push_protection":
+		security.SecretScanningPushProtection = enabled
+	case "dependabot_security_updates":
+		security.DependabotSecurityUpdates = enabled
+	}
+}
+
+func (g *GoliacRemoteImpl) loadRepositoriesWebhooks(ctx context.Context) (map[string]map[string]*GithubWebhook, error) {
+	logrus.Debug("loading repositories webhooks")
+	type Hook struct {
+		Id     int      `json:"id"`
+		Name   string   `json:"name"`
+		Active bool     `json:"active"`
+		Events []string `json:"events"`
+		Config struct {
+			URL         string `json:"url"`
+			ContentType string `json:"content_type"`
+		} `json:"config"`
+	}
+
+	reponames := make([]string, 0, len(g.Repositories(ctx)))
+	for reponame := range g.Repositories(ctx) {
+		reponames = append(reponames, reponame)
+	}
+
+	var mutex sync.Mutex
+	webhooks := make(map[string]map[string]*GithubWebhook)
+	err := concurrentCall(ctx, config.Config.GithubConcurrentThreads, reponames, func(ctx context.Context, reponame string) error {
+		var hooks []Hook
+		for page := 1; page <= FORLOOP_STOP; page++ {
+			// https://docs.github.com/en/rest/repos/webhooks?apiVersion=2022-11-28#list-repository-webhooks
+			body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/hooks?per_page=100&page=%d", config.Config.GithubAppOrganization, reponame, page), "GET", nil)
+			if err != nil {
+				return fmt.Errorf("not able to get webhooks for repository %s: %v. %s", reponame, err, string(body))
+			}
+
+			var pageHooks []Hook
+			err = json.Unmarshal(body, &pageHooks)
+			if err != nil {
+				return fmt.Errorf("not able to get webhooks for repository %s: %v", reponame, err)
+			}
+			hooks = append(hooks, pageHooks...)
+			if len(pageHooks) < 100 {
+				break
+			}
+		}
+
+		repoWebhooks := make(map[string]*GithubWebhook)
+		for _, h := range hooks {
+			// only the web hooks are managed
+			if h.Name != "web" || h.Config.URL == "" {
+				continue
+			}
+			repoWebhooks[h.Config.URL] = &GithubWebhook{
+				Id:          h.Id,
+				URL:         h.Config.URL,
+				Events:      h.Events,
+				ContentType: h.Config.ContentType,
+				Active:      h.Active,
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		webhooks[reponame] = repoWebhooks
+		return nil
+	})
+
+	return webhooks, err
+}
+
+const listRepositoryLabels = `
+query listRepositoryLabels($orgLogin: String!, $repositoryName: String!, $endCursor: String) {
+    repository(owner: $orgLogin, name: $repositoryName) {
+      labels(first: 100, after: $endCursor) {
+        nodes {
+          name
+          color
+          description
+        }
+        pageInfo {
+          hasNextPage
+          endCursor
+        }
+      }
+    }
+  }
+`
+
+type GraplQLRepositoryLabels struct {
+	Data struct {
+		Repository struct {
+			Labels struct {
+				Nodes []struct {
+					Name        string
+					Color       string
+					Description string
+				} `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool
+					EndCursor   string
+				} `json:"pageInfo"`
+			} `json:"labels"`
+		} `json:"repository"`
+	}
+	Errors []struct {
+		Path       []interface{} `json:"path"`
+		Extensions struct {
+			Code         string
+			ErrorMessage string
+		} `json:"extensions"`
+		Message string
+	} `json:"errors"`
+}
+
+func (g *GoliacRemoteImpl) loadRepositoriesLabels(ctx context.Context) (map[string]map[string]*GithubLabel, error) {
+	logrus.Debug("loading repositories labels")
+	reponames := make([]string, 0, len(g.Repositories(ctx)))
+	for reponame := range g.Repositories(ctx) {
+		reponames = append(reponames, reponame)
+	}
+
+	var mutex sync.Mutex
+	labels := make(map[string]map[string]*GithubLabel)
+	err := concurrentCall(ctx, config.Config.GithubConcurrentThreads, reponames, func(ctx context.Context, reponame string) error {
+		variables := make(map[string]interface{})
+		variables["orgLogin"] = config.Config.GithubAppOrganization
+		variables["repositoryName"] = reponame
+		variables["endCursor"] = nil
+
+		repoLabels := make(map[string]*GithubLabel)
+		hasNextPage := true
+		count := 0
+		for hasNextPage {
+			data, err := g.client.QueryGraphQLAPI(ctx, listRepositoryLabels, variables)
+			if err != nil {
+				return err
+			}
+			var gResult GraplQLRepositoryLabels
+
+			err = json.Unmarshal(data, &gResult)
+			if err != nil {
+				return err
+			}
+			if len(gResult.Errors) > 0 {
+				return fmt.Errorf("graphql error on loadRepositoriesLabels: %v (%v)", gResult.Errors[0].Message, gResult.Errors[0].Path)
+			}
+
+			for _, c := range gResult.Data.Repository.Labels.Nodes {
+				description := c.Description
+				repoLabels[strings.ToLower(c.Name)] = &GithubLabel{
+					Name:        c.Name,
+					Color:       c.Color,
+					Description: &description,
+				}
+			}
+
+			hasNextPage = gResult.Data.Repository.Labels.PageInfo.HasNextPage
+			variables["endCursor"] = gResult.Data.Repository.Labels.PageInfo.EndCursor
+
+			count++
+			// sanity check to avoid loops
+			if count > FORLOOP_STOP {
+				break
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		labels[reponame] = repoLabels
+		return nil
+	})
+
+	return labels, err
+}
+
+func labelPayload(label *GithubLabel) map[string]interface{} {
+	payload := map[string]interface{}{
+		"color": label.Color,
+	}
+	if label.Description != nil {
+		payload["description"] = *label.Description
+	}
+	return payload
+}
+
+func (g *GoliacRemoteImpl) setLabelCache(reponame string, label *GithubLabel) {
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if _, ok := g.labels[reponame]; !ok {
+		g.labels[reponame] = make(map[string]*GithubLabel)
+	}
+	description := ""
+	if label.Description != nil {
+		description = *label.Description
+	} else if l, ok := g.labels[reponame][strings.ToLower(label.Name)]; ok && l.Description != nil {
+		description = *l.Description
+	}
+	g.labels[reponame][strings.ToLower(label.Name)] = &GithubLabel{
+		Name:        label.Name,
+		Color:       label.Color,
+		Description: &description,
+	}
+}
+
+func (g *GoliacRemoteImpl) CreateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, label *GithubLabel) {
+	if !dryrun {
+		payload := labelPayload(label)
+		payload["name"] = label.Name
+		// https://docs.github.com/en/rest/issues/labels?apiVersion=2022-11-28#create-a-label
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/labels", config.Config.GithubAppOrganization, reponame),
+			"POST",
+			payload,
+		)
+		if err != nil {
+			logrus.Errorf("failed to create label %s in repository %s: %v. %s", label.Name, reponame, err, string(body))
+			return
+		}
+	}
+
+	g.setLabelCache(reponame, label)
+}
+
+/*
+UpdateRepositoryLabel updates the label currently named labelname (label
+names are case insensitive: the label can be renamed to fix the case)
+*/
+func (g *GoliacRemoteImpl) UpdateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string, label *GithubLabel) {
+	if !dryrun {
+		payload := labelPayload(label)
+		if labelname != label.Name {
+			payload["new_name"] = label.Name
+		}
+		// https://docs.github.com/en/rest/issues/labels?apiVersion=2022-11-28#update-a-label
+		body, err := g.client.CallRestAPI(
+			ctx,
+			// a label name can contain spaces, '?' or '/'
+			fmt.Sprintf("/repos/%s/%s/labels/%s", config.Config.GithubAppOrganization, reponame, url.PathEscape(labelname)),
+			"PATCH",
+			payload,
+		)
+		if err != nil {
+			logrus.Errorf("failed to update label %s of repository %s: %v. %s", labelname, reponame, err, string(body))
+			return
+		}
+	}
+
+	g.setLabelCache(reponame, label)
+}
+
+func (g *GoliacRemoteImpl) DeleteRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string) {
+	if !dryrun {
+		// https://docs.github.com/en/rest/issues/labels?apiVersion=2022-11-28#delete-a-label
+		body, err := g.client.CallRestAPI(
+			ctx,
+			// a label name can contain spaces, '?' or '/'
+			fmt.Sprintf("/repos/%s/%s/labels/%s", config.Config.GithubAppOrganization, reponame, url.PathEscape(labelname)),
+			"DELETE",
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to delete label %s of repository %s: %v. %s", labelname, reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	delete(g.labels[reponame], strings.ToLower(labelname))
+}
+
+func webhookConfigPayload(webhook *GithubWebhook) map[string]interface{} {
+	hookConfig := map[string]interface{}{
+		"url":          webhook.URL,
+		"content_type": webhook.ContentType,
+	}
+	// an unset secret is left out (the current one is kept)
+	if webhook.Secret != "" {
+		hookConfig["secret"] = webhook.Secret
+	}
+	return hookConfig
+}
+
+func webhookPayload(webhook *GithubWebhook) map[string]interface{} {
+	return map[string]interface{}{
+		"active": webhook.Active,
+		"events": webhook.Events,
+		"config": webhookConfigPayload(webhook),
+	}
+}
+
+func (g *GoliacRemoteImpl) AddRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) {
+	id := 0
+	if !dryrun {
+		payload := webhookPayload(webhook)
+		payload["name"] = "web"
+		// https://docs.github.com/en/rest/repos/webhooks?apiVersion=2022-11-28#create-a-repository-webhook
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/hooks", config.Config.GithubAppOrganization, reponame),
+			"POST",
+			payload,
+		)
+		if err != nil {
+			logrus.Errorf("failed to add webhook %s to repository %s: %v. %s", webhook.URL, reponame, err, string(body))
+			return
+		}
+
+		var res struct {
+			Id int `json:"id"`
+		}
+		if err := json.Unmarshal(body, &res); err != nil {
+			logrus.Errorf("failed to read the webhook %s of repository %s: %v", webhook.URL, reponame, err)
+			return
+		}
+		id = res.Id
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if _, ok := g.webhooks[reponame]; !ok {
+		g.webhooks[reponame] = make(map[string]*GithubWebhook)
+	}
+	g.webhooks[reponame][webhook.URL] = &GithubWebhook{
+		Id:          id,
+		URL:         webhook.URL,
+		Events:      webhook.Events,
+		ContentType: webhook.ContentType,
+		Active:      webhook.Active,
+	}
+}
+
+func (g *GoliacRemoteImpl) UpdateRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) {
+	if !dryrun {
+		// the config is updated separately: a config sent to the webhook
+		// endpoint replaces the whole config (and so removes the secret)
+		// https://docs.github.com/en/rest/repos/webhooks?apiVersion=2022-11-28#update-a-webhook-configuration-for-a-repository
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/hooks/%d/config", config.Config.GithubAppOrganization, reponame, webhook.Id),
+			"PATCH",
+			webhookConfigPayload(webhook),
+		)
+		if err != nil {
+			logrus.Errorf("failed to update webhook %s of repository %s: %v. %s", webhook.URL, reponame, err, string(body))
+			return
+		}
+
+		// https://docs.github.com/en/rest/repos/webhooks?apiVersion=2022-11-28#update-a-repository-webhook
+		body, err = g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/hooks/%d", config.Config.GithubAppOrganization, reponame, webhook.Id),
+			"PATCH",
+			map[string]interface{}{
+				"active": webhook.Active,
+				"events": webhook.Events,
+			},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update webhook %s of repository %s: %v. %s", webhook.URL, reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	if _, ok := g.webhooks[reponame]; !ok {
+		g.webhooks[reponame] = make(map[string]*GithubWebhook)
+	}
+	g.webhooks[reponame][webhook.URL] = &GithubWebhook{
+		Id:          webhook.Id,
+		URL:         webhook.URL,
+		Events:      webhook.Events,
+		ContentType: webhook.ContentType,
+		Active:      webhook.Active,
+	}
+}
+
+func (g *GoliacRemoteImpl) DeleteRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *GithubWebhook) {
+	if !dryrun {
+		// https://docs.github.com/en/rest/repos/webhooks?apiVersion=2022-11-28#delete-a-repository-webhook
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/repos/%s/%s/hooks/%d", config.Config.GithubAppOrganization, reponame, webhook.Id),
+			"DELETE",
+			nil,
+		)
+		if err != nil {
+			logrus.Errorf("failed to delete webhook %s of repository %s: %v. %s", webhook.URL, reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	delete(g.webhooks[reponame], webhook.URL)
+}
+
+func (g *GoliacRemoteImpl) loadRepositoriesCustomProperties(ctx context.Context) (map[string]map[string]string, error) {
+	logrus.Debug("loading repositories custom properties")
+	// value is a string, or an array of strings for multi_select properties (not managed by Goliac)
+	type CustomPropertyValue struct {
+		PropertyName string      `json:"property_name"`
+		Value        interface{} `json:"value"`
+	}
+
+	reponames := make([]string, 0, len(g.Repositories(ctx)))
+	for reponame := range g.Repositories(ctx) {
+		reponames = append(reponames, reponame)
+	}
+
+	var mutex sync.Mutex
+	properties := make(map[string]map[string]string)
+	err := concurrentCall(ctx, config.Config.GithubConcurrentThreads, reponames, func(ctx context.Context, reponame string) error {
+		// https://docs.github.com/en/rest/repos/custom-properties?apiVersion=2022-11-28#get-all-custom-property-values-for-a-repository
+		body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/properties/values", config.Config.GithubAppOrganization, reponame), "GET", nil)
+		if err != nil {
+			return fmt.Errorf("not able to get custom properties for repository %s: %v. %s", reponame, err, string(body))
+		}
+
+		var values []CustomPropertyValue
+		err = json.Unmarshal(body, &values)
+		if err != nil {
+			return fmt.Errorf("not able to get custom properties for repository %s: %v", reponame, err)
+		}
+
+		repoProperties := make(map[string]string)
+		for _, v := range values {
+			if value, ok := v.Value.(string); ok {
+				repoProperties[v.PropertyName] = value
+			}
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		properties[reponame] = repoProperties
+		return nil
+	})
+
+	return properties, err
+}
+
+func (g *GoliacRemoteImpl) loadCustomRepositoryRoles(ctx context.Context) (map[string]string, error) {
+	logrus.Debug("loading custom repository roles")
+	type CustomRepositoryRoles struct {
+		CustomRoles []struct {
+			Name     string `json:"name"`
+			BaseRole string `json:"base_role"`
+		} `json:"custom_roles"`
+	}
+
+	// https://docs.github.com/en/enterprise-cloud@latest/rest/orgs/custom-roles?apiVersion=2022-11-28#list-custom-repository-roles-in-an-organization
+	body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/custom-repository-roles", config.Config.GithubAppOrganization), "GET", nil)
+	if err != nil {
+		return nil, fmt.Errorf("not able to get custom repository roles: %v. %s", err, string(body))
+	}
+
+	var result CustomRepositoryRoles
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, fmt.Errorf("not able to get custom repository roles: %v", err)
+	}
+
+	roles := make(map[string]string)
+	for _, r := range result.CustomRoles {
+		roles[r.Name] = r.BaseRole
+	}
+	return roles, nil
+}
+
+func (g *GoliacRemoteImpl) loadCustomPropertiesDefinitions(ctx context.Context) (map[string]bool, error) {
+	logrus.Debug("loading custom properties definitions")
+	type CustomPropertyDefinition struct {
+		PropertyName string `json:"property_name"`
+	}
+
+	// https://docs.github.com/en/rest/orgs/custom-properties?apiVersion=2022-11-28#get-all-custom-properties-for-an-organization
+	body, err := g.client.CallRestAPI(ctx, fmt.Sprintf("/orgs/%s/properties/schema", config.Config.GithubAppOrganization), "GET", nil)
+	if err != nil {
+		return nil, fmt.Errorf("not able to get custom properties definitions: %v. %s", err, string(body))
+	}
+
+	var definitions []CustomPropertyDefinition
+	err = json.Unmarshal(body, &definitions)
+	if err != nil {
+		return nil, fmt.Errorf("not able to get custom properties definitions: %v", err)
+	}
+
+	properties := make(map[string]bool)
+	for _, d := range definitions {
+		properties[d.PropertyName] = true
+	}
+	return properties, nil
+}
+
+/*
+UpdateRepositoryCustomProperties sets the custom properties values of the
+repository (the properties must be defined at the organization level).
+An empty value unsets the property
+*/
+func (g *GoliacRemoteImpl) UpdateRepositoryCustomProperties(ctx context.Context, dryrun bool, reponame string, properties map[string]string) {
+	if !dryrun {
+		names := make([]string, 0, len(properties))
+		for k := range properties {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+
+		values := make([]map[string]interface{}, 0, len(properties))
+		for _, k := range names {
+			var value interface{}
+			if properties[k] != "" {
+				value = properties[k]
+			}
+			values = append(values, map[string]interface{}{
+				"property_name": k,
+				"value":         value,
+			})
+		}
+
+		// https://docs.github.com/en/rest/orgs/custom-properties?apiVersion=2022-11-28#create-or-update-custom-property-values-for-organization-repositories
+		body, err := g.client.CallRestAPI(
+			ctx,
+			fmt.Sprintf("/orgs/%s/properties/values", config.Config.GithubAppOrganization),
+			"PATCH",
+			map[string]interface{}{
+				"repository_names": []string{reponame},
+				"properties":       values,
+			},
+		)
+		if err != nil {
+			logrus.Errorf("failed to update custom properties for repository %s: %v. %s", reponame, err, string(body))
+			return
+		}
+	}
+
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
+	repoProperties := g.customProperties[reponame]
+	if repoProperties == nil {
+		repoProperties = make(map[string]string)
+		g.customProperties[reponame] = repoProperties
+	}
+	for k, v := range properties {
+		if v == "" {
+			delete(repoProperties, k)
+		} else {
+			repoProperties[k] = v
+		}
+	}
+}
+
 func (g *GoliacRemoteImpl) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	// https://docs.github.com/en/rest/collaborators/collaborators?apiVersion=2022-11-28#add-a-repository-collaborator
 	if !dryrun {
@@ -1867,6 +4619,8 @@ func (g *GoliacRemoteImpl) UpdateRepositorySetExternalUser(ctx context.Context,
 		}
 	}
 
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	if repo, ok := g.repositories[reponame]; ok {
 		if permission == "push" {
 			repo.ExternalUsers[githubid] = "WRITE"
@@ -1890,6 +4644,8 @@ func (g *GoliacRemoteImpl) UpdateRepositoryRemoveExternalUser(ctx context.Contex
 		}
 	}
 
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	if repo, ok := g.repositories[reponame]; ok {
 		delete(repo.ExternalUsers, githubid)
 	}
@@ -1911,6 +4667,8 @@ func (g *GoliacRemoteImpl) DeleteRepository(ctx context.Context, dryrun bool, re
 	}
 
 	// update the repositories list
+	g.actionMutex.Lock()
+	defer g.actionMutex.Unlock()
 	if r, ok := g.repositories[reponame]; ok {
 		delete(g.repositoriesByRefId, r.RefId)
 		delete(g.repositories, reponame)
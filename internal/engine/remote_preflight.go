@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alayacare/goliac/internal/github"
+)
+
+/*
+ * PreflightCheck is the result of one call done to verify that the Github
+ * App has the permission needed by Goliac
+ */
+type PreflightCheck struct {
+	Name       string // what Goliac needs to do
+	Permission string // the Github App permission needed
+	Ok         bool
+	Skipped    bool // not tested (for example rulesets without enterprise plan)
+	Err        error
+}
+
+/*
+ * ValidateRemote calls (read only) the endpoints Goliac needs, to report the
+ * Github App permissions missing before any apply
+ */
+func ValidateRemote(ctx context.Context, orgname string, client github.GitHubClient) []PreflightCheck {
+	checks := []PreflightCheck{}
+
+	check := func(name, permission, endpoint string) {
+		_, err := client.CallRestAPI(ctx, endpoint, "GET", nil)
+		checks = append(checks, PreflightCheck{
+			Name:       name,
+			Permission: permission,
+			Ok:         err == nil,
+			Err:        err,
+		})
+	}
+
+	if _, err := client.GetAccessToken(ctx); err != nil {
+		return append(checks, PreflightCheck{
+			Name:       "get an installation token",
+			Permission: "the Github App must be installed on the organization " + orgname,
+			Err:        err,
+		})
+	}
+
+	_, err := getOrgInfo(ctx, orgname, client)
+	checks = append(checks, PreflightCheck{
+		Name:       "read the organization settings",
+		Permission: "Organization permissions: Administration (read and write)",
+		Ok:         err == nil,
+		Err:        err,
+	})
+
+	check("list the organization members", "Organization permissions: Members (read and write)", fmt.Sprintf("/orgs/%s/members?per_page=1", orgname))
+	check("list the teams", "Organization permissions: Members (read and write)", fmt.Sprintf("/orgs/%s/teams?per_page=1", orgname))
+	check("list the repositories", "Repository permissions: Administration (read and write)", fmt.Sprintf("/orgs/%s/repos?per_page=1", orgname))
+	check("list the organization variables", "Organization permissions: Variables (read and write)", fmt.Sprintf("/orgs/%s/actions/variables?per_page=1", orgname))
+
+	if isEnterprise(ctx, orgname, client) {
+		check("list the rulesets", "Organization permissions: Administration (read and write)", fmt.Sprintf("/orgs/%s/rulesets?per_page=1", orgname))
+	} else {
+		checks = append(checks, PreflightCheck{
+			Name:       "list the rulesets",
+			Permission: "Organization permissions: Administration (read and write)",
+			Skipped:    true,
+			Err:        fmt.Errorf("rulesets need an enterprise plan"),
+		})
+	}
+
+	return checks
+}
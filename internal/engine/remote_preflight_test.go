@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/github"
+	"github.com/stretchr/testify/assert"
+)
+
+type GitHubClientPreflightMock struct {
+	results   map[string][]byte
+	forbidden map[string]bool
+	tokenErr  error
+}
+
+func (g *GitHubClientPreflightMock) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
+	return []byte(""), nil
+}
+func (g *GitHubClientPreflightMock) CallRestAPI(ctx context.Context, endpoint, method string, body map[string]interface{}) ([]byte, error) {
+	if g.forbidden[endpoint] {
+		return []byte(`{"message": "Resource not accessible by integration"}`), fmt.Errorf("unexpected status: 403 Forbidden")
+	}
+	if result, ok := g.results[endpoint]; ok {
+		return result, nil
+	}
+	return nil, fmt.Errorf("unexpected status: 404 Not Found")
+}
+func (g *GitHubClientPreflightMock) GetAccessToken(ctx context.Context) (string, error) {
+	return "token", g.tokenErr
+}
+func (g *GitHubClientPreflightMock) GetAppSlug() string {
+	return ""
+}
+func (g *GitHubClientPreflightMock) GetRateLimits() map[string]github.RateLimit {
+	return map[string]github.RateLimit{}
+}
+
+func TestValidateRemote(t *testing.T) {
+
+	newMock := func() *GitHubClientPreflightMock {
+		return &GitHubClientPreflightMock{
+			results: map[string][]byte{
+				"/orgs/myorg":                              []byte(`{"two_factor_requirement_enabled": true,"plan": {"name":"enterprise"}}`),
+				"/orgs/myorg/members?per_page=1":           []byte(`[]`),
+				"/orgs/myorg/teams?per_page=1":             []byte(`[]`),
+				"/orgs/myorg/repos?per_page=1":             []byte(`[]`),
+				"/orgs/myorg/actions/variables?per_page=1": []byte(`{"total_count": 0, "variables": []}`),
+				"/orgs/myorg/rulesets?per_page=1":          []byte(`[]`),
+			},
+			forbidden: map[string]bool{},
+		}
+	}
+
+	t.Run("happy path: all permissions are granted", func(t *testing.T) {
+		checks := ValidateRemote(context.TODO(), "myorg", newMock())
+		assert.Equal(t, 6, len(checks))
+		for _, check := range checks {
+			assert.True(t, check.Ok, check.Name)
+		}
+	})
+
+	t.Run("not happy path: the teams can't be read", func(t *testing.T) {
+		mock := newMock()
+		mock.forbidden["/orgs/myorg/teams?per_page=1"] = true
+
+		checks := ValidateRemote(context.TODO(), "myorg", mock)
+		failed := []PreflightCheck{}
+		for _, check := range checks {
+			if !check.Ok && !check.Skipped {
+				failed = append(failed, check)
+			}
+		}
+		assert.Equal(t, 1, len(failed))
+		assert.Equal(t, "list the teams", failed[0].Name)
+		assert.Equal(t, "Organization permissions: Members (read and write)", failed[0].Permission)
+	})
+
+	t.Run("happy path: rulesets are skipped without enterprise plan", func(t *testing.T) {
+		mock := newMock()
+		mock.results["/orgs/myorg"] = []byte(`{"two_factor_requirement_enabled": true,"plan": {"name":"free"}}`)
+
+		checks := ValidateRemote(context.TODO(), "myorg", mock)
+		assert.Equal(t, 6, len(checks))
+		assert.Equal(t, "list the rulesets", checks[5].Name)
+		assert.True(t, checks[5].Skipped)
+	})
+
+	t.Run("not happy path: the app is not installed", func(t *testing.T) {
+		mock := newMock()
+		mock.tokenErr = fmt.Errorf("unexpected status: 404 Not Found")
+
+		checks := ValidateRemote(context.TODO(), "myorg", mock)
+		assert.Equal(t, 1, len(checks))
+		assert.False(t, checks[0].Ok)
+	})
+}
+
+func TestValidateRemoteGithubServer(t *testing.T) {
+
+	t.Run("happy path: the query strings reach Github", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		assert.Nil(t, err)
+		keyFile := filepath.Join(t.TempDir(), "private-key.pem")
+		err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+		assert.Nil(t, err)
+
+		responses := map[string]string{
+			"GET /app/installations":                       `[{"id": 1, "app_id": 42, "app_slug": "goliac", "account": {"login": "myorg"}}]`,
+			"POST /app/installations/1/access_tokens":      `{"token": "token"}`,
+			"GET /orgs/myorg":                              `{"two_factor_requirement_enabled": true,"plan": {"name":"enterprise"}}`,
+			"GET /orgs/myorg/members?per_page=1":           `[]`,
+			"GET /orgs/myorg/teams?per_page=1":             `[]`,
+			"GET /orgs/myorg/repos?per_page=1":             `[]`,
+			"GET /orgs/myorg/actions/variables?per_page=1": `{"total_count": 0, "variables": []}`,
+			"GET /orgs/myorg/rulesets?per_page=1":          `[]`,
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the raw request URI: an escaped query string (%3F) doesn't match
+			key := r.Method + " " + r.RequestURI
+			response, ok := responses[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if r.Method == "POST" {
+				w.WriteHeader(http.StatusCreated)
+			}
+			w.Write([]byte(response))
+		}))
+		defer server.Close()
+
+		client, err := github.NewGitHubClientImpl(server.URL, "myorg", 42, keyFile)
+		assert.Nil(t, err)
+
+		checks := ValidateRemote(context.TODO(), "myorg", client)
+		assert.Equal(t, 6, len(checks))
+		for _, check := range checks {
+			assert.True(t, check.Ok, check.Name)
+		}
+	})
+}
@@ -9,10 +9,16 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/entity"
 	"github.com/Alayacare/goliac/internal/github"
+	"github.com/Alayacare/goliac/internal/github/githubtest"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/vektah/gqlparser/v2/ast"
@@ -48,7 +54,7 @@ func extractVariable(name string, args ast.ArgumentList, variables map[string]in
 				if variables[value[1:]] == nil {
 					value = ""
 				} else {
-					value = variables[value[1:]].(string)
+					value = fmt.Sprintf("%v", variables[value[1:]])
 				}
 			}
 			return value
@@ -103,6 +109,8 @@ func (m *MockGithubClient) reposNodes(first, after string, args ast.ArgumentList
 	searchName, _ := hasChild("name", children)
 	searchArchived, _ := hasChild("isArchived", children)
 	searchPrivate, _ := hasChild("isPrivate", children)
+	searchTemplate, _ := hasChild("isTemplate", children)
+	searchTemplateRepository, _ := hasChild("templateRepository", children)
 
 	index := iAfter
 	totalCount := 0
@@ -121,6 +129,13 @@ func (m *MockGithubClient) reposNodes(first, after string, args ast.ArgumentList
 		if searchPrivate {
 			block["isPrivate"] = index%10 == 0 // let's pretend each 10 repo is a private repo
 		}
+		if searchTemplate {
+			block["isTemplate"] = index == 7 // let's pretend repo_7 is a template
+		}
+		if searchTemplateRepository && index == 8 {
+			// and repo_8 was generated from it
+			block["templateRepository"] = map[string]interface{}{"nameWithOwner": "myorg/repo_7"}
+		}
 		index++
 		if index > maxToFake { // let's pretend we have maxToFake repos
 			hasNext = false
@@ -244,6 +259,8 @@ func (m *MockGithubClient) teamsNodes(first, after string, args ast.ArgumentList
 
 	searchName, _ := hasChild("name", children)
 	searchSlug, _ := hasChild("slug", children)
+	searchPrivacy, _ := hasChild("privacy", children)
+	searchNotificationSetting, _ := hasChild("notificationSetting", children)
 
 	index := iAfter
 	totalCount := 0
@@ -259,6 +276,19 @@ func (m *MockGithubClient) teamsNodes(first, after string, args ast.ArgumentList
 		if searchSlug {
 			block["slug"] = fmt.Sprintf("slug-%d", index)
 		}
+		// the odd teams are secret, without notifications
+		if searchPrivacy {
+			block["privacy"] = "VISIBLE"
+			if index%2 == 1 {
+				block["privacy"] = "SECRET"
+			}
+		}
+		if searchNotificationSetting {
+			block["notificationSetting"] = "NOTIFICATIONS_ENABLED"
+			if index%2 == 1 {
+				block["notificationSetting"] = "NOTIFICATIONS_DISABLED"
+			}
+		}
 		index++
 		if index > 122 { // let's pretend we have 133 teams
 			hasNext = false
@@ -361,6 +391,9 @@ func (m *MockGithubClient) organization(args ast.ArgumentList, children ast.Sele
 func (m *MockGithubClient) GetAppSlug() string {
 	return "mock-github-client"
 }
+func (m *MockGithubClient) GetRateLimits() map[string]github.RateLimit {
+	return map[string]github.RateLimit{}
+}
 
 func (m *MockGithubClient) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
 
@@ -411,6 +444,105 @@ func (m *MockGithubClient) GetAccessToken(ctx context.Context) (string, error) {
 	return "", nil
 }
 
+/*
+ * MockGithubClientTimeout simulates a Github GraphQL timeout
+ * when the page size requested is greater than maxPageSize
+ */
+type MockGithubClientTimeout struct {
+	MockGithubClient
+	maxPageSize   int
+	pageSizesUsed []int
+}
+
+func (m *MockGithubClientTimeout) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
+	if pageSize, ok := variables["pageSize"].(int); ok {
+		m.pageSizesUsed = append(m.pageSizesUsed, pageSize)
+		if pageSize > m.maxPageSize {
+			return []byte(`{"data":null,"errors":[{"message":"Something went wrong while executing your query. This may be the result of a timeout, or it could be a GitHub bug."}]}`), nil
+		}
+	}
+	return m.MockGithubClient.QueryGraphQLAPI(ctx, query, variables)
+}
+
+/*
+ * MockGithubClientRepositoriesPages returns nbRepos synthetic repositories,
+ * after a simulated network latency per page
+ */
+type MockGithubClientRepositoriesPages struct {
+	MockGithubClient
+	nbRepos int
+	latency time.Duration
+}
+
+func (m *MockGithubClientRepositoriesPages) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
+	time.Sleep(m.latency)
+
+	start := 0
+	if cursor, ok := variables["endCursor"].(string); ok {
+		start, _ = strconv.Atoi(cursor)
+	}
+	end := start + variables["pageSize"].(int)
+	if end > m.nbRepos {
+		end = m.nbRepos
+	}
+
+	nodes := []string{}
+	for i := start; i < end; i++ {
+		nodes = append(nodes, fmt.Sprintf(`{"name":"repo_%d","id":"R_%d","databaseId":%d,"isArchived":%t,"isPrivate":true,"description":"repository number %d","pushedAt":"2024-01-01T10:00:00Z","defaultBranchRef":{"name":"main","branchProtectionRule":{"requiresCommitSignatures":%t}},"collaborators":{"edges":[{"node":{"login":"outside_%d"},"permission":"READ"},{"node":{"login":"outside_%d"},"permission":"WRITE"}]}}`, i, i, i, i%7 == 0, i, i%2 == 0, i, i+1))
+	}
+	return []byte(fmt.Sprintf(`{"data":{"organization":{"repositories":{"nodes":[%s],"pageInfo":{"hasNextPage":%t,"endCursor":"%d"},"totalCount":%d}}}}`, strings.Join(nodes, ","), end < m.nbRepos, end, m.nbRepos)), nil
+}
+
+/*
+ * MockGithubClientLoad answers all the queries of Load (nbRepos repositories,
+ * the rulesets nodes, without user, team or app), and records the maximum
+ * number of queries in flight
+ */
+type MockGithubClientLoad struct {
+	MockGithubClientRepositoriesPages
+	rulesets    string
+	mutex       sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (m *MockGithubClientLoad) enter() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+}
+
+func (m *MockGithubClientLoad) leave() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.inFlight--
+}
+
+func (m *MockGithubClientLoad) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
+	m.enter()
+	defer m.leave()
+
+	if _, ok := variables["pageSize"]; ok {
+		return m.MockGithubClientRepositoriesPages.QueryGraphQLAPI(ctx, query, variables)
+	}
+	time.Sleep(m.latency)
+	return []byte(`{"data":{"organization":{"rulesets":{"nodes":[` + m.rulesets + `]},"membersWithRole":{"edges":[],"pageInfo":{"hasNextPage":false}},"teams":{"nodes":[],"pageInfo":{"hasNextPage":false}}}}}`), nil
+}
+
+func (m *MockGithubClientLoad) CallRestAPI(ctx context.Context, endpoint, method string, body map[string]interface{}) ([]byte, error) {
+	m.enter()
+	defer m.leave()
+
+	time.Sleep(m.latency)
+	if strings.HasSuffix(endpoint, "/installations") {
+		return []byte(`{"total_count":0,"installations":[]}`), nil
+	}
+	return []byte(`[]`), nil
+}
+
 func TestRemoteRepository(t *testing.T) {
 
 	// happy path
@@ -428,7 +560,94 @@ func TestRemoteRepository(t *testing.T) {
 		assert.Equal(t, true, repositories["repo_3"].BoolProperties["archived"])
 		assert.Equal(t, false, repositories["repo_1"].BoolProperties["private"])
 		assert.Equal(t, true, repositories["repo_10"].BoolProperties["private"])
+		assert.True(t, repositories["repo_7"].BoolProperties["is_template"])
+		assert.False(t, repositories["repo_8"].BoolProperties["is_template"])
+		assert.Equal(t, "myorg/repo_7", repositories["repo_8"].TemplateRepository)
+		assert.Equal(t, "", repositories["repo_7"].TemplateRepository)
+	})
+	t.Run("happy path: load remote repositories with a timeout", func(t *testing.T) {
+		client := MockGithubClientTimeout{
+			maxPageSize: 30,
+		}
+
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		ctx := context.TODO()
+		repositories, _, err := remoteImpl.loadRepositories(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 133, len(repositories))
+		// 100 and 50 timed out, then 25 is used for all the pages
+		assert.Equal(t, []int{100, 50, 25, 25, 25, 25, 25, 25}, client.pageSizesUsed)
 	})
+
+	t.Run("happy path: parsing the pages concurrently loads the same repositories", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		client := MockGithubClientRepositoriesPages{nbRepos: 2000}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		config.Config.GithubConcurrentThreads = 1
+		sequential, sequentialByRefId, err := remoteImpl.loadRepositories(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 2000, len(sequential))
+
+		config.Config.GithubConcurrentThreads = 4
+		concurrent, concurrentByRefId, err := remoteImpl.loadRepositories(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, sequential, concurrent)
+		assert.Equal(t, sequentialByRefId, concurrentByRefId)
+		assert.Equal(t, map[string]string{"outside_42": "READ", "outside_43": "WRITE"}, concurrent["repo_42"].ExternalUsers)
+	})
+
+	t.Run("happy path: the independent loads run concurrently", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+
+		config.Config.GithubConcurrentThreads = 1
+		client := &MockGithubClientLoad{MockGithubClientRepositoriesPages: MockGithubClientRepositoriesPages{nbRepos: 20, latency: 10 * time.Millisecond}}
+		remoteImpl := NewGoliacRemoteImpl(client)
+		err := remoteImpl.Load(context.TODO(), false)
+		assert.Nil(t, err)
+		assert.Equal(t, 20, len(remoteImpl.repositories))
+		assert.Equal(t, 1, client.maxInFlight)
+
+		config.Config.GithubConcurrentThreads = 4
+		client = &MockGithubClientLoad{MockGithubClientRepositoriesPages: MockGithubClientRepositoriesPages{nbRepos: 20, latency: 10 * time.Millisecond}}
+		remoteImpl = NewGoliacRemoteImpl(client)
+		err = remoteImpl.Load(context.TODO(), false)
+		assert.Nil(t, err)
+		assert.Equal(t, 20, len(remoteImpl.repositories))
+		assert.Equal(t, 0, len(remoteImpl.teams))
+		assert.Greater(t, client.maxInFlight, 1)
+	})
+
+	t.Run("happy path: the rulesets repositories are resolved on the first load", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+
+		for _, threads := range []int64{1, 4} {
+			config.Config.GithubConcurrentThreads = threads
+			client := &MockGithubClientLoad{
+				MockGithubClientRepositoriesPages: MockGithubClientRepositoriesPages{nbRepos: 20},
+				rulesets:                          `{"databaseId":1,"name":"myruleset","target":"BRANCH","enforcement":"ACTIVE","conditions":{"repositoryId":{"repositoryIds":["R_1","R_2"]}},"rules":{"nodes":[]}}`,
+			}
+			remoteImpl := NewGoliacRemoteImpl(client)
+			err := remoteImpl.Load(context.TODO(), false)
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"repo_1", "repo_2"}, remoteImpl.rulesets["myruleset"].Repositories)
+		}
+	})
+
+	t.Run("not happy path: repositories page size cannot be reduced anymore", func(t *testing.T) {
+		client := MockGithubClientTimeout{
+			maxPageSize: 0,
+		}
+
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		ctx := context.TODO()
+		repositories, _, err := remoteImpl.loadRepositories(ctx)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(repositories))
+	})
+
 	t.Run("happy path: load remote teams", func(t *testing.T) {
 		// MockGithubClient doesn't support concurrent access
 		client := MockGithubClient{}
@@ -440,6 +659,10 @@ func TestRemoteRepository(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, 122, len(teams))
 		assert.Equal(t, "team_1", teams["slug-1"].Name)
+		assert.Equal(t, "secret", teams["slug-1"].Privacy)
+		assert.Equal(t, "notifications_disabled", teams["slug-1"].NotificationSetting)
+		assert.Equal(t, "closed", teams["slug-2"].Privacy)
+		assert.Equal(t, "notifications_enabled", teams["slug-2"].NotificationSetting)
 	})
 
 	t.Run("happy path: load remote team's repos", func(t *testing.T) {
@@ -486,6 +709,9 @@ func (g *GitHubClientIsEnterpriseMock) GetAccessToken(ctx context.Context) (stri
 func (g *GitHubClientIsEnterpriseMock) GetAppSlug() string {
 	return ""
 }
+func (g *GitHubClientIsEnterpriseMock) GetRateLimits() map[string]github.RateLimit {
+	return map[string]github.RateLimit{}
+}
 
 func TestIsEnterprise(t *testing.T) {
 
@@ -595,3 +821,1237 @@ func TestIsEnterprise(t *testing.T) {
 		}
 	})
 }
+
+func TestRemoteActionsPermissions(t *testing.T) {
+
+	t.Run("happy path: load actions permissions, with Actions disabled on a repository", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/actions/permissions": []byte(`{"enabled":true,"allowed_actions":"local_only","selected_actions_url":""}`),
+				"/repos/" + config.Config.GithubAppOrganization + "/repo2/actions/permissions": []byte(`{"enabled":false}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+			"repo2": {Name: "repo2"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		ctx := context.TODO()
+		permissions := remoteImpl.RepositoriesActionsPermissions(ctx)
+		assert.Equal(t, 2, len(permissions))
+		assert.Equal(t, &GithubActionsPermissions{Enabled: true, AllowedActions: "local_only"}, permissions["repo1"])
+		assert.Equal(t, &GithubActionsPermissions{Enabled: false}, permissions["repo2"])
+	})
+
+	t.Run("happy path: load actions permissions concurrently", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		config.Config.GithubConcurrentThreads = 4
+
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{}
+		for i := 0; i < 10; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			client.results["/repos/"+config.Config.GithubAppOrganization+"/"+reponame+"/actions/permissions"] = []byte(`{"enabled":true,"allowed_actions":"all"}`)
+			remoteImpl.repositories[reponame] = &GithubRepository{Name: reponame}
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		ctx := context.TODO()
+		permissions, err := remoteImpl.loadRepositoriesActionsPermissions(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(permissions))
+		assert.Equal(t, &GithubActionsPermissions{Enabled: true, AllowedActions: "all"}, permissions["repo9"])
+	})
+
+	t.Run("not happy path: error when loading actions permissions", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("an error occured"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		ctx := context.TODO()
+		_, err := remoteImpl.loadRepositoriesActionsPermissions(ctx)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(remoteImpl.RepositoriesActionsPermissions(ctx)))
+	})
+}
+
+func TestRemoteCustomProperties(t *testing.T) {
+
+	t.Run("happy path: load custom properties, ignoring multi select ones", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/properties/values": []byte(`[{"property_name":"team-owner","value":"foo"},{"property_name":"tier","value":null},{"property_name":"languages","value":["go","python"]}]`),
+				"/repos/" + config.Config.GithubAppOrganization + "/repo2/properties/values": []byte(`[]`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+			"repo2": {Name: "repo2"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		ctx := context.TODO()
+		properties := remoteImpl.RepositoriesCustomProperties(ctx)
+		assert.Equal(t, 2, len(properties))
+		assert.Equal(t, map[string]string{"team-owner": "foo"}, properties["repo1"])
+		assert.Equal(t, map[string]string{}, properties["repo2"])
+	})
+
+	t.Run("happy path: load custom properties concurrently", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		config.Config.GithubConcurrentThreads = 4
+
+		client := GitHubClientIsEnterpriseMock{results: map[string][]byte{}}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{}
+		for i := 0; i < 10; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			remoteImpl.repositories[reponame] = &GithubRepository{Name: reponame}
+			client.results["/repos/"+config.Config.GithubAppOrganization+"/"+reponame+"/properties/values"] = []byte(fmt.Sprintf(`[{"property_name":"team-owner","value":"team%d"}]`, i))
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		properties, err := remoteImpl.loadRepositoriesCustomProperties(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(properties))
+		assert.Equal(t, map[string]string{"team-owner": "team9"}, properties["repo9"])
+	})
+
+	t.Run("happy path: update the cache", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.customProperties = map[string]map[string]string{
+			"repo1": {"team-owner": "foo", "tier": "1"},
+		}
+
+		ctx := context.TODO()
+		remoteImpl.UpdateRepositoryCustomProperties(ctx, true, "repo1", map[string]string{"team-owner": "bar", "tier": ""})
+		assert.Equal(t, map[string]string{"team-owner": "bar"}, remoteImpl.customProperties["repo1"])
+	})
+
+	t.Run("not happy path: error when loading custom properties", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("an error occured"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		ctx := context.TODO()
+		_, err := remoteImpl.loadRepositoriesCustomProperties(ctx)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(remoteImpl.RepositoriesCustomProperties(ctx)))
+	})
+
+	t.Run("happy path: load custom properties definitions", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/orgs/" + config.Config.GithubAppOrganization + "/properties/schema": []byte(`[{"property_name":"team-owner","value_type":"string"},{"property_name":"tier","value_type":"single_select"}]`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		definitions := remoteImpl.CustomPropertiesDefinitions(context.TODO())
+		assert.Equal(t, map[string]bool{"team-owner": true, "tier": true}, definitions)
+	})
+
+	t.Run("not happy path: error when loading custom properties definitions", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("an error occured"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		assert.Nil(t, remoteImpl.CustomPropertiesDefinitions(context.TODO()))
+	})
+}
+
+func TestRemoteWebhooks(t *testing.T) {
+
+	t.Run("happy path: load webhooks", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/hooks?per_page=100&page=1": []byte(`[{"id":12,"name":"web","active":true,"events":["push","pull_request"],"config":{"url":"https://ci.example.com/hook","content_type":"json","secret":"********"}}]`),
+				"/repos/" + config.Config.GithubAppOrganization + "/repo2/hooks?per_page=100&page=1": []byte(`[]`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+			"repo2": {Name: "repo2"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		webhooks := remoteImpl.RepositoriesWebhooks(context.TODO())
+		assert.Equal(t, 2, len(webhooks))
+		assert.Equal(t, &GithubWebhook{
+			Id:          12,
+			URL:         "https://ci.example.com/hook",
+			Events:      []string{"push", "pull_request"},
+			ContentType: "json",
+			Active:      true,
+		}, webhooks["repo1"]["https://ci.example.com/hook"])
+		assert.Equal(t, 0, len(webhooks["repo2"]))
+	})
+
+	t.Run("happy path: load webhooks concurrently", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		config.Config.GithubConcurrentThreads = 4
+
+		client := GitHubClientIsEnterpriseMock{results: map[string][]byte{}}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{}
+		for i := 0; i < 10; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			remoteImpl.repositories[reponame] = &GithubRepository{Name: reponame}
+			client.results["/repos/"+config.Config.GithubAppOrganization+"/"+reponame+"/hooks?per_page=100&page=1"] = []byte(fmt.Sprintf(`[{"id":%d,"name":"web","active":true,"events":["push"],"config":{"url":"https://ci.example.com/hook","content_type":"json"}}]`, i))
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		webhooks, err := remoteImpl.loadRepositoriesWebhooks(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(webhooks))
+		assert.Equal(t, 9, webhooks["repo9"]["https://ci.example.com/hook"].Id)
+	})
+
+	t.Run("happy path: load the webhooks of all the pages", func(t *testing.T) {
+		hooks := make([]string, 0, 100)
+		for i := 0; i < 100; i++ {
+			hooks = append(hooks, fmt.Sprintf(`{"id":%d,"name":"web","active":true,"events":["push"],"config":{"url":"https://ci.example.com/hook%d","content_type":"json"}}`, i, i))
+		}
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/hooks?per_page=100&page=1": []byte("[" + strings.Join(hooks, ",") + "]"),
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/hooks?per_page=100&page=2": []byte(`[{"id":100,"name":"web","active":true,"events":["push"],"config":{"url":"https://ci.example.com/hook100","content_type":"json"}}]`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		webhooks, err := remoteImpl.loadRepositoriesWebhooks(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 101, len(webhooks["repo1"]))
+		assert.Equal(t, 100, webhooks["repo1"]["https://ci.example.com/hook100"].Id)
+	})
+
+	t.Run("happy path: add a webhook", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/hooks": []byte(`{"id":42,"name":"web"}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		remoteImpl.AddRepositoryWebhook(context.TODO(), false, "repo1", &GithubWebhook{URL: "https://ci.example.com/hook", Events: []string{"push"}, ContentType: "form", Active: true, Secret: "s3cr3t"})
+		webhook := remoteImpl.webhooks["repo1"]["https://ci.example.com/hook"]
+		assert.Equal(t, 42, webhook.Id)
+		// the secret is not kept in the cache
+		assert.Equal(t, "", webhook.Secret)
+	})
+
+	t.Run("happy path: the payload contains the secret only if set", func(t *testing.T) {
+		payload := webhookPayload(&GithubWebhook{URL: "https://ci.example.com/hook", Events: []string{"push"}, ContentType: "json", Active: true})
+		_, ok := payload["config"].(map[string]interface{})["secret"]
+		assert.False(t, ok)
+
+		payload = webhookPayload(&GithubWebhook{URL: "https://ci.example.com/hook", Events: []string{"push"}, ContentType: "json", Active: true, Secret: "s3cr3t"})
+		assert.Equal(t, "s3cr3t", payload["config"].(map[string]interface{})["secret"])
+	})
+
+	t.Run("happy path: update a webhook without secret keeps the current one", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("PATCH", "/repos/"+org+"/repo1/hooks/42/config", `{}`).
+			ReplyRest("PATCH", "/repos/"+org+"/repo1/hooks/42", `{"id":42}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		remoteImpl.UpdateRepositoryWebhook(context.TODO(), false, "repo1", &GithubWebhook{Id: 42, URL: "https://ci.example.com/hook", Events: []string{"push"}, ContentType: "json", Active: true})
+
+		configRequests := client.RequestsTo("PATCH", "/repos/"+org+"/repo1/hooks/42/config")
+		assert.Equal(t, 1, len(configRequests))
+		assert.Equal(t, map[string]interface{}{"url": "https://ci.example.com/hook", "content_type": "json"}, configRequests[0].Body)
+		hookRequests := client.RequestsTo("PATCH", "/repos/"+org+"/repo1/hooks/42")
+		assert.Equal(t, 1, len(hookRequests))
+		_, ok := hookRequests[0].Body["config"]
+		assert.False(t, ok)
+	})
+
+	t.Run("happy path: delete a webhook", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.webhooks = map[string]map[string]*GithubWebhook{
+			"repo1": {"https://ci.example.com/hook": {Id: 12, URL: "https://ci.example.com/hook"}},
+		}
+
+		remoteImpl.DeleteRepositoryWebhook(context.TODO(), false, "repo1", &GithubWebhook{Id: 12, URL: "https://ci.example.com/hook"})
+		assert.Equal(t, 0, len(remoteImpl.webhooks["repo1"]))
+	})
+
+	t.Run("not happy path: error when updating a webhook", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("an error occured"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.webhooks = map[string]map[string]*GithubWebhook{
+			"repo1": {"https://ci.example.com/hook": {Id: 12, URL: "https://ci.example.com/hook", ContentType: "form"}},
+		}
+
+		remoteImpl.UpdateRepositoryWebhook(context.TODO(), false, "repo1", &GithubWebhook{Id: 12, URL: "https://ci.example.com/hook", ContentType: "json"})
+		assert.Equal(t, "form", remoteImpl.webhooks["repo1"]["https://ci.example.com/hook"].ContentType)
+	})
+}
+
+func TestRemoteSecurity(t *testing.T) {
+
+	t.Run("happy path: load security settings", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1": []byte(`{"name":"repo1","security_and_analysis":{"secret_scanning":{"status":"enabled"},"secret_scanning_push_protection":{"status":"disabled"},"dependabot_security_updates":{"status":"enabled"}}}`),
+				"/repos/" + config.Config.GithubAppOrganization + "/repo2": []byte(`{"name":"repo2"}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+			"repo2": {Name: "repo2"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		security := remoteImpl.RepositoriesSecurity(context.TODO())
+		assert.Equal(t, 2, len(security))
+		assert.Equal(t, &GithubRepositorySecurity{SecretScanning: true, DependabotSecurityUpdates: true}, security["repo1"])
+		assert.Equal(t, &GithubRepositorySecurity{}, security["repo2"])
+	})
+
+	t.Run("happy path: load security settings concurrently", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		config.Config.GithubConcurrentThreads = 4
+
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{}
+		for i := 0; i < 10; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			client.results["/repos/"+config.Config.GithubAppOrganization+"/"+reponame] = []byte(`{"security_and_analysis":{"secret_scanning":{"status":"enabled"}}}`)
+			remoteImpl.repositories[reponame] = &GithubRepository{Name: reponame}
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		security, err := remoteImpl.loadRepositoriesSecurity(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(security))
+		assert.Equal(t, &GithubRepositorySecurity{SecretScanning: true}, security["repo9"])
+	})
+
+	t.Run("happy path: update on a 204 (no body)", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1", BoolProperties: map[string]bool{"private": true}},
+		}
+
+		remoteImpl.UpdateRepositorySetSecurity(context.TODO(), false, "repo1", "dependabot_security_updates", true)
+		remoteImpl.UpdateRepositorySetSecurity(context.TODO(), false, "repo1", "secret_scanning", true)
+		assert.Equal(t, &GithubRepositorySecurity{SecretScanning: true, DependabotSecurityUpdates: true}, remoteImpl.security["repo1"])
+	})
+
+	t.Run("happy path: secret scanning is a no-op on public repositories", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("should not be called"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1", BoolProperties: map[string]bool{"private": false}},
+		}
+
+		hook := logrustest.NewGlobal()
+		defer hook.Reset()
+		remoteImpl.UpdateRepositorySetSecurity(context.TODO(), false, "repo1", "secret_scanning_push_protection", false)
+
+		assert.Equal(t, 0, len(remoteImpl.security))
+		found := false
+		for _, entry := range hook.AllEntries() {
+			assert.NotEqual(t, logrus.ErrorLevel, entry.Level)
+			if entry.Level == logrus.WarnLevel {
+				found = true
+			}
+		}
+		assert.True(t, found)
+	})
+}
+
+func TestRemoteMergeQueueRuleset(t *testing.T) {
+
+	t.Run("happy path: merge queue payload with the Github default values", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+
+		payload := remoteImpl.prepareRuleset(&GithubRuleSet{
+			Name:        "mergequeue",
+			Enforcement: "active",
+			Rules: map[string]entity.RuleSetParameters{
+				"merge_queue": {
+					MergeMethod:       "squash",
+					MinEntriesToMerge: 2,
+				},
+			},
+		})
+
+		rules := payload["rules"].([]map[string]interface{})
+		assert.Equal(t, 1, len(rules))
+		assert.Equal(t, "merge_queue", rules[0]["type"])
+		assert.Equal(t, map[string]interface{}{
+			"check_response_timeout_minutes":    60,
+			"grouping_strategy":                 "ALLGREEN",
+			"max_entries_to_build":              5,
+			"max_entries_to_merge":              5,
+			"merge_method":                      "SQUASH",
+			"min_entries_to_merge":              2,
+			"min_entries_to_merge_wait_minutes": 0,
+		}, rules[0]["parameters"])
+	})
+
+	t.Run("happy path: merge queue parsed from graphql", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+
+		var src GraphQLGithubRuleSet
+		err := json.Unmarshal([]byte(`{
+			"name": "mergequeue",
+			"enforcement": "ACTIVE",
+			"rules": {
+				"nodes": [{
+					"type": "MERGE_QUEUE",
+					"parameters": {
+						"checkResponseTimeoutMinutes": 60,
+						"groupingStrategy": "HEADGREEN",
+						"maxEntriesToBuild": 5,
+						"maxEntriesToMerge": 5,
+						"mergeMethod": "REBASE",
+						"minEntriesToMerge": 1,
+						"minEntriesToMergeWaitMinutes": 5
+					}
+				}]
+			}
+		}`), &src)
+		assert.Nil(t, err)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRulset(&src)
+		assert.Equal(t, entity.RuleSetParameters{
+			MergeMethod:                  "rebase",
+			GroupingStrategy:             "headgreen",
+			CheckResponseTimeoutMinutes:  60,
+			MaxEntriesToBuild:            5,
+			MaxEntriesToMerge:            5,
+			MinEntriesToMerge:            1,
+			MinEntriesToMergeWaitMinutes: 5,
+		}, ruleset.Rules["merge_queue"])
+	})
+}
+
+func TestRemoteRequiredDeploymentsRuleset(t *testing.T) {
+
+	t.Run("happy path: required deployments round trip", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+
+		var src GraphQLGithubRuleSet
+		err := json.Unmarshal([]byte(`{
+			"name": "release",
+			"enforcement": "ACTIVE",
+			"rules": {
+				"nodes": [{
+					"type": "REQUIRED_DEPLOYMENTS",
+					"parameters": {
+						"requiredDeploymentEnvironments": ["staging", "production"]
+					}
+				}]
+			}
+		}`), &src)
+		assert.Nil(t, err)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRulset(&src)
+		assert.Equal(t, []string{"staging", "production"}, ruleset.Rules["required_deployments"].RequiredDeploymentEnvironments)
+
+		payload := remoteImpl.prepareRuleset(ruleset)
+		rules := payload["rules"].([]map[string]interface{})
+		assert.Equal(t, 1, len(rules))
+		assert.Equal(t, "required_deployments", rules[0]["type"])
+		assert.Equal(t, map[string]interface{}{
+			"required_deployment_environments": []string{"staging", "production"},
+		}, rules[0]["parameters"])
+	})
+}
+
+func TestRemotePatternRuleset(t *testing.T) {
+
+	t.Run("happy path: commit and committer email patterns round trip", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+
+		var src GraphQLGithubRuleSet
+		err := json.Unmarshal([]byte(`{
+			"name": "conventions",
+			"enforcement": "ACTIVE",
+			"rules": {
+				"nodes": [{
+					"type": "COMMIT_MESSAGE_PATTERN",
+					"parameters": {
+						"name": "conventional commits",
+						"negate": false,
+						"operator": "REGEX",
+						"pattern": "^(feat|fix|chore)(\\(.+\\))?: "
+					}
+				},{
+					"type": "COMMITTER_EMAIL_PATTERN",
+					"parameters": {
+						"name": "corporate email",
+						"negate": false,
+						"operator": "ENDS_WITH",
+						"pattern": "@example.com"
+					}
+				}]
+			}
+		}`), &src)
+		assert.Nil(t, err)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRulset(&src)
+		assert.Equal(t, entity.RuleSetParameters{
+			Name:     "corporate email",
+			Operator: "ends_with",
+			Pattern:  "@example.com",
+		}, ruleset.Rules["committer_email_pattern"])
+		assert.Equal(t, "regex", ruleset.Rules["commit_message_pattern"].Operator)
+
+		payload := remoteImpl.prepareRuleset(ruleset)
+		rules := payload["rules"].([]map[string]interface{})
+		assert.Equal(t, 2, len(rules))
+		for _, rule := range rules {
+			if rule["type"] == "committer_email_pattern" {
+				assert.Equal(t, map[string]interface{}{
+					"name":     "corporate email",
+					"negate":   false,
+					"operator": "ends_with",
+					"pattern":  "@example.com",
+				}, rule["parameters"])
+			} else {
+				assert.Equal(t, "commit_message_pattern", rule["type"])
+			}
+		}
+	})
+}
+
+func TestRemoteRepositoryNameRuleset(t *testing.T) {
+
+	t.Run("happy path: org ruleset on ~ALL minus an excluded pattern round trip", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+
+		var src GraphQLGithubRuleSet
+		err := json.Unmarshal([]byte(`{
+			"name": "default",
+			"enforcement": "ACTIVE",
+			"conditions": {
+				"refName": {
+					"include": ["~DEFAULT_BRANCH"],
+					"exclude": []
+				},
+				"repositoryName": {
+					"include": ["~ALL"],
+					"exclude": ["sandbox-*"],
+					"protected": false
+				}
+			},
+			"rules": {
+				"nodes": [{
+					"type": "REQUIRED_SIGNATURES"
+				}]
+			}
+		}`), &src)
+		assert.Nil(t, err)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRulset(&src)
+		assert.Equal(t, []string{"~ALL"}, ruleset.RepositoryNameInclude)
+		assert.Equal(t, []string{"sandbox-*"}, ruleset.RepositoryNameExclude)
+		assert.Equal(t, 0, len(ruleset.Repositories))
+
+		payload := remoteImpl.prepareRuleset(ruleset)
+		conditions := payload["conditions"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"include":   []string{"~ALL"},
+			"exclude":   []string{"sandbox-*"},
+			"protected": false,
+		}, conditions["repository_name"])
+		// Github accepts only one repository condition
+		_, ok := conditions["repository_id"]
+		assert.False(t, ok)
+	})
+
+	t.Run("happy path: repository ids without name patterns", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1", Id: 42},
+		}
+
+		payload := remoteImpl.prepareRuleset(&GithubRuleSet{
+			Name:         "default",
+			Enforcement:  "active",
+			Rules:        map[string]entity.RuleSetParameters{},
+			Repositories: []string{"repo1"},
+		})
+		conditions := payload["conditions"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"repository_ids": []int{42},
+		}, conditions["repository_id"])
+		_, ok := conditions["repository_name"]
+		assert.False(t, ok)
+	})
+}
+
+func TestRemoteRepositoryPropertyRuleset(t *testing.T) {
+
+	t.Run("happy path: org ruleset on the repositories of a custom property round trip", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+
+		var src GraphQLGithubRuleSet
+		err := json.Unmarshal([]byte(`{
+			"name": "tier1",
+			"enforcement": "ACTIVE",
+			"conditions": {
+				"refName": {
+					"include": ["~DEFAULT_BRANCH"],
+					"exclude": []
+				},
+				"repositoryProperty": {
+					"include": [{"name": "tier", "propertyValues": ["1"]}],
+					"exclude": [{"name": "lifecycle", "propertyValues": ["sunset", "archived"]}]
+				}
+			},
+			"rules": {
+				"nodes": [{
+					"type": "REQUIRED_SIGNATURES"
+				}]
+			}
+		}`), &src)
+		assert.Nil(t, err)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRulset(&src)
+		assert.Equal(t, []entity.RuleSetRepositoryProperty{{Name: "tier", Values: []string{"1"}}}, ruleset.RepositoryPropertyInclude)
+		assert.Equal(t, []entity.RuleSetRepositoryProperty{{Name: "lifecycle", Values: []string{"sunset", "archived"}}}, ruleset.RepositoryPropertyExclude)
+		assert.Equal(t, 0, len(ruleset.Repositories))
+
+		payload := remoteImpl.prepareRuleset(ruleset)
+		conditions := payload["conditions"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"include": []map[string]interface{}{
+				{"name": "tier", "property_values": []string{"1"}, "source": "custom"},
+			},
+			"exclude": []map[string]interface{}{
+				{"name": "lifecycle", "property_values": []string{"sunset", "archived"}, "source": "custom"},
+			},
+		}, conditions["repository_property"])
+		// Github accepts only one repository condition
+		_, ok := conditions["repository_id"]
+		assert.False(t, ok)
+		_, ok = conditions["repository_name"]
+		assert.False(t, ok)
+	})
+}
+
+func TestRemoteCreateRepositoryFromTemplate(t *testing.T) {
+
+	t.Run("happy path: repository generated from the template", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/myorg/template/generate": []byte(`{"id":42,"node_id":"R_42"}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		remoteImpl.CreateRepository(context.TODO(), false, "repo1", "repo1", []string{}, []string{}, map[string]bool{"private": true, "allow_auto_merge": true}, "myorg/template", true)
+
+		assert.Equal(t, 42, remoteImpl.repositories["repo1"].Id)
+		assert.Equal(t, "R_42", remoteImpl.repositories["repo1"].RefId)
+		assert.Equal(t, remoteImpl.repositories["repo1"], remoteImpl.repositoriesByRefId["R_42"])
+	})
+}
+
+func TestRemoteWorkflowsRuleset(t *testing.T) {
+
+	t.Run("happy path: repository names resolved to ids in the payload", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"ci-workflows": {Name: "ci-workflows", Id: 42},
+		}
+
+		payload := remoteImpl.prepareRuleset(&GithubRuleSet{
+			Name:        "workflows",
+			Enforcement: "active",
+			Rules: map[string]entity.RuleSetParameters{
+				"workflows": {
+					RequiredWorkflows: []entity.RuleSetRequiredWorkflow{
+						{Repository: "ci-workflows", Path: ".github/workflows/security.yaml", Ref: "main"},
+					},
+				},
+			},
+		})
+
+		rules := payload["rules"].([]map[string]interface{})
+		assert.Equal(t, 1, len(rules))
+		assert.Equal(t, "workflows", rules[0]["type"])
+		assert.Equal(t, map[string]interface{}{
+			"workflows": []map[string]interface{}{
+				{"repository_id": 42, "path": ".github/workflows/security.yaml", "ref": "main"},
+			},
+		}, rules[0]["parameters"])
+	})
+
+	t.Run("happy path: repository ids resolved to names from graphql", func(t *testing.T) {
+		remoteImpl := NewGoliacRemoteImpl(&GitHubClientIsEnterpriseMock{})
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"ci-workflows": {Name: "ci-workflows", Id: 42},
+		}
+
+		var src GraphQLGithubRuleSet
+		err := json.Unmarshal([]byte(`{
+			"name": "workflows",
+			"enforcement": "ACTIVE",
+			"rules": {
+				"nodes": [{
+					"type": "WORKFLOWS",
+					"parameters": {
+						"workflows": [{"path": ".github/workflows/security.yaml", "ref": "main", "repositoryId": 42}]
+					}
+				}]
+			}
+		}`), &src)
+		assert.Nil(t, err)
+
+		ruleset := remoteImpl.fromGraphQLToGithubRulset(&src)
+		assert.Equal(t, []entity.RuleSetRequiredWorkflow{
+			{Repository: "ci-workflows", RepositoryID: 42, Path: ".github/workflows/security.yaml", Ref: "main"},
+		}, ruleset.Rules["workflows"].RequiredWorkflows)
+	})
+}
+
+/*
+ * GitHubClientTwoFactorMock returns one graphql page per call
+ */
+type GitHubClientTwoFactorMock struct {
+	GitHubClientIsEnterpriseMock
+	pages []string
+	calls int
+}
+
+func (g *GitHubClientTwoFactorMock) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
+	page := g.pages[g.calls]
+	g.calls++
+	return []byte(page), nil
+}
+
+func TestRemoteMembersWithoutTwoFactor(t *testing.T) {
+
+	t.Run("happy path: list the members without two-factor authentication", func(t *testing.T) {
+		client := GitHubClientTwoFactorMock{
+			pages: []string{
+				`{"data":{"organization":{"membersWithRole":{"edges":[{"node":{"login":"user3"},"hasTwoFactorEnabled":false},{"node":{"login":"user1"},"hasTwoFactorEnabled":true}],"pageInfo":{"hasNextPage":true,"endCursor":"cursor1"}}}}}`,
+				`{"data":{"organization":{"membersWithRole":{"edges":[{"node":{"login":"user2"},"hasTwoFactorEnabled":false}],"pageInfo":{"hasNextPage":false,"endCursor":"cursor2"}}}}}`,
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		members := remoteImpl.MembersWithoutTwoFactor(context.TODO())
+		assert.Equal(t, []string{"user2", "user3"}, members)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("not happy path: two-factor status not visible", func(t *testing.T) {
+		client := GitHubClientTwoFactorMock{
+			pages: []string{
+				`{"data":{"organization":{"membersWithRole":{"edges":[{"node":{"login":"user1"},"hasTwoFactorEnabled":null}],"pageInfo":{"hasNextPage":false,"endCursor":"cursor1"}}}}}`,
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		members := remoteImpl.MembersWithoutTwoFactor(context.TODO())
+		assert.Nil(t, members)
+	})
+}
+
+func TestRemoteVerifiedDomains(t *testing.T) {
+
+	t.Run("happy path: list the verified and approved domains", func(t *testing.T) {
+		client := GitHubClientTwoFactorMock{
+			pages: []string{
+				`{"data":{"organization":{"domains":{"nodes":[{"domain":"example.org","isVerified":true,"isApproved":false},{"domain":"pending.com","isVerified":false,"isApproved":false}],"pageInfo":{"hasNextPage":true,"endCursor":"cursor1"}}}}}`,
+				`{"data":{"organization":{"domains":{"nodes":[{"domain":"example.com","isVerified":false,"isApproved":true}],"pageInfo":{"hasNextPage":false,"endCursor":"cursor2"}}}}}`,
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		assert.Equal(t, []string{"example.com", "example.org"}, remoteImpl.VerifiedDomains(context.TODO()))
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("not happy path: domains not visible", func(t *testing.T) {
+		client := GitHubClientTwoFactorMock{
+			pages: []string{
+				`{"data":{"organization":null},"errors":[{"path":["organization","domains"],"message":"Resource not accessible by integration"}]}`,
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		assert.Nil(t, remoteImpl.VerifiedDomains(context.TODO()))
+	})
+}
+
+func TestRemoteOrgPushProtectionCustomLink(t *testing.T) {
+
+	t.Run("happy path: load the push protection custom link", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/orgs/" + config.Config.GithubAppOrganization: []byte(`{"members_can_create_pages":true,"secret_scanning_push_protection_custom_link_enabled":true,"secret_scanning_push_protection_custom_link":"https://wiki.example.com/secrets"}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		assert.Equal(t, "https://wiki.example.com/secrets", remoteImpl.OrgPushProtectionCustomLink(context.TODO()))
+		assert.Equal(t, map[string]bool{"members_can_create_pages": true}, remoteImpl.OrgSettings(context.TODO()))
+	})
+
+	t.Run("happy path: load the default repository permission", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/orgs/" + config.Config.GithubAppOrganization: []byte(`{"default_repository_permission":"read"}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		assert.Equal(t, "read", remoteImpl.OrgDefaultRepositoryPermission(context.TODO()))
+
+		remoteImpl.UpdateOrgDefaultRepositoryPermission(context.TODO(), false, "none")
+		assert.Equal(t, "none", remoteImpl.OrgDefaultRepositoryPermission(context.TODO()))
+	})
+
+	t.Run("happy path: a disabled custom link is empty", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/orgs/" + config.Config.GithubAppOrganization: []byte(`{"secret_scanning_push_protection_custom_link_enabled":false,"secret_scanning_push_protection_custom_link":"https://wiki.example.com/secrets"}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		assert.Equal(t, "", remoteImpl.OrgPushProtectionCustomLink(context.TODO()))
+
+		remoteImpl.UpdateOrgPushProtectionCustomLink(context.TODO(), false, "https://wiki.example.com/new")
+		assert.Equal(t, "https://wiki.example.com/new", remoteImpl.OrgPushProtectionCustomLink(context.TODO()))
+	})
+}
+
+func TestRemoteLabels(t *testing.T) {
+
+	t.Run("happy path: load labels", func(t *testing.T) {
+		client := GitHubClientTwoFactorMock{
+			pages: []string{
+				`{"data":{"repository":{"labels":{"nodes":[{"name":"bug","color":"d73a4a","description":"Something isn't working"}],"pageInfo":{"hasNextPage":true,"endCursor":"cursor1"}}}}}`,
+				`{"data":{"repository":{"labels":{"nodes":[{"name":"Triage","color":"ededed","description":""}],"pageInfo":{"hasNextPage":false,"endCursor":"cursor2"}}}}}`,
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.repositories = map[string]*GithubRepository{
+			"repo1": {Name: "repo1"},
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		labels := remoteImpl.RepositoriesLabels(context.TODO())
+		assert.Equal(t, 1, len(labels))
+		assert.Equal(t, 2, len(labels["repo1"]))
+		assert.Equal(t, "Something isn't working", *labels["repo1"]["bug"].Description)
+		// indexed by the name in lower case
+		assert.Equal(t, "Triage", labels["repo1"]["triage"].Name)
+		assert.Equal(t, "", *labels["repo1"]["triage"].Description)
+		assert.Equal(t, 2, client.calls)
+	})
+
+	t.Run("happy path: load labels concurrently", func(t *testing.T) {
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		config.Config.GithubConcurrentThreads = 4
+
+		client := githubtest.NewRecordingClient().
+			ReplyGraphQL("listRepositoryLabels", `{"data":{"repository":{"labels":{"nodes":[{"name":"bug","color":"d73a4a","description":""}],"pageInfo":{"hasNextPage":false,"endCursor":""}}}}}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+		remoteImpl.repositories = map[string]*GithubRepository{}
+		for i := 0; i < 10; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			remoteImpl.repositories[reponame] = &GithubRepository{Name: reponame}
+		}
+		remoteImpl.ttlExpireRepositories = time.Now().Add(time.Hour)
+
+		labels, err := remoteImpl.loadRepositoriesLabels(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 10, len(labels))
+		assert.Equal(t, "bug", labels["repo9"]["bug"].Name)
+	})
+
+	t.Run("happy path: the label name is escaped in the endpoint", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("PATCH", "/repos/"+org+"/repo1/labels/good%20first%20issue%3F", `{}`).
+			ReplyRest("DELETE", "/repos/"+org+"/repo1/labels/ci%2Fcd", ``)
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		remoteImpl.UpdateRepositoryLabel(context.TODO(), false, "repo1", "good first issue?", &GithubLabel{Name: "good first issue?", Color: "7057ff"})
+		remoteImpl.DeleteRepositoryLabel(context.TODO(), false, "repo1", "ci/cd")
+
+		assert.Equal(t, 1, len(client.RequestsTo("PATCH", "/repos/"+org+"/repo1/labels/good%20first%20issue%3F")))
+		assert.Equal(t, 1, len(client.RequestsTo("DELETE", "/repos/"+org+"/repo1/labels/ci%2Fcd")))
+	})
+
+	t.Run("happy path: update a label keeps the description if not managed", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		description := "Something isn't working"
+		remoteImpl.labels = map[string]map[string]*GithubLabel{
+			"repo1": {"bug": {Name: "bug", Color: "d73a4a", Description: &description}},
+		}
+
+		remoteImpl.UpdateRepositoryLabel(context.TODO(), false, "repo1", "bug", &GithubLabel{Name: "Bug", Color: "ff0000"})
+		assert.Equal(t, &GithubLabel{Name: "Bug", Color: "ff0000", Description: &description}, remoteImpl.labels["repo1"]["bug"])
+	})
+
+	t.Run("happy path: the payload renames a label only if needed", func(t *testing.T) {
+		description := "a bug"
+		assert.Equal(t, map[string]interface{}{"color": "d73a4a", "description": "a bug"}, labelPayload(&GithubLabel{Name: "bug", Color: "d73a4a", Description: &description}))
+		assert.Equal(t, map[string]interface{}{"color": "d73a4a"}, labelPayload(&GithubLabel{Name: "bug", Color: "d73a4a"}))
+	})
+
+	t.Run("happy path: delete a label", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+		remoteImpl.labels = map[string]map[string]*GithubLabel{
+			"repo1": {"bug": {Name: "bug", Color: "d73a4a"}},
+		}
+
+		remoteImpl.DeleteRepositoryLabel(context.TODO(), false, "repo1", "Bug")
+		assert.Equal(t, 0, len(remoteImpl.labels["repo1"]))
+	})
+
+	t.Run("not happy path: error when creating a label", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("an error occured"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		remoteImpl.CreateRepositoryLabel(context.TODO(), false, "repo1", &GithubLabel{Name: "bug", Color: "d73a4a"})
+		assert.Equal(t, 0, len(remoteImpl.labels["repo1"]))
+	})
+}
+
+func TestRemoteTeamsExternalGroups(t *testing.T) {
+
+	t.Run("happy path: load the teams external groups", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("GET", "/orgs/"+org+"/teams/team1/team-sync/group-mappings", `{"groups":[{"group_id":"2","group_name":"okta-b","group_description":""},{"group_id":"1","group_name":"okta-a","group_description":""}]}`).
+			ReplyRest("GET", "/orgs/"+org+"/teams/team2/team-sync/group-mappings", `{"groups":[]}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+		remoteImpl.teams = map[string]*GithubTeam{
+			"team1": {Name: "team1", Slug: "team1"},
+			"team2": {Name: "team2", Slug: "team2"},
+			"team1" + config.Config.GoliacTeamOwnerSuffix: {Name: "team1" + config.Config.GoliacTeamOwnerSuffix, Slug: "team1" + config.Config.GoliacTeamOwnerSuffix},
+		}
+		remoteImpl.ttlExpireTeams = time.Now().Add(time.Hour)
+
+		defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+		config.Config.GithubConcurrentThreads = 4
+
+		assert.Equal(t, map[string][]string{
+			"team1": {"okta-a", "okta-b"},
+			"team2": {},
+		}, remoteImpl.TeamsExternalGroups(context.TODO()))
+		// the owners teams are not synchronized with IdP groups
+		assert.Equal(t, 0, len(client.RequestsTo("GET", "/orgs/"+org+"/teams/team1"+config.Config.GoliacTeamOwnerSuffix+"/team-sync/group-mappings")))
+	})
+
+	t.Run("not happy path: the external groups are not loaded on error", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		logHook := logrustest.NewGlobal()
+		defer logHook.Reset()
+		client := githubtest.NewRecordingClient().
+			ReplyRestError("GET", "/orgs/"+org+"/teams/team1/team-sync/group-mappings", fmt.Errorf("403 Forbidden"), `{"message":"team synchronization is not enabled"}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+		remoteImpl.teams = map[string]*GithubTeam{
+			"team1": {Name: "team1", Slug: "team1"},
+		}
+		remoteImpl.ttlExpireTeams = time.Now().Add(time.Hour)
+
+		assert.Equal(t, 0, len(remoteImpl.TeamsExternalGroups(context.TODO())))
+		assert.Equal(t, logrus.WarnLevel, logHook.LastEntry().Level)
+	})
+
+	t.Run("happy path: set the external groups of a team", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("GET", "/orgs/"+org+"/team-sync/groups?per_page=100&q=okta-a", `{"groups":[{"group_id":"11","group_name":"okta-a-admins","group_description":""},{"group_id":"1","group_name":"okta-a","group_description":"the A team"}]}`).
+			ReplyRest("PATCH", "/orgs/"+org+"/teams/team1/team-sync/group-mappings", `{"groups":[]}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		remoteImpl.UpdateTeamSetExternalGroups(context.TODO(), false, "team1", []string{"okta-a"})
+
+		patches := client.RequestsTo("PATCH", "/orgs/"+org+"/teams/team1/team-sync/group-mappings")
+		assert.Equal(t, 1, len(patches))
+		assert.Equal(t, map[string]interface{}{"groups": []map[string]interface{}{
+			{"group_id": "1", "group_name": "okta-a", "group_description": "the A team"},
+		}}, patches[0].Body)
+		assert.Equal(t, []string{"okta-a"}, remoteImpl.externalGroups["team1"])
+	})
+
+	t.Run("not happy path: unknown external group", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("GET", "/orgs/"+org+"/team-sync/groups?per_page=100&q=unknown", `{"groups":[]}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		remoteImpl.UpdateTeamSetExternalGroups(context.TODO(), false, "team1", []string{"unknown"})
+
+		assert.Equal(t, 0, len(client.RequestsTo("PATCH", "/orgs/"+org+"/teams/team1/team-sync/group-mappings")))
+		assert.Nil(t, remoteImpl.externalGroups["team1"])
+	})
+}
+
+func TestRemoteTransferRepository(t *testing.T) {
+
+	t.Run("happy path: wait for the transferred repository", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("POST", "/repos/someuser/repo1/transfer", `{"name":"repo1"}`).
+			// the transfer is asynchronous
+			ReplyRestError("GET", "/repos/"+org+"/newrepo", fmt.Errorf("404 not found"), `{"message":"Not Found"}`).
+			ReplyRest("GET", "/repos/"+org+"/newrepo", `{"id":42,"node_id":"R_42","private":true,"allow_squash_merge":true,"description":"a repo"}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+		remoteImpl.transferPollDelay = time.Millisecond
+
+		remoteImpl.TransferRepository(context.TODO(), false, "newrepo", "someuser/repo1")
+
+		transfers := client.RequestsTo("POST", "/repos/someuser/repo1/transfer")
+		assert.Equal(t, 1, len(transfers))
+		assert.Equal(t, map[string]interface{}{"new_owner": org, "new_name": "newrepo"}, transfers[0].Body)
+		assert.Equal(t, 2, len(client.RequestsTo("GET", "/repos/"+org+"/newrepo")))
+
+		repo := remoteImpl.repositories["newrepo"]
+		assert.Equal(t, 42, repo.Id)
+		assert.Equal(t, "R_42", repo.RefId)
+		assert.True(t, repo.BoolProperties["private"])
+		assert.True(t, repo.BoolProperties["allow_squash_merge"])
+		assert.Equal(t, "a repo", repo.StringProperties["description"])
+	})
+
+	t.Run("not happy path: the transferred repository is never available", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("POST", "/repos/someuser/repo1/transfer", `{"name":"repo1"}`).
+			ReplyRestError("GET", "/repos/"+org+"/newrepo", fmt.Errorf("404 not found"), `{"message":"Not Found"}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+		remoteImpl.transferPollDelay = time.Millisecond
+
+		remoteImpl.TransferRepository(context.TODO(), false, "newrepo", "someuser/repo1")
+
+		assert.Equal(t, TRANSFER_MAX_ATTEMPTS, len(client.RequestsTo("GET", "/repos/"+org+"/newrepo")))
+		_, ok := remoteImpl.repositories["newrepo"]
+		assert.False(t, ok)
+	})
+
+	t.Run("not happy path: stop waiting when the context is cancelled", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRest("POST", "/repos/someuser/repo1/transfer", `{"name":"repo1"}`).
+			ReplyRestError("GET", "/repos/"+org+"/newrepo", fmt.Errorf("404 not found"), `{"message":"Not Found"}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+		remoteImpl.transferPollDelay = time.Hour
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+		done := make(chan bool)
+		go func() {
+			remoteImpl.TransferRepository(ctx, false, "newrepo", "someuser/repo1")
+			done <- true
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("TransferRepository did not return after the context was cancelled")
+		}
+		assert.Equal(t, 1, len(client.RequestsTo("GET", "/repos/"+org+"/newrepo")))
+		_, ok := remoteImpl.repositories["newrepo"]
+		assert.False(t, ok)
+	})
+
+	t.Run("happy path: nothing is sent in dryrun", func(t *testing.T) {
+		client := githubtest.NewRecordingClient()
+		remoteImpl := NewGoliacRemoteImpl(client)
+		nbRequests := len(client.Requests())
+
+		remoteImpl.TransferRepository(context.TODO(), true, "newrepo", "someuser/repo1")
+
+		assert.Equal(t, nbRequests, len(client.Requests()))
+		_, ok := remoteImpl.repositories["newrepo"]
+		assert.True(t, ok)
+	})
+}
+
+func TestRemoteCustomRepositoryRoles(t *testing.T) {
+
+	t.Run("happy path: load custom repository roles", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/orgs/" + config.Config.GithubAppOrganization + "/custom-repository-roles": []byte(`{"total_count":1,"custom_roles":[{"id":1,"name":"security-reviewer","base_role":"read"}]}`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		roles := remoteImpl.CustomRepositoryRoles(context.TODO())
+		assert.Equal(t, map[string]string{"security-reviewer": "read"}, roles)
+	})
+
+	t.Run("happy path: the custom role of a team is loaded", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{
+				"/repos/" + config.Config.GithubAppOrganization + "/repo1/teams": []byte(`[{"slug":"team1","permission":"push","role_name":"write"},{"slug":"team2","permission":"pull","role_name":"security-reviewer"}]`),
+			},
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		teams, err := remoteImpl.loadTeamRepos(context.TODO(), "repo1")
+		assert.Nil(t, err)
+		assert.Equal(t, "", teams["team1"].RoleName)
+		assert.Equal(t, "WRITE", teams["team1"].Permission)
+		assert.Equal(t, "security-reviewer", teams["team2"].RoleName)
+	})
+
+	t.Run("not happy path: custom repository roles not available", func(t *testing.T) {
+		client := GitHubClientIsEnterpriseMock{
+			results: map[string][]byte{},
+			err:     fmt.Errorf("404 not found"),
+		}
+		remoteImpl := NewGoliacRemoteImpl(&client)
+
+		roles := remoteImpl.CustomRepositoryRoles(context.TODO())
+		assert.Equal(t, 0, len(roles))
+	})
+}
+
+func BenchmarkLoadRepositories(b *testing.B) {
+	defer func(threads int64) { config.Config.GithubConcurrentThreads = threads }(config.Config.GithubConcurrentThreads)
+	client := MockGithubClientRepositoriesPages{nbRepos: 2000, latency: 5 * time.Millisecond}
+	remoteImpl := NewGoliacRemoteImpl(&client)
+
+	for _, threads := range []int64{1, 4} {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			config.Config.GithubConcurrentThreads = threads
+			for i := 0; i < b.N; i++ {
+				if _, _, err := remoteImpl.loadRepositories(context.TODO()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteLoadOrgSecrets(t *testing.T) {
+
+	t.Run("happy path: load all the pages of org secrets and of their repositories", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		page1 := []string{}
+		for i := 0; i < 100; i++ {
+			page1 = append(page1, fmt.Sprintf(`{"name":"SECRET%d","visibility":"all"}`, i))
+		}
+		repos1 := []string{}
+		for i := 0; i < 100; i++ {
+			repos1 = append(repos1, fmt.Sprintf(`{"id":%d,"name":"repo%d"}`, i, i))
+		}
+		client := githubtest.NewRecordingClient().
+			ReplyRest("GET", "/orgs/"+org+"/actions/secrets?per_page=100&page=1", `{"total_count":101,"secrets":[`+strings.Join(page1, ",")+`]}`).
+			ReplyRest("GET", "/orgs/"+org+"/actions/secrets?per_page=100&page=2", `{"total_count":101,"secrets":[{"name":"SECRET100","visibility":"selected"}]}`).
+			ReplyRest("GET", "/orgs/"+org+"/actions/secrets/SECRET100/repositories?per_page=100&page=1", `{"total_count":101,"repositories":[`+strings.Join(repos1, ",")+`]}`).
+			ReplyRest("GET", "/orgs/"+org+"/actions/secrets/SECRET100/repositories?per_page=100&page=2", `{"total_count":101,"repositories":[{"id":100,"name":"repo100"}]}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		secrets, err := remoteImpl.loadOrgSecrets(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 101, len(secrets))
+		assert.Equal(t, 101, len(secrets["SECRET100"].SelectedRepositories))
+		assert.Equal(t, "repo100", secrets["SECRET100"].SelectedRepositories[100])
+	})
+
+	t.Run("not happy path: the org secrets are nil if not loaded", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRestError("GET", "/orgs/"+org+"/actions/secrets?per_page=100&page=1", fmt.Errorf("forbidden"), "")
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		assert.Nil(t, remoteImpl.OrgSecrets(context.TODO()))
+	})
+}
+
+func TestRemoteLoadOrgVariables(t *testing.T) {
+
+	t.Run("happy path: load all the pages of org variables", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		page1 := []string{}
+		for i := 0; i < 100; i++ {
+			page1 = append(page1, fmt.Sprintf(`{"name":"VAR%d","value":"value","visibility":"all"}`, i))
+		}
+		client := githubtest.NewRecordingClient().
+			ReplyRest("GET", "/orgs/"+org+"/actions/variables?per_page=100&page=1", `{"total_count":101,"variables":[`+strings.Join(page1, ",")+`]}`).
+			ReplyRest("GET", "/orgs/"+org+"/actions/variables?per_page=100&page=2", `{"total_count":101,"variables":[{"name":"VAR100","value":"value","visibility":"selected"}]}`).
+			ReplyRest("GET", "/orgs/"+org+"/actions/variables/VAR100/repositories?per_page=100&page=1", `{"total_count":1,"repositories":[{"id":1,"name":"repo1"}]}`)
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		variables, err := remoteImpl.loadOrgVariables(context.TODO())
+		assert.Nil(t, err)
+		assert.Equal(t, 101, len(variables))
+		assert.Equal(t, []string{"repo1"}, variables["VAR100"].SelectedRepositories)
+		assert.Equal(t, 0, len(client.RequestsTo("GET", "/orgs/"+org+"/actions/variables?per_page=100&page=3")))
+	})
+
+	t.Run("not happy path: the org variables are nil if not loaded", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := githubtest.NewRecordingClient().
+			ReplyRestError("GET", "/orgs/"+org+"/actions/variables?per_page=100&page=1", fmt.Errorf("forbidden"), "")
+		remoteImpl := NewGoliacRemoteImpl(client)
+
+		assert.Nil(t, remoteImpl.OrgVariables(context.TODO()))
+	})
+}
@@ -0,0 +1,221 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Alayacare/goliac/internal/entity"
+)
+
+/*
+ * RulesetRuleChange is one line of a ruleset rules diff
+ */
+type RulesetRuleChange struct {
+	RuleType  string
+	Parameter string // empty if the rule itself is added or removed
+	Old       string
+	New       string
+}
+
+/*
+ * rulesetParameterValues returns the parameters (as displayable strings)
+ * that are relevant for a given rule type
+ */
+func rulesetParameterValues(ruletype string, p entity.RuleSetParameters) map[string]string {
+	switch ruletype {
+	case "pull_request":
+		return map[string]string{
+			"dismiss_stale_reviews_on_push":     fmt.Sprintf("%v", p.DismissStaleReviewsOnPush),
+			"require_code_owner_review":         fmt.Sprintf("%v", p.RequireCodeOwnerReview),
+			"required_approving_review_count":   fmt.Sprintf("%d", p.RequiredApprovingReviewCount),
+			"required_review_thread_resolution": fmt.Sprintf("%v", p.RequiredReviewThreadResolution),
+			"require_last_push_approval":        fmt.Sprintf("%v", p.RequireLastPushApproval),
+		}
+	case "required_status_checks":
+		checks := append([]string{}, p.RequiredStatusChecks...)
+		sort.Strings(checks)
+		return map[string]string{
+			"required_status_checks":               strings.Join(checks, ","),
+			"strict_required_status_checks_policy": fmt.Sprintf("%v", p.StrictRequiredStatusChecksPolicy),
+		}
+	case "commit_message_pattern", "commit_author_email_pattern", "committer_email_pattern":
+		return map[string]string{
+			"name":     p.Name,
+			"negate":   fmt.Sprintf("%v", p.Negate),
+			"operator": p.Operator,
+			"pattern":  p.Pattern,
+		}
+	case "merge_queue":
+		// the unset parameters are compared with their Github default values
+		p = entity.MergeQueueParameters(p)
+		return map[string]string{
+			"merge_method":                      p.MergeMethod,
+			"grouping_strategy":                 p.GroupingStrategy,
+			"check_response_timeout_minutes":    fmt.Sprintf("%d", p.CheckResponseTimeoutMinutes),
+			"max_entries_to_build":              fmt.Sprintf("%d", p.MaxEntriesToBuild),
+			"max_entries_to_merge":              fmt.Sprintf("%d", p.MaxEntriesToMerge),
+			"min_entries_to_merge":              fmt.Sprintf("%d", p.MinEntriesToMerge),
+			"min_entries_to_merge_wait_minutes": fmt.Sprintf("%d", p.MinEntriesToMergeWaitMinutes),
+		}
+	case "workflows":
+		workflows := make([]string, 0, len(p.RequiredWorkflows))
+		for _, w := range p.RequiredWorkflows {
+			workflows = append(workflows, fmt.Sprintf("%s:%s@%s", w.Repository, w.Path, w.Ref))
+		}
+		sort.Strings(workflows)
+		return map[string]string{
+			"workflows": strings.Join(workflows, ","),
+		}
+	case "required_deployments":
+		environments := append([]string{}, p.RequiredDeploymentEnvironments...)
+		sort.Strings(environments)
+		return map[string]string{
+			"required_deployment_environments": strings.Join(environments, ","),
+		}
+	}
+	return map[string]string{}
+}
+
+/*
+ * RulesetRulesDiff returns the list of changes (sorted by rule type and parameter)
+ * needed to go from the oldRules to the newRules
+ */
+func RulesetRulesDiff(oldRules map[string]entity.RuleSetParameters, newRules map[string]entity.RuleSetParameters) []RulesetRuleChange {
+	changes := []RulesetRuleChange{}
+
+	ruletypes := map[string]bool{}
+	for k := range oldRules {
+		ruletypes[k] = true
+	}
+	for k := range newRules {
+		ruletypes[k] = true
+	}
+
+	for ruletype := range ruletypes {
+		oldParams, oldExists := oldRules[ruletype]
+		newParams, newExists := newRules[ruletype]
+
+		if !oldExists {
+			changes = append(changes, RulesetRuleChange{RuleType: ruletype, Old: "absent", New: "present"})
+			continue
+		}
+		if !newExists {
+			changes = append(changes, RulesetRuleChange{RuleType: ruletype, Old: "present", New: "absent"})
+			continue
+		}
+
+		oldValues := rulesetParameterValues(ruletype, oldParams)
+		newValues := rulesetParameterValues(ruletype, newParams)
+		for param, nv := range newValues {
+			if ov := oldValues[param]; ov != nv {
+				changes = append(changes, RulesetRuleChange{RuleType: ruletype, Parameter: param, Old: ov, New: nv})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].RuleType != changes[j].RuleType {
+			return changes[i].RuleType < changes[j].RuleType
+		}
+		return changes[i].Parameter < changes[j].Parameter
+	})
+
+	return changes
+}
+
+/*
+ * RenderRulesetRulesDiff renders a ruleset rules diff as a readable table
+ */
+func RenderRulesetRulesDiff(changes []RulesetRuleChange) string {
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE TYPE\tPARAMETER\tOLD\tNEW")
+	for _, c := range changes {
+		param := c.Parameter
+		if param == "" {
+			param = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.RuleType, param, c.Old, c.New)
+	}
+	w.Flush()
+	return buf.String()
+}
+
+/*
+ * repositoryPropertyConditions returns the custom property conditions as
+ * comparable strings, like "tier=1|2" (the order of the values is not relevant)
+ */
+func repositoryPropertyConditions(properties []entity.RuleSetRepositoryProperty) []string {
+	conditions := make([]string, 0, len(properties))
+	for _, p := range properties {
+		values := append([]string{}, p.Values...)
+		sort.Strings(values)
+		conditions = append(conditions, p.Name+"="+strings.Join(values, "|"))
+	}
+	return conditions
+}
+
+/*
+ * RulesetFieldsDiff returns the ruleset fields (other than the rules) that
+ * differ between the oldRuleset and the newRuleset, like
+ * "enforcement: evaluate -> active". The order of the lists is not relevant.
+ */
+func RulesetFieldsDiff(oldRuleset *GithubRuleSet, newRuleset *GithubRuleSet) []string {
+	changes := []string{}
+
+	if oldRuleset.Enforcement != newRuleset.Enforcement {
+		changes = append(changes, fmt.Sprintf("enforcement: %s -> %s", oldRuleset.Enforcement, newRuleset.Enforcement))
+	}
+
+	apps := map[string]bool{}
+	for k := range oldRuleset.BypassApps {
+		apps[k] = true
+	}
+	for k := range newRuleset.BypassApps {
+		apps[k] = true
+	}
+	appnames := make([]string, 0, len(apps))
+	for k := range apps {
+		appnames = append(appnames, k)
+	}
+	sort.Strings(appnames)
+	for _, appname := range appnames {
+		oldMode, ok := oldRuleset.BypassApps[appname]
+		if !ok {
+			oldMode = "absent"
+		}
+		newMode, ok := newRuleset.BypassApps[appname]
+		if !ok {
+			newMode = "absent"
+		}
+		if oldMode != newMode {
+			changes = append(changes, fmt.Sprintf("bypassApps.%s: %s -> %s", appname, oldMode, newMode))
+		}
+	}
+
+	lists := []struct {
+		field    string
+		old, new []string
+	}{
+		{"on.include", oldRuleset.OnInclude, newRuleset.OnInclude},
+		{"on.exclude", oldRuleset.OnExclude, newRuleset.OnExclude},
+		{"repositories", oldRuleset.Repositories, newRuleset.Repositories},
+		{"repositories.include", oldRuleset.RepositoryNameInclude, newRuleset.RepositoryNameInclude},
+		{"repositories.exclude", oldRuleset.RepositoryNameExclude, newRuleset.RepositoryNameExclude},
+		{"repositories.properties.include", repositoryPropertyConditions(oldRuleset.RepositoryPropertyInclude), repositoryPropertyConditions(newRuleset.RepositoryPropertyInclude)},
+		{"repositories.properties.exclude", repositoryPropertyConditions(oldRuleset.RepositoryPropertyExclude), repositoryPropertyConditions(newRuleset.RepositoryPropertyExclude)},
+	}
+	for _, l := range lists {
+		// the "left only" elements are the ones only in the second array
+		if res, added, removed := entity.StringArrayEquivalent(l.old, l.new); !res {
+			sort.Strings(removed)
+			sort.Strings(added)
+			changes = append(changes, fmt.Sprintf("%s: -[%s] +[%s]", l.field, strings.Join(removed, ","), strings.Join(added, ",")))
+		}
+	}
+
+	return changes
+}
@@ -0,0 +1,123 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRulesetRulesDiff(t *testing.T) {
+
+	t.Run("happy path: required_approving_review_count changed", func(t *testing.T) {
+		oldRules := map[string]entity.RuleSetParameters{
+			"pull_request": {RequiredApprovingReviewCount: 1},
+		}
+		newRules := map[string]entity.RuleSetParameters{
+			"pull_request": {RequiredApprovingReviewCount: 2},
+		}
+
+		changes := RulesetRulesDiff(oldRules, newRules)
+		assert.Equal(t, []RulesetRuleChange{
+			{RuleType: "pull_request", Parameter: "required_approving_review_count", Old: "1", New: "2"},
+		}, changes)
+
+		table := RenderRulesetRulesDiff(changes)
+		lines := strings.Split(strings.TrimSpace(table), "\n")
+		assert.Equal(t, 2, len(lines))
+		assert.Equal(t, []string{"RULE", "TYPE", "PARAMETER", "OLD", "NEW"}, strings.Fields(lines[0]))
+		assert.Equal(t, []string{"pull_request", "required_approving_review_count", "1", "2"}, strings.Fields(lines[1]))
+	})
+
+	t.Run("happy path: rule added and removed", func(t *testing.T) {
+		oldRules := map[string]entity.RuleSetParameters{
+			"required_signatures": {},
+		}
+		newRules := map[string]entity.RuleSetParameters{
+			"required_status_checks": {RequiredStatusChecks: []string{"ci"}},
+		}
+
+		changes := RulesetRulesDiff(oldRules, newRules)
+		assert.Equal(t, []RulesetRuleChange{
+			{RuleType: "required_signatures", Old: "present", New: "absent"},
+			{RuleType: "required_status_checks", Old: "absent", New: "present"},
+		}, changes)
+	})
+
+	t.Run("happy path: pattern, merge queue, workflows and deployments parameters changed", func(t *testing.T) {
+		oldRules := map[string]entity.RuleSetParameters{
+			"commit_message_pattern": {Operator: "starts_with", Pattern: "feat"},
+			"merge_queue":            {},
+			"workflows": {RequiredWorkflows: []entity.RuleSetRequiredWorkflow{
+				{Repository: "ci", Path: ".github/workflows/ci.yaml"},
+			}},
+			"required_deployments": {RequiredDeploymentEnvironments: []string{"staging"}},
+		}
+		newRules := map[string]entity.RuleSetParameters{
+			"commit_message_pattern": {Operator: "regex", Pattern: "feat"},
+			"merge_queue":            {MergeMethod: "squash", CheckResponseTimeoutMinutes: 60},
+			"workflows": {RequiredWorkflows: []entity.RuleSetRequiredWorkflow{
+				{Repository: "ci", Path: ".github/workflows/ci.yaml", Ref: "main"},
+			}},
+			"required_deployments": {RequiredDeploymentEnvironments: []string{"production", "staging"}},
+		}
+
+		changes := RulesetRulesDiff(oldRules, newRules)
+		assert.Equal(t, []RulesetRuleChange{
+			{RuleType: "commit_message_pattern", Parameter: "operator", Old: "starts_with", New: "regex"},
+			{RuleType: "merge_queue", Parameter: "merge_method", Old: "merge", New: "squash"},
+			{RuleType: "required_deployments", Parameter: "required_deployment_environments", Old: "staging", New: "production,staging"},
+			{RuleType: "workflows", Parameter: "workflows", Old: "ci:.github/workflows/ci.yaml@", New: "ci:.github/workflows/ci.yaml@main"},
+		}, changes)
+	})
+
+	t.Run("happy path: no changes", func(t *testing.T) {
+		rules := map[string]entity.RuleSetParameters{
+			"pull_request": {RequiredApprovingReviewCount: 1},
+		}
+
+		changes := RulesetRulesDiff(rules, rules)
+		assert.Equal(t, 0, len(changes))
+	})
+}
+
+func TestRulesetFieldsDiff(t *testing.T) {
+
+	t.Run("happy path: enforcement, bypass apps and branches changed", func(t *testing.T) {
+		oldRuleset := &GithubRuleSet{
+			Enforcement:  "evaluate",
+			BypassApps:   map[string]string{"app1": "always"},
+			OnInclude:    []string{"~DEFAULT_BRANCH"},
+			Repositories: []string{"repo1", "repo2"},
+		}
+		newRuleset := &GithubRuleSet{
+			Enforcement:  "active",
+			BypassApps:   map[string]string{"app1": "pull_request", "app2": "always"},
+			OnInclude:    []string{"~DEFAULT_BRANCH"},
+			Repositories: []string{"repo3", "repo2"},
+		}
+
+		assert.Equal(t, []string{
+			"enforcement: evaluate -> active",
+			"bypassApps.app1: always -> pull_request",
+			"bypassApps.app2: absent -> always",
+			"repositories: -[repo1] +[repo3]",
+		}, RulesetFieldsDiff(oldRuleset, newRuleset))
+	})
+
+	t.Run("happy path: the order of the lists doesn't matter", func(t *testing.T) {
+		oldRuleset := &GithubRuleSet{
+			Enforcement: "active",
+			OnInclude:   []string{"main", "~DEFAULT_BRANCH"},
+		}
+		newRuleset := &GithubRuleSet{
+			Enforcement: "active",
+			BypassApps:  map[string]string{},
+			OnInclude:   []string{"~DEFAULT_BRANCH", "main"},
+			OnExclude:   []string{},
+		}
+
+		assert.Equal(t, 0, len(RulesetFieldsDiff(oldRuleset, newRuleset)))
+	})
+}
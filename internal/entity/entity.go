@@ -32,3 +32,27 @@ func parseEntity(fs billy.Filesystem, filename string) (*Entity, error) {
 
 	return yamldata, nil
 }
+
+/*
+ * EntityError is an error about an entity (a repository, a team, a ruleset,
+ * a user, the org variables or the org secrets). The message is the one of
+ * the wrapped error
+ */
+type EntityError struct {
+	Kind     string // repository, team, ruleset, user, org variables, org secrets
+	Name     string
+	Filename string // empty if the error is not about a single file
+	Err      error
+}
+
+func (e *EntityError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EntityError) Unwrap() error {
+	return e.Err
+}
+
+func NewEntityError(kind string, name string, filename string, err error) error {
+	return &EntityError{Kind: kind, Name: name, Filename: filename, Err: err}
+}
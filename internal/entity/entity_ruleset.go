@@ -3,6 +3,7 @@ package entity
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
@@ -20,6 +21,74 @@ type RuleSetParameters struct {
 	// RequiredStatusChecksParameters
 	RequiredStatusChecks             []string `yaml:"requiredStatusChecks"`
 	StrictRequiredStatusChecksPolicy bool     `yaml:"strictRequiredStatusChecksPolicy"`
+
+	// CommitMessagePatternParameters, CommitAuthorEmailPatternParameters, CommitterEmailPatternParameters
+	Name     string `yaml:"name"`
+	Negate   bool   `yaml:"negate"`
+	Operator string `yaml:"operator"` // starts_with, ends_with, contains, regex
+	Pattern  string `yaml:"pattern"`
+
+	// MergeQueueParameters
+	MergeMethod                  string `yaml:"mergeMethod"`      // merge, squash, rebase
+	GroupingStrategy             string `yaml:"groupingStrategy"` // allgreen, headgreen
+	CheckResponseTimeoutMinutes  int    `yaml:"checkResponseTimeoutMinutes"`
+	MaxEntriesToBuild            int    `yaml:"maxEntriesToBuild"`
+	MaxEntriesToMerge            int    `yaml:"maxEntriesToMerge"`
+	MinEntriesToMerge            int    `yaml:"minEntriesToMerge"`
+	MinEntriesToMergeWaitMinutes int    `yaml:"minEntriesToMergeWaitMinutes"`
+
+	// WorkflowsParameters
+	RequiredWorkflows []RuleSetRequiredWorkflow `yaml:"requiredWorkflows"`
+
+	// RequiredDeploymentsParameters
+	RequiredDeploymentEnvironments []string `yaml:"requiredDeploymentEnvironments"` // environments that must be successfully deployed to before merging
+}
+
+// a repository custom property condition, like tier in [1, 2]
+type RuleSetRepositoryProperty struct {
+	Name   string   `yaml:"name"`   // custom property defined at the organization level
+	Values []string `yaml:"values"` // the repository matches if its value is one of them
+}
+
+type RuleSetRequiredWorkflow struct {
+	Repository   string `yaml:"repository"` // name of a repository managed by Goliac
+	RepositoryID int    `yaml:"-"`          // resolved from the repository name
+	Path         string `yaml:"path"`       // like .github/workflows/ci.yaml
+	Ref          string `yaml:"ref"`        // branch or tag (default branch if empty)
+}
+
+// ruletypes using the pattern parameters (Name, Negate, Operator, Pattern)
+var patternRuletypes = map[string]bool{
+	"commit_message_pattern":      true,
+	"commit_author_email_pattern": true,
+	"committer_email_pattern":     true,
+}
+
+func IsPatternRuletype(ruletype string) bool {
+	return patternRuletypes[ruletype]
+}
+
+/*
+ * MergeQueueParameters returns the merge queue parameters with the Github
+ * default values for the ones not specified
+ */
+func MergeQueueParameters(p RuleSetParameters) RuleSetParameters {
+	if p.MergeMethod == "" {
+		p.MergeMethod = "merge"
+	}
+	if p.GroupingStrategy == "" {
+		p.GroupingStrategy = "allgreen"
+	}
+	if p.CheckResponseTimeoutMinutes == 0 {
+		p.CheckResponseTimeoutMinutes = 60
+	}
+	if p.MaxEntriesToBuild == 0 {
+		p.MaxEntriesToBuild = 5
+	}
+	if p.MaxEntriesToMerge == 0 {
+		p.MaxEntriesToMerge = 5
+	}
+	return p
 }
 
 func CompareRulesetParameters(ruletype string, left RuleSetParameters, right RuleSetParameters) bool {
@@ -51,6 +120,39 @@ func CompareRulesetParameters(ruletype string, left RuleSetParameters, right Rul
 			return false
 		}
 		return true
+	case "required_deployments":
+		res, _, _ := StringArrayEquivalent(left.RequiredDeploymentEnvironments, right.RequiredDeploymentEnvironments)
+		return res
+	case "commit_message_pattern", "commit_author_email_pattern", "committer_email_pattern":
+		return left.Name == right.Name &&
+			left.Negate == right.Negate &&
+			left.Operator == right.Operator &&
+			left.Pattern == right.Pattern
+	case "merge_queue":
+		left = MergeQueueParameters(left)
+		right = MergeQueueParameters(right)
+		return left.MergeMethod == right.MergeMethod &&
+			left.GroupingStrategy == right.GroupingStrategy &&
+			left.CheckResponseTimeoutMinutes == right.CheckResponseTimeoutMinutes &&
+			left.MaxEntriesToBuild == right.MaxEntriesToBuild &&
+			left.MaxEntriesToMerge == right.MaxEntriesToMerge &&
+			left.MinEntriesToMerge == right.MinEntriesToMerge &&
+			left.MinEntriesToMergeWaitMinutes == right.MinEntriesToMergeWaitMinutes
+	case "workflows":
+		// the repository ids are not compared, the local ones are unknown
+		if len(left.RequiredWorkflows) != len(right.RequiredWorkflows) {
+			return false
+		}
+		workflows := make(map[RuleSetRequiredWorkflow]bool)
+		for _, w := range left.RequiredWorkflows {
+			workflows[RuleSetRequiredWorkflow{Repository: w.Repository, Path: w.Path, Ref: w.Ref}] = true
+		}
+		for _, w := range right.RequiredWorkflows {
+			if !workflows[RuleSetRequiredWorkflow{Repository: w.Repository, Path: w.Path, Ref: w.Ref}] {
+				return false
+			}
+		}
+		return true
 	}
 	return false
 }
@@ -71,6 +173,18 @@ type RuleSet struct {
 			Include []string // ~DEFAULT_BRANCH, ~ALL, branch_name, ...
 			Exclude []string //  branch_name, ...
 		}
+		// if set, the ruleset targets the repositories by name (instead of
+		// the repositories matching the goliac.yaml pattern)
+		Repositories struct {
+			Include []string // ~ALL, fnmatch patterns, ...
+			Exclude []string // fnmatch patterns, ...
+			// if set, the ruleset targets the repositories by custom property
+			// values (instead of by name)
+			Properties struct {
+				Include []RuleSetRepositoryProperty
+				Exclude []RuleSetRepositoryProperty
+			} `yaml:"properties,omitempty"`
+		} `yaml:"repositories,omitempty"`
 
 		Rules []struct {
 			Ruletype   string // required_signatures, pull_request, required_status_checks...
@@ -133,15 +247,20 @@ func ReadRuleSetDirectory(fs billy.Filesystem, dirname string) (map[string]*Rule
 		if e.Name()[0] == '.' {
 			continue
 		}
-		ruleset, err := NewRuleSet(fs, filepath.Join(dirname, e.Name()))
+		filename := filepath.Join(dirname, e.Name())
+		ruleset, err := NewRuleSet(fs, filename)
 		if err != nil {
-			errors = append(errors, err)
+			errors = append(errors, NewEntityError("ruleset", strings.TrimSuffix(e.Name(), ".yaml"), filename, err))
 		} else {
-			err := ruleset.Validate(filepath.Join(dirname, e.Name()))
+			err := ruleset.Validate(filename)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, NewEntityError("ruleset", ruleset.Name, filename, err))
 			} else {
 				rulesets[ruleset.Name] = ruleset
+				// a disabled ruleset is created but inert
+				if ruleset.Spec.Enforcement == "disabled" || ruleset.Spec.Enforcement == "disable" {
+					warning = append(warning, fmt.Errorf("ruleset %s is disabled (ruleset filename %s): consider removing it", ruleset.Name, filepath.Join(dirname, e.Name())))
+				}
 			}
 
 		}
@@ -168,13 +287,75 @@ func (r *RuleSet) Validate(filename string) error {
 		return fmt.Errorf("invalid metadata.name: %s for ruleset filename %s", r.Name, filename)
 	}
 
+	if len(r.Spec.Repositories.Exclude) > 0 && len(r.Spec.Repositories.Include) == 0 {
+		return fmt.Errorf("invalid repositories: exclude without include for ruleset filename %s", filename)
+	}
+	properties := r.Spec.Repositories.Properties
+	if len(properties.Include) > 0 || len(properties.Exclude) > 0 {
+		// Github accepts only one repository condition
+		if len(r.Spec.Repositories.Include) > 0 {
+			return fmt.Errorf("invalid repositories: properties and include cannot be both set for ruleset filename %s", filename)
+		}
+		if len(properties.Include) == 0 {
+			return fmt.Errorf("invalid repositories: properties exclude without include for ruleset filename %s", filename)
+		}
+		for _, p := range append(append([]RuleSetRepositoryProperty{}, properties.Include...), properties.Exclude...) {
+			if p.Name == "" || len(p.Values) == 0 {
+				return fmt.Errorf("invalid repositories: the name and the values of a property must be set for ruleset filename %s", filename)
+			}
+		}
+	}
+
 	for _, rule := range r.Spec.Rules {
-		if rule.Ruletype != "required_signatures" && rule.Ruletype != "pull_request" && rule.Ruletype != "required_status_checks" {
+		if rule.Ruletype != "required_signatures" && rule.Ruletype != "pull_request" && rule.Ruletype != "required_status_checks" && rule.Ruletype != "merge_queue" && rule.Ruletype != "workflows" && rule.Ruletype != "required_deployments" && !IsPatternRuletype(rule.Ruletype) {
 			return fmt.Errorf("invalid rulettype: %s for ruleset filename %s", rule.Ruletype, filename)
 		}
+		if IsPatternRuletype(rule.Ruletype) {
+			switch rule.Parameters.Operator {
+			case "starts_with", "ends_with", "contains", "regex":
+			default:
+				return fmt.Errorf("invalid operator: %s for rule %s in ruleset filename %s", rule.Parameters.Operator, rule.Ruletype, filename)
+			}
+			if rule.Parameters.Pattern == "" {
+				return fmt.Errorf("pattern is empty for rule %s in ruleset filename %s", rule.Ruletype, filename)
+			}
+		}
+		if rule.Ruletype == "merge_queue" {
+			mergeQueue := MergeQueueParameters(rule.Parameters)
+			if mergeQueue.MergeMethod != "merge" && mergeQueue.MergeMethod != "squash" && mergeQueue.MergeMethod != "rebase" {
+				return fmt.Errorf("invalid mergeMethod: %s for rule %s in ruleset filename %s", rule.Parameters.MergeMethod, rule.Ruletype, filename)
+			}
+			if mergeQueue.GroupingStrategy != "allgreen" && mergeQueue.GroupingStrategy != "headgreen" {
+				return fmt.Errorf("invalid groupingStrategy: %s for rule %s in ruleset filename %s", rule.Parameters.GroupingStrategy, rule.Ruletype, filename)
+			}
+			if mergeQueue.MinEntriesToMerge > mergeQueue.MaxEntriesToMerge {
+				return fmt.Errorf("minEntriesToMerge (%d) is greater than maxEntriesToMerge (%d) for rule %s in ruleset filename %s", mergeQueue.MinEntriesToMerge, mergeQueue.MaxEntriesToMerge, rule.Ruletype, filename)
+			}
+		}
+		if rule.Ruletype == "required_deployments" {
+			if len(rule.Parameters.RequiredDeploymentEnvironments) == 0 {
+				return fmt.Errorf("requiredDeploymentEnvironments is empty for rule %s in ruleset filename %s", rule.Ruletype, filename)
+			}
+			for _, e := range rule.Parameters.RequiredDeploymentEnvironments {
+				if e == "" {
+					return fmt.Errorf("empty environment in requiredDeploymentEnvironments for rule %s in ruleset filename %s", rule.Ruletype, filename)
+				}
+			}
+		}
+		if rule.Ruletype == "workflows" {
+			if len(rule.Parameters.RequiredWorkflows) == 0 {
+				return fmt.Errorf("requiredWorkflows is empty for rule %s in ruleset filename %s", rule.Ruletype, filename)
+			}
+			for _, w := range rule.Parameters.RequiredWorkflows {
+				if w.Repository == "" || w.Path == "" {
+					return fmt.Errorf("the repository and the path of a required workflow must be set for rule %s in ruleset filename %s", rule.Ruletype, filename)
+				}
+			}
+		}
 	}
 
-	if r.Spec.Enforcement != "disable" && r.Spec.Enforcement != "active" && r.Spec.Enforcement != "evaluate" {
+	// "disable" is kept for backward compatibility (Github uses "disabled")
+	if r.Spec.Enforcement != "disable" && r.Spec.Enforcement != "disabled" && r.Spec.Enforcement != "active" && r.Spec.Enforcement != "evaluate" {
 		return fmt.Errorf("invalid enforcement: %s for ruleset filename %s", r.Spec.Enforcement, filename)
 	}
 
@@ -191,3 +372,21 @@ func (r *RuleSet) Validate(filename string) error {
 
 	return nil
 }
+
+/*
+ * ValidateRequiredWorkflows checks that the repositories of the required
+ * workflows are managed by Goliac (Github only knows them by id)
+ */
+func (r *RuleSet) ValidateRequiredWorkflows(repositories map[string]*Repository) error {
+	for _, rule := range r.Spec.Rules {
+		if rule.Ruletype != "workflows" {
+			continue
+		}
+		for _, w := range rule.Parameters.RequiredWorkflows {
+			if _, ok := repositories[w.Repository]; !ok {
+				return fmt.Errorf("invalid required workflow %s: the repository %s doesn't exist (check ruleset %s)", w.Path, w.Repository, r.Name)
+			}
+		}
+	}
+	return nil
+}
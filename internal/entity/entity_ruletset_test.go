@@ -94,3 +94,453 @@ func TestRulesetParametersComparison(t *testing.T) {
 		assert.True(t, res)
 	})
 }
+
+func TestRulesetPatternRules(t *testing.T) {
+
+	t.Run("happy path: commit message pattern", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  on:
+    include: 
+    - "~DEFAULT_BRANCH"
+
+  rules:
+    - ruletype: commit_message_pattern
+      parameters:
+        name: jira ticket
+        operator: regex
+        pattern: "^[A-Z]+-[0-9]+ "
+    - ruletype: committer_email_pattern
+      parameters:
+        operator: ends_with
+        pattern: "@example.com"
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(rulesets))
+		rule := rulesets["ruleset1"].Spec.Rules[0]
+		assert.Equal(t, "jira ticket", rule.Parameters.Name)
+		assert.Equal(t, "regex", rule.Parameters.Operator)
+		assert.Equal(t, "^[A-Z]+-[0-9]+ ", rule.Parameters.Pattern)
+
+		assert.True(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, rule.Parameters))
+		negated := rule.Parameters
+		negated.Negate = true
+		assert.False(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, negated))
+	})
+
+	t.Run("not happy path: invalid operator", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  rules:
+    - ruletype: commit_author_email_pattern
+      parameters:
+        operator: equals
+        pattern: "@example.com"
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("not happy path: empty pattern", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  rules:
+    - ruletype: commit_message_pattern
+      parameters:
+        operator: contains
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+}
+
+func TestRulesetMergeQueueRule(t *testing.T) {
+
+	t.Run("happy path: merge queue with the Github default values", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  on:
+    include: 
+    - "~DEFAULT_BRANCH"
+
+  rules:
+    - ruletype: merge_queue
+      parameters:
+        mergeMethod: squash
+        minEntriesToMerge: 2
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(rulesets))
+		rule := rulesets["ruleset1"].Spec.Rules[0]
+		assert.Equal(t, "squash", rule.Parameters.MergeMethod)
+		assert.Equal(t, 2, rule.Parameters.MinEntriesToMerge)
+
+		// as returned by Github
+		remote := RuleSetParameters{
+			MergeMethod:                 "squash",
+			GroupingStrategy:            "allgreen",
+			CheckResponseTimeoutMinutes: 60,
+			MaxEntriesToBuild:           5,
+			MaxEntriesToMerge:           5,
+			MinEntriesToMerge:           2,
+		}
+		assert.True(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, remote))
+		remote.MaxEntriesToBuild = 10
+		assert.False(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, remote))
+	})
+
+	t.Run("not happy path: invalid merge method", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  rules:
+    - ruletype: merge_queue
+      parameters:
+        mergeMethod: fastforward
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("not happy path: min entries greater than max entries", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  rules:
+    - ruletype: merge_queue
+      parameters:
+        minEntriesToMerge: 10
+        maxEntriesToMerge: 5
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+}
+
+func TestRulesetWorkflowsRule(t *testing.T) {
+
+	t.Run("happy path: required workflows", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  on:
+    include: 
+    - "~DEFAULT_BRANCH"
+
+  rules:
+    - ruletype: workflows
+      parameters:
+        requiredWorkflows:
+          - repository: ci-workflows
+            path: .github/workflows/security.yaml
+            ref: main
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(rulesets))
+		rule := rulesets["ruleset1"].Spec.Rules[0]
+		assert.Equal(t, []RuleSetRequiredWorkflow{{Repository: "ci-workflows", Path: ".github/workflows/security.yaml", Ref: "main"}}, rule.Parameters.RequiredWorkflows)
+
+		repositories := map[string]*Repository{"ci-workflows": {}}
+		assert.Nil(t, rulesets["ruleset1"].ValidateRequiredWorkflows(repositories))
+		assert.NotNil(t, rulesets["ruleset1"].ValidateRequiredWorkflows(map[string]*Repository{}))
+
+		// as returned by Github (with the repository id)
+		remote := RuleSetParameters{
+			RequiredWorkflows: []RuleSetRequiredWorkflow{{Repository: "ci-workflows", RepositoryID: 42, Path: ".github/workflows/security.yaml", Ref: "main"}},
+		}
+		assert.True(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, remote))
+		remote.RequiredWorkflows[0].Ref = "v1"
+		assert.False(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, remote))
+	})
+
+	t.Run("not happy path: required workflow without path", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  rules:
+    - ruletype: workflows
+      parameters:
+        requiredWorkflows:
+          - repository: ci-workflows
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+}
+
+func TestRulesetRequiredDeploymentsRule(t *testing.T) {
+
+	t.Run("happy path: required deployments", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  on:
+    include: 
+    - "release/*"
+
+  rules:
+    - ruletype: required_deployments
+      parameters:
+        requiredDeploymentEnvironments:
+          - staging
+          - production
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(rulesets))
+		rule := rulesets["ruleset1"].Spec.Rules[0]
+		assert.Equal(t, []string{"staging", "production"}, rule.Parameters.RequiredDeploymentEnvironments)
+
+		// the order doesn't matter
+		remote := RuleSetParameters{RequiredDeploymentEnvironments: []string{"production", "staging"}}
+		assert.True(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, remote))
+		remote.RequiredDeploymentEnvironments = []string{"staging"}
+		assert.False(t, CompareRulesetParameters(rule.Ruletype, rule.Parameters, remote))
+	})
+
+	t.Run("not happy path: no environment", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  rules:
+    - ruletype: required_deployments
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+}
+
+func TestRulesetConditions(t *testing.T) {
+
+	t.Run("happy path: repositories targeted by name", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  repositories:
+    include:
+      - ~ALL
+    exclude:
+      - sandbox-*
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, []string{"~ALL"}, rulesets["ruleset1"].Spec.Repositories.Include)
+		assert.Equal(t, []string{"sandbox-*"}, rulesets["ruleset1"].Spec.Repositories.Exclude)
+	})
+
+	t.Run("not happy path: repositories exclude without include", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  repositories:
+    exclude:
+      - sandbox-*
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("happy path: repositories targeted by custom property", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  repositories:
+    properties:
+      include:
+        - name: tier
+          values: ["1", "2"]
+      exclude:
+        - name: lifecycle
+          values: [sunset]
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, []RuleSetRepositoryProperty{{Name: "tier", Values: []string{"1", "2"}}}, rulesets["ruleset1"].Spec.Repositories.Properties.Include)
+		assert.Equal(t, []RuleSetRepositoryProperty{{Name: "lifecycle", Values: []string{"sunset"}}}, rulesets["ruleset1"].Spec.Repositories.Properties.Exclude)
+	})
+
+	t.Run("not happy path: repositories targeted by name and by custom property", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  repositories:
+    include:
+      - ~ALL
+    properties:
+      include:
+        - name: tier
+          values: ["1"]
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+
+	t.Run("not happy path: custom property without values", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: active
+  repositories:
+    properties:
+      include:
+        - name: tier
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		_, errs, _ := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 1, len(errs))
+	})
+}
+
+func TestRulesetEnforcement(t *testing.T) {
+
+	t.Run("happy path: disabled ruleset reported", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("rulesets", 0755)
+		err := utils.WriteFile(fs, "rulesets/ruleset1.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset1
+spec:
+  enforcement: disabled
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "rulesets/ruleset2.yaml", []byte(`
+apiVersion: v1
+kind: Ruleset
+name: ruleset2
+spec:
+  enforcement: active
+  rules:
+    - ruletype: required_signatures
+`), 0644)
+		assert.Nil(t, err)
+
+		rulesets, errs, warns := ReadRuleSetDirectory(fs, "rulesets")
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 2, len(rulesets))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, "ruleset ruleset1 is disabled (ruleset filename rulesets/ruleset1.yaml): consider removing it", warns[0].Error())
+	})
+}
@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * OrgSecret is a Github Actions secret defined at the organization level.
+ * Its value is never managed by Goliac: the secret must be created out-of-band,
+ * Goliac only manages its visibility (and its selected repositories)
+ */
+type OrgSecret struct {
+	Name                 string   `yaml:"name"`
+	Visibility           string   `yaml:"visibility"`           // all, private, selected
+	SelectedRepositories []string `yaml:"selectedRepositories"` // only used if visibility is selected
+}
+
+/*
+ * OrgSecrets are the Github Actions secrets defined at the organization level
+ * (in the org-secrets.yaml file)
+ */
+type OrgSecrets struct {
+	Entity `yaml:",inline"`
+	Spec   struct {
+		Secrets []OrgSecret `yaml:"secrets"`
+	} `yaml:"spec"`
+}
+
+/*
+ * NewOrgSecrets reads a file and returns an OrgSecrets object
+ * The next step is to validate the OrgSecrets object using the Validate method
+ */
+func NewOrgSecrets(fs billy.Filesystem, filename string) (*OrgSecrets, error) {
+	filecontent, err := utils.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := OrgSecrets{}
+	err = yaml.Unmarshal(filecontent, &secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secrets, nil
+}
+
+/**
+ * ReadOrgSecrets reads the org secrets file (if it exists) and returns
+ * - a map of OrgSecret objects (the key is the secret name)
+ * - a slice of errors that must stop the validation process
+ * - a slice of warning that must not stop the validation process
+ */
+func ReadOrgSecrets(fs billy.Filesystem, filename string, repositories map[string]*Repository) (map[string]*OrgSecret, []error, []Warning) {
+	errors := []error{}
+	warning := []Warning{}
+	secrets := make(map[string]*OrgSecret)
+
+	exist, err := utils.Exists(fs, filename)
+	if err != nil {
+		errors = append(errors, err)
+		return secrets, errors, warning
+	}
+	if !exist {
+		return secrets, errors, warning
+	}
+
+	orgsecrets, err := NewOrgSecrets(fs, filename)
+	if err != nil {
+		errors = append(errors, NewEntityError("org secrets", "", filename, err))
+		return secrets, errors, warning
+	}
+
+	errs, warns := orgsecrets.Validate(filename, repositories)
+	for _, err := range errs {
+		errors = append(errors, NewEntityError("org secrets", "", filename, err))
+	}
+	warning = append(warning, warns...)
+	if len(errs) > 0 {
+		return secrets, errors, warning
+	}
+
+	for i := range orgsecrets.Spec.Secrets {
+		s := orgsecrets.Spec.Secrets[i]
+		secrets[s.Name] = &s
+	}
+
+	return secrets, errors, warning
+}
+
+func (o *OrgSecrets) Validate(filename string, repositories map[string]*Repository) ([]error, []Warning) {
+	errors := []error{}
+	warnings := []Warning{}
+
+	if o.ApiVersion != "v1" {
+		errors = append(errors, fmt.Errorf("invalid apiVersion: %s for org secrets filename %s", o.ApiVersion, filename))
+	}
+
+	if o.Kind != "OrgSecrets" {
+		errors = append(errors, fmt.Errorf("invalid kind: %s for org secrets filename %s", o.Kind, filename))
+	}
+
+	names := make(map[string]bool)
+	for _, s := range o.Spec.Secrets {
+		if s.Name == "" {
+			errors = append(errors, fmt.Errorf("secret name is empty in org secrets filename %s", filename))
+			continue
+		}
+		if names[s.Name] {
+			errors = append(errors, fmt.Errorf("secret %s is defined twice in org secrets filename %s", s.Name, filename))
+		}
+		names[s.Name] = true
+
+		switch s.Visibility {
+		case "all", "private":
+			if len(s.SelectedRepositories) > 0 {
+				warnings = append(warnings, fmt.Errorf("secret %s has selectedRepositories but its visibility is %s (in org secrets filename %s)", s.Name, s.Visibility, filename))
+			}
+		case "selected":
+			for _, r := range s.SelectedRepositories {
+				if _, ok := repositories[r]; !ok {
+					warnings = append(warnings, fmt.Errorf("secret %s refers to the repository %s that doesn't exist (in org secrets filename %s)", s.Name, r, filename))
+				}
+			}
+		default:
+			errors = append(errors, fmt.Errorf("invalid visibility: %s for secret %s in org secrets filename %s", s.Visibility, s.Name, filename))
+		}
+	}
+
+	return errors, warnings
+}
@@ -0,0 +1,88 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgSecrets(t *testing.T) {
+
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "org-secrets.yaml", []byte(`
+apiVersion: v1
+kind: OrgSecrets
+name: org-secrets
+spec:
+  secrets:
+    - name: FOO
+      visibility: all
+    - name: BAR
+      visibility: selected
+      selectedRepositories:
+      - repo1
+`), 0644)
+		assert.Nil(t, err)
+
+		repos := map[string]*Repository{"repo1": {}}
+		secrets, errs, warns := ReadOrgSecrets(fs, "org-secrets.yaml", repos)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, 2, len(secrets))
+		assert.Equal(t, "selected", secrets["BAR"].Visibility)
+		assert.Equal(t, []string{"repo1"}, secrets["BAR"].SelectedRepositories)
+	})
+
+	t.Run("happy path: no file", func(t *testing.T) {
+		fs := memfs.New()
+
+		secrets, errs, warns := ReadOrgSecrets(fs, "org-secrets.yaml", map[string]*Repository{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, 0, len(secrets))
+	})
+
+	t.Run("not happy path: secret defined twice", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "org-secrets.yaml", []byte(`
+apiVersion: v1
+kind: OrgSecrets
+name: org-secrets
+spec:
+  secrets:
+    - name: FOO
+      visibility: all
+    - name: FOO
+      visibility: private
+`), 0644)
+		assert.Nil(t, err)
+
+		secrets, errs, _ := ReadOrgSecrets(fs, "org-secrets.yaml", map[string]*Repository{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(secrets))
+	})
+
+	t.Run("not happy path: unknown selected repository", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "org-secrets.yaml", []byte(`
+apiVersion: v1
+kind: OrgSecrets
+name: org-secrets
+spec:
+  secrets:
+    - name: FOO
+      visibility: selected
+      selectedRepositories:
+      - unknown
+`), 0644)
+		assert.Nil(t, err)
+
+		secrets, errs, warns := ReadOrgSecrets(fs, "org-secrets.yaml", map[string]*Repository{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 1, len(secrets))
+	})
+}
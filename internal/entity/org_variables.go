@@ -0,0 +1,131 @@
+package entity
+
+import (
+	"fmt"
+
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5"
+	"gopkg.in/yaml.v3"
+)
+
+type OrgVariable struct {
+	Name                 string   `yaml:"name"`
+	Value                string   `yaml:"value"`
+	Visibility           string   `yaml:"visibility"`           // all, private, selected
+	SelectedRepositories []string `yaml:"selectedRepositories"` // only used if visibility is selected
+}
+
+/*
+ * OrgVariables are the Github Actions variables defined at the organization level
+ * (in the org-variables.yaml file)
+ */
+type OrgVariables struct {
+	Entity `yaml:",inline"`
+	Spec   struct {
+		Variables []OrgVariable `yaml:"variables"`
+	} `yaml:"spec"`
+}
+
+/*
+ * NewOrgVariables reads a file and returns an OrgVariables object
+ * The next step is to validate the OrgVariables object using the Validate method
+ */
+func NewOrgVariables(fs billy.Filesystem, filename string) (*OrgVariables, error) {
+	filecontent, err := utils.ReadFile(fs, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := OrgVariables{}
+	err = yaml.Unmarshal(filecontent, &variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return &variables, nil
+}
+
+/**
+ * ReadOrgVariables reads the org variables file (if it exists) and returns
+ * - a map of OrgVariable objects (the key is the variable name)
+ * - a slice of errors that must stop the validation process
+ * - a slice of warning that must not stop the validation process
+ */
+func ReadOrgVariables(fs billy.Filesystem, filename string, repositories map[string]*Repository) (map[string]*OrgVariable, []error, []Warning) {
+	errors := []error{}
+	warning := []Warning{}
+	variables := make(map[string]*OrgVariable)
+
+	exist, err := utils.Exists(fs, filename)
+	if err != nil {
+		errors = append(errors, err)
+		return variables, errors, warning
+	}
+	if !exist {
+		return variables, errors, warning
+	}
+
+	orgvariables, err := NewOrgVariables(fs, filename)
+	if err != nil {
+		errors = append(errors, NewEntityError("org variables", "", filename, err))
+		return variables, errors, warning
+	}
+
+	errs, warns := orgvariables.Validate(filename, repositories)
+	for _, err := range errs {
+		errors = append(errors, NewEntityError("org variables", "", filename, err))
+	}
+	warning = append(warning, warns...)
+	if len(errs) > 0 {
+		return variables, errors, warning
+	}
+
+	for i := range orgvariables.Spec.Variables {
+		v := orgvariables.Spec.Variables[i]
+		variables[v.Name] = &v
+	}
+
+	return variables, errors, warning
+}
+
+func (o *OrgVariables) Validate(filename string, repositories map[string]*Repository) ([]error, []Warning) {
+	errors := []error{}
+	warnings := []Warning{}
+
+	if o.ApiVersion != "v1" {
+		errors = append(errors, fmt.Errorf("invalid apiVersion: %s for org variables filename %s", o.ApiVersion, filename))
+	}
+
+	if o.Kind != "OrgVariables" {
+		errors = append(errors, fmt.Errorf("invalid kind: %s for org variables filename %s", o.Kind, filename))
+	}
+
+	names := make(map[string]bool)
+	for _, v := range o.Spec.Variables {
+		if v.Name == "" {
+			errors = append(errors, fmt.Errorf("variable name is empty in org variables filename %s", filename))
+			continue
+		}
+		if names[v.Name] {
+			errors = append(errors, fmt.Errorf("variable %s is defined twice in org variables filename %s", v.Name, filename))
+		}
+		names[v.Name] = true
+
+		switch v.Visibility {
+		case "all", "private":
+			if len(v.SelectedRepositories) > 0 {
+				warnings = append(warnings, fmt.Errorf("variable %s has selectedRepositories but its visibility is %s (in org variables filename %s)", v.Name, v.Visibility, filename))
+			}
+		case "selected":
+			for _, r := range v.SelectedRepositories {
+				if _, ok := repositories[r]; !ok {
+					warnings = append(warnings, fmt.Errorf("variable %s refers to the repository %s that doesn't exist (in org variables filename %s)", v.Name, r, filename))
+				}
+			}
+		default:
+			errors = append(errors, fmt.Errorf("invalid visibility: %s for variable %s in org variables filename %s", v.Visibility, v.Name, filename))
+		}
+	}
+
+	return errors, warnings
+}
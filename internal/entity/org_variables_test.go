@@ -0,0 +1,90 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrgVariables(t *testing.T) {
+
+	t.Run("happy path", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "org-variables.yaml", []byte(`
+apiVersion: v1
+kind: OrgVariables
+name: org-variables
+spec:
+  variables:
+    - name: FOO
+      value: bar
+      visibility: all
+    - name: BAR
+      value: foo
+      visibility: selected
+      selectedRepositories:
+      - repo1
+`), 0644)
+		assert.Nil(t, err)
+
+		repos := map[string]*Repository{"repo1": {}}
+		variables, errs, warns := ReadOrgVariables(fs, "org-variables.yaml", repos)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, 2, len(variables))
+		assert.Equal(t, "selected", variables["BAR"].Visibility)
+		assert.Equal(t, []string{"repo1"}, variables["BAR"].SelectedRepositories)
+	})
+
+	t.Run("happy path: no file", func(t *testing.T) {
+		fs := memfs.New()
+
+		variables, errs, warns := ReadOrgVariables(fs, "org-variables.yaml", map[string]*Repository{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+		assert.Equal(t, 0, len(variables))
+	})
+
+	t.Run("not happy path: invalid visibility", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "org-variables.yaml", []byte(`
+apiVersion: v1
+kind: OrgVariables
+name: org-variables
+spec:
+  variables:
+    - name: FOO
+      value: bar
+      visibility: public
+`), 0644)
+		assert.Nil(t, err)
+
+		variables, errs, _ := ReadOrgVariables(fs, "org-variables.yaml", map[string]*Repository{})
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, 0, len(variables))
+	})
+
+	t.Run("not happy path: unknown selected repository", func(t *testing.T) {
+		fs := memfs.New()
+		err := utils.WriteFile(fs, "org-variables.yaml", []byte(`
+apiVersion: v1
+kind: OrgVariables
+name: org-variables
+spec:
+  variables:
+    - name: FOO
+      value: bar
+      visibility: selected
+      selectedRepositories:
+      - unknown
+`), 0644)
+		assert.Nil(t, err)
+
+		variables, errs, warns := ReadOrgVariables(fs, "org-variables.yaml", map[string]*Repository{})
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+		assert.Equal(t, 1, len(variables))
+	})
+}
@@ -3,24 +3,74 @@ package entity
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
 	"gopkg.in/yaml.v3"
 )
 
+type RepositoryActionsPermissions struct {
+	Enabled        bool   `yaml:"enabled"`
+	AllowedActions string `yaml:"allowed_actions,omitempty"` // all, local_only, selected (only used if enabled)
+}
+
+// nil means not managed by Goliac
+type RepositorySecurity struct {
+	SecretScanning               *bool `yaml:"secret_scanning,omitempty"`
+	SecretScanningPushProtection *bool `yaml:"secret_scanning_push_protection,omitempty"`
+	DependabotSecurityUpdates    *bool `yaml:"dependabot_security_updates,omitempty"`
+	VulnerabilityAlerts          *bool `yaml:"vulnerability_alerts,omitempty"` // same as dependabot_alerts
+}
+
+type RepositoryWebhook struct {
+	URL         string   `yaml:"url"`
+	Events      []string `yaml:"events,omitempty"`       // default: push
+	ContentType string   `yaml:"content_type,omitempty"` // json or form (default: form)
+	Active      *bool    `yaml:"active,omitempty"`       // default: true
+	SecretEnv   string   `yaml:"secret_env,omitempty"`   // environment variable (of the Goliac server) holding the secret. Github never returns it, so a secret change alone is not detected
+}
+
+type RepositoryLabel struct {
+	Name        string  `yaml:"name"`
+	Color       string  `yaml:"color"`                 // hexadecimal color code, without the leading '#'
+	Description *string `yaml:"description,omitempty"` // nil means not managed by Goliac
+}
+
 type Repository struct {
 	Entity `yaml:",inline"`
 	Spec   struct {
-		Writers             []string `yaml:"writers,omitempty"`
-		Readers             []string `yaml:"readers,omitempty"`
-		ExternalUserReaders []string `yaml:"externalUserReaders,omitempty"`
-		ExternalUserWriters []string `yaml:"externalUserWriters,omitempty"`
-		IsPublic            bool     `yaml:"public,omitempty"`
-		AllowAutoMerge      bool     `yaml:"allow_auto_merge,omitempty"`
-		DeleteBranchOnMerge bool     `yaml:"delete_branch_on_merge,omitempty"`
-		AllowUpdateBranch   bool     `yaml:"allow_update_branch,omitempty"`
+		Writers                  []string                      `yaml:"writers,omitempty"`
+		Readers                  []string                      `yaml:"readers,omitempty"`
+		ExternalUserReaders      []string                      `yaml:"externalUserReaders,omitempty"`
+		ExternalUserWriters      []string                      `yaml:"externalUserWriters,omitempty"`
+		CustomRoles              map[string]string             `yaml:"custom_roles,omitempty"` // team name -> custom repository role (defined at the organization level)
+		IsPublic                 bool                          `yaml:"public,omitempty"`
+		AllowAutoMerge           bool                          `yaml:"allow_auto_merge,omitempty"`
+		DeleteBranchOnMerge      bool                          `yaml:"delete_branch_on_merge,omitempty"`
+		AllowUpdateBranch        bool                          `yaml:"allow_update_branch,omitempty"`
+		RequireSignedCommits     *bool                         `yaml:"require_signed_commits,omitempty"`     // nil means the branch protection template value (if any) is used
+		BranchProtectionTemplate string                        `yaml:"branch_protection_template,omitempty"` // name of a template defined in goliac.yaml
+		AllowMergeCommit         *bool                         `yaml:"allow_merge_commit,omitempty"`         // nil means not managed by Goliac (unless disabled in goliac.yaml)
+		AllowSquashMerge         *bool                         `yaml:"allow_squash_merge,omitempty"`         // nil means not managed by Goliac (unless disabled in goliac.yaml)
+		AllowRebaseMerge         *bool                         `yaml:"allow_rebase_merge,omitempty"`         // nil means not managed by Goliac (unless disabled in goliac.yaml)
+		IsTemplate               *bool                         `yaml:"is_template,omitempty"`                // nil means not managed by Goliac
+		Description              *string                       `yaml:"description,omitempty"`                // nil means not managed by Goliac
+		Homepage                 *string                       `yaml:"homepage,omitempty"`                   // nil means not managed by Goliac
+		DependabotAlerts         *bool                         `yaml:"dependabot_alerts,omitempty"`          // nil means not managed by Goliac
+		ActionsPermissions       *RepositoryActionsPermissions `yaml:"actions_permissions,omitempty"`        // nil means not managed by Goliac
+		CustomProperties         map[string]string             `yaml:"custom_properties,omitempty"`          // only the properties listed are managed by Goliac (an empty value unsets the property)
+		Security                 *RepositorySecurity           `yaml:"security,omitempty"`                   // nil means not managed by Goliac
+		Webhooks                 []RepositoryWebhook           `yaml:"webhooks,omitempty"`                   // nil means not managed by Goliac (an empty list removes all the webhooks)
+		Labels                   []RepositoryLabel             `yaml:"labels,omitempty"`                     // nil means not managed by Goliac
+		LabelsManaged            bool                          `yaml:"labels_managed,omitempty"`             // if set, the labels not listed are removed (included the Github default ones)
+		// only used when the repository is created
+		TemplateRepository         string `yaml:"template_repository,omitempty"` // <owner>/<name> of the template repository
+		TemplateIncludeAllBranches bool   `yaml:"template_include_all_branches,omitempty"`
+		TransferFrom               string `yaml:"transfer_from,omitempty"` // <owner>/<name> of the repository to transfer into the organization (the Github app must be installed on the owner)
 	} `yaml:"spec,omitempty"`
 	Archived bool    `yaml:"archived,omitempty"` // implicit: will be set by Goliac
 	Owner    *string `yaml:"owner,omitempty"`    // implicit. team name owning the repo (if any)
@@ -82,12 +132,13 @@ func ReadRepositories(fs billy.Filesystem, archivedDirname string, teamDirname s
 				warning = append(warning, fmt.Errorf("File %s doesn't have a .yaml extension", entry.Name()))
 				continue
 			}
-			repo, err := NewRepository(fs, filepath.Join(archivedDirname, entry.Name()))
+			filename := filepath.Join(archivedDirname, entry.Name())
+			repo, err := NewRepository(fs, filename)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, NewEntityError("repository", strings.TrimSuffix(entry.Name(), ".yaml"), filename, err))
 			} else {
-				if err := repo.Validate(filepath.Join(archivedDirname, entry.Name()), teams, externalUsers); err != nil {
-					errors = append(errors, err)
+				if err := repo.Validate(filename, teams, externalUsers); err != nil {
+					errors = append(errors, NewEntityError("repository", repo.Name, filename, err))
 				} else {
 					repo.Archived = true
 					repos[repo.Name] = repo
@@ -139,12 +190,13 @@ func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, team
 			warnings = append(warnings, subwarns...)
 		}
 		if !sube.IsDir() && filepath.Ext(sube.Name()) == ".yaml" && sube.Name() != "team.yaml" {
-			repo, err := NewRepository(fs, filepath.Join(teamDirPath, sube.Name()))
+			filename := filepath.Join(teamDirPath, sube.Name())
+			repo, err := NewRepository(fs, filename)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, NewEntityError("repository", strings.TrimSuffix(sube.Name(), ".yaml"), filename, err))
 			} else {
-				if err := repo.Validate(filepath.Join(teamDirPath, sube.Name()), teams, externalUsers); err != nil {
-					errors = append(errors, err)
+				if err := repo.Validate(filename, teams, externalUsers); err != nil {
+					errors = append(errors, NewEntityError("repository", repo.Name, filename, err))
 				} else {
 					// check if the repository doesn't already exists
 					if _, exist := repos[repo.Name]; exist {
@@ -152,7 +204,7 @@ func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, team
 						if repos[repo.Name].Owner != nil {
 							existing = filepath.Join(*repos[repo.Name].Owner, repo.Name)
 						}
-						errors = append(errors, fmt.Errorf("Repository %s defined in 2 places (check %s and %s)", repo.Name, filepath.Join(teamDirPath, sube.Name()), existing))
+						errors = append(errors, NewEntityError("repository", repo.Name, filename, fmt.Errorf("Repository %s defined in 2 places (check %s and %s)", repo.Name, filename, existing)))
 					} else {
 						teamname := teamName
 						repo.Owner = &teamname
@@ -166,6 +218,114 @@ func recursiveReadRepositories(fs billy.Filesystem, archivedDirPath string, team
 	return errors, warnings
 }
 
+func isFalse(b *bool) bool {
+	return b != nil && !*b
+}
+
+/*
+ * ValidateRepositoriesQuota checks that no team owns more (non archived)
+ * repositories than its limit (repositories_quota in goliac.yaml)
+ */
+func ValidateRepositoriesQuota(repositories map[string]*Repository, repoconfig *config.RepositoryConfig) []error {
+	owned := make(map[string]int)
+	for _, r := range repositories {
+		if r.Owner != nil && !r.Archived {
+			owned[*r.Owner]++
+		}
+	}
+
+	teamnames := make([]string, 0, len(owned))
+	for teamname := range owned {
+		teamnames = append(teamnames, teamname)
+	}
+	sort.Strings(teamnames)
+
+	errors := []error{}
+	for _, teamname := range teamnames {
+		limit := repoconfig.RepositoriesQuota.MaxPerTeam
+		if teamLimit, ok := repoconfig.RepositoriesQuota.Teams[teamname]; ok {
+			limit = teamLimit
+		}
+		if limit > 0 && owned[teamname] > limit {
+			errors = append(errors, NewEntityError("team", teamname, "", fmt.Errorf("team %s owns %d repositories, more than its limit of %d (repositories_quota in goliac.yaml)", teamname, owned[teamname], limit)))
+		}
+	}
+	return errors
+}
+
+/*
+ * ValidateBranchProtectionTemplate checks that the branch protection template
+ * referenced by the repository (if any) is defined in goliac.yaml
+ */
+func (r *Repository) ValidateBranchProtectionTemplate(repoconfig *config.RepositoryConfig) error {
+	if r.Spec.BranchProtectionTemplate == "" {
+		return nil
+	}
+	if _, ok := repoconfig.BranchProtectionTemplates[r.Spec.BranchProtectionTemplate]; !ok {
+		return fmt.Errorf("invalid branch_protection_template: %s is not defined in goliac.yaml for the repository %s", r.Spec.BranchProtectionTemplate, r.Name)
+	}
+	return nil
+}
+
+/*
+ * ValidateVisibility checks the repository visibility against the
+ * visibilities allowed in the organization (goliac.yaml)
+ */
+func (r *Repository) ValidateVisibility(repoconfig *config.RepositoryConfig) error {
+	if len(repoconfig.AllowedRepositoryVisibilities) == 0 || r.Archived {
+		return nil
+	}
+	visibility := "private"
+	if r.Spec.IsPublic {
+		visibility = "public"
+	}
+	for _, v := range repoconfig.AllowedRepositoryVisibilities {
+		if v != "public" && v != "private" && v != "internal" {
+			return fmt.Errorf("invalid allowed_repository_visibilities: %s (must be public, private or internal) in goliac.yaml", v)
+		}
+		if v == visibility {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid visibility: %s is not allowed by allowed_repository_visibilities in goliac.yaml for the repository %s", visibility, r.Name)
+}
+
+/*
+ * ValidateMergeMethods checks the repository merge methods against the merge
+ * methods allowed in the organization (goliac.yaml)
+ */
+func (r *Repository) ValidateMergeMethods(repoconfig *config.RepositoryConfig) error {
+	methods := []struct {
+		name  string
+		org   *bool
+		local *bool
+	}{
+		{"allow_merge_commit", repoconfig.MergeMethods.AllowMergeCommit, r.Spec.AllowMergeCommit},
+		{"allow_squash_merge", repoconfig.MergeMethods.AllowSquashMerge, r.Spec.AllowSquashMerge},
+		{"allow_rebase_merge", repoconfig.MergeMethods.AllowRebaseMerge, r.Spec.AllowRebaseMerge},
+	}
+	allowed := 0
+	for _, m := range methods {
+		if isFalse(m.org) {
+			if m.local != nil && *m.local {
+				return fmt.Errorf("invalid merge methods: %s is disabled in goliac.yaml for the repository %s", m.name, r.Name)
+			}
+			continue
+		}
+		if !isFalse(m.local) {
+			allowed++
+		}
+	}
+	if allowed == 0 {
+		return fmt.Errorf("invalid merge methods: at least one merge method must be allowed (see goliac.yaml) for the repository %s", r.Name)
+	}
+	return nil
+}
+
+var labelColorRegex = regexp.MustCompile(`^[0-9a-fA-F]{6}$`)
+
+var webhookSecretEnvRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 func (r *Repository) Validate(filename string, teams map[string]*Team, externalUsers map[string]*User) error {
 
 	if r.ApiVersion != "v1" {
@@ -195,6 +355,14 @@ func (r *Repository) Validate(filename string, teams map[string]*Team, externalU
 			return fmt.Errorf("invalid reader: %s doesn't exist (check repository filename %s)", reader, filename)
 		}
 	}
+	for team, role := range r.Spec.CustomRoles {
+		if _, ok := teams[team]; !ok {
+			return fmt.Errorf("invalid custom_roles: %s doesn't exist (check repository filename %s)", team, filename)
+		}
+		if role == "" {
+			return fmt.Errorf("invalid custom_roles: empty role for the team %s in repository filename %s", team, filename)
+		}
+	}
 
 	for _, externalUserReader := range r.Spec.ExternalUserReaders {
 		if _, ok := externalUsers[externalUserReader]; !ok {
@@ -208,5 +376,83 @@ func (r *Repository) Validate(filename string, teams map[string]*Team, externalU
 		}
 	}
 
+	if r.Spec.ActionsPermissions != nil && r.Spec.ActionsPermissions.Enabled {
+		switch r.Spec.ActionsPermissions.AllowedActions {
+		case "", "all", "local_only", "selected":
+		default:
+			return fmt.Errorf("invalid actions_permissions allowed_actions: %s (must be all, local_only or selected) in repository filename %s", r.Spec.ActionsPermissions.AllowedActions, filename)
+		}
+	}
+
+	if r.Spec.Security != nil && r.Spec.Security.VulnerabilityAlerts != nil && r.Spec.DependabotAlerts != nil && *r.Spec.Security.VulnerabilityAlerts != *r.Spec.DependabotAlerts {
+		return fmt.Errorf("invalid security vulnerability_alerts: it contradicts dependabot_alerts in repository filename %s", filename)
+	}
+
+	if r.Spec.TemplateRepository != "" {
+		parts := strings.Split(r.Spec.TemplateRepository, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid template_repository: %s (must be <owner>/<name>) in repository filename %s", r.Spec.TemplateRepository, filename)
+		}
+	} else if r.Spec.TemplateIncludeAllBranches {
+		return fmt.Errorf("invalid template_include_all_branches: template_repository is not set in repository filename %s", filename)
+	}
+
+	if r.Spec.TransferFrom != "" {
+		parts := strings.Split(r.Spec.TransferFrom, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid transfer_from: %s (must be <owner>/<name>) in repository filename %s", r.Spec.TransferFrom, filename)
+		}
+		if r.Spec.TemplateRepository != "" {
+			return fmt.Errorf("invalid transfer_from: a repository cannot be both transferred and created from template_repository in repository filename %s", filename)
+		}
+	}
+
+	webhooks := make(map[string]bool)
+	for _, w := range r.Spec.Webhooks {
+		if w.URL == "" {
+			return fmt.Errorf("invalid webhooks: empty url in repository filename %s", filename)
+		}
+		if webhooks[w.URL] {
+			return fmt.Errorf("invalid webhooks: url %s is declared twice in repository filename %s", w.URL, filename)
+		}
+		webhooks[w.URL] = true
+		if w.SecretEnv != "" && !webhookSecretEnvRegex.MatchString(w.SecretEnv) {
+			return fmt.Errorf("invalid webhooks secret_env: %s (must be an environment variable name) in repository filename %s", w.SecretEnv, filename)
+		}
+		switch w.ContentType {
+		case "", "json", "form":
+		default:
+			return fmt.Errorf("invalid webhooks content_type: %s (must be json or form) in repository filename %s", w.ContentType, filename)
+		}
+	}
+
+	if isFalse(r.Spec.AllowMergeCommit) && isFalse(r.Spec.AllowSquashMerge) && isFalse(r.Spec.AllowRebaseMerge) {
+		return fmt.Errorf("invalid merge methods: at least one merge method must be allowed in repository filename %s", filename)
+	}
+
+	labels := make(map[string]bool)
+	for _, l := range r.Spec.Labels {
+		if l.Name == "" {
+			return fmt.Errorf("invalid labels: empty name in repository filename %s", filename)
+		}
+		// label names are case insensitive
+		if labels[strings.ToLower(l.Name)] {
+			return fmt.Errorf("invalid labels: label %s is declared twice in repository filename %s", l.Name, filename)
+		}
+		labels[strings.ToLower(l.Name)] = true
+		if !labelColorRegex.MatchString(l.Color) {
+			return fmt.Errorf("invalid labels color: %s for label %s (must be a 6 characters hexadecimal color code, without '#') in repository filename %s", l.Color, l.Name, filename)
+		}
+	}
+	if r.Spec.LabelsManaged && r.Spec.Labels == nil {
+		return fmt.Errorf("invalid labels_managed: labels is not set in repository filename %s", filename)
+	}
+
+	for k := range r.Spec.CustomProperties {
+		if k == "" {
+			return fmt.Errorf("invalid custom_properties: empty property name in repository filename %s", filename)
+		}
+	}
+
 	return nil
 }
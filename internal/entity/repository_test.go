@@ -3,6 +3,7 @@ package entity
 import (
 	"testing"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
@@ -214,4 +215,642 @@ name: repo1
 		assert.NotNil(t, repos)
 		assert.Equal(t, len(repos), 1)
 	})
+
+	t.Run("happy path: actions permissions", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  actions_permissions:
+    enabled: true
+    allowed_actions: local_only
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+		assert.Equal(t, len(repos), 1)
+		assert.Equal(t, true, repos["repo1"].Spec.ActionsPermissions.Enabled)
+		assert.Equal(t, "local_only", repos["repo1"].Spec.ActionsPermissions.AllowedActions)
+	})
+
+	t.Run("not happy path: invalid actions permissions allowed_actions", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  actions_permissions:
+    enabled: true
+    allowed_actions: everything
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("happy path: custom properties", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  custom_properties:
+    data-classification: confidential
+    tier: ""
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, warns := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+		assert.Equal(t, len(repos), 1)
+		assert.Equal(t, map[string]string{"data-classification": "confidential", "tier": ""}, repos["repo1"].Spec.CustomProperties)
+	})
+
+	t.Run("not happy path: empty custom property name", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  custom_properties:
+    "": confidential
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("not happy path: vulnerability alerts contradicting dependabot alerts", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  dependabot_alerts: false
+  security:
+    secret_scanning: true
+    vulnerability_alerts: true
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("happy path: template repository", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  template_repository: myorg/template
+  template_include_all_branches: true
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, "myorg/template", repos["repo1"].Spec.TemplateRepository)
+		assert.True(t, repos["repo1"].Spec.TemplateIncludeAllBranches)
+	})
+
+	t.Run("not happy path: invalid template repository", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  template_repository: template
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("happy path: webhooks", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  webhooks:
+    - url: https://ci.example.com/hook
+      events: [push, pull_request]
+      content_type: json
+      active: false
+      secret_env: CI_WEBHOOK_SECRET
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, 1, len(repos["repo1"].Spec.Webhooks))
+		webhook := repos["repo1"].Spec.Webhooks[0]
+		assert.Equal(t, "https://ci.example.com/hook", webhook.URL)
+		assert.Equal(t, []string{"push", "pull_request"}, webhook.Events)
+		assert.Equal(t, false, *webhook.Active)
+		assert.Equal(t, "CI_WEBHOOK_SECRET", webhook.SecretEnv)
+	})
+
+	t.Run("happy path: an empty webhooks list is managed", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  webhooks: []
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.NotNil(t, repos["repo1"].Spec.Webhooks)
+		assert.Equal(t, 0, len(repos["repo1"].Spec.Webhooks))
+	})
+
+	t.Run("not happy path: webhook declared twice", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  webhooks:
+    - url: https://ci.example.com/hook
+    - url: https://ci.example.com/hook
+      content_type: json
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("not happy path: invalid webhook content type", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  webhooks:
+    - url: https://ci.example.com/hook
+      content_type: xml
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("not happy path: webhook secret in the repository file", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  webhooks:
+    - url: https://ci.example.com/hook
+      secret_env: s3cr3t-value!
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("happy path: labels", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  labels:
+    - name: bug
+      color: d73a4a
+      description: Something isn't working
+    - name: triage
+      color: EDEDED
+  labels_managed: true
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, 2, len(repos["repo1"].Spec.Labels))
+		assert.Equal(t, "Something isn't working", *repos["repo1"].Spec.Labels[0].Description)
+		assert.Nil(t, repos["repo1"].Spec.Labels[1].Description)
+		assert.True(t, repos["repo1"].Spec.LabelsManaged)
+	})
+
+	t.Run("not happy path: label declared twice", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  labels:
+    - name: bug
+      color: d73a4a
+    - name: Bug
+      color: d73a4a
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("not happy path: invalid label color", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  labels:
+    - name: bug
+      color: "#d73a4a"
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("not happy path: labels_managed without labels", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  labels_managed: true
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+}
+
+func TestRepositoryMergeMethods(t *testing.T) {
+	disabled := false
+	enabled := true
+
+	t.Run("happy path: merge methods allowed in goliac.yaml", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.MergeMethods.AllowMergeCommit = &disabled
+
+		repo := Repository{}
+		repo.Name = "repo1"
+		repo.Spec.AllowSquashMerge = &enabled
+		repo.Spec.AllowRebaseMerge = &disabled
+
+		assert.Nil(t, repo.ValidateMergeMethods(&repoconfig))
+	})
+
+	t.Run("not happy path: merge method disabled in goliac.yaml", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.MergeMethods.AllowMergeCommit = &disabled
+
+		repo := Repository{}
+		repo.Name = "repo1"
+		repo.Spec.AllowMergeCommit = &enabled
+
+		assert.NotNil(t, repo.ValidateMergeMethods(&repoconfig))
+	})
+
+	t.Run("not happy path: no merge method left", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.MergeMethods.AllowMergeCommit = &disabled
+		repoconfig.MergeMethods.AllowRebaseMerge = &disabled
+
+		repo := Repository{}
+		repo.Name = "repo1"
+		repo.Spec.AllowSquashMerge = &disabled
+
+		assert.NotNil(t, repo.ValidateMergeMethods(&repoconfig))
+	})
+
+	t.Run("not happy path: all merge methods disabled in the repository", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  allow_merge_commit: false
+  allow_squash_merge: false
+  allow_rebase_merge: false
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+}
+
+func TestRepositoryTransferFrom(t *testing.T) {
+
+	t.Run("happy path: transfer_from", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  transfer_from: someuser/oldrepo
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		repos, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, "someuser/oldrepo", repos["repo1"].Spec.TransferFrom)
+	})
+
+	t.Run("not happy path: invalid transfer_from", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  transfer_from: oldrepo
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+
+	t.Run("not happy path: transfer_from and template_repository", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUserTeam(t, fs)
+
+		err := utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  transfer_from: someuser/oldrepo
+  template_repository: myorg/template
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, _, _ := ReadTeamDirectory(fs, "teams", users)
+
+		_, errs, _ := ReadRepositories(fs, "archived", "teams", teams, map[string]*User{})
+		assert.Equal(t, len(errs), 1)
+	})
+}
+
+func TestRepositoryBranchProtectionTemplate(t *testing.T) {
+	t.Run("happy path: template defined in goliac.yaml", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{
+			BranchProtectionTemplates: map[string]config.BranchProtectionTemplate{
+				"standard": {},
+			},
+		}
+
+		repo := Repository{}
+		repo.Name = "repo1"
+		repo.Spec.BranchProtectionTemplate = "standard"
+
+		assert.Nil(t, repo.ValidateBranchProtectionTemplate(&repoconfig))
+	})
+
+	t.Run("not happy path: unknown template", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+
+		repo := Repository{}
+		repo.Name = "repo1"
+		repo.Spec.BranchProtectionTemplate = "standard"
+
+		assert.NotNil(t, repo.ValidateBranchProtectionTemplate(&repoconfig))
+	})
+}
+
+func TestRepositoriesQuota(t *testing.T) {
+	newRepositories := func() map[string]*Repository {
+		repos := make(map[string]*Repository)
+		for _, r := range []struct {
+			name     string
+			owner    string
+			archived bool
+		}{
+			{"repo1", "team1", false},
+			{"repo2", "team1", false},
+			{"repo3", "team1", true},
+			{"repo4", "team2", false},
+		} {
+			owner := r.owner
+			repo := &Repository{Owner: &owner, Archived: r.archived}
+			repo.Name = r.name
+			repos[r.name] = repo
+		}
+		return repos
+	}
+
+	t.Run("happy path: no limit", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		assert.Equal(t, 0, len(ValidateRepositoriesQuota(newRepositories(), &repoconfig)))
+	})
+
+	t.Run("happy path: archived repositories are not counted", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.RepositoriesQuota.MaxPerTeam = 2
+		assert.Equal(t, 0, len(ValidateRepositoriesQuota(newRepositories(), &repoconfig)))
+	})
+
+	t.Run("not happy path: global limit exceeded", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.RepositoriesQuota.MaxPerTeam = 1
+
+		errs := ValidateRepositoriesQuota(newRepositories(), &repoconfig)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "team team1 owns 2 repositories, more than its limit of 1 (repositories_quota in goliac.yaml)", errs[0].Error())
+	})
+
+	t.Run("happy path: per team limit", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		repoconfig.RepositoriesQuota.MaxPerTeam = 1
+		repoconfig.RepositoriesQuota.Teams = map[string]int{"team1": 0}
+
+		// team1 has no limit
+		assert.Equal(t, 0, len(ValidateRepositoriesQuota(newRepositories(), &repoconfig)))
+
+		repoconfig.RepositoriesQuota.MaxPerTeam = 0
+		repoconfig.RepositoriesQuota.Teams = map[string]int{"team2": 1, "team1": 1}
+		errs := ValidateRepositoriesQuota(newRepositories(), &repoconfig)
+		assert.Equal(t, 1, len(errs))
+		assert.Contains(t, errs[0].Error(), "team team1")
+	})
+}
+
+func TestRepositoryVisibility(t *testing.T) {
+	newRepository := func(public bool) *Repository {
+		repo := &Repository{}
+		repo.Name = "repo1"
+		repo.Spec.IsPublic = public
+		return repo
+	}
+
+	t.Run("happy path: all visibilities allowed by default", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{}
+		assert.Nil(t, newRepository(true).ValidateVisibility(&repoconfig))
+	})
+
+	t.Run("happy path: private repository restricted to private and internal", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{AllowedRepositoryVisibilities: []string{"private", "internal"}}
+		assert.Nil(t, newRepository(false).ValidateVisibility(&repoconfig))
+	})
+
+	t.Run("not happy path: public repository restricted to private and internal", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{AllowedRepositoryVisibilities: []string{"private", "internal"}}
+		assert.NotNil(t, newRepository(true).ValidateVisibility(&repoconfig))
+	})
+
+	t.Run("happy path: archived public repository", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{AllowedRepositoryVisibilities: []string{"private"}}
+		repo := newRepository(true)
+		repo.Archived = true
+		assert.Nil(t, repo.ValidateVisibility(&repoconfig))
+	})
+
+	t.Run("not happy path: unknown visibility in goliac.yaml", func(t *testing.T) {
+		repoconfig := config.RepositoryConfig{AllowedRepositoryVisibilities: []string{"secret"}}
+		assert.NotNil(t, newRepository(false).ValidateVisibility(&repoconfig))
+	})
+}
+
+func TestRepositoryCustomRoles(t *testing.T) {
+	teams := map[string]*Team{
+		"team1": {},
+	}
+
+	t.Run("happy path: custom role granted to an existing team", func(t *testing.T) {
+		repo := Repository{}
+		repo.ApiVersion = "v1"
+		repo.Kind = "Repository"
+		repo.Name = "repo1"
+		repo.Spec.CustomRoles = map[string]string{"team1": "security-reviewer"}
+
+		assert.Nil(t, repo.Validate("teams/team1/repo1.yaml", teams, map[string]*User{}))
+	})
+
+	t.Run("not happy path: unknown team", func(t *testing.T) {
+		repo := Repository{}
+		repo.ApiVersion = "v1"
+		repo.Kind = "Repository"
+		repo.Name = "repo1"
+		repo.Spec.CustomRoles = map[string]string{"team2": "security-reviewer"}
+
+		assert.NotNil(t, repo.Validate("teams/team1/repo1.yaml", teams, map[string]*User{}))
+	})
+
+	t.Run("not happy path: empty role", func(t *testing.T) {
+		repo := Repository{}
+		repo.ApiVersion = "v1"
+		repo.Kind = "Repository"
+		repo.Name = "repo1"
+		repo.Spec.CustomRoles = map[string]string{"team1": ""}
+
+		assert.NotNil(t, repo.Validate("teams/team1/repo1.yaml", teams, map[string]*User{}))
+	})
 }
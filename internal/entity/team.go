@@ -3,20 +3,25 @@ package entity
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
+	"github.com/gosimple/slug"
 	"gopkg.in/yaml.v3"
 )
 
 type Team struct {
 	Entity `yaml:",inline"`
 	Spec   struct {
-		ExternallyManaged bool     `yaml:"externallyManaged,omitempty"`
-		Owners            []string `yaml:"owners,omitempty"`
-		Members           []string `yaml:"members,omitempty"`
+		ExternallyManaged   bool     `yaml:"externallyManaged,omitempty"`
+		ExternalGroups      []string `yaml:"externalGroups,omitempty"`      // IdP groups (Github team synchronization) providing the members
+		Privacy             string   `yaml:"privacy,omitempty"`             // secret or closed. Not managed if empty
+		NotificationSetting string   `yaml:"notificationSetting,omitempty"` // notifications_enabled or notifications_disabled. Not managed if empty
+		Owners              []string `yaml:"owners,omitempty"`
+		Members             []string `yaml:"members,omitempty"`
 	} `yaml:"spec"`
 	ParentTeam *string `yaml:"parentTeam,omitempty"`
 }
@@ -81,20 +86,101 @@ func ReadTeamDirectory(fs billy.Filesystem, dirname string, users map[string]*Us
 
 		recursiveReadTeamDirectory(fs, filepath.Join(dirname, e.Name()), nil, users, teams, &errors, &warning)
 	}
+
+	errors = append(errors, validateParentTeams(teams)...)
+	errors = append(errors, validateTeamSlugs(teams)...)
 	return teams, errors, warning
 }
 
+/*
+ * validateTeamSlugs checks that 2 teams don't have the same Github slug
+ * (derived from the team name, like "Team A" and "team-a")
+ */
+func validateTeamSlugs(teams map[string]*Team) []error {
+	errors := []error{}
+
+	teamnames := make([]string, 0, len(teams))
+	for teamname := range teams {
+		teamnames = append(teamnames, teamname)
+	}
+	sort.Strings(teamnames)
+
+	slugs := make(map[string]string)
+	for _, teamname := range teamnames {
+		teamslug := slug.Make(teamname)
+		if other, ok := slugs[teamslug]; ok {
+			errors = append(errors, NewEntityError("team", teamname, "", fmt.Errorf("invalid name: the teams %s and %s have the same Github slug %s", other, teamname, teamslug)))
+			continue
+		}
+		slugs[teamslug] = teamname
+	}
+
+	return errors
+}
+
+/*
+ * validateParentTeams checks that the parent teams (that can be declared
+ * with parentTeam) exist, and that there is no cycle in the teams hierarchy
+ */
+func validateParentTeams(teams map[string]*Team) []error {
+	errors := []error{}
+
+	teamnames := make([]string, 0, len(teams))
+	for teamname := range teams {
+		teamnames = append(teamnames, teamname)
+	}
+	sort.Strings(teamnames)
+
+	for _, teamname := range teamnames {
+		if parent := teams[teamname].ParentTeam; parent != nil {
+			if _, ok := teams[*parent]; !ok {
+				errors = append(errors, NewEntityError("team", teamname, "", fmt.Errorf("invalid parentTeam: %s doesn't exist for team %s", *parent, teamname)))
+			} else if teams[*parent].Spec.Privacy == "secret" {
+				// Github: a secret team cannot be nested
+				errors = append(errors, NewEntityError("team", teamname, "", fmt.Errorf("invalid parentTeam: %s is a secret team and cannot be the parent of team %s", *parent, teamname)))
+			}
+		}
+	}
+
+	inCycle := make(map[string]bool)
+	for _, teamname := range teamnames {
+		path := []string{}
+		visited := make(map[string]int) // index in the path
+		current := teamname
+		for !inCycle[current] {
+			if i, ok := visited[current]; ok {
+				cycle := append(path[i:], current)
+				for _, t := range path[i:] {
+					inCycle[t] = true
+				}
+				errors = append(errors, NewEntityError("team", cycle[0], "", fmt.Errorf("invalid parentTeam: cycle detected between the teams %s", strings.Join(cycle, " -> "))))
+				break
+			}
+			visited[current] = len(path)
+			path = append(path, current)
+
+			team, ok := teams[current]
+			if !ok || team.ParentTeam == nil {
+				break
+			}
+			current = *team.ParentTeam
+		}
+	}
+
+	return errors
+}
+
 func recursiveReadTeamDirectory(fs billy.Filesystem, dirname string, parentTeam *string, users map[string]*User, teams map[string]*Team, errors *[]error, warning *[]Warning) {
 
 	team, err := NewTeam(fs, filepath.Join(dirname, "team.yaml"), parentTeam)
 	if err != nil {
-		*errors = append(*errors, err)
+		*errors = append(*errors, NewEntityError("team", filepath.Base(dirname), filepath.Join(dirname, "team.yaml"), err))
 		return
 	} else {
 		err, warns := team.Validate(dirname, users)
 		*warning = append(*warning, warns...)
 		if err != nil {
-			*errors = append(*errors, err)
+			*errors = append(*errors, NewEntityError("team", team.Name, filepath.Join(dirname, "team.yaml"), err))
 			return
 		} else {
 			teams[team.Name] = team
@@ -119,7 +205,7 @@ func recursiveReadTeamDirectory(fs billy.Filesystem, dirname string, parentTeam
 			continue
 		}
 		if _, ok := teams[e.Name()]; ok {
-			*errors = append(*errors, fmt.Errorf("team %s already exists in %s", e.Name(), dirname))
+			*errors = append(*errors, NewEntityError("team", e.Name(), filepath.Join(dirname, e.Name(), "team.yaml"), fmt.Errorf("team %s already exists in %s", e.Name(), dirname)))
 			continue
 		}
 
@@ -155,13 +241,35 @@ func (t *Team) Validate(dirname string, users map[string]*User) (error, []Warnin
 		return fmt.Errorf("invalid metadata.name: %s for team filename %s/team.yaml", t.Name, dirname), warnings
 	}
 
+	// the owners of an externallyManaged team are still managed (in its -goliac-owners team)
 	if t.Spec.ExternallyManaged {
-		if len(t.Spec.Owners) > 0 {
-			return fmt.Errorf("externallyManaged team cannot have owners for team filename %s/team.yaml", dirname), warnings
-		}
 		if len(t.Spec.Members) > 0 {
 			return fmt.Errorf("externallyManaged team cannot have members for team filename %s/team.yaml", dirname), warnings
 		}
+		if len(t.Spec.ExternalGroups) > 0 {
+			return fmt.Errorf("externallyManaged team cannot have externalGroups for team filename %s/team.yaml", dirname), warnings
+		}
+		if t.Spec.Privacy != "" || t.Spec.NotificationSetting != "" {
+			return fmt.Errorf("externallyManaged team cannot have privacy nor notificationSetting for team filename %s/team.yaml", dirname), warnings
+		}
+	}
+
+	if len(t.Spec.ExternalGroups) > 0 && len(t.Spec.Members) > 0 {
+		return fmt.Errorf("team with externalGroups cannot have members (they come from the external groups) for team filename %s/team.yaml", dirname), warnings
+	}
+
+	switch t.Spec.Privacy {
+	case "", "closed":
+	case "secret":
+		if t.ParentTeam != nil {
+			return fmt.Errorf("a secret team cannot have a parent team for team filename %s/team.yaml", dirname), warnings
+		}
+	default:
+		return fmt.Errorf("invalid privacy: %s (can be secret or closed) for team filename %s/team.yaml", t.Spec.Privacy, dirname), warnings
+	}
+
+	if t.Spec.NotificationSetting != "" && t.Spec.NotificationSetting != "notifications_enabled" && t.Spec.NotificationSetting != "notifications_disabled" {
+		return fmt.Errorf("invalid notificationSetting: %s (can be notifications_enabled or notifications_disabled) for team filename %s/team.yaml", t.Spec.NotificationSetting, dirname), warnings
 	}
 
 	for _, owner := range t.Spec.Owners {
@@ -148,6 +148,168 @@ name: team2
 		assert.NotNil(t, teams)
 	})
 
+	t.Run("happy path: team with external groups", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  externalGroups:
+  - okta-team1
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+		assert.Equal(t, []string{"okta-team1"}, teams["team1"].Spec.ExternalGroups)
+	})
+
+	t.Run("happy path: externally managed team with owners", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  externallyManaged: true
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+		assert.Equal(t, []string{"user1", "user2"}, teams["team1"].Spec.Owners)
+	})
+
+	t.Run("not happy path: team with external groups and members", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  externalGroups:
+  - okta-team1
+  owners:
+  - user1
+  members:
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, errs, warns := ReadUserDirectory(fs, "users")
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+
+		_, errs, _ = ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "team with externalGroups cannot have members (they come from the external groups) for team filename teams/team1/team.yaml", errs[0].Error())
+	})
+
+	t.Run("happy path: team with privacy and notification setting", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  privacy: secret
+  notificationSetting: notifications_disabled
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+
+		teams, errs, warns := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, len(errs), 0)
+		assert.Equal(t, len(warns), 0)
+		assert.Equal(t, "secret", teams["team1"].Spec.Privacy)
+		assert.Equal(t, "notifications_disabled", teams["team1"].Spec.NotificationSetting)
+	})
+
+	t.Run("not happy path: invalid privacy", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  privacy: public
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid privacy: public (can be secret or closed) for team filename teams/team1/team.yaml", errs[0].Error())
+	})
+
+	t.Run("not happy path: secret sub team", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fs.MkdirAll("teams/team1/team2", 0755)
+
+		err := utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		err = utils.WriteFile(fs, "teams/team1/team2/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team2
+spec:
+  privacy: secret
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+		users, _, _ := ReadUserDirectory(fs, "users")
+
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "a secret team cannot have a parent team for team filename teams/team1/team2/team.yaml", errs[0].Error())
+	})
+
 	t.Run("not happy path: not able to create a sub team without a defined parent", func(t *testing.T) {
 		// create a new user
 		fs := memfs.New()
@@ -276,6 +438,114 @@ spec:
 	})
 }
 
+func fixtureCreateTopLevelTeam(t *testing.T, fs billy.Filesystem, teamname string, parent string) {
+	fs.MkdirAll("teams/"+teamname, 0755)
+	err := utils.WriteFile(fs, "teams/"+teamname+"/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: `+teamname+`
+spec:
+  owners:
+  - user1
+  - user2
+parentTeam: `+parent+`
+`), 0644)
+	assert.Nil(t, err)
+}
+
+func TestTeamParentTeam(t *testing.T) {
+
+	t.Run("happy path: declared parent team", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fixtureCreateTopLevelTeam(t, fs, "teamA", "teamB")
+		fixtureCreateTopLevelTeam(t, fs, "teamB", "teamC")
+		fixtureCreateTopLevelTeam(t, fs, "teamC", "null")
+
+		users, _, _ := ReadUserDirectory(fs, "users")
+		teams, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, "teamB", *teams["teamA"].ParentTeam)
+		assert.Nil(t, teams["teamC"].ParentTeam)
+	})
+
+	t.Run("not happy path: two teams cycle", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fixtureCreateTopLevelTeam(t, fs, "teamA", "teamB")
+		fixtureCreateTopLevelTeam(t, fs, "teamB", "teamA")
+
+		users, _, _ := ReadUserDirectory(fs, "users")
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid parentTeam: cycle detected between the teams teamA -> teamB -> teamA", errs[0].Error())
+	})
+
+	t.Run("not happy path: three teams cycle", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fixtureCreateTopLevelTeam(t, fs, "teamA", "teamB")
+		fixtureCreateTopLevelTeam(t, fs, "teamB", "teamC")
+		fixtureCreateTopLevelTeam(t, fs, "teamC", "teamA")
+		// outside of the cycle, but pointing to it
+		fixtureCreateTopLevelTeam(t, fs, "teamD", "teamA")
+
+		users, _, _ := ReadUserDirectory(fs, "users")
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid parentTeam: cycle detected between the teams teamA -> teamB -> teamC -> teamA", errs[0].Error())
+	})
+
+	t.Run("not happy path: missing parent team", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fixtureCreateTopLevelTeam(t, fs, "teamA", "unknown")
+
+		users, _, _ := ReadUserDirectory(fs, "users")
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid parentTeam: unknown doesn't exist for team teamA", errs[0].Error())
+	})
+
+	t.Run("not happy path: secret parent team", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fixtureCreateTopLevelTeam(t, fs, "teamA", "teamB")
+		fs.MkdirAll("teams/teamB", 0755)
+		err := utils.WriteFile(fs, "teams/teamB/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: teamB
+spec:
+  privacy: secret
+  owners:
+  - user1
+  - user2
+`), 0644)
+		assert.Nil(t, err)
+
+		users, _, _ := ReadUserDirectory(fs, "users")
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid parentTeam: teamB is a secret team and cannot be the parent of team teamA", errs[0].Error())
+	})
+}
+
+func TestTeamSlug(t *testing.T) {
+
+	t.Run("not happy path: 2 teams with the same slug", func(t *testing.T) {
+		fs := memfs.New()
+		fixtureCreateUser(t, fs)
+		fixtureCreateTopLevelTeam(t, fs, "team A", "null")
+		fixtureCreateTopLevelTeam(t, fs, "team-a", "null")
+
+		users, _, _ := ReadUserDirectory(fs, "users")
+		_, errs, _ := ReadTeamDirectory(fs, "teams", users)
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "invalid name: the teams team A and team-a have the same Github slug team-a", errs[0].Error())
+	})
+}
+
 func TestAdjustTeam(t *testing.T) {
 	t.Run("happy path: no change ", func(t *testing.T) {
 		team := Team{}
@@ -74,13 +74,14 @@ func ReadUserDirectory(fs billy.Filesystem, dirname string) (map[string]*User, [
 		if !strings.HasSuffix(e.Name(), ".yaml") {
 			continue
 		}
-		user, err := NewUser(fs, filepath.Join(dirname, e.Name()))
+		filename := filepath.Join(dirname, e.Name())
+		user, err := NewUser(fs, filename)
 		if err != nil {
-			errors = append(errors, err)
+			errors = append(errors, NewEntityError("user", strings.TrimSuffix(e.Name(), ".yaml"), filename, err))
 		} else {
-			err = user.Validate(filepath.Join(dirname, e.Name()))
+			err = user.Validate(filename)
 			if err != nil {
-				errors = append(errors, err)
+				errors = append(errors, NewEntityError("user", user.Name, filename, err))
 			} else {
 				users[user.Name] = user
 			}
@@ -0,0 +1,237 @@
+package entity
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * FileError is an error found in a single yaml file. Line is 0 if the
+ * error cannot be located in the file
+ */
+type FileError struct {
+	Filename string
+	Line     int
+	Message  string
+}
+
+func (e *FileError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Filename, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.Filename, e.Line, e.Message)
+}
+
+var yamlLineRegex = regexp.MustCompile(`line (\d+): (.*)`)
+var yamlUnknownFieldRegex = regexp.MustCompile(`^field (\S+) not found in type .*`)
+
+// the field (and the value) an error message is about, like 'invalid writer: team1 doesn't exist'
+var validationFieldRegex = regexp.MustCompile(`^(?:invalid\s+)?(?:spec\.|metadata\.)?([A-Za-z_]+)(?:[^:]*:\s+([^\s(]+))?`)
+
+/*
+ * ValidateFile validates a single entity file (User, Team, Repository, RuleSet,
+ * OrgVariables or OrgSecrets) without the rest of the teams directory:
+ * - the apiVersion and the kind
+ * - the unknown fields
+ * - the constraints that can be checked in isolation (the references to
+ *   other users, teams or repositories are not checked)
+ */
+func ValidateFile(filename string, content []byte) ([]error, []Warning) {
+	e := &Entity{}
+	if err := yaml.Unmarshal(content, e); err != nil {
+		return yamlFileErrors(filename, err), nil
+	}
+	if e.ApiVersion != "v1" {
+		return []error{locateFileError(filename, content, fmt.Errorf("invalid apiVersion: %s", e.ApiVersion))}, nil
+	}
+
+	var errs []error
+	var warns []Warning
+	switch e.Kind {
+	case "User":
+		user := &User{}
+		if err := decodeKnownFields(content, user); err != nil {
+			return yamlFileErrors(filename, err), nil
+		}
+		if err := user.Validate(filename); err != nil {
+			errs = append(errs, err)
+		}
+	case "Team":
+		team := &Team{}
+		if err := decodeKnownFields(content, team); err != nil {
+			return yamlFileErrors(filename, err), nil
+		}
+		// the owners and members are checked against the users directory
+		users := make(map[string]*User)
+		for _, u := range append(team.Spec.Owners, team.Spec.Members...) {
+			users[u] = &User{}
+		}
+		err, w := team.Validate(filepath.Dir(filename), users)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		warns = append(warns, w...)
+	case "Repository":
+		repository := &Repository{}
+		if err := decodeKnownFields(content, repository); err != nil {
+			return yamlFileErrors(filename, err), nil
+		}
+		// the teams and the external users are checked against their directories
+		teams := make(map[string]*Team)
+		for _, t := range append(repository.Spec.Writers, repository.Spec.Readers...) {
+			teams[t] = &Team{}
+		}
+		for t := range repository.Spec.CustomRoles {
+			teams[t] = &Team{}
+		}
+		externalUsers := make(map[string]*User)
+		for _, u := range append(repository.Spec.ExternalUserReaders, repository.Spec.ExternalUserWriters...) {
+			externalUsers[u] = &User{}
+		}
+		if err := repository.Validate(filename, teams, externalUsers); err != nil {
+			errs = append(errs, err)
+		}
+	case "Ruleset":
+		ruleset := &RuleSet{}
+		if err := decodeKnownFields(content, ruleset); err != nil {
+			return yamlFileErrors(filename, err), nil
+		}
+		if err := ruleset.Validate(filename); err != nil {
+			errs = append(errs, err)
+		}
+	case "OrgVariables":
+		variables := &OrgVariables{}
+		if err := decodeKnownFields(content, variables); err != nil {
+			return yamlFileErrors(filename, err), nil
+		}
+		// the selected repositories are checked against the teams directory
+		repositories := make(map[string]*Repository)
+		for _, v := range variables.Spec.Variables {
+			for _, r := range v.SelectedRepositories {
+				repositories[r] = &Repository{}
+			}
+		}
+		errs, warns = variables.Validate(filename, repositories)
+	case "OrgSecrets":
+		secrets := &OrgSecrets{}
+		if err := decodeKnownFields(content, secrets); err != nil {
+			return yamlFileErrors(filename, err), nil
+		}
+		repositories := make(map[string]*Repository)
+		for _, s := range secrets.Spec.Secrets {
+			for _, r := range s.SelectedRepositories {
+				repositories[r] = &Repository{}
+			}
+		}
+		errs, warns = secrets.Validate(filename, repositories)
+	default:
+		return []error{locateFileError(filename, content, fmt.Errorf("invalid kind: %s (must be User, Team, Repository, Ruleset, OrgVariables or OrgSecrets)", e.Kind))}, nil
+	}
+
+	fileErrs := []error{}
+	for _, err := range errs {
+		fileErrs = append(fileErrs, locateFileError(filename, content, err))
+	}
+	return fileErrs, warns
+}
+
+/*
+ * decodeKnownFields unmarshals the yaml content, and fails on the fields
+ * not defined in the entity
+ */
+func decodeKnownFields(content []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	err := decoder.Decode(out)
+	if err == io.EOF {
+		return fmt.Errorf("empty file")
+	}
+	return err
+}
+
+/*
+ * yamlFileErrors returns one FileError per line reported by the yaml parser
+ */
+func yamlFileErrors(filename string, err error) []error {
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+
+	errs := []error{}
+	for _, message := range messages {
+		fileErr := &FileError{Filename: filename, Message: message}
+		if m := yamlLineRegex.FindStringSubmatch(message); m != nil {
+			fileErr.Line, _ = strconv.Atoi(m[1])
+			fileErr.Message = yamlUnknownFieldRegex.ReplaceAllString(m[2], "unknown field $1")
+		}
+		errs = append(errs, fileErr)
+	}
+	return errs
+}
+
+/*
+ * locateFileError finds (if possible) the line of the field, or of the value,
+ * a validation error is about
+ */
+func locateFileError(filename string, content []byte, err error) error {
+	fileErr := &FileError{Filename: filename, Message: err.Error()}
+
+	var root yaml.Node
+	if yaml.Unmarshal(content, &root) != nil {
+		return fileErr
+	}
+	m := validationFieldRegex.FindStringSubmatch(err.Error())
+	if m == nil {
+		return fileErr
+	}
+
+	for _, key := range []string{m[1], m[1] + "s"} {
+		keyNode, valueNode := findYamlKey(&root, key)
+		if keyNode == nil {
+			continue
+		}
+		fileErr.Line = keyNode.Line
+		if m[2] != "" {
+			if valueNode := findYamlScalar(valueNode, m[2]); valueNode != nil {
+				fileErr.Line = valueNode.Line
+			}
+		}
+		break
+	}
+	return fileErr
+}
+
+func findYamlKey(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i], node.Content[i+1]
+			}
+		}
+	}
+	for _, child := range node.Content {
+		if k, v := findYamlKey(child, key); k != nil {
+			return k, v
+		}
+	}
+	return nil, nil
+}
+
+func findYamlScalar(node *yaml.Node, value string) *yaml.Node {
+	if node.Kind == yaml.ScalarNode && node.Value == value {
+		return node
+	}
+	for _, child := range node.Content {
+		if found := findYamlScalar(child, value); found != nil {
+			return found
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateFile(t *testing.T) {
+
+	t.Run("happy path: a repository referring to unknown teams", func(t *testing.T) {
+		errs, warns := ValidateFile("teams/team1/repo1.yaml", []byte(`apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+    - team2
+  externalUserReaders:
+    - external1
+`))
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 0, len(warns))
+	})
+
+	t.Run("happy path: a team with a single owner", func(t *testing.T) {
+		errs, warns := ValidateFile("teams/team1/team.yaml", []byte(`apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+    - user1
+`))
+		assert.Equal(t, 0, len(errs))
+		assert.Equal(t, 1, len(warns))
+	})
+
+	t.Run("not happy path: wrong apiVersion", func(t *testing.T) {
+		errs, _ := ValidateFile("users/user1.yaml", []byte(`kind: User
+apiVersion: v2
+name: user1
+spec:
+  githubID: github1
+`))
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "users/user1.yaml:2: invalid apiVersion: v2", errs[0].Error())
+	})
+
+	t.Run("not happy path: unknown kind", func(t *testing.T) {
+		errs, _ := ValidateFile("workflows/workflow1.yaml", []byte(`apiVersion: v1
+kind: Workflow
+name: workflow1
+`))
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "workflows/workflow1.yaml:2: invalid kind: Workflow (must be User, Team, Repository, Ruleset, OrgVariables or OrgSecrets)", errs[0].Error())
+	})
+
+	t.Run("not happy path: unknown fields", func(t *testing.T) {
+		errs, _ := ValidateFile("teams/team1/repo1.yaml", []byte(`apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writer:
+    - team2
+  public: true
+  visibility: private
+`))
+		assert.Equal(t, 2, len(errs))
+		assert.Equal(t, "teams/team1/repo1.yaml:5: unknown field writer", errs[0].Error())
+		assert.Equal(t, "teams/team1/repo1.yaml:8: unknown field visibility", errs[1].Error())
+	})
+
+	t.Run("not happy path: the error is located on the invalid value", func(t *testing.T) {
+		errs, _ := ValidateFile("teams/team1/repo1.yaml", []byte(`apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  labels:
+    - name: bug
+      color: d73a4a
+    - name: feature
+      color: blue
+`))
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "teams/team1/repo1.yaml:9: invalid labels color: blue for label feature (must be a 6 characters hexadecimal color code, without '#') in repository filename repo1.yaml", errs[0].Error())
+	})
+
+	t.Run("not happy path: the team name doesn't match its directory", func(t *testing.T) {
+		errs, _ := ValidateFile("teams/team1/team.yaml", []byte(`apiVersion: v1
+kind: Team
+name: team2
+`))
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "teams/team1/team.yaml:3: invalid metadata.name: team2 for team filename teams/team1/team.yaml", errs[0].Error())
+	})
+
+	t.Run("not happy path: invalid yaml", func(t *testing.T) {
+		errs, _ := ValidateFile("users/user1.yaml", []byte(`apiVersion: v1
+kind: User
+  name: user1
+`))
+		assert.Equal(t, 1, len(errs))
+		assert.Equal(t, "users/user1.yaml:3: mapping values are not allowed in this context", errs[0].Error())
+	})
+}
@@ -6,15 +6,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/metrics"
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/sirupsen/logrus"
 )
@@ -24,6 +27,8 @@ type GitHubClient interface {
 	CallRestAPI(ctx context.Context, endpoint, method string, body map[string]interface{}) ([]byte, error)
 	GetAccessToken(ctx context.Context) (string, error)
 	GetAppSlug() string
+	// the rate limits (per resource) reported by the last API responses
+	GetRateLimits() map[string]RateLimit
 }
 
 type GitHubClientImpl struct {
@@ -34,8 +39,26 @@ type GitHubClientImpl struct {
 	privateKey      []byte
 	accessToken     string
 	httpClient      *http.Client
+	transport       http.RoundTripper // proxy and CA aware transport, used under the AuthorizedTransport
 	tokenExpiration time.Time
-	mu              sync.Mutex
+	mu              sync.Mutex       // protects the access token
+	refreshWindow   time.Duration    // the access token is renewed when it expires in less than this window
+	now             func() time.Time // time.Now, except in tests
+	maxRetries      int              // how many times a rate limited request is retried
+	retryBaseDelay  time.Duration    // base delay of the exponential backoff
+	retryMaxDelay   time.Duration    // cap of the delay before a retry (0 means no cap)
+
+	conditionalRequests bool                       // send If-None-Match on REST GET calls
+	etagCache           map[string]*etagCacheEntry // key is the url
+	etagMutex           sync.Mutex
+
+	rateLimits      map[string]RateLimit // resource (core, graphql, ...) -> last rate limit
+	rateLimitsMutex sync.Mutex
+}
+
+type etagCacheEntry struct {
+	etag string
+	body []byte
 }
 
 type AuthorizedTransport struct {
@@ -43,27 +66,14 @@ type AuthorizedTransport struct {
 }
 
 func (t *AuthorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	t.client.mu.Lock()
-	defer t.client.mu.Unlock()
-
-	// Refresh the access token if necessary
-	if t.client.accessToken == "" || time.Until(t.client.tokenExpiration) < 5*time.Minute {
-		token, err := t.client.createJWT()
-		if err != nil {
-			return nil, err
-		}
-
-		accessToken, expiresAt, err := t.client.getAccessTokenForInstallation(req.Context(), token)
-		if err != nil {
-			return nil, err
-		}
-		t.client.accessToken = accessToken
-		t.client.tokenExpiration = expiresAt
+	accessToken, err := t.client.GetAccessToken(req.Context())
+	if err != nil {
+		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+t.client.accessToken)
+	req.Header.Add("Authorization", "Bearer "+accessToken)
 
-	return http.DefaultTransport.RoundTrip(req)
+	return t.client.baseTransport().RoundTrip(req)
 }
 
 /**
@@ -90,11 +100,23 @@ func NewGitHubClientImpl(githubServer, organizationName string, appID int64, pri
 	}
 
 	client := &GitHubClientImpl{
-		gitHubServer: githubServer,
-		appID:        appID,
-		privateKey:   privateKey,
+		gitHubServer:        githubServer,
+		appID:               appID,
+		privateKey:          privateKey,
+		maxRetries:          config.Config.GithubMaxRetries,
+		retryBaseDelay:      time.Duration(config.Config.GithubRetryBaseDelay) * time.Millisecond,
+		retryMaxDelay:       time.Duration(config.Config.GithubRetryMaxDelay) * time.Millisecond,
+		conditionalRequests: config.Config.GithubConditionalRequests,
+		etagCache:           make(map[string]*etagCacheEntry),
+		refreshWindow:       time.Duration(config.Config.GithubTokenRefreshWindow) * time.Second,
 	}
 
+	transport, err := newHTTPTransport(config.Config.GithubCACert)
+	if err != nil {
+		return nil, err
+	}
+	client.transport = transport
+
 	// create JWT
 	token, err := client.createJWT()
 	if err != nil {
@@ -121,41 +143,182 @@ func NewGitHubClientImpl(githubServer, organizationName string, appID int64, pri
 		return nil, fmt.Errorf("installation not found for organization: %s", organizationName)
 	}
 
-	transport := &AuthorizedTransport{
+	httpClient := &http.Client{Transport: &AuthorizedTransport{
 		client: client,
-	}
-
-	httpClient := &http.Client{Transport: transport}
+	}}
 
 	client.httpClient = httpClient
 
 	return client, nil
 }
 
-// waitRateLimit helps dealing with rate limits
+// rateLimitResetDelay helps dealing with rate limits
 // cf https://docs.github.com/en/rest/guides/best-practices-for-integrators?apiVersion=2022-11-28#dealing-with-rate-limits
-func waitRateLimit(resetTimeStr string) error {
+func rateLimitResetDelay(resetTimeStr string) (time.Duration, error) {
 	if resetTimeStr == "" {
-		return fmt.Errorf("X-RateLimit-Reset header not found")
+		return 0, fmt.Errorf("X-RateLimit-Reset header not found")
 	}
 
-	logrus.Infof("Rate limit exceeded, waiting for %s", resetTimeStr)
-
 	// Parse the reset time.
 	resetTimeUnix, err := strconv.ParseInt(resetTimeStr, 10, 64)
 	if err != nil {
-		return fmt.Errorf("failed to parse X-RateLimit-Reset header: %w", err)
+		return 0, fmt.Errorf("failed to parse X-RateLimit-Reset header: %w", err)
 	}
 
 	resetTime := time.Unix(resetTimeUnix, 0)
 
 	// Calculate how long we need to wait.
-	waitDuration := time.Until(resetTime)
+	return time.Until(resetTime), nil
+}
+
+/*
+ * isRateLimited returns true if Github asks us to slow down
+ * - HTTP 429
+ * - HTTP 403 with rate limit headers or a secondary rate limit message
+ * - GraphQL errors with the RATE_LIMITED type (returned with a HTTP 200)
+ */
+func isRateLimited(resp *http.Response, body []byte) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			return true
+		}
+		return strings.Contains(strings.ToLower(string(body)), "rate limit")
+	}
+	if resp.StatusCode == http.StatusOK && bytes.Contains(body, []byte("RATE_LIMITED")) {
+		var gErrors struct {
+			Errors []struct {
+				Type       string `json:"type"`
+				Extensions struct {
+					Code string `json:"code"`
+				} `json:"extensions"`
+			} `json:"errors"`
+		}
+		if err := json.Unmarshal(body, &gErrors); err == nil {
+			for _, e := range gErrors.Errors {
+				if e.Type == "RATE_LIMITED" || e.Extensions.Code == "RATE_LIMITED" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+/*
+ * primaryRateLimitDelay returns how long to wait for the reset of the primary
+ * rate limit (no request left until X-RateLimit-Reset). It returns false for
+ * the secondary (abuse) rate limits
+ */
+func (client *GitHubClientImpl) primaryRateLimitDelay(resp *http.Response) (time.Duration, bool) {
+	if resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	delay, err := rateLimitResetDelay(resp.Header.Get("X-RateLimit-Reset"))
+	if err != nil {
+		return 0, false
+	}
+	// the reset time may be (slightly) in the past
+	if delay < client.retryBaseDelay {
+		delay = client.retryBaseDelay
+	}
+	return delay, true
+}
+
+/*
+ * retryDelay returns how long to wait before retrying a request hitting a secondary
+ * rate limit: the Retry-After header if present, else a jittered exponential backoff.
+ * The delay is capped by retryMaxDelay
+ */
+func (client *GitHubClientImpl) retryDelay(resp *http.Response, attempt int) time.Duration {
+	delay := client.requestedRetryDelay(resp, attempt)
+	if delay < 0 {
+		delay = 0
+	}
+	if client.retryMaxDelay > 0 && delay > client.retryMaxDelay {
+		delay = client.retryMaxDelay
+	}
+	return delay
+}
+
+func (client *GitHubClientImpl) requestedRetryDelay(resp *http.Response, attempt int) time.Duration {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		// Retry-After can also be a HTTP date
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return time.Until(date)
+		}
+	}
+
+	backoff := client.retryBaseDelay * time.Duration(1<<attempt)
+	jitter := time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+	return backoff + jitter
+}
+
+/*
+ * doWithRetry sends the request (created via newRequest at each attempt)
+ * and retries it as long as Github rate limits us:
+ * - on the primary rate limit, it waits until X-RateLimit-Reset (not capped, and
+ *   not counted in maxRetries: all the requests are refused until then)
+ * - on a secondary rate limit, it retries up to maxRetries times (cf retryDelay)
+ */
+func (client *GitHubClientImpl) doWithRetry(ctx context.Context, newRequest func() (*http.Request, error)) (*http.Response, []byte, error) {
+	stats := ctx.Value(config.ContextKeyStatistics)
+
+	for attempt := 0; ; {
+		req, err := newRequest()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if stats != nil {
+			goliacStats := stats.(*config.GoliacStatistics)
+			atomic.AddInt64(&goliacStats.GithubApiCalls, 1)
+		}
+		metrics.GithubApiCallsTotal.Inc(req.Method)
+
+		resp, err := client.httpClient.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		client.recordRateLimit(resp.Header)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !isRateLimited(resp, body) {
+			return resp, body, nil
+		}
 
-	// Wait until the reset time.
-	time.Sleep(waitDuration)
+		delay, primary := client.primaryRateLimitDelay(resp)
+		if primary {
+			logrus.Infof("Github rate limit exceeded on %s %s, waiting for its reset in %v", req.Method, req.URL.Path, delay)
+		} else {
+			if attempt >= client.maxRetries {
+				return resp, body, nil
+			}
+			delay = client.retryDelay(resp, attempt)
+			attempt++
+			logrus.Debugf("Github secondary rate limit reached on %s %s (status %d), retrying in %v (%d/%d)", req.Method, req.URL.Path, resp.StatusCode, delay, attempt, client.maxRetries)
+		}
 
-	return nil
+		if stats != nil {
+			goliacStats := stats.(*config.GoliacStatistics)
+			atomic.AddInt64(&goliacStats.GithubThrottled, 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
 }
 
 type GraphQLRequest struct {
@@ -193,63 +356,26 @@ func (client *GitHubClientImpl) QueryGraphQLAPI(ctx context.Context, query strin
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", client.gitHubServer+"/graphql", bytes.NewBuffer(body))
+	resp, responseBody, err := client.doWithRetry(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", client.gitHubServer+"/graphql", bytes.NewBuffer(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-
-	stats := ctx.Value(config.ContextKeyStatistics)
-	if stats != nil {
-		goliacStats := stats.(*config.GoliacStatistics)
-		goliacStats.GithubApiCalls++
+	if isRateLimited(resp, responseBody) {
+		return nil, fmt.Errorf("rate limit still reached after %d retries: %s", client.maxRetries, resp.Status)
 	}
-
-	resp, err := client.httpClient.Do(req)
-	if err != nil {
-		return nil, err
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
 	}
-	defer resp.Body.Close()
-
-	// fmt.Println(string(body))
-	// fmt.Println(resp.StatusCode)
-	// for k, v := range resp.Header {
-	// 	fmt.Println(k, v)
-	// }
-
-	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden {
-		if stats != nil {
-			goliacStats := stats.(*config.GoliacStatistics)
-			goliacStats.GithubThrottled++
-		}
-
-		if resp.Header.Get("X-RateLimit-Reset") != "" {
-			// We're being rate limited. Get the reset time from the headers.
-			if err := waitRateLimit(resp.Header.Get("X-RateLimit-Reset")); err != nil {
-				return nil, err
-			}
-		} else if resp.Header.Get("Retry-After") != "" {
-			retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
-			if err != nil {
-				return nil, err
-			}
-			logrus.Debugf("2nd rate limit reached, waiting for %d seconds", retryAfter)
-			time.Sleep(time.Duration(retryAfter) * time.Second)
-		} else {
-			return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-		}
-
-		// Retry the request.
-		return client.QueryGraphQLAPI(ctx, query, variables)
-	} else {
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
 
-		return responseBody, nil
-	}
+	return responseBody, nil
 }
 
 /*
@@ -266,61 +392,90 @@ func (client *GitHubClientImpl) QueryGraphQLAPI(ctx context.Context, query strin
  * responseBody, err := client.CallRestAPIWithBody("orgs/my-org/repos", "POST", body)
  */
 func (client *GitHubClientImpl) CallRestAPI(ctx context.Context, endpoint, method string, body map[string]interface{}) ([]byte, error) {
-	var bodyReader io.Reader
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, err
 		}
-		bodyReader = bytes.NewBuffer(jsonBody)
 	}
-	urlpath, err := url.JoinPath(client.gitHubServer, endpoint)
+	// the query string (if any) must not be escaped by url.JoinPath
+	endpointPath, query, hasQuery := strings.Cut(endpoint, "?")
+	urlpath, err := url.JoinPath(client.gitHubServer, endpointPath)
 	if err != nil {
 		return nil, err
 	}
-
-	stats := ctx.Value(config.ContextKeyStatistics)
-	if stats != nil {
-		goliacStats := stats.(*config.GoliacStatistics)
-		goliacStats.GithubApiCalls++
+	if hasQuery {
+		urlpath += "?" + query
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, urlpath, bodyReader)
-	if err != nil {
-		return nil, err
+	conditional := client.conditionalRequests && method == "GET"
+	var cached *etagCacheEntry
+	if conditional {
+		cached = client.getETag(urlpath)
 	}
-	req.Header.Set("Accept", "application/vnd.github+json")
-	//	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-	resp, err := client.httpClient.Do(req)
+
+	resp, responseBody, err := client.doWithRetry(ctx, func() (*http.Request, error) {
+		var bodyReader io.Reader
+		if jsonBody != nil {
+			bodyReader = bytes.NewBuffer(jsonBody)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, urlpath, bodyReader)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		//	req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if cached != nil {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusTooManyRequests {
-		if stats != nil {
+	// the resource didn't change since the last call: a 304 doesn't count
+	// against the primary rate limit (but still carries the rate limit headers)
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		if stats := ctx.Value(config.ContextKeyStatistics); stats != nil {
 			goliacStats := stats.(*config.GoliacStatistics)
-			goliacStats.GithubThrottled++
+			atomic.AddInt64(&goliacStats.GithubNotModified, 1)
 		}
+		logrus.Debugf("%s not modified (remaining rate limit: %s)", endpoint, resp.Header.Get("X-RateLimit-Remaining"))
+		return cached.body, nil
+	}
 
-		// We're being rate limited. Get the reset time from the headers.
-		if err := waitRateLimit(resp.Header.Get("X-RateLimit-Reset")); err != nil {
-			return nil, err
-		}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return responseBody, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
 
-		// Retry the request.
-		return client.CallRestAPI(ctx, endpoint, method, body)
-	} else {
-		responseBody, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			return responseBody, fmt.Errorf("unexpected status: %s", resp.Status)
+	if conditional {
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			client.setETag(urlpath, etag, responseBody)
 		}
+	}
+
+	return responseBody, nil
+}
 
-		return responseBody, nil
+func (client *GitHubClientImpl) getETag(urlpath string) *etagCacheEntry {
+	client.etagMutex.Lock()
+	defer client.etagMutex.Unlock()
+	return client.etagCache[urlpath]
+}
+
+func (client *GitHubClientImpl) setETag(urlpath string, etag string, body []byte) {
+	client.etagMutex.Lock()
+	defer client.etagMutex.Unlock()
+	if client.etagCache == nil {
+		client.etagCache = make(map[string]*etagCacheEntry)
+	}
+	client.etagCache[urlpath] = &etagCacheEntry{
+		etag: etag,
+		body: body,
 	}
 }
 
@@ -347,7 +502,8 @@ func (client *GitHubClientImpl) createJWT() (string, error) {
 }
 
 type AccessTokenResponse struct {
-	Token string `json:"token"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 func (client *GitHubClientImpl) getAccessTokenForInstallation(ctx context.Context, jwt string) (string, time.Time, error) {
@@ -362,10 +518,10 @@ func (client *GitHubClientImpl) getAccessTokenForInstallation(ctx context.Contex
 	stats := ctx.Value(config.ContextKeyStatistics)
 	if stats != nil {
 		goliacStats := stats.(*config.GoliacStatistics)
-		goliacStats.GithubApiCalls++
+		atomic.AddInt64(&goliacStats.GithubApiCalls, 1)
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := (&http.Client{Transport: client.baseTransport()}).Do(req)
 	if err != nil {
 		return "", time.Now(), err
 	}
@@ -381,7 +537,18 @@ func (client *GitHubClientImpl) getAccessTokenForInstallation(ctx context.Contex
 		return "", time.Now(), err
 	}
 
-	return accessTokenResponse.Token, time.Now().Add(1 * time.Hour), nil
+	if accessTokenResponse.ExpiresAt.IsZero() {
+		// installation tokens are valid for 1 hour
+		return accessTokenResponse.Token, client.currentTime().Add(1 * time.Hour), nil
+	}
+	return accessTokenResponse.Token, accessTokenResponse.ExpiresAt, nil
+}
+
+func (client *GitHubClientImpl) currentTime() time.Time {
+	if client.now != nil {
+		return client.now()
+	}
+	return time.Now()
 }
 
 /*
@@ -403,9 +570,11 @@ func (client *GitHubClientImpl) getAccessTokenForInstallation(ctx context.Contex
  *	},
  */
 func (client *GitHubClientImpl) GetAccessToken(ctx context.Context) (string, error) {
-	logrus.Debugf("GetAccessToken(): client.tokenExpiration: %v", client.tokenExpiration)
+	client.mu.Lock()
+	defer client.mu.Unlock()
 
-	if client.accessToken != "" && client.tokenExpiration.After(time.Now()) {
+	// reuse the installation token until it is about to expire
+	if client.accessToken != "" && client.currentTime().Add(client.refreshWindow).Before(client.tokenExpiration) {
 		return client.accessToken, nil
 	}
 
@@ -2,10 +2,22 @@ package github
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/config"
 )
 
 type MockRoundTripper struct {
@@ -47,3 +59,603 @@ func TestQueryGraphQLAPI(t *testing.T) {
 		t.Errorf("expected 'octocat' in the result, got %s", result)
 	}
 }
+
+func TestCallRestAPIQueryString(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/orgs/myorg/members" && r.URL.RawQuery == "per_page=1":
+		case r.URL.Path == "/orgs/myorg/team-sync/groups" && r.URL.Query().Get("q") == "okta team":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[]`))
+	}))
+	defer testServer.Close()
+
+	client := &GitHubClientImpl{
+		gitHubServer: testServer.URL,
+		httpClient:   testServer.Client(),
+	}
+
+	for _, endpoint := range []string{"/orgs/myorg/members?per_page=1", "/orgs/myorg/team-sync/groups?q=okta+team"} {
+		if _, err := client.CallRestAPI(context.TODO(), endpoint, "GET", nil); err != nil {
+			t.Errorf("unexpected error for %s: %v", endpoint, err)
+		}
+	}
+}
+
+func TestRateLimitRetry(t *testing.T) {
+
+	t.Run("happy path: REST call retried after a 429", func(t *testing.T) {
+		nbCalls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nbCalls++
+			if nbCalls < 3 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name": "octocat"}`))
+		}))
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:   testServer.URL,
+			httpClient:     testServer.Client(),
+			maxRetries:     3,
+			retryBaseDelay: time.Millisecond,
+		}
+
+		stats := &config.GoliacStatistics{}
+		ctx := context.WithValue(context.TODO(), config.ContextKeyStatistics, stats)
+		result, err := client.CallRestAPI(ctx, "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(result), "octocat") {
+			t.Errorf("expected 'octocat' in the result, got %s", result)
+		}
+		if nbCalls != 3 || stats.GithubApiCalls != 3 || stats.GithubThrottled != 2 {
+			t.Errorf("expected 3 calls and 2 throttled, got %d calls, %d throttled", nbCalls, stats.GithubThrottled)
+		}
+	})
+
+	t.Run("happy path: GraphQL RATE_LIMITED error retried", func(t *testing.T) {
+		nbCalls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nbCalls++
+			w.WriteHeader(http.StatusOK)
+			if nbCalls == 1 {
+				w.Write([]byte(`{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data": {"user": {"name": "octocat"}}}`))
+		}))
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:   testServer.URL,
+			httpClient:     testServer.Client(),
+			maxRetries:     3,
+			retryBaseDelay: time.Millisecond,
+		}
+
+		result, err := client.QueryGraphQLAPI(context.TODO(), `query { user(login: "octocat") { name } }`, nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(result), "octocat") {
+			t.Errorf("expected 'octocat' in the result, got %s", result)
+		}
+		if nbCalls != 2 {
+			t.Errorf("expected 2 calls, got %d", nbCalls)
+		}
+	})
+
+	t.Run("not happy path: too many retries", func(t *testing.T) {
+		nbCalls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nbCalls++
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit"}`))
+		}))
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:   testServer.URL,
+			httpClient:     testServer.Client(),
+			maxRetries:     2,
+			retryBaseDelay: time.Millisecond,
+		}
+
+		_, err := client.QueryGraphQLAPI(context.TODO(), `query { user(login: "octocat") { name } }`, nil)
+		if err == nil {
+			t.Errorf("expected an error")
+		}
+		if nbCalls != 3 {
+			t.Errorf("expected 3 calls, got %d", nbCalls)
+		}
+	})
+
+	t.Run("happy path: Retry-After capped by the max delay", func(t *testing.T) {
+		nbCalls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nbCalls++
+			if nbCalls == 1 {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message": "You have exceeded a secondary rate limit"}`))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name": "octocat"}`))
+		}))
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:   testServer.URL,
+			httpClient:     testServer.Client(),
+			maxRetries:     3,
+			retryBaseDelay: time.Millisecond,
+			retryMaxDelay:  10 * time.Millisecond,
+		}
+
+		start := time.Now()
+		_, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if nbCalls != 2 {
+			t.Errorf("expected 2 calls, got %d", nbCalls)
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Errorf("expected the Retry-After delay to be capped")
+		}
+	})
+	t.Run("happy path: primary rate limit waited for, even without retries", func(t *testing.T) {
+		nbCalls := 0
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nbCalls++
+			if nbCalls == 1 {
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name": "octocat"}`))
+		}))
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:   testServer.URL,
+			httpClient:     testServer.Client(),
+			maxRetries:     0,
+			retryBaseDelay: time.Millisecond,
+		}
+
+		result, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(result), "octocat") {
+			t.Errorf("expected 'octocat' in the result, got %s", result)
+		}
+		if nbCalls != 2 {
+			t.Errorf("expected 2 calls, got %d", nbCalls)
+		}
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	client := &GitHubClientImpl{
+		retryBaseDelay: time.Second,
+		retryMaxDelay:  time.Minute,
+	}
+
+	t.Run("happy path: Retry-After in seconds", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", "30")
+		if delay := client.retryDelay(resp, 0); delay != 30*time.Second {
+			t.Errorf("expected 30s, got %v", delay)
+		}
+	})
+
+	t.Run("happy path: Retry-After capped", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", "3600")
+		if delay := client.retryDelay(resp, 0); delay != time.Minute {
+			t.Errorf("expected 1m, got %v", delay)
+		}
+	})
+
+	t.Run("happy path: Retry-After as a HTTP date in the past", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("Retry-After", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+		if delay := client.retryDelay(resp, 0); delay != 0 {
+			t.Errorf("expected 0, got %v", delay)
+		}
+	})
+
+	t.Run("happy path: exponential backoff capped", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		if delay := client.retryDelay(resp, 10); delay != time.Minute {
+			t.Errorf("expected 1m, got %v", delay)
+		}
+	})
+
+	t.Run("happy path: primary rate limit reset not capped", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		delay, primary := client.primaryRateLimitDelay(resp)
+		if !primary || delay < 59*time.Minute {
+			t.Errorf("expected to wait for the reset (1h), got %v", delay)
+		}
+	})
+
+	t.Run("happy path: secondary rate limit", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{}}
+		resp.Header.Set("X-RateLimit-Remaining", "0")
+		resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		resp.Header.Set("Retry-After", "30")
+		if _, primary := client.primaryRateLimitDelay(resp); primary {
+			t.Errorf("expected a secondary rate limit")
+		}
+		if delay := client.retryDelay(resp, 0); delay != 30*time.Second {
+			t.Errorf("expected 30s, got %v", delay)
+		}
+	})
+}
+
+func TestConditionalRequests(t *testing.T) {
+
+	newTestServer := func(nbCalls *int, ifNoneMatch *string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*nbCalls++
+			*ifNoneMatch = r.Header.Get("If-None-Match")
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			if r.Header.Get("If-None-Match") == `"etag1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"etag1"`)
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name": "octocat"}`))
+		}))
+	}
+
+	t.Run("happy path: cached body returned on a 304", func(t *testing.T) {
+		nbCalls := 0
+		ifNoneMatch := ""
+		testServer := newTestServer(&nbCalls, &ifNoneMatch)
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:        testServer.URL,
+			httpClient:          testServer.Client(),
+			conditionalRequests: true,
+		}
+
+		stats := &config.GoliacStatistics{}
+		ctx := context.WithValue(context.TODO(), config.ContextKeyStatistics, stats)
+		_, err := client.CallRestAPI(ctx, "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if ifNoneMatch != "" {
+			t.Errorf("expected no If-None-Match header on the first call, got %s", ifNoneMatch)
+		}
+
+		result, err := client.CallRestAPI(ctx, "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if ifNoneMatch != `"etag1"` {
+			t.Errorf("expected the If-None-Match header on the second call, got %s", ifNoneMatch)
+		}
+		if !strings.Contains(string(result), "octocat") {
+			t.Errorf("expected 'octocat' in the result, got %s", result)
+		}
+		if nbCalls != 2 || stats.GithubApiCalls != 2 || stats.GithubNotModified != 1 {
+			t.Errorf("expected 2 calls and 1 not modified, got %d calls, %d not modified", nbCalls, stats.GithubNotModified)
+		}
+	})
+
+	t.Run("happy path: disabled", func(t *testing.T) {
+		nbCalls := 0
+		ifNoneMatch := ""
+		testServer := newTestServer(&nbCalls, &ifNoneMatch)
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer: testServer.URL,
+			httpClient:   testServer.Client(),
+		}
+
+		for i := 0; i < 2; i++ {
+			result, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(result), "octocat") {
+				t.Errorf("expected 'octocat' in the result, got %s", result)
+			}
+		}
+		if ifNoneMatch != "" {
+			t.Errorf("expected no If-None-Match header, got %s", ifNoneMatch)
+		}
+	})
+
+	t.Run("happy path: only for GET calls", func(t *testing.T) {
+		nbCalls := 0
+		ifNoneMatch := ""
+		testServer := newTestServer(&nbCalls, &ifNoneMatch)
+		defer testServer.Close()
+
+		client := &GitHubClientImpl{
+			gitHubServer:        testServer.URL,
+			httpClient:          testServer.Client(),
+			conditionalRequests: true,
+		}
+
+		for i := 0; i < 2; i++ {
+			_, err := client.CallRestAPI(context.TODO(), "/users/octocat", "PATCH", map[string]interface{}{"name": "octocat"})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		if ifNoneMatch != "" {
+			t.Errorf("expected no If-None-Match header, got %s", ifNoneMatch)
+		}
+	})
+}
+
+func TestAccessTokenCache(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	nbTokens := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/app/installations/1/access_tokens" {
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token%d", nbTokens) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name": "octocat"}`))
+			return
+		}
+		nbTokens++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(fmt.Sprintf(`{"token": "token%d", "expires_at": "%s"}`, nbTokens, now.Add(time.Hour).Format(time.RFC3339))))
+	}))
+	defer testServer.Close()
+
+	client := &GitHubClientImpl{
+		gitHubServer:   testServer.URL,
+		installationID: 1,
+		privateKey:     privateKey,
+		refreshWindow:  5 * time.Minute,
+		now:            func() time.Time { return now },
+	}
+	client.httpClient = &http.Client{Transport: &AuthorizedTransport{client: client}}
+
+	// concurrent calls share the same token
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if nbTokens != 1 {
+		t.Errorf("expected 1 token, got %d", nbTokens)
+	}
+
+	// still valid outside of the refresh window
+	now = now.Add(50 * time.Minute)
+	token, err := client.GetAccessToken(context.TODO())
+	if err != nil || token != "token1" || nbTokens != 1 {
+		t.Errorf("expected token1 to be reused, got %s (%d tokens, err %v)", token, nbTokens, err)
+	}
+
+	// expiring in less than 5 minutes
+	now = now.Add(6 * time.Minute)
+	token, err = client.GetAccessToken(context.TODO())
+	if err != nil || token != "token2" || nbTokens != 2 {
+		t.Errorf("expected a new token2, got %s (%d tokens, err %v)", token, nbTokens, err)
+	}
+	if _, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestHTTPTransport(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	githubHandler := func(hosts *[]string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*hosts = append(*hosts, r.Host)
+			if r.URL.Path == "/app/installations/1/access_tokens" {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"token": "token1"}`))
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer token1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"name": "octocat"}`))
+		}
+	}
+	newClient := func(server string, transport http.RoundTripper) *GitHubClientImpl {
+		client := &GitHubClientImpl{
+			gitHubServer:   server,
+			installationID: 1,
+			privateKey:     privateKey,
+			transport:      transport,
+		}
+		client.httpClient = &http.Client{Transport: &AuthorizedTransport{client: client}}
+		return client
+	}
+
+	t.Run("happy path: trust a custom CA", func(t *testing.T) {
+		hosts := []string{}
+		testServer := httptest.NewTLSServer(githubHandler(&hosts))
+		defer testServer.Close()
+
+		caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+		caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testServer.Certificate().Raw})
+		if err := os.WriteFile(caCertFile, caCert, 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// the test server certificate is not trusted by default
+		client := newClient(testServer.URL, nil)
+		if _, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil); err == nil {
+			t.Errorf("expected a certificate error")
+		}
+
+		transport, err := newHTTPTransport(caCertFile)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		client = newClient(testServer.URL, transport)
+		result, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(result), "octocat") {
+			t.Errorf("expected 'octocat' in the result, got %s", result)
+		}
+	})
+
+	t.Run("happy path: go through the proxy", func(t *testing.T) {
+		hosts := []string{}
+		proxyServer := httptest.NewServer(githubHandler(&hosts))
+		defer proxyServer.Close()
+
+		t.Setenv("HTTPS_PROXY", proxyServer.URL)
+		t.Setenv("HTTP_PROXY", proxyServer.URL)
+		t.Setenv("NO_PROXY", "internal.example.com")
+
+		transport, err := newHTTPTransport("")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat", nil)
+		proxyURL, err := transport.Proxy(req)
+		if err != nil || proxyURL == nil || proxyURL.String() != proxyServer.URL {
+			t.Errorf("expected the proxy %s, got %v (err %v)", proxyServer.URL, proxyURL, err)
+		}
+		req, _ = http.NewRequest("GET", "https://github.internal.example.com/users/octocat", nil)
+		proxyURL, err = transport.Proxy(req)
+		if err != nil || proxyURL != nil {
+			t.Errorf("expected no proxy for NO_PROXY hosts, got %v (err %v)", proxyURL, err)
+		}
+
+		// the token and the API calls reach the server through the proxy
+		client := newClient("http://github.example.com", transport)
+		result, err := client.CallRestAPI(context.TODO(), "/users/octocat", "GET", nil)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if !strings.Contains(string(result), "octocat") {
+			t.Errorf("expected 'octocat' in the result, got %s", result)
+		}
+		if len(hosts) != 2 || hosts[0] != "github.example.com" || hosts[1] != "github.example.com" {
+			t.Errorf("expected 2 proxied requests to github.example.com, got %v", hosts)
+		}
+	})
+
+	t.Run("not happy path: invalid CA file", func(t *testing.T) {
+		if _, err := newHTTPTransport(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+			t.Errorf("expected an error for a missing file")
+		}
+
+		caCertFile := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(caCertFile, []byte("not a certificate"), 0600); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := newHTTPTransport(caCertFile); err == nil {
+			t.Errorf("expected an error for a file without certificate")
+		}
+	})
+}
+
+func TestRateLimits(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/graphql" {
+			w.Header().Set("X-RateLimit-Resource", "graphql")
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "4990")
+			w.Header().Set("X-RateLimit-Used", "10")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+		} else if r.URL.Path == "/users/octocat" {
+			w.Header().Set("X-RateLimit-Limit", "5000")
+			w.Header().Set("X-RateLimit-Remaining", "12")
+			w.Header().Set("X-RateLimit-Used", "4988")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(now.Add(time.Hour).Unix(), 10))
+		}
+		// other paths: no rate limit headers (like a Github Enterprise with the rate limiting disabled)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer testServer.Close()
+
+	client := &GitHubClientImpl{
+		gitHubServer: testServer.URL,
+		httpClient:   testServer.Client(),
+		now:          func() time.Time { return now },
+	}
+
+	if rateLimits := client.GetRateLimits(); len(rateLimits) != 0 {
+		t.Errorf("expected no rate limit before any call, got %v", rateLimits)
+	}
+
+	for _, path := range []string{"/users/octocat", "/meta"} {
+		if _, err := client.CallRestAPI(context.TODO(), path, "GET", nil); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if _, err := client.QueryGraphQLAPI(context.TODO(), `query { viewer { login } }`, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	rateLimits := client.GetRateLimits()
+	expected := map[string]RateLimit{
+		"core":    {Limit: 5000, Remaining: 12, Used: 4988, Reset: now.Add(time.Hour), CapturedAt: now},
+		"graphql": {Limit: 5000, Remaining: 4990, Used: 10, Reset: now.Add(time.Hour), CapturedAt: now},
+	}
+	if len(rateLimits) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, rateLimits)
+	}
+	for resource, rateLimit := range expected {
+		if rateLimits[resource] != rateLimit {
+			t.Errorf("expected the %s rate limit %v, got %v", resource, rateLimit, rateLimits[resource])
+		}
+	}
+}
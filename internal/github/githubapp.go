@@ -25,7 +25,7 @@ func (client *GitHubClientImpl) getInstallations(jwt string) ([]Installation, er
 	req.Header.Add("Authorization", "Bearer "+jwt)
 	req.Header.Add("Accept", "application/vnd.github.machine-man-preview+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := (&http.Client{Transport: client.baseTransport()}).Do(req)
 	if err != nil {
 		return nil, err
 	}
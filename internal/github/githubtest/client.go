@@ -0,0 +1,167 @@
+package githubtest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Alayacare/goliac/internal/github"
+)
+
+/*
+ * Request is a request received by the RecordingClient
+ */
+type Request struct {
+	Method    string                 // GET, POST, PUT, PATCH, DELETE (or GRAPHQL)
+	Endpoint  string                 // the REST endpoint (without the leading '/'), or the GraphQL operation name
+	Body      map[string]interface{} // the REST body
+	Query     string                 // the GraphQL query
+	Variables map[string]interface{} // the GraphQL variables
+}
+
+type response struct {
+	body []byte
+	err  error
+}
+
+/*
+ * RecordingClient is a github.GitHubClient for tests: it records all the
+ * requests it receives, and replays the canned responses registered with
+ * ReplyRest/ReplyGraphQL. The responses registered for a request are replayed
+ * in order, the last one being repeated.
+ * A request without a canned response gets an error.
+ */
+type RecordingClient struct {
+	AppSlug     string
+	AccessToken string
+
+	mutex     sync.Mutex
+	requests  []Request
+	responses map[string][]response // key is "<method> <endpoint>"
+}
+
+var _ github.GitHubClient = &RecordingClient{}
+
+func NewRecordingClient() *RecordingClient {
+	return &RecordingClient{
+		AppSlug:     "goliac-test-app",
+		AccessToken: "token",
+		requests:    []Request{},
+		responses:   make(map[string][]response),
+	}
+}
+
+// the endpoints are used with or without a leading '/'
+func requestKey(method string, endpoint string) string {
+	return strings.ToUpper(method) + " " + strings.TrimPrefix(endpoint, "/")
+}
+
+var graphqlOperationName = regexp.MustCompile(`(?:query|mutation)\s+(\w+)`)
+
+func operationName(query string) string {
+	matches := graphqlOperationName.FindStringSubmatch(query)
+	if len(matches) == 2 {
+		return matches[1]
+	}
+	return ""
+}
+
+/*
+ * ReplyRest registers a canned response for a REST request
+ */
+func (c *RecordingClient) ReplyRest(method string, endpoint string, body string) *RecordingClient {
+	return c.reply(requestKey(method, endpoint), response{body: []byte(body)})
+}
+
+/*
+ * ReplyRestError registers an error (and its body) for a REST request
+ */
+func (c *RecordingClient) ReplyRestError(method string, endpoint string, err error, body string) *RecordingClient {
+	return c.reply(requestKey(method, endpoint), response{body: []byte(body), err: err})
+}
+
+/*
+ * ReplyGraphQL registers a canned response for a GraphQL operation
+ * (identified by its name, for example "listAllTeamsInOrg")
+ */
+func (c *RecordingClient) ReplyGraphQL(operation string, body string) *RecordingClient {
+	return c.reply(requestKey("GRAPHQL", operation), response{body: []byte(body)})
+}
+
+func (c *RecordingClient) reply(key string, r response) *RecordingClient {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.responses[key] = append(c.responses[key], r)
+	return c
+}
+
+func (c *RecordingClient) record(request Request) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.requests = append(c.requests, request)
+
+	key := requestKey(request.Method, request.Endpoint)
+	responses := c.responses[key]
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("githubtest: no response registered for %s", key)
+	}
+	r := responses[0]
+	if len(responses) > 1 {
+		c.responses[key] = responses[1:]
+	}
+	return r.body, r.err
+}
+
+func (c *RecordingClient) QueryGraphQLAPI(ctx context.Context, query string, variables map[string]interface{}) ([]byte, error) {
+	return c.record(Request{
+		Method:    "GRAPHQL",
+		Endpoint:  operationName(query),
+		Query:     query,
+		Variables: variables,
+	})
+}
+
+func (c *RecordingClient) CallRestAPI(ctx context.Context, endpoint, method string, body map[string]interface{}) ([]byte, error) {
+	return c.record(Request{
+		Method:   strings.ToUpper(method),
+		Endpoint: strings.TrimPrefix(endpoint, "/"),
+		Body:     body,
+	})
+}
+
+func (c *RecordingClient) GetAccessToken(ctx context.Context) (string, error) {
+	return c.AccessToken, nil
+}
+
+func (c *RecordingClient) GetAppSlug() string {
+	return c.AppSlug
+}
+func (c *RecordingClient) GetRateLimits() map[string]github.RateLimit {
+	return map[string]github.RateLimit{}
+}
+
+/*
+ * Requests returns all the requests received, in order
+ */
+func (c *RecordingClient) Requests() []Request {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]Request{}, c.requests...)
+}
+
+/*
+ * RequestsTo returns the requests received for a REST endpoint (or a
+ * GraphQL operation with the "GRAPHQL" method), in order
+ */
+func (c *RecordingClient) RequestsTo(method string, endpoint string) []Request {
+	key := requestKey(method, endpoint)
+	requests := []Request{}
+	for _, r := range c.Requests() {
+		if requestKey(r.Method, r.Endpoint) == key {
+			requests = append(requests, r)
+		}
+	}
+	return requests
+}
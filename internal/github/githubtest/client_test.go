@@ -0,0 +1,84 @@
+package githubtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingClient(t *testing.T) {
+
+	t.Run("happy path: record a create team call", func(t *testing.T) {
+		org := config.Config.GithubAppOrganization
+		client := NewRecordingClient().
+			ReplyRest("POST", "/orgs/"+org+"/teams", `{"id":1,"slug":"new-team"}`).
+			ReplyRest("PUT", "/orgs/"+org+"/teams/new-team/memberships/user1", `{}`)
+
+		remote := engine.NewGoliacRemoteImpl(client)
+		remote.CreateTeam(context.TODO(), false, "new team", "a new team", nil, []string{"user1"})
+
+		requests := client.RequestsTo("POST", "/orgs/"+org+"/teams")
+		assert.Equal(t, 1, len(requests))
+		assert.Equal(t, map[string]interface{}{
+			"name":        "new team",
+			"description": "a new team",
+			"privacy":     "closed",
+		}, requests[0].Body)
+
+		// the endpoint is matched with or without the leading '/'
+		memberships := client.RequestsTo("PUT", "orgs/"+org+"/teams/new-team/memberships/user1")
+		assert.Equal(t, 1, len(memberships))
+		assert.Equal(t, map[string]interface{}{"role": "member"}, memberships[0].Body)
+
+		assert.Equal(t, "new-team", remote.Teams(context.TODO())["new-team"].Slug)
+	})
+
+	t.Run("happy path: nothing is sent in dryrun", func(t *testing.T) {
+		client := NewRecordingClient()
+
+		remote := engine.NewGoliacRemoteImpl(client)
+		// requests sent at the remote creation
+		nbRequests := len(client.Requests())
+		remote.CreateTeam(context.TODO(), true, "new team", "a new team", nil, []string{"user1"})
+
+		assert.Equal(t, nbRequests, len(client.Requests()))
+	})
+
+	t.Run("happy path: replay the responses in order, the last one is repeated", func(t *testing.T) {
+		client := NewRecordingClient().
+			ReplyGraphQL("listAllTeamsInOrg", `{"page":1}`).
+			ReplyGraphQL("listAllTeamsInOrg", `{"page":2}`)
+
+		query := "query listAllTeamsInOrg($orgLogin: String!) { organization(login: $orgLogin) { id } }"
+		for _, expected := range []string{`{"page":1}`, `{"page":2}`, `{"page":2}`} {
+			body, err := client.QueryGraphQLAPI(context.TODO(), query, map[string]interface{}{"orgLogin": "myorg"})
+			assert.Nil(t, err)
+			assert.Equal(t, expected, string(body))
+		}
+
+		requests := client.RequestsTo("GRAPHQL", "listAllTeamsInOrg")
+		assert.Equal(t, 3, len(requests))
+		assert.Equal(t, "myorg", requests[0].Variables["orgLogin"])
+	})
+
+	t.Run("not happy path: canned error", func(t *testing.T) {
+		client := NewRecordingClient().
+			ReplyRestError("DELETE", "/orgs/myorg/teams/team1", fmt.Errorf("404 not found"), `{"message":"Not Found"}`)
+
+		body, err := client.CallRestAPI(context.TODO(), "/orgs/myorg/teams/team1", "DELETE", nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, `{"message":"Not Found"}`, string(body))
+	})
+
+	t.Run("not happy path: no canned response", func(t *testing.T) {
+		client := NewRecordingClient()
+
+		_, err := client.CallRestAPI(context.TODO(), "/orgs/myorg/teams", "GET", nil)
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, len(client.Requests()))
+	})
+}
@@ -0,0 +1,60 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*
+ * RateLimit is the Github rate limit of a resource (core, graphql, search, ...)
+ * as reported by the x-ratelimit-* headers of the last API response
+ */
+type RateLimit struct {
+	Limit      int       `json:"limit"`
+	Remaining  int       `json:"remaining"`
+	Used       int       `json:"used"`
+	Reset      time.Time `json:"reset"`       // when the quota is renewed
+	CapturedAt time.Time `json:"captured_at"` // when the response was received
+}
+
+/*
+ * recordRateLimit keeps the rate limit reported by the response headers
+ * (per resource, the REST and GraphQL APIs having their own quota)
+ */
+func (client *GitHubClientImpl) recordRateLimit(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	used, _ := strconv.Atoi(header.Get("X-RateLimit-Used"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	client.rateLimitsMutex.Lock()
+	defer client.rateLimitsMutex.Unlock()
+	if client.rateLimits == nil {
+		client.rateLimits = make(map[string]RateLimit)
+	}
+	client.rateLimits[resource] = RateLimit{
+		Limit:      limit,
+		Remaining:  remaining,
+		Used:       used,
+		Reset:      time.Unix(reset, 0).UTC(),
+		CapturedAt: client.currentTime().UTC(),
+	}
+}
+
+func (client *GitHubClientImpl) GetRateLimits() map[string]RateLimit {
+	client.rateLimitsMutex.Lock()
+	defer client.rateLimitsMutex.Unlock()
+	rateLimits := make(map[string]RateLimit, len(client.rateLimits))
+	for resource, rateLimit := range client.rateLimits {
+		rateLimits[resource] = rateLimit
+	}
+	return rateLimits
+}
@@ -0,0 +1,56 @@
+package github
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+/*
+ * newHTTPTransport returns the transport used to call Github:
+ * - through the proxy set in HTTPS_PROXY (and NO_PROXY)
+ * - trusting the CA certificates of caCertFile (a PEM bundle), in addition
+ *   to the system ones, if caCertFile is not empty
+ */
+func newHTTPTransport(caCertFile string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// read on each new transport (http.ProxyFromEnvironment reads the environment only once)
+	proxyFunc := httpproxy.FromEnvironment().ProxyFunc()
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+
+	if caCertFile != "" {
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the Github CA certificates %s: %v", caCertFile, err)
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil || rootCAs == nil {
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no valid PEM certificate found in the Github CA certificates %s", caCertFile)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    rootCAs,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return transport, nil
+}
+
+// the transport used to call Github (http.DefaultTransport if the client has none)
+func (client *GitHubClientImpl) baseTransport() http.RoundTripper {
+	if client.transport == nil {
+		return http.DefaultTransport
+	}
+	return client.transport
+}
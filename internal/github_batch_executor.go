@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
@@ -16,6 +17,15 @@ type GithubCommand interface {
 	Apply(ctx context.Context)
 }
 
+/*
+ * GithubRepositoryCommand is a GithubCommand scoped to a single repository.
+ * Commands of different repositories can be applied concurrently
+ */
+type GithubRepositoryCommand interface {
+	GithubCommand
+	Repository() string
+}
+
 /*
  * GithubBatchExecutor will collects all commands to apply
  * if there the number of changes to apply is not too big, it will apply on the `Commit()`
@@ -109,6 +119,34 @@ func (g *GithubBatchExecutor) UpdateTeamSetParent(ctx context.Context, dryrun bo
 	})
 }
 
+func (g *GithubBatchExecutor) UpdateTeamRename(ctx context.Context, dryrun bool, teamslug string, newname string) {
+	g.commands = append(g.commands, &GithubCommandUpdateTeamRename{
+		client:   g.client,
+		dryrun:   dryrun,
+		teamslug: teamslug,
+		newname:  newname,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateTeamUpdateProperty(ctx context.Context, dryrun bool, teamslug string, propertyName string, propertyValue string) {
+	g.commands = append(g.commands, &GithubCommandUpdateTeamUpdateProperty{
+		client:        g.client,
+		dryrun:        dryrun,
+		teamslug:      teamslug,
+		propertyName:  propertyName,
+		propertyValue: propertyValue,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateTeamSetExternalGroups(ctx context.Context, dryrun bool, teamslug string, groups []string) {
+	g.commands = append(g.commands, &GithubCommandUpdateTeamSetExternalGroups{
+		client:   g.client,
+		dryrun:   dryrun,
+		teamslug: teamslug,
+		groups:   groups,
+	})
+}
+
 func (g *GithubBatchExecutor) DeleteTeam(ctx context.Context, dryrun bool, teamslug string) {
 	g.commands = append(g.commands, &GithubCommandDeleteTeam{
 		client:   g.client,
@@ -117,15 +155,26 @@ func (g *GithubBatchExecutor) DeleteTeam(ctx context.Context, dryrun bool, teams
 	})
 }
 
-func (g *GithubBatchExecutor) CreateRepository(ctx context.Context, dryrun bool, reponame string, description string, writers []string, readers []string, boolProperties map[string]bool) {
+func (g *GithubBatchExecutor) CreateRepository(ctx context.Context, dryrun bool, reponame string, description string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) {
 	g.commands = append(g.commands, &GithubCommandCreateRepository{
-		client:         g.client,
-		dryrun:         dryrun,
-		reponame:       reponame,
-		description:    description,
-		readers:        readers,
-		writers:        writers,
-		boolProperties: boolProperties,
+		client:             g.client,
+		dryrun:             dryrun,
+		reponame:           reponame,
+		description:        description,
+		readers:            readers,
+		writers:            writers,
+		boolProperties:     boolProperties,
+		templateRepository: templateRepository,
+		includeAllBranches: includeAllBranches,
+	})
+}
+
+func (g *GithubBatchExecutor) TransferRepository(ctx context.Context, dryrun bool, reponame string, source string) {
+	g.commands = append(g.commands, &GithubCommandTransferRepository{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		source:   source,
 	})
 }
 
@@ -168,6 +217,34 @@ func (g *GithubBatchExecutor) UpdateRepositoryUpdateBoolProperty(ctx context.Con
 	})
 }
 
+func (g *GithubBatchExecutor) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryUpdateProperty{
+		client:        g.client,
+		dryrun:        dryrun,
+		reponame:      reponame,
+		propertyName:  propertyName,
+		propertyValue: propertyValue,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositorySetDependabotAlerts{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		enabled:  enabled,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositorySetRequiredSignatures{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		enabled:  enabled,
+	})
+}
+
 func (g *GithubBatchExecutor) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	g.commands = append(g.commands, &GithubCommandUpdateRepositorySetExternalUser{
 		client:     g.client,
@@ -219,6 +296,155 @@ func (g *GithubBatchExecutor) DeleteRuleset(ctx context.Context, dryrun bool, ru
 	})
 }
 
+func (g *GithubBatchExecutor) AddOrgVariable(ctx context.Context, dryrun bool, variable *engine.GithubOrgVariable) {
+	g.commands = append(g.commands, &GithubCommandAddOrgVariable{
+		client:   g.client,
+		dryrun:   dryrun,
+		variable: variable,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateOrgVariable(ctx context.Context, dryrun bool, variable *engine.GithubOrgVariable) {
+	g.commands = append(g.commands, &GithubCommandUpdateOrgVariable{
+		client:   g.client,
+		dryrun:   dryrun,
+		variable: variable,
+	})
+}
+
+func (g *GithubBatchExecutor) DeleteOrgVariable(ctx context.Context, dryrun bool, variablename string) {
+	g.commands = append(g.commands, &GithubCommandDeleteOrgVariable{
+		client:       g.client,
+		dryrun:       dryrun,
+		variablename: variablename,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateOrgSecret(ctx context.Context, dryrun bool, secret *engine.GithubOrgSecret) {
+	g.commands = append(g.commands, &GithubCommandUpdateOrgSecret{
+		client: g.client,
+		dryrun: dryrun,
+		secret: secret,
+	})
+}
+
+func (g *GithubBatchExecutor) DeleteOrgSecret(ctx context.Context, dryrun bool, secretname string) {
+	g.commands = append(g.commands, &GithubCommandDeleteOrgSecret{
+		client:     g.client,
+		dryrun:     dryrun,
+		secretname: secretname,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositoryActionsPermissions(ctx context.Context, dryrun bool, reponame string, enabled bool, allowedActions string) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryActionsPermissions{
+		client:         g.client,
+		dryrun:         dryrun,
+		reponame:       reponame,
+		enabled:        enabled,
+		allowedActions: allowedActions,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositorySetSecurity(ctx context.Context, dryrun bool, reponame string, feature string, enabled bool) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositorySetSecurity{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		feature:  feature,
+		enabled:  enabled,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositoryCustomProperties(ctx context.Context, dryrun bool, reponame string, properties map[string]string) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryCustomProperties{
+		client:     g.client,
+		dryrun:     dryrun,
+		reponame:   reponame,
+		properties: properties,
+	})
+}
+
+func (g *GithubBatchExecutor) AddRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *engine.GithubWebhook) {
+	g.commands = append(g.commands, &GithubCommandAddRepositoryWebhook{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		webhook:  webhook,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *engine.GithubWebhook) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryWebhook{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		webhook:  webhook,
+	})
+}
+
+func (g *GithubBatchExecutor) DeleteRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *engine.GithubWebhook) {
+	g.commands = append(g.commands, &GithubCommandDeleteRepositoryWebhook{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		webhook:  webhook,
+	})
+}
+
+func (g *GithubBatchExecutor) CreateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, label *engine.GithubLabel) {
+	g.commands = append(g.commands, &GithubCommandCreateRepositoryLabel{
+		client:   g.client,
+		dryrun:   dryrun,
+		reponame: reponame,
+		label:    label,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string, label *engine.GithubLabel) {
+	g.commands = append(g.commands, &GithubCommandUpdateRepositoryLabel{
+		client:    g.client,
+		dryrun:    dryrun,
+		reponame:  reponame,
+		labelname: labelname,
+		label:     label,
+	})
+}
+
+func (g *GithubBatchExecutor) DeleteRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string) {
+	g.commands = append(g.commands, &GithubCommandDeleteRepositoryLabel{
+		client:    g.client,
+		dryrun:    dryrun,
+		reponame:  reponame,
+		labelname: labelname,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateOrgPushProtectionCustomLink(ctx context.Context, dryrun bool, link string) {
+	g.commands = append(g.commands, &GithubCommandUpdateOrgPushProtectionCustomLink{
+		client: g.client,
+		dryrun: dryrun,
+		link:   link,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateOrgDefaultRepositoryPermission(ctx context.Context, dryrun bool, permission string) {
+	g.commands = append(g.commands, &GithubCommandUpdateOrgDefaultRepositoryPermission{
+		client:     g.client,
+		dryrun:     dryrun,
+		permission: permission,
+	})
+}
+
+func (g *GithubBatchExecutor) UpdateOrgSetting(ctx context.Context, dryrun bool, settingName string, settingValue bool) {
+	g.commands = append(g.commands, &GithubCommandUpdateOrgSetting{
+		client:       g.client,
+		dryrun:       dryrun,
+		settingName:  settingName,
+		settingValue: settingValue,
+	})
+}
+
 func (g *GithubBatchExecutor) Begin(dryrun bool) {
 	g.commands = make([]GithubCommand, 0)
 }
@@ -226,16 +452,83 @@ func (g *GithubBatchExecutor) Rollback(dryrun bool, err error) {
 	g.commands = make([]GithubCommand, 0)
 }
 func (g *GithubBatchExecutor) Commit(ctx context.Context, dryrun bool) error {
+	// last line of defense: nothing is ever changed by a read-only server
+	if !dryrun && config.Config.ServerReadOnly && len(g.commands) > 0 {
+		g.commands = make([]GithubCommand, 0)
+		return fmt.Errorf("not applying the changes: the server is read-only (GOLIAC_SERVER_READONLY)")
+	}
 	if len(g.commands) > g.maxChangesets && !config.Config.MaxChangesetsOverride {
 		return fmt.Errorf("more than %d changesets to apply (total of %d), this is suspicious. Aborting (see Goliac troubleshooting guide for help)", g.maxChangesets, len(g.commands))
 	}
-	for _, c := range g.commands {
-		c.Apply(ctx)
+	if config.Config.GithubConcurrentThreads <= 1 {
+		for _, c := range g.commands {
+			c.Apply(ctx)
+		}
+	} else {
+		g.applyConcurrently(ctx, config.Config.GithubConcurrentThreads)
 	}
 	g.commands = make([]GithubCommand, 0)
 	return nil
 }
 
+/*
+ * applyConcurrently dispatches the repository commands through a pool of maxGoroutines workers.
+ * Commands of a same repository are applied in order by the same worker, and any
+ * non repository command (teams, rulesets, ...) acts as a barrier: every command
+ * queued before it is applied first
+ */
+func (g *GithubBatchExecutor) applyConcurrently(ctx context.Context, maxGoroutines int64) {
+	segment := make([]GithubRepositoryCommand, 0)
+	for _, c := range g.commands {
+		if rc, ok := c.(GithubRepositoryCommand); ok {
+			segment = append(segment, rc)
+			continue
+		}
+		applyRepositoryCommandsConcurrently(ctx, segment, maxGoroutines)
+		segment = make([]GithubRepositoryCommand, 0)
+		c.Apply(ctx)
+	}
+	applyRepositoryCommandsConcurrently(ctx, segment, maxGoroutines)
+}
+
+func applyRepositoryCommandsConcurrently(ctx context.Context, commands []GithubRepositoryCommand, maxGoroutines int64) {
+	if len(commands) == 0 {
+		return
+	}
+
+	// regroup the commands per repository (keeping their order)
+	repositories := make([]string, 0)
+	commandsPerRepo := make(map[string][]GithubRepositoryCommand)
+	for _, c := range commands {
+		if _, ok := commandsPerRepo[c.Repository()]; !ok {
+			repositories = append(repositories, c.Repository())
+		}
+		commandsPerRepo[c.Repository()] = append(commandsPerRepo[c.Repository()], c)
+	}
+
+	var wg sync.WaitGroup
+	reposChan := make(chan []GithubRepositoryCommand, len(repositories))
+
+	for i := int64(0); i < maxGoroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for repoCommands := range reposChan {
+				for _, c := range repoCommands {
+					c.Apply(ctx)
+				}
+			}
+		}()
+	}
+
+	for _, r := range repositories {
+		reposChan <- commandsPerRepo[r]
+	}
+	close(reposChan)
+
+	wg.Wait()
+}
+
 type GithubCommandAddUserToOrg struct {
 	client   engine.ReconciliatorExecutor
 	dryrun   bool
@@ -254,10 +547,17 @@ type GithubCommandCreateRepository struct {
 	writers        []string
 	readers        []string
 	boolProperties map[string]bool
+
+	templateRepository string
+	includeAllBranches bool
 }
 
 func (g *GithubCommandCreateRepository) Apply(ctx context.Context) {
-	g.client.CreateRepository(ctx, g.dryrun, g.reponame, g.description, g.writers, g.readers, g.boolProperties)
+	g.client.CreateRepository(ctx, g.dryrun, g.reponame, g.description, g.writers, g.readers, g.boolProperties, g.templateRepository, g.includeAllBranches)
+}
+
+func (g *GithubCommandCreateRepository) Repository() string {
+	return g.reponame
 }
 
 type GithubCommandCreateTeam struct {
@@ -283,6 +583,21 @@ func (g *GithubCommandDeleteRepository) Apply(ctx context.Context) {
 	g.client.DeleteRepository(ctx, g.dryrun, g.reponame)
 }
 
+func (g *GithubCommandDeleteRepository) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateTeamRename struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	teamslug string
+	newname  string
+}
+
+func (g *GithubCommandUpdateTeamRename) Apply(ctx context.Context) {
+	g.client.UpdateTeamRename(ctx, g.dryrun, g.teamslug, g.newname)
+}
+
 type GithubCommandDeleteTeam struct {
 	client   engine.ReconciliatorExecutor
 	dryrun   bool
@@ -314,6 +629,25 @@ func (g *GithubCommandUpdateRepositoryRemoveTeamAccess) Apply(ctx context.Contex
 	g.client.UpdateRepositoryRemoveTeamAccess(ctx, g.dryrun, g.reponame, g.teamslug)
 }
 
+func (g *GithubCommandUpdateRepositoryRemoveTeamAccess) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandTransferRepository struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	source   string
+}
+
+func (g *GithubCommandTransferRepository) Apply(ctx context.Context) {
+	g.client.TransferRepository(ctx, g.dryrun, g.reponame, g.source)
+}
+
+func (g *GithubCommandTransferRepository) Repository() string {
+	return g.reponame
+}
+
 type GithubCommandUpdateRepositoryAddTeamAccess struct {
 	client     engine.ReconciliatorExecutor
 	dryrun     bool
@@ -326,6 +660,10 @@ func (g *GithubCommandUpdateRepositoryAddTeamAccess) Apply(ctx context.Context)
 	g.client.UpdateRepositoryAddTeamAccess(ctx, g.dryrun, g.reponame, g.teamslug, g.permission)
 }
 
+func (g *GithubCommandUpdateRepositoryAddTeamAccess) Repository() string {
+	return g.reponame
+}
+
 type GithubCommandUpdateRepositoryUpdateTeamAccess struct {
 	client     engine.ReconciliatorExecutor
 	dryrun     bool
@@ -338,6 +676,10 @@ func (g *GithubCommandUpdateRepositoryUpdateTeamAccess) Apply(ctx context.Contex
 	g.client.UpdateRepositoryUpdateTeamAccess(ctx, g.dryrun, g.reponame, g.teamslug, g.permission)
 }
 
+func (g *GithubCommandUpdateRepositoryUpdateTeamAccess) Repository() string {
+	return g.reponame
+}
+
 type GithubCommandUpdateRepositorySetExternalUser struct {
 	client     engine.ReconciliatorExecutor
 	dryrun     bool
@@ -350,6 +692,10 @@ func (g *GithubCommandUpdateRepositorySetExternalUser) Apply(ctx context.Context
 	g.client.UpdateRepositorySetExternalUser(ctx, g.dryrun, g.reponame, g.githubid, g.permission)
 }
 
+func (g *GithubCommandUpdateRepositorySetExternalUser) Repository() string {
+	return g.reponame
+}
+
 type GithubCommandUpdateRepositoryRemoveExternalUser struct {
 	client   engine.ReconciliatorExecutor
 	dryrun   bool
@@ -361,6 +707,10 @@ func (g *GithubCommandUpdateRepositoryRemoveExternalUser) Apply(ctx context.Cont
 	g.client.UpdateRepositoryRemoveExternalUser(ctx, g.dryrun, g.reponame, g.githubid)
 }
 
+func (g *GithubCommandUpdateRepositoryRemoveExternalUser) Repository() string {
+	return g.reponame
+}
+
 type GithubCommandUpdateRepositoryUpdateBoolProperty struct {
 	client        engine.ReconciliatorExecutor
 	dryrun        bool
@@ -373,6 +723,10 @@ func (g *GithubCommandUpdateRepositoryUpdateBoolProperty) Apply(ctx context.Cont
 	g.client.UpdateRepositoryUpdateBoolProperty(ctx, g.dryrun, g.reponame, g.propertyName, g.propertyValue)
 }
 
+func (g *GithubCommandUpdateRepositoryUpdateBoolProperty) Repository() string {
+	return g.reponame
+}
+
 type GithubCommandUpdateTeamAddMember struct {
 	client   engine.ReconciliatorExecutor
 	dryrun   bool
@@ -419,6 +773,29 @@ func (g *GithubCommandUpdateTeamSetParent) Apply(ctx context.Context) {
 	g.client.UpdateTeamSetParent(ctx, g.dryrun, g.teamslug, g.parentTeam)
 }
 
+type GithubCommandUpdateTeamUpdateProperty struct {
+	client        engine.ReconciliatorExecutor
+	dryrun        bool
+	teamslug      string
+	propertyName  string
+	propertyValue string
+}
+
+func (g *GithubCommandUpdateTeamUpdateProperty) Apply(ctx context.Context) {
+	g.client.UpdateTeamUpdateProperty(ctx, g.dryrun, g.teamslug, g.propertyName, g.propertyValue)
+}
+
+type GithubCommandUpdateTeamSetExternalGroups struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	teamslug string
+	groups   []string
+}
+
+func (g *GithubCommandUpdateTeamSetExternalGroups) Apply(ctx context.Context) {
+	g.client.UpdateTeamSetExternalGroups(ctx, g.dryrun, g.teamslug, g.groups)
+}
+
 type GithubCommandAddRuletset struct {
 	client  engine.ReconciliatorExecutor
 	dryrun  bool
@@ -448,3 +825,268 @@ type GithubCommandDeleteRuletset struct {
 func (g *GithubCommandDeleteRuletset) Apply(ctx context.Context) {
 	g.client.DeleteRuleset(ctx, g.dryrun, g.rulesetid)
 }
+
+type GithubCommandAddOrgVariable struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	variable *engine.GithubOrgVariable
+}
+
+func (g *GithubCommandAddOrgVariable) Apply(ctx context.Context) {
+	g.client.AddOrgVariable(ctx, g.dryrun, g.variable)
+}
+
+type GithubCommandUpdateOrgVariable struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	variable *engine.GithubOrgVariable
+}
+
+func (g *GithubCommandUpdateOrgVariable) Apply(ctx context.Context) {
+	g.client.UpdateOrgVariable(ctx, g.dryrun, g.variable)
+}
+
+type GithubCommandDeleteOrgVariable struct {
+	client       engine.ReconciliatorExecutor
+	dryrun       bool
+	variablename string
+}
+
+func (g *GithubCommandDeleteOrgVariable) Apply(ctx context.Context) {
+	g.client.DeleteOrgVariable(ctx, g.dryrun, g.variablename)
+}
+
+type GithubCommandUpdateOrgSecret struct {
+	client engine.ReconciliatorExecutor
+	dryrun bool
+	secret *engine.GithubOrgSecret
+}
+
+func (g *GithubCommandUpdateOrgSecret) Apply(ctx context.Context) {
+	g.client.UpdateOrgSecret(ctx, g.dryrun, g.secret)
+}
+
+type GithubCommandDeleteOrgSecret struct {
+	client     engine.ReconciliatorExecutor
+	dryrun     bool
+	secretname string
+}
+
+func (g *GithubCommandDeleteOrgSecret) Apply(ctx context.Context) {
+	g.client.DeleteOrgSecret(ctx, g.dryrun, g.secretname)
+}
+
+type GithubCommandUpdateRepositoryActionsPermissions struct {
+	client         engine.ReconciliatorExecutor
+	dryrun         bool
+	reponame       string
+	enabled        bool
+	allowedActions string
+}
+
+func (g *GithubCommandUpdateRepositoryActionsPermissions) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryActionsPermissions(ctx, g.dryrun, g.reponame, g.enabled, g.allowedActions)
+}
+
+func (g *GithubCommandUpdateRepositoryActionsPermissions) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateRepositorySetSecurity struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	feature  string
+	enabled  bool
+}
+
+func (g *GithubCommandUpdateRepositorySetSecurity) Apply(ctx context.Context) {
+	g.client.UpdateRepositorySetSecurity(ctx, g.dryrun, g.reponame, g.feature, g.enabled)
+}
+
+func (g *GithubCommandUpdateRepositorySetSecurity) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateRepositoryCustomProperties struct {
+	client     engine.ReconciliatorExecutor
+	dryrun     bool
+	reponame   string
+	properties map[string]string
+}
+
+func (g *GithubCommandUpdateRepositoryCustomProperties) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryCustomProperties(ctx, g.dryrun, g.reponame, g.properties)
+}
+
+func (g *GithubCommandUpdateRepositoryCustomProperties) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandAddRepositoryWebhook struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	webhook  *engine.GithubWebhook
+}
+
+func (g *GithubCommandAddRepositoryWebhook) Apply(ctx context.Context) {
+	g.client.AddRepositoryWebhook(ctx, g.dryrun, g.reponame, g.webhook)
+}
+
+func (g *GithubCommandAddRepositoryWebhook) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateRepositoryWebhook struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	webhook  *engine.GithubWebhook
+}
+
+func (g *GithubCommandUpdateRepositoryWebhook) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryWebhook(ctx, g.dryrun, g.reponame, g.webhook)
+}
+
+func (g *GithubCommandUpdateRepositoryWebhook) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandDeleteRepositoryWebhook struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	webhook  *engine.GithubWebhook
+}
+
+func (g *GithubCommandDeleteRepositoryWebhook) Apply(ctx context.Context) {
+	g.client.DeleteRepositoryWebhook(ctx, g.dryrun, g.reponame, g.webhook)
+}
+
+func (g *GithubCommandDeleteRepositoryWebhook) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandCreateRepositoryLabel struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	label    *engine.GithubLabel
+}
+
+func (g *GithubCommandCreateRepositoryLabel) Apply(ctx context.Context) {
+	g.client.CreateRepositoryLabel(ctx, g.dryrun, g.reponame, g.label)
+}
+
+func (g *GithubCommandCreateRepositoryLabel) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateRepositoryLabel struct {
+	client    engine.ReconciliatorExecutor
+	dryrun    bool
+	reponame  string
+	labelname string
+	label     *engine.GithubLabel
+}
+
+func (g *GithubCommandUpdateRepositoryLabel) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryLabel(ctx, g.dryrun, g.reponame, g.labelname, g.label)
+}
+
+func (g *GithubCommandUpdateRepositoryLabel) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandDeleteRepositoryLabel struct {
+	client    engine.ReconciliatorExecutor
+	dryrun    bool
+	reponame  string
+	labelname string
+}
+
+func (g *GithubCommandDeleteRepositoryLabel) Apply(ctx context.Context) {
+	g.client.DeleteRepositoryLabel(ctx, g.dryrun, g.reponame, g.labelname)
+}
+
+func (g *GithubCommandDeleteRepositoryLabel) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateOrgPushProtectionCustomLink struct {
+	client engine.ReconciliatorExecutor
+	dryrun bool
+	link   string
+}
+
+func (g *GithubCommandUpdateOrgPushProtectionCustomLink) Apply(ctx context.Context) {
+	g.client.UpdateOrgPushProtectionCustomLink(ctx, g.dryrun, g.link)
+}
+
+type GithubCommandUpdateOrgDefaultRepositoryPermission struct {
+	client     engine.ReconciliatorExecutor
+	dryrun     bool
+	permission string
+}
+
+func (g *GithubCommandUpdateOrgDefaultRepositoryPermission) Apply(ctx context.Context) {
+	g.client.UpdateOrgDefaultRepositoryPermission(ctx, g.dryrun, g.permission)
+}
+
+type GithubCommandUpdateOrgSetting struct {
+	client       engine.ReconciliatorExecutor
+	dryrun       bool
+	settingName  string
+	settingValue bool
+}
+
+func (g *GithubCommandUpdateOrgSetting) Apply(ctx context.Context) {
+	g.client.UpdateOrgSetting(ctx, g.dryrun, g.settingName, g.settingValue)
+}
+
+type GithubCommandUpdateRepositorySetRequiredSignatures struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	enabled  bool
+}
+
+func (g *GithubCommandUpdateRepositorySetRequiredSignatures) Apply(ctx context.Context) {
+	g.client.UpdateRepositorySetRequiredSignatures(ctx, g.dryrun, g.reponame, g.enabled)
+}
+
+func (g *GithubCommandUpdateRepositorySetRequiredSignatures) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateRepositoryUpdateProperty struct {
+	client        engine.ReconciliatorExecutor
+	dryrun        bool
+	reponame      string
+	propertyName  string
+	propertyValue string
+}
+
+func (g *GithubCommandUpdateRepositoryUpdateProperty) Apply(ctx context.Context) {
+	g.client.UpdateRepositoryUpdateProperty(ctx, g.dryrun, g.reponame, g.propertyName, g.propertyValue)
+}
+
+func (g *GithubCommandUpdateRepositoryUpdateProperty) Repository() string {
+	return g.reponame
+}
+
+type GithubCommandUpdateRepositorySetDependabotAlerts struct {
+	client   engine.ReconciliatorExecutor
+	dryrun   bool
+	reponame string
+	enabled  bool
+}
+
+func (g *GithubCommandUpdateRepositorySetDependabotAlerts) Apply(ctx context.Context) {
+	g.client.UpdateRepositorySetDependabotAlerts(ctx, g.dryrun, g.reponame, g.enabled)
+}
+
+func (g *GithubCommandUpdateRepositorySetDependabotAlerts) Repository() string {
+	return g.reponame
+}
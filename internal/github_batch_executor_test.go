@@ -0,0 +1,176 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+/*
+ * RecordingExecutorMock records (in a thread safe way) the calls
+ * received from the GithubBatchExecutor
+ */
+type RecordingExecutorMock struct {
+	GoliacRemoteExecutorMock
+	mutex sync.Mutex
+	calls []string
+}
+
+func (e *RecordingExecutorMock) record(call string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.calls = append(e.calls, call)
+}
+
+func (e *RecordingExecutorMock) CreateTeam(ctx context.Context, dryrun bool, teamname string, description string, parentTeam *int, members []string) {
+	e.record("create_team " + teamname)
+}
+func (e *RecordingExecutorMock) AddRuleset(ctx context.Context, dryrun bool, ruleset *engine.GithubRuleSet) {
+	e.record("add_ruleset " + ruleset.Name)
+}
+func (e *RecordingExecutorMock) CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) {
+	e.record("create_repository " + reponame)
+}
+func (e *RecordingExecutorMock) UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) {
+	e.record("update_repository_add_team " + reponame + " " + teamslug)
+}
+func (e *RecordingExecutorMock) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
+	e.record(fmt.Sprintf("update_repository_update_bool_property %s %s %v", reponame, propertyName, propertyValue))
+}
+func (e *RecordingExecutorMock) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	e.record(fmt.Sprintf("update_repository_update_property %s %s %s", reponame, propertyName, propertyValue))
+}
+func (e *RecordingExecutorMock) UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	e.record(fmt.Sprintf("update_repository_set_dependabot_alerts %s %v", reponame, enabled))
+}
+func (e *RecordingExecutorMock) DeleteRepository(ctx context.Context, dryrun bool, reponame string) {
+	e.record("delete_repository " + reponame)
+}
+
+func indexOf(calls []string, call string) int {
+	for i, c := range calls {
+		if c == call {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestGithubBatchExecutorConcurrency(t *testing.T) {
+	nbRepos := 50
+
+	// queue the same commands a reconciliation of nbRepos repositories would
+	runBatch := func(threads int64) []string {
+		config.Config.GithubConcurrentThreads = threads
+		defer func() { config.Config.GithubConcurrentThreads = 1 }()
+
+		ctx := context.TODO()
+		executor := &RecordingExecutorMock{}
+		batch := NewGithubBatchExecutor(executor, 1000)
+
+		batch.CreateTeam(ctx, false, "team1", "", nil, []string{})
+		for i := 0; i < nbRepos; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			if i%2 == 0 {
+				batch.CreateRepository(ctx, false, reponame, "", []string{}, []string{}, map[string]bool{}, "", false)
+			}
+			batch.UpdateRepositoryAddTeamAccess(ctx, false, reponame, "team1", "push")
+			batch.UpdateRepositoryUpdateBoolProperty(ctx, false, reponame, "archived", false)
+			batch.UpdateRepositoryUpdateProperty(ctx, false, reponame, "description", "new description")
+			batch.UpdateRepositorySetDependabotAlerts(ctx, false, reponame, true)
+			batch.UpdateRepositoryUpdateBoolProperty(ctx, false, reponame, "archived", true)
+		}
+		batch.AddRuleset(ctx, false, &engine.GithubRuleSet{Name: "ruleset1"})
+		for i := 0; i < nbRepos; i += 10 {
+			batch.DeleteRepository(ctx, false, fmt.Sprintf("repo%d", i))
+		}
+
+		err := batch.Commit(ctx, false)
+		assert.Nil(t, err)
+		return executor.calls
+	}
+
+	t.Run("happy path: same executor calls regardless of the number of threads", func(t *testing.T) {
+		sequential := runBatch(1)
+		concurrent := runBatch(8)
+
+		assert.Equal(t, len(sequential), len(concurrent))
+
+		sortedSequential := append([]string{}, sequential...)
+		sortedConcurrent := append([]string{}, concurrent...)
+		sort.Strings(sortedSequential)
+		sort.Strings(sortedConcurrent)
+		assert.Equal(t, sortedSequential, sortedConcurrent)
+	})
+
+	t.Run("happy path: commands of a repository are applied in order", func(t *testing.T) {
+		calls := runBatch(8)
+
+		for i := 0; i < nbRepos; i++ {
+			reponame := fmt.Sprintf("repo%d", i)
+			addTeam := indexOf(calls, "update_repository_add_team "+reponame+" team1")
+			unarchive := indexOf(calls, "update_repository_update_bool_property "+reponame+" archived false")
+			description := indexOf(calls, "update_repository_update_property "+reponame+" description new description")
+			dependabot := indexOf(calls, "update_repository_set_dependabot_alerts "+reponame+" true")
+			archive := indexOf(calls, "update_repository_update_bool_property "+reponame+" archived true")
+
+			if i%2 == 0 {
+				assert.Less(t, indexOf(calls, "create_repository "+reponame), addTeam)
+			}
+			assert.Less(t, addTeam, unarchive)
+			assert.Less(t, unarchive, description)
+			assert.Less(t, description, dependabot)
+			assert.Less(t, dependabot, archive)
+		}
+	})
+
+	t.Run("happy path: non repository commands are barriers", func(t *testing.T) {
+		calls := runBatch(8)
+
+		createTeam := indexOf(calls, "create_team team1")
+		addRuleset := indexOf(calls, "add_ruleset ruleset1")
+		assert.Equal(t, 0, createTeam)
+		assert.Equal(t, 1+nbRepos*5+nbRepos/2, addRuleset)
+		for i := 0; i < nbRepos; i += 10 {
+			assert.Greater(t, indexOf(calls, fmt.Sprintf("delete_repository repo%d", i)), addRuleset)
+		}
+	})
+}
+
+func TestGithubBatchExecutorReadOnly(t *testing.T) {
+	defer func() { config.Config.ServerReadOnly = false }()
+	config.Config.ServerReadOnly = true
+
+	t.Run("not happy path: a read-only server never applies the changes", func(t *testing.T) {
+		ctx := context.TODO()
+		executor := &RecordingExecutorMock{}
+		batch := NewGithubBatchExecutor(executor, 1000)
+
+		batch.CreateTeam(ctx, false, "team1", "", nil, []string{})
+		batch.DeleteRepository(ctx, false, "repo1")
+		err := batch.Commit(ctx, false)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(executor.calls))
+
+		// and they are not applied by the next commit either
+		err = batch.Commit(ctx, false)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(executor.calls))
+	})
+
+	t.Run("happy path: a read-only server can run a dryrun", func(t *testing.T) {
+		ctx := context.TODO()
+		executor := &RecordingExecutorMock{}
+		batch := NewGithubBatchExecutor(executor, 1000)
+
+		batch.CreateTeam(ctx, true, "team1", "", nil, []string{})
+		err := batch.Commit(ctx, true)
+		assert.Nil(t, err)
+	})
+}
@@ -146,10 +146,11 @@ func (s *GithubWebhookServerImpl) handlePushEvent(w http.ResponseWriter, body []
 	}
 
 	// Check if the push is to the main branch
+	// (the pushes to the other branches are acknowledged, but ignored)
 	if pushEvent.Ref == fmt.Sprintf("refs/heads/%s", s.mainBranch) {
 		s.callback()
 	} else {
-		http.Error(w, "Parse push event: wrong branch", http.StatusBadRequest)
+		logrus.Debugf("push event on %s ignored (not on the %s branch)", pushEvent.Ref, s.mainBranch)
 	}
 
 	w.WriteHeader(http.StatusOK)
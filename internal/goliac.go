@@ -6,7 +6,9 @@ import (
 	"net/url"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
@@ -14,6 +16,7 @@ import (
 	"github.com/Alayacare/goliac/internal/github"
 	"github.com/Alayacare/goliac/internal/usersync"
 	"github.com/go-git/go-billy/v5"
+	"github.com/gosimple/slug"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,9 +37,41 @@ type Goliac interface {
 	// will clone run the user-plugin to sync users, and will commit to the team repository, return true if a change was done
 	UsersUpdate(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, dryrun bool, force bool) (bool, error)
 
+	// will clone and load the team repository, and look for inconsistencies with Github
+	// (like orphaned owners teams). If fix is true, Goliac will try to fix them.
+	// it returns the list of issues found
+	Doctor(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, fix bool) ([]string, error)
+
+	// will clone and load the team repository, and list the (non archived) managed
+	// repositories without any push for the given duration
+	StaleRepositories(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, since time.Duration) ([]StaleRepository, error)
+
+	// will clone the team repository, and compute the changes of the declared state (the yaml files)
+	// between the base and head refs (branches, tags or commits). Github is not involved
+	Diff(ctx context.Context, fs billy.Filesystem, repositoryUrl, base, head string) ([]engine.PlannedAction, error)
+
 	// flush remote cache
 	FlushCache()
 
+	// returns the operations collected during the last Apply (in dryrun or not)
+	GetPlannedActions() []engine.PlannedAction
+
+	// returns the sha of the teams repository commit reconciled during the last Apply (if any)
+	GetAppliedCommit() string
+
+	// returns the verified (or approved) domains of the Github organization
+	GetVerifiedDomains(ctx context.Context) []string
+
+	// returns the last Github rate limits (per resource) seen by the admin operations client
+	GetRateLimits() map[string]github.RateLimit
+
+	// if set, the changes done by Goliac to the teams repository (CODEOWNERS, users sync, ...)
+	// are pushed to this branch and a pull request is opened, instead of pushing to the teams repository branch
+	SetTargetBranch(branch string)
+
+	// if set, Apply only reconciliates the entities matching the filter (the other ones are ignored)
+	SetEntityFilter(filter engine.EntityFilter)
+
 	GetLocal() engine.GoliacLocalResources
 }
 
@@ -46,6 +81,10 @@ type GoliacImpl struct {
 	localGithubClient  github.GitHubClient // github client for team repository operations
 	remoteGithubClient github.GitHubClient // github client for admin operations
 	repoconfig         *config.RepositoryConfig
+	plannedActions     []engine.PlannedAction
+	appliedCommit      string
+	targetBranch       string
+	filter             engine.EntityFilter
 }
 
 func NewGoliacImpl() (Goliac, error) {
@@ -90,8 +129,80 @@ func (g *GoliacImpl) FlushCache() {
 	g.remote.FlushCache()
 }
 
+func (g *GoliacImpl) GetPlannedActions() []engine.PlannedAction {
+	return g.plannedActions
+}
+
+func (g *GoliacImpl) GetAppliedCommit() string {
+	return g.appliedCommit
+}
+
+func (g *GoliacImpl) GetVerifiedDomains(ctx context.Context) []string {
+	return g.remote.VerifiedDomains(ctx)
+}
+
+func (g *GoliacImpl) GetRateLimits() map[string]github.RateLimit {
+	if g.remoteGithubClient == nil {
+		return map[string]github.RateLimit{}
+	}
+	return g.remoteGithubClient.GetRateLimits()
+}
+
+func (g *GoliacImpl) SetTargetBranch(branch string) {
+	g.targetBranch = branch
+	g.local.SetTargetBranch(branch)
+}
+
+func (g *GoliacImpl) SetEntityFilter(filter engine.EntityFilter) {
+	g.filter = filter
+}
+
+/*
+ * teamsRepositoryName returns the name of the teams repository from its url
+ */
+func teamsRepositoryName(repositoryUrl string) (string, error) {
+	u, err := url.Parse(repositoryUrl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %v", repositoryUrl, err)
+	}
+	return strings.TrimSuffix(path.Base(u.Path), filepath.Ext(path.Base(u.Path))), nil
+}
+
+/*
+ * openTargetBranchPullRequest opens a pull request from the target branch
+ * to the teams repository branch, if Goliac pushed some commits to it
+ */
+func (g *GoliacImpl) openTargetBranchPullRequest(ctx context.Context, teamreponame string, branch string) error {
+	if g.targetBranch == "" || !g.local.TargetBranchPushed() {
+		return nil
+	}
+
+	// https://docs.github.com/en/rest/pulls/pulls?apiVersion=2022-11-28#create-a-pull-request
+	body, err := g.localGithubClient.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/pulls", config.Config.GithubAppOrganization, teamreponame), "POST",
+		map[string]interface{}{
+			"title": "Goliac: update the teams repository",
+			"head":  g.targetBranch,
+			"base":  branch,
+			"body":  "Changes computed by Goliac (like the CODEOWNERS file or the users sync) to review before merging.",
+		})
+	if err != nil {
+		// the pull request of a previous run is still open: the push updated it
+		if strings.Contains(string(body), "A pull request already exists") {
+			logrus.Infof("the pull request from %s to %s is already open", g.targetBranch, branch)
+			return nil
+		}
+		return fmt.Errorf("not able to open the pull request from %s to %s: %v. %s", g.targetBranch, branch, err, string(body))
+	}
+	logrus.Infof("pull request opened from %s to %s", g.targetBranch, branch)
+	return nil
+}
+
 func (g *GoliacImpl) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool, repositoryUrl, branch string, forcesync bool) (error, []error, []entity.Warning, *engine.UnmanagedResources) {
+	g.plannedActions = []engine.PlannedAction{}
+	g.appliedCommit = ""
+	endGroup := config.LogGroup("Load and validate the goliac organization")
 	err, errs, warns := g.loadAndValidateGoliacOrganization(ctx, fs, repositoryUrl, branch)
+	endGroup()
 	defer g.local.Close(fs)
 	if err != nil {
 		return fmt.Errorf("failed to load and validate: %s", err), errs, warns, nil
@@ -101,13 +212,11 @@ func (g *GoliacImpl) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool
 		return fmt.Errorf("local mode is not supported for plan/apply, you must specify the https url of the remote team git repository. Check the documentation"), errs, warns, nil
 	}
 
-	u, err := url.Parse(repositoryUrl)
+	teamreponame, err := teamsRepositoryName(repositoryUrl)
 	if err != nil {
-		return fmt.Errorf("failed to parse %s: %v", repositoryUrl, err), errs, warns, nil
+		return err, errs, warns, nil
 	}
 
-	teamreponame := strings.TrimSuffix(path.Base(u.Path), filepath.Ext(path.Base(u.Path)))
-
 	// ensure that the team repo is configured to only allow squash and merge
 	if !dryrun {
 		err := g.forceSquashMergeOnTeamsRepo(ctx, teamreponame, branch)
@@ -116,7 +225,20 @@ func (g *GoliacImpl) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool
 		}
 	}
 
+	endGroup = config.LogGroup("Reconciliation with Github")
 	unmanaged, err := g.applyToGithub(ctx, dryrun, config.Config.GithubAppOrganization, teamreponame, branch, forcesync, config.Config.SyncUsersBeforeApply)
+	endGroup()
+	if commit, err := g.local.GetHeadCommit(); err == nil {
+		g.appliedCommit = commit.Hash.String()
+	}
+	// the commits may have been pushed even if the reconciliation failed
+	if prErr := g.openTargetBranchPullRequest(ctx, teamreponame, branch); prErr != nil {
+		if err != nil {
+			logrus.Error(prErr)
+		} else {
+			err = prErr
+		}
+	}
 	if err != nil {
 		return err, errs, warns, unmanaged
 	}
@@ -188,6 +310,14 @@ func (g *GoliacImpl) forceSquashMergeOnTeamsRepo(ctx context.Context, teamrepona
 	if config.Config.ServerGitBranchProtectionRequiredCheck != "" {
 		contexts = append(contexts, config.Config.ServerGitBranchProtectionRequiredCheck)
 	}
+
+	var requiredReviews map[string]interface{}
+	if g.repoconfig.RequiredApprovals > 0 {
+		requiredReviews = map[string]interface{}{
+			"dismiss_stale_reviews":           true,
+			"required_approving_review_count": g.repoconfig.RequiredApprovals,
+		}
+	}
 	_, err = g.remoteGithubClient.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/branches/%s/protection", config.Config.GithubAppOrganization, teamreponame, branchname), "PUT",
 		map[string]interface{}{
 			"required_status_checks": map[string]interface{}{
@@ -195,14 +325,8 @@ func (g *GoliacImpl) forceSquashMergeOnTeamsRepo(ctx context.Context, teamrepona
 				"contexts": contexts, // Status checks to enforce, see scaffold.go for the job name
 			},
 			"enforce_admins":                nil,
-			"required_pull_request_reviews": nil,
-			// required_pull_request_reviews could have been
-			//{
-			// "dismiss_stale_reviews": true,   // Optional: Whether or not approved reviews are dismissed when a new commit is pushed.
-			//"require_code_owner_reviews": false,  // Optional: If set, only code owners can approve the PR.
-			//"required_approving_review_count": 1   // Number of approvals required. Set this to 1 for one review.
-			//},
-			"restrictions": nil,
+			"required_pull_request_reviews": requiredReviews, // only with required_approvals (goliac.yaml)
+			"restrictions":                  nil,
 		})
 	return err
 }
@@ -284,13 +408,24 @@ func (g *GoliacImpl) applyCommitsToGithub(ctx context.Context, dryrun bool, team
 	reposToArchive := make(map[string]*engine.GithubRepoComparable)
 	var unmanaged *engine.UnmanagedResources
 
+	// max_destructive_changes limits the whole apply, not each commit
+	ctx = context.WithValue(ctx, engine.KeyDestructiveChanges, &engine.DestructiveChanges{})
+	// the Goliac commits bypass the approvals required on the teams repository
+	ctx = context.WithValue(ctx, engine.KeyTeamsRepoApp, g.localGithubClient.GetAppSlug())
+
 	commits, err := g.local.ListCommitsFromTag(GOLIAC_GIT_TAG)
 	// if we can get commits
 	if err != nil {
+		if commit, err := g.local.GetHeadCommit(); err == nil && !dryrun {
+			if err := g.checkRequiredApprovals(ctx, teamreponame, commit); err != nil {
+				return unmanaged, err
+			}
+		}
 		ga := NewGithubBatchExecutor(g.remote, g.repoconfig.MaxChangesets)
 		reconciliator := engine.NewGoliacReconciliatorImpl(ga, g.repoconfig)
 
-		unmanaged, err = reconciliator.Reconciliate(ctx, g.local, g.remote, teamreponame, dryrun, reposToArchive)
+		unmanaged, err = reconciliator.Reconciliate(ctx, g.local, g.remote, teamreponame, dryrun, reposToArchive, g.filter)
+		g.plannedActions = append(g.plannedActions, reconciliator.PlannedActions()...)
 		if err != nil {
 			return unmanaged, fmt.Errorf("error when reconciliating: %v", err)
 		}
@@ -304,9 +439,15 @@ func (g *GoliacImpl) applyCommitsToGithub(ctx context.Context, dryrun bool, team
 
 		if err == nil {
 			ctx = context.WithValue(ctx, engine.KeyAuthor, fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email))
+			if !dryrun {
+				if err := g.checkRequiredApprovals(ctx, teamreponame, commit); err != nil {
+					return unmanaged, err
+				}
+			}
 		}
 
-		unmanaged, err = reconciliator.Reconciliate(ctx, g.local, g.remote, teamreponame, dryrun, reposToArchive)
+		unmanaged, err = reconciliator.Reconciliate(ctx, g.local, g.remote, teamreponame, dryrun, reposToArchive, g.filter)
+		g.plannedActions = append(g.plannedActions, reconciliator.PlannedActions()...)
 		if err != nil {
 			return unmanaged, fmt.Errorf("error when reconciliating: %v", err)
 		}
@@ -314,6 +455,12 @@ func (g *GoliacImpl) applyCommitsToGithub(ctx context.Context, dryrun bool, team
 		// we have 1 or more commits to apply
 		var lastErr error
 		for _, commit := range commits {
+			// the next commits contain the changes of this one: the apply stops there
+			if !dryrun {
+				if err := g.checkRequiredApprovals(ctx, teamreponame, commit); err != nil {
+					return unmanaged, err
+				}
+			}
 			if err := g.local.CheckoutCommit(commit); err == nil {
 				errs, _ := g.local.LoadAndValidate()
 				if len(errs) > 0 {
@@ -326,7 +473,8 @@ func (g *GoliacImpl) applyCommitsToGithub(ctx context.Context, dryrun bool, team
 				reconciliator := engine.NewGoliacReconciliatorImpl(ga, g.repoconfig)
 
 				ctx := context.WithValue(ctx, engine.KeyAuthor, fmt.Sprintf("%s <%s>", commit.Author.Name, commit.Author.Email))
-				unmanaged, err = reconciliator.Reconciliate(ctx, g.local, g.remote, teamreponame, dryrun, reposToArchive)
+				unmanaged, err = reconciliator.Reconciliate(ctx, g.local, g.remote, teamreponame, dryrun, reposToArchive, g.filter)
+				g.plannedActions = append(g.plannedActions, reconciliator.PlannedActions()...)
 				if err != nil {
 					// we keep the last error and continue
 					// to see if the next commit can be applied without error
@@ -335,7 +483,8 @@ func (g *GoliacImpl) applyCommitsToGithub(ctx context.Context, dryrun bool, team
 				} else {
 					lastErr = nil
 				}
-				if !dryrun && err == nil {
+				// a filtered apply doesn't apply the whole commit
+				if !dryrun && err == nil && g.filter == nil {
 					accessToken, err := g.localGithubClient.GetAccessToken(ctx)
 					if err != nil {
 						return unmanaged, err
@@ -391,5 +540,128 @@ func (g *GoliacImpl) UsersUpdate(ctx context.Context, fs billy.Filesystem, repos
 		return false, fmt.Errorf("user sync Plugin %s not found", repoconfig.UserSync.Plugin)
 	}
 
-	return g.local.SyncUsersAndTeams(repoconfig, userplugin, accessToken, dryrun, force)
+	change, err := g.local.SyncUsersAndTeams(repoconfig, userplugin, accessToken, dryrun, force)
+	if err != nil {
+		return change, err
+	}
+
+	teamreponame, err := teamsRepositoryName(repositoryUrl)
+	if err != nil {
+		return change, err
+	}
+	return change, g.openTargetBranchPullRequest(ctx, teamreponame, branch)
+}
+
+func (g *GoliacImpl) Doctor(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, fix bool) ([]string, error) {
+	err, _, _ := g.loadAndValidateGoliacOrganization(ctx, fs, repositoryUrl, branch)
+	defer g.local.Close(fs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load and validate: %s", err)
+	}
+
+	err = g.remote.Load(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("error when fetching data from Github: %v", err)
+	}
+
+	return g.doctorOrphanedOwnersTeams(ctx, fix), nil
+}
+
+type StaleRepository struct {
+	Name     string
+	PushedAt *time.Time // nil if the repository was never pushed
+}
+
+func (g *GoliacImpl) StaleRepositories(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, since time.Duration) ([]StaleRepository, error) {
+	err, _, _ := g.loadAndValidateGoliacOrganization(ctx, fs, repositoryUrl, branch)
+	defer g.local.Close(fs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load and validate: %s", err)
+	}
+
+	err = g.remote.Load(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("error when fetching data from Github: %v", err)
+	}
+
+	return g.staleRepositories(ctx, time.Now().Add(-since)), nil
+}
+
+/*
+ * staleRepositories returns the managed repositories (not archived) that
+ * were not pushed since the given time, the oldest first
+ */
+func (g *GoliacImpl) staleRepositories(ctx context.Context, since time.Time) []StaleRepository {
+	stale := []StaleRepository{}
+
+	rRepos := g.remote.Repositories(ctx)
+	for reponame, lRepo := range g.local.Repositories() {
+		if lRepo.Archived {
+			continue
+		}
+		rRepo, ok := rRepos[reponame]
+		if !ok {
+			// not created yet
+			continue
+		}
+		if rRepo.PushedAt == nil || rRepo.PushedAt.Before(since) {
+			stale = append(stale, StaleRepository{
+				Name:     reponame,
+				PushedAt: rRepo.PushedAt,
+			})
+		}
+	}
+
+	// never pushed repositories first
+	pushedAt := func(r StaleRepository) time.Time {
+		if r.PushedAt == nil {
+			return time.Time{}
+		}
+		return *r.PushedAt
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		ti, tj := pushedAt(stale[i]), pushedAt(stale[j])
+		if ti.Equal(tj) {
+			return stale[i].Name < stale[j].Name
+		}
+		return ti.Before(tj)
+	})
+
+	return stale
+}
+
+/*
+ * doctorOrphanedOwnersTeams looks for "<team>-goliac-owners" teams that don't have
+ * a corresponding team managed by Goliac anymore (for example after manual edits),
+ * and removes them if fix is true and destructive operations on teams are allowed
+ */
+func (g *GoliacImpl) doctorOrphanedOwnersTeams(ctx context.Context, fix bool) []string {
+	issues := []string{}
+
+	localTeams := make(map[string]bool)
+	for teamname := range g.local.Teams() {
+		localTeams[slug.Make(teamname)] = true
+	}
+
+	for teamslug := range g.remote.Teams(ctx) {
+		if !strings.HasSuffix(teamslug, config.Config.GoliacTeamOwnerSuffix) {
+			continue
+		}
+		if localTeams[strings.TrimSuffix(teamslug, config.Config.GoliacTeamOwnerSuffix)] {
+			continue
+		}
+
+		issues = append(issues, fmt.Sprintf("orphaned owners team: %s", teamslug))
+		if !fix {
+			continue
+		}
+		if !g.repoconfig.DestructiveOperations.AllowDestructiveTeams {
+			logrus.Warnf("not removing the orphaned owners team %s: destructive operations on teams are not allowed", teamslug)
+			continue
+		}
+		logrus.WithFields(map[string]interface{}{"dryrun": false, "command": "delete_team"}).Infof("teamslug: %s", teamslug)
+		g.remote.DeleteTeam(ctx, false, teamslug)
+	}
+
+	return issues
 }
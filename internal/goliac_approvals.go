@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type commitPullRequest struct {
+	Number         int     `json:"number"`
+	MergedAt       *string `json:"merged_at"`
+	MergeCommitSha string  `json:"merge_commit_sha"`
+}
+
+type repositoryActivity struct {
+	After string `json:"after"`
+	Actor struct {
+		Login string `json:"login"`
+	} `json:"actor"`
+}
+
+type pullRequestReview struct {
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	State string `json:"state"`
+}
+
+/*
+ * checkRequiredApprovals returns an error if the pull request that merged the
+ * commit in the teams repository has less than required_approvals approvals.
+ * The commits pushed by Goliac itself (codeowners, users sync, ...) are not checked
+ */
+func (g *GoliacImpl) checkRequiredApprovals(ctx context.Context, teamreponame string, commit *object.Commit) error {
+	required := g.repoconfig.RequiredApprovals
+	if required <= 0 {
+		return nil
+	}
+	sha := commit.Hash.String()
+
+	// https://docs.github.com/en/rest/commits/commits?apiVersion=2022-11-28#list-pull-requests-associated-with-a-commit
+	body, err := g.remoteGithubClient.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/commits/%s/pulls", config.Config.GithubAppOrganization, teamreponame, sha), "GET", nil)
+	if err != nil {
+		return fmt.Errorf("not able to get the pull request of commit %s: %v. %s", sha, err, string(body))
+	}
+	var pulls []commitPullRequest
+	if err := json.Unmarshal(body, &pulls); err != nil {
+		return fmt.Errorf("not able to get the pull request of commit %s: %v", sha, err)
+	}
+	number := 0
+	for _, pull := range pulls {
+		// the pull requests are squashed and merged (forceSquashMergeOnTeamsRepo only
+		// allows the squash merge on the teams repository): the merge commit is the
+		// commit itself. A commit of a merged branch (merge or rebase) is not applied
+		if pull.MergedAt != nil && pull.MergeCommitSha == sha {
+			number = pull.Number
+			break
+		}
+	}
+	if number == 0 {
+		pushedByGoliac, err := g.pushedByGoliac(ctx, teamreponame, sha)
+		if err != nil {
+			return err
+		}
+		if pushedByGoliac {
+			return nil
+		}
+		return fmt.Errorf("commit %s was not merged from a pull request: %d approval(s) required (required_approvals in goliac.yaml)", sha, required)
+	}
+
+	// https://docs.github.com/en/rest/pulls/reviews?apiVersion=2022-11-28#list-reviews-for-a-pull-request
+	body, err = g.remoteGithubClient.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/pulls/%d/reviews?per_page=100", config.Config.GithubAppOrganization, teamreponame, number), "GET", nil)
+	if err != nil {
+		return fmt.Errorf("not able to get the reviews of pull request #%d: %v. %s", number, err, string(body))
+	}
+	var reviews []pullRequestReview
+	if err := json.Unmarshal(body, &reviews); err != nil {
+		return fmt.Errorf("not able to get the reviews of pull request #%d: %v", number, err)
+	}
+
+	// only the last review of each reviewer counts (a comment doesn't change it)
+	states := make(map[string]string)
+	for _, review := range reviews {
+		if review.State == "COMMENTED" || review.State == "PENDING" {
+			continue
+		}
+		states[review.User.Login] = review.State
+	}
+	approvals := 0
+	for _, state := range states {
+		if state == "APPROVED" {
+			approvals++
+		}
+	}
+	if approvals < required {
+		return fmt.Errorf("pull request #%d (commit %s) has %d approval(s), %d required (required_approvals in goliac.yaml)", number, sha, approvals, required)
+	}
+	return nil
+}
+
+/*
+ * pushedByGoliac returns true if the commit was pushed to the teams repository
+ * by the Goliac app. Unlike the commit author (set by whoever commits), the
+ * push actor is set by Github.
+ * The activities are paginated with cursors (in the Link header): they are
+ * filtered on the Goliac app, so the first page holds its last 100 pushes. An
+ * older push is not found, and the commit is then checked as any other one
+ */
+func (g *GoliacImpl) pushedByGoliac(ctx context.Context, teamreponame string, sha string) (bool, error) {
+	// the commits are pushed with the token of the teams repository app
+	actor := g.localGithubClient.GetAppSlug() + "[bot]"
+	// https://docs.github.com/en/rest/repos/repos?apiVersion=2022-11-28#list-repository-activities
+	body, err := g.remoteGithubClient.CallRestAPI(ctx, fmt.Sprintf("/repos/%s/%s/activity?activity_type=push&actor=%s&per_page=100", config.Config.GithubAppOrganization, teamreponame, url.QueryEscape(actor)), "GET", nil)
+	if err != nil {
+		return false, fmt.Errorf("not able to get the pushes of the teams repository: %v. %s", err, string(body))
+	}
+	var activities []repositoryActivity
+	if err := json.Unmarshal(body, &activities); err != nil {
+		return false, fmt.Errorf("not able to get the pushes of the teams repository: %v", err)
+	}
+	for _, activity := range activities {
+		if activity.After == sha {
+			return activity.Actor.Login == actor, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,349 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/go-git/go-billy/v5"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * localResourcesSnapshot keeps the local resources loaded at a given git ref
+ */
+type localResourcesSnapshot struct {
+	teams         map[string]*entity.Team
+	repositories  map[string]*entity.Repository
+	users         map[string]*entity.User
+	externalUsers map[string]*entity.User
+	rulesets      map[string]*entity.RuleSet
+	orgVariables  map[string]*entity.OrgVariable
+	orgSecrets    map[string]*entity.OrgSecret
+}
+
+func (s *localResourcesSnapshot) Teams() map[string]*entity.Team {
+	return s.teams
+}
+func (s *localResourcesSnapshot) Repositories() map[string]*entity.Repository {
+	return s.repositories
+}
+func (s *localResourcesSnapshot) Users() map[string]*entity.User {
+	return s.users
+}
+func (s *localResourcesSnapshot) ExternalUsers() map[string]*entity.User {
+	return s.externalUsers
+}
+func (s *localResourcesSnapshot) RuleSets() map[string]*entity.RuleSet {
+	return s.rulesets
+}
+func (s *localResourcesSnapshot) OrgVariables() map[string]*entity.OrgVariable {
+	return s.orgVariables
+}
+func (s *localResourcesSnapshot) OrgSecrets() map[string]*entity.OrgSecret {
+	return s.orgSecrets
+}
+
+func (g *GoliacImpl) Diff(ctx context.Context, fs billy.Filesystem, repositoryUrl, base, head string) ([]engine.PlannedAction, error) {
+	if !strings.HasPrefix(repositoryUrl, "https://") {
+		return nil, fmt.Errorf("local mode is not supported for diff, you must specify the https url of the remote team git repository. Check the documentation")
+	}
+
+	accessToken, err := g.localGithubClient.GetAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// all the remote branches are fetched, the default branch is only used for the checkout
+	err = g.local.Clone(fs, accessToken, repositoryUrl, config.Config.ServerGitBranch)
+	defer g.local.Close(fs)
+	if err != nil {
+		return nil, fmt.Errorf("unable to clone: %v", err)
+	}
+
+	return g.diffRefs(base, head)
+}
+
+func (g *GoliacImpl) diffRefs(base, head string) ([]engine.PlannedAction, error) {
+	baseResources, err := g.loadLocalAtRef(base)
+	if err != nil {
+		return nil, err
+	}
+	headResources, err := g.loadLocalAtRef(head)
+	if err != nil {
+		return nil, err
+	}
+	return diffLocalResources(baseResources, headResources), nil
+}
+
+func (g *GoliacImpl) loadLocalAtRef(ref string) (engine.GoliacLocalResources, error) {
+	if err := g.local.CheckoutRef(ref); err != nil {
+		return nil, err
+	}
+	errs, _ := g.local.LoadAndValidate()
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("not able to load and validate the goliac organization at %s: %v", ref, errs[0])
+	}
+	// the local maps are replaced (not updated) at each load
+	return &localResourcesSnapshot{
+		teams:         g.local.Teams(),
+		repositories:  g.local.Repositories(),
+		users:         g.local.Users(),
+		externalUsers: g.local.ExternalUsers(),
+		rulesets:      g.local.RuleSets(),
+		orgVariables:  g.local.OrgVariables(),
+		orgSecrets:    g.local.OrgSecrets(),
+	}, nil
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	set := make(map[string]bool)
+	for _, m := range maps {
+		for k := range m {
+			set[k] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+/*
+ * specProperties returns the yaml properties of a spec (as declared in the yaml files)
+ */
+func specProperties(spec interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return properties
+	}
+	yaml.Unmarshal(out, &properties)
+	return properties
+}
+
+/*
+ * diffLocalResources computes the changes of the declared state (the yaml model)
+ * between base and head. Github is not involved
+ */
+func diffLocalResources(base, head engine.GoliacLocalResources) []engine.PlannedAction {
+	actions := []engine.PlannedAction{}
+	record := func(operation string, target string, before interface{}, after interface{}) {
+		actions = append(actions, engine.PlannedAction{
+			Operation: operation,
+			Target:    target,
+			Before:    before,
+			After:     after,
+		})
+	}
+
+	// users
+	diffUsers := func(prefix string, bUsers, hUsers map[string]*entity.User) {
+		for _, name := range sortedKeys(bUsers, hUsers) {
+			bUser, inBase := bUsers[name]
+			hUser, inHead := hUsers[name]
+			switch {
+			case !inBase:
+				record("add_"+prefix, prefix+"/"+name, nil, hUser.Spec.GithubID)
+			case !inHead:
+				record("remove_"+prefix, prefix+"/"+name, bUser.Spec.GithubID, nil)
+			case bUser.Spec.GithubID != hUser.Spec.GithubID:
+				record("update_"+prefix, prefix+"/"+name, bUser.Spec.GithubID, hUser.Spec.GithubID)
+			}
+		}
+	}
+	diffUsers("user", base.Users(), head.Users())
+	diffUsers("external_user", base.ExternalUsers(), head.ExternalUsers())
+
+	// teams
+	teamRoles := func(team *entity.Team) map[string]string {
+		roles := make(map[string]string)
+		for _, m := range team.Spec.Members {
+			roles[m] = "member"
+		}
+		for _, o := range team.Spec.Owners {
+			roles[o] = "owner"
+		}
+		return roles
+	}
+	bTeams, hTeams := base.Teams(), head.Teams()
+	for _, name := range sortedKeys(bTeams, hTeams) {
+		bTeam, inBase := bTeams[name]
+		hTeam, inHead := hTeams[name]
+		if !inBase {
+			record("create_team", "team/"+name, nil, map[string]interface{}{"owners": hTeam.Spec.Owners, "members": hTeam.Spec.Members, "parent_team": hTeam.ParentTeam})
+			continue
+		}
+		if !inHead {
+			record("delete_team", "team/"+name, nil, nil)
+			continue
+		}
+		bRoles, hRoles := teamRoles(bTeam), teamRoles(hTeam)
+		for _, member := range sortedKeys(bRoles, hRoles) {
+			bRole, hRole := bRoles[member], hRoles[member]
+			switch {
+			case bRole == "":
+				record("update_team_add_member", "team/"+name+"/member/"+member, nil, hRole)
+			case hRole == "":
+				record("update_team_remove_member", "team/"+name+"/member/"+member, bRole, nil)
+			case bRole != hRole:
+				record("update_team_update_member", "team/"+name+"/member/"+member, bRole, hRole)
+			}
+		}
+		if !reflect.DeepEqual(bTeam.ParentTeam, hTeam.ParentTeam) {
+			record("update_team_parentteam", "team/"+name, bTeam.ParentTeam, hTeam.ParentTeam)
+		}
+		if bTeam.Spec.ExternallyManaged != hTeam.Spec.ExternallyManaged {
+			record("update_team_externally_managed", "team/"+name, bTeam.Spec.ExternallyManaged, hTeam.Spec.ExternallyManaged)
+		}
+		if res, _, _ := entity.StringArrayEquivalent(bTeam.Spec.ExternalGroups, hTeam.Spec.ExternalGroups); !res {
+			record("update_team_external_groups", "team/"+name, bTeam.Spec.ExternalGroups, hTeam.Spec.ExternalGroups)
+		}
+		if bTeam.Spec.Privacy != hTeam.Spec.Privacy {
+			record("update_team_update_property", "team/"+name+"/privacy", bTeam.Spec.Privacy, hTeam.Spec.Privacy)
+		}
+		if bTeam.Spec.NotificationSetting != hTeam.Spec.NotificationSetting {
+			record("update_team_update_property", "team/"+name+"/notification_setting", bTeam.Spec.NotificationSetting, hTeam.Spec.NotificationSetting)
+		}
+	}
+
+	// repositories
+	bRepos, hRepos := base.Repositories(), head.Repositories()
+	addedRepos := map[string]*entity.Repository{}
+	removedRepos := map[string]*entity.Repository{}
+	for _, name := range sortedKeys(bRepos, hRepos) {
+		bRepo, inBase := bRepos[name]
+		hRepo, inHead := hRepos[name]
+		if !inBase {
+			addedRepos[name] = hRepo
+			continue
+		}
+		if !inHead {
+			removedRepos[name] = bRepo
+			continue
+		}
+		diffRepository(name, bRepo, hRepo, record)
+	}
+	// a repository removed and another one added with the same owner and spec is a rename
+	for _, oldname := range sortedKeys(removedRepos) {
+		bRepo := removedRepos[oldname]
+		for _, newname := range sortedKeys(addedRepos) {
+			hRepo := addedRepos[newname]
+			if reflect.DeepEqual(bRepo.Owner, hRepo.Owner) && bRepo.Archived == hRepo.Archived && reflect.DeepEqual(bRepo.Spec, hRepo.Spec) {
+				record("rename_repository", "repository/"+oldname, oldname, newname)
+				delete(removedRepos, oldname)
+				delete(addedRepos, newname)
+				break
+			}
+		}
+	}
+	for _, name := range sortedKeys(addedRepos) {
+		hRepo := addedRepos[name]
+		record("create_repository", "repository/"+name, nil, map[string]interface{}{"owner": hRepo.Owner, "archived": hRepo.Archived, "spec": specProperties(hRepo.Spec)})
+	}
+	for _, name := range sortedKeys(removedRepos) {
+		record("delete_repository", "repository/"+name, nil, nil)
+	}
+
+	// rulesets
+	bRulesets, hRulesets := base.RuleSets(), head.RuleSets()
+	for _, name := range sortedKeys(bRulesets, hRulesets) {
+		bRuleset, inBase := bRulesets[name]
+		hRuleset, inHead := hRulesets[name]
+		switch {
+		case !inBase:
+			record("add_ruleset", "ruleset/"+name, nil, specProperties(hRuleset.Spec))
+		case !inHead:
+			record("delete_ruleset", "ruleset/"+name, nil, nil)
+		case !reflect.DeepEqual(bRuleset.Spec, hRuleset.Spec):
+			record("update_ruleset", "ruleset/"+name, specProperties(bRuleset.Spec), specProperties(hRuleset.Spec))
+		}
+	}
+
+	// organization variables
+	bVariables, hVariables := base.OrgVariables(), head.OrgVariables()
+	for _, name := range sortedKeys(bVariables, hVariables) {
+		bVariable, inBase := bVariables[name]
+		hVariable, inHead := hVariables[name]
+		switch {
+		case !inBase:
+			record("add_org_variable", "variable/"+name, nil, hVariable)
+		case !inHead:
+			record("delete_org_variable", "variable/"+name, bVariable, nil)
+		case !reflect.DeepEqual(bVariable, hVariable):
+			record("update_org_variable", "variable/"+name, bVariable, hVariable)
+		}
+	}
+
+	// organization secrets (only their visibility)
+	bSecrets, hSecrets := base.OrgSecrets(), head.OrgSecrets()
+	for _, name := range sortedKeys(bSecrets, hSecrets) {
+		bSecret, inBase := bSecrets[name]
+		hSecret, inHead := hSecrets[name]
+		switch {
+		case !inBase:
+			record("add_org_secret", "secret/"+name, nil, hSecret)
+		case !inHead:
+			record("delete_org_secret", "secret/"+name, bSecret, nil)
+		case !reflect.DeepEqual(bSecret, hSecret):
+			record("update_org_secret", "secret/"+name, bSecret, hSecret)
+		}
+	}
+
+	return actions
+}
+
+func diffRepository(name string, bRepo, hRepo *entity.Repository, record func(string, string, interface{}, interface{})) {
+	if !reflect.DeepEqual(bRepo.Owner, hRepo.Owner) {
+		record("update_repository_owner", "repository/"+name, bRepo.Owner, hRepo.Owner)
+	}
+	if bRepo.Archived != hRepo.Archived {
+		if hRepo.Archived {
+			record("archive_repository", "repository/"+name, nil, nil)
+		} else {
+			record("unarchive_repository", "repository/"+name, nil, nil)
+		}
+	}
+
+	// team permissions (the owner is always a writer)
+	permissions := func(repo *entity.Repository) map[string]string {
+		perms := make(map[string]string)
+		for _, t := range repo.Spec.Readers {
+			perms[t] = "pull"
+		}
+		for _, t := range repo.Spec.Writers {
+			perms[t] = "push"
+		}
+		return perms
+	}
+	bPerms, hPerms := permissions(bRepo), permissions(hRepo)
+	for _, team := range sortedKeys(bPerms, hPerms) {
+		bPerm, hPerm := bPerms[team], hPerms[team]
+		switch {
+		case bPerm == "":
+			record("update_repository_add_team", "repository/"+name+"/team/"+team, nil, hPerm)
+		case hPerm == "":
+			record("update_repository_remove_team", "repository/"+name+"/team/"+team, bPerm, nil)
+		case bPerm != hPerm:
+			record("update_repository_update_team", "repository/"+name+"/team/"+team, bPerm, hPerm)
+		}
+	}
+
+	// all the other properties, as declared in the yaml files
+	bProperties, hProperties := specProperties(bRepo.Spec), specProperties(hRepo.Spec)
+	for _, key := range []string{"writers", "readers"} {
+		delete(bProperties, key)
+		delete(hProperties, key)
+	}
+	for _, key := range sortedKeys(bProperties, hProperties) {
+		if !reflect.DeepEqual(bProperties[key], hProperties[key]) {
+			record("update_repository_update_property", "repository/"+name+"/"+key, bProperties[key], hProperties[key])
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDiffSnapshot() *localResourcesSnapshot {
+	team1 := &entity.Team{}
+	team1.Name = "team1"
+	team1.Spec.Owners = []string{"user1"}
+	team1.Spec.Members = []string{"user2"}
+
+	repo1 := &entity.Repository{}
+	repo1.Name = "repo1"
+	owner := "team1"
+	repo1.Owner = &owner
+	repo1.Spec.Readers = []string{"team2"}
+
+	return &localResourcesSnapshot{
+		teams:         map[string]*entity.Team{"team1": team1},
+		repositories:  map[string]*entity.Repository{"repo1": repo1},
+		users:         map[string]*entity.User{},
+		externalUsers: map[string]*entity.User{},
+		rulesets:      map[string]*entity.RuleSet{},
+		orgVariables:  map[string]*entity.OrgVariable{},
+	}
+}
+
+func TestDiffLocalResources(t *testing.T) {
+
+	t.Run("happy path: no changes", func(t *testing.T) {
+		actions := diffLocalResources(newDiffSnapshot(), newDiffSnapshot())
+		assert.Equal(t, 0, len(actions))
+	})
+
+	t.Run("happy path: team member added and promoted", func(t *testing.T) {
+		head := newDiffSnapshot()
+		head.teams["team1"].Spec.Owners = []string{"user1", "user2"}
+		head.teams["team1"].Spec.Members = []string{"user3"}
+
+		actions := diffLocalResources(newDiffSnapshot(), head)
+		assert.Equal(t, 2, len(actions))
+		assert.Equal(t, "update_team_update_member", actions[0].Operation)
+		assert.Equal(t, "team/team1/member/user2", actions[0].Target)
+		assert.Equal(t, "member", actions[0].Before)
+		assert.Equal(t, "owner", actions[0].After)
+		assert.Equal(t, "update_team_add_member", actions[1].Operation)
+		assert.Equal(t, "team/team1/member/user3", actions[1].Target)
+	})
+
+	t.Run("happy path: repository permission changed", func(t *testing.T) {
+		head := newDiffSnapshot()
+		head.repositories["repo1"].Spec.Readers = []string{}
+		head.repositories["repo1"].Spec.Writers = []string{"team2"}
+
+		actions := diffLocalResources(newDiffSnapshot(), head)
+		assert.Equal(t, 1, len(actions))
+		assert.Equal(t, "update_repository_update_team", actions[0].Operation)
+		assert.Equal(t, "repository/repo1/team/team2", actions[0].Target)
+		assert.Equal(t, "pull", actions[0].Before)
+		assert.Equal(t, "push", actions[0].After)
+	})
+
+	t.Run("happy path: repository property changed", func(t *testing.T) {
+		head := newDiffSnapshot()
+		head.repositories["repo1"].Spec.IsPublic = true
+
+		actions := diffLocalResources(newDiffSnapshot(), head)
+		assert.Equal(t, 1, len(actions))
+		assert.Equal(t, "update_repository_update_property", actions[0].Operation)
+		assert.Equal(t, "repository/repo1/public", actions[0].Target)
+		assert.Equal(t, nil, actions[0].Before)
+		assert.Equal(t, true, actions[0].After)
+	})
+
+	t.Run("happy path: repository renamed", func(t *testing.T) {
+		head := newDiffSnapshot()
+		repo := head.repositories["repo1"]
+		delete(head.repositories, "repo1")
+		head.repositories["repo1-renamed"] = repo
+
+		actions := diffLocalResources(newDiffSnapshot(), head)
+		assert.Equal(t, 1, len(actions))
+		assert.Equal(t, "rename_repository", actions[0].Operation)
+		assert.Equal(t, "repo1", actions[0].Before)
+		assert.Equal(t, "repo1-renamed", actions[0].After)
+	})
+
+	t.Run("happy path: repository and team created", func(t *testing.T) {
+		head := newDiffSnapshot()
+		team2 := &entity.Team{}
+		team2.Name = "team2"
+		team2.Spec.Owners = []string{"user3"}
+		head.teams["team2"] = team2
+		repo2 := &entity.Repository{}
+		repo2.Name = "repo2"
+		repo2.Spec.IsPublic = true
+		head.repositories["repo2"] = repo2
+
+		actions := diffLocalResources(newDiffSnapshot(), head)
+		assert.Equal(t, 2, len(actions))
+		assert.Equal(t, "create_team", actions[0].Operation)
+		assert.Equal(t, "create_repository", actions[1].Operation)
+		assert.Equal(t, "repository/repo2", actions[1].Target)
+	})
+}
+
+func TestGoliacDiff(t *testing.T) {
+
+	t.Run("happy path: diff between a tag and a commit", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)        // create a fake bare repository
+		fs.MkdirAll("teams", 0755)      // create a fake cloned repository
+		fs.MkdirAll(os.TempDir(), 0755) // need a tmp folder
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture1)
+		assert.Nil(t, err)
+
+		// add user3 as a member of team1, and give read access to repo1 to team2
+		utils.WriteFile(clonedFs, "teams/team1/team.yaml", []byte(`apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+    - user1
+    - user2
+  members:
+    - user3
+`), 0644)
+		utils.WriteFile(clonedFs, "teams/team1/repo1.yaml", []byte(`apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  readers:
+    - team2
+`), 0644)
+		wt, err := clonedRepo.Worktree()
+		assert.Nil(t, err)
+		_, err = wt.Add(".")
+		assert.Nil(t, err)
+		hash, err := wt.Commit("update team1", &git.CommitOptions{
+			Author: &object.Signature{
+				Name:  "Goliac",
+				Email: "goliac@example.com",
+				When:  time.Now(),
+			},
+		})
+		assert.Nil(t, err)
+
+		goliac := GoliacImpl{
+			local:             engine.NewGoliacLocalImplWithRepo(clonedRepo),
+			remote:            NewGoliacRemoteExecutorMock(),
+			localGithubClient: NewGitHubClientMock(),
+		}
+
+		actions, err := goliac.diffRefs("v0.1.0", hash.String())
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(actions))
+		assert.Equal(t, "update_team_add_member", actions[0].Operation)
+		assert.Equal(t, "team/team1/member/user3", actions[0].Target)
+		assert.Equal(t, "update_repository_add_team", actions[1].Operation)
+		assert.Equal(t, "repository/repo1/team/team2", actions[1].Target)
+		assert.Equal(t, "pull", actions[1].After)
+
+		// and the other way around
+		actions, err = goliac.diffRefs(hash.String(), "v0.1.0")
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(actions))
+		assert.Equal(t, "update_team_remove_member", actions[0].Operation)
+		assert.Equal(t, "update_repository_remove_team", actions[1].Operation)
+	})
+
+	t.Run("not happy path: unknown ref", func(t *testing.T) {
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)
+		fs.MkdirAll("teams", 0755)
+		fs.MkdirAll(os.TempDir(), 0755)
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture1)
+		assert.Nil(t, err)
+
+		goliac := GoliacImpl{
+			local:             engine.NewGoliacLocalImplWithRepo(clonedRepo),
+			remote:            NewGoliacRemoteExecutorMock(),
+			localGithubClient: NewGitHubClientMock(),
+		}
+
+		_, err = goliac.diffRefs("v0.1.0", "unknown-branch")
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+
+	"github.com/Alayacare/goliac/internal/entity"
+)
+
+/*
+ * ErrorGroup gathers the errors reported for the same entity
+ * (a repository, a team, a ruleset, ...)
+ */
+type ErrorGroup struct {
+	Kind   string // repository, team, ruleset, user, org variables, org secrets (or empty if unknown)
+	Name   string
+	Errors []error
+}
+
+func (g *ErrorGroup) Heading() string {
+	if g.Kind == "" {
+		return fmt.Sprintf("other: %d error(s)", len(g.Errors))
+	}
+	// the org variables and the org secrets are a single file
+	if g.Name == "" {
+		return fmt.Sprintf("%s: %d error(s)", g.Kind, len(g.Errors))
+	}
+	return fmt.Sprintf("%s %s: %d error(s)", g.Kind, g.Name, len(g.Errors))
+}
+
+var errorOperation = regexp.MustCompile(`^invalid ([^:]+):`)
+
+/*
+ * errorEntity returns the entity an error is about (empty if the error is
+ * not an entity.EntityError)
+ */
+func errorEntity(err error) (string, string) {
+	var entityErr *entity.EntityError
+	if errors.As(err, &entityErr) {
+		return entityErr.Kind, entityErr.Name
+	}
+	return "", ""
+}
+
+/*
+ * errorOperationName returns the attribute an error is about (for example
+ * "labels" for "invalid labels: ..."), or "validation"
+ */
+func errorOperationName(err error) string {
+	if m := errorOperation.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return "validation"
+}
+
+/*
+ * GroupErrorsByEntity groups the errors per entity, sorted by kind and name.
+ * Errors that cannot be attached to an entity are returned last.
+ */
+func GroupErrorsByEntity(errs []error) []*ErrorGroup {
+	groups := make(map[string]*ErrorGroup)
+	for _, err := range errs {
+		kind, name := errorEntity(err)
+		key := kind + "/" + name
+		group, ok := groups[key]
+		if !ok {
+			group = &ErrorGroup{Kind: kind, Name: name}
+			groups[key] = group
+		}
+		group.Errors = append(group.Errors, err)
+	}
+
+	sorted := make([]*ErrorGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if (sorted[i].Kind == "") != (sorted[j].Kind == "") {
+			return sorted[j].Kind == ""
+		}
+		if sorted[i].Kind != sorted[j].Kind {
+			return sorted[i].Kind < sorted[j].Kind
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+/*
+ * WriteErrorReport writes the errors grouped by entity, for example
+ *
+ *	2 error(s) found
+ *	repository repo1: 2 error(s)
+ *	  - [labels] invalid labels: empty name in repository filename teams/team1/repo1.yaml
+ *	  - [webhooks] invalid webhooks: empty url in repository filename teams/team1/repo1.yaml
+ */
+func WriteErrorReport(w io.Writer, errs []error) {
+	if len(errs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%d error(s) found\n", len(errs))
+	for _, group := range GroupErrorsByEntity(errs) {
+		fmt.Fprintln(w, group.Heading())
+		for _, err := range group.Errors {
+			fmt.Fprintf(w, "  - [%s] %v\n", errorOperationName(err), err)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorReport(t *testing.T) {
+
+	t.Run("happy path: errors of 2 repositories are grouped under separate headings", func(t *testing.T) {
+		errs := []error{
+			entity.NewEntityError("repository", "repo2", "teams/team1/repo2.yaml", fmt.Errorf("invalid labels: empty name in repository filename teams/team1/repo2.yaml")),
+			entity.NewEntityError("repository", "repo1", "teams/team1/repo1.yaml", fmt.Errorf("invalid webhooks: empty url in repository filename teams/team1/repo1.yaml")),
+			entity.NewEntityError("repository", "repo1", "teams/team1/repo1.yaml", fmt.Errorf("invalid writer: user3 doesn't exist (check repository filename teams/team1/repo1.yaml)")),
+		}
+
+		var buf bytes.Buffer
+		WriteErrorReport(&buf, errs)
+
+		assert.Equal(t, `3 error(s) found
+repository repo1: 2 error(s)
+  - [webhooks] invalid webhooks: empty url in repository filename teams/team1/repo1.yaml
+  - [writer] invalid writer: user3 doesn't exist (check repository filename teams/team1/repo1.yaml)
+repository repo2: 1 error(s)
+  - [labels] invalid labels: empty name in repository filename teams/team1/repo2.yaml
+`, buf.String())
+	})
+
+	t.Run("happy path: group teams, rulesets and unknown entities", func(t *testing.T) {
+		errs := []error{
+			fmt.Errorf("not able to read the teams directory"),
+			entity.NewEntityError("team", "team1", "teams/team1/team.yaml", fmt.Errorf("invalid owner: user1 doesn't exist in team filename teams/team1/team.yaml")),
+			entity.NewEntityError("team", "team1", "", fmt.Errorf("invalid parentTeam: team3 doesn't exist for team team1")),
+			entity.NewEntityError("ruleset", "default", "rulesets/default.yaml", fmt.Errorf("invalid enforcement: foo for ruleset filename rulesets/default.yaml")),
+			entity.NewEntityError("org variables", "", "org-variables.yaml", fmt.Errorf("variable name is empty in org variables filename org-variables.yaml")),
+		}
+
+		groups := GroupErrorsByEntity(errs)
+		assert.Equal(t, 4, len(groups))
+		assert.Equal(t, "org variables: 1 error(s)", groups[0].Heading())
+		assert.Equal(t, "ruleset default: 1 error(s)", groups[1].Heading())
+		assert.Equal(t, "team team1: 2 error(s)", groups[2].Heading())
+		assert.Equal(t, "other: 1 error(s)", groups[3].Heading())
+	})
+
+	t.Run("happy path: the entity comes from the error fields, not from the message", func(t *testing.T) {
+		errs := []error{
+			// the message mentions another repository
+			entity.NewEntityError("repository", "repo1", "teams/team1/repo1.yaml", fmt.Errorf("Repository repo1 defined in 2 places (check teams/team1/repo1.yaml and teams/team2/repo1.yaml)")),
+			// not an entity error, even if the message looks like one
+			fmt.Errorf("invalid labels: empty name in repository filename teams/team1/repo2.yaml"),
+		}
+
+		groups := GroupErrorsByEntity(errs)
+		assert.Equal(t, 2, len(groups))
+		assert.Equal(t, "repository repo1: 1 error(s)", groups[0].Heading())
+		assert.Equal(t, "other: 1 error(s)", groups[1].Heading())
+	})
+
+	t.Run("happy path: the errors read from the teams directory are grouped per entity", func(t *testing.T) {
+		fs := memfs.New()
+		utils.WriteFile(fs, "users/user1.yaml", []byte(`
+apiVersion: v1
+kind: User
+name: user1
+spec:
+  githubID: github1
+`), 0644)
+		utils.WriteFile(fs, "teams/team1/team.yaml", []byte(`
+apiVersion: v1
+kind: Team
+name: team1
+spec:
+  owners:
+    - user1
+`), 0644)
+		utils.WriteFile(fs, "teams/team1/repo1.yaml", []byte(`
+apiVersion: v1
+kind: Repository
+name: repo1
+spec:
+  writers:
+    - unknown
+`), 0644)
+
+		users, _, _ := entity.ReadUserDirectory(fs, "users")
+		teams, _, _ := entity.ReadTeamDirectory(fs, "teams", users)
+		_, errs, _ := entity.ReadRepositories(fs, "archived", "teams", teams, map[string]*entity.User{})
+
+		groups := GroupErrorsByEntity(errs)
+		assert.Equal(t, 1, len(groups))
+		assert.Equal(t, "repository repo1: 1 error(s)", groups[0].Heading())
+	})
+
+	t.Run("happy path: nothing is written without errors", func(t *testing.T) {
+		var buf bytes.Buffer
+		WriteErrorReport(&buf, []error{})
+		assert.Equal(t, "", buf.String())
+	})
+}
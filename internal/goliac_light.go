@@ -2,9 +2,11 @@ package internal
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/sirupsen/logrus"
 )
@@ -17,6 +19,10 @@ import (
 type GoliacLight interface {
 	// Validate a local teams directory
 	Validate(path string) error
+
+	// Validate a single entity file, without the rest of the teams directory.
+	// Errors are written to stderr, one per line, as <file>:<line>: <message>
+	ValidateFile(filename string) error
 }
 
 type GoliacLightImpl struct {
@@ -47,3 +53,23 @@ func (g *GoliacLightImpl) Validate(path string) error {
 
 	return nil
 }
+
+func (g *GoliacLightImpl) ValidateFile(filename string) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	errs, warns := entity.ValidateFile(filename, content)
+
+	for _, warn := range warns {
+		logrus.Warn(warn)
+	}
+	if len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return fmt.Errorf("Not able to validate %s", filename)
+	}
+
+	return nil
+}
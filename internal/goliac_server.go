@@ -3,6 +3,7 @@ package internal
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -12,6 +13,7 @@ import (
 	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
 	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/metrics"
 	"github.com/Alayacare/goliac/internal/notification"
 	"github.com/Alayacare/goliac/swagger_gen/models"
 	"github.com/Alayacare/goliac/swagger_gen/restapi"
@@ -49,6 +51,9 @@ type GoliacServer interface {
 	GetUnmanaged(app.GetUnmanagedParams) middleware.Responder
 }
 
+// how the notifications and the reports of a read-only server (GOLIAC_SERVER_READONLY) are labelled
+const READONLY_DRIFT_LABEL = "drift detected (read-only)"
+
 type GoliacServerImpl struct {
 	goliac              Goliac
 	applyLobbyMutex     sync.Mutex
@@ -67,6 +72,9 @@ type GoliacServerImpl struct {
 	lastTimeToApply     time.Duration
 	maxTimeToApply      time.Duration
 	lastUnmanaged       *engine.UnmanagedResources
+	lastPlanHash        string // hash of the changes of the last successful apply
+	statusMutex         sync.Mutex
+	verifiedDomains     []string // snapshot of the verified domains at the end of the last successful apply
 }
 
 func NewGoliacServer(goliac Goliac, notificationService notification.NotificationService) GoliacServer {
@@ -78,6 +86,14 @@ func NewGoliacServer(goliac Goliac, notificationService notification.Notificatio
 	}
 	server.applyLobbyCond = sync.NewCond(&server.applyLobbyMutex)
 
+	if config.Config.ServerPlanHashFile != "" {
+		hash, err := readPlanHash(config.Config.ServerPlanHashFile)
+		if err != nil {
+			logrus.Warnf("not able to read the plan hash file %s: %v", config.Config.ServerPlanHashFile, err)
+		}
+		server.lastPlanHash = hash
+	}
+
 	return &server
 }
 
@@ -118,11 +134,11 @@ func (g *GoliacServerImpl) GetUnmanaged(app.GetUnmanagedParams) middleware.Respo
 func (g *GoliacServerImpl) GetStatistics(app.GetStatiticsParams) middleware.Responder {
 	return app.NewGetStatiticsOK().WithPayload(&models.Statistics{
 		LastTimeToApply:     g.lastTimeToApply.Truncate(time.Second).String(),
-		LastGithubAPICalls:  int64(g.lastStatistics.GithubApiCalls),
-		LastGithubThrottled: int64(g.lastStatistics.GithubThrottled),
+		LastGithubAPICalls:  g.lastStatistics.GithubApiCalls,
+		LastGithubThrottled: g.lastStatistics.GithubThrottled,
 		MaxTimeToApply:      g.maxTimeToApply.Truncate(time.Second).String(),
-		MaxGithubAPICalls:   int64(g.maxStatistics.GithubApiCalls),
-		MaxGithubThrottled:  int64(g.maxStatistics.GithubThrottled),
+		MaxGithubAPICalls:   g.maxStatistics.GithubApiCalls,
+		MaxGithubThrottled:  g.maxStatistics.GithubThrottled,
 	})
 }
 
@@ -505,7 +521,14 @@ func (g *GoliacServerImpl) GetStatus(app.GetStatusParams) middleware.Responder {
 		Version:          config.GoliacBuildVersion,
 		DetailedErrors:   make([]string, 0),
 		DetailedWarnings: make([]string, 0),
+		VerifiedDomains:  make([]string, 0),
+	}
+	// the verified domains are not fetched from Github here (it would race with a running apply)
+	g.statusMutex.Lock()
+	if g.verifiedDomains != nil {
+		s.VerifiedDomains = append(s.VerifiedDomains, g.verifiedDomains...)
 	}
+	g.statusMutex.Unlock()
 	if g.lastSyncError != nil {
 		s.LastSyncError = g.lastSyncError.Error()
 	}
@@ -595,6 +618,22 @@ func (g *GoliacServerImpl) Serve() {
 		}()
 	}
 
+	// start the dedicated metrics server
+	var metricsserver *http.Server
+	if config.Config.ServerMetricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		metricsserver = &http.Server{
+			Addr:    config.Config.ServerMetricsAddr,
+			Handler: g.healthHandler(mux),
+		}
+		go func() {
+			if err := metricsserver.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logrus.Error(err)
+			}
+		}()
+	}
+
 	logrus.Info("Server started")
 	// Start the goroutine
 	wg.Add(1)
@@ -608,6 +647,9 @@ func (g *GoliacServerImpl) Serve() {
 				if webhookserver != nil {
 					webhookserver.Shutdown()
 				}
+				if metricsserver != nil {
+					metricsserver.Shutdown(context.Background())
+				}
 				return
 			default:
 				g.syncInterval--
@@ -645,6 +687,15 @@ reapply the last commit, ie HEAD)
 */
 func (g *GoliacServerImpl) triggerApply(forceresync bool) {
 	err, errs, warns, applied := g.serveApply(forceresync)
+	if config.Config.ServerReportDir != "" && (applied || err != nil) {
+		report := NewApplyReport(time.Now(), g.goliac.GetAppliedCommit(), g.goliac.GetPlannedActions(), err, errs, warns)
+		if config.Config.ServerReadOnly {
+			report.Label = READONLY_DRIFT_LABEL
+		}
+		if err := WriteApplyReport(config.Config.ServerReportDir, config.Config.ServerReportMaxCount, report); err != nil {
+			logrus.Errorf("not able to write the apply report: %v", err)
+		}
+	}
 	if !applied && err == nil {
 		// the run was skipped
 		g.syncInterval = config.Config.ServerApplyInterval
@@ -658,7 +709,24 @@ func (g *GoliacServerImpl) triggerApply(forceresync bool) {
 		// log the error only if it's a new one
 		if err != nil && (previousError == nil || err.Error() != previousError.Error()) {
 			logrus.Error(err)
-			if err := g.notificationService.SendNotification(fmt.Sprintf("Goliac error when syncing: %s", err)); err != nil {
+		}
+		actions := g.goliac.GetPlannedActions()
+		planChanged := true
+		if err == nil {
+			// the same changes applied again (like a drift reverted at each run) are notified once
+			hash := PlanHash(actions)
+			planChanged = hash != g.lastPlanHash
+			if planChanged {
+				g.lastPlanHash = hash
+				if config.Config.ServerPlanHashFile != "" {
+					if err := writePlanHash(config.Config.ServerPlanHashFile, hash); err != nil {
+						logrus.Errorf("not able to write the plan hash file %s: %v", config.Config.ServerPlanHashFile, err)
+					}
+				}
+			}
+		}
+		if message := notificationMessage(config.Config.NotifyOn, err, previousError, len(actions), planChanged, config.Config.ServerReadOnly, g.goliac.GetAppliedCommit()); message != "" {
+			if err := g.notificationService.SendNotification(message, notificationSummary(actions, err, errs)); err != nil {
 				logrus.Error(err)
 			}
 		}
@@ -666,6 +734,61 @@ func (g *GoliacServerImpl) triggerApply(forceresync bool) {
 	}
 }
 
+// notificationSummary returns the changes and the number of errors of an apply run
+func notificationSummary(actions []engine.PlannedAction, err error, errs []error) notification.NotificationSummary {
+	summary := notification.NotificationSummary{
+		Changes:    make([]string, 0, len(actions)),
+		ErrorCount: len(errs),
+	}
+	for _, action := range actions {
+		summary.Changes = append(summary.Changes, action.Operation+" "+action.Target)
+	}
+	if err != nil && summary.ErrorCount == 0 {
+		summary.ErrorCount = 1
+	}
+	return summary
+}
+
+/*
+notificationMessage returns the notification to send after an apply run
+(or an empty string if there is nothing worth notifying), depending on
+notifyOn (GOLIAC_NOTIFY_ON):
+- errors: only a new error is notified
+- changes: a new error, or the changes applied (if they differ from the last ones)
+- always: the error (even if it was already notified) or the changes applied (even none)
+*/
+func notificationMessage(notifyOn string, err error, previousError error, nbChanges int, planChanged bool, readOnly bool, commit string) string {
+	newError := err != nil && (previousError == nil || err.Error() != previousError.Error())
+
+	changesMessage := fmt.Sprintf("Goliac applied %d change(s)", nbChanges)
+	if readOnly {
+		changesMessage = fmt.Sprintf("Goliac %s: %d change(s) not applied", READONLY_DRIFT_LABEL, nbChanges)
+	}
+	if commit != "" {
+		changesMessage += fmt.Sprintf(" (commit %s)", commit)
+	}
+
+	switch notifyOn {
+	case "always":
+		if err != nil {
+			return fmt.Sprintf("Goliac error when syncing: %s", err)
+		}
+		return changesMessage
+	case "changes":
+		if newError {
+			return fmt.Sprintf("Goliac error when syncing: %s", err)
+		}
+		if err == nil && nbChanges > 0 && planChanged {
+			return changesMessage
+		}
+	default:
+		if newError {
+			return fmt.Sprintf("Goliac error when syncing: %s", err)
+		}
+	}
+	return ""
+}
+
 func (g *GoliacServerImpl) StartRESTApi() (*restapi.Server, error) {
 	swaggerSpec, err := loads.Embedded(restapi.SwaggerJSON, restapi.FlatSwaggerJSON)
 	if err != nil {
@@ -701,6 +824,7 @@ func (g *GoliacServerImpl) StartRESTApi() (*restapi.Server, error) {
 	server.Port = config.Config.SwaggerPort
 
 	server.ConfigureAPI()
+	server.SetHandler(g.healthHandler(server.GetHandler()))
 
 	return server, nil
 }
@@ -760,11 +884,16 @@ func (g *GoliacServerImpl) serveApply(forceresync bool) (error, []error, []entit
 	ctx := context.WithValue(context.Background(), config.ContextKeyStatistics, &stats)
 
 	fs := osfs.New("/")
-	err, errs, warns, unmanaged := g.goliac.Apply(ctx, fs, false, repo, branch, forceresync)
+	// in read-only mode, the apply is always a dryrun
+	err, errs, warns, unmanaged := g.goliac.Apply(ctx, fs, config.Config.ServerReadOnly, repo, branch, forceresync)
+	endTime := time.Now()
+	metrics.ApplyRunsTotal.Inc()
+	metrics.ReconciliationDuration.ObserveDuration(endTime.Sub(startTime))
 	if err != nil {
+		metrics.ApplyErrorsTotal.Inc()
 		return fmt.Errorf("failed to apply on branch %s: %s", branch, err), errs, warns, false
 	}
-	endTime := time.Now()
+	metrics.LastSuccessfulApply.SetToTime(endTime)
 	g.lastTimeToApply = endTime.Sub(startTime)
 	g.lastStatistics.GithubApiCalls = stats.GithubApiCalls
 	g.lastStatistics.GithubThrottled = stats.GithubThrottled
@@ -785,5 +914,10 @@ func (g *GoliacServerImpl) serveApply(forceresync bool) (error, []error, []entit
 		g.lastUnmanaged = unmanaged
 	}
 
+	domains := g.goliac.GetVerifiedDomains(ctx)
+	g.statusMutex.Lock()
+	g.verifiedDomains = domains
+	g.statusMutex.Unlock()
+
 	return nil, errs, warns, true
 }
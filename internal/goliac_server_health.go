@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/github"
+)
+
+/*
+ * HealthStatus is the JSON body returned by /healthz and /readyz
+ */
+type HealthStatus struct {
+	Status         string `json:"status"`
+	LastApplyTime  string `json:"last_apply_time,omitempty"`
+	LastApplyError string `json:"last_apply_error,omitempty"`
+	NbErrors       int    `json:"nb_errors,omitempty"`
+}
+
+/*
+ * readinessMaxAge returns how old the last apply can be before the server
+ * is not ready anymore (GOLIAC_SERVER_READINESS_MAX_AGE, or 2 apply intervals)
+ */
+func readinessMaxAge() time.Duration {
+	if config.Config.ServerReadinessMaxAge > 0 {
+		return time.Duration(config.Config.ServerReadinessMaxAge) * time.Second
+	}
+	return 2 * time.Duration(config.Config.ServerApplyInterval) * time.Second
+}
+
+/*
+ * readiness returns the HTTP status code and the body of /readyz: the server
+ * is ready if the last apply succeeded less than maxAge ago
+ */
+func (g *GoliacServerImpl) readiness(now time.Time, maxAge time.Duration) (int, HealthStatus) {
+	status := HealthStatus{
+		Status: "not ready",
+	}
+	if g.lastSyncTime == nil {
+		status.LastApplyError = "no apply yet"
+		return http.StatusServiceUnavailable, status
+	}
+	status.LastApplyTime = g.lastSyncTime.UTC().Format(time.RFC3339)
+	if g.lastSyncError != nil {
+		status.LastApplyError = g.lastSyncError.Error()
+		status.NbErrors = len(g.detailedErrors)
+		return http.StatusServiceUnavailable, status
+	}
+	if now.Sub(*g.lastSyncTime) > maxAge {
+		status.LastApplyError = fmt.Sprintf("the last apply is older than %s", maxAge)
+		return http.StatusServiceUnavailable, status
+	}
+	status.Status = "ok"
+	return http.StatusOK, status
+}
+
+/*
+ * ServerStatus is the JSON body returned by /status: the last apply, and the
+ * Github rate limits (per resource) reported by the last API responses
+ */
+type ServerStatus struct {
+	LastApplyTime    string                      `json:"last_apply_time,omitempty"`
+	LastApplySuccess bool                        `json:"last_apply_success"`
+	LastApplyError   string                      `json:"last_apply_error,omitempty"`
+	RateLimits       map[string]github.RateLimit `json:"rate_limits"`
+}
+
+func (g *GoliacServerImpl) status() ServerStatus {
+	status := ServerStatus{
+		RateLimits: g.goliac.GetRateLimits(),
+	}
+	if status.RateLimits == nil {
+		status.RateLimits = map[string]github.RateLimit{}
+	}
+	if g.lastSyncTime != nil {
+		status.LastApplyTime = g.lastSyncTime.UTC().Format(time.RFC3339)
+		status.LastApplySuccess = g.lastSyncError == nil
+	}
+	if g.lastSyncError != nil {
+		status.LastApplyError = g.lastSyncError.Error()
+	}
+	return status
+}
+
+func writeHealthStatus(w http.ResponseWriter, code int, status HealthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+/*
+ * healthHandler serves
+ * - /healthz: the process is alive
+ * - /readyz: the last apply succeeded recently enough (see readiness)
+ * - /status: the last apply and the Github rate limits (see status)
+ * and passes the other requests to next
+ */
+func (g *GoliacServerImpl) healthHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/healthz":
+			writeHealthStatus(w, http.StatusOK, HealthStatus{Status: "ok"})
+		case "/readyz":
+			code, status := g.readiness(time.Now(), readinessMaxAge())
+			writeHealthStatus(w, code, status)
+		case "/status":
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(g.status())
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+)
+
+const APPLY_REPORT_PREFIX = "goliac-apply-"
+
+/*
+ * ApplyReport is the audit record of an apply done by the server
+ * (written as JSON in GOLIAC_SERVER_REPORT_DIR)
+ */
+type ApplyReport struct {
+	Timestamp string                 `json:"timestamp"`
+	Commit    string                 `json:"commit"` // the teams repository commit reconciled
+	Changes   []engine.PlannedAction `json:"changes"`
+	Errors    []string               `json:"errors"`
+	Warnings  []string               `json:"warnings"`
+	Label     string                 `json:"label,omitempty"` // like "drift detected (read-only)" when the changes were not applied
+	time      time.Time
+}
+
+func NewApplyReport(now time.Time, commit string, changes []engine.PlannedAction, err error, errs []error, warns []entity.Warning) *ApplyReport {
+	report := &ApplyReport{
+		Timestamp: now.UTC().Format(time.RFC3339),
+		Commit:    commit,
+		Changes:   engine.WithoutReasons(changes),
+		Errors:    []string{},
+		Warnings:  []string{},
+		time:      now,
+	}
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for _, e := range errs {
+		report.Errors = append(report.Errors, e.Error())
+	}
+	for _, w := range warns {
+		report.Warnings = append(report.Warnings, w.Error())
+	}
+	return report
+}
+
+/*
+ * WriteApplyReport writes the report in a timestamped file in dir, and
+ * removes the oldest reports to keep (at most) maxReports of them
+ */
+func WriteApplyReport(dir string, maxReports int, report *ApplyReport) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the filenames sort in chronological order
+	filename := filepath.Join(dir, fmt.Sprintf("%s%s.json", APPLY_REPORT_PREFIX, report.time.UTC().Format("20060102T150405.000000000Z")))
+	if err := os.WriteFile(filename, content, 0644); err != nil {
+		return err
+	}
+
+	return pruneApplyReports(dir, maxReports)
+}
+
+func pruneApplyReports(dir string, maxReports int) error {
+	if maxReports <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	reports := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasPrefix(e.Name(), APPLY_REPORT_PREFIX) && strings.HasSuffix(e.Name(), ".json") {
+			reports = append(reports, e.Name())
+		}
+	}
+	sort.Strings(reports)
+	for len(reports) > maxReports {
+		if err := os.Remove(filepath.Join(dir, reports[0])); err != nil {
+			return err
+		}
+		reports = reports[1:]
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyReport(t *testing.T) {
+
+	t.Run("happy path: write a report", func(t *testing.T) {
+		dir := t.TempDir()
+		now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		report := NewApplyReport(now, "abcdef", []engine.PlannedAction{
+			{Operation: "create_team", Target: "team/team1"},
+		}, nil, []error{fmt.Errorf("an error")}, []entity.Warning{fmt.Errorf("a warning")})
+
+		err := WriteApplyReport(dir, 10, report)
+		assert.Nil(t, err)
+
+		content, err := os.ReadFile(filepath.Join(dir, "goliac-apply-20240501T100000.000000000Z.json"))
+		assert.Nil(t, err)
+		var read map[string]interface{}
+		err = json.Unmarshal(content, &read)
+		assert.Nil(t, err)
+		assert.Equal(t, "2024-05-01T10:00:00Z", read["timestamp"])
+		assert.Equal(t, "abcdef", read["commit"])
+		assert.Equal(t, []interface{}{map[string]interface{}{"operation": "create_team", "target": "team/team1"}}, read["changes"])
+		assert.Equal(t, []interface{}{"an error"}, read["errors"])
+		assert.Equal(t, []interface{}{"a warning"}, read["warnings"])
+	})
+
+	t.Run("happy path: the apply error is reported", func(t *testing.T) {
+		report := NewApplyReport(time.Now(), "", nil, fmt.Errorf("failed to apply"), nil, nil)
+		assert.Equal(t, []string{"failed to apply"}, report.Errors)
+		assert.Equal(t, []engine.PlannedAction{}, report.Changes)
+	})
+
+	t.Run("happy path: the oldest reports are pruned", func(t *testing.T) {
+		dir := t.TempDir()
+		// not a report: never pruned
+		err := os.WriteFile(filepath.Join(dir, "README"), []byte("hello"), 0644)
+		assert.Nil(t, err)
+
+		start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		for i := 0; i < 5; i++ {
+			report := NewApplyReport(start.Add(time.Duration(i)*time.Minute), "abcdef", nil, nil, nil, nil)
+			err := WriteApplyReport(dir, 3, report)
+			assert.Nil(t, err)
+		}
+
+		entries, err := os.ReadDir(dir)
+		assert.Nil(t, err)
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		assert.Equal(t, []string{
+			"README",
+			"goliac-apply-20240501T100200.000000000Z.json",
+			"goliac-apply-20240501T100300.000000000Z.json",
+			"goliac-apply-20240501T100400.000000000Z.json",
+		}, names)
+	})
+}
@@ -2,14 +2,24 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-git/go-billy/v5"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
 	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/github"
+	"github.com/Alayacare/goliac/internal/notification"
 	"github.com/Alayacare/goliac/swagger_gen/restapi/operations/app"
 )
 
@@ -19,6 +29,8 @@ type GoliacLocalMock struct {
 	users         map[string]*entity.User
 	externalUsers map[string]*entity.User
 	rulesets      map[string]*entity.RuleSet
+	orgVariables  map[string]*entity.OrgVariable
+	orgSecrets    map[string]*entity.OrgSecret
 }
 
 func (g *GoliacLocalMock) Teams() map[string]*entity.Team {
@@ -36,6 +48,12 @@ func (g *GoliacLocalMock) ExternalUsers() map[string]*entity.User {
 func (g *GoliacLocalMock) RuleSets() map[string]*entity.RuleSet {
 	return g.rulesets
 }
+func (g *GoliacLocalMock) OrgVariables() map[string]*entity.OrgVariable {
+	return g.orgVariables
+}
+func (g *GoliacLocalMock) OrgSecrets() map[string]*entity.OrgSecret {
+	return g.orgSecrets
+}
 
 func fixtureGoliacLocal() *GoliacLocalMock {
 	l := GoliacLocalMock{
@@ -44,6 +62,8 @@ func fixtureGoliacLocal() *GoliacLocalMock {
 		users:         make(map[string]*entity.User),
 		externalUsers: make(map[string]*entity.User),
 		rulesets:      make(map[string]*entity.RuleSet),
+		orgVariables:  make(map[string]*entity.OrgVariable),
+		orgSecrets:    make(map[string]*entity.OrgSecret),
 	}
 
 	// users
@@ -106,10 +126,15 @@ func fixtureGoliacLocal() *GoliacLocalMock {
 }
 
 type GoliacMock struct {
-	local engine.GoliacLocalResources
+	local           engine.GoliacLocalResources
+	verifiedDomains []string
+	plannedActions  []engine.PlannedAction
+	dryruns         []bool // the dryrun parameter of each Apply call
+	rateLimits      map[string]github.RateLimit
 }
 
 func (g *GoliacMock) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool, repo string, branch string, forceresync bool) (error, []error, []entity.Warning, *engine.UnmanagedResources) {
+	g.dryruns = append(g.dryruns, dryrun)
 	unmanaged := &engine.UnmanagedResources{
 		Users:        make(map[string]bool),
 		Teams:        make(map[string]bool),
@@ -122,8 +147,36 @@ func (g *GoliacMock) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool
 func (g *GoliacMock) UsersUpdate(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, dryrun bool, force bool) (bool, error) {
 	return false, nil
 }
+func (g *GoliacMock) Doctor(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, fix bool) ([]string, error) {
+	return []string{}, nil
+}
+func (g *GoliacMock) StaleRepositories(ctx context.Context, fs billy.Filesystem, repositoryUrl, branch string, since time.Duration) ([]StaleRepository, error) {
+	return []StaleRepository{}, nil
+}
+func (g *GoliacMock) Diff(ctx context.Context, fs billy.Filesystem, repositoryUrl, base, head string) ([]engine.PlannedAction, error) {
+	return []engine.PlannedAction{}, nil
+}
 func (g *GoliacMock) FlushCache() {
 }
+func (g *GoliacMock) GetPlannedActions() []engine.PlannedAction {
+	if g.plannedActions == nil {
+		return []engine.PlannedAction{}
+	}
+	return g.plannedActions
+}
+func (g *GoliacMock) GetAppliedCommit() string {
+	return ""
+}
+func (g *GoliacMock) GetVerifiedDomains(ctx context.Context) []string {
+	return g.verifiedDomains
+}
+func (g *GoliacMock) GetRateLimits() map[string]github.RateLimit {
+	return g.rateLimits
+}
+func (g *GoliacMock) SetTargetBranch(branch string) {
+}
+func (g *GoliacMock) SetEntityFilter(filter engine.EntityFilter) {
+}
 
 func (g *GoliacMock) GetLocal() engine.GoliacLocalResources {
 	return g.local
@@ -153,6 +206,35 @@ func TestAppGetUsers(t *testing.T) {
 		assert.Equal(t, int64(2), payload.Payload.NbTeams)
 		assert.Equal(t, int64(3), payload.Payload.NbUsers)
 		assert.Equal(t, int64(1), payload.Payload.NbUsersExternal)
+		assert.Equal(t, []string{}, payload.Payload.VerifiedDomains)
+	})
+
+	t.Run("happy path: get status with the verified domains", func(t *testing.T) {
+		repository := config.Config.ServerGitRepository
+		defer func() {
+			config.Config.ServerGitRepository = repository
+		}()
+		config.Config.ServerGitRepository = "https://github.com/myorg/teams"
+
+		goliac := NewGoliacMock(fixture)
+		goliac.(*GoliacMock).verifiedDomains = []string{"example.com", "example.org"}
+		server := GoliacServerImpl{
+			goliac: goliac,
+			ready:  true,
+		}
+
+		// the verified domains are only known after an apply
+		res := server.GetStatus(app.GetStatusParams{})
+		payload := res.(*app.GetStatusOK)
+		assert.Equal(t, []string{}, payload.Payload.VerifiedDomains)
+
+		err, _, _, ok := server.serveApply(false)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+
+		res = server.GetStatus(app.GetStatusParams{})
+		payload = res.(*app.GetStatusOK)
+		assert.Equal(t, []string{"example.com", "example.org"}, payload.Payload.VerifiedDomains)
 	})
 
 	t.Run("happy path: list users", func(t *testing.T) {
@@ -228,3 +310,341 @@ func TestAppGetRepositories(t *testing.T) {
 		assert.NotZero(t, res.(*app.GetRepositoryDefault))
 	})
 }
+
+func TestNotificationMessage(t *testing.T) {
+	t.Run("happy path: errors mode only notifies new errors", func(t *testing.T) {
+		assert.Equal(t, "", notificationMessage("errors", nil, nil, 3, true, false, "abc"))
+		assert.Equal(t, "Goliac error when syncing: boom", notificationMessage("errors", fmt.Errorf("boom"), nil, 0, true, false, ""))
+		assert.Equal(t, "", notificationMessage("errors", fmt.Errorf("boom"), fmt.Errorf("boom"), 0, true, false, ""))
+	})
+
+	t.Run("happy path: changes mode doesn't notify a no-op apply", func(t *testing.T) {
+		assert.Equal(t, "", notificationMessage("changes", nil, nil, 0, true, false, "abc"))
+		assert.Equal(t, "Goliac applied 2 change(s) (commit abc)", notificationMessage("changes", nil, nil, 2, true, false, "abc"))
+		assert.Equal(t, "Goliac error when syncing: boom", notificationMessage("changes", fmt.Errorf("boom"), nil, 2, true, false, "abc"))
+		assert.Equal(t, "", notificationMessage("changes", fmt.Errorf("boom"), fmt.Errorf("boom"), 0, true, false, ""))
+	})
+
+	t.Run("happy path: always mode notifies each apply", func(t *testing.T) {
+		assert.Equal(t, "Goliac applied 0 change(s)", notificationMessage("always", nil, nil, 0, true, false, ""))
+		assert.Equal(t, "Goliac drift detected (read-only): 2 change(s) not applied (commit abc)", notificationMessage("always", nil, nil, 2, true, true, "abc"))
+		assert.Equal(t, "Goliac error when syncing: boom", notificationMessage("always", fmt.Errorf("boom"), fmt.Errorf("boom"), 0, true, false, ""))
+	})
+}
+
+func TestNotificationSummary(t *testing.T) {
+	t.Run("happy path: the changes and the errors of the run", func(t *testing.T) {
+		actions := []engine.PlannedAction{
+			{Operation: "create_team", Target: "team/team1"},
+			{Operation: "update_team_add_member", Target: "team/team1/member/user1"},
+		}
+		summary := notificationSummary(actions, fmt.Errorf("boom"), []error{fmt.Errorf("error1"), fmt.Errorf("error2")})
+		assert.Equal(t, []string{"create_team team/team1", "update_team_add_member team/team1/member/user1"}, summary.Changes)
+		assert.Equal(t, 2, summary.ErrorCount)
+	})
+
+	t.Run("happy path: an error without details", func(t *testing.T) {
+		summary := notificationSummary(nil, fmt.Errorf("boom"), nil)
+		assert.Equal(t, 0, len(summary.Changes))
+		assert.Equal(t, 1, summary.ErrorCount)
+	})
+}
+
+type NotificationServiceMock struct {
+	messages []string
+}
+
+func (n *NotificationServiceMock) SendNotification(message string, summary notification.NotificationSummary) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func TestPlanHash(t *testing.T) {
+	t.Run("happy path: the hash doesn't depend on the order of the actions", func(t *testing.T) {
+		a := engine.PlannedAction{Operation: "create_team", Target: "team/team1", After: []string{"user1"}}
+		b := engine.PlannedAction{Operation: "update_repository_update_bool_property", Target: "repository/repo1/private", Before: true, After: false}
+
+		assert.Equal(t, PlanHash([]engine.PlannedAction{a, b}), PlanHash([]engine.PlannedAction{b, a}))
+		assert.NotEqual(t, PlanHash([]engine.PlannedAction{a, b}), PlanHash([]engine.PlannedAction{a}))
+		assert.NotEqual(t, PlanHash([]engine.PlannedAction{}), PlanHash([]engine.PlannedAction{a}))
+	})
+
+	t.Run("happy path: the hash doesn't depend on the reasons", func(t *testing.T) {
+		a := engine.PlannedAction{Operation: "create_team", Target: "team/team1", After: []string{"user1"}}
+		explained := a
+		explained.Reason = "the team team1 is defined in teams/team1/team.yaml but doesn't exist on Github"
+
+		assert.Equal(t, PlanHash([]engine.PlannedAction{a}), PlanHash([]engine.PlannedAction{explained}))
+		assert.Equal(t, "", engine.WithoutReasons([]engine.PlannedAction{explained})[0].Reason)
+		assert.NotEqual(t, "", explained.Reason)
+	})
+}
+
+func TestTriggerApplyNotifications(t *testing.T) {
+	repository := config.Config.ServerGitRepository
+	notifyOn := config.Config.NotifyOn
+	planHashFile := config.Config.ServerPlanHashFile
+	defer func() {
+		config.Config.ServerGitRepository = repository
+		config.Config.NotifyOn = notifyOn
+		config.Config.ServerPlanHashFile = planHashFile
+	}()
+	config.Config.ServerGitRepository = "https://github.com/myorg/teams"
+	config.Config.NotifyOn = "changes"
+
+	actions := []engine.PlannedAction{
+		{Operation: "update_repository_update_bool_property", Target: "repository/repo1/private", Before: false, After: true},
+	}
+
+	t.Run("happy path: an unchanged plan across cycles is notified once", func(t *testing.T) {
+		config.Config.ServerPlanHashFile = ""
+		goliac := &GoliacMock{local: fixtureGoliacLocal(), plannedActions: actions}
+		notifications := &NotificationServiceMock{}
+		server := NewGoliacServer(goliac, notifications).(*GoliacServerImpl)
+
+		server.triggerApply(false)
+		server.triggerApply(false)
+		server.triggerApply(false)
+		assert.Equal(t, 1, len(notifications.messages))
+
+		// a new plan is notified
+		goliac.plannedActions = append(actions, engine.PlannedAction{Operation: "create_team", Target: "team/team1"})
+		server.triggerApply(false)
+		assert.Equal(t, 2, len(notifications.messages))
+	})
+
+	t.Run("happy path: the same changes are notified again after a run without changes", func(t *testing.T) {
+		config.Config.ServerPlanHashFile = ""
+		goliac := &GoliacMock{local: fixtureGoliacLocal(), plannedActions: actions}
+		notifications := &NotificationServiceMock{}
+		server := NewGoliacServer(goliac, notifications).(*GoliacServerImpl)
+
+		server.triggerApply(false)
+		goliac.plannedActions = []engine.PlannedAction{}
+		server.triggerApply(false)
+		goliac.plannedActions = actions
+		server.triggerApply(false)
+		assert.Equal(t, 2, len(notifications.messages))
+	})
+
+	t.Run("happy path: the last notified plan is persisted across restarts", func(t *testing.T) {
+		config.Config.ServerPlanHashFile = filepath.Join(t.TempDir(), "plan.hash")
+		goliac := &GoliacMock{local: fixtureGoliacLocal(), plannedActions: actions}
+		notifications := &NotificationServiceMock{}
+
+		server := NewGoliacServer(goliac, notifications).(*GoliacServerImpl)
+		server.triggerApply(false)
+		assert.Equal(t, 1, len(notifications.messages))
+
+		// the server restarts
+		server = NewGoliacServer(goliac, notifications).(*GoliacServerImpl)
+		server.triggerApply(false)
+		assert.Equal(t, 1, len(notifications.messages))
+	})
+
+	t.Run("happy path: a read-only server only reports the drift", func(t *testing.T) {
+		reportDir := config.Config.ServerReportDir
+		defer func() {
+			config.Config.ServerReadOnly = false
+			config.Config.ServerReportDir = reportDir
+		}()
+		config.Config.ServerReadOnly = true
+		config.Config.ServerReportDir = t.TempDir()
+		config.Config.ServerPlanHashFile = ""
+		goliac := &GoliacMock{local: fixtureGoliacLocal(), plannedActions: actions}
+		notifications := &NotificationServiceMock{}
+		server := NewGoliacServer(goliac, notifications).(*GoliacServerImpl)
+
+		server.triggerApply(false)
+		server.triggerApply(true)
+		assert.Equal(t, []bool{true, true}, goliac.dryruns)
+		assert.Equal(t, []string{"Goliac drift detected (read-only): 1 change(s) not applied"}, notifications.messages)
+
+		files, err := os.ReadDir(config.Config.ServerReportDir)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(files))
+		content, err := os.ReadFile(filepath.Join(config.Config.ServerReportDir, files[0].Name()))
+		assert.Nil(t, err)
+		var report ApplyReport
+		assert.Nil(t, json.Unmarshal(content, &report))
+		assert.Equal(t, READONLY_DRIFT_LABEL, report.Label)
+	})
+}
+
+func TestReadiness(t *testing.T) {
+	now := time.Now()
+
+	t.Run("not happy path: no apply yet", func(t *testing.T) {
+		server := GoliacServerImpl{}
+		code, status := server.readiness(now, time.Hour)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "not ready", status.Status)
+	})
+
+	t.Run("happy path: recent successful apply", func(t *testing.T) {
+		lastSync := now.Add(-10 * time.Minute)
+		server := GoliacServerImpl{lastSyncTime: &lastSync}
+		code, status := server.readiness(now, time.Hour)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "ok", status.Status)
+		assert.Equal(t, lastSync.UTC().Format(time.RFC3339), status.LastApplyTime)
+	})
+
+	t.Run("not happy path: last apply failed", func(t *testing.T) {
+		lastSync := now.Add(-10 * time.Minute)
+		server := GoliacServerImpl{
+			lastSyncTime:   &lastSync,
+			lastSyncError:  fmt.Errorf("boom"),
+			detailedErrors: []error{fmt.Errorf("error1"), fmt.Errorf("error2")},
+		}
+		code, status := server.readiness(now, time.Hour)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, "boom", status.LastApplyError)
+		assert.Equal(t, 2, status.NbErrors)
+	})
+
+	t.Run("not happy path: last apply too old", func(t *testing.T) {
+		lastSync := now.Add(-2 * time.Hour)
+		server := GoliacServerImpl{lastSyncTime: &lastSync}
+		code, _ := server.readiness(now, time.Hour)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+	})
+
+	t.Run("happy path: the max age defaults to 2 apply intervals", func(t *testing.T) {
+		interval := config.Config.ServerApplyInterval
+		maxAge := config.Config.ServerReadinessMaxAge
+		defer func() {
+			config.Config.ServerApplyInterval = interval
+			config.Config.ServerReadinessMaxAge = maxAge
+		}()
+		config.Config.ServerApplyInterval = 600
+		config.Config.ServerReadinessMaxAge = 0
+		assert.Equal(t, 20*time.Minute, readinessMaxAge())
+		config.Config.ServerReadinessMaxAge = 60
+		assert.Equal(t, time.Minute, readinessMaxAge())
+	})
+
+	t.Run("happy path: healthz and readyz handlers", func(t *testing.T) {
+		server := GoliacServerImpl{}
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		handler := server.healthHandler(next)
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.JSONEq(t, `{"status":"not ready","last_apply_error":"no apply yet"}`, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+		assert.Equal(t, http.StatusTeapot, rec.Code)
+	})
+
+	t.Run("happy path: status handler", func(t *testing.T) {
+		reset := time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)
+		lastSync := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		goliac := &GoliacMock{
+			rateLimits: map[string]github.RateLimit{
+				"core": {Limit: 5000, Remaining: 4200, Used: 800, Reset: reset, CapturedAt: lastSync},
+			},
+		}
+		server := GoliacServerImpl{goliac: goliac}
+		handler := server.healthHandler(http.NotFoundHandler())
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"last_apply_success":false,"rate_limits":{"core":{"limit":5000,"remaining":4200,"used":800,"reset":"2024-01-01T11:00:00Z","captured_at":"2024-01-01T10:00:00Z"}}}`, rec.Body.String())
+
+		server.lastSyncTime = &lastSync
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		assert.Contains(t, rec.Body.String(), `"last_apply_time":"2024-01-01T10:00:00Z","last_apply_success":true`)
+
+		server.lastSyncError = fmt.Errorf("boom")
+		goliac.rateLimits = nil
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+		assert.JSONEq(t, `{"last_apply_time":"2024-01-01T10:00:00Z","last_apply_success":false,"last_apply_error":"boom","rate_limits":{}}`, rec.Body.String())
+	})
+}
+
+type GoliacBlockingMock struct {
+	GoliacMock
+	started chan bool
+	release chan bool
+	mutex   sync.Mutex
+	nbApply int
+}
+
+func (g *GoliacBlockingMock) Apply(ctx context.Context, fs billy.Filesystem, dryrun bool, repo string, branch string, forceresync bool) (error, []error, []entity.Warning, *engine.UnmanagedResources) {
+	g.mutex.Lock()
+	g.nbApply++
+	g.mutex.Unlock()
+	g.started <- true
+	<-g.release
+	return nil, nil, nil, nil
+}
+
+func TestServeApplyCoalescing(t *testing.T) {
+	t.Run("happy path: one apply in flight, at most one queued", func(t *testing.T) {
+		repository := config.Config.ServerGitRepository
+		defer func() {
+			config.Config.ServerGitRepository = repository
+		}()
+		config.Config.ServerGitRepository = "https://github.com/myorg/teams"
+
+		goliac := &GoliacBlockingMock{
+			started: make(chan bool),
+			release: make(chan bool),
+		}
+		server := NewGoliacServer(goliac, nil).(*GoliacServerImpl)
+
+		var wg sync.WaitGroup
+		applied := make(chan bool, 2)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _, ok := server.serveApply(false)
+			applied <- ok
+		}()
+		// the first apply is in flight
+		<-goliac.started
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, _, ok := server.serveApply(false)
+			applied <- ok
+		}()
+		// wait for the second apply to be queued
+		for {
+			server.applyLobbyMutex.Lock()
+			queued := server.applyLobby
+			server.applyLobbyMutex.Unlock()
+			if queued {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		// a third trigger is coalesced with the queued one
+		_, _, _, ok := server.serveApply(false)
+		assert.False(t, ok)
+
+		goliac.release <- true
+		<-goliac.started
+		goliac.release <- true
+		wg.Wait()
+
+		assert.True(t, <-applied)
+		assert.True(t, <-applied)
+		assert.Equal(t, 2, goliac.nbApply)
+	})
+}
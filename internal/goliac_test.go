@@ -4,21 +4,26 @@ import (
 	"context"
 	"os"
 	"regexp"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/Alayacare/goliac/internal/config"
 	"github.com/Alayacare/goliac/internal/engine"
 	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/github"
 	"github.com/Alayacare/goliac/internal/usersync"
 	"github.com/Alayacare/goliac/internal/utils"
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/memfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/cache"
 	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/client"
 	"github.com/go-git/go-git/v5/plumbing/transport/server"
 	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -324,6 +329,9 @@ func helperCreateAndClone(root billy.Filesystem, src billy.Filesystem, target bi
 //
 
 type GitHubClientMock struct {
+	restCallsMutex sync.Mutex
+	restCalls      []string          // "METHOD endpoint"
+	restResponses  map[string]string // "METHOD endpoint" -> response body
 }
 
 func NewGitHubClientMock() *GitHubClientMock {
@@ -412,6 +420,12 @@ func (c *GitHubClientMock) QueryGraphQLAPI(ctx context.Context, query string, va
 }
 
 func (c *GitHubClientMock) CallRestAPI(ctx context.Context, endpoint, method string, body map[string]interface{}) ([]byte, error) {
+	c.restCallsMutex.Lock()
+	defer c.restCallsMutex.Unlock()
+	c.restCalls = append(c.restCalls, method+" "+endpoint)
+	if response, ok := c.restResponses[method+" "+endpoint]; ok {
+		return []byte(response), nil
+	}
 	return nil, nil
 }
 func (c *GitHubClientMock) GetAccessToken(context.Context) (string, error) {
@@ -420,6 +434,9 @@ func (c *GitHubClientMock) GetAccessToken(context.Context) (string, error) {
 func (c *GitHubClientMock) GetAppSlug() string {
 	return "goliac-project-app"
 }
+func (c *GitHubClientMock) GetRateLimits() map[string]github.RateLimit {
+	return map[string]github.RateLimit{}
+}
 
 //
 // remote mock
@@ -428,6 +445,8 @@ func (c *GitHubClientMock) GetAppSlug() string {
 type GoliacRemoteExecutorMock struct {
 	teams1Members []string
 	teams2Members []string
+	extraTeams    map[string]*engine.GithubTeam
+	teamsDeleted  []string
 	nbChanges     int
 }
 
@@ -465,7 +484,7 @@ func (e *GoliacRemoteExecutorMock) TeamSlugByName(ctx context.Context) map[strin
 }
 
 func (e *GoliacRemoteExecutorMock) Teams(ctx context.Context) map[string]*engine.GithubTeam {
-	return map[string]*engine.GithubTeam{
+	teams := map[string]*engine.GithubTeam{
 		"team1": &engine.GithubTeam{
 			Slug:        "team1",
 			Name:        "team1",
@@ -491,7 +510,15 @@ func (e *GoliacRemoteExecutorMock) Teams(ctx context.Context) map[string]*engine
 			Maintainers: []string{},
 		},
 	}
+	for k, v := range e.extraTeams {
+		teams[k] = v
+	}
+	return teams
 }
+
+var repo1PushedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+var repo2PushedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func (e *GoliacRemoteExecutorMock) Repositories(ctx context.Context) map[string]*engine.GithubRepository {
 	return map[string]*engine.GithubRepository{
 		"repo1": &engine.GithubRepository{
@@ -506,6 +533,7 @@ func (e *GoliacRemoteExecutorMock) Repositories(ctx context.Context) map[string]
 				"allow_update_branch":    false,
 			},
 			ExternalUsers: map[string]string{},
+			PushedAt:      &repo1PushedAt,
 		},
 		"repo2": &engine.GithubRepository{
 			Name:  "repo2",
@@ -519,6 +547,7 @@ func (e *GoliacRemoteExecutorMock) Repositories(ctx context.Context) map[string]
 				"allow_update_branch":    false,
 			},
 			ExternalUsers: map[string]string{},
+			PushedAt:      &repo2PushedAt,
 		},
 	}
 }
@@ -562,6 +591,54 @@ func (e *GoliacRemoteExecutorMock) AppIds(ctx context.Context) map[string]int {
 		"goliac-project-app": 1,
 	}
 }
+func (e *GoliacRemoteExecutorMock) OrgVariables(ctx context.Context) map[string]*engine.GithubOrgVariable {
+	return map[string]*engine.GithubOrgVariable{}
+}
+func (e *GoliacRemoteExecutorMock) OrgSecrets(ctx context.Context) map[string]*engine.GithubOrgSecret {
+	return map[string]*engine.GithubOrgSecret{}
+}
+func (e *GoliacRemoteExecutorMock) OrgSettings(ctx context.Context) map[string]bool {
+	return map[string]bool{}
+}
+func (e *GoliacRemoteExecutorMock) OrgPushProtectionCustomLink(ctx context.Context) string {
+	return ""
+}
+func (e *GoliacRemoteExecutorMock) OrgDefaultRepositoryPermission(ctx context.Context) string {
+	return ""
+}
+func (e *GoliacRemoteExecutorMock) RepositoriesActionsPermissions(ctx context.Context) map[string]*engine.GithubActionsPermissions {
+	return map[string]*engine.GithubActionsPermissions{}
+}
+func (e *GoliacRemoteExecutorMock) RepositoriesSecurity(ctx context.Context) map[string]*engine.GithubRepositorySecurity {
+	return map[string]*engine.GithubRepositorySecurity{}
+}
+func (e *GoliacRemoteExecutorMock) RepositoriesWebhooks(ctx context.Context) map[string]map[string]*engine.GithubWebhook {
+	return map[string]map[string]*engine.GithubWebhook{}
+}
+func (e *GoliacRemoteExecutorMock) RepositoriesLabels(ctx context.Context) map[string]map[string]*engine.GithubLabel {
+	return map[string]map[string]*engine.GithubLabel{}
+}
+func (e *GoliacRemoteExecutorMock) RepositoriesCustomProperties(ctx context.Context) map[string]map[string]string {
+	return map[string]map[string]string{}
+}
+func (e *GoliacRemoteExecutorMock) SamlIdentities(ctx context.Context) map[string]string {
+	return nil
+}
+func (e *GoliacRemoteExecutorMock) TeamsExternalGroups(ctx context.Context) map[string][]string {
+	return nil
+}
+func (e *GoliacRemoteExecutorMock) CustomRepositoryRoles(ctx context.Context) map[string]string {
+	return nil
+}
+func (e *GoliacRemoteExecutorMock) CustomPropertiesDefinitions(ctx context.Context) map[string]bool {
+	return nil
+}
+func (e *GoliacRemoteExecutorMock) MembersWithoutTwoFactor(ctx context.Context) []string {
+	return nil
+}
+func (e *GoliacRemoteExecutorMock) VerifiedDomains(ctx context.Context) []string {
+	return nil
+}
 func (e *GoliacRemoteExecutorMock) IsEnterprise() bool {
 	return true
 }
@@ -588,16 +665,32 @@ func (e *GoliacRemoteExecutorMock) UpdateTeamRemoveMember(ctx context.Context, d
 func (e *GoliacRemoteExecutorMock) UpdateTeamSetParent(ctx context.Context, dryrun bool, teamslug string, parentTeam *int) {
 	e.nbChanges++
 }
+func (e *GoliacRemoteExecutorMock) UpdateTeamRename(ctx context.Context, dryrun bool, teamslug string, newname string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateTeamUpdateProperty(ctx context.Context, dryrun bool, teamslug string, propertyName string, propertyValue string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateTeamSetExternalGroups(ctx context.Context, dryrun bool, teamslug string, groups []string) {
+	e.nbChanges++
+}
 func (e *GoliacRemoteExecutorMock) DeleteTeam(ctx context.Context, dryrun bool, teamslug string) {
+	e.teamsDeleted = append(e.teamsDeleted, teamslug)
 	e.nbChanges++
 }
 
-func (e *GoliacRemoteExecutorMock) CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool) {
+func (e *GoliacRemoteExecutorMock) CreateRepository(ctx context.Context, dryrun bool, reponame string, descrition string, writers []string, readers []string, boolProperties map[string]bool, templateRepository string, includeAllBranches bool) {
 	e.nbChanges++
 }
 func (e *GoliacRemoteExecutorMock) UpdateRepositoryUpdateBoolProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue bool) {
 	e.nbChanges++
 }
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryUpdateProperty(ctx context.Context, dryrun bool, reponame string, propertyName string, propertyValue string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) TransferRepository(ctx context.Context, dryrun bool, reponame string, source string) {
+	e.nbChanges++
+}
 func (e *GoliacRemoteExecutorMock) UpdateRepositoryAddTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string, permission string) {
 	e.nbChanges++
 }
@@ -607,6 +700,9 @@ func (e *GoliacRemoteExecutorMock) UpdateRepositoryUpdateTeamAccess(ctx context.
 func (e *GoliacRemoteExecutorMock) UpdateRepositoryRemoveTeamAccess(ctx context.Context, dryrun bool, reponame string, teamslug string) {
 	e.nbChanges++
 }
+func (e *GoliacRemoteExecutorMock) UpdateRepositorySetRequiredSignatures(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	e.nbChanges++
+}
 func (e *GoliacRemoteExecutorMock) AddRuleset(ctx context.Context, dryrun bool, ruleset *engine.GithubRuleSet) {
 	e.nbChanges++
 }
@@ -616,6 +712,60 @@ func (e *GoliacRemoteExecutorMock) UpdateRuleset(ctx context.Context, dryrun boo
 func (e *GoliacRemoteExecutorMock) DeleteRuleset(ctx context.Context, dryrun bool, rulesetid int) {
 	e.nbChanges++
 }
+func (e *GoliacRemoteExecutorMock) AddOrgVariable(ctx context.Context, dryrun bool, variable *engine.GithubOrgVariable) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateOrgVariable(ctx context.Context, dryrun bool, variable *engine.GithubOrgVariable) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) DeleteOrgVariable(ctx context.Context, dryrun bool, variablename string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateOrgSecret(ctx context.Context, dryrun bool, secret *engine.GithubOrgSecret) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) DeleteOrgSecret(ctx context.Context, dryrun bool, secretname string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateOrgPushProtectionCustomLink(ctx context.Context, dryrun bool, link string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateOrgDefaultRepositoryPermission(ctx context.Context, dryrun bool, permission string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateOrgSetting(ctx context.Context, dryrun bool, settingName string, settingValue bool) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositorySetDependabotAlerts(ctx context.Context, dryrun bool, reponame string, enabled bool) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryActionsPermissions(ctx context.Context, dryrun bool, reponame string, enabled bool, allowedActions string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositorySetSecurity(ctx context.Context, dryrun bool, reponame string, feature string, enabled bool) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryCustomProperties(ctx context.Context, dryrun bool, reponame string, properties map[string]string) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) AddRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *engine.GithubWebhook) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *engine.GithubWebhook) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) DeleteRepositoryWebhook(ctx context.Context, dryrun bool, reponame string, webhook *engine.GithubWebhook) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) CreateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, label *engine.GithubLabel) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) UpdateRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string, label *engine.GithubLabel) {
+	e.nbChanges++
+}
+func (e *GoliacRemoteExecutorMock) DeleteRepositoryLabel(ctx context.Context, dryrun bool, reponame string, labelname string) {
+	e.nbChanges++
+}
 func (e *GoliacRemoteExecutorMock) UpdateRepositorySetExternalUser(ctx context.Context, dryrun bool, reponame string, githubid string, permission string) {
 	e.nbChanges++
 }
@@ -747,4 +897,340 @@ func TestGoliacApply(t *testing.T) {
 		assert.True(t, exist)
 
 	})
+
+	t.Run("happy path: user4 to sync via a pull request", func(t *testing.T) {
+
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)        // create a fake bare repository
+		fs.MkdirAll("teams", 0755)      // create a fake cloned repository
+		fs.MkdirAll(os.TempDir(), 0755) // need a tmp folder
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture2)
+		assert.Nil(t, err)
+
+		local := engine.NewGoliacLocalImplWithRepo(clonedRepo)
+		errs, _ := local.LoadAndValidateLocal(clonedFs)
+		assert.Equal(t, 0, len(errs))
+
+		repoconfig, err := local.LoadRepoConfig()
+		assert.Nil(t, err)
+
+		githubClient := NewGitHubClientMock()
+		remote := NewGoliacRemoteExecutorMock().(*GoliacRemoteExecutorMock)
+		remote.teams2Members = []string{"github3"}
+
+		usersync.InitPlugins(githubClient)
+
+		goliac := GoliacImpl{
+			local:              local,
+			remote:             remote,
+			remoteGithubClient: githubClient,
+			localGithubClient:  githubClient,
+			repoconfig:         repoconfig,
+		}
+		goliac.SetTargetBranch("goliac-changes")
+
+		err, errs, _, _ = goliac.Apply(context.Background(), fs, false, "inmemory:///teams", "master", false)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(errs))
+
+		// a pull request is opened
+		assert.Contains(t, githubClient.restCalls, "POST /repos/"+config.Config.GithubAppOrganization+"/teams/pulls")
+
+		// user4 is committed to the target branch, not to master
+		loader := server.NewFilesystemLoader(fs)
+		client.InstallProtocol("inmemory", server.NewClient(loader))
+
+		for branch, expected := range map[string]bool{"master": false, "goliac-changes": true} {
+			checkFs := memfs.New()
+			checkRepo, err := git.Clone(memory.NewStorage(), checkFs, &git.CloneOptions{
+				URL:           "inmemory:///src",
+				ReferenceName: plumbing.NewBranchReferenceName(branch),
+				SingleBranch:  true,
+			})
+			assert.Nil(t, err)
+			assert.NotNil(t, checkRepo)
+
+			exist, err := utils.Exists(checkFs, "users/org/user4.yaml")
+			assert.Nil(t, err)
+			assert.Equal(t, expected, exist, "user4 in the %s branch", branch)
+		}
+	})
+}
+
+func TestGoliacRequiredApprovals(t *testing.T) {
+	// the teams repository (with team1 members to add on Github) and its Goliac
+	setup := func(t *testing.T, requiredApprovals int, responses func(sha string) map[string]string) (*GoliacImpl, *GoliacRemoteExecutorMock, *GitHubClientMock) {
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)        // create a fake bare repository
+		fs.MkdirAll("teams", 0755)      // create a fake cloned repository
+		fs.MkdirAll(os.TempDir(), 0755) // need a tmp folder
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture1)
+		assert.Nil(t, err)
+		head, err := clonedRepo.Head()
+		assert.Nil(t, err)
+
+		local := engine.NewGoliacLocalImplWithRepo(clonedRepo)
+		errs, _ := local.LoadAndValidateLocal(clonedFs)
+		assert.Equal(t, 0, len(errs))
+
+		repoconfig, err := local.LoadRepoConfig()
+		assert.Nil(t, err)
+		repoconfig.RequiredApprovals = requiredApprovals
+
+		githubClient := NewGitHubClientMock()
+		githubClient.restResponses = responses(head.Hash().String())
+		remote := NewGoliacRemoteExecutorMock().(*GoliacRemoteExecutorMock)
+		remote.teams1Members = []string{"github1"}
+
+		usersync.InitPlugins(githubClient)
+
+		goliac := &GoliacImpl{
+			local:              local,
+			remote:             remote,
+			remoteGithubClient: githubClient,
+			localGithubClient:  githubClient,
+			repoconfig:         repoconfig,
+		}
+		return goliac, remote, githubClient
+	}
+	pulls := func(sha string) string {
+		return `[{"number":12,"merged_at":"2024-01-01T10:00:00Z","merge_commit_sha":"` + sha + `"}]`
+	}
+	org := config.Config.GithubAppOrganization
+
+	t.Run("happy path: the pull request has enough approvals", func(t *testing.T) {
+		goliac, remote, githubClient := setup(t, 2, func(sha string) map[string]string {
+			return map[string]string{
+				"GET /repos/" + org + "/teams/commits/" + sha + "/pulls":     pulls(sha),
+				"GET /repos/" + org + "/teams/pulls/12/reviews?per_page=100": `[{"user":{"login":"alice"},"state":"CHANGES_REQUESTED"},{"user":{"login":"alice"},"state":"APPROVED"},{"user":{"login":"bob"},"state":"APPROVED"},{"user":{"login":"bob"},"state":"COMMENTED"}]`,
+			}
+		})
+
+		_, err := goliac.applyCommitsToGithub(context.Background(), false, "teams", "master", false)
+		assert.Nil(t, err)
+		assert.True(t, remote.nbChanges > 0)
+		assert.Contains(t, githubClient.restCalls, "GET /repos/"+org+"/teams/pulls/12/reviews?per_page=100")
+	})
+
+	t.Run("not happy path: the pull request lacks approvals", func(t *testing.T) {
+		goliac, remote, _ := setup(t, 2, func(sha string) map[string]string {
+			return map[string]string{
+				"GET /repos/" + org + "/teams/commits/" + sha + "/pulls":     pulls(sha),
+				"GET /repos/" + org + "/teams/pulls/12/reviews?per_page=100": `[{"user":{"login":"alice"},"state":"APPROVED"},{"user":{"login":"bob"},"state":"APPROVED"},{"user":{"login":"bob"},"state":"CHANGES_REQUESTED"}]`,
+			}
+		})
+
+		_, err := goliac.applyCommitsToGithub(context.Background(), false, "teams", "master", false)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "pull request #12")
+		assert.Contains(t, err.Error(), "has 1 approval(s), 2 required (required_approvals in goliac.yaml)")
+		assert.Equal(t, 0, remote.nbChanges)
+	})
+
+	t.Run("not happy path: the commit was not merged from a pull request", func(t *testing.T) {
+		goliac, remote, _ := setup(t, 1, func(sha string) map[string]string {
+			return map[string]string{
+				"GET /repos/" + org + "/teams/commits/" + sha + "/pulls":                                                  `[]`,
+				"GET /repos/" + org + "/teams/activity?activity_type=push&actor=goliac-project-app%5Bbot%5D&per_page=100": `[]`,
+			}
+		})
+
+		_, err := goliac.applyCommitsToGithub(context.Background(), false, "teams", "master", false)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "was not merged from a pull request: 1 approval(s) required")
+		assert.Equal(t, 0, remote.nbChanges)
+	})
+
+	t.Run("happy path: the plan is not blocked", func(t *testing.T) {
+		goliac, _, githubClient := setup(t, 2, func(sha string) map[string]string {
+			return map[string]string{}
+		})
+
+		_, err := goliac.applyCommitsToGithub(context.Background(), true, "teams", "master", false)
+		assert.Nil(t, err)
+		assert.True(t, len(goliac.GetPlannedActions()) > 0)
+		for _, call := range githubClient.restCalls {
+			assert.NotContains(t, call, "/pulls")
+		}
+	})
+
+	t.Run("happy path: the commits pushed by Goliac are not checked", func(t *testing.T) {
+		goliac, remote, _ := setup(t, 2, func(sha string) map[string]string {
+			return map[string]string{
+				"GET /repos/" + org + "/teams/commits/" + sha + "/pulls":                                                  `[]`,
+				"GET /repos/" + org + "/teams/activity?activity_type=push&actor=goliac-project-app%5Bbot%5D&per_page=100": `[{"after":"` + sha + `","actor":{"login":"goliac-project-app[bot]"}}]`,
+			}
+		})
+
+		_, err := goliac.applyCommitsToGithub(context.Background(), false, "teams", "master", false)
+		assert.Nil(t, err)
+		assert.True(t, remote.nbChanges > 0)
+	})
+
+	t.Run("not happy path: a commit with the Goliac email not pushed by Goliac", func(t *testing.T) {
+		headSha := ""
+		goliac, remote, githubClient := setup(t, 1, func(sha string) map[string]string {
+			headSha = sha
+			return map[string]string{
+				"GET /repos/" + org + "/teams/commits/" + sha + "/pulls":                                                  `[]`,
+				"GET /repos/" + org + "/teams/activity?activity_type=push&actor=goliac-project-app%5Bbot%5D&per_page=100": `[{"after":"` + sha + `","actor":{"login":"mallory"}}]`,
+			}
+		})
+		commit := &object.Commit{Hash: plumbing.NewHash(headSha), Author: object.Signature{Name: "Goliac", Email: config.Config.GoliacEmail}}
+
+		err := goliac.checkRequiredApprovals(context.TODO(), "teams", commit)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "was not merged from a pull request")
+		assert.Contains(t, githubClient.restCalls, "GET /repos/"+org+"/teams/activity?activity_type=push&actor=goliac-project-app%5Bbot%5D&per_page=100")
+
+		_, err = goliac.applyCommitsToGithub(context.Background(), false, "teams", "master", false)
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, remote.nbChanges)
+	})
+}
+
+func TestGoliacDoctor(t *testing.T) {
+
+	t.Run("happy path: orphaned owners team detected and removed", func(t *testing.T) {
+
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)        // create a fake bare repository
+		fs.MkdirAll("teams", 0755)      // create a fake cloned repository
+		fs.MkdirAll(os.TempDir(), 0755) // need a tmp folder
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture1)
+		assert.Nil(t, err)
+
+		local := engine.NewGoliacLocalImplWithRepo(clonedRepo)
+		errs, _ := local.LoadAndValidateLocal(clonedFs)
+		assert.Equal(t, len(errs), 0)
+
+		repoconfig, err := local.LoadRepoConfig()
+		assert.Nil(t, err)
+		repoconfig.DestructiveOperations.AllowDestructiveTeams = true
+
+		githubClient := NewGitHubClientMock()
+		remote := NewGoliacRemoteExecutorMock().(*GoliacRemoteExecutorMock)
+		remote.extraTeams = map[string]*engine.GithubTeam{
+			"team3-goliac-owners": {
+				Slug:    "team3-goliac-owners",
+				Name:    "team3-goliac-owners",
+				Members: []string{"github1"},
+			},
+		}
+
+		goliac := GoliacImpl{
+			local:              local,
+			remote:             remote,
+			remoteGithubClient: githubClient,
+			localGithubClient:  githubClient,
+			repoconfig:         repoconfig,
+		}
+
+		issues := goliac.doctorOrphanedOwnersTeams(context.Background(), true)
+		assert.Equal(t, 1, len(issues))
+		assert.Equal(t, []string{"team3-goliac-owners"}, remote.teamsDeleted)
+	})
+
+	t.Run("happy path: orphaned owners team not removed without destructive operations", func(t *testing.T) {
+
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)        // create a fake bare repository
+		fs.MkdirAll("teams", 0755)      // create a fake cloned repository
+		fs.MkdirAll(os.TempDir(), 0755) // need a tmp folder
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture1)
+		assert.Nil(t, err)
+
+		local := engine.NewGoliacLocalImplWithRepo(clonedRepo)
+		errs, _ := local.LoadAndValidateLocal(clonedFs)
+		assert.Equal(t, len(errs), 0)
+
+		repoconfig, err := local.LoadRepoConfig()
+		assert.Nil(t, err)
+
+		githubClient := NewGitHubClientMock()
+		remote := NewGoliacRemoteExecutorMock().(*GoliacRemoteExecutorMock)
+		remote.extraTeams = map[string]*engine.GithubTeam{
+			"team3-goliac-owners": {
+				Slug:    "team3-goliac-owners",
+				Name:    "team3-goliac-owners",
+				Members: []string{"github1"},
+			},
+		}
+
+		goliac := GoliacImpl{
+			local:              local,
+			remote:             remote,
+			remoteGithubClient: githubClient,
+			localGithubClient:  githubClient,
+			repoconfig:         repoconfig,
+		}
+
+		issues := goliac.doctorOrphanedOwnersTeams(context.Background(), true)
+		assert.Equal(t, 1, len(issues))
+		assert.Equal(t, 0, len(remote.teamsDeleted))
+	})
+}
+
+func TestGoliacStaleRepositories(t *testing.T) {
+
+	newGoliac := func(t *testing.T) GoliacImpl {
+		fs := memfs.New()
+		fs.MkdirAll("src", 0755)        // create a fake bare repository
+		fs.MkdirAll("teams", 0755)      // create a fake cloned repository
+		fs.MkdirAll(os.TempDir(), 0755) // need a tmp folder
+		srcsFs, _ := fs.Chroot("src")
+		clonedFs, _ := fs.Chroot("teams")
+		_, clonedRepo, err := helperCreateAndClone(fs, srcsFs, clonedFs, repoFixture1)
+		assert.Nil(t, err)
+
+		local := engine.NewGoliacLocalImplWithRepo(clonedRepo)
+		errs, _ := local.LoadAndValidateLocal(clonedFs)
+		assert.Equal(t, len(errs), 0)
+
+		repoconfig, err := local.LoadRepoConfig()
+		assert.Nil(t, err)
+
+		githubClient := NewGitHubClientMock()
+		return GoliacImpl{
+			local:              local,
+			remote:             NewGoliacRemoteExecutorMock(),
+			remoteGithubClient: githubClient,
+			localGithubClient:  githubClient,
+			repoconfig:         repoconfig,
+		}
+	}
+
+	t.Run("happy path: repository older than the threshold is listed", func(t *testing.T) {
+		goliac := newGoliac(t)
+
+		// repo1 was pushed in 2020, repo2 in 2024
+		stale := goliac.staleRepositories(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, 1, len(stale))
+		assert.Equal(t, "repo1", stale[0].Name)
+		assert.Equal(t, repo1PushedAt, *stale[0].PushedAt)
+	})
+
+	t.Run("happy path: oldest repositories first", func(t *testing.T) {
+		goliac := newGoliac(t)
+
+		stale := goliac.staleRepositories(context.Background(), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, 2, len(stale))
+		assert.Equal(t, "repo1", stale[0].Name)
+		assert.Equal(t, "repo2", stale[1].Name)
+	})
+
+	t.Run("happy path: no stale repository", func(t *testing.T) {
+		goliac := newGoliac(t)
+
+		stale := goliac.staleRepositories(context.Background(), time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, 0, len(stale))
+	})
 }
@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * Goliac operational metrics, exposed (in the Prometheus text exposition format)
+ * on the /metrics endpoint of the server when GOLIAC_SERVER_METRICS_ENABLED is set
+ */
+var (
+	ApplyRunsTotal         = NewCounter("goliac_apply_runs_total", "Number of apply runs")
+	ApplyErrorsTotal       = NewCounter("goliac_apply_errors_total", "Number of apply runs that failed")
+	ReconciliationDuration = NewHistogram("goliac_reconciliation_duration_seconds", "Duration of the apply runs", []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200})
+	GithubApiCallsTotal    = NewCounterVec("goliac_github_api_calls_total", "Number of Github API calls", "method")
+	ResourcesChangesTotal  = NewCounterVec("goliac_resources_changes_total", "Number of Github resources created, updated or deleted", "change")
+	LastSuccessfulApply    = NewGauge("goliac_last_successful_apply_timestamp_seconds", "Unix timestamp of the last successful apply run")
+
+	registry = []metric{ApplyRunsTotal, ApplyErrorsTotal, ReconciliationDuration, GithubApiCallsTotal, ResourcesChangesTotal, LastSuccessfulApply}
+)
+
+type metric interface {
+	write(w io.Writer)
+}
+
+/*
+ * Counter is a monotonically increasing value
+ */
+type Counter struct {
+	name  string
+	help  string
+	mutex sync.Mutex
+	value float64
+}
+
+func NewCounter(name string, help string) *Counter {
+	return &Counter{
+		name: name,
+		help: help,
+	}
+}
+
+func (c *Counter) Inc() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.value++
+}
+
+func (c *Counter) Value() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.value
+}
+
+func (c *Counter) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	fmt.Fprintf(w, "%s %s\n", c.name, formatFloat(c.Value()))
+}
+
+/*
+ * Gauge is a value that can go up and down
+ */
+type Gauge struct {
+	name  string
+	help  string
+	mutex sync.Mutex
+	value float64
+}
+
+func NewGauge(name string, help string) *Gauge {
+	return &Gauge{
+		name: name,
+		help: help,
+	}
+}
+
+func (g *Gauge) Set(value float64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.value = value
+}
+
+func (g *Gauge) SetToTime(t time.Time) {
+	g.Set(float64(t.Unix()))
+}
+
+func (g *Gauge) Value() float64 {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.value
+}
+
+func (g *Gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.Value()))
+}
+
+/*
+ * CounterVec is a set of counters partitioned by the value of a label
+ */
+type CounterVec struct {
+	name   string
+	help   string
+	label  string
+	mutex  sync.Mutex
+	values map[string]float64 // key is the label value
+}
+
+func NewCounterVec(name string, help string, label string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		label:  label,
+		values: make(map[string]float64),
+	}
+}
+
+func (c *CounterVec) Inc(labelValue string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.values[labelValue]++
+}
+
+func (c *CounterVec) Value(labelValue string) float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.values[labelValue]
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	labelValues := make([]string, 0, len(c.values))
+	for v := range c.values {
+		labelValues = append(labelValues, v)
+	}
+	sort.Strings(labelValues)
+	for _, v := range labelValues {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", c.name, c.label, escapeLabelValue(v), formatFloat(c.values[v]))
+	}
+}
+
+/*
+ * Histogram counts observations in cumulative buckets
+ */
+type Histogram struct {
+	name    string
+	help    string
+	buckets []float64 // upper bounds, sorted
+	mutex   sync.Mutex
+	counts  []uint64 // one per bucket (non cumulative)
+	count   uint64
+	sum     float64
+}
+
+func NewHistogram(name string, help string, buckets []float64) *Histogram {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{
+		name:    name,
+		help:    help,
+		buckets: sorted,
+		counts:  make([]uint64, len(sorted)),
+	}
+}
+
+func (h *Histogram) Observe(value float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for i, upperBound := range h.buckets {
+		if value <= upperBound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	cumulative := uint64(0)
+	for i, upperBound := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(upperBound), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, h.count)
+}
+
+func formatFloat(f float64) string {
+	// shortest representation that round-trips (timestamps keep all their digits)
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+/*
+ * Handler serves all the Goliac metrics in the Prometheus text exposition format
+ */
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, m := range registry {
+			m.write(w)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+
+	t.Run("happy path: counter", func(t *testing.T) {
+		c := NewCounter("test_total", "a test counter")
+		c.Inc()
+		c.Inc()
+
+		var b strings.Builder
+		c.write(&b)
+		assert.Equal(t, "# HELP test_total a test counter\n# TYPE test_total counter\ntest_total 2\n", b.String())
+	})
+
+	t.Run("happy path: counter with a label", func(t *testing.T) {
+		c := NewCounterVec("test_calls_total", "a test counter", "method")
+		c.Inc("POST")
+		c.Inc("GET")
+		c.Inc("GET")
+
+		var b strings.Builder
+		c.write(&b)
+		assert.Equal(t, "# HELP test_calls_total a test counter\n# TYPE test_calls_total counter\n"+
+			"test_calls_total{method=\"GET\"} 2\ntest_calls_total{method=\"POST\"} 1\n", b.String())
+	})
+
+	t.Run("happy path: gauge keeps all the digits of a timestamp", func(t *testing.T) {
+		g := NewGauge("test_timestamp_seconds", "a test gauge")
+		g.SetToTime(time.Unix(1760000123, 0))
+
+		var b strings.Builder
+		g.write(&b)
+		assert.Equal(t, "# HELP test_timestamp_seconds a test gauge\n# TYPE test_timestamp_seconds gauge\ntest_timestamp_seconds 1.760000123e+09\n", b.String())
+	})
+
+	t.Run("happy path: histogram buckets are cumulative", func(t *testing.T) {
+		h := NewHistogram("test_duration_seconds", "a test histogram", []float64{10, 1})
+		h.Observe(0.5)
+		h.Observe(5)
+		h.Observe(50)
+
+		var b strings.Builder
+		h.write(&b)
+		assert.Equal(t, "# HELP test_duration_seconds a test histogram\n# TYPE test_duration_seconds histogram\n"+
+			"test_duration_seconds_bucket{le=\"1\"} 1\n"+
+			"test_duration_seconds_bucket{le=\"10\"} 2\n"+
+			"test_duration_seconds_bucket{le=\"+Inf\"} 3\n"+
+			"test_duration_seconds_sum 55.5\n"+
+			"test_duration_seconds_count 3\n", b.String())
+	})
+
+	t.Run("happy path: handler exposes all the metrics", func(t *testing.T) {
+		GithubApiCallsTotal.Inc("GET")
+
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain"))
+		body := rec.Body.String()
+		assert.Contains(t, body, "# TYPE goliac_apply_runs_total counter")
+		assert.Contains(t, body, "# TYPE goliac_apply_errors_total counter")
+		assert.Contains(t, body, "# TYPE goliac_reconciliation_duration_seconds histogram")
+		assert.Contains(t, body, "goliac_github_api_calls_total{method=\"GET\"}")
+		assert.Contains(t, body, "# TYPE goliac_resources_changes_total counter")
+		assert.Contains(t, body, "# TYPE goliac_last_successful_apply_timestamp_seconds gauge")
+	})
+}
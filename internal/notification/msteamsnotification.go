@@ -0,0 +1,55 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type MsTeamsNotificationService struct {
+	WebhookUrl string
+	client     *http.Client
+	retryDelay time.Duration // initial delay, doubled after each failed attempt
+}
+
+/*
+ * NewMsTeamsNotificationService sends the notifications to a Microsoft Teams
+ * incoming webhook
+ */
+func NewMsTeamsNotificationService(webhookUrl string) NotificationService {
+	return &MsTeamsNotificationService{
+		WebhookUrl: webhookUrl,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		retryDelay: 1 * time.Second,
+	}
+}
+
+// https://learn.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+type MsTeamsMessageCard struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	Summary    string `json:"summary"`
+	ThemeColor string `json:"themeColor"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+func (s *MsTeamsNotificationService) SendNotification(message string, summary NotificationSummary) error {
+	msg := MsTeamsMessageCard{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		Summary:    "Goliac notification",
+		ThemeColor: "d73a4a",
+		Title:      "Goliac",
+		Text:       message,
+	}
+
+	jsonPayload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	// the webhook url carries its own secret: no token
+	return postWithRetry(s.client, s.WebhookUrl, "", jsonPayload, s.retryDelay)
+}
@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMsTeamsNotification(t *testing.T) {
+
+	t.Run("happy path", func(t *testing.T) {
+		var received MsTeamsMessageCard
+		var contentType string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType = r.Header.Get("Content-Type")
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		service := NewMsTeamsNotificationService(server.URL)
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.Nil(t, err)
+		assert.Equal(t, "application/json", contentType)
+		assert.Equal(t, "MessageCard", received.Type)
+		assert.Equal(t, "hello", received.Text)
+	})
+
+	t.Run("happy path: retry on 5xx", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		service := NewMsTeamsNotificationService(server.URL).(*MsTeamsNotificationService)
+		service.retryDelay = time.Millisecond
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("not happy path: max attempts reached", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		service := NewMsTeamsNotificationService(server.URL).(*MsTeamsNotificationService)
+		service.retryDelay = time.Millisecond
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.NotNil(t, err)
+		assert.Equal(t, WEBHOOK_MAX_ATTEMPTS, attempts)
+	})
+
+	t.Run("not happy path: no retry on 4xx", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		service := NewMsTeamsNotificationService(server.URL).(*MsTeamsNotificationService)
+		service.retryDelay = time.Millisecond
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -1,7 +1,15 @@
 package notification
 
+/*
+ * NotificationSummary is the outcome of the apply run being notified
+ */
+type NotificationSummary struct {
+	Changes    []string // the planned actions ("operation target")
+	ErrorCount int
+}
+
 type NotificationService interface {
-	SendNotification(message string) error
+	SendNotification(message string, summary NotificationSummary) error
 }
 
 type NullNotificationService struct {
@@ -11,6 +19,6 @@ func NewNullNotificationService() NotificationService {
 	return &NullNotificationService{}
 }
 
-func (s *NullNotificationService) SendNotification(message string) error {
+func (s *NullNotificationService) SendNotification(message string, summary NotificationSummary) error {
 	return nil
 }
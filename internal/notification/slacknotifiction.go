@@ -24,7 +24,7 @@ type SlackMessage struct {
 	Text    string `json:"text"`
 }
 
-func (s *SlackNotificationService) SendNotification(message string) error {
+func (s *SlackNotificationService) SendNotification(message string, summary NotificationSummary) error {
 	url := "https://slack.com/api/chat.postMessage"
 
 	// Prepare the message payload
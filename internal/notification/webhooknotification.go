@@ -0,0 +1,107 @@
+package notification
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const WEBHOOK_MAX_ATTEMPTS = 3
+
+type WebhookNotificationService struct {
+	Url          string
+	Token        string
+	Organization string
+	client       *http.Client
+	retryDelay   time.Duration // initial delay, doubled after each failed attempt
+}
+
+func NewWebhookNotificationService(url string, token string, organization string) NotificationService {
+	return &WebhookNotificationService{
+		Url:          url,
+		Token:        token,
+		Organization: organization,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		retryDelay:   1 * time.Second,
+	}
+}
+
+type WebhookMessage struct {
+	EventType    string   `json:"event_type"`
+	Timestamp    string   `json:"timestamp"`
+	Organization string   `json:"organization"`
+	Message      string   `json:"message"`
+	Changes      []string `json:"changes"`
+	ErrorCount   int      `json:"error_count"`
+}
+
+func (s *WebhookNotificationService) SendNotification(message string, summary NotificationSummary) error {
+	changes := summary.Changes
+	if changes == nil {
+		changes = []string{}
+	}
+	msg := WebhookMessage{
+		EventType:    "notification",
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Organization: s.Organization,
+		Message:      message,
+		Changes:      changes,
+		ErrorCount:   summary.ErrorCount,
+	}
+
+	jsonPayload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	return postWithRetry(s.client, s.Url, s.Token, jsonPayload, s.retryDelay)
+}
+
+/*
+ * postWithRetry POSTs a JSON payload, and retries (up to WEBHOOK_MAX_ATTEMPTS
+ * attempts) on 5xx responses, doubling the delay after each failed attempt
+ */
+func postWithRetry(client *http.Client, url string, token string, jsonPayload []byte, retryDelay time.Duration) error {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := post(client, url, token, jsonPayload)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= WEBHOOK_MAX_ATTEMPTS {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post returns an error, and if the request can be retried (5xx responses)
+func post(client *http.Client, url string, token string, jsonPayload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if err != nil {
+		return false, fmt.Errorf("failed to create new request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return true, fmt.Errorf("received %v response", resp.Status)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("received non-2xx response: %v", resp.Status)
+	}
+
+	return false, nil
+}
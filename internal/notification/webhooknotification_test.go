@@ -0,0 +1,99 @@
+package notification
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookNotification(t *testing.T) {
+
+	t.Run("happy path", func(t *testing.T) {
+		var received WebhookMessage
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		service := NewWebhookNotificationService(server.URL, "token", "myorg")
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.Nil(t, err)
+		assert.Equal(t, "Bearer token", authorization)
+		assert.Equal(t, "myorg", received.Organization)
+		assert.Equal(t, "hello", received.Message)
+	})
+
+	t.Run("happy path: the changes and the error count are sent", func(t *testing.T) {
+		var received WebhookMessage
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&received)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		service := NewWebhookNotificationService(server.URL, "", "myorg")
+		err := service.SendNotification("Goliac error when syncing: boom", NotificationSummary{
+			Changes:    []string{"create_team team/team1"},
+			ErrorCount: 2,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"create_team team/team1"}, received.Changes)
+		assert.Equal(t, 2, received.ErrorCount)
+	})
+
+	t.Run("happy path: retry on 5xx", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		service := NewWebhookNotificationService(server.URL, "", "myorg").(*WebhookNotificationService)
+		service.retryDelay = time.Millisecond
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("not happy path: max attempts reached", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		service := NewWebhookNotificationService(server.URL, "", "myorg").(*WebhookNotificationService)
+		service.retryDelay = time.Millisecond
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.NotNil(t, err)
+		assert.Equal(t, WEBHOOK_MAX_ATTEMPTS, attempts)
+	})
+
+	t.Run("not happy path: no retry on 4xx", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		service := NewWebhookNotificationService(server.URL, "", "myorg").(*WebhookNotificationService)
+		service.retryDelay = time.Millisecond
+		err := service.SendNotification("hello", NotificationSummary{})
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Alayacare/goliac/internal/engine"
+)
+
+/*
+ * PlanHash returns a stable hash of the planned actions: the same changes
+ * give the same hash, whatever the order (and the reason) they were planned in
+ */
+func PlanHash(actions []engine.PlannedAction) string {
+	lines := make([]string, 0, len(actions))
+	for _, a := range engine.WithoutReasons(actions) {
+		line, err := json.Marshal(a)
+		if err != nil {
+			// not expected (the before/after values come from the entities)
+			line = []byte(a.Operation + " " + a.Target)
+		}
+		lines = append(lines, string(line))
+	}
+	sort.Strings(lines)
+
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+ * readPlanHash returns the plan hash persisted in filename (or an empty
+ * string if there is none yet)
+ */
+func readPlanHash(filename string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+func writePlanHash(filename string, hash string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(hash+"\n"), 0644)
+}
@@ -0,0 +1,567 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/gosimple/slug"
+	"github.com/sirupsen/logrus"
+)
+
+/*
+ * exportGoliacConf is the goliac.yaml written by Export
+ * (only the settings needed to mirror the organization)
+ */
+type exportGoliacConf struct {
+	AdminTeam           string `yaml:"admin_team"`
+	EveryoneTeamEnabled bool   `yaml:"everyone_team_enabled,omitempty"`
+	Rulesets            []struct {
+		Pattern string `yaml:"pattern"`
+		Ruleset string `yaml:"ruleset"`
+	} `yaml:"rulesets,omitempty"`
+	MaxChangesets         int  `yaml:"max_changesets"`
+	ArchiveOnDelete       bool `yaml:"archive_on_delete"`
+	ManageGithubVariables bool `yaml:"manage_github_variables,omitempty"`
+	RequiredApprovals     int  `yaml:"required_approvals,omitempty"`
+	DestructiveOperations struct {
+		AllowDestructiveRepositories bool `yaml:"repositories"`
+		AllowDestructiveTeams        bool `yaml:"teams"`
+		AllowDestructiveUsers        bool `yaml:"users"`
+		AllowDestructiveRulesets     bool `yaml:"rulesets"`
+	} `yaml:"destructive_operations"`
+	UserSync struct {
+		Plugin string `yaml:"plugin"`
+	} `yaml:"usersync"`
+}
+
+/*
+ * Export writes the current state of the Github organization as a Goliac
+ * directory structure. Unlike Generate, nothing is added (no admin team,
+ * no README, no Github action): the goal is to get a directory that can be
+ * planned without any change (for disaster recovery, or to diff with an
+ * existing teams repository)
+ */
+func (s *Scaffold) Export(rootpath string, adminteam string) error {
+	if _, err := os.Stat(rootpath); os.IsNotExist(err) {
+		// Create the directory if it does not exist
+		err := os.MkdirAll(rootpath, 0755)
+		if err != nil {
+			return fmt.Errorf("error creating directory: %v", err)
+		}
+	}
+	fs := osfs.New(rootpath)
+
+	ctx := context.Background()
+	if err := s.remote.Load(ctx, true); err != nil {
+		logrus.Warnf("Not able to load all information from Github: %v, but I will try to continue", err)
+	}
+
+	return s.export(ctx, fs, adminteam)
+}
+
+func (s *Scaffold) export(ctx context.Context, fs billy.Filesystem, adminteam string) error {
+	utils.RemoveAll(fs, "users")
+	utils.RemoveAll(fs, "teams")
+	utils.RemoveAll(fs, "rulesets")
+	utils.RemoveAll(fs, "archived")
+
+	fs.MkdirAll("archived", 0755)
+	fs.MkdirAll("rulesets", 0755)
+	fs.MkdirAll("teams", 0755)
+
+	conf := exportGoliacConf{
+		AdminTeam:       adminteam,
+		MaxChangesets:   50,
+		ArchiveOnDelete: true,
+	}
+	conf.UserSync.Plugin = "noop"
+	if s.remote.IsEnterprise() {
+		conf.UserSync.Plugin = "fromgithubsaml"
+	}
+	if _, ok := s.remote.TeamSlugByName(ctx)[adminteam]; !ok {
+		logrus.Warnf("the admin team %s doesn't exist in the organization", adminteam)
+	}
+
+	usermap, err := s.generateUsers(ctx, fs, "users")
+	if err != nil {
+		return fmt.Errorf("error creaing the users directory: %v", err)
+	}
+
+	if _, ok := s.remote.Teams(ctx)["everyone"]; ok {
+		conf.EveryoneTeamEnabled = true
+	}
+
+	signedRepos, err := s.exportRulesets(ctx, fs, "rulesets", &conf)
+	if err != nil {
+		return fmt.Errorf("error creating the rulesets directory: %v", err)
+	}
+
+	if err := s.exportTeams(ctx, fs, "teams", "archived", usermap, signedRepos); err != nil {
+		return fmt.Errorf("error creating the teams directory: %v", err)
+	}
+
+	if variables := s.remote.OrgVariables(ctx); len(variables) > 0 {
+		conf.ManageGithubVariables = true
+		if err := s.exportOrgVariables(fs, "org-variables.yaml", variables); err != nil {
+			return fmt.Errorf("error creating the org-variables.yaml file: %v", err)
+		}
+	}
+
+	if err := writeYamlFile("goliac.yaml", &conf, fs); err != nil {
+		return fmt.Errorf("error creating the goliac.yaml file: %v", err)
+	}
+
+	return nil
+}
+
+/*
+ * exportTeams writes the teams (and their repositories). The owners of a team
+ * are the members of its Goliac owners team (or the Github maintainers if the
+ * team was not managed by Goliac). A repository is attached to the team
+ * having an admin (else write) access to it; the repositories without such a
+ * team cannot be exported and are reported
+ */
+func (s *Scaffold) exportTeams(ctx context.Context, fs billy.Filesystem, teamspath string, archivedpath string, usermap map[string]string, signedRepos map[string]bool) error {
+	teams := s.remote.Teams(ctx)
+	teamsNameBySlug := make(map[string]string)
+	for name, teamslug := range s.remote.TeamSlugByName(ctx) {
+		teamsNameBySlug[teamslug] = name
+	}
+
+	teamIds := make(map[int]*engine.GithubTeam)
+	for _, t := range teams {
+		teamIds[t.Id] = t
+	}
+
+	exported := func(teamslug string) bool {
+		if _, ok := teams[teamslug]; !ok {
+			return false
+		}
+		// the owners teams and the everyone team are generated by Goliac
+		if strings.HasSuffix(teamslug, config.Config.GoliacTeamOwnerSuffix) || teamslug == "everyone" {
+			return false
+		}
+		return !strings.HasPrefix(teams[teamslug].Name, config.DEFAULT_ARCHIVED_TEAM_PREFIX)
+	}
+
+	// github id -> user name
+	username := func(githubid string) (string, bool) {
+		name, ok := usermap[githubid]
+		if !ok {
+			logrus.Warnf("user %s is not defined in the users directory: not exported", githubid)
+		}
+		return name, ok
+	}
+
+	teamPaths := make(map[string]string)
+	teamslugs := make([]string, 0, len(teams))
+	for teamslug := range teams {
+		if exported(teamslug) {
+			teamslugs = append(teamslugs, teamslug)
+		}
+	}
+	sort.Strings(teamslugs)
+
+	for _, teamslug := range teamslugs {
+		t := teams[teamslug]
+		lTeam := entity.Team{}
+		lTeam.ApiVersion = "v1"
+		lTeam.Kind = "Team"
+		lTeam.Name = t.Name
+
+		owners := map[string]bool{}
+		if ownersTeam, ok := teams[teamslug+config.Config.GoliacTeamOwnerSuffix]; ok {
+			for _, m := range ownersTeam.Members {
+				owners[m] = true
+			}
+			for _, m := range ownersTeam.Maintainers {
+				owners[m] = true
+			}
+		} else {
+			for _, m := range t.Maintainers {
+				owners[m] = true
+			}
+		}
+		members := append(append([]string{}, t.Members...), t.Maintainers...)
+		sort.Strings(members)
+		for _, m := range members {
+			name, ok := username(m)
+			if !ok {
+				continue
+			}
+			if owners[m] {
+				lTeam.Spec.Owners = append(lTeam.Spec.Owners, name)
+			} else {
+				lTeam.Spec.Members = append(lTeam.Spec.Members, name)
+			}
+		}
+
+		teamPath, err := buildTeamPath(teamIds, t)
+		if err != nil {
+			logrus.Errorf("unable to compute team's path: %v (for team %s)", err, t.Name)
+			continue
+		}
+		teamPaths[teamslug] = teamPath
+		fs.MkdirAll(filepath.Join(teamspath, teamPath), 0755)
+		if err := writeYamlFile(filepath.Join(teamspath, teamPath, "team.yaml"), &lTeam, fs); err != nil {
+			logrus.Errorf("not able to write team file %s/team.yaml: %v", teamPath, err)
+		}
+	}
+
+	// repository name -> team slug -> access
+	reposAccess := make(map[string]map[string]*engine.GithubTeamRepo)
+	for teamslug, repos := range s.remote.TeamRepositories(ctx) {
+		if !exported(teamslug) {
+			continue
+		}
+		for reponame, access := range repos {
+			if reposAccess[reponame] == nil {
+				reposAccess[reponame] = make(map[string]*engine.GithubTeamRepo)
+			}
+			reposAccess[reponame][teamslug] = access
+		}
+	}
+
+	// the settings loaded on demand (nil if they cannot be loaded: then they are not exported)
+	actionsPermissions := s.remote.RepositoriesActionsPermissions(ctx)
+	security := s.remote.RepositoriesSecurity(ctx)
+	webhooks := s.remote.RepositoriesWebhooks(ctx)
+	labels := s.remote.RepositoriesLabels(ctx)
+	customProperties := s.remote.RepositoriesCustomProperties(ctx)
+
+	externalUsers := make(map[string]bool)
+	repos := s.remote.Repositories(ctx)
+	reponames := make([]string, 0, len(repos))
+	for reponame := range repos {
+		reponames = append(reponames, reponame)
+	}
+	sort.Strings(reponames)
+
+	for _, reponame := range reponames {
+		rRepo := repos[reponame]
+		lRepo := entity.Repository{}
+		lRepo.ApiVersion = "v1"
+		lRepo.Kind = "Repository"
+		lRepo.Name = reponame
+
+		access := reposAccess[reponame]
+		accessSlugs := make([]string, 0, len(access))
+		for teamslug := range access {
+			accessSlugs = append(accessSlugs, teamslug)
+		}
+		sort.Strings(accessSlugs)
+
+		// the owner is the first team with an admin access, else a write access
+		owner := ""
+		for _, permission := range []string{"ADMIN", "WRITE"} {
+			for _, teamslug := range accessSlugs {
+				if owner == "" && access[teamslug].RoleName == "" && access[teamslug].Permission == permission {
+					owner = teamslug
+				}
+			}
+		}
+		for _, teamslug := range accessSlugs {
+			a := access[teamslug]
+			switch {
+			case teamslug == owner:
+			case a.RoleName != "":
+				if lRepo.Spec.CustomRoles == nil {
+					lRepo.Spec.CustomRoles = make(map[string]string)
+				}
+				lRepo.Spec.CustomRoles[teamsNameBySlug[teamslug]] = a.RoleName
+			case a.Permission == "ADMIN" || a.Permission == "WRITE":
+				lRepo.Spec.Writers = append(lRepo.Spec.Writers, teamsNameBySlug[teamslug])
+			default:
+				lRepo.Spec.Readers = append(lRepo.Spec.Readers, teamsNameBySlug[teamslug])
+			}
+		}
+
+		githubids := make([]string, 0, len(rRepo.ExternalUsers))
+		for githubid := range rRepo.ExternalUsers {
+			githubids = append(githubids, githubid)
+		}
+		sort.Strings(githubids)
+		for _, githubid := range githubids {
+			externalUsers[githubid] = true
+			if rRepo.ExternalUsers[githubid] == "WRITE" {
+				lRepo.Spec.ExternalUserWriters = append(lRepo.Spec.ExternalUserWriters, githubid)
+			} else {
+				lRepo.Spec.ExternalUserReaders = append(lRepo.Spec.ExternalUserReaders, githubid)
+			}
+		}
+
+		lRepo.Spec.IsPublic = !rRepo.BoolProperties["private"]
+		lRepo.Spec.AllowAutoMerge = rRepo.BoolProperties["allow_auto_merge"]
+		lRepo.Spec.DeleteBranchOnMerge = rRepo.BoolProperties["delete_branch_on_merge"]
+		lRepo.Spec.AllowUpdateBranch = rRepo.BoolProperties["allow_update_branch"]
+		if v, ok := rRepo.BoolProperties["allow_merge_commit"]; ok {
+			lRepo.Spec.AllowMergeCommit = &v
+		}
+		if v, ok := rRepo.BoolProperties["allow_squash_merge"]; ok {
+			lRepo.Spec.AllowSquashMerge = &v
+		}
+		if v, ok := rRepo.BoolProperties["allow_rebase_merge"]; ok {
+			lRepo.Spec.AllowRebaseMerge = &v
+		}
+		if rRepo.BoolProperties["is_template"] {
+			isTemplate := true
+			lRepo.Spec.IsTemplate = &isTemplate
+		}
+		if v := rRepo.StringProperties["description"]; v != "" {
+			lRepo.Spec.Description = &v
+		}
+		if v := rRepo.StringProperties["homepage"]; v != "" {
+			lRepo.Spec.Homepage = &v
+		}
+		if rRepo.DependabotAlerts {
+			dependabotAlerts := true
+			lRepo.Spec.DependabotAlerts = &dependabotAlerts
+		}
+		if rRepo.RequireSignedCommits || signedRepos[slug.Make(reponame)] {
+			requireSignedCommits := true
+			lRepo.Spec.RequireSignedCommits = &requireSignedCommits
+		}
+		if p, ok := actionsPermissions[reponame]; ok {
+			lRepo.Spec.ActionsPermissions = &entity.RepositoryActionsPermissions{
+				Enabled:        p.Enabled,
+				AllowedActions: p.AllowedActions,
+			}
+		}
+		if sec, ok := security[reponame]; ok {
+			secretScanning := sec.SecretScanning
+			secretScanningPushProtection := sec.SecretScanningPushProtection
+			dependabotSecurityUpdates := sec.DependabotSecurityUpdates
+			lRepo.Spec.Security = &entity.RepositorySecurity{
+				SecretScanning:               &secretScanning,
+				SecretScanningPushProtection: &secretScanningPushProtection,
+				DependabotSecurityUpdates:    &dependabotSecurityUpdates,
+			}
+		}
+		if properties, ok := customProperties[reponame]; ok && len(properties) > 0 {
+			lRepo.Spec.CustomProperties = make(map[string]string)
+			for k, v := range properties {
+				lRepo.Spec.CustomProperties[k] = v
+			}
+		}
+		if hooks, ok := webhooks[reponame]; ok {
+			lRepo.Spec.Webhooks = exportWebhooks(hooks)
+		}
+		if repoLabels, ok := labels[reponame]; ok {
+			lRepo.Spec.Labels = exportLabels(repoLabels)
+		}
+
+		if rRepo.BoolProperties["archived"] {
+			// no owner in the archived directory
+			if owner != "" {
+				lRepo.Spec.Writers = append([]string{teamsNameBySlug[owner]}, lRepo.Spec.Writers...)
+			}
+			if err := writeYamlFile(path.Join(archivedpath, reponame+".yaml"), &lRepo, fs); err != nil {
+				logrus.Errorf("not able to write repo file %s/%s.yaml: %v", archivedpath, reponame, err)
+			}
+			continue
+		}
+		teamPath, ok := teamPaths[owner]
+		if !ok {
+			logrus.Warnf("repository %s has no team with an admin or write access: not exported", reponame)
+			continue
+		}
+		if err := writeYamlFile(path.Join(teamspath, teamPath, reponame+".yaml"), &lRepo, fs); err != nil {
+			logrus.Errorf("not able to write repo file %s/%s.yaml: %v", teamPath, reponame, err)
+		}
+	}
+
+	for githubid := range externalUsers {
+		user := entity.User{}
+		user.ApiVersion = "v1"
+		user.Kind = "User"
+		user.Name = githubid
+		user.Spec.GithubID = githubid
+		if err := writeYamlFile(path.Join("users", "external", githubid+".yaml"), &user, fs); err != nil {
+			logrus.Errorf("Not able to write user file external/%s.yaml: %v", githubid, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+ * exportRulesets writes the rulesets, and references them in goliac.yaml.
+ * The rulesets targeting repositories by id are referenced with a pattern
+ * matching exactly these repositories.
+ * Returns the repositories covered by the Goliac required signatures ruleset
+ * (that is not exported, but regenerated from the repositories files).
+ * The teams repository approvals ruleset is exported as required_approvals
+ */
+func (s *Scaffold) exportRulesets(ctx context.Context, fs billy.Filesystem, rulesetspath string, conf *exportGoliacConf) (map[string]bool, error) {
+	signedRepos := make(map[string]bool)
+	rulesets := s.remote.RuleSets(ctx)
+
+	names := make([]string, 0, len(rulesets))
+	for name := range rulesets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rs := rulesets[name]
+		if name == engine.REQUIRED_SIGNATURES_RULESET {
+			for _, reponame := range rs.Repositories {
+				signedRepos[reponame] = true
+			}
+			continue
+		}
+		if name == engine.TEAMS_REPO_APPROVALS_RULESET {
+			conf.RequiredApprovals = rs.Rules["pull_request"].RequiredApprovingReviewCount
+			continue
+		}
+
+		lRuleset := entity.RuleSet{}
+		lRuleset.ApiVersion = "v1"
+		lRuleset.Kind = "Ruleset"
+		lRuleset.Name = rs.Name
+		lRuleset.Spec.Enforcement = rs.Enforcement
+
+		apps := make([]string, 0, len(rs.BypassApps))
+		for appname := range rs.BypassApps {
+			apps = append(apps, appname)
+		}
+		sort.Strings(apps)
+		for _, appname := range apps {
+			lRuleset.Spec.BypassApps = append(lRuleset.Spec.BypassApps, struct {
+				AppName string
+				Mode    string
+			}{AppName: appname, Mode: rs.BypassApps[appname]})
+		}
+		lRuleset.Spec.On.Include = rs.OnInclude
+		lRuleset.Spec.On.Exclude = rs.OnExclude
+
+		ruletypes := make([]string, 0, len(rs.Rules))
+		for ruletype := range rs.Rules {
+			ruletypes = append(ruletypes, ruletype)
+		}
+		sort.Strings(ruletypes)
+		for _, ruletype := range ruletypes {
+			lRuleset.Spec.Rules = append(lRuleset.Spec.Rules, struct {
+				Ruletype   string
+				Parameters entity.RuleSetParameters
+			}{Ruletype: ruletype, Parameters: rs.Rules[ruletype]})
+		}
+
+		pattern := ".*"
+		if len(rs.RepositoryPropertyInclude) > 0 {
+			lRuleset.Spec.Repositories.Properties.Include = rs.RepositoryPropertyInclude
+			lRuleset.Spec.Repositories.Properties.Exclude = rs.RepositoryPropertyExclude
+		} else if len(rs.RepositoryNameInclude) > 0 {
+			lRuleset.Spec.Repositories.Include = rs.RepositoryNameInclude
+			lRuleset.Spec.Repositories.Exclude = rs.RepositoryNameExclude
+		} else {
+			quoted := make([]string, 0, len(rs.Repositories))
+			for _, reponame := range rs.Repositories {
+				quoted = append(quoted, regexp.QuoteMeta(slug.Make(reponame)))
+			}
+			sort.Strings(quoted)
+			pattern = "^(" + strings.Join(quoted, "|") + ")$"
+		}
+
+		if err := writeYamlFile(path.Join(rulesetspath, rs.Name+".yaml"), &lRuleset, fs); err != nil {
+			return nil, err
+		}
+		conf.Rulesets = append(conf.Rulesets, struct {
+			Pattern string `yaml:"pattern"`
+			Ruleset string `yaml:"ruleset"`
+		}{Pattern: pattern, Ruleset: rs.Name})
+	}
+
+	return signedRepos, nil
+}
+
+func (s *Scaffold) exportOrgVariables(fs billy.Filesystem, filename string, variables map[string]*engine.GithubOrgVariable) error {
+	orgVariables := entity.OrgVariables{}
+	orgVariables.ApiVersion = "v1"
+	orgVariables.Kind = "OrgVariables"
+	orgVariables.Name = "org-variables"
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v := variables[name]
+		orgVariables.Spec.Variables = append(orgVariables.Spec.Variables, entity.OrgVariable{
+			Name:                 v.Name,
+			Value:                v.Value,
+			Visibility:           v.Visibility,
+			SelectedRepositories: v.SelectedRepositories,
+		})
+	}
+
+	return writeYamlFile(filename, &orgVariables, fs)
+}
+
+/*
+ * exportWebhooks returns the webhooks of a repository sorted by url.
+ * Github never returns the secret: it is kept as is on Github
+ */
+func exportWebhooks(webhooks map[string]*engine.GithubWebhook) []entity.RepositoryWebhook {
+	urls := make([]string, 0, len(webhooks))
+	for url := range webhooks {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	exported := make([]entity.RepositoryWebhook, 0, len(urls))
+	for _, url := range urls {
+		w := webhooks[url]
+		webhook := entity.RepositoryWebhook{
+			URL:         w.URL,
+			Events:      w.Events,
+			ContentType: w.ContentType,
+		}
+		if !w.Active {
+			active := false
+			webhook.Active = &active
+		}
+		exported = append(exported, webhook)
+	}
+	return exported
+}
+
+/*
+ * exportLabels returns the labels of a repository sorted by name
+ * (the labels not listed are left untouched: labels_managed is not set)
+ */
+func exportLabels(labels map[string]*engine.GithubLabel) []entity.RepositoryLabel {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	exported := make([]entity.RepositoryLabel, 0, len(names))
+	for _, name := range names {
+		l := labels[name]
+		label := entity.RepositoryLabel{
+			Name:  l.Name,
+			Color: l.Color,
+		}
+		if l.Description != nil && *l.Description != "" {
+			description := *l.Description
+			label.Description = &description
+		}
+		exported = append(exported, label)
+	}
+	return exported
+}
@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/Alayacare/goliac/internal/utils"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * NewExportGoliacRemoteMock returns an organization managed by Goliac
+ * (with the owners teams)
+ */
+func NewExportGoliacRemoteMock() *ScaffoldGoliacRemoteMock {
+	parentId := 2
+	bugDescription := "Something isn't working"
+	repoProperties := func(archived bool) map[string]bool {
+		return map[string]bool{
+			"private":                true,
+			"archived":               archived,
+			"allow_auto_merge":       false,
+			"delete_branch_on_merge": true,
+			"allow_update_branch":    false,
+			"allow_squash_merge":     true,
+		}
+	}
+
+	return &ScaffoldGoliacRemoteMock{
+		users: map[string]string{
+			"githubid1": "ADMIN",
+			"githubid2": "MEMBER",
+			"githubid3": "MEMBER",
+		},
+		teams: map[string]*engine.GithubTeam{
+			"admin":                 {Name: "admin", Id: 1, Slug: "admin", Members: []string{"githubid1"}},
+			"admin-goliac-owners":   {Name: "admin-goliac-owners", Id: 11, Slug: "admin-goliac-owners", Members: []string{"githubid1"}},
+			"regular":               {Name: "regular", Id: 2, Slug: "regular", Members: []string{"githubid2", "githubid3"}},
+			"regular-goliac-owners": {Name: "regular-goliac-owners", Id: 12, Slug: "regular-goliac-owners", Members: []string{"githubid2"}},
+			"child":                 {Name: "child", Id: 3, Slug: "child", Members: []string{"githubid3"}, ParentTeam: &parentId},
+			"child-goliac-owners":   {Name: "child-goliac-owners", Id: 13, Slug: "child-goliac-owners", Members: []string{}},
+		},
+		repos: map[string]*engine.GithubRepository{
+			"repo1": {Name: "repo1", BoolProperties: repoProperties(false), StringProperties: map[string]string{"description": "the first repository"}, ExternalUsers: map[string]string{}},
+			"repo2": {Name: "repo2", BoolProperties: repoProperties(false), StringProperties: map[string]string{}, ExternalUsers: map[string]string{"external1": "READ"}},
+			"repo3": {Name: "repo3", BoolProperties: repoProperties(true), StringProperties: map[string]string{}, ExternalUsers: map[string]string{}},
+			"repo4": {Name: "repo4", BoolProperties: repoProperties(false), StringProperties: map[string]string{}, ExternalUsers: map[string]string{}},
+		},
+		teamsRepos: map[string]map[string]*engine.GithubTeamRepo{
+			"regular": {
+				"repo1": {Name: "repo1", Permission: "WRITE"},
+				"repo3": {Name: "repo3", Permission: "WRITE"},
+			},
+			"admin": {
+				"repo1": {Name: "repo1", Permission: "READ"},
+				"repo2": {Name: "repo2", Permission: "WRITE"},
+			},
+			"child": {
+				"repo2": {Name: "repo2", Permission: "READ"},
+			},
+		},
+		rulesets: map[string]*engine.GithubRuleSet{
+			"default": {
+				Name:        "default",
+				Id:          1,
+				Enforcement: "active",
+				BypassApps:  map[string]string{},
+				OnInclude:   []string{"~DEFAULT_BRANCH"},
+				OnExclude:   []string{},
+				Rules: map[string]entity.RuleSetParameters{
+					"pull_request": {RequiredApprovingReviewCount: 1},
+				},
+				Repositories: []string{"repo1", "repo2"},
+			},
+			engine.REQUIRED_SIGNATURES_RULESET: {
+				Name:        engine.REQUIRED_SIGNATURES_RULESET,
+				Id:          2,
+				Enforcement: "active",
+				BypassApps:  map[string]string{},
+				OnInclude:   []string{"~DEFAULT_BRANCH"},
+				OnExclude:   []string{},
+				Rules: map[string]entity.RuleSetParameters{
+					"required_signatures": {},
+				},
+				Repositories: []string{"repo2"},
+			},
+			engine.TEAMS_REPO_APPROVALS_RULESET: {
+				Name:        engine.TEAMS_REPO_APPROVALS_RULESET,
+				Id:          3,
+				Enforcement: "active",
+				BypassApps:  map[string]string{},
+				OnInclude:   []string{"~DEFAULT_BRANCH"},
+				OnExclude:   []string{},
+				Rules: map[string]entity.RuleSetParameters{
+					"pull_request": {DismissStaleReviewsOnPush: true, RequiredApprovingReviewCount: 2},
+				},
+				Repositories: []string{"teams"},
+			},
+		},
+		variables: map[string]*engine.GithubOrgVariable{
+			"VAR1": {Name: "VAR1", Value: "value1", Visibility: "all"},
+		},
+		actionsPermissions: map[string]*engine.GithubActionsPermissions{
+			"repo1": {Enabled: true, AllowedActions: "local_only"},
+			"repo2": {Enabled: false},
+		},
+		security: map[string]*engine.GithubRepositorySecurity{
+			"repo1": {SecretScanning: true, DependabotSecurityUpdates: true},
+		},
+		webhooks: map[string]map[string]*engine.GithubWebhook{
+			"repo1": {
+				"https://ci.example.com/hook": {Id: 1, URL: "https://ci.example.com/hook", Events: []string{"push", "pull_request"}, ContentType: "json", Active: false},
+			},
+		},
+		labels: map[string]map[string]*engine.GithubLabel{
+			"repo1": {
+				"bug": {Name: "bug", Color: "d73a4a", Description: &bugDescription},
+			},
+		},
+		customProperties: map[string]map[string]string{
+			"repo1": {"tier": "1"},
+		},
+	}
+}
+
+func TestScaffoldExport(t *testing.T) {
+
+	t.Run("happy path: export a Goliac managed organization", func(t *testing.T) {
+		fs := memfs.New()
+		scaffold := &Scaffold{
+			remote:                     NewExportGoliacRemoteMock(),
+			loadUsersFromGithubOrgSaml: NoLoadGithubSamlUsersMock,
+		}
+
+		err := scaffold.export(context.TODO(), fs, "admin")
+		assert.Nil(t, err)
+
+		regular, err := entity.NewTeam(fs, "teams/regular/team.yaml", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"githubid2"}, regular.Spec.Owners)
+		assert.Equal(t, []string{"githubid3"}, regular.Spec.Members)
+
+		found, err := utils.Exists(fs, "teams/regular/child/team.yaml")
+		assert.Nil(t, err)
+		assert.True(t, found)
+
+		repo1, err := entity.NewRepository(fs, "teams/regular/repo1.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"admin"}, repo1.Spec.Readers)
+		assert.Equal(t, 0, len(repo1.Spec.Writers))
+		assert.Equal(t, "the first repository", *repo1.Spec.Description)
+		assert.Equal(t, &entity.RepositoryActionsPermissions{Enabled: true, AllowedActions: "local_only"}, repo1.Spec.ActionsPermissions)
+		assert.True(t, *repo1.Spec.Security.SecretScanning)
+		assert.False(t, *repo1.Spec.Security.SecretScanningPushProtection)
+		assert.Equal(t, map[string]string{"tier": "1"}, repo1.Spec.CustomProperties)
+		assert.Equal(t, 1, len(repo1.Spec.Webhooks))
+		assert.Equal(t, "https://ci.example.com/hook", repo1.Spec.Webhooks[0].URL)
+		assert.False(t, *repo1.Spec.Webhooks[0].Active)
+		assert.Equal(t, "", repo1.Spec.Webhooks[0].SecretEnv)
+		assert.Equal(t, 1, len(repo1.Spec.Labels))
+		assert.Equal(t, "Something isn't working", *repo1.Spec.Labels[0].Description)
+
+		repo2, err := entity.NewRepository(fs, "teams/admin/repo2.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"external1"}, repo2.Spec.ExternalUserReaders)
+		assert.True(t, *repo2.Spec.RequireSignedCommits)
+		assert.Equal(t, &entity.RepositoryActionsPermissions{Enabled: false}, repo2.Spec.ActionsPermissions)
+		assert.Nil(t, repo2.Spec.Security)
+		assert.Nil(t, repo2.Spec.Webhooks)
+
+		// archived repositories keep their owner as a writer
+		repo3, err := entity.NewRepository(fs, "archived/repo3.yaml")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"regular"}, repo3.Spec.Writers)
+
+		// no team can own repo4
+		found, err = utils.Exists(fs, "teams/admin/repo4.yaml")
+		assert.Nil(t, err)
+		assert.False(t, found)
+
+		// the Goliac generated rulesets are not exported
+		found, err = utils.Exists(fs, "rulesets/"+engine.REQUIRED_SIGNATURES_RULESET+".yaml")
+		assert.Nil(t, err)
+		assert.False(t, found)
+		found, err = utils.Exists(fs, "rulesets/"+engine.TEAMS_REPO_APPROVALS_RULESET+".yaml")
+		assert.Nil(t, err)
+		assert.False(t, found)
+
+		found, err = utils.Exists(fs, "README.md")
+		assert.Nil(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("happy path: the export is valid and plans without change", func(t *testing.T) {
+		fs := memfs.New()
+		remote := NewExportGoliacRemoteMock()
+		scaffold := &Scaffold{
+			remote:                     remote,
+			loadUsersFromGithubOrgSaml: NoLoadGithubSamlUsersMock,
+		}
+
+		err := scaffold.export(context.TODO(), fs, "admin")
+		assert.Nil(t, err)
+
+		local := engine.NewGoliacLocalImpl()
+		errs, _ := local.LoadAndValidateLocal(fs)
+		assert.Equal(t, 0, len(errs))
+
+		content, err := utils.ReadFile(fs, "goliac.yaml")
+		assert.Nil(t, err)
+		var repoconfig config.RepositoryConfig
+		err = yaml.Unmarshal(content, &repoconfig)
+		assert.Nil(t, err)
+		assert.Equal(t, "admin", repoconfig.AdminTeam)
+		assert.True(t, repoconfig.ManageGithubVariables)
+		assert.Equal(t, 1, len(repoconfig.Rulesets))
+		assert.Equal(t, 2, repoconfig.RequiredApprovals)
+
+		executor := &GoliacRemoteExecutorMock{}
+		reconciliator := engine.NewGoliacReconciliatorImpl(executor, &repoconfig)
+		_, err = reconciliator.Reconciliate(context.TODO(), local, remote, "teams", true, map[string]*engine.GithubRepoComparable{}, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, executor.nbChanges)
+	})
+}
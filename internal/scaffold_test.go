@@ -19,6 +19,14 @@ type ScaffoldGoliacRemoteMock struct {
 	teams      map[string]*engine.GithubTeam
 	repos      map[string]*engine.GithubRepository
 	teamsRepos map[string]map[string]*engine.GithubTeamRepo
+	rulesets   map[string]*engine.GithubRuleSet
+	variables  map[string]*engine.GithubOrgVariable
+
+	actionsPermissions map[string]*engine.GithubActionsPermissions
+	security           map[string]*engine.GithubRepositorySecurity
+	webhooks           map[string]map[string]*engine.GithubWebhook
+	labels             map[string]map[string]*engine.GithubLabel
+	customProperties   map[string]map[string]string
 }
 
 func (s *ScaffoldGoliacRemoteMock) Load(ctx context.Context, continueOnError bool) error {
@@ -48,11 +56,59 @@ func (s *ScaffoldGoliacRemoteMock) TeamRepositories(ctx context.Context) map[str
 	return s.teamsRepos
 }
 func (s *ScaffoldGoliacRemoteMock) RuleSets(ctx context.Context) map[string]*engine.GithubRuleSet {
-	return nil
+	return s.rulesets
 }
 func (s *ScaffoldGoliacRemoteMock) AppIds(ctx context.Context) map[string]int {
 	return nil
 }
+func (s *ScaffoldGoliacRemoteMock) OrgVariables(ctx context.Context) map[string]*engine.GithubOrgVariable {
+	return s.variables
+}
+func (s *ScaffoldGoliacRemoteMock) OrgSecrets(ctx context.Context) map[string]*engine.GithubOrgSecret {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) OrgSettings(ctx context.Context) map[string]bool {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) OrgPushProtectionCustomLink(ctx context.Context) string {
+	return ""
+}
+func (s *ScaffoldGoliacRemoteMock) OrgDefaultRepositoryPermission(ctx context.Context) string {
+	return ""
+}
+func (s *ScaffoldGoliacRemoteMock) RepositoriesActionsPermissions(ctx context.Context) map[string]*engine.GithubActionsPermissions {
+	return s.actionsPermissions
+}
+func (s *ScaffoldGoliacRemoteMock) RepositoriesSecurity(ctx context.Context) map[string]*engine.GithubRepositorySecurity {
+	return s.security
+}
+func (s *ScaffoldGoliacRemoteMock) RepositoriesWebhooks(ctx context.Context) map[string]map[string]*engine.GithubWebhook {
+	return s.webhooks
+}
+func (s *ScaffoldGoliacRemoteMock) RepositoriesLabels(ctx context.Context) map[string]map[string]*engine.GithubLabel {
+	return s.labels
+}
+func (s *ScaffoldGoliacRemoteMock) RepositoriesCustomProperties(ctx context.Context) map[string]map[string]string {
+	return s.customProperties
+}
+func (s *ScaffoldGoliacRemoteMock) SamlIdentities(ctx context.Context) map[string]string {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) TeamsExternalGroups(ctx context.Context) map[string][]string {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) CustomRepositoryRoles(ctx context.Context) map[string]string {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) CustomPropertiesDefinitions(ctx context.Context) map[string]bool {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) MembersWithoutTwoFactor(ctx context.Context) []string {
+	return nil
+}
+func (s *ScaffoldGoliacRemoteMock) VerifiedDomains(ctx context.Context) []string {
+	return nil
+}
 func (s *ScaffoldGoliacRemoteMock) IsEnterprise() bool {
 	return true
 }
@@ -9,4 +9,6 @@ func InitPlugins(client github.GitHubClient) {
 	engine.RegisterPlugin("noop", NewUserSyncPluginNoop())
 	engine.RegisterPlugin("shellscript", NewUserSyncPluginShellScript())
 	engine.RegisterPlugin("fromgithubsaml", NewUserSyncPluginFromGithubSaml(client))
+	engine.RegisterPlugin("ldap", NewUserSyncPluginLdap())
+	engine.RegisterPlugin("okta", NewUserSyncPluginOkta())
 }
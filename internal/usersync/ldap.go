@@ -0,0 +1,96 @@
+package usersync
+
+import (
+	"fmt"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const LDAP_PAGE_SIZE = 500
+
+/*
+ * UserSyncPluginLdap: this plugin sync users from a LDAP directory.
+ * Each LDAP entry matching the GOLIAC_SYNC_LDAP_FILTER filter (under GOLIAC_SYNC_LDAP_BASE_DN)
+ * becomes a user, as long as it has the GOLIAC_SYNC_LDAP_GITHUB_ATTRIBUTE attribute
+ */
+type UserSyncPluginLdap struct{}
+
+func NewUserSyncPluginLdap() engine.UserSyncPlugin {
+	return &UserSyncPluginLdap{}
+}
+
+func (p *UserSyncPluginLdap) UpdateUsers(repoconfig *config.RepositoryConfig, fs billy.Filesystem, orguserdirrectorypath string) (map[string]*entity.User, error) {
+	if config.Config.SyncLdapUrl == "" {
+		return nil, fmt.Errorf("GOLIAC_SYNC_LDAP_URL is not set")
+	}
+
+	// DialURL uses TLS for ldaps:// urls
+	conn, err := ldap.DialURL(config.Config.SyncLdapUrl)
+	if err != nil {
+		return nil, fmt.Errorf("not able to connect to %s: %v", config.Config.SyncLdapUrl, err)
+	}
+	defer conn.Close()
+
+	if config.Config.SyncLdapBindDN != "" {
+		err = conn.Bind(config.Config.SyncLdapBindDN, config.Config.SyncLdapBindPassword)
+		if err != nil {
+			return nil, fmt.Errorf("not able to bind to %s as %s: %v", config.Config.SyncLdapUrl, config.Config.SyncLdapBindDN, err)
+		}
+	}
+
+	searchRequest := ldap.NewSearchRequest(
+		config.Config.SyncLdapBaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		config.Config.SyncLdapFilter,
+		[]string{config.Config.SyncLdapUserAttribute, config.Config.SyncLdapGithubAttribute},
+		nil,
+	)
+
+	result, err := conn.SearchWithPaging(searchRequest, LDAP_PAGE_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("not able to search users in %s: %v", config.Config.SyncLdapBaseDN, err)
+	}
+
+	users := ldapEntriesToUsers(result.Entries, config.Config.SyncLdapUserAttribute, config.Config.SyncLdapGithubAttribute)
+	if len(users) == 0 {
+		return nil, fmt.Errorf("not able to find any LDAP users with a %s attribute", config.Config.SyncLdapGithubAttribute)
+	}
+
+	return users, nil
+}
+
+/*
+ * ldapEntriesToUsers converts LDAP entries into users
+ * (entries without a username or a github login are skipped)
+ */
+func ldapEntriesToUsers(entries []*ldap.Entry, userAttribute string, githubAttribute string) map[string]*entity.User {
+	users := make(map[string]*entity.User)
+
+	for _, e := range entries {
+		username := e.GetAttributeValue(userAttribute)
+		githubid := e.GetAttributeValue(githubAttribute)
+		if username == "" || githubid == "" {
+			logrus.Debugf("skipping LDAP entry %s: missing %s or %s attribute", e.DN, userAttribute, githubAttribute)
+			continue
+		}
+
+		user := &entity.User{}
+		user.ApiVersion = "v1"
+		user.Kind = "User"
+		user.Name = username
+		user.Spec.GithubID = githubid
+
+		users[username] = user
+	}
+
+	return users
+}
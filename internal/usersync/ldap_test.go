@@ -0,0 +1,41 @@
+package usersync
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLdapEntriesToUsers(t *testing.T) {
+
+	t.Run("happy path: map the github attribute", func(t *testing.T) {
+		entries := []*ldap.Entry{
+			ldap.NewEntry("uid=alice,ou=people,dc=example,dc=com", map[string][]string{
+				"uid":         {"alice"},
+				"githubLogin": {"alice-gh"},
+			}),
+			ldap.NewEntry("uid=bob,ou=people,dc=example,dc=com", map[string][]string{
+				"uid":         {"bob"},
+				"githubLogin": {"bob-gh"},
+			}),
+		}
+
+		users := ldapEntriesToUsers(entries, "uid", "githubLogin")
+		assert.Equal(t, 2, len(users))
+		assert.Equal(t, "alice", users["alice"].Name)
+		assert.Equal(t, "alice-gh", users["alice"].Spec.GithubID)
+		assert.Equal(t, "User", users["bob"].Kind)
+	})
+
+	t.Run("not happy path: entries without github attribute are skipped", func(t *testing.T) {
+		entries := []*ldap.Entry{
+			ldap.NewEntry("uid=alice,ou=people,dc=example,dc=com", map[string][]string{
+				"uid": {"alice"},
+			}),
+		}
+
+		users := ldapEntriesToUsers(entries, "uid", "githubLogin")
+		assert.Equal(t, 0, len(users))
+	})
+}
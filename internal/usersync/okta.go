@@ -0,0 +1,152 @@
+package usersync
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/Alayacare/goliac/internal/engine"
+	"github.com/Alayacare/goliac/internal/entity"
+	"github.com/go-git/go-billy/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const OKTA_PAGE_SIZE = 200
+const OKTA_MAX_RATELIMIT_RETRIES = 5
+
+var oktaNextLinkRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+/*
+ * UserSyncPluginOkta: this plugin sync the active users from Okta.
+ * The GitHub login is read from a custom profile attribute (GOLIAC_SYNC_OKTA_GITHUB_ATTRIBUTE)
+ * Deactivated (or suspended) users are omitted, so they are removed on the next apply.
+ * The users are listed with the Okta Users API (/api/v1/users), not SCIM: Okta is a
+ * SCIM client (it provisions the apps), it doesn't serve its own users via a SCIM /Users endpoint
+ */
+type UserSyncPluginOkta struct {
+	client     *http.Client
+	baseUrl    string // https://<GOLIAC_SYNC_OKTA_DOMAIN> if empty
+	retryDelay time.Duration
+}
+
+func NewUserSyncPluginOkta() engine.UserSyncPlugin {
+	return &UserSyncPluginOkta{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		retryDelay: 2 * time.Second,
+	}
+}
+
+type oktaUser struct {
+	Id      string                 `json:"id"`
+	Status  string                 `json:"status"` // ACTIVE, DEPROVISIONED, SUSPENDED, ...
+	Profile map[string]interface{} `json:"profile"`
+}
+
+func (p *UserSyncPluginOkta) UpdateUsers(repoconfig *config.RepositoryConfig, fs billy.Filesystem, orguserdirrectorypath string) (map[string]*entity.User, error) {
+	baseUrl := p.baseUrl
+	if baseUrl == "" {
+		if config.Config.SyncOktaDomain == "" {
+			return nil, fmt.Errorf("GOLIAC_SYNC_OKTA_DOMAIN is not set")
+		}
+		baseUrl = "https://" + config.Config.SyncOktaDomain
+	}
+	if config.Config.SyncOktaToken == "" {
+		return nil, fmt.Errorf("GOLIAC_SYNC_OKTA_TOKEN is not set")
+	}
+
+	users := make(map[string]*entity.User)
+
+	// https://developer.okta.com/docs/reference/api/users/#list-users
+	next := fmt.Sprintf("%s/api/v1/users?limit=%d", baseUrl, OKTA_PAGE_SIZE)
+	count := 0
+	for next != "" {
+		oktaUsers, nextUrl, err := p.getUsersPage(next)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, u := range oktaUsers {
+			if u.Status != "ACTIVE" {
+				continue
+			}
+			login, _ := u.Profile["login"].(string)
+			githubid, _ := u.Profile[config.Config.SyncOktaGithubAttribute].(string)
+			if login == "" || githubid == "" {
+				logrus.Debugf("skipping Okta user %s: missing login or %s attribute", u.Id, config.Config.SyncOktaGithubAttribute)
+				continue
+			}
+
+			user := &entity.User{}
+			user.ApiVersion = "v1"
+			user.Kind = "User"
+			user.Name = login
+			user.Spec.GithubID = githubid
+
+			users[login] = user
+		}
+
+		next = nextUrl
+		count++
+		// sanity check to avoid loops: a partial list of users would remove the other ones
+		if next != "" && count >= engine.FORLOOP_STOP*10 {
+			return nil, fmt.Errorf("too many Okta users pages (more than %d): aborting the users sync", engine.FORLOOP_STOP*10)
+		}
+	}
+
+	if len(users) == 0 {
+		return nil, fmt.Errorf("not able to find any active Okta users with a %s attribute", config.Config.SyncOktaGithubAttribute)
+	}
+
+	return users, nil
+}
+
+/*
+ * getUsersPage returns the users of one page, and the url of the next page (if any)
+ */
+func (p *UserSyncPluginOkta) getUsersPage(url string) ([]oktaUser, string, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Authorization", "SSWS "+config.Config.SyncOktaToken)
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return nil, "", fmt.Errorf("not able to list Okta users: %v", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < OKTA_MAX_RATELIMIT_RETRIES {
+			logrus.Debugf("Okta rate limit reached, waiting for %v", p.retryDelay)
+			time.Sleep(p.retryDelay)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("not able to list Okta users: unexpected status %s. %s", resp.Status, string(body))
+		}
+
+		var users []oktaUser
+		if err := json.Unmarshal(body, &users); err != nil {
+			return nil, "", fmt.Errorf("not able to list Okta users: %v", err)
+		}
+
+		// cursor-based pagination via the Link header
+		nextUrl := ""
+		for _, link := range resp.Header.Values("Link") {
+			if m := oktaNextLinkRegexp.FindStringSubmatch(link); m != nil {
+				nextUrl = m[1]
+			}
+		}
+		return users, nextUrl, nil
+	}
+}
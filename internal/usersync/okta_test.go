@@ -0,0 +1,96 @@
+package usersync
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Alayacare/goliac/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOktaUpdateUsers(t *testing.T) {
+
+	config.Config.SyncOktaToken = "token"
+	config.Config.SyncOktaGithubAttribute = "githubLogin"
+
+	t.Run("happy path: paginated active users", func(t *testing.T) {
+		nbCalls := 0
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nbCalls++
+			assert.Equal(t, "SSWS token", r.Header.Get("Authorization"))
+
+			// first call is rate limited
+			if nbCalls == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			if r.URL.Query().Get("after") == "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/users?limit=200>; rel="self"`, server.URL))
+				w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/users?limit=200&after=cursor1>; rel="next"`, server.URL))
+				fmt.Fprint(w, `[
+					{"id":"1","status":"ACTIVE","profile":{"login":"alice@example.com","githubLogin":"alice-gh"}},
+					{"id":"2","status":"DEPROVISIONED","profile":{"login":"bob@example.com","githubLogin":"bob-gh"}}
+				]`)
+				return
+			}
+			fmt.Fprint(w, `[
+				{"id":"3","status":"ACTIVE","profile":{"login":"carol@example.com","githubLogin":"carol-gh"}},
+				{"id":"4","status":"ACTIVE","profile":{"login":"dave@example.com"}}
+			]`)
+		}))
+		defer server.Close()
+
+		plugin := &UserSyncPluginOkta{
+			client:     server.Client(),
+			baseUrl:    server.URL,
+			retryDelay: time.Millisecond,
+		}
+
+		users, err := plugin.UpdateUsers(nil, nil, "")
+		assert.Nil(t, err)
+		assert.Equal(t, 3, nbCalls)
+		assert.Equal(t, 2, len(users))
+		assert.Equal(t, "alice-gh", users["alice@example.com"].Spec.GithubID)
+		assert.Equal(t, "carol-gh", users["carol@example.com"].Spec.GithubID)
+	})
+
+	t.Run("not happy path: Okta error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		plugin := &UserSyncPluginOkta{
+			client:     server.Client(),
+			baseUrl:    server.URL,
+			retryDelay: time.Millisecond,
+		}
+
+		_, err := plugin.UpdateUsers(nil, nil, "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("not happy path: too many pages", func(t *testing.T) {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// always a next page
+			w.Header().Add("Link", fmt.Sprintf(`<%s/api/v1/users?limit=200&after=cursor>; rel="next"`, server.URL))
+			fmt.Fprint(w, `[{"id":"1","status":"ACTIVE","profile":{"login":"alice@example.com","githubLogin":"alice-gh"}}]`)
+		}))
+		defer server.Close()
+
+		plugin := &UserSyncPluginOkta{
+			client:     server.Client(),
+			baseUrl:    server.URL,
+			retryDelay: time.Millisecond,
+		}
+
+		users, err := plugin.UpdateUsers(nil, nil, "")
+		assert.NotNil(t, err)
+		assert.Nil(t, users)
+	})
+}
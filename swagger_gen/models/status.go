@@ -44,6 +44,9 @@ type Status struct {
 	// nb users external
 	NbUsersExternal int64 `json:"nbUsersExternal"`
 
+	// verified domains
+	VerifiedDomains []string `json:"verifiedDomains"`
+
 	// version
 	Version string `json:"version,omitempty"`
 }
@@ -623,6 +623,12 @@ func init() {
           "type": "integer",
           "x-omitempty": false
         },
+        "verifiedDomains": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
         "version": {
           "type": "string"
         }
@@ -1471,6 +1477,12 @@ func init() {
           "type": "integer",
           "x-omitempty": false
         },
+        "verifiedDomains": {
+          "type": "array",
+          "items": {
+            "type": "string"
+          }
+        },
         "version": {
           "type": "string"
         }